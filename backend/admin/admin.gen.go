@@ -6,6 +6,7 @@ package admin
 import (
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/oapi-codegen/runtime"
@@ -13,6 +14,7 @@ import (
 	externalRef1 "github.com/stephenzsy/small-kms/backend/models/agent"
 	externalRef2 "github.com/stephenzsy/small-kms/backend/models/cert"
 	externalRef3 "github.com/stephenzsy/small-kms/backend/models/key"
+	externalRef4 "github.com/stephenzsy/small-kms/backend/models/webhook"
 )
 
 const (
@@ -24,6 +26,15 @@ type ErrorResult struct {
 	Message *string `json:"message,omitempty"`
 }
 
+// ACMEAccountIdParameter defines model for ACMEAccountIdParameter.
+type ACMEAccountIdParameter = string
+
+// ACMEAuthzIdParameter defines model for ACMEAuthzIdParameter.
+type ACMEAuthzIdParameter = string
+
+// ACMEOrderIdParameter defines model for ACMEOrderIdParameter.
+type ACMEOrderIdParameter = string
+
 // IdParameter defines model for IdParameter.
 type IdParameter = string
 
@@ -33,9 +44,33 @@ type NamespaceIdParameter = string
 // NamespaceProviderParameter defines model for NamespaceProviderParameter.
 type NamespaceProviderParameter = externalRef0.NamespaceProvider
 
+// ACMEProblemResponse defines model for ACMEProblemResponse.
+type ACMEProblemResponse = map[string]interface{}
+
+// ACMEResponse defines model for ACMEResponse.
+type ACMEResponse = map[string]interface{}
+
 // ErrorResponse defines model for ErrorResponse.
 type ErrorResponse = ErrorResult
 
+// ListAuditRecordsParams defines parameters for ListAuditRecords.
+type ListAuditRecordsParams struct {
+	// Actor Client principal ID of the actor
+	Actor             *string                         `form:"actor,omitempty" json:"actor,omitempty"`
+	From              *time.Time                      `form:"from,omitempty" json:"from,omitempty"`
+	NamespaceId       *string                         `form:"namespaceId,omitempty" json:"namespaceId,omitempty"`
+	NamespaceProvider *externalRef0.NamespaceProvider `form:"namespaceProvider,omitempty" json:"namespaceProvider,omitempty"`
+	ResourceId        *string                         `form:"resourceId,omitempty" json:"resourceId,omitempty"`
+	ResourceProvider  *string                         `form:"resourceProvider,omitempty" json:"resourceProvider,omitempty"`
+	To                *time.Time                      `form:"to,omitempty" json:"to,omitempty"`
+}
+
+// VerifyAuditRecordsParams defines parameters for VerifyAuditRecords.
+type VerifyAuditRecordsParams struct {
+	// FromSeq Seq of the first record to verify, the record before it is trusted as the checkpoint
+	FromSeq *int64 `form:"fromSeq,omitempty" json:"fromSeq,omitempty"`
+}
+
 // DeleteAgentInstanceParams defines parameters for DeleteAgentInstance.
 type DeleteAgentInstanceParams struct {
 	// Force Force delete
@@ -63,6 +98,12 @@ type GetCertificateParams struct {
 	Pending *bool `form:"pending,omitempty" json:"pending,omitempty"`
 }
 
+// GetCertificateCRLParams defines parameters for GetCertificateCRL.
+type GetCertificateCRLParams struct {
+	// Pem Return PEM encoded CRL instead of DER
+	Pem *bool `form:"pem,omitempty" json:"pem,omitempty"`
+}
+
 // ListKeysParams defines parameters for ListKeys.
 type ListKeysParams struct {
 	// PolicyId Policy ID
@@ -78,6 +119,12 @@ type GetKeyParams struct {
 	Verify *bool `form:"verify,omitempty" json:"verify,omitempty"`
 }
 
+// ListWebhookDeadLettersParams defines parameters for ListWebhookDeadLetters.
+type ListWebhookDeadLettersParams struct {
+	// SubscriptionId Subscription ID
+	SubscriptionId *string `form:"subscriptionId,omitempty" json:"subscriptionId,omitempty"`
+}
+
 // CreateAgentJSONRequestBody defines body for CreateAgent for application/json ContentType.
 type CreateAgentJSONRequestBody = externalRef1.CreateAgentRequest
 
@@ -96,6 +143,9 @@ type UpdateAgentInstanceJSONRequestBody = externalRef1.AgentInstanceParameters
 // AgentDockerImagePullJSONRequestBody defines body for AgentDockerImagePull for application/json ContentType.
 type AgentDockerImagePullJSONRequestBody = externalRef1.PullImageRequest
 
+// CreateACMEExternalAccountBindingJSONRequestBody defines body for CreateACMEExternalAccountBinding for application/json ContentType.
+type CreateACMEExternalAccountBindingJSONRequestBody = externalRef2.CreateAcmeExternalAccountBindingRequest
+
 // PutCertificatePolicyJSONRequestBody defines body for PutCertificatePolicy for application/json ContentType.
 type PutCertificatePolicyJSONRequestBody = externalRef2.CertificatePolicyParameters
 
@@ -111,12 +161,51 @@ type ExchangePKCS12JSONRequestBody = externalRef2.ExchangePKCS12Request
 // UpdatePendingCertificateJSONRequestBody defines body for UpdatePendingCertificate for application/json ContentType.
 type UpdatePendingCertificateJSONRequestBody = externalRef2.UpdatePendingCertificateRequest
 
+// RevokeCertificateJSONRequestBody defines body for RevokeCertificate for application/json ContentType.
+type RevokeCertificateJSONRequestBody = externalRef2.RevokeCertificateRequest
+
 // GetCertificateSecretJSONRequestBody defines body for GetCertificateSecret for application/json ContentType.
 type GetCertificateSecretJSONRequestBody = externalRef2.CertificateSecretRequest
 
 // PutKeyPolicyJSONRequestBody defines body for PutKeyPolicy for application/json ContentType.
 type PutKeyPolicyJSONRequestBody = externalRef3.CreateKeyPolicyRequest
 
+// ImportKeyJSONRequestBody defines body for ImportKey for application/json ContentType.
+type ImportKeyJSONRequestBody = externalRef3.ImportKeyRequest
+
+// DecryptDataKeyJSONRequestBody defines body for DecryptDataKey for application/json ContentType.
+type DecryptDataKeyJSONRequestBody = externalRef3.DecryptDataKeyRequest
+
+// DecryptWithKeyJSONRequestBody defines body for DecryptWithKey for application/json ContentType.
+type DecryptWithKeyJSONRequestBody = externalRef3.KeyOperationRequest
+
+// EncryptWithKeyJSONRequestBody defines body for EncryptWithKey for application/json ContentType.
+type EncryptWithKeyJSONRequestBody = externalRef3.KeyOperationRequest
+
+// GenerateDataKeyJSONRequestBody defines body for GenerateDataKey for application/json ContentType.
+type GenerateDataKeyJSONRequestBody = externalRef3.GenerateDataKeyRequest
+
+// SignWithKeyJSONRequestBody defines body for SignWithKey for application/json ContentType.
+type SignWithKeyJSONRequestBody = externalRef3.KeySignRequest
+
+// UnwrapWithKeyJSONRequestBody defines body for UnwrapWithKey for application/json ContentType.
+type UnwrapWithKeyJSONRequestBody = externalRef3.KeyOperationRequest
+
+// VerifyWithKeyJSONRequestBody defines body for VerifyWithKey for application/json ContentType.
+type VerifyWithKeyJSONRequestBody = externalRef3.KeyVerifyRequest
+
+// WrapWithKeyJSONRequestBody defines body for WrapWithKey for application/json ContentType.
+type WrapWithKeyJSONRequestBody = externalRef3.KeyOperationRequest
+
+// PutSSHCAPolicyJSONRequestBody defines body for PutSSHCAPolicy for application/json ContentType.
+type PutSSHCAPolicyJSONRequestBody = externalRef2.SSHCAPolicyParameters
+
+// SignSSHCertificateJSONRequestBody defines body for SignSSHCertificate for application/json ContentType.
+type SignSSHCertificateJSONRequestBody = externalRef2.SignSSHCertificateRequest
+
+// PutWebhookSubscriptionJSONRequestBody defines body for PutWebhookSubscription for application/json ContentType.
+type PutWebhookSubscriptionJSONRequestBody = externalRef4.WebhookSubscriptionParameters
+
 // ServerInterface represents all server handlers.
 type ServerInterface interface {
 
@@ -128,6 +217,12 @@ type ServerInterface interface {
 	// Get agent
 	// (GET /v2/agents/{id})
 	GetAgent(ctx echo.Context, id IdParameter) error
+	// List audit records, newest first
+	// (GET /v2/audit-records)
+	ListAuditRecords(ctx echo.Context, params ListAuditRecordsParams) error
+	// Verify the hash chain of audit records
+	// (GET /v2/audit-records/verify)
+	VerifyAuditRecords(ctx echo.Context, params VerifyAuditRecordsParams) error
 	// Get diagnostics
 	// (GET /v2/diagnostics)
 	GetDiagnostics(ctx echo.Context) error
@@ -155,6 +250,9 @@ type ServerInterface interface {
 	// Put profile
 	// (PUT /v2/profiles/{namespaceProvider}/{namespaceId})
 	PutProfile(ctx echo.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter) error
+	// Get the key authorization of a pending ACME http-01 challenge served by the agent
+	// (GET /v2/service-principal/{namespaceId}/acme-http01-challenges/{id})
+	GetACMEHTTP01Challenge(ctx echo.Context, namespaceId NamespaceIdParameter, id IdParameter) error
 	// Get agent config
 	// (GET /v2/service-principal/{namespaceId}/agent-config)
 	GetAgentConfigBundle(ctx echo.Context, namespaceId NamespaceIdParameter) error
@@ -200,6 +298,9 @@ type ServerInterface interface {
 	// Sync managed app
 	// (POST /v2/system-apps/{id})
 	SyncSystemApp(ctx echo.Context, id IdParameter) error
+	// Create a one-time ACME external account binding key for the principal
+	// (POST /v2/{namespaceProvider}/{namespaceId}/acme-external-account-bindings)
+	CreateACMEExternalAccountBinding(ctx echo.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter) error
 	// List certificate policies
 	// (GET /v2/{namespaceProvider}/{namespaceId}/certificate-policies)
 	ListCertificatePolicies(ctx echo.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter) error
@@ -209,6 +310,42 @@ type ServerInterface interface {
 	// put certificate policy
 	// (PUT /v2/{namespaceProvider}/{namespaceId}/certificate-policies/{id})
 	PutCertificatePolicy(ctx echo.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter, id IdParameter) error
+	// Get, update or deactivate an ACME account
+	// (POST /v2/{namespaceProvider}/{namespaceId}/certificate-policies/{id}/acme/accounts/{accountId})
+	UpdateACMEAccount(ctx echo.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter, id IdParameter, accountId ACMEAccountIdParameter) error
+	// Get an ACME authorization
+	// (POST /v2/{namespaceProvider}/{namespaceId}/certificate-policies/{id}/acme/authz/{authzId})
+	GetACMEAuthorization(ctx echo.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter, id IdParameter, authzId ACMEAuthzIdParameter) error
+	// Respond to an ACME challenge, the server validates http-01 and tls-alpn-01 challenges
+	// (POST /v2/{namespaceProvider}/{namespaceId}/certificate-policies/{id}/acme/authz/{authzId}/{challengeType})
+	RespondACMEChallenge(ctx echo.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter, id IdParameter, authzId ACMEAuthzIdParameter, challengeType string) error
+	// Download the certificate chain of a valid ACME order
+	// (POST /v2/{namespaceProvider}/{namespaceId}/certificate-policies/{id}/acme/certificates/{certificateId})
+	GetACMECertificate(ctx echo.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter, id IdParameter, certificateId string) error
+	// ACME directory of the intermediate CA certificate policy (RFC 8555)
+	// (GET /v2/{namespaceProvider}/{namespaceId}/certificate-policies/{id}/acme/directory)
+	GetACMEDirectory(ctx echo.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter, id IdParameter) error
+	// Create or look up an ACME account, new accounts require external account binding
+	// (POST /v2/{namespaceProvider}/{namespaceId}/certificate-policies/{id}/acme/new-account)
+	NewACMEAccount(ctx echo.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter, id IdParameter) error
+	// Get a new ACME nonce
+	// (GET /v2/{namespaceProvider}/{namespaceId}/certificate-policies/{id}/acme/new-nonce)
+	GetACMENonce(ctx echo.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter, id IdParameter) error
+	// Get a new ACME nonce
+	// (HEAD /v2/{namespaceProvider}/{namespaceId}/certificate-policies/{id}/acme/new-nonce)
+	HeadACMENonce(ctx echo.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter, id IdParameter) error
+	// Create an ACME order
+	// (POST /v2/{namespaceProvider}/{namespaceId}/certificate-policies/{id}/acme/new-order)
+	NewACMEOrder(ctx echo.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter, id IdParameter) error
+	// Get an ACME order
+	// (POST /v2/{namespaceProvider}/{namespaceId}/certificate-policies/{id}/acme/orders/{orderId})
+	GetACMEOrder(ctx echo.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter, id IdParameter, orderId ACMEOrderIdParameter) error
+	// Finalize an ACME order with a CSR
+	// (POST /v2/{namespaceProvider}/{namespaceId}/certificate-policies/{id}/acme/orders/{orderId}/finalize)
+	FinalizeACMEOrder(ctx echo.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter, id IdParameter, orderId ACMEOrderIdParameter) error
+	// Revoke a certificate issued to the ACME account
+	// (POST /v2/{namespaceProvider}/{namespaceId}/certificate-policies/{id}/acme/revoke-cert)
+	RevokeACMECertificate(ctx echo.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter, id IdParameter) error
 	// enroll certificate
 	// (POST /v2/{namespaceProvider}/{namespaceId}/certificate-policies/{id}/enroll)
 	EnrollCertificate(ctx echo.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter, id IdParameter, params EnrollCertificateParams) error
@@ -221,6 +358,9 @@ type ServerInterface interface {
 	// put certificate policy issuer
 	// (PUT /v2/{namespaceProvider}/{namespaceId}/certificate-policies/{id}/issuer)
 	PutCertificatePolicyIssuer(ctx echo.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter, id IdParameter) error
+	// Time-stamping authority of the intermediate CA policy (RFC 3161)
+	// (POST /v2/{namespaceProvider}/{namespaceId}/certificate-policies/{id}/timestamp)
+	RespondTimestamp(ctx echo.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter, id IdParameter) error
 	// List certificates
 	// (GET /v2/{namespaceProvider}/{namespaceId}/certificates)
 	ListCertificates(ctx echo.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter, params ListCertificatesParams) error
@@ -230,18 +370,30 @@ type ServerInterface interface {
 	// Get certificate
 	// (GET /v2/{namespaceProvider}/{namespaceId}/certificates/{id})
 	GetCertificate(ctx echo.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter, id IdParameter, params GetCertificateParams) error
+	// Get CRL signed by the CA certificate
+	// (GET /v2/{namespaceProvider}/{namespaceId}/certificates/{id}/crl)
+	GetCertificateCRL(ctx echo.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter, id IdParameter, params GetCertificateCRLParams) error
 	// Exchange PKCS12
 	// (POST /v2/{namespaceProvider}/{namespaceId}/certificates/{id}/exchange-pkcs12)
 	ExchangePKCS12(ctx echo.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter, id IdParameter) error
 	// Add certificate as MS Entra key credential
 	// (POST /v2/{namespaceProvider}/{namespaceId}/certificates/{id}/ms-entra-key-credential)
 	AddMsEntraKeyCredential(ctx echo.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter, id IdParameter) error
+	// OCSP responder of the CA certificate (RFC 6960)
+	// (POST /v2/{namespaceProvider}/{namespaceId}/certificates/{id}/ocsp)
+	RespondOCSP(ctx echo.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter, id IdParameter) error
 	// Update pending certificate
 	// (POST /v2/{namespaceProvider}/{namespaceId}/certificates/{id}/pending)
 	UpdatePendingCertificate(ctx echo.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter, id IdParameter) error
+	// Revoke certificate
+	// (POST /v2/{namespaceProvider}/{namespaceId}/certificates/{id}/revoke)
+	RevokeCertificate(ctx echo.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter, id IdParameter) error
 	// Get certificate secret
 	// (POST /v2/{namespaceProvider}/{namespaceId}/certificates/{id}/secret)
 	GetCertificateSecret(ctx echo.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter, id IdParameter) error
+	// Get public keys of the namespace as JWK set
+	// (GET /v2/{namespaceProvider}/{namespaceId}/.well-known/jwks.json)
+	GetNamespaceJwks(ctx echo.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter) error
 	// List key policies
 	// (GET /v2/{namespaceProvider}/{namespaceId}/key-policies)
 	ListKeyPolicies(ctx echo.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter) error
@@ -254,18 +406,84 @@ type ServerInterface interface {
 	// put certificate policy
 	// (POST /v2/{namespaceProvider}/{namespaceId}/key-policies/{id}/generate)
 	GenerateKey(ctx echo.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter, id IdParameter) error
+	// Import an existing private key as a new key version of the policy
+	// (POST /v2/{namespaceProvider}/{namespaceId}/key-policies/{id}/import)
+	ImportKey(ctx echo.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter, id IdParameter) error
+	// Get public keys of the key policy as JWK set
+	// (GET /v2/{namespaceProvider}/{namespaceId}/key-policies/{id}/jwks.json)
+	GetKeyPolicyJwks(ctx echo.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter, id IdParameter) error
 	// List keys
 	// (GET /v2/{namespaceProvider}/{namespaceId}/keys)
 	ListKeys(ctx echo.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter, params ListKeysParams) error
 	// Get key
 	// (GET /v2/{namespaceProvider}/{namespaceId}/keys/{id})
 	GetKey(ctx echo.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter, id IdParameter, params GetKeyParams) error
+	// Decrypt with key
+	// (POST /v2/{namespaceProvider}/{namespaceId}/keys/{id}/decrypt)
+	DecryptWithKey(ctx echo.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter, id IdParameter) error
+	// Decrypt data key wrapped with key
+	// (POST /v2/{namespaceProvider}/{namespaceId}/keys/{id}/decrypt-data-key)
+	DecryptDataKey(ctx echo.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter, id IdParameter) error
+	// Encrypt with key
+	// (POST /v2/{namespaceProvider}/{namespaceId}/keys/{id}/encrypt)
+	EncryptWithKey(ctx echo.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter, id IdParameter) error
+	// Generate data key wrapped with key
+	// (POST /v2/{namespaceProvider}/{namespaceId}/keys/{id}/generate-data-key)
+	GenerateDataKey(ctx echo.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter, id IdParameter) error
+	// Sign digest with key
+	// (POST /v2/{namespaceProvider}/{namespaceId}/keys/{id}/sign)
+	SignWithKey(ctx echo.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter, id IdParameter) error
+	// Unwrap key with key
+	// (POST /v2/{namespaceProvider}/{namespaceId}/keys/{id}/unwrapKey)
+	UnwrapWithKey(ctx echo.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter, id IdParameter) error
+	// Verify signature with key
+	// (POST /v2/{namespaceProvider}/{namespaceId}/keys/{id}/verify)
+	VerifyWithKey(ctx echo.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter, id IdParameter) error
+	// Wrap key with key
+	// (POST /v2/{namespaceProvider}/{namespaceId}/keys/{id}/wrapKey)
+	WrapWithKey(ctx echo.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter, id IdParameter) error
 	// Get member group
 	// (GET /v2/{namespaceProvider}/{namespaceId}/memberOf/{id})
 	GetMemberOf(ctx echo.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter, id IdParameter) error
 	// Sync member group
 	// (POST /v2/{namespaceProvider}/{namespaceId}/memberOf/{id})
 	SyncMemberOf(ctx echo.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter, id IdParameter) error
+	// List webhook deliveries which failed after all retries
+	// (GET /v2/{namespaceProvider}/{namespaceId}/webhook-dead-letters)
+	ListWebhookDeadLetters(ctx echo.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter, params ListWebhookDeadLettersParams) error
+	// List SSH CA policies
+	// (GET /v2/{namespaceProvider}/{namespaceId}/ssh-ca-policies)
+	ListSSHCAPolicies(ctx echo.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter) error
+	// Get SSH CA policy
+	// (GET /v2/{namespaceProvider}/{namespaceId}/ssh-ca-policies/{id})
+	GetSSHCAPolicy(ctx echo.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter, id IdParameter) error
+	// Put SSH CA policy, the CA key is created in the key store on first put or when the key spec changes
+	// (PUT /v2/{namespaceProvider}/{namespaceId}/ssh-ca-policies/{id})
+	PutSSHCAPolicy(ctx echo.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter, id IdParameter) error
+	// Get OpenSSH key revocation list of the certificates signed by the SSH CA, for RevokedKeys
+	// (GET /v2/{namespaceProvider}/{namespaceId}/ssh-ca-policies/{id}/krl)
+	GetSSHCAPolicyKRL(ctx echo.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter, id IdParameter) error
+	// Sign SSH certificate of the public key
+	// (POST /v2/{namespaceProvider}/{namespaceId}/ssh-ca-policies/{id}/sign)
+	SignSSHCertificate(ctx echo.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter, id IdParameter) error
+	// Get SSH certificate
+	// (GET /v2/{namespaceProvider}/{namespaceId}/ssh-certificates/{id})
+	GetSSHCertificate(ctx echo.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter, id IdParameter) error
+	// Revoke SSH certificate, it is listed in the KRL of the SSH CA policy
+	// (POST /v2/{namespaceProvider}/{namespaceId}/ssh-certificates/{id}/revoke)
+	RevokeSSHCertificate(ctx echo.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter, id IdParameter) error
+	// List webhook subscriptions
+	// (GET /v2/{namespaceProvider}/{namespaceId}/webhook-subscriptions)
+	ListWebhookSubscriptions(ctx echo.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter) error
+	// Delete webhook subscription
+	// (DELETE /v2/{namespaceProvider}/{namespaceId}/webhook-subscriptions/{id})
+	DeleteWebhookSubscription(ctx echo.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter, id IdParameter) error
+	// Get webhook subscription
+	// (GET /v2/{namespaceProvider}/{namespaceId}/webhook-subscriptions/{id})
+	GetWebhookSubscription(ctx echo.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter, id IdParameter) error
+	// Put webhook subscription
+	// (PUT /v2/{namespaceProvider}/{namespaceId}/webhook-subscriptions/{id})
+	PutWebhookSubscription(ctx echo.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter, id IdParameter) error
 }
 
 // ServerInterfaceWrapper converts echo contexts to parameters.
@@ -328,6 +546,88 @@ func (w *ServerInterfaceWrapper) GetAgent(ctx echo.Context) error {
 	return err
 }
 
+// ListAuditRecords converts echo context to params.
+func (w *ServerInterfaceWrapper) ListAuditRecords(ctx echo.Context) error {
+	var err error
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params ListAuditRecordsParams
+	// ------------- Optional query parameter "namespaceProvider" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "namespaceProvider", ctx.QueryParams(), &params.NamespaceProvider)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceProvider: %s", err))
+	}
+
+	// ------------- Optional query parameter "namespaceId" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "namespaceId", ctx.QueryParams(), &params.NamespaceId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceId: %s", err))
+	}
+
+	// ------------- Optional query parameter "resourceProvider" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "resourceProvider", ctx.QueryParams(), &params.ResourceProvider)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter resourceProvider: %s", err))
+	}
+
+	// ------------- Optional query parameter "resourceId" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "resourceId", ctx.QueryParams(), &params.ResourceId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter resourceId: %s", err))
+	}
+
+	// ------------- Optional query parameter "actor" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "actor", ctx.QueryParams(), &params.Actor)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter actor: %s", err))
+	}
+
+	// ------------- Optional query parameter "from" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "from", ctx.QueryParams(), &params.From)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter from: %s", err))
+	}
+
+	// ------------- Optional query parameter "to" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "to", ctx.QueryParams(), &params.To)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter to: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.ListAuditRecords(ctx, params)
+	return err
+}
+
+// VerifyAuditRecords converts echo context to params.
+func (w *ServerInterfaceWrapper) VerifyAuditRecords(ctx echo.Context) error {
+	var err error
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params VerifyAuditRecordsParams
+	// ------------- Optional query parameter "fromSeq" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "fromSeq", ctx.QueryParams(), &params.FromSeq)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter fromSeq: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.VerifyAuditRecords(ctx, params)
+	return err
+}
+
 // GetDiagnostics converts echo context to params.
 func (w *ServerInterfaceWrapper) GetDiagnostics(ctx echo.Context) error {
 	var err error
@@ -531,6 +831,32 @@ func (w *ServerInterfaceWrapper) PutProfile(ctx echo.Context) error {
 	return err
 }
 
+// GetACMEHTTP01Challenge converts echo context to params.
+func (w *ServerInterfaceWrapper) GetACMEHTTP01Challenge(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "namespaceId" -------------
+	var namespaceId NamespaceIdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceId", runtime.ParamLocationPath, ctx.Param("namespaceId"), &namespaceId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceId: %s", err))
+	}
+
+	// ------------- Path parameter "id" -------------
+	var id IdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetACMEHTTP01Challenge(ctx, namespaceId, id)
+	return err
+}
+
 // GetAgentConfigBundle converts echo context to params.
 func (w *ServerInterfaceWrapper) GetAgentConfigBundle(ctx echo.Context) error {
 	var err error
@@ -890,6 +1216,32 @@ func (w *ServerInterfaceWrapper) SyncSystemApp(ctx echo.Context) error {
 	return err
 }
 
+// CreateACMEExternalAccountBinding converts echo context to params.
+func (w *ServerInterfaceWrapper) CreateACMEExternalAccountBinding(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "namespaceProvider" -------------
+	var namespaceProvider NamespaceProviderParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceProvider", runtime.ParamLocationPath, ctx.Param("namespaceProvider"), &namespaceProvider)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceProvider: %s", err))
+	}
+
+	// ------------- Path parameter "namespaceId" -------------
+	var namespaceId NamespaceIdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceId", runtime.ParamLocationPath, ctx.Param("namespaceId"), &namespaceId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceId: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.CreateACMEExternalAccountBinding(ctx, namespaceProvider, namespaceId)
+	return err
+}
+
 // ListCertificatePolicies converts echo context to params.
 func (w *ServerInterfaceWrapper) ListCertificatePolicies(ctx echo.Context) error {
 	var err error
@@ -984,8 +1336,8 @@ func (w *ServerInterfaceWrapper) PutCertificatePolicy(ctx echo.Context) error {
 	return err
 }
 
-// EnrollCertificate converts echo context to params.
-func (w *ServerInterfaceWrapper) EnrollCertificate(ctx echo.Context) error {
+// UpdateACMEAccount converts echo context to params.
+func (w *ServerInterfaceWrapper) UpdateACMEAccount(ctx echo.Context) error {
 	var err error
 	// ------------- Path parameter "namespaceProvider" -------------
 	var namespaceProvider NamespaceProviderParameter
@@ -1011,24 +1363,21 @@ func (w *ServerInterfaceWrapper) EnrollCertificate(ctx echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
 	}
 
-	ctx.Set(BearerAuthScopes, []string{})
-
-	// Parameter object where we will unmarshal all parameters from the context
-	var params EnrollCertificateParams
-	// ------------- Optional query parameter "onBehalfOfApplication" -------------
+	// ------------- Path parameter "accountId" -------------
+	var accountId ACMEAccountIdParameter
 
-	err = runtime.BindQueryParameter("form", true, false, "onBehalfOfApplication", ctx.QueryParams(), &params.OnBehalfOfApplication)
+	err = runtime.BindStyledParameterWithLocation("simple", false, "accountId", runtime.ParamLocationPath, ctx.Param("accountId"), &accountId)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter onBehalfOfApplication: %s", err))
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter accountId: %s", err))
 	}
 
 	// Invoke the callback with all the unmarshaled arguments
-	err = w.Handler.EnrollCertificate(ctx, namespaceProvider, namespaceId, id, params)
+	err = w.Handler.UpdateACMEAccount(ctx, namespaceProvider, namespaceId, id, accountId)
 	return err
 }
 
-// GenerateCertificate converts echo context to params.
-func (w *ServerInterfaceWrapper) GenerateCertificate(ctx echo.Context) error {
+// GetACMEAuthorization converts echo context to params.
+func (w *ServerInterfaceWrapper) GetACMEAuthorization(ctx echo.Context) error {
 	var err error
 	// ------------- Path parameter "namespaceProvider" -------------
 	var namespaceProvider NamespaceProviderParameter
@@ -1054,15 +1403,21 @@ func (w *ServerInterfaceWrapper) GenerateCertificate(ctx echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
 	}
 
-	ctx.Set(BearerAuthScopes, []string{})
+	// ------------- Path parameter "authzId" -------------
+	var authzId ACMEAuthzIdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "authzId", runtime.ParamLocationPath, ctx.Param("authzId"), &authzId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter authzId: %s", err))
+	}
 
 	// Invoke the callback with all the unmarshaled arguments
-	err = w.Handler.GenerateCertificate(ctx, namespaceProvider, namespaceId, id)
+	err = w.Handler.GetACMEAuthorization(ctx, namespaceProvider, namespaceId, id, authzId)
 	return err
 }
 
-// GetCertificatePolicyIssuer converts echo context to params.
-func (w *ServerInterfaceWrapper) GetCertificatePolicyIssuer(ctx echo.Context) error {
+// RespondACMEChallenge converts echo context to params.
+func (w *ServerInterfaceWrapper) RespondACMEChallenge(ctx echo.Context) error {
 	var err error
 	// ------------- Path parameter "namespaceProvider" -------------
 	var namespaceProvider NamespaceProviderParameter
@@ -1088,15 +1443,29 @@ func (w *ServerInterfaceWrapper) GetCertificatePolicyIssuer(ctx echo.Context) er
 		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
 	}
 
-	ctx.Set(BearerAuthScopes, []string{})
+	// ------------- Path parameter "authzId" -------------
+	var authzId ACMEAuthzIdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "authzId", runtime.ParamLocationPath, ctx.Param("authzId"), &authzId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter authzId: %s", err))
+	}
+
+	// ------------- Path parameter "challengeType" -------------
+	var challengeType string
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "challengeType", runtime.ParamLocationPath, ctx.Param("challengeType"), &challengeType)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter challengeType: %s", err))
+	}
 
 	// Invoke the callback with all the unmarshaled arguments
-	err = w.Handler.GetCertificatePolicyIssuer(ctx, namespaceProvider, namespaceId, id)
+	err = w.Handler.RespondACMEChallenge(ctx, namespaceProvider, namespaceId, id, authzId, challengeType)
 	return err
 }
 
-// PutCertificatePolicyIssuer converts echo context to params.
-func (w *ServerInterfaceWrapper) PutCertificatePolicyIssuer(ctx echo.Context) error {
+// GetACMECertificate converts echo context to params.
+func (w *ServerInterfaceWrapper) GetACMECertificate(ctx echo.Context) error {
 	var err error
 	// ------------- Path parameter "namespaceProvider" -------------
 	var namespaceProvider NamespaceProviderParameter
@@ -1122,15 +1491,21 @@ func (w *ServerInterfaceWrapper) PutCertificatePolicyIssuer(ctx echo.Context) er
 		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
 	}
 
-	ctx.Set(BearerAuthScopes, []string{})
+	// ------------- Path parameter "certificateId" -------------
+	var certificateId string
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "certificateId", runtime.ParamLocationPath, ctx.Param("certificateId"), &certificateId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter certificateId: %s", err))
+	}
 
 	// Invoke the callback with all the unmarshaled arguments
-	err = w.Handler.PutCertificatePolicyIssuer(ctx, namespaceProvider, namespaceId, id)
+	err = w.Handler.GetACMECertificate(ctx, namespaceProvider, namespaceId, id, certificateId)
 	return err
 }
 
-// ListCertificates converts echo context to params.
-func (w *ServerInterfaceWrapper) ListCertificates(ctx echo.Context) error {
+// GetACMEDirectory converts echo context to params.
+func (w *ServerInterfaceWrapper) GetACMEDirectory(ctx echo.Context) error {
 	var err error
 	// ------------- Path parameter "namespaceProvider" -------------
 	var namespaceProvider NamespaceProviderParameter
@@ -1148,24 +1523,21 @@ func (w *ServerInterfaceWrapper) ListCertificates(ctx echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceId: %s", err))
 	}
 
-	ctx.Set(BearerAuthScopes, []string{})
-
-	// Parameter object where we will unmarshal all parameters from the context
-	var params ListCertificatesParams
-	// ------------- Optional query parameter "policyId" -------------
+	// ------------- Path parameter "id" -------------
+	var id IdParameter
 
-	err = runtime.BindQueryParameter("form", true, false, "policyId", ctx.QueryParams(), &params.PolicyId)
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter policyId: %s", err))
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
 	}
 
 	// Invoke the callback with all the unmarshaled arguments
-	err = w.Handler.ListCertificates(ctx, namespaceProvider, namespaceId, params)
+	err = w.Handler.GetACMEDirectory(ctx, namespaceProvider, namespaceId, id)
 	return err
 }
 
-// DeleteCertificate converts echo context to params.
-func (w *ServerInterfaceWrapper) DeleteCertificate(ctx echo.Context) error {
+// NewACMEAccount converts echo context to params.
+func (w *ServerInterfaceWrapper) NewACMEAccount(ctx echo.Context) error {
 	var err error
 	// ------------- Path parameter "namespaceProvider" -------------
 	var namespaceProvider NamespaceProviderParameter
@@ -1191,15 +1563,13 @@ func (w *ServerInterfaceWrapper) DeleteCertificate(ctx echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
 	}
 
-	ctx.Set(BearerAuthScopes, []string{})
-
 	// Invoke the callback with all the unmarshaled arguments
-	err = w.Handler.DeleteCertificate(ctx, namespaceProvider, namespaceId, id)
+	err = w.Handler.NewACMEAccount(ctx, namespaceProvider, namespaceId, id)
 	return err
 }
 
-// GetCertificate converts echo context to params.
-func (w *ServerInterfaceWrapper) GetCertificate(ctx echo.Context) error {
+// GetACMENonce converts echo context to params.
+func (w *ServerInterfaceWrapper) GetACMENonce(ctx echo.Context) error {
 	var err error
 	// ------------- Path parameter "namespaceProvider" -------------
 	var namespaceProvider NamespaceProviderParameter
@@ -1225,31 +1595,13 @@ func (w *ServerInterfaceWrapper) GetCertificate(ctx echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
 	}
 
-	ctx.Set(BearerAuthScopes, []string{})
-
-	// Parameter object where we will unmarshal all parameters from the context
-	var params GetCertificateParams
-	// ------------- Optional query parameter "includeJwk" -------------
-
-	err = runtime.BindQueryParameter("form", true, false, "includeJwk", ctx.QueryParams(), &params.IncludeJwk)
-	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter includeJwk: %s", err))
-	}
-
-	// ------------- Optional query parameter "pending" -------------
-
-	err = runtime.BindQueryParameter("form", true, false, "pending", ctx.QueryParams(), &params.Pending)
-	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter pending: %s", err))
-	}
-
 	// Invoke the callback with all the unmarshaled arguments
-	err = w.Handler.GetCertificate(ctx, namespaceProvider, namespaceId, id, params)
+	err = w.Handler.GetACMENonce(ctx, namespaceProvider, namespaceId, id)
 	return err
 }
 
-// ExchangePKCS12 converts echo context to params.
-func (w *ServerInterfaceWrapper) ExchangePKCS12(ctx echo.Context) error {
+// HeadACMENonce converts echo context to params.
+func (w *ServerInterfaceWrapper) HeadACMENonce(ctx echo.Context) error {
 	var err error
 	// ------------- Path parameter "namespaceProvider" -------------
 	var namespaceProvider NamespaceProviderParameter
@@ -1275,15 +1627,13 @@ func (w *ServerInterfaceWrapper) ExchangePKCS12(ctx echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
 	}
 
-	ctx.Set(BearerAuthScopes, []string{})
-
 	// Invoke the callback with all the unmarshaled arguments
-	err = w.Handler.ExchangePKCS12(ctx, namespaceProvider, namespaceId, id)
+	err = w.Handler.HeadACMENonce(ctx, namespaceProvider, namespaceId, id)
 	return err
 }
 
-// AddMsEntraKeyCredential converts echo context to params.
-func (w *ServerInterfaceWrapper) AddMsEntraKeyCredential(ctx echo.Context) error {
+// NewACMEOrder converts echo context to params.
+func (w *ServerInterfaceWrapper) NewACMEOrder(ctx echo.Context) error {
 	var err error
 	// ------------- Path parameter "namespaceProvider" -------------
 	var namespaceProvider NamespaceProviderParameter
@@ -1309,15 +1659,13 @@ func (w *ServerInterfaceWrapper) AddMsEntraKeyCredential(ctx echo.Context) error
 		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
 	}
 
-	ctx.Set(BearerAuthScopes, []string{})
-
 	// Invoke the callback with all the unmarshaled arguments
-	err = w.Handler.AddMsEntraKeyCredential(ctx, namespaceProvider, namespaceId, id)
+	err = w.Handler.NewACMEOrder(ctx, namespaceProvider, namespaceId, id)
 	return err
 }
 
-// UpdatePendingCertificate converts echo context to params.
-func (w *ServerInterfaceWrapper) UpdatePendingCertificate(ctx echo.Context) error {
+// GetACMEOrder converts echo context to params.
+func (w *ServerInterfaceWrapper) GetACMEOrder(ctx echo.Context) error {
 	var err error
 	// ------------- Path parameter "namespaceProvider" -------------
 	var namespaceProvider NamespaceProviderParameter
@@ -1343,15 +1691,21 @@ func (w *ServerInterfaceWrapper) UpdatePendingCertificate(ctx echo.Context) erro
 		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
 	}
 
-	ctx.Set(BearerAuthScopes, []string{})
+	// ------------- Path parameter "orderId" -------------
+	var orderId ACMEOrderIdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "orderId", runtime.ParamLocationPath, ctx.Param("orderId"), &orderId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter orderId: %s", err))
+	}
 
 	// Invoke the callback with all the unmarshaled arguments
-	err = w.Handler.UpdatePendingCertificate(ctx, namespaceProvider, namespaceId, id)
+	err = w.Handler.GetACMEOrder(ctx, namespaceProvider, namespaceId, id, orderId)
 	return err
 }
 
-// GetCertificateSecret converts echo context to params.
-func (w *ServerInterfaceWrapper) GetCertificateSecret(ctx echo.Context) error {
+// FinalizeACMEOrder converts echo context to params.
+func (w *ServerInterfaceWrapper) FinalizeACMEOrder(ctx echo.Context) error {
 	var err error
 	// ------------- Path parameter "namespaceProvider" -------------
 	var namespaceProvider NamespaceProviderParameter
@@ -1377,15 +1731,21 @@ func (w *ServerInterfaceWrapper) GetCertificateSecret(ctx echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
 	}
 
-	ctx.Set(BearerAuthScopes, []string{})
+	// ------------- Path parameter "orderId" -------------
+	var orderId ACMEOrderIdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "orderId", runtime.ParamLocationPath, ctx.Param("orderId"), &orderId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter orderId: %s", err))
+	}
 
 	// Invoke the callback with all the unmarshaled arguments
-	err = w.Handler.GetCertificateSecret(ctx, namespaceProvider, namespaceId, id)
+	err = w.Handler.FinalizeACMEOrder(ctx, namespaceProvider, namespaceId, id, orderId)
 	return err
 }
 
-// ListKeyPolicies converts echo context to params.
-func (w *ServerInterfaceWrapper) ListKeyPolicies(ctx echo.Context) error {
+// RevokeACMECertificate converts echo context to params.
+func (w *ServerInterfaceWrapper) RevokeACMECertificate(ctx echo.Context) error {
 	var err error
 	// ------------- Path parameter "namespaceProvider" -------------
 	var namespaceProvider NamespaceProviderParameter
@@ -1403,15 +1763,21 @@ func (w *ServerInterfaceWrapper) ListKeyPolicies(ctx echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceId: %s", err))
 	}
 
-	ctx.Set(BearerAuthScopes, []string{})
+	// ------------- Path parameter "id" -------------
+	var id IdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
 
 	// Invoke the callback with all the unmarshaled arguments
-	err = w.Handler.ListKeyPolicies(ctx, namespaceProvider, namespaceId)
+	err = w.Handler.RevokeACMECertificate(ctx, namespaceProvider, namespaceId, id)
 	return err
 }
 
-// GetKeyPolicy converts echo context to params.
-func (w *ServerInterfaceWrapper) GetKeyPolicy(ctx echo.Context) error {
+// EnrollCertificate converts echo context to params.
+func (w *ServerInterfaceWrapper) EnrollCertificate(ctx echo.Context) error {
 	var err error
 	// ------------- Path parameter "namespaceProvider" -------------
 	var namespaceProvider NamespaceProviderParameter
@@ -1439,13 +1805,22 @@ func (w *ServerInterfaceWrapper) GetKeyPolicy(ctx echo.Context) error {
 
 	ctx.Set(BearerAuthScopes, []string{})
 
+	// Parameter object where we will unmarshal all parameters from the context
+	var params EnrollCertificateParams
+	// ------------- Optional query parameter "onBehalfOfApplication" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "onBehalfOfApplication", ctx.QueryParams(), &params.OnBehalfOfApplication)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter onBehalfOfApplication: %s", err))
+	}
+
 	// Invoke the callback with all the unmarshaled arguments
-	err = w.Handler.GetKeyPolicy(ctx, namespaceProvider, namespaceId, id)
+	err = w.Handler.EnrollCertificate(ctx, namespaceProvider, namespaceId, id, params)
 	return err
 }
 
-// PutKeyPolicy converts echo context to params.
-func (w *ServerInterfaceWrapper) PutKeyPolicy(ctx echo.Context) error {
+// GenerateCertificate converts echo context to params.
+func (w *ServerInterfaceWrapper) GenerateCertificate(ctx echo.Context) error {
 	var err error
 	// ------------- Path parameter "namespaceProvider" -------------
 	var namespaceProvider NamespaceProviderParameter
@@ -1474,12 +1849,12 @@ func (w *ServerInterfaceWrapper) PutKeyPolicy(ctx echo.Context) error {
 	ctx.Set(BearerAuthScopes, []string{})
 
 	// Invoke the callback with all the unmarshaled arguments
-	err = w.Handler.PutKeyPolicy(ctx, namespaceProvider, namespaceId, id)
+	err = w.Handler.GenerateCertificate(ctx, namespaceProvider, namespaceId, id)
 	return err
 }
 
-// GenerateKey converts echo context to params.
-func (w *ServerInterfaceWrapper) GenerateKey(ctx echo.Context) error {
+// GetCertificatePolicyIssuer converts echo context to params.
+func (w *ServerInterfaceWrapper) GetCertificatePolicyIssuer(ctx echo.Context) error {
 	var err error
 	// ------------- Path parameter "namespaceProvider" -------------
 	var namespaceProvider NamespaceProviderParameter
@@ -1508,12 +1883,78 @@ func (w *ServerInterfaceWrapper) GenerateKey(ctx echo.Context) error {
 	ctx.Set(BearerAuthScopes, []string{})
 
 	// Invoke the callback with all the unmarshaled arguments
-	err = w.Handler.GenerateKey(ctx, namespaceProvider, namespaceId, id)
+	err = w.Handler.GetCertificatePolicyIssuer(ctx, namespaceProvider, namespaceId, id)
 	return err
 }
 
-// ListKeys converts echo context to params.
-func (w *ServerInterfaceWrapper) ListKeys(ctx echo.Context) error {
+// PutCertificatePolicyIssuer converts echo context to params.
+func (w *ServerInterfaceWrapper) PutCertificatePolicyIssuer(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "namespaceProvider" -------------
+	var namespaceProvider NamespaceProviderParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceProvider", runtime.ParamLocationPath, ctx.Param("namespaceProvider"), &namespaceProvider)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceProvider: %s", err))
+	}
+
+	// ------------- Path parameter "namespaceId" -------------
+	var namespaceId NamespaceIdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceId", runtime.ParamLocationPath, ctx.Param("namespaceId"), &namespaceId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceId: %s", err))
+	}
+
+	// ------------- Path parameter "id" -------------
+	var id IdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.PutCertificatePolicyIssuer(ctx, namespaceProvider, namespaceId, id)
+	return err
+}
+
+// RespondTimestamp converts echo context to params.
+func (w *ServerInterfaceWrapper) RespondTimestamp(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "namespaceProvider" -------------
+	var namespaceProvider NamespaceProviderParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceProvider", runtime.ParamLocationPath, ctx.Param("namespaceProvider"), &namespaceProvider)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceProvider: %s", err))
+	}
+
+	// ------------- Path parameter "namespaceId" -------------
+	var namespaceId NamespaceIdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceId", runtime.ParamLocationPath, ctx.Param("namespaceId"), &namespaceId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceId: %s", err))
+	}
+
+	// ------------- Path parameter "id" -------------
+	var id IdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.RespondTimestamp(ctx, namespaceProvider, namespaceId, id)
+	return err
+}
+
+// ListCertificates converts echo context to params.
+func (w *ServerInterfaceWrapper) ListCertificates(ctx echo.Context) error {
 	var err error
 	// ------------- Path parameter "namespaceProvider" -------------
 	var namespaceProvider NamespaceProviderParameter
@@ -1534,7 +1975,7 @@ func (w *ServerInterfaceWrapper) ListKeys(ctx echo.Context) error {
 	ctx.Set(BearerAuthScopes, []string{})
 
 	// Parameter object where we will unmarshal all parameters from the context
-	var params ListKeysParams
+	var params ListCertificatesParams
 	// ------------- Optional query parameter "policyId" -------------
 
 	err = runtime.BindQueryParameter("form", true, false, "policyId", ctx.QueryParams(), &params.PolicyId)
@@ -1543,12 +1984,46 @@ func (w *ServerInterfaceWrapper) ListKeys(ctx echo.Context) error {
 	}
 
 	// Invoke the callback with all the unmarshaled arguments
-	err = w.Handler.ListKeys(ctx, namespaceProvider, namespaceId, params)
+	err = w.Handler.ListCertificates(ctx, namespaceProvider, namespaceId, params)
 	return err
 }
 
-// GetKey converts echo context to params.
-func (w *ServerInterfaceWrapper) GetKey(ctx echo.Context) error {
+// DeleteCertificate converts echo context to params.
+func (w *ServerInterfaceWrapper) DeleteCertificate(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "namespaceProvider" -------------
+	var namespaceProvider NamespaceProviderParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceProvider", runtime.ParamLocationPath, ctx.Param("namespaceProvider"), &namespaceProvider)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceProvider: %s", err))
+	}
+
+	// ------------- Path parameter "namespaceId" -------------
+	var namespaceId NamespaceIdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceId", runtime.ParamLocationPath, ctx.Param("namespaceId"), &namespaceId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceId: %s", err))
+	}
+
+	// ------------- Path parameter "id" -------------
+	var id IdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.DeleteCertificate(ctx, namespaceProvider, namespaceId, id)
+	return err
+}
+
+// GetCertificate converts echo context to params.
+func (w *ServerInterfaceWrapper) GetCertificate(ctx echo.Context) error {
 	var err error
 	// ------------- Path parameter "namespaceProvider" -------------
 	var namespaceProvider NamespaceProviderParameter
@@ -1577,7 +2052,7 @@ func (w *ServerInterfaceWrapper) GetKey(ctx echo.Context) error {
 	ctx.Set(BearerAuthScopes, []string{})
 
 	// Parameter object where we will unmarshal all parameters from the context
-	var params GetKeyParams
+	var params GetCertificateParams
 	// ------------- Optional query parameter "includeJwk" -------------
 
 	err = runtime.BindQueryParameter("form", true, false, "includeJwk", ctx.QueryParams(), &params.IncludeJwk)
@@ -1585,20 +2060,61 @@ func (w *ServerInterfaceWrapper) GetKey(ctx echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter includeJwk: %s", err))
 	}
 
-	// ------------- Optional query parameter "verify" -------------
+	// ------------- Optional query parameter "pending" -------------
 
-	err = runtime.BindQueryParameter("form", true, false, "verify", ctx.QueryParams(), &params.Verify)
+	err = runtime.BindQueryParameter("form", true, false, "pending", ctx.QueryParams(), &params.Pending)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter verify: %s", err))
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter pending: %s", err))
 	}
 
 	// Invoke the callback with all the unmarshaled arguments
-	err = w.Handler.GetKey(ctx, namespaceProvider, namespaceId, id, params)
+	err = w.Handler.GetCertificate(ctx, namespaceProvider, namespaceId, id, params)
 	return err
 }
 
-// GetMemberOf converts echo context to params.
-func (w *ServerInterfaceWrapper) GetMemberOf(ctx echo.Context) error {
+// GetCertificateCRL converts echo context to params.
+func (w *ServerInterfaceWrapper) GetCertificateCRL(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "namespaceProvider" -------------
+	var namespaceProvider NamespaceProviderParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceProvider", runtime.ParamLocationPath, ctx.Param("namespaceProvider"), &namespaceProvider)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceProvider: %s", err))
+	}
+
+	// ------------- Path parameter "namespaceId" -------------
+	var namespaceId NamespaceIdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceId", runtime.ParamLocationPath, ctx.Param("namespaceId"), &namespaceId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceId: %s", err))
+	}
+
+	// ------------- Path parameter "id" -------------
+	var id IdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetCertificateCRLParams
+	// ------------- Optional query parameter "pem" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "pem", ctx.QueryParams(), &params.Pem)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter pem: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetCertificateCRL(ctx, namespaceProvider, namespaceId, id, params)
+	return err
+}
+
+// ExchangePKCS12 converts echo context to params.
+func (w *ServerInterfaceWrapper) ExchangePKCS12(ctx echo.Context) error {
 	var err error
 	// ------------- Path parameter "namespaceProvider" -------------
 	var namespaceProvider NamespaceProviderParameter
@@ -1627,12 +2143,12 @@ func (w *ServerInterfaceWrapper) GetMemberOf(ctx echo.Context) error {
 	ctx.Set(BearerAuthScopes, []string{})
 
 	// Invoke the callback with all the unmarshaled arguments
-	err = w.Handler.GetMemberOf(ctx, namespaceProvider, namespaceId, id)
+	err = w.Handler.ExchangePKCS12(ctx, namespaceProvider, namespaceId, id)
 	return err
 }
 
-// SyncMemberOf converts echo context to params.
-func (w *ServerInterfaceWrapper) SyncMemberOf(ctx echo.Context) error {
+// AddMsEntraKeyCredential converts echo context to params.
+func (w *ServerInterfaceWrapper) AddMsEntraKeyCredential(ctx echo.Context) error {
 	var err error
 	// ------------- Path parameter "namespaceProvider" -------------
 	var namespaceProvider NamespaceProviderParameter
@@ -1661,7 +2177,1175 @@ func (w *ServerInterfaceWrapper) SyncMemberOf(ctx echo.Context) error {
 	ctx.Set(BearerAuthScopes, []string{})
 
 	// Invoke the callback with all the unmarshaled arguments
-	err = w.Handler.SyncMemberOf(ctx, namespaceProvider, namespaceId, id)
+	err = w.Handler.AddMsEntraKeyCredential(ctx, namespaceProvider, namespaceId, id)
+	return err
+}
+
+// RespondOCSP converts echo context to params.
+func (w *ServerInterfaceWrapper) RespondOCSP(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "namespaceProvider" -------------
+	var namespaceProvider NamespaceProviderParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceProvider", runtime.ParamLocationPath, ctx.Param("namespaceProvider"), &namespaceProvider)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceProvider: %s", err))
+	}
+
+	// ------------- Path parameter "namespaceId" -------------
+	var namespaceId NamespaceIdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceId", runtime.ParamLocationPath, ctx.Param("namespaceId"), &namespaceId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceId: %s", err))
+	}
+
+	// ------------- Path parameter "id" -------------
+	var id IdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.RespondOCSP(ctx, namespaceProvider, namespaceId, id)
+	return err
+}
+
+// UpdatePendingCertificate converts echo context to params.
+func (w *ServerInterfaceWrapper) UpdatePendingCertificate(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "namespaceProvider" -------------
+	var namespaceProvider NamespaceProviderParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceProvider", runtime.ParamLocationPath, ctx.Param("namespaceProvider"), &namespaceProvider)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceProvider: %s", err))
+	}
+
+	// ------------- Path parameter "namespaceId" -------------
+	var namespaceId NamespaceIdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceId", runtime.ParamLocationPath, ctx.Param("namespaceId"), &namespaceId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceId: %s", err))
+	}
+
+	// ------------- Path parameter "id" -------------
+	var id IdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.UpdatePendingCertificate(ctx, namespaceProvider, namespaceId, id)
+	return err
+}
+
+// RevokeCertificate converts echo context to params.
+func (w *ServerInterfaceWrapper) RevokeCertificate(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "namespaceProvider" -------------
+	var namespaceProvider NamespaceProviderParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceProvider", runtime.ParamLocationPath, ctx.Param("namespaceProvider"), &namespaceProvider)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceProvider: %s", err))
+	}
+
+	// ------------- Path parameter "namespaceId" -------------
+	var namespaceId NamespaceIdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceId", runtime.ParamLocationPath, ctx.Param("namespaceId"), &namespaceId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceId: %s", err))
+	}
+
+	// ------------- Path parameter "id" -------------
+	var id IdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.RevokeCertificate(ctx, namespaceProvider, namespaceId, id)
+	return err
+}
+
+// GetCertificateSecret converts echo context to params.
+func (w *ServerInterfaceWrapper) GetCertificateSecret(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "namespaceProvider" -------------
+	var namespaceProvider NamespaceProviderParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceProvider", runtime.ParamLocationPath, ctx.Param("namespaceProvider"), &namespaceProvider)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceProvider: %s", err))
+	}
+
+	// ------------- Path parameter "namespaceId" -------------
+	var namespaceId NamespaceIdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceId", runtime.ParamLocationPath, ctx.Param("namespaceId"), &namespaceId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceId: %s", err))
+	}
+
+	// ------------- Path parameter "id" -------------
+	var id IdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetCertificateSecret(ctx, namespaceProvider, namespaceId, id)
+	return err
+}
+
+// GetNamespaceJwks converts echo context to params.
+func (w *ServerInterfaceWrapper) GetNamespaceJwks(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "namespaceProvider" -------------
+	var namespaceProvider NamespaceProviderParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceProvider", runtime.ParamLocationPath, ctx.Param("namespaceProvider"), &namespaceProvider)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceProvider: %s", err))
+	}
+
+	// ------------- Path parameter "namespaceId" -------------
+	var namespaceId NamespaceIdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceId", runtime.ParamLocationPath, ctx.Param("namespaceId"), &namespaceId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceId: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetNamespaceJwks(ctx, namespaceProvider, namespaceId)
+	return err
+}
+
+// ListKeyPolicies converts echo context to params.
+func (w *ServerInterfaceWrapper) ListKeyPolicies(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "namespaceProvider" -------------
+	var namespaceProvider NamespaceProviderParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceProvider", runtime.ParamLocationPath, ctx.Param("namespaceProvider"), &namespaceProvider)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceProvider: %s", err))
+	}
+
+	// ------------- Path parameter "namespaceId" -------------
+	var namespaceId NamespaceIdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceId", runtime.ParamLocationPath, ctx.Param("namespaceId"), &namespaceId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceId: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.ListKeyPolicies(ctx, namespaceProvider, namespaceId)
+	return err
+}
+
+// GetKeyPolicy converts echo context to params.
+func (w *ServerInterfaceWrapper) GetKeyPolicy(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "namespaceProvider" -------------
+	var namespaceProvider NamespaceProviderParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceProvider", runtime.ParamLocationPath, ctx.Param("namespaceProvider"), &namespaceProvider)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceProvider: %s", err))
+	}
+
+	// ------------- Path parameter "namespaceId" -------------
+	var namespaceId NamespaceIdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceId", runtime.ParamLocationPath, ctx.Param("namespaceId"), &namespaceId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceId: %s", err))
+	}
+
+	// ------------- Path parameter "id" -------------
+	var id IdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetKeyPolicy(ctx, namespaceProvider, namespaceId, id)
+	return err
+}
+
+// PutKeyPolicy converts echo context to params.
+func (w *ServerInterfaceWrapper) PutKeyPolicy(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "namespaceProvider" -------------
+	var namespaceProvider NamespaceProviderParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceProvider", runtime.ParamLocationPath, ctx.Param("namespaceProvider"), &namespaceProvider)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceProvider: %s", err))
+	}
+
+	// ------------- Path parameter "namespaceId" -------------
+	var namespaceId NamespaceIdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceId", runtime.ParamLocationPath, ctx.Param("namespaceId"), &namespaceId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceId: %s", err))
+	}
+
+	// ------------- Path parameter "id" -------------
+	var id IdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.PutKeyPolicy(ctx, namespaceProvider, namespaceId, id)
+	return err
+}
+
+// GenerateKey converts echo context to params.
+func (w *ServerInterfaceWrapper) GenerateKey(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "namespaceProvider" -------------
+	var namespaceProvider NamespaceProviderParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceProvider", runtime.ParamLocationPath, ctx.Param("namespaceProvider"), &namespaceProvider)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceProvider: %s", err))
+	}
+
+	// ------------- Path parameter "namespaceId" -------------
+	var namespaceId NamespaceIdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceId", runtime.ParamLocationPath, ctx.Param("namespaceId"), &namespaceId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceId: %s", err))
+	}
+
+	// ------------- Path parameter "id" -------------
+	var id IdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GenerateKey(ctx, namespaceProvider, namespaceId, id)
+	return err
+}
+
+// ImportKey converts echo context to params.
+func (w *ServerInterfaceWrapper) ImportKey(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "namespaceProvider" -------------
+	var namespaceProvider NamespaceProviderParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceProvider", runtime.ParamLocationPath, ctx.Param("namespaceProvider"), &namespaceProvider)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceProvider: %s", err))
+	}
+
+	// ------------- Path parameter "namespaceId" -------------
+	var namespaceId NamespaceIdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceId", runtime.ParamLocationPath, ctx.Param("namespaceId"), &namespaceId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceId: %s", err))
+	}
+
+	// ------------- Path parameter "id" -------------
+	var id IdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.ImportKey(ctx, namespaceProvider, namespaceId, id)
+	return err
+}
+
+// GetKeyPolicyJwks converts echo context to params.
+func (w *ServerInterfaceWrapper) GetKeyPolicyJwks(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "namespaceProvider" -------------
+	var namespaceProvider NamespaceProviderParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceProvider", runtime.ParamLocationPath, ctx.Param("namespaceProvider"), &namespaceProvider)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceProvider: %s", err))
+	}
+
+	// ------------- Path parameter "namespaceId" -------------
+	var namespaceId NamespaceIdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceId", runtime.ParamLocationPath, ctx.Param("namespaceId"), &namespaceId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceId: %s", err))
+	}
+
+	// ------------- Path parameter "id" -------------
+	var id IdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetKeyPolicyJwks(ctx, namespaceProvider, namespaceId, id)
+	return err
+}
+
+// ListKeys converts echo context to params.
+func (w *ServerInterfaceWrapper) ListKeys(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "namespaceProvider" -------------
+	var namespaceProvider NamespaceProviderParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceProvider", runtime.ParamLocationPath, ctx.Param("namespaceProvider"), &namespaceProvider)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceProvider: %s", err))
+	}
+
+	// ------------- Path parameter "namespaceId" -------------
+	var namespaceId NamespaceIdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceId", runtime.ParamLocationPath, ctx.Param("namespaceId"), &namespaceId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceId: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params ListKeysParams
+	// ------------- Optional query parameter "policyId" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "policyId", ctx.QueryParams(), &params.PolicyId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter policyId: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.ListKeys(ctx, namespaceProvider, namespaceId, params)
+	return err
+}
+
+// GetKey converts echo context to params.
+func (w *ServerInterfaceWrapper) GetKey(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "namespaceProvider" -------------
+	var namespaceProvider NamespaceProviderParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceProvider", runtime.ParamLocationPath, ctx.Param("namespaceProvider"), &namespaceProvider)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceProvider: %s", err))
+	}
+
+	// ------------- Path parameter "namespaceId" -------------
+	var namespaceId NamespaceIdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceId", runtime.ParamLocationPath, ctx.Param("namespaceId"), &namespaceId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceId: %s", err))
+	}
+
+	// ------------- Path parameter "id" -------------
+	var id IdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetKeyParams
+	// ------------- Optional query parameter "includeJwk" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "includeJwk", ctx.QueryParams(), &params.IncludeJwk)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter includeJwk: %s", err))
+	}
+
+	// ------------- Optional query parameter "verify" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "verify", ctx.QueryParams(), &params.Verify)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter verify: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetKey(ctx, namespaceProvider, namespaceId, id, params)
+	return err
+}
+
+// DecryptWithKey converts echo context to params.
+func (w *ServerInterfaceWrapper) DecryptWithKey(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "namespaceProvider" -------------
+	var namespaceProvider NamespaceProviderParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceProvider", runtime.ParamLocationPath, ctx.Param("namespaceProvider"), &namespaceProvider)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceProvider: %s", err))
+	}
+
+	// ------------- Path parameter "namespaceId" -------------
+	var namespaceId NamespaceIdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceId", runtime.ParamLocationPath, ctx.Param("namespaceId"), &namespaceId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceId: %s", err))
+	}
+
+	// ------------- Path parameter "id" -------------
+	var id IdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.DecryptWithKey(ctx, namespaceProvider, namespaceId, id)
+	return err
+}
+
+// DecryptDataKey converts echo context to params.
+func (w *ServerInterfaceWrapper) DecryptDataKey(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "namespaceProvider" -------------
+	var namespaceProvider NamespaceProviderParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceProvider", runtime.ParamLocationPath, ctx.Param("namespaceProvider"), &namespaceProvider)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceProvider: %s", err))
+	}
+
+	// ------------- Path parameter "namespaceId" -------------
+	var namespaceId NamespaceIdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceId", runtime.ParamLocationPath, ctx.Param("namespaceId"), &namespaceId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceId: %s", err))
+	}
+
+	// ------------- Path parameter "id" -------------
+	var id IdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.DecryptDataKey(ctx, namespaceProvider, namespaceId, id)
+	return err
+}
+
+// EncryptWithKey converts echo context to params.
+func (w *ServerInterfaceWrapper) EncryptWithKey(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "namespaceProvider" -------------
+	var namespaceProvider NamespaceProviderParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceProvider", runtime.ParamLocationPath, ctx.Param("namespaceProvider"), &namespaceProvider)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceProvider: %s", err))
+	}
+
+	// ------------- Path parameter "namespaceId" -------------
+	var namespaceId NamespaceIdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceId", runtime.ParamLocationPath, ctx.Param("namespaceId"), &namespaceId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceId: %s", err))
+	}
+
+	// ------------- Path parameter "id" -------------
+	var id IdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.EncryptWithKey(ctx, namespaceProvider, namespaceId, id)
+	return err
+}
+
+// GenerateDataKey converts echo context to params.
+func (w *ServerInterfaceWrapper) GenerateDataKey(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "namespaceProvider" -------------
+	var namespaceProvider NamespaceProviderParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceProvider", runtime.ParamLocationPath, ctx.Param("namespaceProvider"), &namespaceProvider)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceProvider: %s", err))
+	}
+
+	// ------------- Path parameter "namespaceId" -------------
+	var namespaceId NamespaceIdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceId", runtime.ParamLocationPath, ctx.Param("namespaceId"), &namespaceId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceId: %s", err))
+	}
+
+	// ------------- Path parameter "id" -------------
+	var id IdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GenerateDataKey(ctx, namespaceProvider, namespaceId, id)
+	return err
+}
+
+// SignWithKey converts echo context to params.
+func (w *ServerInterfaceWrapper) SignWithKey(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "namespaceProvider" -------------
+	var namespaceProvider NamespaceProviderParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceProvider", runtime.ParamLocationPath, ctx.Param("namespaceProvider"), &namespaceProvider)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceProvider: %s", err))
+	}
+
+	// ------------- Path parameter "namespaceId" -------------
+	var namespaceId NamespaceIdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceId", runtime.ParamLocationPath, ctx.Param("namespaceId"), &namespaceId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceId: %s", err))
+	}
+
+	// ------------- Path parameter "id" -------------
+	var id IdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.SignWithKey(ctx, namespaceProvider, namespaceId, id)
+	return err
+}
+
+// UnwrapWithKey converts echo context to params.
+func (w *ServerInterfaceWrapper) UnwrapWithKey(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "namespaceProvider" -------------
+	var namespaceProvider NamespaceProviderParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceProvider", runtime.ParamLocationPath, ctx.Param("namespaceProvider"), &namespaceProvider)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceProvider: %s", err))
+	}
+
+	// ------------- Path parameter "namespaceId" -------------
+	var namespaceId NamespaceIdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceId", runtime.ParamLocationPath, ctx.Param("namespaceId"), &namespaceId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceId: %s", err))
+	}
+
+	// ------------- Path parameter "id" -------------
+	var id IdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.UnwrapWithKey(ctx, namespaceProvider, namespaceId, id)
+	return err
+}
+
+// VerifyWithKey converts echo context to params.
+func (w *ServerInterfaceWrapper) VerifyWithKey(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "namespaceProvider" -------------
+	var namespaceProvider NamespaceProviderParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceProvider", runtime.ParamLocationPath, ctx.Param("namespaceProvider"), &namespaceProvider)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceProvider: %s", err))
+	}
+
+	// ------------- Path parameter "namespaceId" -------------
+	var namespaceId NamespaceIdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceId", runtime.ParamLocationPath, ctx.Param("namespaceId"), &namespaceId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceId: %s", err))
+	}
+
+	// ------------- Path parameter "id" -------------
+	var id IdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.VerifyWithKey(ctx, namespaceProvider, namespaceId, id)
+	return err
+}
+
+// WrapWithKey converts echo context to params.
+func (w *ServerInterfaceWrapper) WrapWithKey(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "namespaceProvider" -------------
+	var namespaceProvider NamespaceProviderParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceProvider", runtime.ParamLocationPath, ctx.Param("namespaceProvider"), &namespaceProvider)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceProvider: %s", err))
+	}
+
+	// ------------- Path parameter "namespaceId" -------------
+	var namespaceId NamespaceIdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceId", runtime.ParamLocationPath, ctx.Param("namespaceId"), &namespaceId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceId: %s", err))
+	}
+
+	// ------------- Path parameter "id" -------------
+	var id IdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.WrapWithKey(ctx, namespaceProvider, namespaceId, id)
+	return err
+}
+
+// GetMemberOf converts echo context to params.
+func (w *ServerInterfaceWrapper) GetMemberOf(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "namespaceProvider" -------------
+	var namespaceProvider NamespaceProviderParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceProvider", runtime.ParamLocationPath, ctx.Param("namespaceProvider"), &namespaceProvider)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceProvider: %s", err))
+	}
+
+	// ------------- Path parameter "namespaceId" -------------
+	var namespaceId NamespaceIdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceId", runtime.ParamLocationPath, ctx.Param("namespaceId"), &namespaceId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceId: %s", err))
+	}
+
+	// ------------- Path parameter "id" -------------
+	var id IdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetMemberOf(ctx, namespaceProvider, namespaceId, id)
+	return err
+}
+
+// SyncMemberOf converts echo context to params.
+func (w *ServerInterfaceWrapper) SyncMemberOf(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "namespaceProvider" -------------
+	var namespaceProvider NamespaceProviderParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceProvider", runtime.ParamLocationPath, ctx.Param("namespaceProvider"), &namespaceProvider)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceProvider: %s", err))
+	}
+
+	// ------------- Path parameter "namespaceId" -------------
+	var namespaceId NamespaceIdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceId", runtime.ParamLocationPath, ctx.Param("namespaceId"), &namespaceId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceId: %s", err))
+	}
+
+	// ------------- Path parameter "id" -------------
+	var id IdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.SyncMemberOf(ctx, namespaceProvider, namespaceId, id)
+	return err
+}
+
+// ListWebhookDeadLetters converts echo context to params.
+func (w *ServerInterfaceWrapper) ListWebhookDeadLetters(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "namespaceProvider" -------------
+	var namespaceProvider NamespaceProviderParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceProvider", runtime.ParamLocationPath, ctx.Param("namespaceProvider"), &namespaceProvider)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceProvider: %s", err))
+	}
+
+	// ------------- Path parameter "namespaceId" -------------
+	var namespaceId NamespaceIdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceId", runtime.ParamLocationPath, ctx.Param("namespaceId"), &namespaceId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceId: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params ListWebhookDeadLettersParams
+	// ------------- Optional query parameter "subscriptionId" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "subscriptionId", ctx.QueryParams(), &params.SubscriptionId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter subscriptionId: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.ListWebhookDeadLetters(ctx, namespaceProvider, namespaceId, params)
+	return err
+}
+
+// ListSSHCAPolicies converts echo context to params.
+func (w *ServerInterfaceWrapper) ListSSHCAPolicies(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "namespaceProvider" -------------
+	var namespaceProvider NamespaceProviderParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceProvider", runtime.ParamLocationPath, ctx.Param("namespaceProvider"), &namespaceProvider)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceProvider: %s", err))
+	}
+
+	// ------------- Path parameter "namespaceId" -------------
+	var namespaceId NamespaceIdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceId", runtime.ParamLocationPath, ctx.Param("namespaceId"), &namespaceId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceId: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.ListSSHCAPolicies(ctx, namespaceProvider, namespaceId)
+	return err
+}
+
+// GetSSHCAPolicy converts echo context to params.
+func (w *ServerInterfaceWrapper) GetSSHCAPolicy(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "namespaceProvider" -------------
+	var namespaceProvider NamespaceProviderParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceProvider", runtime.ParamLocationPath, ctx.Param("namespaceProvider"), &namespaceProvider)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceProvider: %s", err))
+	}
+
+	// ------------- Path parameter "namespaceId" -------------
+	var namespaceId NamespaceIdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceId", runtime.ParamLocationPath, ctx.Param("namespaceId"), &namespaceId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceId: %s", err))
+	}
+
+	// ------------- Path parameter "id" -------------
+	var id IdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetSSHCAPolicy(ctx, namespaceProvider, namespaceId, id)
+	return err
+}
+
+// PutSSHCAPolicy converts echo context to params.
+func (w *ServerInterfaceWrapper) PutSSHCAPolicy(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "namespaceProvider" -------------
+	var namespaceProvider NamespaceProviderParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceProvider", runtime.ParamLocationPath, ctx.Param("namespaceProvider"), &namespaceProvider)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceProvider: %s", err))
+	}
+
+	// ------------- Path parameter "namespaceId" -------------
+	var namespaceId NamespaceIdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceId", runtime.ParamLocationPath, ctx.Param("namespaceId"), &namespaceId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceId: %s", err))
+	}
+
+	// ------------- Path parameter "id" -------------
+	var id IdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.PutSSHCAPolicy(ctx, namespaceProvider, namespaceId, id)
+	return err
+}
+
+// GetSSHCAPolicyKRL converts echo context to params.
+func (w *ServerInterfaceWrapper) GetSSHCAPolicyKRL(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "namespaceProvider" -------------
+	var namespaceProvider NamespaceProviderParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceProvider", runtime.ParamLocationPath, ctx.Param("namespaceProvider"), &namespaceProvider)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceProvider: %s", err))
+	}
+
+	// ------------- Path parameter "namespaceId" -------------
+	var namespaceId NamespaceIdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceId", runtime.ParamLocationPath, ctx.Param("namespaceId"), &namespaceId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceId: %s", err))
+	}
+
+	// ------------- Path parameter "id" -------------
+	var id IdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetSSHCAPolicyKRL(ctx, namespaceProvider, namespaceId, id)
+	return err
+}
+
+// SignSSHCertificate converts echo context to params.
+func (w *ServerInterfaceWrapper) SignSSHCertificate(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "namespaceProvider" -------------
+	var namespaceProvider NamespaceProviderParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceProvider", runtime.ParamLocationPath, ctx.Param("namespaceProvider"), &namespaceProvider)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceProvider: %s", err))
+	}
+
+	// ------------- Path parameter "namespaceId" -------------
+	var namespaceId NamespaceIdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceId", runtime.ParamLocationPath, ctx.Param("namespaceId"), &namespaceId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceId: %s", err))
+	}
+
+	// ------------- Path parameter "id" -------------
+	var id IdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.SignSSHCertificate(ctx, namespaceProvider, namespaceId, id)
+	return err
+}
+
+// GetSSHCertificate converts echo context to params.
+func (w *ServerInterfaceWrapper) GetSSHCertificate(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "namespaceProvider" -------------
+	var namespaceProvider NamespaceProviderParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceProvider", runtime.ParamLocationPath, ctx.Param("namespaceProvider"), &namespaceProvider)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceProvider: %s", err))
+	}
+
+	// ------------- Path parameter "namespaceId" -------------
+	var namespaceId NamespaceIdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceId", runtime.ParamLocationPath, ctx.Param("namespaceId"), &namespaceId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceId: %s", err))
+	}
+
+	// ------------- Path parameter "id" -------------
+	var id IdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetSSHCertificate(ctx, namespaceProvider, namespaceId, id)
+	return err
+}
+
+// RevokeSSHCertificate converts echo context to params.
+func (w *ServerInterfaceWrapper) RevokeSSHCertificate(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "namespaceProvider" -------------
+	var namespaceProvider NamespaceProviderParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceProvider", runtime.ParamLocationPath, ctx.Param("namespaceProvider"), &namespaceProvider)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceProvider: %s", err))
+	}
+
+	// ------------- Path parameter "namespaceId" -------------
+	var namespaceId NamespaceIdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceId", runtime.ParamLocationPath, ctx.Param("namespaceId"), &namespaceId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceId: %s", err))
+	}
+
+	// ------------- Path parameter "id" -------------
+	var id IdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.RevokeSSHCertificate(ctx, namespaceProvider, namespaceId, id)
+	return err
+}
+
+// ListWebhookSubscriptions converts echo context to params.
+func (w *ServerInterfaceWrapper) ListWebhookSubscriptions(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "namespaceProvider" -------------
+	var namespaceProvider NamespaceProviderParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceProvider", runtime.ParamLocationPath, ctx.Param("namespaceProvider"), &namespaceProvider)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceProvider: %s", err))
+	}
+
+	// ------------- Path parameter "namespaceId" -------------
+	var namespaceId NamespaceIdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceId", runtime.ParamLocationPath, ctx.Param("namespaceId"), &namespaceId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceId: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.ListWebhookSubscriptions(ctx, namespaceProvider, namespaceId)
+	return err
+}
+
+// DeleteWebhookSubscription converts echo context to params.
+func (w *ServerInterfaceWrapper) DeleteWebhookSubscription(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "namespaceProvider" -------------
+	var namespaceProvider NamespaceProviderParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceProvider", runtime.ParamLocationPath, ctx.Param("namespaceProvider"), &namespaceProvider)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceProvider: %s", err))
+	}
+
+	// ------------- Path parameter "namespaceId" -------------
+	var namespaceId NamespaceIdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceId", runtime.ParamLocationPath, ctx.Param("namespaceId"), &namespaceId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceId: %s", err))
+	}
+
+	// ------------- Path parameter "id" -------------
+	var id IdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.DeleteWebhookSubscription(ctx, namespaceProvider, namespaceId, id)
+	return err
+}
+
+// GetWebhookSubscription converts echo context to params.
+func (w *ServerInterfaceWrapper) GetWebhookSubscription(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "namespaceProvider" -------------
+	var namespaceProvider NamespaceProviderParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceProvider", runtime.ParamLocationPath, ctx.Param("namespaceProvider"), &namespaceProvider)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceProvider: %s", err))
+	}
+
+	// ------------- Path parameter "namespaceId" -------------
+	var namespaceId NamespaceIdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceId", runtime.ParamLocationPath, ctx.Param("namespaceId"), &namespaceId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceId: %s", err))
+	}
+
+	// ------------- Path parameter "id" -------------
+	var id IdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetWebhookSubscription(ctx, namespaceProvider, namespaceId, id)
+	return err
+}
+
+// PutWebhookSubscription converts echo context to params.
+func (w *ServerInterfaceWrapper) PutWebhookSubscription(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "namespaceProvider" -------------
+	var namespaceProvider NamespaceProviderParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceProvider", runtime.ParamLocationPath, ctx.Param("namespaceProvider"), &namespaceProvider)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceProvider: %s", err))
+	}
+
+	// ------------- Path parameter "namespaceId" -------------
+	var namespaceId NamespaceIdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "namespaceId", runtime.ParamLocationPath, ctx.Param("namespaceId"), &namespaceId)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter namespaceId: %s", err))
+	}
+
+	// ------------- Path parameter "id" -------------
+	var id IdParameter
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "id", runtime.ParamLocationPath, ctx.Param("id"), &id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.PutWebhookSubscription(ctx, namespaceProvider, namespaceId, id)
 	return err
 }
 
@@ -1696,6 +3380,8 @@ func RegisterHandlersWithBaseURL(router EchoRouter, si ServerInterface, baseURL
 	router.POST(baseURL+"/v1/service-principal/:namespaceId/agent-instances/:id/token", wrapper.GetAgentAuthToken)
 	router.POST(baseURL+"/v2/agents", wrapper.CreateAgent)
 	router.GET(baseURL+"/v2/agents/:id", wrapper.GetAgent)
+	router.GET(baseURL+"/v2/audit-records", wrapper.ListAuditRecords)
+	router.GET(baseURL+"/v2/audit-records/verify", wrapper.VerifyAuditRecords)
 	router.GET(baseURL+"/v2/diagnostics", wrapper.GetDiagnostics)
 	router.GET(baseURL+"/v2/external-ca/:namespaceId/certificiate-issuers", wrapper.ListExternalCertificateIssuers)
 	router.GET(baseURL+"/v2/external-ca/:namespaceId/certificiate-issuers/:id", wrapper.GetExternalCertificateIssuer)
@@ -1705,6 +3391,7 @@ func RegisterHandlersWithBaseURL(router EchoRouter, si ServerInterface, baseURL
 	router.GET(baseURL+"/v2/profiles/:namespaceProvider/:namespaceId", wrapper.GetProfile)
 	router.POST(baseURL+"/v2/profiles/:namespaceProvider/:namespaceId", wrapper.SyncProfile)
 	router.PUT(baseURL+"/v2/profiles/:namespaceProvider/:namespaceId", wrapper.PutProfile)
+	router.GET(baseURL+"/v2/service-principal/:namespaceId/acme-http01-challenges/:id", wrapper.GetACMEHTTP01Challenge)
 	router.GET(baseURL+"/v2/service-principal/:namespaceId/agent-config", wrapper.GetAgentConfigBundle)
 	router.GET(baseURL+"/v2/service-principal/:namespaceId/agent-config/:configName", wrapper.GetAgentConfig)
 	router.PUT(baseURL+"/v2/service-principal/:namespaceId/agent-config/:configName", wrapper.PutAgentConfig)
@@ -1720,27 +3407,67 @@ func RegisterHandlersWithBaseURL(router EchoRouter, si ServerInterface, baseURL
 	router.GET(baseURL+"/v2/service-principal/:namespaceId/agent-instances/:id/docker/networks", wrapper.ListAgentDockerNetowks)
 	router.GET(baseURL+"/v2/system-apps/:id", wrapper.GetSystemApp)
 	router.POST(baseURL+"/v2/system-apps/:id", wrapper.SyncSystemApp)
+	router.POST(baseURL+"/v2/:namespaceProvider/:namespaceId/acme-external-account-bindings", wrapper.CreateACMEExternalAccountBinding)
 	router.GET(baseURL+"/v2/:namespaceProvider/:namespaceId/certificate-policies", wrapper.ListCertificatePolicies)
 	router.GET(baseURL+"/v2/:namespaceProvider/:namespaceId/certificate-policies/:id", wrapper.GetCertificatePolicy)
 	router.PUT(baseURL+"/v2/:namespaceProvider/:namespaceId/certificate-policies/:id", wrapper.PutCertificatePolicy)
+	router.POST(baseURL+"/v2/:namespaceProvider/:namespaceId/certificate-policies/:id/acme/accounts/:accountId", wrapper.UpdateACMEAccount)
+	router.POST(baseURL+"/v2/:namespaceProvider/:namespaceId/certificate-policies/:id/acme/authz/:authzId", wrapper.GetACMEAuthorization)
+	router.POST(baseURL+"/v2/:namespaceProvider/:namespaceId/certificate-policies/:id/acme/authz/:authzId/:challengeType", wrapper.RespondACMEChallenge)
+	router.POST(baseURL+"/v2/:namespaceProvider/:namespaceId/certificate-policies/:id/acme/certificates/:certificateId", wrapper.GetACMECertificate)
+	router.GET(baseURL+"/v2/:namespaceProvider/:namespaceId/certificate-policies/:id/acme/directory", wrapper.GetACMEDirectory)
+	router.POST(baseURL+"/v2/:namespaceProvider/:namespaceId/certificate-policies/:id/acme/new-account", wrapper.NewACMEAccount)
+	router.GET(baseURL+"/v2/:namespaceProvider/:namespaceId/certificate-policies/:id/acme/new-nonce", wrapper.GetACMENonce)
+	router.HEAD(baseURL+"/v2/:namespaceProvider/:namespaceId/certificate-policies/:id/acme/new-nonce", wrapper.HeadACMENonce)
+	router.POST(baseURL+"/v2/:namespaceProvider/:namespaceId/certificate-policies/:id/acme/new-order", wrapper.NewACMEOrder)
+	router.POST(baseURL+"/v2/:namespaceProvider/:namespaceId/certificate-policies/:id/acme/orders/:orderId", wrapper.GetACMEOrder)
+	router.POST(baseURL+"/v2/:namespaceProvider/:namespaceId/certificate-policies/:id/acme/orders/:orderId/finalize", wrapper.FinalizeACMEOrder)
+	router.POST(baseURL+"/v2/:namespaceProvider/:namespaceId/certificate-policies/:id/acme/revoke-cert", wrapper.RevokeACMECertificate)
 	router.POST(baseURL+"/v2/:namespaceProvider/:namespaceId/certificate-policies/:id/enroll", wrapper.EnrollCertificate)
 	router.POST(baseURL+"/v2/:namespaceProvider/:namespaceId/certificate-policies/:id/generate", wrapper.GenerateCertificate)
 	router.GET(baseURL+"/v2/:namespaceProvider/:namespaceId/certificate-policies/:id/issuer", wrapper.GetCertificatePolicyIssuer)
 	router.PUT(baseURL+"/v2/:namespaceProvider/:namespaceId/certificate-policies/:id/issuer", wrapper.PutCertificatePolicyIssuer)
+	router.POST(baseURL+"/v2/:namespaceProvider/:namespaceId/certificate-policies/:id/timestamp", wrapper.RespondTimestamp)
 	router.GET(baseURL+"/v2/:namespaceProvider/:namespaceId/certificates", wrapper.ListCertificates)
 	router.DELETE(baseURL+"/v2/:namespaceProvider/:namespaceId/certificates/:id", wrapper.DeleteCertificate)
 	router.GET(baseURL+"/v2/:namespaceProvider/:namespaceId/certificates/:id", wrapper.GetCertificate)
+	router.GET(baseURL+"/v2/:namespaceProvider/:namespaceId/certificates/:id/crl", wrapper.GetCertificateCRL)
 	router.POST(baseURL+"/v2/:namespaceProvider/:namespaceId/certificates/:id/exchange-pkcs12", wrapper.ExchangePKCS12)
 	router.POST(baseURL+"/v2/:namespaceProvider/:namespaceId/certificates/:id/ms-entra-key-credential", wrapper.AddMsEntraKeyCredential)
+	router.POST(baseURL+"/v2/:namespaceProvider/:namespaceId/certificates/:id/ocsp", wrapper.RespondOCSP)
 	router.POST(baseURL+"/v2/:namespaceProvider/:namespaceId/certificates/:id/pending", wrapper.UpdatePendingCertificate)
+	router.POST(baseURL+"/v2/:namespaceProvider/:namespaceId/certificates/:id/revoke", wrapper.RevokeCertificate)
 	router.POST(baseURL+"/v2/:namespaceProvider/:namespaceId/certificates/:id/secret", wrapper.GetCertificateSecret)
+	router.GET(baseURL+"/v2/:namespaceProvider/:namespaceId/.well-known/jwks.json", wrapper.GetNamespaceJwks)
 	router.GET(baseURL+"/v2/:namespaceProvider/:namespaceId/key-policies", wrapper.ListKeyPolicies)
 	router.GET(baseURL+"/v2/:namespaceProvider/:namespaceId/key-policies/:id", wrapper.GetKeyPolicy)
 	router.PUT(baseURL+"/v2/:namespaceProvider/:namespaceId/key-policies/:id", wrapper.PutKeyPolicy)
 	router.POST(baseURL+"/v2/:namespaceProvider/:namespaceId/key-policies/:id/generate", wrapper.GenerateKey)
+	router.POST(baseURL+"/v2/:namespaceProvider/:namespaceId/key-policies/:id/import", wrapper.ImportKey)
+	router.GET(baseURL+"/v2/:namespaceProvider/:namespaceId/key-policies/:id/jwks.json", wrapper.GetKeyPolicyJwks)
 	router.GET(baseURL+"/v2/:namespaceProvider/:namespaceId/keys", wrapper.ListKeys)
 	router.GET(baseURL+"/v2/:namespaceProvider/:namespaceId/keys/:id", wrapper.GetKey)
+	router.POST(baseURL+"/v2/:namespaceProvider/:namespaceId/keys/:id/decrypt", wrapper.DecryptWithKey)
+	router.POST(baseURL+"/v2/:namespaceProvider/:namespaceId/keys/:id/decrypt-data-key", wrapper.DecryptDataKey)
+	router.POST(baseURL+"/v2/:namespaceProvider/:namespaceId/keys/:id/encrypt", wrapper.EncryptWithKey)
+	router.POST(baseURL+"/v2/:namespaceProvider/:namespaceId/keys/:id/generate-data-key", wrapper.GenerateDataKey)
+	router.POST(baseURL+"/v2/:namespaceProvider/:namespaceId/keys/:id/sign", wrapper.SignWithKey)
+	router.POST(baseURL+"/v2/:namespaceProvider/:namespaceId/keys/:id/unwrapKey", wrapper.UnwrapWithKey)
+	router.POST(baseURL+"/v2/:namespaceProvider/:namespaceId/keys/:id/verify", wrapper.VerifyWithKey)
+	router.POST(baseURL+"/v2/:namespaceProvider/:namespaceId/keys/:id/wrapKey", wrapper.WrapWithKey)
 	router.GET(baseURL+"/v2/:namespaceProvider/:namespaceId/memberOf/:id", wrapper.GetMemberOf)
 	router.POST(baseURL+"/v2/:namespaceProvider/:namespaceId/memberOf/:id", wrapper.SyncMemberOf)
+	router.GET(baseURL+"/v2/:namespaceProvider/:namespaceId/webhook-dead-letters", wrapper.ListWebhookDeadLetters)
+	router.GET(baseURL+"/v2/:namespaceProvider/:namespaceId/ssh-ca-policies", wrapper.ListSSHCAPolicies)
+	router.GET(baseURL+"/v2/:namespaceProvider/:namespaceId/ssh-ca-policies/:id", wrapper.GetSSHCAPolicy)
+	router.PUT(baseURL+"/v2/:namespaceProvider/:namespaceId/ssh-ca-policies/:id", wrapper.PutSSHCAPolicy)
+	router.GET(baseURL+"/v2/:namespaceProvider/:namespaceId/ssh-ca-policies/:id/krl", wrapper.GetSSHCAPolicyKRL)
+	router.POST(baseURL+"/v2/:namespaceProvider/:namespaceId/ssh-ca-policies/:id/sign", wrapper.SignSSHCertificate)
+	router.GET(baseURL+"/v2/:namespaceProvider/:namespaceId/ssh-certificates/:id", wrapper.GetSSHCertificate)
+	router.POST(baseURL+"/v2/:namespaceProvider/:namespaceId/ssh-certificates/:id/revoke", wrapper.RevokeSSHCertificate)
+	router.GET(baseURL+"/v2/:namespaceProvider/:namespaceId/webhook-subscriptions", wrapper.ListWebhookSubscriptions)
+	router.DELETE(baseURL+"/v2/:namespaceProvider/:namespaceId/webhook-subscriptions/:id", wrapper.DeleteWebhookSubscription)
+	router.GET(baseURL+"/v2/:namespaceProvider/:namespaceId/webhook-subscriptions/:id", wrapper.GetWebhookSubscription)
+	router.PUT(baseURL+"/v2/:namespaceProvider/:namespaceId/webhook-subscriptions/:id", wrapper.PutWebhookSubscription)
 
 }
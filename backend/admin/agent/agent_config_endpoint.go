@@ -63,11 +63,21 @@ func putAgentConfigEndpoint(c ctx.RequestContext, namespaceId string, param *age
 		AllowedImageRepos:        req.AllowedImageRepos,
 	}
 
+	resp, err := saveAgentConfigEndpointInternal(c, namespaceId, doc)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(resp.RawResponse.StatusCode, doc.ToModel())
+}
+
+// saveAgentConfigEndpointInternal resolves the certificate and the keys of the policies and updates the config bundle
+func saveAgentConfigEndpointInternal(c ctx.RequestContext, namespaceId string, doc *agentConfigDocEndpoint) (resp azcosmos.ItemResponse, err error) {
 	versiond := md5.New()
 
-	certPolicy, err := cert.GetCertificatePolicyInternal(c, models.NamespaceProviderServicePrincipal, namespaceId, req.TlsCertificatePolicyId)
+	certPolicy, err := cert.GetCertificatePolicyInternal(c, models.NamespaceProviderServicePrincipal, namespaceId, doc.TLSCertificatePolicyID)
 	if err != nil {
-		return err
+		return resp, err
 	}
 	versiond.Write([]byte(doc.TLSCertificatePolicyID))
 	versiond.Write(certPolicy.Version)
@@ -75,22 +85,21 @@ func putAgentConfigEndpoint(c ctx.RequestContext, namespaceId string, param *age
 	if !doc.TLSCertificateAutoEnroll {
 		doc.TLSCertificateID, err = certPolicy.GetLatestIssuedCertificateID(c)
 		if err != nil {
-			return err
+			return resp, err
 		}
 		versiond.Write([]byte(doc.TLSCertificateID))
 	}
 
-	keyPolicy, err := key.GetKeyPolicyInternal(c, models.NamespaceProviderServicePrincipal, namespaceId, req.JwtVerifyKeyPolicyId)
+	keyPolicy, err := key.GetKeyPolicyInternal(c, models.NamespaceProviderServicePrincipal, namespaceId, doc.JWTVerifyKeyPolicyID)
 	if err != nil {
-		return err
+		return resp, err
 	}
 	versiond.Write([]byte(doc.JWTVerifyKeyPolicyID))
 	versiond.Write(keyPolicy.Version)
 
-	doc.JWTVerifyKeyIDs, err = key.ListLatestActiveKeysByPolicyInternal(c, models.NamespaceProviderServicePrincipal, namespaceId,
-		keyPolicy.Identifier())
+	doc.JWTVerifyKeyIDs, err = key.ListVerifyKeysByPolicyInternal(c, models.NamespaceProviderServicePrincipal, namespaceId, keyPolicy)
 	if err != nil {
-		return err
+		return resp, err
 	}
 	for _, keyId := range doc.JWTVerifyKeyIDs {
 		versiond.Write([]byte(keyId))
@@ -101,9 +110,9 @@ func putAgentConfigEndpoint(c ctx.RequestContext, namespaceId string, param *age
 
 	doc.Version = versiond.Sum(nil)
 	docSvc := resdoc.GetDocService(c)
-	resp, err := docSvc.Upsert(c, doc, nil)
+	resp, err = docSvc.Upsert(c, doc, nil)
 	if err != nil {
-		return err
+		return resp, err
 	}
 
 	ops := azcosmos.PatchOperations{}
@@ -112,11 +121,26 @@ func putAgentConfigEndpoint(c ctx.RequestContext, namespaceId string, param *age
 		Version: doc.Version,
 	})
 	_, err = docSvc.PatchByIdentifier(c, bundleDocIdentifier(namespaceId), ops, nil)
+	return resp, err
+}
+
+// RefreshAgentConfigEndpointOnKeyRotated updates the JWT verify keys of the agent endpoint config which uses the rotated key policy
+func RefreshAgentConfigEndpointOnKeyRotated(c ctx.RequestContext, policy resdoc.DocIdentifier) error {
+	if policy.NamespaceProvider != models.NamespaceProviderServicePrincipal {
+		return nil
+	}
+	doc, err := getAgentConfigEndpointInternal(c, policy.NamespaceID)
 	if err != nil {
+		if errors.Is(err, base.ErrResponseStatusNotFound) {
+			return nil
+		}
 		return err
 	}
-
-	return c.JSON(resp.RawResponse.StatusCode, doc.ToModel())
+	if doc.JWTVerifyKeyPolicyID != policy.ID {
+		return nil
+	}
+	_, err = saveAgentConfigEndpointInternal(c, policy.NamespaceID, doc)
+	return err
 }
 
 func getAgentConfigEndpoint(c ctx.RequestContext, namespaceId string) error {
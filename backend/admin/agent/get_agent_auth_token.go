@@ -5,7 +5,6 @@ import (
 	agentauth "github.com/stephenzsy/small-kms/backend/agent/auth"
 	"github.com/stephenzsy/small-kms/backend/base"
 	cloudkey "github.com/stephenzsy/small-kms/backend/cloud/key"
-	cloudkeyaz "github.com/stephenzsy/small-kms/backend/cloud/key/az"
 	cloudkeyx "github.com/stephenzsy/small-kms/backend/cloud/key/x"
 	"github.com/stephenzsy/small-kms/backend/internal/auth"
 	"github.com/stephenzsy/small-kms/backend/internal/authz"
@@ -37,7 +36,7 @@ func (*AgentAdminServer) GetAgentAuthToken(ec echo.Context, namespaceId string,
 		return err
 	}
 
-	ck := cloudkeyaz.NewAzCloudSignatureKeyWithKID(c, kv.GetAzKeyVaultService(c).AzKeysClient(), keyDoc.JsonWebKey.KeyID, cloudkey.SignatureAlgorithmES384, false, keyDoc.PublicKey())
+	ck := kv.GetCloudKeyStore(c).NewSignatureKey(c, keyDoc.JsonWebKey.KeyID, cloudkey.SignatureAlgorithmES384, false, keyDoc.PublicKey())
 
 	accessToken, _, err := agentauth.NewSignedAgentAuthJWT(cloudkeyx.NewJWTSigningMethod(cloudkey.SignatureAlgorithmES384), identity.ClientPrincipalID().String(), instance.Endpoint, ck)
 	if err != nil {
@@ -15,7 +15,6 @@ import (
 	"github.com/stephenzsy/small-kms/backend/base"
 	"github.com/stephenzsy/small-kms/backend/cert/v2"
 	cloudkey "github.com/stephenzsy/small-kms/backend/cloud/key"
-	cloudkeyaz "github.com/stephenzsy/small-kms/backend/cloud/key/az"
 	cloudkeyx "github.com/stephenzsy/small-kms/backend/cloud/key/x"
 	"github.com/stephenzsy/small-kms/backend/internal/auth"
 	kv "github.com/stephenzsy/small-kms/backend/internal/keyvault"
@@ -147,7 +146,7 @@ func (p *ProxyClientPool) GetClient(c context.Context, namespaceID string, insta
 		return nil, err
 	}
 
-	ck := cloudkeyaz.NewAzCloudSignatureKeyWithKID(c, kv.GetAzKeyVaultService(c).AzKeysClient(), keyDoc.JsonWebKey.KeyID, cloudkey.SignatureAlgorithmES384, false, keyDoc.PublicKey())
+	ck := kv.GetCloudKeyStore(c).NewSignatureKey(c, keyDoc.JsonWebKey.KeyID, cloudkey.SignatureAlgorithmES384, false, keyDoc.PublicKey())
 	identity := auth.GetAuthIdentity(c)
 	accessToken, exp, err := agentauth.NewSignedAgentAuthJWT(cloudkeyx.NewJWTSigningMethod(cloudkey.SignatureAlgorithmES384), identity.ClientPrincipalID().String(), instanceDoc.Endpoint, ck)
 	if err != nil {
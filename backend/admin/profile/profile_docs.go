@@ -13,6 +13,7 @@ type ProfileDoc struct {
 	ServicePrincipalType *string `json:"servicePrincipalType,omitempty"`
 	AppId                *string `json:"appId,omitempty"`
 	Mail                 *string `json:"mail,omitempty"`
+	DeviceID             *string `json:"deviceId,omitempty"`
 }
 
 type AppDoc struct {
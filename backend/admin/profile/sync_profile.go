@@ -67,7 +67,7 @@ func SyncProfileInternal(c ctx.RequestContext, namespaceId string, gclient *msgr
 	}
 	dirObj, err := gclient.DirectoryObjects().ByDirectoryObjectId(namespaceId).Get(c, &directoryobjects.DirectoryObjectItemRequestBuilderGetRequestConfiguration{
 		QueryParameters: &directoryobjects.DirectoryObjectItemRequestBuilderGetQueryParameters{
-			Select: []string{"id", "displayName", "appId", "servicePrincipalType", "userPrincipalName", "mail"},
+			Select: []string{"id", "displayName", "appId", "servicePrincipalType", "userPrincipalName", "mail", "deviceId"},
 		},
 	})
 	if err != nil {
@@ -98,6 +98,13 @@ func SyncProfileInternal(c ctx.RequestContext, namespaceId string, gclient *msgr
 		doc.UserPrincipalName = usr.GetUserPrincipalName()
 		doc.Mail = usr.GetMail()
 		// ok
+	case "#microsoft.graph.device":
+		// the device ID is assigned by the device at registration, it is not the object ID
+		dev := dirObj.(gmodels.Deviceable)
+		doc.PartitionKey.ResourceProvider = models.ProfileResourceProviderDevice
+		doc.DisplayName = dev.GetDisplayName()
+		doc.ID = *dev.GetId()
+		doc.DeviceID = dev.GetDeviceId()
 	default:
 		return bad(fmt.Errorf("%w: object type is not supported %s not supported", base.ErrResponseStatusBadRequest, *dirObj.GetOdataType()))
 	}
@@ -2,17 +2,22 @@ package adminserver
 
 import (
 	"net/http"
+	"slices"
+	"time"
 
 	echo "github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
 	"github.com/stephenzsy/small-kms/backend/admin"
 	agentadmin "github.com/stephenzsy/small-kms/backend/admin/agent"
 	"github.com/stephenzsy/small-kms/backend/admin/profile"
 	"github.com/stephenzsy/small-kms/backend/admin/systemapp"
 	"github.com/stephenzsy/small-kms/backend/api"
+	"github.com/stephenzsy/small-kms/backend/audit"
 	"github.com/stephenzsy/small-kms/backend/base"
 	"github.com/stephenzsy/small-kms/backend/cert/v2"
 	"github.com/stephenzsy/small-kms/backend/key/v2"
 	"github.com/stephenzsy/small-kms/backend/models"
+	"github.com/stephenzsy/small-kms/backend/webhook"
 )
 
 type server struct {
@@ -23,6 +28,8 @@ type server struct {
 	*key.KeyAdminServer
 	*cert.CertServer
 	*agentadmin.AgentPushProxiedServer
+	*webhook.WebhookServer
+	*audit.AuditServer
 }
 
 // GetMemberGroup implements admin.ServerInterface.
@@ -32,6 +39,28 @@ func (*server) GetMemberOf(ctx echo.Context, namespaceProvider models.NamespaceP
 
 var _ admin.ServerInterface = (*server)(nil)
 
+// AnonymousRoutePaths are routes served without authentication, consumed by relying parties
+var AnonymousRoutePaths = []string{
+	"/v2/:namespaceProvider/:namespaceId/certificates/:id/crl",
+	"/v2/:namespaceProvider/:namespaceId/certificates/:id/ocsp",
+	"/v2/:namespaceProvider/:namespaceId/certificate-policies/:id/timestamp",
+	"/v2/:namespaceProvider/:namespaceId/.well-known/jwks.json",
+	"/v2/:namespaceProvider/:namespaceId/key-policies/:id/jwks.json",
+	"/v2/:namespaceProvider/:namespaceId/ssh-ca-policies/:id/krl",
+	// ACME requests are authenticated by the JWS signature of the account key
+	"/v2/:namespaceProvider/:namespaceId/certificate-policies/:id/acme/directory",
+	"/v2/:namespaceProvider/:namespaceId/certificate-policies/:id/acme/new-nonce",
+	"/v2/:namespaceProvider/:namespaceId/certificate-policies/:id/acme/new-account",
+	"/v2/:namespaceProvider/:namespaceId/certificate-policies/:id/acme/accounts/:accountId",
+	"/v2/:namespaceProvider/:namespaceId/certificate-policies/:id/acme/new-order",
+	"/v2/:namespaceProvider/:namespaceId/certificate-policies/:id/acme/orders/:orderId",
+	"/v2/:namespaceProvider/:namespaceId/certificate-policies/:id/acme/orders/:orderId/finalize",
+	"/v2/:namespaceProvider/:namespaceId/certificate-policies/:id/acme/authz/:authzId",
+	"/v2/:namespaceProvider/:namespaceId/certificate-policies/:id/acme/authz/:authzId/:challengeType",
+	"/v2/:namespaceProvider/:namespaceId/certificate-policies/:id/acme/certificates/:certificateId",
+	"/v2/:namespaceProvider/:namespaceId/certificate-policies/:id/acme/revoke-cert",
+}
+
 func NewServer(apiServer api.APIServer) (*server, error) {
 	if keyAdminServer, err := key.NewServer(apiServer); err != nil {
 		return nil, err
@@ -46,6 +75,37 @@ func NewServer(apiServer api.APIServer) (*server, error) {
 			KeyAdminServer:         keyAdminServer,
 			CertServer:             certServer,
 			AgentPushProxiedServer: agentadmin.NewAgentPushProxiedServer(apiServer),
+			WebhookServer:          webhook.NewServer(apiServer),
+			AuditServer:            audit.NewServer(apiServer),
 		}, nil
 	}
 }
+
+// RateLimitedRoutePaths are anonymous routes which sign with a CA issued key and record every request
+var RateLimitedRoutePaths = []string{
+	"/v2/:namespaceProvider/:namespaceId/certificate-policies/:id/timestamp",
+}
+
+const (
+	rateLimitedRouteRate      = 10 // requests per second
+	rateLimitedRouteBurst     = 20
+	rateLimitedRouteExpiresIn = 3 * time.Minute
+)
+
+// NewRateLimiter limits the requests to RateLimitedRoutePaths of each policy on this replica,
+// the limit is per policy rather than per client as the client address is set by the proxy
+func NewRateLimiter() echo.MiddlewareFunc {
+	return middleware.RateLimiterWithConfig(middleware.RateLimiterConfig{
+		Skipper: func(c echo.Context) bool {
+			return !slices.Contains(RateLimitedRoutePaths, c.Path())
+		},
+		Store: middleware.NewRateLimiterMemoryStoreWithConfig(middleware.RateLimiterMemoryStoreConfig{
+			Rate:      rateLimitedRouteRate,
+			Burst:     rateLimitedRouteBurst,
+			ExpiresIn: rateLimitedRouteExpiresIn,
+		}),
+		IdentifierExtractor: func(c echo.Context) (string, error) {
+			return c.Param("namespaceProvider") + "/" + c.Param("namespaceId") + "/" + c.Param("id"), nil
+		},
+	})
+}
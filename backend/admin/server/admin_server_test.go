@@ -0,0 +1,35 @@
+package adminserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	echo "github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiter(t *testing.T) {
+	e := echo.New()
+	e.Use(NewRateLimiter())
+	ok := func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	}
+	e.POST(RateLimitedRoutePaths[0], ok)
+	e.GET("/v2/:namespaceProvider/:namespaceId/certificates/:id/crl", ok)
+
+	status := func(method, path string) int {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+		return rec.Code
+	}
+	for i := 0; i < rateLimitedRouteBurst; i++ {
+		assert.Equal(t, http.StatusOK, status(http.MethodPost, "/v2/int-ca/default/certificate-policies/a/timestamp"))
+	}
+	assert.Equal(t, http.StatusTooManyRequests, status(http.MethodPost, "/v2/int-ca/default/certificate-policies/a/timestamp"))
+	// the limit is per policy
+	assert.Equal(t, http.StatusOK, status(http.MethodPost, "/v2/int-ca/default/certificate-policies/b/timestamp"))
+	for i := 0; i <= rateLimitedRouteBurst; i++ {
+		assert.Equal(t, http.StatusOK, status(http.MethodGet, "/v2/int-ca/default/certificates/a/crl"))
+	}
+}
@@ -135,6 +135,9 @@ func main() {
 			tm := taskmanager.NewChainedTaskManager().
 				WithTask(taskmanager.IntervalExecutorTask(cm2Poller, 0)).
 				WithTask(echoTask)
+			if acmeHTTP01Address := envSvc.Default(agentcommon.EnvKeyACMEHTTP01Address, "", common.IdentityEnvVarPrefixAgent); acmeHTTP01Address != "" {
+				tm = tm.WithTask(agentconfigmanager.NewACMEHTTP01Task(cm2, acmeHTTP01Address))
+			}
 			logger.Fatal().Err(taskmanager.StartWithGracefulShutdown(c, tm)).Msg("task manager exited")
 			return
 		}
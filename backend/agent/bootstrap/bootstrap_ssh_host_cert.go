@@ -0,0 +1,65 @@
+package bootstrap
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	agentclient "github.com/stephenzsy/small-kms/backend/agent/client/v2"
+	agentcommon "github.com/stephenzsy/small-kms/backend/agent/common"
+	"github.com/stephenzsy/small-kms/backend/common"
+	"github.com/stephenzsy/small-kms/backend/models"
+)
+
+// BootstrapSSHHostCertificates signs the host keys of the machine the agent runs on,
+// host keys that do not exist are skipped
+func (*ServicePrincipalBootstraper) BootstrapSSHHostCertificates(c context.Context,
+	namespaceProvider models.NamespaceProvider, namespaceID string, policyID string, hostKeyPaths []string) error {
+	var client *agentclient.ClientWithResponses
+	envSvc := common.NewEnvService()
+	if baseUrl, ok := envSvc.RequireNonWhitespace(agentcommon.EnvKeyAPIBaseURL, common.IdentityEnvVarPrefixApp); !ok {
+		return envSvc.ErrMissing(agentcommon.EnvKeyAPIBaseURL)
+	} else if clientID, ok := envSvc.RequireNonWhitespace(common.EnvKeyAzClientID, common.IdentityEnvVarPrefixAgent); !ok {
+		return envSvc.ErrMissing(common.EnvKeyAzClientID)
+	} else if tenantID, ok := envSvc.RequireNonWhitespace(common.EnvKeyAzTenantID, common.IdentityEnvVarPrefixAgent); !ok {
+		return envSvc.ErrMissing(common.EnvKeyAzTenantID)
+	} else if certPath, ok := envSvc.RequireAbsPath(common.EnvKeyAzClientCertPath, common.IdentityEnvVarPrefixAgent); !ok {
+		return envSvc.ErrMissing(common.EnvKeyAzClientCertPath)
+	} else if cert, key, err := parseCertificateKeyPair(certPath); err != nil {
+		return err
+	} else if cred, err := azidentity.NewClientCertificateCredential(tenantID, clientID, []*x509.Certificate{cert}, key, nil); err != nil {
+		return err
+	} else if apiAuthScope, ok := envSvc.RequireNonWhitespace(agentcommon.EnvKeyAPIAuthScope, common.IdentityEnvVarPrefixApp); !ok {
+		return envSvc.ErrMissing(agentcommon.EnvKeyAPIAuthScope)
+	} else if client, err = agentclient.NewClientWithResponses(baseUrl,
+		agentclient.WithRequestEditorFn(agentclient.AzTokenCredentialRequestEditorFn(cred, policy.TokenRequestOptions{
+			Scopes: []string{apiAuthScope},
+		}))); err != nil {
+		return err
+	}
+
+	signed := 0
+	for _, hostKeyPath := range hostKeyPaths {
+		if _, err := os.Stat(hostKeyPath); err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				fmt.Printf("host key %s does not exist, skipping\n", hostKeyPath)
+				continue
+			}
+			return err
+		}
+		sshCert, certPath, err := agentcommon.SignSSHHostCertificate(c, client, namespaceProvider, namespaceID, policyID, hostKeyPath)
+		if err != nil {
+			return fmt.Errorf("failed to sign host key %s: %w", hostKeyPath, err)
+		}
+		fmt.Printf("signed host key %s, serial %s, written to %s\n", hostKeyPath, sshCert.Serial, certPath)
+		signed++
+	}
+	if signed == 0 {
+		return errors.New("no host keys to sign")
+	}
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/joho/godotenv"
 	"github.com/stephenzsy/small-kms/backend/agent/bootstrap"
+	"github.com/stephenzsy/small-kms/backend/models"
 	"github.com/urfave/cli/v2"
 )
 
@@ -82,6 +83,42 @@ func main() {
 							return bootstrap.NewServicePrincipalBootstraper().BootstarpActiveServer(c.Context)
 						},
 					},
+					{
+						Name:  "ssh-host-cert",
+						Usage: "sign the SSH host keys of this machine",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "namespace-provider",
+								Usage: "namespace provider of the SSH CA policy",
+								Value: string(models.NamespaceProviderServicePrincipal),
+							},
+							&cli.StringFlag{
+								Name:  "namespace-id",
+								Usage: "namespace identifier of the SSH CA policy",
+								Value: "me",
+							},
+							&cli.StringFlag{
+								Name:     "policy-id",
+								Usage:    "identifier of the SSH CA policy",
+								Required: true,
+							},
+							&cli.StringSliceFlag{
+								Name:  "host-key",
+								Usage: "path to the host public key, missing keys are skipped",
+								Value: cli.NewStringSlice(
+									"/etc/ssh/ssh_host_ed25519_key.pub",
+									"/etc/ssh/ssh_host_ecdsa_key.pub",
+									"/etc/ssh/ssh_host_rsa_key.pub"),
+							},
+						},
+						Action: func(c *cli.Context) error {
+							return bootstrap.NewServicePrincipalBootstraper().BootstrapSSHHostCertificates(c.Context,
+								models.NamespaceProvider(c.String("namespace-provider")),
+								c.String("namespace-id"),
+								c.String("policy-id"),
+								c.StringSlice("host-key"))
+						},
+					},
 				},
 			},
 		},
@@ -68,6 +68,9 @@ type UpdatePendingCertificateJSONRequestBody = externalRef2.UpdatePendingCertifi
 // GetCertificateSecretJSONRequestBody defines body for GetCertificateSecret for application/json ContentType.
 type GetCertificateSecretJSONRequestBody = externalRef2.CertificateSecretRequest
 
+// SignSSHCertificateJSONRequestBody defines body for SignSSHCertificate for application/json ContentType.
+type SignSSHCertificateJSONRequestBody = externalRef2.SignSSHCertificateRequest
+
 // RequestEditorFn  is the function signature for the RequestEditor callback function
 type RequestEditorFn func(ctx context.Context, req *http.Request) error
 
@@ -147,6 +150,9 @@ type ClientInterface interface {
 	// CreateOneTimeKey request
 	CreateOneTimeKey(ctx context.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter, reqEditors ...RequestEditorFn) (*http.Response, error)
 
+	// GetACMEHTTP01Challenge request
+	GetACMEHTTP01Challenge(ctx context.Context, namespaceId NamespaceIdParameter, id IdParameter, reqEditors ...RequestEditorFn) (*http.Response, error)
+
 	// GetAgentConfigBundle request
 	GetAgentConfigBundle(ctx context.Context, namespaceId NamespaceIdParameter, reqEditors ...RequestEditorFn) (*http.Response, error)
 
@@ -181,6 +187,11 @@ type ClientInterface interface {
 
 	// GetKey request
 	GetKey(ctx context.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter, id IdParameter, params *GetKeyParams, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// SignSSHCertificateWithBody request with any body
+	SignSSHCertificateWithBody(ctx context.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter, id IdParameter, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	SignSSHCertificate(ctx context.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter, id IdParameter, body SignSSHCertificateJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error)
 }
 
 func (c *Client) GetAgent(ctx context.Context, id IdParameter, reqEditors ...RequestEditorFn) (*http.Response, error) {
@@ -207,6 +218,18 @@ func (c *Client) CreateOneTimeKey(ctx context.Context, namespaceProvider Namespa
 	return c.Client.Do(req)
 }
 
+func (c *Client) GetACMEHTTP01Challenge(ctx context.Context, namespaceId NamespaceIdParameter, id IdParameter, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewGetACMEHTTP01ChallengeRequest(c.Server, namespaceId, id)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
 func (c *Client) GetAgentConfigBundle(ctx context.Context, namespaceId NamespaceIdParameter, reqEditors ...RequestEditorFn) (*http.Response, error) {
 	req, err := NewGetAgentConfigBundleRequest(c.Server, namespaceId)
 	if err != nil {
@@ -363,6 +386,30 @@ func (c *Client) GetKey(ctx context.Context, namespaceProvider NamespaceProvider
 	return c.Client.Do(req)
 }
 
+func (c *Client) SignSSHCertificateWithBody(ctx context.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter, id IdParameter, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewSignSSHCertificateRequestWithBody(c.Server, namespaceProvider, namespaceId, id, contentType, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) SignSSHCertificate(ctx context.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter, id IdParameter, body SignSSHCertificateJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewSignSSHCertificateRequest(c.Server, namespaceProvider, namespaceId, id, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
 // NewGetAgentRequest generates requests for GetAgent
 func NewGetAgentRequest(server string, id IdParameter) (*http.Request, error) {
 	var err error
@@ -438,6 +485,47 @@ func NewCreateOneTimeKeyRequest(server string, namespaceProvider NamespaceProvid
 	return req, nil
 }
 
+// NewGetACMEHTTP01ChallengeRequest generates requests for GetACMEHTTP01Challenge
+func NewGetACMEHTTP01ChallengeRequest(server string, namespaceId NamespaceIdParameter, id IdParameter) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParamWithLocation("simple", false, "namespaceId", runtime.ParamLocationPath, namespaceId)
+	if err != nil {
+		return nil, err
+	}
+
+	var pathParam1 string
+
+	pathParam1, err = runtime.StyleParamWithLocation("simple", false, "id", runtime.ParamLocationPath, id)
+	if err != nil {
+		return nil, err
+	}
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/v2/service-principal/%s/acme-http01-challenges/%s", pathParam0, pathParam1)
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
 // NewGetAgentConfigBundleRequest generates requests for GetAgentConfigBundle
 func NewGetAgentConfigBundleRequest(server string, namespaceId NamespaceIdParameter) (*http.Request, error) {
 	var err error
@@ -947,6 +1035,67 @@ func NewGetKeyRequest(server string, namespaceProvider NamespaceProviderParamete
 	return req, nil
 }
 
+// NewSignSSHCertificateRequest calls the generic SignSSHCertificate builder with application/json body
+func NewSignSSHCertificateRequest(server string, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter, id IdParameter, body SignSSHCertificateJSONRequestBody) (*http.Request, error) {
+	var bodyReader io.Reader
+	buf, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	bodyReader = bytes.NewReader(buf)
+	return NewSignSSHCertificateRequestWithBody(server, namespaceProvider, namespaceId, id, "application/json", bodyReader)
+}
+
+// NewSignSSHCertificateRequestWithBody generates requests for SignSSHCertificate with any type of body
+func NewSignSSHCertificateRequestWithBody(server string, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter, id IdParameter, contentType string, body io.Reader) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParamWithLocation("simple", false, "namespaceProvider", runtime.ParamLocationPath, namespaceProvider)
+	if err != nil {
+		return nil, err
+	}
+
+	var pathParam1 string
+
+	pathParam1, err = runtime.StyleParamWithLocation("simple", false, "namespaceId", runtime.ParamLocationPath, namespaceId)
+	if err != nil {
+		return nil, err
+	}
+
+	var pathParam2 string
+
+	pathParam2, err = runtime.StyleParamWithLocation("simple", false, "id", runtime.ParamLocationPath, id)
+	if err != nil {
+		return nil, err
+	}
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/v2/%s/%s/ssh-ca-policies/%s/sign", pathParam0, pathParam1, pathParam2)
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", queryURL.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Content-Type", contentType)
+
+	return req, nil
+}
+
 func (c *Client) applyEditors(ctx context.Context, req *http.Request, additionalEditors []RequestEditorFn) error {
 	for _, r := range c.RequestEditors {
 		if err := r(ctx, req); err != nil {
@@ -996,6 +1145,9 @@ type ClientWithResponsesInterface interface {
 	// CreateOneTimeKeyWithResponse request
 	CreateOneTimeKeyWithResponse(ctx context.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter, reqEditors ...RequestEditorFn) (*CreateOneTimeKeyResponse, error)
 
+	// GetACMEHTTP01ChallengeWithResponse request
+	GetACMEHTTP01ChallengeWithResponse(ctx context.Context, namespaceId NamespaceIdParameter, id IdParameter, reqEditors ...RequestEditorFn) (*GetACMEHTTP01ChallengeResponse, error)
+
 	// GetAgentConfigBundleWithResponse request
 	GetAgentConfigBundleWithResponse(ctx context.Context, namespaceId NamespaceIdParameter, reqEditors ...RequestEditorFn) (*GetAgentConfigBundleResponse, error)
 
@@ -1030,6 +1182,11 @@ type ClientWithResponsesInterface interface {
 
 	// GetKeyWithResponse request
 	GetKeyWithResponse(ctx context.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter, id IdParameter, params *GetKeyParams, reqEditors ...RequestEditorFn) (*GetKeyResponse, error)
+
+	// SignSSHCertificateWithBodyWithResponse request with any body
+	SignSSHCertificateWithBodyWithResponse(ctx context.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter, id IdParameter, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*SignSSHCertificateResponse, error)
+
+	SignSSHCertificateWithResponse(ctx context.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter, id IdParameter, body SignSSHCertificateJSONRequestBody, reqEditors ...RequestEditorFn) (*SignSSHCertificateResponse, error)
 }
 
 type GetAgentResponse struct {
@@ -1078,6 +1235,29 @@ func (r CreateOneTimeKeyResponse) StatusCode() int {
 	return 0
 }
 
+type GetACMEHTTP01ChallengeResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *externalRef2.AcmeHttp01ChallengeResponse
+	JSON404      *ErrorResponse
+}
+
+// Status returns HTTPResponse.Status
+func (r GetACMEHTTP01ChallengeResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r GetACMEHTTP01ChallengeResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
 type GetAgentConfigBundleResponse struct {
 	Body         []byte
 	HTTPResponse *http.Response
@@ -1287,6 +1467,31 @@ func (r GetKeyResponse) StatusCode() int {
 	return 0
 }
 
+type SignSSHCertificateResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON201      *externalRef2.SSHCertificateResponse
+	JSON400      *ErrorResponse
+	JSON403      *ErrorResponse
+	JSON404      *ErrorResponse
+}
+
+// Status returns HTTPResponse.Status
+func (r SignSSHCertificateResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r SignSSHCertificateResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
 // GetAgentWithResponse request returning *GetAgentResponse
 func (c *ClientWithResponses) GetAgentWithResponse(ctx context.Context, id IdParameter, reqEditors ...RequestEditorFn) (*GetAgentResponse, error) {
 	rsp, err := c.GetAgent(ctx, id, reqEditors...)
@@ -1305,6 +1510,15 @@ func (c *ClientWithResponses) CreateOneTimeKeyWithResponse(ctx context.Context,
 	return ParseCreateOneTimeKeyResponse(rsp)
 }
 
+// GetACMEHTTP01ChallengeWithResponse request returning *GetACMEHTTP01ChallengeResponse
+func (c *ClientWithResponses) GetACMEHTTP01ChallengeWithResponse(ctx context.Context, namespaceId NamespaceIdParameter, id IdParameter, reqEditors ...RequestEditorFn) (*GetACMEHTTP01ChallengeResponse, error) {
+	rsp, err := c.GetACMEHTTP01Challenge(ctx, namespaceId, id, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseGetACMEHTTP01ChallengeResponse(rsp)
+}
+
 // GetAgentConfigBundleWithResponse request returning *GetAgentConfigBundleResponse
 func (c *ClientWithResponses) GetAgentConfigBundleWithResponse(ctx context.Context, namespaceId NamespaceIdParameter, reqEditors ...RequestEditorFn) (*GetAgentConfigBundleResponse, error) {
 	rsp, err := c.GetAgentConfigBundle(ctx, namespaceId, reqEditors...)
@@ -1418,6 +1632,23 @@ func (c *ClientWithResponses) GetKeyWithResponse(ctx context.Context, namespaceP
 	return ParseGetKeyResponse(rsp)
 }
 
+// SignSSHCertificateWithBodyWithResponse request with arbitrary body returning *SignSSHCertificateResponse
+func (c *ClientWithResponses) SignSSHCertificateWithBodyWithResponse(ctx context.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter, id IdParameter, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*SignSSHCertificateResponse, error) {
+	rsp, err := c.SignSSHCertificateWithBody(ctx, namespaceProvider, namespaceId, id, contentType, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseSignSSHCertificateResponse(rsp)
+}
+
+func (c *ClientWithResponses) SignSSHCertificateWithResponse(ctx context.Context, namespaceProvider NamespaceProviderParameter, namespaceId NamespaceIdParameter, id IdParameter, body SignSSHCertificateJSONRequestBody, reqEditors ...RequestEditorFn) (*SignSSHCertificateResponse, error) {
+	rsp, err := c.SignSSHCertificate(ctx, namespaceProvider, namespaceId, id, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseSignSSHCertificateResponse(rsp)
+}
+
 // ParseGetAgentResponse parses an HTTP response from a GetAgentWithResponse call
 func ParseGetAgentResponse(rsp *http.Response) (*GetAgentResponse, error) {
 	bodyBytes, err := io.ReadAll(rsp.Body)
@@ -1484,6 +1715,39 @@ func ParseCreateOneTimeKeyResponse(rsp *http.Response) (*CreateOneTimeKeyRespons
 	return response, nil
 }
 
+// ParseGetACMEHTTP01ChallengeResponse parses an HTTP response from a GetACMEHTTP01ChallengeWithResponse call
+func ParseGetACMEHTTP01ChallengeResponse(rsp *http.Response) (*GetACMEHTTP01ChallengeResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &GetACMEHTTP01ChallengeResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest externalRef2.AcmeHttp01ChallengeResponse
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 404:
+		var dest ErrorResponse
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON404 = &dest
+
+	}
+
+	return response, nil
+}
+
 // ParseGetAgentConfigBundleResponse parses an HTTP response from a GetAgentConfigBundleWithResponse call
 func ParseGetAgentConfigBundleResponse(rsp *http.Response) (*GetAgentConfigBundleResponse, error) {
 	bodyBytes, err := io.ReadAll(rsp.Body)
@@ -1794,3 +2058,50 @@ func ParseGetKeyResponse(rsp *http.Response) (*GetKeyResponse, error) {
 
 	return response, nil
 }
+
+// ParseSignSSHCertificateResponse parses an HTTP response from a SignSSHCertificateWithResponse call
+func ParseSignSSHCertificateResponse(rsp *http.Response) (*SignSSHCertificateResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &SignSSHCertificateResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 201:
+		var dest externalRef2.SSHCertificateResponse
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON201 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 400:
+		var dest ErrorResponse
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON400 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 403:
+		var dest ErrorResponse
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON403 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 404:
+		var dest ErrorResponse
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON404 = &dest
+
+	}
+
+	return response, nil
+}
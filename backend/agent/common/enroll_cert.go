@@ -3,7 +3,9 @@ package agentcommon
 import (
 	"context"
 	"crypto"
+	"crypto/ed25519"
 	"crypto/elliptic"
+	"crypto/rand"
 	"crypto/x509"
 	"encoding/pem"
 	"fmt"
@@ -56,6 +58,10 @@ func EnrollCertificate(c context.Context,
 		}
 	case cloudkey.KeyTypeRSA:
 		privateKey, err = cryptoStore.GenerateRSAKeyPair(*policyResp.JSON200.KeySpec.KeySize)
+	case cloudkey.KeyTypeOKP:
+		if policyResp.JSON200.KeySpec.Crv == cloudkey.CurveNameEd25519 {
+			_, privateKey, err = ed25519.GenerateKey(rand.Reader)
+		}
 	}
 	if err != nil {
 		return bad(err)
@@ -67,6 +73,11 @@ func EnrollCertificate(c context.Context,
 	if err != nil {
 		return bad(err)
 	}
+	// the signed request proves possession of the private key
+	csrBytes, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{}, privateKey)
+	if err != nil {
+		return bad(err)
+	}
 
 	resp, err := client.EnrollCertificateWithResponse(c, models.NamespaceProviderServicePrincipal,
 		"me",
@@ -75,6 +86,7 @@ func EnrollCertificate(c context.Context,
 			OnBehalfOfApplication: &onBehalfOf,
 		},
 		certmodels.EnrollCertificateRequest{
+			CSR:       string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csrBytes})),
 			PublicKey: *publicJwk,
 		})
 	if err != nil {
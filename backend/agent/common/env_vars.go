@@ -6,4 +6,5 @@ const (
 	EnvKeyAPIBaseURL         = "API_BASE_URL"
 	EnvKeyAPIAuthScope       = "API_AUTH_SCOPE"
 	EnvKeyAcrImageRepository = "AZURE_ACR_IMAGE_REPOSITORY"
+	EnvKeyACMEHTTP01Address  = "ACME_HTTP01_LISTEN_ADDRESS"
 )
@@ -0,0 +1,50 @@
+package agentcommon
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	agentclient "github.com/stephenzsy/small-kms/backend/agent/client/v2"
+	"github.com/stephenzsy/small-kms/backend/models"
+	certmodels "github.com/stephenzsy/small-kms/backend/models/cert"
+)
+
+// SignSSHHostCertificate signs the host public key at pubKeyPath and writes the
+// certificate next to it, e.g. ssh_host_ecdsa_key-cert.pub.
+// No principals are requested, the server resolves them from the policy templates of the agent identity
+func SignSSHHostCertificate(c context.Context,
+	client agentclient.ClientWithResponsesInterface,
+	namespaceProvider models.NamespaceProvider,
+	namespaceID string,
+	policyID string,
+	pubKeyPath string) (*certmodels.SSHCertificate, string, error) {
+	logger := log.Ctx(c)
+
+	pubKey, err := os.ReadFile(pubKeyPath)
+	if err != nil {
+		return nil, "", err
+	}
+
+	resp, err := client.SignSSHCertificateWithResponse(c, namespaceProvider, namespaceID, policyID,
+		certmodels.SignSSHCertificateRequest{
+			PublicKey: strings.TrimSpace(string(pubKey)),
+		})
+	if err != nil {
+		return nil, "", err
+	} else if resp.StatusCode() != http.StatusCreated {
+		if resp.StatusCode() == http.StatusBadRequest {
+			logger.Error().Any("response", resp.JSON400).Send()
+		}
+		return nil, "", fmt.Errorf("unexpected status code: %d", resp.StatusCode())
+	}
+
+	certPath := strings.TrimSuffix(pubKeyPath, ".pub") + "-cert.pub"
+	if err := os.WriteFile(certPath, []byte(resp.JSON201.Certificate+"\n"), 0644); err != nil {
+		return nil, "", err
+	}
+	return resp.JSON201, certPath, nil
+}
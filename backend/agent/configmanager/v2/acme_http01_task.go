@@ -0,0 +1,101 @@
+package agentconfigmanager
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/stephenzsy/small-kms/backend/taskmanager"
+)
+
+const acmeHTTP01ChallengePathPrefix = "/.well-known/acme-challenge/"
+
+// tokens are base64url encoded, RFC 8555 section 8.1
+var acmeChallengeTokenRegex = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// acmeHTTP01Handler serves the key authorizations of the http-01 challenges presented to the service principal
+type acmeHTTP01Handler struct {
+	cm ConfigManager
+}
+
+func (h *acmeHTTP01Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	token, ok := strings.CutPrefix(r.URL.Path, acmeHTTP01ChallengePathPrefix)
+	if r.Method != http.MethodGet || !ok || !acmeChallengeTokenRegex.MatchString(token) {
+		http.NotFound(w, r)
+		return
+	}
+	resp, err := h.cm.Client().GetACMEHTTP01ChallengeWithResponse(r.Context(), "me", token)
+	if err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("failed to get ACME http-01 challenge")
+		http.Error(w, http.StatusText(http.StatusBadGateway), http.StatusBadGateway)
+		return
+	}
+	if resp.JSON200 == nil {
+		http.NotFound(w, r)
+		return
+	}
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if !strings.EqualFold(host, resp.JSON200.Domain) {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain")
+	w.Write([]byte(resp.JSON200.KeyAuthorization))
+}
+
+type acmeHTTP01Task struct {
+	server *http.Server
+}
+
+// Name implements taskmanager.Task.
+func (*acmeHTTP01Task) Name() string {
+	return "ACMEHTTP01"
+}
+
+// Start implements taskmanager.Task.
+func (t *acmeHTTP01Task) Start(c context.Context, sigCh <-chan os.Signal) error {
+	logger := log.Ctx(c).With().Str("task", t.Name()).Logger()
+	t.server.BaseContext = func(net.Listener) context.Context {
+		return logger.WithContext(c)
+	}
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- t.server.ListenAndServe()
+	}()
+	logger.Info().Str("addr", t.server.Addr).Msg("ACME http-01 responder started")
+
+	select {
+	case <-c.Done():
+		return c.Err()
+	case err := <-errCh:
+		return err
+	case <-sigCh:
+		err := t.server.Shutdown(c)
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	}
+}
+
+var _ taskmanager.Task = (*acmeHTTP01Task)(nil)
+
+// NewACMEHTTP01Task serves http-01 challenges of external ACME issuers on the address, usually :80
+func NewACMEHTTP01Task(cm ConfigManager, addr string) *acmeHTTP01Task {
+	return &acmeHTTP01Task{
+		server: &http.Server{
+			Addr:              addr,
+			Handler:           &acmeHTTP01Handler{cm: cm},
+			ReadHeaderTimeout: 10 * time.Second,
+		},
+	}
+}
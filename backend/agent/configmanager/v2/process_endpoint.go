@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto"
 	"crypto/ecdsa"
+	"crypto/mlkem"
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
@@ -91,10 +92,15 @@ func (p *endpointProcessor) processEndpoint(c context.Context, ref *agentmodels.
 		if err != nil {
 			return err
 		}
+		kemJwk, err := cloudkey.NewEphemeralMLKEM768Jwk()
+		if err != nil {
+			return err
+		}
 		resp, err := p.cm.Client().GetCertificateSecretWithResponse(c,
 			models.NamespaceProviderServicePrincipal,
 			"me", endpointConfig.TLSCertificateID, certmodels.CertificateSecretRequest{
-				Jwk: *jwk,
+				Jwk:  *jwk,
+				Jwks: []cloudkey.JsonWebKey{*kemJwk.PublicJWK()},
 			})
 		if err != nil {
 			return err
@@ -105,8 +111,15 @@ func (p *endpointProcessor) processEndpoint(c context.Context, ref *agentmodels.
 		if err != nil {
 			return err
 		}
-		if pemBytes, _, err := jwe.Decrypt(func(*cloudkey.JoseHeader) (crypto.PrivateKey, error) {
-			return jwk.PrivateKey().(*ecdsa.PrivateKey).ECDH()
+		if pemBytes, _, err := jwe.Decrypt(func(header *cloudkey.JoseHeader) (crypto.PrivateKey, error) {
+			ecdhKey, err := jwk.PrivateKey().(*ecdsa.PrivateKey).ECDH()
+			if err != nil || header.Algorithm != cloudkey.JwkEncAlgEcdhEsMlKem768 {
+				return ecdhKey, err
+			}
+			return &cloudkey.HybridKeyAgreementPrivateKey{
+				ECDH: ecdhKey,
+				KEM:  kemJwk.PrivateKey().(*mlkem.DecapsulationKey768),
+			}, nil
 		}); err != nil {
 			return err
 		} else {
@@ -2,8 +2,11 @@ package api
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
@@ -15,7 +18,10 @@ import (
 	msgraphsdkgo "github.com/microsoftgraph/msgraph-sdk-go"
 	"github.com/rs/zerolog/log"
 	"github.com/stephenzsy/small-kms/backend/base"
+	cloudkey "github.com/stephenzsy/small-kms/backend/cloud/key"
 	cloudkeyaz "github.com/stephenzsy/small-kms/backend/cloud/key/az"
+	cloudkeylocal "github.com/stephenzsy/small-kms/backend/cloud/key/local"
+	cloudkeypkcs11 "github.com/stephenzsy/small-kms/backend/cloud/key/pkcs11"
 	"github.com/stephenzsy/small-kms/backend/common"
 	"github.com/stephenzsy/small-kms/backend/internal/auth"
 	ctx "github.com/stephenzsy/small-kms/backend/internal/context"
@@ -45,11 +51,13 @@ type apiServer struct {
 	azCertificatesClient    *azcertificates.Client
 	azKeysClient            *azkeys.Client
 	azSecretsClient         *azsecrets.Client
+	cloudKeyStore           cloudkey.KeyStore
 	appConfidentialIdentity auth.AzureAppConfidentialIdentity
 	buildID                 string
 	azSubscriptionID        string
 	resourceGroupName       string
 	extractedKeyVaultName   string
+	publicBaseURL           string
 
 	azCosmosEndpoint        string
 	azCosmosClient          *azcosmos.Client
@@ -117,6 +125,8 @@ func (s *apiServer) Value(key any) any {
 		return s.docServiceNew
 	case kv.AzKeyVaultServiceContextKey:
 		return s
+	case kv.CloudKeyStoreContextKey:
+		return s.cloudKeyStore
 	case graph.ServiceClientIDContextKey:
 		return s.ServiceIdentity().ClientID()
 	case graph.ServiceMsGraphClientContextKey:
@@ -125,6 +135,8 @@ func (s *apiServer) Value(key any) any {
 		return s.appConfidentialIdentity.ClientID()
 	case auth.AppConfidentialIdentityContextKey:
 		return s.appConfidentialIdentity
+	case publicBaseURLContextKey:
+		return s.publicBaseURL
 	}
 	return s.parentCtx.Value(key)
 }
@@ -146,46 +158,109 @@ func NewApiServer(c context.Context, buildID string) (*apiServer, error) {
 		return nil, err
 	}
 
-	// cosmos
-	if cosmosConnStr := s.EnvService().Default(envKeyAzCosmosConnectionString, "", common.IdentityEnvVarPrefixService); cosmosConnStr != "" {
-		s.azCosmosClient, err = azcosmos.NewClientFromConnectionString(cosmosConnStr, nil)
-		if err != nil {
+	// document store
+	var innerDocService resdoc.DocService
+	switch docServiceBackend := s.EnvService().Default(envKeyDocServiceBackend, docServiceBackendCosmos, common.IdentityEnvVarPrefixService); docServiceBackend {
+	case docServiceBackendEmbedded:
+		// the legacy v1 doc service is only available with cosmos, v1 routes respond 501 without it
+		dbFile := s.EnvService().Default(envKeyEmbeddedDBFile, "kms.db", common.IdentityEnvVarPrefixService)
+		if innerDocService, err = resdoc.NewEmbeddedDocService(dbFile); err != nil {
 			return nil, err
 		}
-	} else if s.azCosmosEndpoint, ok = s.EnvService().RequireNonWhitespace(envKeyAzCosmosResourceEndpoint, common.IdentityEnvVarPrefixService); !ok {
-		return nil, s.EnvService().ErrMissing(envKeyAzCosmosResourceEndpoint)
-	} else if s.azCosmosClient, err = azcosmos.NewClient(s.azCosmosEndpoint, s.ServiceIdentity().TokenCredential(), nil); err != nil {
-		return nil, err
+	case docServiceBackendCosmos:
+		if cosmosConnStr := s.EnvService().Default(envKeyAzCosmosConnectionString, "", common.IdentityEnvVarPrefixService); cosmosConnStr != "" {
+			s.azCosmosClient, err = azcosmos.NewClientFromConnectionString(cosmosConnStr, nil)
+			if err != nil {
+				return nil, err
+			}
+		} else if s.azCosmosEndpoint, ok = s.EnvService().RequireNonWhitespace(envKeyAzCosmosResourceEndpoint, common.IdentityEnvVarPrefixService); !ok {
+			return nil, s.EnvService().ErrMissing(envKeyAzCosmosResourceEndpoint)
+		} else if s.azCosmosClient, err = azcosmos.NewClient(s.azCosmosEndpoint, s.ServiceIdentity().TokenCredential(), nil); err != nil {
+			return nil, err
+		}
+
+		s.azCosmosDatabaseID = s.EnvService().Default(envKeyAzCosmosDatabaseID, "kms", common.IdentityEnvVarPrefixService)
+		if s.azCosmosDatabaseClient, err = s.azCosmosClient.NewDatabase(s.azCosmosDatabaseID); err != nil {
+			return nil, err
+		}
+		s.azCosmosContainerID = s.EnvService().Default(envKeyAzCosmosContainerName, "Certs", common.IdentityEnvVarPrefixService)
+		if s.azCosmosContainerClient, err = s.azCosmosDatabaseClient.NewContainer(s.azCosmosContainerID); err != nil {
+			return nil, err
+		}
+		s.docService = base.NewAzCosmosCRUDDocService(s.azCosmosContainerClient)
+		innerDocService = resdoc.NewAzCosmosSingleContainerDocService(s.azCosmosContainerClient)
+	default:
+		return nil, fmt.Errorf("unsupported %s: %s", envKeyDocServiceBackend, docServiceBackend)
 	}
+	s.docServiceNew = resdoc.NewAuditedDocService(innerDocService)
 
-	s.azCosmosDatabaseID = s.EnvService().Default(envKeyAzCosmosDatabaseID, "kms", common.IdentityEnvVarPrefixService)
-	if s.azCosmosDatabaseClient, err = s.azCosmosClient.NewDatabase(s.azCosmosDatabaseID); err != nil {
-		return nil, err
+	// key stores, keys are created in the default store unless the key policy selects another configured store
+	keyStoreBackend := cloudkey.KeyStoreKind(s.EnvService().Default(envKeyKeyStoreBackend, string(cloudkey.KeyStoreKindAzKeyVault), common.IdentityEnvVarPrefixService))
+	if !keyStoreBackend.IsSupported() {
+		return s, fmt.Errorf("unsupported %s: %s", envKeyKeyStoreBackend, keyStoreBackend)
 	}
-	s.azCosmosContainerID = s.EnvService().Default(envKeyAzCosmosContainerName, "Certs", common.IdentityEnvVarPrefixService)
-	if s.azCosmosContainerClient, err = s.azCosmosDatabaseClient.NewContainer(s.azCosmosContainerID); err != nil {
-		return nil, err
+	var keyStores []cloudkey.KeyStore
+	if masterKeyStr, ok := s.EnvService().RequireNonWhitespace(envKeyLocalKeyStoreMasterKey, common.IdentityEnvVarPrefixService); ok {
+		masterKey, err := base64.StdEncoding.DecodeString(masterKeyStr)
+		if err != nil {
+			return s, fmt.Errorf("invalid %s: %w", envKeyLocalKeyStoreMasterKey, err)
+		}
+		keyStoreDir := s.EnvService().Default(envKeyLocalKeyStoreDir, "keys", common.IdentityEnvVarPrefixService)
+		localKeyStore, err := cloudkeylocal.NewKeyStore(keyStoreDir, masterKey)
+		if err != nil {
+			return s, err
+		}
+		keyStores = append(keyStores, localKeyStore)
+	} else if keyStoreBackend == cloudkey.KeyStoreKindLocal {
+		return s, s.EnvService().ErrMissing(envKeyLocalKeyStoreMasterKey)
+	}
+	if modulePath, ok := s.EnvService().RequireNonWhitespace(envKeyPKCS11ModulePath, common.IdentityEnvVarPrefixService); ok {
+		tokenLabel, ok := s.EnvService().RequireNonWhitespace(envKeyPKCS11TokenLabel, common.IdentityEnvVarPrefixService)
+		if !ok {
+			return s, s.EnvService().ErrMissing(envKeyPKCS11TokenLabel)
+		}
+		pin, ok := s.EnvService().RequireNonWhitespace(envKeyPKCS11Pin, common.IdentityEnvVarPrefixService)
+		if !ok {
+			return s, s.EnvService().ErrMissing(envKeyPKCS11Pin)
+		}
+		pkcs11KeyStore, err := cloudkeypkcs11.NewKeyStore(modulePath, tokenLabel, pin)
+		if err != nil {
+			return s, err
+		}
+		keyStores = append(keyStores, pkcs11KeyStore)
+	} else if keyStoreBackend == cloudkey.KeyStoreKindPKCS11 {
+		return s, s.EnvService().ErrMissing(envKeyPKCS11ModulePath)
 	}
-	s.docService = base.NewAzCosmosCRUDDocService(s.azCosmosContainerClient)
-	s.docServiceNew = resdoc.NewAzCosmosSingleContainerDocService(s.azCosmosContainerClient)
 
-	// keyvault
+	// keyvault, only secrets are required with another default key store
 	if s.azKeyVaultEndpoint, ok = s.EnvService().RequireNonWhitespace(common.EnvKeyAzKeyvaultResourceEndpoint, common.IdentityEnvVarPrefixService); !ok {
-		return s, s.EnvService().ErrMissing(common.EnvKeyAzKeyvaultResourceEndpoint)
-	}
-	s.extractedKeyVaultName = cloudkeyaz.ExtractKeyVaultName(s.azKeyVaultEndpoint)
-	if s.azKeysClient, err = azkeys.NewClient(s.azKeyVaultEndpoint, s.ServiceIdentity().TokenCredential(), nil); err != nil {
-		return s, err
+		if keyStoreBackend == cloudkey.KeyStoreKindAzKeyVault {
+			return s, s.EnvService().ErrMissing(common.EnvKeyAzKeyvaultResourceEndpoint)
+		}
+	} else {
+		s.extractedKeyVaultName = cloudkeyaz.ExtractKeyVaultName(s.azKeyVaultEndpoint)
+		if s.azKeysClient, err = azkeys.NewClient(s.azKeyVaultEndpoint, s.ServiceIdentity().TokenCredential(), nil); err != nil {
+			return s, err
+		}
+		if s.azCertificatesClient, err = azcertificates.NewClient(s.azKeyVaultEndpoint, s.ServiceIdentity().TokenCredential(), nil); err != nil {
+			return s, err
+		}
+		if s.azSecretsClient, err = azsecrets.NewClient(s.azKeyVaultEndpoint, s.ServiceIdentity().TokenCredential(), nil); err != nil {
+			return s, err
+		}
 	}
-	if s.azCertificatesClient, err = azcertificates.NewClient(s.azKeyVaultEndpoint, s.ServiceIdentity().TokenCredential(), nil); err != nil {
-		return s, err
+	if s.azKeysClient != nil {
+		keyStores = append(keyStores, cloudkeyaz.NewKeyStore(s.azKeysClient, s.azSecretsClient))
 	}
-	if s.azSecretsClient, err = azsecrets.NewClient(s.azKeyVaultEndpoint, s.ServiceIdentity().TokenCredential(), nil); err != nil {
-		return s, err
+	for _, keyStore := range keyStores {
+		if keyStore.Kind() == keyStoreBackend {
+			s.cloudKeyStore = cloudkey.NewKeyStoreSet(keyStore, keyStores...)
+		}
 	}
 
 	s.azSubscriptionID = s.EnvService().Default(common.EnvKeyAzSubscriptionID, "", common.IdentityEnvVarPrefixService)
 	s.resourceGroupName = s.EnvService().Default(common.EnvKeyAzResourceGroupName, "", common.IdentityEnvVarPrefixService)
+	s.publicBaseURL = strings.TrimSuffix(s.EnvService().Default(envKeyPublicBaseURL, "", common.IdentityEnvVarPrefixService), "/")
 
 	s.serviceMsGraphClient, err = msgraphsdkgo.NewGraphServiceClientWithCredentials(s.appConfidentialIdentity.TokenCredential(), nil)
 	if err != nil {
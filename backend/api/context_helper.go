@@ -13,6 +13,7 @@ type contextKey int
 
 const (
 	delegatedARMAuthRoleAssignmentsClient contextKey = iota
+	publicBaseURLContextKey
 )
 
 func (s *apiServer) WithDelegatedARMAuthRoleAssignmentsClient(c ctx.RequestContext) (ctx.RequestContext, *armauthorization.RoleAssignmentsClient, error) {
@@ -24,3 +25,12 @@ func (s *apiServer) WithDelegatedARMAuthRoleAssignmentsClient(c ctx.RequestConte
 func GetDelegatedARMAuthRoleAssignmentsClient(c context.Context) *armauthorization.RoleAssignmentsClient {
 	return auth.GetDelegateClient[armauthorization.RoleAssignmentsClient, contextKey](c, delegatedARMAuthRoleAssignmentsClient)
 }
+
+// GetPublicBaseURL returns the base URL where unauthenticated endpoints are reachable by relying parties,
+// empty if not configured
+func GetPublicBaseURL(c context.Context) string {
+	if v, ok := c.Value(publicBaseURLContextKey).(string); ok {
+		return v
+	}
+	return ""
+}
@@ -5,4 +5,18 @@ const (
 	envKeyAzCosmosResourceEndpoint = "AZURE_COSMOS_RESOURCEENDPOINT"
 	envKeyAzCosmosDatabaseID       = "AZURE_COSMOS_DATABASE_ID"
 	envKeyAzCosmosContainerName    = "AZURE_COSMOS_CONTAINERNAME_CERTS"
+	envKeyPublicBaseURL            = "PUBLIC_BASE_URL"
+	envKeyDocServiceBackend        = "DOC_SERVICE_BACKEND"
+	envKeyEmbeddedDBFile           = "EMBEDDED_DB_FILE"
+	envKeyKeyStoreBackend          = "KEY_STORE_BACKEND"
+	envKeyLocalKeyStoreDir         = "LOCAL_KEY_STORE_DIR"
+	envKeyLocalKeyStoreMasterKey   = "LOCAL_KEY_STORE_MASTER_KEY"
+	envKeyPKCS11ModulePath         = "PKCS11_MODULE_PATH"
+	envKeyPKCS11TokenLabel         = "PKCS11_TOKEN_LABEL"
+	envKeyPKCS11Pin                = "PKCS11_PIN"
+)
+
+const (
+	docServiceBackendCosmos   = "cosmos"
+	docServiceBackendEmbedded = "embedded"
 )
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stephenzsy/small-kms/backend/base"
+)
+
+// LegacyRouter registers v1 routes which read and write through the cosmos CRUD doc service,
+// when the doc service backend does not provide it the routes respond 501 Not Implemented
+type LegacyRouter struct {
+	*echo.Echo
+	disabled       bool
+	disabledRoutes []*echo.Route
+}
+
+func NewLegacyRouter(e *echo.Echo, c context.Context) *LegacyRouter {
+	return &LegacyRouter{
+		Echo:     e,
+		disabled: base.GetAzCosmosCRUDService(c) == nil,
+	}
+}
+
+// DisabledRoutes returns the routes registered while the legacy doc service is unavailable
+func (r *LegacyRouter) DisabledRoutes() []*echo.Route {
+	return r.disabledRoutes
+}
+
+func respondLegacyNotImplemented(echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		return c.NoContent(http.StatusNotImplemented)
+	}
+}
+
+func (r *LegacyRouter) add(method, path string, h echo.HandlerFunc, m []echo.MiddlewareFunc) *echo.Route {
+	if !r.disabled {
+		return r.Echo.Add(method, path, h, m...)
+	}
+	route := r.Echo.Add(method, path, h, append(m, respondLegacyNotImplemented)...)
+	r.disabledRoutes = append(r.disabledRoutes, route)
+	return route
+}
+
+func (r *LegacyRouter) CONNECT(path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc) *echo.Route {
+	return r.add(http.MethodConnect, path, h, m)
+}
+
+func (r *LegacyRouter) DELETE(path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc) *echo.Route {
+	return r.add(http.MethodDelete, path, h, m)
+}
+
+func (r *LegacyRouter) GET(path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc) *echo.Route {
+	return r.add(http.MethodGet, path, h, m)
+}
+
+func (r *LegacyRouter) HEAD(path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc) *echo.Route {
+	return r.add(http.MethodHead, path, h, m)
+}
+
+func (r *LegacyRouter) OPTIONS(path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc) *echo.Route {
+	return r.add(http.MethodOptions, path, h, m)
+}
+
+func (r *LegacyRouter) PATCH(path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc) *echo.Route {
+	return r.add(http.MethodPatch, path, h, m)
+}
+
+func (r *LegacyRouter) POST(path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc) *echo.Route {
+	return r.add(http.MethodPost, path, h, m)
+}
+
+func (r *LegacyRouter) PUT(path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc) *echo.Route {
+	return r.add(http.MethodPut, path, h, m)
+}
+
+func (r *LegacyRouter) TRACE(path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc) *echo.Route {
+	return r.add(http.MethodTrace, path, h, m)
+}
@@ -0,0 +1,15 @@
+package audit
+
+import (
+	"github.com/stephenzsy/small-kms/backend/api"
+)
+
+type AuditServer struct {
+	api.APIServer
+}
+
+func NewServer(apiServer api.APIServer) *AuditServer {
+	return &AuditServer{
+		APIServer: apiServer,
+	}
+}
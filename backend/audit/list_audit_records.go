@@ -0,0 +1,67 @@
+package audit
+
+import (
+	"fmt"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
+	"github.com/labstack/echo/v4"
+	"github.com/stephenzsy/small-kms/backend/admin"
+	"github.com/stephenzsy/small-kms/backend/api"
+	"github.com/stephenzsy/small-kms/backend/base"
+	"github.com/stephenzsy/small-kms/backend/internal/authz"
+	ctx "github.com/stephenzsy/small-kms/backend/internal/context"
+	auditmodels "github.com/stephenzsy/small-kms/backend/models/audit"
+	"github.com/stephenzsy/small-kms/backend/resdoc"
+	"github.com/stephenzsy/small-kms/backend/utils"
+)
+
+const auditRecordsListLimit = 1000
+
+// ListAuditRecords implements admin.ServerInterface.
+func (*AuditServer) ListAuditRecords(ec echo.Context, params admin.ListAuditRecordsParams) error {
+	c := ec.(ctx.RequestContext)
+
+	if !authz.AuthorizeAdminOnly(c) {
+		return base.ErrResponseStatusForbidden
+	}
+
+	qb := resdoc.NewAuditRecordQueryBuilder().
+		WithOrderBy(resdoc.AuditRecordQueryColSeq+" DESC").
+		WithOffsetLimit(0, auditRecordsListLimit)
+	addFilter := func(column, name string, value any) {
+		qb.WithWhereClauses(fmt.Sprintf("%s = %s", column, name))
+		qb.Parameters = append(qb.Parameters, azcosmos.QueryParameter{Name: name, Value: value})
+	}
+	if params.NamespaceProvider != nil {
+		addFilter(resdoc.AuditRecordQueryColNamespaceProvider, "@namespaceProvider", *params.NamespaceProvider)
+	}
+	if params.NamespaceId != nil && *params.NamespaceId != "" {
+		addFilter(resdoc.AuditRecordQueryColNamespaceID, "@namespaceId", *params.NamespaceId)
+	}
+	if params.ResourceProvider != nil && *params.ResourceProvider != "" {
+		addFilter(resdoc.AuditRecordQueryColResourceProvider, "@resourceProvider", *params.ResourceProvider)
+	}
+	if params.ResourceId != nil && *params.ResourceId != "" {
+		addFilter(resdoc.AuditRecordQueryColResourceID, "@resourceId", *params.ResourceId)
+	}
+	if params.Actor != nil && *params.Actor != "" {
+		addFilter(resdoc.AuditRecordQueryColActorID, "@actor", *params.Actor)
+	}
+	if params.From != nil && params.To != nil && params.To.Before(*params.From) {
+		return fmt.Errorf("%w: to must not be before from", base.ErrResponseStatusBadRequest)
+	}
+	if params.From != nil {
+		qb.WithWhereClauses(resdoc.AuditRecordQueryColTime + " >= @from")
+		qb.Parameters = append(qb.Parameters, azcosmos.QueryParameter{Name: "@from", Value: params.From.Unix()})
+	}
+	if params.To != nil {
+		qb.WithWhereClauses(resdoc.AuditRecordQueryColTime + " <= @to")
+		qb.Parameters = append(qb.Parameters, azcosmos.QueryParameter{Name: "@to", Value: params.To.Unix()})
+	}
+
+	pager := resdoc.NewQueryDocPager[*resdoc.AuditRecordDoc](c, qb, resdoc.AuditRecordPartitionKey)
+	modelPager := utils.NewMappedItemsPager(pager, func(doc *resdoc.AuditRecordDoc) auditmodels.AuditRecord {
+		return doc.ToModel()
+	})
+	return api.RespondPagerList(c, utils.NewSerializableItemsPager(modelPager))
+}
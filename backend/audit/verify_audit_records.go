@@ -0,0 +1,82 @@
+package audit
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
+	"github.com/labstack/echo/v4"
+	"github.com/stephenzsy/small-kms/backend/admin"
+	"github.com/stephenzsy/small-kms/backend/base"
+	"github.com/stephenzsy/small-kms/backend/internal/authz"
+	ctx "github.com/stephenzsy/small-kms/backend/internal/context"
+	auditmodels "github.com/stephenzsy/small-kms/backend/models/audit"
+	"github.com/stephenzsy/small-kms/backend/resdoc"
+)
+
+// VerifyAuditRecords implements admin.ServerInterface.
+func (*AuditServer) VerifyAuditRecords(ec echo.Context, params admin.VerifyAuditRecordsParams) error {
+	c := ec.(ctx.RequestContext)
+
+	if !authz.AuthorizeAdminOnly(c) {
+		return base.ErrResponseStatusForbidden
+	}
+
+	// the record before the first record to verify is the trusted checkpoint
+	var checkpoint *resdoc.AuditRecordDoc
+	fromSeq := int64(1)
+	if params.FromSeq != nil && *params.FromSeq > 1 {
+		fromSeq = *params.FromSeq
+		var err error
+		if checkpoint, err = resdoc.ReadAuditRecord(c, fromSeq-1); err != nil {
+			if errors.Is(err, resdoc.ErrAzCosmosDocNotFound) {
+				return fmt.Errorf("%w: checkpoint record %d not found", base.ErrResponseStatusBadRequest, fromSeq-1)
+			}
+			return err
+		}
+	}
+
+	// read the head first, records appended afterwards are covered by the chain
+	headSeq, err := resdoc.GetAuditChainHeadSeq(c)
+	if err != nil {
+		return err
+	}
+	qb := resdoc.NewAuditRecordQueryBuilder().
+		WithWhereClauses(resdoc.AuditRecordQueryColSeq + " >= @fromSeq").
+		WithOrderBy(resdoc.AuditRecordQueryColSeq + " ASC")
+	qb.Parameters = append(qb.Parameters, azcosmos.QueryParameter{Name: "@fromSeq", Value: fromSeq})
+	pager := resdoc.NewQueryDocPager[*resdoc.AuditRecordDoc](c, qb, resdoc.AuditRecordPartitionKey)
+
+	// verify page by page, the chain does not have to fit in memory
+	verifier := resdoc.NewAuditChainVerifier(checkpoint)
+	result := auditmodels.AuditChainVerification{}
+	for pager.More() && result.BrokenSeq == 0 {
+		records, err := pager.NextPage()
+		if err != nil {
+			return err
+		}
+		for _, record := range records {
+			if brokenSeq, err := verifier.Verify(record); err != nil {
+				if !errors.Is(err, resdoc.ErrAuditChainBroken) {
+					return err
+				}
+				result.BrokenSeq = brokenSeq
+				result.Message = err.Error()
+				break
+			}
+			result.RecordCount++
+		}
+	}
+	result.LastSeq = verifier.LastSeq()
+
+	if result.BrokenSeq == 0 {
+		if headSeq > result.LastSeq {
+			result.BrokenSeq = result.LastSeq + 1
+			result.Message = "records after the last record have been removed"
+		} else {
+			result.Verified = true
+		}
+	}
+	return c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,204 @@
+package cert
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	cloudkey "github.com/stephenzsy/small-kms/backend/cloud/key"
+	ctx "github.com/stephenzsy/small-kms/backend/internal/context"
+	"github.com/stephenzsy/small-kms/backend/models"
+	"github.com/stephenzsy/small-kms/backend/resdoc"
+	"golang.org/x/crypto/acme"
+)
+
+type acmeNewAccountRequest struct {
+	Contact                []string        `json:"contact"`
+	TermsOfServiceAgreed   bool            `json:"termsOfServiceAgreed"`
+	OnlyReturnExisting     bool            `json:"onlyReturnExisting"`
+	ExternalAccountBinding json.RawMessage `json:"externalAccountBinding"`
+}
+
+type acmeUpdateAccountRequest struct {
+	Status  string   `json:"status"`
+	Contact []string `json:"contact"`
+}
+
+func validateACMEContact(contact []string) error {
+	for _, v := range contact {
+		if !strings.HasPrefix(v, "mailto:") {
+			return newACMEProblem(acmeErrorInvalidContact, http.StatusBadRequest, "only mailto contacts are supported: %s", v)
+		}
+	}
+	return nil
+}
+
+// NewACMEAccount implements admin.ServerInterface.
+func (*CertServer) NewACMEAccount(ec echo.Context, namespaceProvider models.NamespaceProvider, namespaceId string, id string) error {
+	return serveACME(ec, namespaceProvider, namespaceId, id, true, func(c ctx.RequestContext, dir *acmeDirectory) error {
+		url := dir.url("new-account")
+		m, err := dir.readJWS(c, url)
+		if err != nil {
+			return err
+		}
+		if m.header.JWK == nil || m.header.KeyID != "" {
+			return newACMEProblem(acmeErrorMalformed, http.StatusBadRequest, "JWS must be signed with the account key in jwk")
+		}
+		publicKey, err := getACMEAccountPublicKey(m.header.JWK)
+		if err != nil {
+			return err
+		}
+		if err := m.verify(publicKey); err != nil {
+			return err
+		}
+		req := acmeNewAccountRequest{}
+		if err := m.unmarshalPayload(&req); err != nil {
+			return err
+		}
+
+		thumbprint, err := acmeJWKThumbprint(m.header.JWK)
+		if err != nil {
+			return err
+		}
+		if existing, err := dir.findAccountByThumbprint(c, thumbprint); err != nil {
+			return err
+		} else if existing != nil {
+			c.Response().Header().Set(echo.HeaderLocation, dir.url("accounts", existing.ID))
+			return c.JSON(http.StatusOK, existing.toResource())
+		}
+		if req.OnlyReturnExisting {
+			return newACMEProblem(acmeErrorAccountDoesNotExist, http.StatusBadRequest, "account does not exist")
+		}
+		if err := validateACMEContact(req.Contact); err != nil {
+			return err
+		}
+		if len(req.ExternalAccountBinding) == 0 {
+			return newACMEProblem(acmeErrorExternalAccountRequired, http.StatusUnauthorized, "external account binding is required")
+		}
+		keyDoc, err := dir.consumeExternalAccountBinding(c, req.ExternalAccountBinding, thumbprint, url)
+		if err != nil {
+			return err
+		}
+
+		accountID, err := uuid.NewRandom()
+		if err != nil {
+			return err
+		}
+		doc := &acmeAccountDoc{
+			ResourceDoc: resdoc.ResourceDoc{
+				PartitionKey: dir.partitionKey(models.ResourceProviderACMEAccount),
+				ID:           accountID.String(),
+			},
+			Status:                 acme.StatusValid,
+			Thumbprint:             thumbprint,
+			Contact:                req.Contact,
+			BoundNamespaceProvider: keyDoc.BoundNamespaceProvider,
+			BoundNamespaceID:       keyDoc.BoundNamespaceID,
+			ExternalAccountKeyID:   keyDoc.ID,
+		}
+		if err := doc.JsonWebKey.SetPublicKey(publicKey); err != nil {
+			return err
+		}
+		if _, err := resdoc.GetDocService(c).Create(c, doc, nil); err != nil {
+			return err
+		}
+		c.Response().Header().Set(echo.HeaderLocation, dir.url("accounts", doc.ID))
+		return c.JSON(http.StatusCreated, doc.toResource())
+	})
+}
+
+// consumeExternalAccountBinding verifies the binding JWS signs the account key with the MAC key, RFC 8555 section 7.3.4,
+// the MAC key is deleted so it cannot bind another account
+func (d *acmeDirectory) consumeExternalAccountBinding(c ctx.RequestContext, eab json.RawMessage, accountThumbprint string, url string) (*acmeExternalAccountKeyDoc, error) {
+	m, err := parseACMEJWS(eab)
+	if err != nil {
+		return nil, err
+	}
+	switch m.header.Alg {
+	case cloudkey.SignatureAlgorithmHS256, cloudkey.SignatureAlgorithmHS384, cloudkey.SignatureAlgorithmHS512:
+	default:
+		return nil, newACMEProblem(acmeErrorBadSignatureAlgorithm, http.StatusBadRequest, "unsupported external account binding algorithm: %s", m.header.Alg)
+	}
+	if m.header.KeyID == "" || m.header.Nonce != "" || m.header.JWK != nil || m.header.URL != url {
+		return nil, newACMEProblem(acmeErrorMalformed, http.StatusBadRequest, "invalid external account binding header")
+	}
+
+	docSvc := resdoc.GetDocService(c)
+	identifier := d.docIdentifier(models.ResourceProviderACMEExternalAccountKey, m.header.KeyID)
+	keyDoc := &acmeExternalAccountKeyDoc{}
+	if err := docSvc.Read(c, identifier, keyDoc, nil); err != nil {
+		if errors.Is(err, resdoc.ErrAzCosmosDocNotFound) {
+			return nil, newACMEProblem(acmeErrorUnauthorized, http.StatusUnauthorized, "external account binding key not found")
+		}
+		return nil, err
+	}
+	if time.Now().After(keyDoc.NotAfter.Time) {
+		return nil, newACMEProblem(acmeErrorUnauthorized, http.StatusUnauthorized, "external account binding key has expired")
+	}
+	if err := m.verify(keyDoc.HMACKey); err != nil {
+		return nil, newACMEProblem(acmeErrorUnauthorized, http.StatusUnauthorized, "external account binding verification failed")
+	}
+	boundJWK := cloudkey.JsonWebKey{}
+	if err := m.unmarshalPayload(&boundJWK); err != nil {
+		return nil, err
+	}
+	if boundThumbprint, err := acmeJWKThumbprint(&boundJWK); err != nil || boundThumbprint != accountThumbprint {
+		return nil, newACMEProblem(acmeErrorUnauthorized, http.StatusUnauthorized, "external account binding does not match the account key")
+	}
+
+	if _, err := docSvc.Delete(c, identifier, nil); err != nil {
+		if errors.Is(resdoc.HandleAzCosmosError(err), resdoc.ErrAzCosmosDocNotFound) {
+			return nil, newACMEProblem(acmeErrorUnauthorized, http.StatusUnauthorized, "external account binding key has been used")
+		}
+		return nil, err
+	}
+	return keyDoc, nil
+}
+
+// UpdateACMEAccount implements admin.ServerInterface.
+func (*CertServer) UpdateACMEAccount(ec echo.Context, namespaceProvider models.NamespaceProvider, namespaceId string, id string, accountId string) error {
+	return serveACME(ec, namespaceProvider, namespaceId, id, true, func(c ctx.RequestContext, dir *acmeDirectory) error {
+		m, account, err := dir.readAccountJWS(c, dir.url("accounts", accountId))
+		if err != nil {
+			return err
+		}
+		if account.ID != accountId {
+			return newACMEProblem(acmeErrorUnauthorized, http.StatusUnauthorized, "account does not match the JWS key ID")
+		}
+		if m.isPostAsGet() {
+			return c.JSON(http.StatusOK, account.toResource())
+		}
+
+		req := acmeUpdateAccountRequest{}
+		if err := m.unmarshalPayload(&req); err != nil {
+			return err
+		}
+		patchOps := azcosmos.PatchOperations{}
+		if req.Contact != nil {
+			if err := validateACMEContact(req.Contact); err != nil {
+				return err
+			}
+			patchOps.AppendSet("/contact", req.Contact)
+			account.Contact = req.Contact
+		}
+		switch req.Status {
+		case "":
+		case acme.StatusDeactivated:
+			patchOps.AppendSet("/status", acme.StatusDeactivated)
+			account.Status = acme.StatusDeactivated
+		default:
+			return newACMEProblem(acmeErrorMalformed, http.StatusBadRequest, "account status can only be updated to deactivated")
+		}
+		if req.Contact != nil || req.Status != "" {
+			if _, err := resdoc.GetDocService(c).Patch(c, account, patchOps, nil); err != nil {
+				return err
+			}
+		}
+		return c.JSON(http.StatusOK, account.toResource())
+	})
+}
@@ -0,0 +1,73 @@
+package cert
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
+	cloudkey "github.com/stephenzsy/small-kms/backend/cloud/key"
+	ctx "github.com/stephenzsy/small-kms/backend/internal/context"
+	"github.com/stephenzsy/small-kms/backend/models"
+	"github.com/stephenzsy/small-kms/backend/resdoc"
+	"github.com/stephenzsy/small-kms/backend/utils"
+)
+
+// acmeAccountDoc is an ACME account of the directory, certificates ordered by the account are stored
+// in the namespace of the principal bound by the external account binding
+type acmeAccountDoc struct {
+	resdoc.ResourceDoc
+	Status     string              `json:"status"`
+	JsonWebKey cloudkey.JsonWebKey `json:"jwk"`
+	Thumbprint string              `json:"thumbprint"`
+	Contact    []string            `json:"contact,omitempty"`
+
+	BoundNamespaceProvider models.NamespaceProvider `json:"boundNamespaceProvider"`
+	BoundNamespaceID       string                   `json:"boundNamespaceId"`
+	ExternalAccountKeyID   string                   `json:"eabKeyId"`
+}
+
+type acmeAccountResource struct {
+	Status  string   `json:"status"`
+	Contact []string `json:"contact,omitempty"`
+}
+
+func (d *acmeAccountDoc) toResource() *acmeAccountResource {
+	return &acmeAccountResource{
+		Status:  d.Status,
+		Contact: d.Contact,
+	}
+}
+
+// acmeExternalAccountKeyDoc is a one-time MAC key binding a new ACME account to an Entra principal, RFC 8555 section 7.3.4
+type acmeExternalAccountKeyDoc struct {
+	resdoc.ResourceDoc
+	HMACKey  []byte             `json:"hmacKey"`
+	NotAfter resdoc.NumericDate `json:"exp"`
+
+	BoundNamespaceProvider models.NamespaceProvider `json:"boundNamespaceProvider"`
+	BoundNamespaceID       string                   `json:"boundNamespaceId"`
+}
+
+func (d *acmeDirectory) getAccount(c ctx.RequestContext, accountID string) (*acmeAccountDoc, error) {
+	doc := &acmeAccountDoc{}
+	if err := resdoc.GetDocService(c).Read(c, d.docIdentifier(models.ResourceProviderACMEAccount, accountID), doc, nil); err != nil {
+		if errors.Is(err, resdoc.ErrAzCosmosDocNotFound) {
+			return nil, newACMEProblem(acmeErrorAccountDoesNotExist, http.StatusBadRequest, "account does not exist")
+		}
+		return nil, err
+	}
+	return doc, nil
+}
+
+// findAccountByThumbprint returns nil if no account of the directory has the key
+func (d *acmeDirectory) findAccountByThumbprint(c ctx.RequestContext, thumbprint string) (*acmeAccountDoc, error) {
+	qb := resdoc.NewDefaultCosmoQueryBuilder().
+		WithWhereClauses("c.thumbprint = @thumbprint")
+	qb.Parameters = append(qb.Parameters, azcosmos.QueryParameter{Name: "@thumbprint", Value: thumbprint})
+	pager := resdoc.NewQueryDocPager[*resdoc.ResourceQueryDoc](c, qb, d.partitionKey(models.ResourceProviderACMEAccount))
+	docs, err := utils.PagerToSlice[*resdoc.ResourceQueryDoc](pager)
+	if err != nil || len(docs) == 0 {
+		return nil, err
+	}
+	return d.getAccount(c, docs[0].ID)
+}
@@ -0,0 +1,122 @@
+package cert
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
+	"github.com/labstack/echo/v4"
+	ctx "github.com/stephenzsy/small-kms/backend/internal/context"
+	"github.com/stephenzsy/small-kms/backend/models"
+	"github.com/stephenzsy/small-kms/backend/resdoc"
+	"golang.org/x/crypto/acme"
+)
+
+type acmeUpdateAuthorizationRequest struct {
+	Status string `json:"status"`
+}
+
+// GetACMEAuthorization implements admin.ServerInterface.
+func (*CertServer) GetACMEAuthorization(ec echo.Context, namespaceProvider models.NamespaceProvider, namespaceId string, id string, authzId string) error {
+	return serveACME(ec, namespaceProvider, namespaceId, id, true, func(c ctx.RequestContext, dir *acmeDirectory) error {
+		m, account, err := dir.readAccountJWS(c, dir.url("authz", authzId))
+		if err != nil {
+			return err
+		}
+		authz, err := dir.getAuthorization(c, account, authzId)
+		if err != nil {
+			return err
+		}
+		if m.isPostAsGet() {
+			return c.JSON(http.StatusOK, dir.toAuthorizationResource(authz))
+		}
+
+		// deactivation, RFC 8555 section 7.5.2
+		req := acmeUpdateAuthorizationRequest{}
+		if err := m.unmarshalPayload(&req); err != nil {
+			return err
+		}
+		if req.Status != acme.StatusDeactivated {
+			return newACMEProblem(acmeErrorMalformed, http.StatusBadRequest, "authorization status can only be updated to deactivated")
+		}
+		switch authz.Status {
+		case acme.StatusPending, acme.StatusValid:
+		default:
+			return newACMEProblem(acmeErrorMalformed, http.StatusBadRequest, "authorization is %s", authz.Status)
+		}
+		patchOps := azcosmos.PatchOperations{}
+		patchOps.AppendSet("/status", acme.StatusDeactivated)
+		if _, err := resdoc.GetDocService(c).Patch(c, authz, patchOps, nil); err != nil {
+			return err
+		}
+		authz.Status = acme.StatusDeactivated
+		if err := dir.refreshOrderStatus(c, account, authz.OrderID); err != nil {
+			return err
+		}
+		return c.JSON(http.StatusOK, dir.toAuthorizationResource(authz))
+	})
+}
+
+// RespondACMEChallenge implements admin.ServerInterface.
+func (*CertServer) RespondACMEChallenge(ec echo.Context, namespaceProvider models.NamespaceProvider, namespaceId string, id string, authzId string, challengeType string) error {
+	return serveACME(ec, namespaceProvider, namespaceId, id, true, func(c ctx.RequestContext, dir *acmeDirectory) error {
+		m, account, err := dir.readAccountJWS(c, dir.url("authz", authzId, challengeType))
+		if err != nil {
+			return err
+		}
+		authz, err := dir.getAuthorization(c, account, authzId)
+		if err != nil {
+			return err
+		}
+		var challenge *acmeChallenge
+		for i := range authz.Challenges {
+			if authz.Challenges[i].Type == challengeType {
+				challenge = &authz.Challenges[i]
+				break
+			}
+		}
+		if challenge == nil {
+			return newACMEProblem(acmeErrorMalformed, http.StatusNotFound, "challenge not found")
+		}
+		c.Response().Header().Set("Link", fmt.Sprintf(`<%s>;rel="up"`, dir.url("authz", authz.ID)))
+
+		// a POST-as-GET fetches the challenge, any other payload is a response to the challenge
+		if m.isPostAsGet() || challenge.Status != acme.StatusPending || authz.Status != acme.StatusPending {
+			return c.JSON(http.StatusOK, dir.toChallengeResource(authz, challenge))
+		}
+
+		keyAuthorization := challenge.Token + "." + account.Thumbprint
+		domain := authz.ACMEIdentifier.Value
+		var validationErr *acmeProblem
+		switch challenge.Type {
+		case acmeChallengeTypeHTTP01:
+			validationErr = validateHTTP01Challenge(c, net.JoinHostPort(domain, "80"), challenge.Token, keyAuthorization)
+		case acmeChallengeTypeTLSALPN01:
+			validationErr = validateTLSALPN01Challenge(c, net.JoinHostPort(domain, "443"), domain, keyAuthorization)
+		}
+		if validationErr == nil {
+			challenge.Status = acme.StatusValid
+			challenge.Validated = &resdoc.NumericDate{}
+			challenge.Validated.Time = time.Now().Truncate(time.Second)
+			authz.Status = acme.StatusValid
+		} else {
+			challenge.Status = acme.StatusInvalid
+			challenge.Error = validationErr
+			authz.Status = acme.StatusInvalid
+		}
+		if _, err := resdoc.GetDocService(c).Upsert(c, authz, &azcosmos.ItemOptions{
+			IfMatchEtag: authz.GetETag(),
+		}); err != nil {
+			if isACMEPreconditionFailed(err) {
+				return newACMEProblem(acmeErrorMalformed, http.StatusConflict, "challenge is being processed")
+			}
+			return err
+		}
+		if err := dir.refreshOrderStatus(c, account, authz.OrderID); err != nil {
+			return err
+		}
+		return c.JSON(http.StatusOK, dir.toChallengeResource(authz, challenge))
+	})
+}
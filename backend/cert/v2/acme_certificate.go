@@ -0,0 +1,125 @@
+package cert
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stephenzsy/small-kms/backend/base"
+	ctx "github.com/stephenzsy/small-kms/backend/internal/context"
+	"github.com/stephenzsy/small-kms/backend/models"
+	certmodels "github.com/stephenzsy/small-kms/backend/models/cert"
+)
+
+type acmeRevokeCertificateRequest struct {
+	Certificate string `json:"certificate"`
+	Reason      *int   `json:"reason,omitempty"`
+}
+
+// readACMECertificate reads a certificate issued by the directory to the account
+func (d *acmeDirectory) readACMECertificate(c ctx.RequestContext, account *acmeAccountDoc, certificateID string) (*certDocBase, error) {
+	certDoc := &certDocBase{}
+	if err := readCertDocInternal(c, account.BoundNamespaceProvider, account.BoundNamespaceID, certificateID, certDoc); err != nil {
+		if errors.Is(err, base.ErrResponseStatusNotFound) {
+			return nil, newACMEProblem(acmeErrorMalformed, http.StatusNotFound, "certificate not found")
+		}
+		return nil, err
+	}
+	if certDoc.PolicyIdentifier != d.policy.Identifier() || len(certDoc.JsonWebKey.CertificateChain) == 0 {
+		return nil, newACMEProblem(acmeErrorMalformed, http.StatusNotFound, "certificate not found")
+	}
+	return certDoc, nil
+}
+
+// GetACMECertificate implements admin.ServerInterface.
+func (*CertServer) GetACMECertificate(ec echo.Context, namespaceProvider models.NamespaceProvider, namespaceId string, id string, certificateId string) error {
+	return serveACME(ec, namespaceProvider, namespaceId, id, true, func(c ctx.RequestContext, dir *acmeDirectory) error {
+		m, account, err := dir.readAccountJWS(c, dir.url("certificates", certificateId))
+		if err != nil {
+			return err
+		}
+		if !m.isPostAsGet() {
+			return newACMEProblem(acmeErrorMalformed, http.StatusBadRequest, "certificate must be fetched with POST-as-GET")
+		}
+		certDoc, err := dir.readACMECertificate(c, account, certificateId)
+		if err != nil {
+			return err
+		}
+		buf := bytes.Buffer{}
+		for _, der := range certDoc.JsonWebKey.CertificateChain {
+			if err := pem.Encode(&buf, &pem.Block{Type: "CERTIFICATE", Bytes: der}); err != nil {
+				return err
+			}
+		}
+		return c.Blob(http.StatusOK, contentTypePEMCertChain, buf.Bytes())
+	})
+}
+
+// RevokeACMECertificate implements admin.ServerInterface.
+func (*CertServer) RevokeACMECertificate(ec echo.Context, namespaceProvider models.NamespaceProvider, namespaceId string, id string) error {
+	return serveACME(ec, namespaceProvider, namespaceId, id, true, func(c ctx.RequestContext, dir *acmeDirectory) error {
+		m, account, err := dir.readAccountJWS(c, dir.url("revoke-cert"))
+		if err != nil {
+			return err
+		}
+		req := acmeRevokeCertificateRequest{}
+		if err := m.unmarshalPayload(&req); err != nil {
+			return err
+		}
+		der, err := base64.RawURLEncoding.DecodeString(req.Certificate)
+		if err != nil {
+			return newACMEProblem(acmeErrorMalformed, http.StatusBadRequest, "invalid certificate encoding")
+		}
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return newACMEProblem(acmeErrorMalformed, http.StatusBadRequest, "invalid certificate")
+		}
+		reason := certmodels.RevocationReasonUnspecified
+		if req.Reason != nil {
+			var ok bool
+			if reason, ok = certmodels.RevocationReasonFromCode(*req.Reason); !ok {
+				return newACMEProblem(acmeErrorBadRevocationReason, http.StatusBadRequest, "unsupported revocation reason: %d", *req.Reason)
+			}
+		}
+
+		issuer, err := dir.policy.getIssuerCertIdentifier(c)
+		if err != nil {
+			return err
+		}
+		link, err := findIssuedCertLinkBySerialNumberInternal(c, issuer, cert.SerialNumber.Bytes())
+		if err != nil {
+			return err
+		}
+		if link == nil || link.LinkTo.NamespaceProvider != account.BoundNamespaceProvider || link.LinkTo.NamespaceID != account.BoundNamespaceID {
+			return newACMEProblem(acmeErrorUnauthorized, http.StatusForbidden, "certificate was not issued to the account")
+		}
+		certDoc, err := dir.readACMECertificate(c, account, link.LinkTo.ID)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(certDoc.GetCertificateBytes(), der) {
+			return newACMEProblem(acmeErrorUnauthorized, http.StatusForbidden, "certificate was not issued to the account")
+		}
+		if certDoc.Status == certmodels.CertificateStatusRevoked {
+			return newACMEProblem(acmeErrorAlreadyRevoked, http.StatusBadRequest, "certificate has already been revoked")
+		}
+
+		revocation := certmodels.CertificateRevocation{
+			Reason:    reason,
+			RevokedBy: m.header.KeyID,
+		}
+		revocation.RevokedAt.Time = time.Now().Truncate(time.Second)
+		if err := revokeCertificateInternal(c, certDoc, revocation); err != nil {
+			return err
+		}
+		if err := republishCRLInternal(c, certDoc.Issuer); err != nil {
+			return err
+		}
+		return c.NoContent(http.StatusOK)
+	})
+}
@@ -0,0 +1,199 @@
+package cert
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+	cloudkey "github.com/stephenzsy/small-kms/backend/cloud/key"
+	ctx "github.com/stephenzsy/small-kms/backend/internal/context"
+	"golang.org/x/crypto/acme"
+)
+
+const acmeAccountKeyMinRSASize = 2048
+
+// acmeJWS is a JWS in flattened JSON serialization, RFC 7515 section 7.2.2
+type acmeJWS struct {
+	Protected string `json:"protected"`
+	Payload   string `json:"payload"`
+	Signature string `json:"signature"`
+}
+
+// acmeJWSHeader is the protected header, RFC 8555 section 6.2
+type acmeJWSHeader struct {
+	Alg   cloudkey.JsonWebSignatureAlgorithm `json:"alg"`
+	Nonce string                             `json:"nonce,omitempty"`
+	URL   string                             `json:"url"`
+	KeyID string                             `json:"kid,omitempty"`
+	JWK   *cloudkey.JsonWebKey               `json:"jwk,omitempty"`
+}
+
+type acmeJWSMessage struct {
+	header       acmeJWSHeader
+	payload      []byte
+	signingInput string
+	signature    []byte
+}
+
+func parseACMEJWS(data []byte) (*acmeJWSMessage, error) {
+	jws := acmeJWS{}
+	if err := json.Unmarshal(data, &jws); err != nil {
+		return nil, newACMEProblem(acmeErrorMalformed, http.StatusBadRequest, "invalid JWS")
+	}
+	m := &acmeJWSMessage{
+		signingInput: jws.Protected + "." + jws.Payload,
+	}
+	headerBytes, err := base64.RawURLEncoding.DecodeString(jws.Protected)
+	if err != nil {
+		return nil, newACMEProblem(acmeErrorMalformed, http.StatusBadRequest, "invalid JWS protected header")
+	}
+	if err := json.Unmarshal(headerBytes, &m.header); err != nil {
+		return nil, newACMEProblem(acmeErrorMalformed, http.StatusBadRequest, "invalid JWS protected header")
+	}
+	if m.payload, err = base64.RawURLEncoding.DecodeString(jws.Payload); err != nil {
+		return nil, newACMEProblem(acmeErrorMalformed, http.StatusBadRequest, "invalid JWS payload")
+	}
+	if m.signature, err = base64.RawURLEncoding.DecodeString(jws.Signature); err != nil {
+		return nil, newACMEProblem(acmeErrorMalformed, http.StatusBadRequest, "invalid JWS signature")
+	}
+	return m, nil
+}
+
+// verify checks the signature with the public key, or with the MAC key for external account bindings
+func (m *acmeJWSMessage) verify(key any) error {
+	method := jwt.GetSigningMethod(string(m.header.Alg))
+	if method == nil || method == jwt.SigningMethodNone {
+		return newACMEProblem(acmeErrorBadSignatureAlgorithm, http.StatusBadRequest, "unsupported JWS algorithm: %s", m.header.Alg)
+	}
+	if err := method.Verify(m.signingInput, m.signature, key); err != nil {
+		return newACMEProblem(acmeErrorMalformed, http.StatusBadRequest, "JWS signature verification failed")
+	}
+	return nil
+}
+
+// isPostAsGet returns true for POST-as-GET requests, RFC 8555 section 6.3
+func (m *acmeJWSMessage) isPostAsGet() bool {
+	return len(m.payload) == 0
+}
+
+func (m *acmeJWSMessage) unmarshalPayload(v any) error {
+	if err := json.Unmarshal(m.payload, v); err != nil {
+		return newACMEProblem(acmeErrorMalformed, http.StatusBadRequest, "invalid JWS payload")
+	}
+	return nil
+}
+
+// account keys must be asymmetric keys
+func isACMEAccountKeyAlg(alg cloudkey.JsonWebSignatureAlgorithm) bool {
+	switch alg {
+	case cloudkey.SignatureAlgorithmRS256,
+		cloudkey.SignatureAlgorithmRS384,
+		cloudkey.SignatureAlgorithmRS512,
+		cloudkey.SignatureAlgorithmPS256,
+		cloudkey.SignatureAlgorithmPS384,
+		cloudkey.SignatureAlgorithmPS512,
+		cloudkey.SignatureAlgorithmES256,
+		cloudkey.SignatureAlgorithmES384,
+		cloudkey.SignatureAlgorithmES512:
+		return true
+	}
+	return false
+}
+
+// getACMEAccountPublicKey validates the account key from the JWS header
+func getACMEAccountPublicKey(jwk *cloudkey.JsonWebKey) (crypto.PublicKey, error) {
+	switch jwk.KeyType {
+	case cloudkey.KeyTypeRSA:
+		if len(jwk.E) == 0 || jwk.N.BitLen() < acmeAccountKeyMinRSASize {
+			return nil, newACMEProblem(acmeErrorBadPublicKey, http.StatusBadRequest, "RSA key must be at least %d bits", acmeAccountKeyMinRSASize)
+		}
+		if pub, ok := jwk.PublicKey().(*rsa.PublicKey); ok && pub.E > 1 {
+			return pub, nil
+		}
+	case cloudkey.KeyTypeEC:
+		if pub, ok := jwk.PublicKey().(*ecdsa.PublicKey); ok && pub != nil {
+			// validates the point is on the curve
+			if _, err := pub.ECDH(); err == nil {
+				return pub, nil
+			}
+		}
+	}
+	return nil, newACMEProblem(acmeErrorBadPublicKey, http.StatusBadRequest, "unsupported account key")
+}
+
+// acmeJWKThumbprint returns the base64url encoded JWK thumbprint, RFC 7638
+func acmeJWKThumbprint(jwk *cloudkey.JsonWebKey) (string, error) {
+	var canonical string
+	switch jwk.KeyType {
+	case cloudkey.KeyTypeRSA:
+		canonical = fmt.Sprintf(`{"e":"%s","kty":"RSA","n":"%s"}`,
+			base64.RawURLEncoding.EncodeToString(jwk.E), base64.RawURLEncoding.EncodeToString(jwk.N))
+	case cloudkey.KeyTypeEC:
+		canonical = fmt.Sprintf(`{"crv":"%s","kty":"EC","x":"%s","y":"%s"}`,
+			jwk.Curve, base64.RawURLEncoding.EncodeToString(jwk.X), base64.RawURLEncoding.EncodeToString(jwk.Y))
+	default:
+		return "", cloudkey.ErrInvalidKeyType
+	}
+	digest := sha256.Sum256([]byte(canonical))
+	return base64.RawURLEncoding.EncodeToString(digest[:]), nil
+}
+
+// readJWS reads the JWS of the request, the url header must match the URL of the resource and the nonce is consumed
+func (d *acmeDirectory) readJWS(c ctx.RequestContext, url string) (*acmeJWSMessage, error) {
+	if contentType := c.Request().Header.Get(echo.HeaderContentType); contentType != contentTypeACMEJWS {
+		return nil, newACMEProblem(acmeErrorMalformed, http.StatusUnsupportedMediaType, "content type must be %s", contentTypeACMEJWS)
+	}
+	body, err := io.ReadAll(io.LimitReader(c.Request().Body, acmeRequestMaxBodySize))
+	if err != nil {
+		return nil, err
+	}
+	m, err := parseACMEJWS(body)
+	if err != nil {
+		return nil, err
+	}
+	if !isACMEAccountKeyAlg(m.header.Alg) {
+		return nil, newACMEProblem(acmeErrorBadSignatureAlgorithm, http.StatusBadRequest, "unsupported JWS algorithm: %s", m.header.Alg)
+	}
+	if m.header.URL != url {
+		return nil, newACMEProblem(acmeErrorUnauthorized, http.StatusUnauthorized, "JWS url does not match the request")
+	}
+	if err := d.consumeNonce(c, m.header.Nonce); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// readAccountJWS reads the JWS of a request signed by an existing account
+func (d *acmeDirectory) readAccountJWS(c ctx.RequestContext, url string) (*acmeJWSMessage, *acmeAccountDoc, error) {
+	m, err := d.readJWS(c, url)
+	if err != nil {
+		return nil, nil, err
+	}
+	if m.header.JWK != nil || m.header.KeyID == "" {
+		return nil, nil, newACMEProblem(acmeErrorMalformed, http.StatusBadRequest, "JWS must be signed with the account key ID")
+	}
+	accountID, ok := strings.CutPrefix(m.header.KeyID, d.url("accounts")+"/")
+	if !ok {
+		return nil, nil, newACMEProblem(acmeErrorAccountDoesNotExist, http.StatusBadRequest, "account does not exist")
+	}
+	account, err := d.getAccount(c, accountID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := m.verify(account.JsonWebKey.PublicKey()); err != nil {
+		return nil, nil, err
+	}
+	if account.Status != acme.StatusValid {
+		return nil, nil, newACMEProblem(acmeErrorUnauthorized, http.StatusUnauthorized, "account is %s", account.Status)
+	}
+	return m, account, nil
+}
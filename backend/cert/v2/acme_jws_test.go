@@ -0,0 +1,109 @@
+package cert
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	cloudkey "github.com/stephenzsy/small-kms/backend/cloud/key"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestACMEJWS(t *testing.T, header map[string]any, payload []byte, key any) []byte {
+	headerBytes, err := json.Marshal(header)
+	require.NoError(t, err)
+	protected := base64.RawURLEncoding.EncodeToString(headerBytes)
+	encodedPayload := base64.RawURLEncoding.EncodeToString(payload)
+	signature, err := jwt.GetSigningMethod(header["alg"].(string)).Sign(protected+"."+encodedPayload, key)
+	require.NoError(t, err)
+	jws, err := json.Marshal(acmeJWS{
+		Protected: protected,
+		Payload:   encodedPayload,
+		Signature: base64.RawURLEncoding.EncodeToString(signature),
+	})
+	require.NoError(t, err)
+	return jws
+}
+
+func TestACMEJWSVerify(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	jwk := &cloudkey.JsonWebKey{}
+	require.NoError(t, jwk.SetPublicKey(key.Public()))
+
+	data := newTestACMEJWS(t, map[string]any{
+		"alg":   "ES256",
+		"nonce": "nonce",
+		"url":   "https://example.com/acme/new-account",
+		"jwk":   jwk,
+	}, []byte(`{"termsOfServiceAgreed":true}`), key)
+
+	m, err := parseACMEJWS(data)
+	require.NoError(t, err)
+	assert.Equal(t, cloudkey.SignatureAlgorithmES256, m.header.Alg)
+	assert.Equal(t, "https://example.com/acme/new-account", m.header.URL)
+	assert.False(t, m.isPostAsGet())
+
+	pub, err := getACMEAccountPublicKey(m.header.JWK)
+	require.NoError(t, err)
+	assert.NoError(t, m.verify(pub))
+
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	assert.Error(t, m.verify(otherKey.Public()))
+
+	m.header.Alg = "none"
+	assert.Error(t, m.verify(pub))
+}
+
+func TestACMEExternalAccountBindingVerify(t *testing.T) {
+	hmacKey := make([]byte, 32)
+	_, err := rand.Read(hmacKey)
+	require.NoError(t, err)
+
+	data := newTestACMEJWS(t, map[string]any{
+		"alg": "HS256",
+		"kid": "kid",
+		"url": "https://example.com/acme/new-account",
+	}, []byte(`{}`), hmacKey)
+	m, err := parseACMEJWS(data)
+	require.NoError(t, err)
+	assert.NoError(t, m.verify(hmacKey))
+	assert.Error(t, m.verify([]byte("wrong key")))
+}
+
+func TestACMEAccountPublicKey(t *testing.T) {
+	smallKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	jwk := &cloudkey.JsonWebKey{}
+	require.NoError(t, jwk.SetPublicKey(smallKey.Public()))
+	_, err = getACMEAccountPublicKey(jwk)
+	assert.Error(t, err)
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	require.NoError(t, jwk.SetPublicKey(key.Public()))
+	_, err = getACMEAccountPublicKey(jwk)
+	assert.NoError(t, err)
+}
+
+// RFC 7638 section 3.1
+func TestACMEJWKThumbprint(t *testing.T) {
+	jwk := &cloudkey.JsonWebKey{}
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"kty": "RSA",
+		"n": "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw",
+		"e": "AQAB",
+		"alg": "RS256",
+		"kid": "2011-04-29"
+	}`), jwk))
+	thumbprint, err := acmeJWKThumbprint(jwk)
+	require.NoError(t, err)
+	assert.Equal(t, "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs", thumbprint)
+}
@@ -0,0 +1,69 @@
+package cert
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"time"
+
+	ctx "github.com/stephenzsy/small-kms/backend/internal/context"
+	"github.com/stephenzsy/small-kms/backend/models"
+	"github.com/stephenzsy/small-kms/backend/resdoc"
+)
+
+const acmeNonceValidity = time.Hour
+
+// acmeNonceDoc is an anti-replay nonce of the ACME directory, it is deleted once used,
+// and removed by the per-item time to live if it is never used
+type acmeNonceDoc struct {
+	resdoc.ResourceDoc
+	NotAfter resdoc.NumericDate `json:"exp"`
+	TTL      int                `json:"ttl"`
+}
+
+func (d *acmeDirectory) newNonce(c ctx.RequestContext) (string, error) {
+	nonceBytes := make([]byte, 16)
+	if _, err := rand.Read(nonceBytes); err != nil {
+		return "", err
+	}
+	doc := &acmeNonceDoc{
+		ResourceDoc: resdoc.ResourceDoc{
+			PartitionKey: d.partitionKey(models.ResourceProviderACMENonce),
+			ID:           base64.RawURLEncoding.EncodeToString(nonceBytes),
+		},
+	}
+	doc.NotAfter.Time = time.Now().Add(acmeNonceValidity).Truncate(time.Second)
+	doc.TTL = int(acmeNonceValidity / time.Second)
+	if _, err := resdoc.GetDocService(c).Create(c, doc, nil); err != nil {
+		return "", err
+	}
+	return doc.ID, nil
+}
+
+// consumeNonce fails with badNonce if the nonce was not issued by the directory, has been used or has expired
+func (d *acmeDirectory) consumeNonce(c ctx.RequestContext, nonce string) error {
+	if nonce == "" {
+		return newACMEProblem(acmeErrorBadNonce, http.StatusBadRequest, "nonce is required")
+	}
+	docSvc := resdoc.GetDocService(c)
+	identifier := d.docIdentifier(models.ResourceProviderACMENonce, nonce)
+	doc := &acmeNonceDoc{}
+	if err := docSvc.Read(c, identifier, doc, nil); err != nil {
+		if errors.Is(err, resdoc.ErrAzCosmosDocNotFound) {
+			return newACMEProblem(acmeErrorBadNonce, http.StatusBadRequest, "invalid nonce")
+		}
+		return err
+	}
+	// delete fails if the nonce has been consumed by a concurrent request
+	if _, err := docSvc.Delete(c, identifier, nil); err != nil {
+		if errors.Is(resdoc.HandleAzCosmosError(err), resdoc.ErrAzCosmosDocNotFound) {
+			return newACMEProblem(acmeErrorBadNonce, http.StatusBadRequest, "invalid nonce")
+		}
+		return err
+	}
+	if time.Now().After(doc.NotAfter.Time) {
+		return newACMEProblem(acmeErrorBadNonce, http.StatusBadRequest, "nonce has expired")
+	}
+	return nil
+}
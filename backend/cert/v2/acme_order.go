@@ -0,0 +1,347 @@
+package cert
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+	cloudkey "github.com/stephenzsy/small-kms/backend/cloud/key"
+	ctx "github.com/stephenzsy/small-kms/backend/internal/context"
+	"github.com/stephenzsy/small-kms/backend/models"
+	certmodels "github.com/stephenzsy/small-kms/backend/models/cert"
+	webhookmodels "github.com/stephenzsy/small-kms/backend/models/webhook"
+	"github.com/stephenzsy/small-kms/backend/resdoc"
+	"golang.org/x/crypto/acme"
+)
+
+const acmeMaxOrderIdentifiers = 100
+
+type acmeNewOrderRequest struct {
+	Identifiers []acmeIdentifier `json:"identifiers"`
+	NotBefore   string           `json:"notBefore"`
+	NotAfter    string           `json:"notAfter"`
+}
+
+type acmeFinalizeOrderRequest struct {
+	CSR string `json:"csr"`
+}
+
+// validateACMEDNSName accepts fully qualified host names only, wildcards and IP addresses are rejected
+func validateACMEDNSName(name string) bool {
+	if len(name) == 0 || len(name) > 253 || net.ParseIP(name) != nil {
+		return false
+	}
+	labels := strings.Split(name, ".")
+	if len(labels) < 2 {
+		return false
+	}
+	for _, label := range labels {
+		if len(label) == 0 || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, r := range label {
+			if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// sanitizeACMEIdentifiers returns the sorted identifiers without duplicates
+func sanitizeACMEIdentifiers(identifiers []acmeIdentifier) ([]acmeIdentifier, error) {
+	if len(identifiers) == 0 || len(identifiers) > acmeMaxOrderIdentifiers {
+		return nil, newACMEProblem(acmeErrorMalformed, http.StatusBadRequest, "order must have 1 to %d identifiers", acmeMaxOrderIdentifiers)
+	}
+	names := make([]string, 0, len(identifiers))
+	for _, identifier := range identifiers {
+		if identifier.Type != acmeIdentifierTypeDNS {
+			return nil, newACMEProblem(acmeErrorUnsupportedIdentifier, http.StatusBadRequest, "unsupported identifier type: %s", identifier.Type)
+		}
+		name := strings.ToLower(strings.TrimSpace(identifier.Value))
+		if !validateACMEDNSName(name) {
+			return nil, newACMEProblem(acmeErrorRejectedIdentifier, http.StatusBadRequest, "identifier is not allowed: %s", identifier.Value)
+		}
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	names = slices.Compact(names)
+	sanitized := make([]acmeIdentifier, len(names))
+	for i, name := range names {
+		sanitized[i] = acmeIdentifier{Type: acmeIdentifierTypeDNS, Value: name}
+	}
+	return sanitized, nil
+}
+
+func newACMEChallengeToken() (string, error) {
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(tokenBytes), nil
+}
+
+// NewACMEOrder implements admin.ServerInterface.
+func (*CertServer) NewACMEOrder(ec echo.Context, namespaceProvider models.NamespaceProvider, namespaceId string, id string) error {
+	return serveACME(ec, namespaceProvider, namespaceId, id, true, func(c ctx.RequestContext, dir *acmeDirectory) error {
+		m, account, err := dir.readAccountJWS(c, dir.url("new-order"))
+		if err != nil {
+			return err
+		}
+		req := acmeNewOrderRequest{}
+		if err := m.unmarshalPayload(&req); err != nil {
+			return err
+		}
+		if req.NotBefore != "" || req.NotAfter != "" {
+			return newACMEProblem(acmeErrorMalformed, http.StatusBadRequest, "notBefore and notAfter are not supported")
+		}
+		identifiers, err := sanitizeACMEIdentifiers(req.Identifiers)
+		if err != nil {
+			return err
+		}
+
+		orderID, err := uuid.NewRandom()
+		if err != nil {
+			return err
+		}
+		order := &acmeOrderDoc{
+			ResourceDoc: resdoc.ResourceDoc{
+				PartitionKey: dir.partitionKey(models.ResourceProviderACMEOrder),
+				ID:           orderID.String(),
+			},
+			AccountID:      account.ID,
+			Status:         acme.StatusPending,
+			Identifiers:    identifiers,
+			Authorizations: make([]string, 0, len(identifiers)),
+		}
+		order.NotAfter.Time = time.Now().Add(acmeOrderValidity).Truncate(time.Second)
+
+		docSvc := resdoc.GetDocService(c)
+		for _, identifier := range identifiers {
+			authzID, err := uuid.NewRandom()
+			if err != nil {
+				return err
+			}
+			authz := &acmeAuthorizationDoc{
+				ResourceDoc: resdoc.ResourceDoc{
+					PartitionKey: dir.partitionKey(models.ResourceProviderACMEAuthorization),
+					ID:           authzID.String(),
+				},
+				AccountID:      account.ID,
+				OrderID:        order.ID,
+				Status:         acme.StatusPending,
+				NotAfter:       order.NotAfter,
+				ACMEIdentifier: identifier,
+			}
+			for _, challengeType := range []string{acmeChallengeTypeHTTP01, acmeChallengeTypeTLSALPN01} {
+				token, err := newACMEChallengeToken()
+				if err != nil {
+					return err
+				}
+				authz.Challenges = append(authz.Challenges, acmeChallenge{
+					Type:   challengeType,
+					Token:  token,
+					Status: acme.StatusPending,
+				})
+			}
+			if _, err := docSvc.Create(c, authz, nil); err != nil {
+				return err
+			}
+			order.Authorizations = append(order.Authorizations, authz.ID)
+		}
+		if _, err := docSvc.Create(c, order, nil); err != nil {
+			return err
+		}
+		c.Response().Header().Set(echo.HeaderLocation, dir.url("orders", order.ID))
+		return c.JSON(http.StatusCreated, dir.toOrderResource(order))
+	})
+}
+
+// GetACMEOrder implements admin.ServerInterface.
+func (*CertServer) GetACMEOrder(ec echo.Context, namespaceProvider models.NamespaceProvider, namespaceId string, id string, orderId string) error {
+	return serveACME(ec, namespaceProvider, namespaceId, id, true, func(c ctx.RequestContext, dir *acmeDirectory) error {
+		m, account, err := dir.readAccountJWS(c, dir.url("orders", orderId))
+		if err != nil {
+			return err
+		}
+		if !m.isPostAsGet() {
+			return newACMEProblem(acmeErrorMalformed, http.StatusBadRequest, "order must be fetched with POST-as-GET")
+		}
+		order, err := dir.getOrder(c, account, orderId)
+		if err != nil {
+			return err
+		}
+		return c.JSON(http.StatusOK, dir.toOrderResource(order))
+	})
+}
+
+// validateACMECSR checks the CSR requests exactly the identifiers of the order, RFC 8555 section 7.4
+func validateACMECSR(csr *x509.CertificateRequest, identifiers []acmeIdentifier) error {
+	if err := csr.CheckSignature(); err != nil {
+		return newACMEProblem(acmeErrorBadCSR, http.StatusBadRequest, "invalid CSR signature")
+	}
+	if len(csr.IPAddresses) > 0 || len(csr.EmailAddresses) > 0 || len(csr.URIs) > 0 {
+		return newACMEProblem(acmeErrorBadCSR, http.StatusBadRequest, "CSR must only request DNS names")
+	}
+	names := slices.Clone(csr.DNSNames)
+	if csr.Subject.CommonName != "" {
+		names = append(names, csr.Subject.CommonName)
+	}
+	names = certmodels.SanitizeDNSNames(names)
+	if len(names) != len(identifiers) {
+		return newACMEProblem(acmeErrorBadCSR, http.StatusBadRequest, "CSR names do not match the order identifiers")
+	}
+	for i, identifier := range identifiers {
+		if names[i] != identifier.Value {
+			return newACMEProblem(acmeErrorBadCSR, http.StatusBadRequest, "CSR names do not match the order identifiers")
+		}
+	}
+
+	switch pub := csr.PublicKey.(type) {
+	case *rsa.PublicKey:
+		if pub.N.BitLen() >= acmeAccountKeyMinRSASize {
+			return nil
+		}
+	case *ecdsa.PublicKey:
+		switch pub.Curve {
+		case elliptic.P256(), elliptic.P384(), elliptic.P521():
+			return nil
+		}
+	}
+	return newACMEProblem(acmeErrorBadPublicKey, http.StatusBadRequest, "unsupported certificate key")
+}
+
+// FinalizeACMEOrder implements admin.ServerInterface.
+func (*CertServer) FinalizeACMEOrder(ec echo.Context, namespaceProvider models.NamespaceProvider, namespaceId string, id string, orderId string) error {
+	return serveACME(ec, namespaceProvider, namespaceId, id, true, func(c ctx.RequestContext, dir *acmeDirectory) error {
+		m, account, err := dir.readAccountJWS(c, dir.url("orders", orderId, "finalize"))
+		if err != nil {
+			return err
+		}
+		order, err := dir.getOrder(c, account, orderId)
+		if err != nil {
+			return err
+		}
+		if order.Status != acme.StatusReady {
+			return newACMEProblem(acmeErrorOrderNotReady, http.StatusForbidden, "order is %s", order.Status)
+		}
+		req := acmeFinalizeOrderRequest{}
+		if err := m.unmarshalPayload(&req); err != nil {
+			return err
+		}
+		csrBytes, err := base64.RawURLEncoding.DecodeString(req.CSR)
+		if err != nil {
+			return newACMEProblem(acmeErrorBadCSR, http.StatusBadRequest, "invalid CSR encoding")
+		}
+		csr, err := x509.ParseCertificateRequest(csrBytes)
+		if err != nil {
+			return newACMEProblem(acmeErrorBadCSR, http.StatusBadRequest, "invalid CSR")
+		}
+		if err := validateACMECSR(csr, order.Identifiers); err != nil {
+			return err
+		}
+
+		// only one finalize request of the order may proceed
+		docSvc := resdoc.GetDocService(c)
+		patchOps := azcosmos.PatchOperations{}
+		patchOps.AppendSet("/status", acme.StatusProcessing)
+		if _, err := docSvc.Patch(c, order, patchOps, &azcosmos.ItemOptions{
+			IfMatchEtag: order.GetETag(),
+		}); err != nil {
+			if isACMEPreconditionFailed(err) {
+				return newACMEProblem(acmeErrorOrderNotReady, http.StatusForbidden, "order is being finalized")
+			}
+			return err
+		}
+
+		patchOps = azcosmos.PatchOperations{}
+		if certDoc, err := dir.issueCertificate(c, account, order, csr); err != nil {
+			log.Ctx(c).Error().Err(err).Str("orderId", order.ID).Msg("failed to issue ACME certificate")
+			order.Status = acme.StatusInvalid
+			order.Error = newACMEProblem(acmeErrorServerInternal, http.StatusInternalServerError, "failed to issue certificate")
+			patchOps.AppendSet("/error", order.Error)
+		} else {
+			order.Status = acme.StatusValid
+			order.CertificateID = certDoc.ID
+			patchOps.AppendSet("/certificateId", order.CertificateID)
+		}
+		patchOps.AppendSet("/status", order.Status)
+		if _, err := docSvc.Patch(c, order, patchOps, nil); err != nil {
+			return err
+		}
+		c.Response().Header().Set(echo.HeaderLocation, dir.url("orders", order.ID))
+		return c.JSON(http.StatusOK, dir.toOrderResource(order))
+	})
+}
+
+// issueCertificate signs a server certificate with the current issuer certificate of the policy,
+// the certificate is stored in the namespace bound to the account
+func (d *acmeDirectory) issueCertificate(c ctx.RequestContext, account *acmeAccountDoc, order *acmeOrderDoc, csr *x509.CertificateRequest) (*certDocInternal, error) {
+	certDoc := &certDocInternal{}
+	var err error
+	if certDoc.certUUID, err = uuid.NewRandom(); err != nil {
+		return nil, err
+	}
+	certDoc.PartitionKey = resdoc.PartitionKey{
+		NamespaceProvider: account.BoundNamespaceProvider,
+		NamespaceID:       account.BoundNamespaceID,
+		ResourceProvider:  models.ResourceProviderCert,
+	}
+	certDoc.ID = certDoc.certUUID.String()
+	certDoc.Status = certmodels.CertificateStatusPending
+	if err := certDoc.JsonWebKey.SetPublicKey(csr.PublicKey); err != nil {
+		return nil, err
+	}
+	certDoc.JsonWebKey.KeyOperations = []cloudkey.JsonWebKeyOperation{cloudkey.JsonWebKeyOperationSign, cloudkey.JsonWebKeyOperationVerify}
+	if certDoc.JsonWebKey.KeyType == cloudkey.KeyTypeRSA {
+		certDoc.JsonWebKey.KeyOperations = append(certDoc.JsonWebKey.KeyOperations,
+			cloudkey.JsonWebKeyOperationWrapKey, cloudkey.JsonWebKeyOperationUnwrapKey)
+	}
+	dnsNames := make([]string, len(order.Identifiers))
+	for i, identifier := range order.Identifiers {
+		dnsNames[i] = identifier.Value
+	}
+	certDoc.Subject = certmodels.CertificateSubject{
+		CommonName: dnsNames[0],
+	}
+	certDoc.SANs = &certmodels.SubjectAlternativeNames{
+		DNSNames: dnsNames,
+	}
+	certDoc.Flags = []certmodels.CertificateFlag{certmodels.CertificateFlagServerAuth}
+	certDoc.PolicyIdentifier = d.policy.Identifier()
+	certDoc.PolicyVersion = d.policy.Version
+	if certDoc.Issuer, err = d.policy.getIssuerCertIdentifier(c); err != nil {
+		return nil, err
+	}
+	now := time.Now().Truncate(time.Second)
+	certDoc.NotBefore.Time = now
+	certDoc.NotAfter.Time = now.Add(acmeCertificateValidity)
+
+	der, err := certDoc.CreateCertificate(c, &enrollX509CSR{csr})
+	if err != nil {
+		return nil, err
+	}
+	if err := certDoc.CollectSignedCertificate(c, der); err != nil {
+		return nil, err
+	}
+	if _, err := resdoc.GetDocService(c).Create(c, certDoc, nil); err != nil {
+		return nil, err
+	}
+	if err := syncIssuedCertLinkInternal(c, certDoc); err != nil {
+		return nil, err
+	}
+	publishCertificateEvent(c, certDoc, webhookmodels.EventTypeCertificateIssued)
+	return certDoc, nil
+}
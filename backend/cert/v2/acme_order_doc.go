@@ -0,0 +1,220 @@
+package cert
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
+	ctx "github.com/stephenzsy/small-kms/backend/internal/context"
+	"github.com/stephenzsy/small-kms/backend/models"
+	"github.com/stephenzsy/small-kms/backend/resdoc"
+	"golang.org/x/crypto/acme"
+)
+
+const (
+	acmeOrderValidity       = 7 * 24 * time.Hour
+	acmeCertificateValidity = 90 * 24 * time.Hour
+
+	acmeIdentifierTypeDNS = "dns"
+
+	acmeChallengeTypeDNS01     = "dns-01"
+	acmeChallengeTypeHTTP01    = "http-01"
+	acmeChallengeTypeTLSALPN01 = "tls-alpn-01"
+)
+
+type acmeIdentifier struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// acmeOrderDoc is an ACME order, RFC 8555 section 7.1.3, authorizations are referenced by ID
+type acmeOrderDoc struct {
+	resdoc.ResourceDoc
+	AccountID      string             `json:"accountId"`
+	Status         string             `json:"status"`
+	NotAfter       resdoc.NumericDate `json:"exp"`
+	Identifiers    []acmeIdentifier   `json:"identifiers"`
+	Authorizations []string           `json:"authorizations"`
+	CertificateID  string             `json:"certificateId,omitempty"`
+	Error          *acmeProblem       `json:"error,omitempty"`
+}
+
+type acmeOrderResource struct {
+	Status         string           `json:"status"`
+	Expires        time.Time        `json:"expires"`
+	Identifiers    []acmeIdentifier `json:"identifiers"`
+	Authorizations []string         `json:"authorizations"`
+	Finalize       string           `json:"finalize"`
+	Certificate    string           `json:"certificate,omitempty"`
+	Error          *acmeProblem     `json:"error,omitempty"`
+}
+
+func (d *acmeDirectory) toOrderResource(order *acmeOrderDoc) *acmeOrderResource {
+	r := &acmeOrderResource{
+		Status:         order.Status,
+		Expires:        order.NotAfter.Time,
+		Identifiers:    order.Identifiers,
+		Authorizations: make([]string, len(order.Authorizations)),
+		Finalize:       d.url("orders", order.ID, "finalize"),
+		Error:          order.Error,
+	}
+	for i, authzID := range order.Authorizations {
+		r.Authorizations[i] = d.url("authz", authzID)
+	}
+	if order.CertificateID != "" {
+		r.Certificate = d.url("certificates", order.CertificateID)
+	}
+	return r
+}
+
+type acmeChallenge struct {
+	Type      string              `json:"type"`
+	Token     string              `json:"token"`
+	Status    string              `json:"status"`
+	Validated *resdoc.NumericDate `json:"validated,omitempty"`
+	Error     *acmeProblem        `json:"error,omitempty"`
+}
+
+// acmeAuthorizationDoc is an ACME authorization of an identifier of an order, RFC 8555 section 7.1.4
+type acmeAuthorizationDoc struct {
+	resdoc.ResourceDoc
+	AccountID      string             `json:"accountId"`
+	OrderID        string             `json:"orderId"`
+	Status         string             `json:"status"`
+	NotAfter       resdoc.NumericDate `json:"exp"`
+	ACMEIdentifier acmeIdentifier     `json:"identifier"`
+	Challenges     []acmeChallenge    `json:"challenges"`
+}
+
+type acmeChallengeResource struct {
+	Type      string       `json:"type"`
+	URL       string       `json:"url"`
+	Token     string       `json:"token"`
+	Status    string       `json:"status"`
+	Validated *time.Time   `json:"validated,omitempty"`
+	Error     *acmeProblem `json:"error,omitempty"`
+}
+
+type acmeAuthorizationResource struct {
+	Status     string                  `json:"status"`
+	Expires    time.Time               `json:"expires"`
+	Identifier acmeIdentifier          `json:"identifier"`
+	Challenges []acmeChallengeResource `json:"challenges"`
+}
+
+func (d *acmeDirectory) toChallengeResource(authz *acmeAuthorizationDoc, challenge *acmeChallenge) acmeChallengeResource {
+	r := acmeChallengeResource{
+		Type:   challenge.Type,
+		URL:    d.url("authz", authz.ID, challenge.Type),
+		Token:  challenge.Token,
+		Status: challenge.Status,
+		Error:  challenge.Error,
+	}
+	if challenge.Validated != nil {
+		r.Validated = &challenge.Validated.Time
+	}
+	return r
+}
+
+func (d *acmeDirectory) toAuthorizationResource(authz *acmeAuthorizationDoc) *acmeAuthorizationResource {
+	r := &acmeAuthorizationResource{
+		Status:     authz.Status,
+		Expires:    authz.NotAfter.Time,
+		Identifier: authz.ACMEIdentifier,
+		Challenges: make([]acmeChallengeResource, len(authz.Challenges)),
+	}
+	for i := range authz.Challenges {
+		r.Challenges[i] = d.toChallengeResource(authz, &authz.Challenges[i])
+	}
+	return r
+}
+
+// getOrder reads an order of the account, pending and ready orders past their expiry are reported as invalid
+func (d *acmeDirectory) getOrder(c ctx.RequestContext, account *acmeAccountDoc, orderID string) (*acmeOrderDoc, error) {
+	doc := &acmeOrderDoc{}
+	if err := resdoc.GetDocService(c).Read(c, d.docIdentifier(models.ResourceProviderACMEOrder, orderID), doc, nil); err != nil {
+		if errors.Is(err, resdoc.ErrAzCosmosDocNotFound) {
+			return nil, newACMEProblem(acmeErrorMalformed, http.StatusNotFound, "order not found")
+		}
+		return nil, err
+	}
+	if doc.AccountID != account.ID {
+		return nil, newACMEProblem(acmeErrorUnauthorized, http.StatusForbidden, "order does not belong to the account")
+	}
+	switch doc.Status {
+	case acme.StatusPending, acme.StatusReady:
+		if time.Now().After(doc.NotAfter.Time) {
+			doc.Status = acme.StatusInvalid
+		}
+	}
+	return doc, nil
+}
+
+// getAuthorization reads an authorization of the account, pending and valid authorizations past their expiry are reported as expired
+func (d *acmeDirectory) getAuthorization(c ctx.RequestContext, account *acmeAccountDoc, authzID string) (*acmeAuthorizationDoc, error) {
+	doc := &acmeAuthorizationDoc{}
+	if err := resdoc.GetDocService(c).Read(c, d.docIdentifier(models.ResourceProviderACMEAuthorization, authzID), doc, nil); err != nil {
+		if errors.Is(err, resdoc.ErrAzCosmosDocNotFound) {
+			return nil, newACMEProblem(acmeErrorMalformed, http.StatusNotFound, "authorization not found")
+		}
+		return nil, err
+	}
+	if doc.AccountID != account.ID {
+		return nil, newACMEProblem(acmeErrorUnauthorized, http.StatusForbidden, "authorization does not belong to the account")
+	}
+	switch doc.Status {
+	case acme.StatusPending, acme.StatusValid:
+		if time.Now().After(doc.NotAfter.Time) {
+			doc.Status = acme.StatusExpired
+		}
+	}
+	return doc, nil
+}
+
+// refreshOrderStatus moves a pending order to ready once every authorization is valid,
+// or to invalid if any authorization has failed, RFC 8555 section 7.1.6
+func (d *acmeDirectory) refreshOrderStatus(c ctx.RequestContext, account *acmeAccountDoc, orderID string) error {
+	order, err := d.getOrder(c, account, orderID)
+	if err != nil {
+		return err
+	}
+	if order.Status != acme.StatusPending {
+		return nil
+	}
+	status := acme.StatusReady
+	for _, authzID := range order.Authorizations {
+		authz, err := d.getAuthorization(c, account, authzID)
+		if err != nil {
+			return err
+		}
+		switch authz.Status {
+		case acme.StatusValid:
+		case acme.StatusPending:
+			if status == acme.StatusReady {
+				status = acme.StatusPending
+			}
+		default:
+			status = acme.StatusInvalid
+		}
+	}
+	if status == acme.StatusPending {
+		return nil
+	}
+	patchOps := azcosmos.PatchOperations{}
+	patchOps.AppendSet("/status", status)
+	if _, err = resdoc.GetDocService(c).Patch(c, order, patchOps, &azcosmos.ItemOptions{
+		IfMatchEtag: order.GetETag(),
+	}); isACMEPreconditionFailed(err) {
+		// refreshed by a concurrent request
+		return nil
+	}
+	return err
+}
+
+// isACMEPreconditionFailed returns true if a conditional update lost to a concurrent request
+func isACMEPreconditionFailed(err error) bool {
+	var respErr *azcore.ResponseError
+	return errors.As(err, &respErr) && respErr.StatusCode == http.StatusPreconditionFailed
+}
@@ -0,0 +1,145 @@
+package cert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
+	"github.com/rs/zerolog/log"
+	"github.com/stephenzsy/small-kms/backend/base"
+	ctx "github.com/stephenzsy/small-kms/backend/internal/context"
+	"github.com/stephenzsy/small-kms/backend/models"
+	certmodels "github.com/stephenzsy/small-kms/backend/models/cert"
+	"github.com/stephenzsy/small-kms/backend/resdoc"
+	"github.com/stephenzsy/small-kms/backend/taskmanager"
+	"github.com/stephenzsy/small-kms/backend/utils"
+	"golang.org/x/crypto/acme"
+)
+
+const acmeOrderPollInterval = 30 * time.Second
+
+// pending orders of all external issuers share one link partition,
+// so the poller can find them without a cross partition query
+var acmePendingOrderLinkPartitionKey = resdoc.PartitionKey{
+	NamespaceProvider: models.NamespaceProviderExternalCA,
+	NamespaceID:       "default",
+	ResourceProvider:  models.ResourceProviderLink,
+}
+
+func getACMEPendingOrderLinkID(certDoc resdoc.DocIdentifier) string {
+	return fmt.Sprintf("%s-%s-%s-%s", models.LinkProviderACMEPendingOrder, certDoc.NamespaceProvider, certDoc.NamespaceID, certDoc.ID)
+}
+
+// registerACMEPendingOrderInternal schedules the order of the certificate to be finalized in the background
+func registerACMEPendingOrderInternal(c context.Context, certDoc *certDocACME) error {
+	doc := &resdoc.LinkResourceDoc{
+		ResourceDoc: resdoc.ResourceDoc{
+			PartitionKey: acmePendingOrderLinkPartitionKey,
+			ID:           getACMEPendingOrderLinkID(certDoc.Identifier()),
+		},
+		LinkTo:       certDoc.Identifier(),
+		LinkProvider: models.LinkProviderACMEPendingOrder,
+	}
+	_, err := resdoc.GetDocService(c).Upsert(c, doc, nil)
+	return err
+}
+
+func listACMEPendingOrderLinksInternal(c context.Context) ([]*resdoc.LinkResourceDoc, error) {
+	qb := resdoc.NewDefaultCosmoQueryBuilder().
+		WithExtraColumns(certIssuedLinkQueryColLinkTo).
+		WithWhereClauses("c.linkProvider = @linkProvider")
+	qb.Parameters = append(qb.Parameters,
+		azcosmos.QueryParameter{Name: "@linkProvider", Value: models.LinkProviderACMEPendingOrder})
+	pager := resdoc.NewQueryDocPager[*resdoc.LinkResourceDoc](c, qb, acmePendingOrderLinkPartitionKey)
+	return utils.PagerToSlice[*resdoc.LinkResourceDoc](pager)
+}
+
+// pollACMEPendingOrder returns true once the order no longer needs to be polled
+func pollACMEPendingOrder(c ctx.RequestContext, certIdentifier resdoc.DocIdentifier) (bool, error) {
+	certDoc := &certDocACME{}
+	if err := readCertDocInternal(c, certIdentifier.NamespaceProvider, certIdentifier.NamespaceID, certIdentifier.ID, certDoc); err != nil {
+		if errors.Is(err, base.ErrResponseStatusNotFound) {
+			return true, nil
+		}
+		return false, err
+	}
+	if certDoc.Status != certmodels.CertificateStatusPendingAuthorization {
+		// completed manually
+		return true, nil
+	}
+	if err := certDoc.restore(c); err != nil {
+		return false, err
+	}
+	order, err := certDoc.acmeClient.GetOrder(c, certDoc.OrderURL)
+	if err != nil {
+		return false, err
+	}
+	switch order.Status {
+	case acme.StatusPending, acme.StatusProcessing:
+		return false, nil
+	case acme.StatusReady, acme.StatusValid:
+		if err := certDoc.finalize(c); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+
+	// invalid, the order can no longer be fulfilled
+	log.Ctx(c).Warn().Str("order", order.URI).Str("status", order.Status).Str("cert", certIdentifier.String()).Msg("ACME order failed")
+	certDoc.Status = certmodels.CertificateStatusDeactivated
+	if _, err := resdoc.GetDocService(c).Upsert(c, certDoc, &azcosmos.ItemOptions{
+		IfMatchEtag: certDoc.ETag,
+	}); err != nil {
+		return false, err
+	}
+	certDoc.cleanUpChallenges(c)
+	return true, nil
+}
+
+type acmeOrderPollTaskExecutor struct {
+	serviceContext context.Context
+}
+
+// Close implements taskmanager.IntervalExecutor.
+func (*acmeOrderPollTaskExecutor) Close(context.Context) error {
+	return nil
+}
+
+// Execute implements taskmanager.IntervalExecutor.
+func (e *acmeOrderPollTaskExecutor) Execute(c context.Context) (time.Duration, error) {
+	logger := log.Ctx(c)
+	rc := ctx.NewBackgroundRequestContext(c, e.serviceContext)
+	links, err := listACMEPendingOrderLinksInternal(rc)
+	if err != nil {
+		return acmeOrderPollInterval, err
+	}
+	for _, link := range links {
+		done, err := pollACMEPendingOrder(rc, link.LinkTo)
+		if err != nil {
+			logger.Error().Err(err).Str("cert", link.LinkTo.String()).Msg("failed to poll ACME order")
+			continue
+		}
+		if done {
+			if _, err := resdoc.GetDocService(rc).Delete(rc, link.Identifier(), nil); err != nil {
+				logger.Error().Err(resdoc.HandleAzCosmosError(err)).Str("link", link.ID).Msg("failed to delete ACME pending order link")
+			}
+		}
+	}
+	return acmeOrderPollInterval, nil
+}
+
+// Name implements taskmanager.IntervalExecutor.
+func (*acmeOrderPollTaskExecutor) Name() string {
+	return "ACMEOrderPoller"
+}
+
+var _ taskmanager.IntervalExecutor = (*acmeOrderPollTaskExecutor)(nil)
+
+// NewACMEOrderPollTaskExecutor polls the pending orders of external ACME issuers and finalizes them once authorized
+func NewACMEOrderPollTaskExecutor(serviceContext context.Context) taskmanager.IntervalExecutor {
+	return &acmeOrderPollTaskExecutor{
+		serviceContext: serviceContext,
+	}
+}
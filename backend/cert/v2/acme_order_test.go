@@ -0,0 +1,28 @@
+package cert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSanitizeACMEIdentifiers(t *testing.T) {
+	identifiers, err := sanitizeACMEIdentifiers([]acmeIdentifier{
+		{Type: acmeIdentifierTypeDNS, Value: "WWW.example.com"},
+		{Type: acmeIdentifierTypeDNS, Value: "example.com"},
+		{Type: acmeIdentifierTypeDNS, Value: "www.example.com"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []acmeIdentifier{
+		{Type: acmeIdentifierTypeDNS, Value: "example.com"},
+		{Type: acmeIdentifierTypeDNS, Value: "www.example.com"},
+	}, identifiers)
+
+	for _, value := range []string{"*.example.com", "10.0.0.1", "localhost", "-a.example.com", "a..example.com"} {
+		_, err = sanitizeACMEIdentifiers([]acmeIdentifier{{Type: acmeIdentifierTypeDNS, Value: value}})
+		assert.Error(t, err, value)
+	}
+	_, err = sanitizeACMEIdentifiers([]acmeIdentifier{{Type: "ip", Value: "10.0.0.1"}})
+	assert.Error(t, err)
+}
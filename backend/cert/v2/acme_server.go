@@ -0,0 +1,204 @@
+package cert
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+	"github.com/stephenzsy/small-kms/backend/api"
+	"github.com/stephenzsy/small-kms/backend/base"
+	ctx "github.com/stephenzsy/small-kms/backend/internal/context"
+	"github.com/stephenzsy/small-kms/backend/models"
+	"github.com/stephenzsy/small-kms/backend/resdoc"
+)
+
+// RFC 8555 section 6.7 error types
+const (
+	acmeErrorAccountDoesNotExist     = "urn:ietf:params:acme:error:accountDoesNotExist"
+	acmeErrorAlreadyRevoked          = "urn:ietf:params:acme:error:alreadyRevoked"
+	acmeErrorBadCSR                  = "urn:ietf:params:acme:error:badCSR"
+	acmeErrorBadNonce                = "urn:ietf:params:acme:error:badNonce"
+	acmeErrorBadPublicKey            = "urn:ietf:params:acme:error:badPublicKey"
+	acmeErrorBadRevocationReason     = "urn:ietf:params:acme:error:badRevocationReason"
+	acmeErrorBadSignatureAlgorithm   = "urn:ietf:params:acme:error:badSignatureAlgorithm"
+	acmeErrorConnection              = "urn:ietf:params:acme:error:connection"
+	acmeErrorExternalAccountRequired = "urn:ietf:params:acme:error:externalAccountRequired"
+	acmeErrorIncorrectResponse       = "urn:ietf:params:acme:error:incorrectResponse"
+	acmeErrorInvalidContact          = "urn:ietf:params:acme:error:invalidContact"
+	acmeErrorMalformed               = "urn:ietf:params:acme:error:malformed"
+	acmeErrorOrderNotReady           = "urn:ietf:params:acme:error:orderNotReady"
+	acmeErrorRejectedIdentifier      = "urn:ietf:params:acme:error:rejectedIdentifier"
+	acmeErrorServerInternal          = "urn:ietf:params:acme:error:serverInternal"
+	acmeErrorTLS                     = "urn:ietf:params:acme:error:tls"
+	acmeErrorUnauthorized            = "urn:ietf:params:acme:error:unauthorized"
+	acmeErrorUnsupportedIdentifier   = "urn:ietf:params:acme:error:unsupportedIdentifier"
+)
+
+const (
+	contentTypeACMEProblem   = "application/problem+json"
+	contentTypeACMEJWS       = "application/jose+json"
+	contentTypePEMCertChain  = "application/pem-certificate-chain"
+	acmeHeaderReplayNonce    = "Replay-Nonce"
+	acmeRequestMaxBodySize   = 64 * 1024
+	acmeDirectoryPathSegment = "acme"
+)
+
+// acmeProblem is a problem document, RFC 8555 section 6.7, ACME handlers return it as error
+type acmeProblem struct {
+	Type   string `json:"type"`
+	Detail string `json:"detail,omitempty"`
+	Status int    `json:"status,omitempty"`
+}
+
+// Error implements error.
+func (p *acmeProblem) Error() string {
+	return fmt.Sprintf("%s: %s", p.Type, p.Detail)
+}
+
+func newACMEProblem(problemType string, status int, format string, args ...any) *acmeProblem {
+	return &acmeProblem{
+		Type:   problemType,
+		Detail: fmt.Sprintf(format, args...),
+		Status: status,
+	}
+}
+
+type acmeDirectoryMeta struct {
+	ExternalAccountRequired bool `json:"externalAccountRequired"`
+}
+
+type acmeDirectoryResource struct {
+	NewNonce   string            `json:"newNonce"`
+	NewAccount string            `json:"newAccount"`
+	NewOrder   string            `json:"newOrder"`
+	RevokeCert string            `json:"revokeCert"`
+	Meta       acmeDirectoryMeta `json:"meta"`
+}
+
+// acmeDirectory is the ACME server of an intermediate CA certificate policy, certificates are signed
+// by the current issuer certificate of the policy
+type acmeDirectory struct {
+	policy  *CertPolicyDoc
+	baseURL string
+}
+
+func getACMEDirectoryURL(c ctx.RequestContext, policy resdoc.DocIdentifier) string {
+	baseURL := api.GetPublicBaseURL(c)
+	if baseURL == "" {
+		baseURL = fmt.Sprintf("%s://%s", c.Scheme(), c.Request().Host)
+	}
+	return fmt.Sprintf("%s/v2/%s/%s/certificate-policies/%s/%s", baseURL,
+		policy.NamespaceProvider, policy.NamespaceID, policy.ID, acmeDirectoryPathSegment)
+}
+
+func loadACMEDirectory(c ctx.RequestContext, namespaceProvider models.NamespaceProvider, namespaceId string, policyID string) (*acmeDirectory, error) {
+	if namespaceProvider != models.NamespaceProviderIntermediateCA {
+		return nil, newACMEProblem(acmeErrorMalformed, http.StatusNotFound, "ACME is only served by intermediate CA certificate policies")
+	}
+	policy, err := GetCertificatePolicyInternal(c, namespaceProvider, namespaceId, policyID)
+	if err != nil {
+		return nil, err
+	}
+	return &acmeDirectory{
+		policy:  policy,
+		baseURL: getACMEDirectoryURL(c, policy.Identifier()),
+	}, nil
+}
+
+// url returns the absolute URL of a resource of the directory
+func (d *acmeDirectory) url(elem ...string) string {
+	return d.baseURL + "/" + strings.Join(elem, "/")
+}
+
+func (d *acmeDirectory) partitionKey(resourceProvider models.ResourceProvider) resdoc.PartitionKey {
+	return resdoc.PartitionKey{
+		NamespaceProvider: d.policy.PartitionKey.NamespaceProvider,
+		NamespaceID:       d.policy.PartitionKey.NamespaceID,
+		ResourceProvider:  resourceProvider,
+	}
+}
+
+func (d *acmeDirectory) docIdentifier(resourceProvider models.ResourceProvider, id string) resdoc.DocIdentifier {
+	return resdoc.DocIdentifier{
+		PartitionKey: d.partitionKey(resourceProvider),
+		ID:           id,
+	}
+}
+
+// serveACME writes errors as ACME problem documents, responses carry a fresh nonce if issueNonce is set
+func serveACME(ec echo.Context, namespaceProvider models.NamespaceProvider, namespaceId string, policyID string, issueNonce bool,
+	handler func(c ctx.RequestContext, dir *acmeDirectory) error) error {
+	c := ec.(ctx.RequestContext)
+
+	// anonymous endpoint, requests are authenticated by the JWS signature of the account key
+	c = c.Elevate()
+	err := func() error {
+		dir, err := loadACMEDirectory(c, namespaceProvider, namespaceId, policyID)
+		if err != nil {
+			return err
+		}
+		header := c.Response().Header()
+		header.Set(echo.HeaderCacheControl, "no-store")
+		header.Set("Link", fmt.Sprintf(`<%s>;rel="index"`, dir.url("directory")))
+		if issueNonce {
+			nonce, err := dir.newNonce(c)
+			if err != nil {
+				return err
+			}
+			header.Set(acmeHeaderReplayNonce, nonce)
+		}
+		return handler(c, dir)
+	}()
+	if err == nil || c.Response().Committed {
+		return err
+	}
+
+	var problem *acmeProblem
+	if !errors.As(err, &problem) {
+		var respErr *base.HttpResponseError
+		if errors.As(err, &respErr) {
+			problem = newACMEProblem(acmeErrorMalformed, respErr.StatusCode, "%s", err.Error())
+		} else {
+			log.Ctx(c).Error().Err(err).Msg("ACME request failed")
+			problem = newACMEProblem(acmeErrorServerInternal, http.StatusInternalServerError, "internal error")
+		}
+	}
+	body, err := json.Marshal(problem)
+	if err != nil {
+		return err
+	}
+	return c.Blob(problem.Status, contentTypeACMEProblem, body)
+}
+
+// GetACMEDirectory implements admin.ServerInterface.
+func (*CertServer) GetACMEDirectory(ec echo.Context, namespaceProvider models.NamespaceProvider, namespaceId string, id string) error {
+	return serveACME(ec, namespaceProvider, namespaceId, id, false, func(c ctx.RequestContext, dir *acmeDirectory) error {
+		return c.JSON(http.StatusOK, &acmeDirectoryResource{
+			NewNonce:   dir.url("new-nonce"),
+			NewAccount: dir.url("new-account"),
+			NewOrder:   dir.url("new-order"),
+			RevokeCert: dir.url("revoke-cert"),
+			Meta: acmeDirectoryMeta{
+				ExternalAccountRequired: true,
+			},
+		})
+	})
+}
+
+// GetACMENonce implements admin.ServerInterface.
+func (*CertServer) GetACMENonce(ec echo.Context, namespaceProvider models.NamespaceProvider, namespaceId string, id string) error {
+	return serveACME(ec, namespaceProvider, namespaceId, id, true, func(c ctx.RequestContext, _ *acmeDirectory) error {
+		return c.NoContent(http.StatusNoContent)
+	})
+}
+
+// HeadACMENonce implements admin.ServerInterface.
+func (*CertServer) HeadACMENonce(ec echo.Context, namespaceProvider models.NamespaceProvider, namespaceId string, id string) error {
+	return serveACME(ec, namespaceProvider, namespaceId, id, true, func(c ctx.RequestContext, _ *acmeDirectory) error {
+		return c.NoContent(http.StatusOK)
+	})
+}
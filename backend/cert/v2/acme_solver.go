@@ -0,0 +1,112 @@
+package cert
+
+import (
+	"context"
+	"errors"
+
+	"github.com/stephenzsy/small-kms/backend/models"
+	"github.com/stephenzsy/small-kms/backend/resdoc"
+	"golang.org/x/crypto/acme"
+)
+
+// acmeChallengeSolver provisions the response to an ACME challenge of an external issuer
+type acmeChallengeSolver interface {
+	ChallengeType() string
+	Present(c context.Context, client *acme.Client, challenge *acme.Challenge, domain string) error
+	CleanUp(c context.Context, client *acme.Client, challenge *acme.Challenge, domain string) error
+}
+
+// acmeHTTP01ChallengeDoc is served by the agent instances of the service principal at
+// /.well-known/acme-challenge/{token}
+type acmeHTTP01ChallengeDoc struct {
+	resdoc.ResourceDoc
+	Domain           string `json:"domain"`
+	KeyAuthorization string `json:"keyAuthorization"`
+}
+
+type acmeHTTP01AgentSolver struct {
+	agentNamespaceID string
+}
+
+// ChallengeType implements acmeChallengeSolver.
+func (*acmeHTTP01AgentSolver) ChallengeType() string {
+	return acmeChallengeTypeHTTP01
+}
+
+func (s *acmeHTTP01AgentSolver) docIdentifier(token string) resdoc.DocIdentifier {
+	return resdoc.NewDocIdentifier(models.NamespaceProviderServicePrincipal, s.agentNamespaceID, models.ResourceProviderACMEHTTP01Challenge, token)
+}
+
+// Present implements acmeChallengeSolver.
+func (s *acmeHTTP01AgentSolver) Present(c context.Context, client *acme.Client, challenge *acme.Challenge, domain string) error {
+	keyAuthorization, err := client.HTTP01ChallengeResponse(challenge.Token)
+	if err != nil {
+		return err
+	}
+	doc := &acmeHTTP01ChallengeDoc{
+		ResourceDoc: resdoc.ResourceDoc{
+			PartitionKey: resdoc.PartitionKey{
+				NamespaceProvider: models.NamespaceProviderServicePrincipal,
+				NamespaceID:       s.agentNamespaceID,
+				ResourceProvider:  models.ResourceProviderACMEHTTP01Challenge,
+			},
+			ID: challenge.Token,
+		},
+		Domain:           domain,
+		KeyAuthorization: keyAuthorization,
+	}
+	_, err = resdoc.GetDocService(c).Upsert(c, doc, nil)
+	return err
+}
+
+// CleanUp implements acmeChallengeSolver.
+func (s *acmeHTTP01AgentSolver) CleanUp(c context.Context, client *acme.Client, challenge *acme.Challenge, domain string) error {
+	_, err := resdoc.GetDocService(c).Delete(c, s.docIdentifier(challenge.Token), nil)
+	if err != nil {
+		if err = resdoc.HandleAzCosmosError(err); errors.Is(err, resdoc.ErrAzCosmosDocNotFound) {
+			return nil
+		}
+	}
+	return err
+}
+
+var _ acmeChallengeSolver = (*acmeHTTP01AgentSolver)(nil)
+
+type acmeDNS01RFC2136Solver struct {
+	provider  *rfc2136Provider
+	getSecret func(c context.Context) (string, error)
+}
+
+// ChallengeType implements acmeChallengeSolver.
+func (*acmeDNS01RFC2136Solver) ChallengeType() string {
+	return acmeChallengeTypeDNS01
+}
+
+func (s *acmeDNS01RFC2136Solver) update(c context.Context, client *acme.Client, challenge *acme.Challenge, domain string, insert bool) error {
+	value, err := client.DNS01ChallengeRecord(challenge.Token)
+	if err != nil {
+		return err
+	}
+	p := *s.provider
+	if s.getSecret != nil {
+		if p.tsigSecret, err = s.getSecret(c); err != nil {
+			return err
+		}
+	}
+	if insert {
+		return p.present(c, acmeDNS01RecordName(domain), value)
+	}
+	return p.cleanUp(c, acmeDNS01RecordName(domain), value)
+}
+
+// Present implements acmeChallengeSolver.
+func (s *acmeDNS01RFC2136Solver) Present(c context.Context, client *acme.Client, challenge *acme.Challenge, domain string) error {
+	return s.update(c, client, challenge, domain, true)
+}
+
+// CleanUp implements acmeChallengeSolver.
+func (s *acmeDNS01RFC2136Solver) CleanUp(c context.Context, client *acme.Client, challenge *acme.Challenge, domain string) error {
+	return s.update(c, client, challenge, domain, false)
+}
+
+var _ acmeChallengeSolver = (*acmeDNS01RFC2136Solver)(nil)
@@ -0,0 +1,104 @@
+package cert
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+const (
+	acmeDNS01RecordTTL      = 60
+	rfc2136DefaultPort      = "53"
+	rfc2136UpdateTimeout    = 10 * time.Second
+	rfc2136TSIGFudgeSeconds = 300
+)
+
+var rfc2136TSIGAlgorithms = map[string]string{
+	"hmac-sha1":   dns.HmacSHA1,
+	"hmac-sha224": dns.HmacSHA224,
+	"hmac-sha256": dns.HmacSHA256,
+	"hmac-sha384": dns.HmacSHA384,
+	"hmac-sha512": dns.HmacSHA512,
+}
+
+// normalizeTSIGAlgorithm returns the fully qualified algorithm name, defaults to hmac-sha256
+func normalizeTSIGAlgorithm(alg string) (string, bool) {
+	if alg == "" {
+		return dns.HmacSHA256, true
+	}
+	v, ok := rfc2136TSIGAlgorithms[strings.TrimSuffix(strings.ToLower(alg), ".")]
+	return v, ok
+}
+
+// acmeDNS01RecordName returns the name of the TXT record of the dns-01 challenge, RFC 8555 section 8.4
+func acmeDNS01RecordName(domain string) string {
+	return dns.Fqdn("_acme-challenge." + strings.TrimPrefix(domain, "*."))
+}
+
+// rfc2136Provider sends dynamic updates to the primary nameserver of the zone, RFC 2136 and RFC 8945 (TSIG)
+type rfc2136Provider struct {
+	nameserver    string
+	zone          string
+	tsigKeyName   string
+	tsigAlgorithm string
+	tsigSecret    string
+}
+
+func (p *rfc2136Provider) present(c context.Context, fqdn string, value string) error {
+	return p.update(c, fqdn, value, true)
+}
+
+func (p *rfc2136Provider) cleanUp(c context.Context, fqdn string, value string) error {
+	return p.update(c, fqdn, value, false)
+}
+
+func (p *rfc2136Provider) update(c context.Context, fqdn string, value string, insert bool) error {
+	zone := dns.Fqdn(p.zone)
+	if !dns.IsSubDomain(zone, fqdn) {
+		return fmt.Errorf("record %s is not in zone %s", fqdn, zone)
+	}
+	rr := []dns.RR{&dns.TXT{
+		Hdr: dns.RR_Header{
+			Name:   fqdn,
+			Rrtype: dns.TypeTXT,
+			Class:  dns.ClassINET,
+			Ttl:    acmeDNS01RecordTTL,
+		},
+		Txt: []string{value},
+	}}
+	m := new(dns.Msg)
+	m.SetUpdate(zone)
+	if insert {
+		m.Insert(rr)
+	} else {
+		m.Remove(rr)
+	}
+
+	client := &dns.Client{Timeout: rfc2136UpdateTimeout}
+	if p.tsigKeyName != "" {
+		alg, ok := normalizeTSIGAlgorithm(p.tsigAlgorithm)
+		if !ok {
+			return fmt.Errorf("unsupported TSIG algorithm: %s", p.tsigAlgorithm)
+		}
+		keyName := dns.Fqdn(p.tsigKeyName)
+		m.SetTsig(keyName, alg, rfc2136TSIGFudgeSeconds, time.Now().Unix())
+		client.TsigSecret = map[string]string{keyName: p.tsigSecret}
+	}
+
+	nameserver := p.nameserver
+	if _, _, err := net.SplitHostPort(nameserver); err != nil {
+		nameserver = net.JoinHostPort(nameserver, rfc2136DefaultPort)
+	}
+	resp, _, err := client.ExchangeContext(c, m, nameserver)
+	if err != nil {
+		return err
+	}
+	if resp.Rcode != dns.RcodeSuccess {
+		return fmt.Errorf("dynamic update of %s failed: %s", fqdn, dns.RcodeToString[resp.Rcode])
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package cert
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testTSIGKeyName = "acme-update."
+	testTSIGSecret  = "c2VjcmV0LXNlY3JldC1zZWNyZXQtc2VjcmV0LXNlY3JldA=="
+)
+
+type testRFC2136Server struct {
+	mu      sync.Mutex
+	records map[string][]string
+}
+
+func (s *testRFC2136Server) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
+	m := new(dns.Msg)
+	m.SetReply(r)
+	if r.Opcode != dns.OpcodeUpdate || r.IsTsig() == nil || w.TsigStatus() != nil {
+		m.Rcode = dns.RcodeRefused
+	} else {
+		s.mu.Lock()
+		for _, rr := range r.Ns {
+			txt, ok := rr.(*dns.TXT)
+			if !ok {
+				continue
+			}
+			name := txt.Hdr.Name
+			switch txt.Hdr.Class {
+			case dns.ClassINET:
+				s.records[name] = append(s.records[name], txt.Txt...)
+			case dns.ClassNONE:
+				remaining := s.records[name][:0]
+				for _, v := range s.records[name] {
+					if v != txt.Txt[0] {
+						remaining = append(remaining, v)
+					}
+				}
+				s.records[name] = remaining
+			}
+		}
+		s.mu.Unlock()
+	}
+	if tsig := r.IsTsig(); tsig != nil {
+		m.SetTsig(testTSIGKeyName, dns.HmacSHA256, rfc2136TSIGFudgeSeconds, int64(tsig.TimeSigned))
+	}
+	w.WriteMsg(m)
+}
+
+func startTestRFC2136Server(t *testing.T) (*testRFC2136Server, string) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	handler := &testRFC2136Server{records: map[string][]string{}}
+	started := make(chan struct{})
+	server := &dns.Server{
+		PacketConn:        pc,
+		Handler:           handler,
+		TsigSecret:        map[string]string{testTSIGKeyName: testTSIGSecret},
+		NotifyStartedFunc: func() { close(started) },
+		// the default accept func rejects dynamic updates
+		MsgAcceptFunc: func(dh dns.Header) dns.MsgAcceptAction {
+			return dns.MsgAccept
+		},
+	}
+	go server.ActivateAndServe()
+	<-started
+	t.Cleanup(func() { server.Shutdown() })
+	return handler, pc.LocalAddr().String()
+}
+
+func TestRFC2136ProviderUpdate(t *testing.T) {
+	server, addr := startTestRFC2136Server(t)
+	p := &rfc2136Provider{
+		nameserver:  addr,
+		zone:        "example.com",
+		tsigKeyName: "acme-update",
+		tsigSecret:  testTSIGSecret,
+	}
+	fqdn := acmeDNS01RecordName("www.example.com")
+	assert.Equal(t, "_acme-challenge.www.example.com.", fqdn)
+
+	require.NoError(t, p.present(context.Background(), fqdn, "value"))
+	assert.Equal(t, []string{"value"}, server.records[fqdn])
+	require.NoError(t, p.cleanUp(context.Background(), fqdn, "value"))
+	assert.Empty(t, server.records[fqdn])
+
+	assert.Error(t, p.present(context.Background(), acmeDNS01RecordName("www.example.org"), "value"))
+
+	p.tsigSecret = "d3Jvbmc="
+	assert.Error(t, p.present(context.Background(), fqdn, "value"))
+
+	p.tsigSecret = testTSIGSecret
+	p.tsigAlgorithm = "hmac-md5"
+	assert.Error(t, p.present(context.Background(), fqdn, "value"))
+}
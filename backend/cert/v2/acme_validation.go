@@ -0,0 +1,137 @@
+package cert
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/stephenzsy/small-kms/backend/internal/netguard"
+)
+
+const (
+	acmeValidationTimeout     = 10 * time.Second
+	acmeHTTP01MaxResponseSize = 4 * 1024
+	acmeTLSALPNProtocol       = "acme-tls/1"
+)
+
+// id-pe-acmeIdentifier, RFC 8737 section 6.1
+var oidExtensionACMEIdentifier = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 1, 31}
+
+// checkACMEValidationAddress rejects connections to addresses other than public addresses on the challenge ports,
+// so a DNS name or a redirect cannot point the validation to the internal network
+var checkACMEValidationAddress = netguard.PublicAddressControl(80, 443)
+
+func newACMEValidationDialer() *net.Dialer {
+	return &net.Dialer{
+		Timeout: acmeValidationTimeout,
+		Control: checkACMEValidationAddress,
+	}
+}
+
+// checkACMEValidationRedirect only follows redirects to the challenge ports over HTTP or HTTPS, RFC 8555 section 8.3
+func checkACMEValidationRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= 10 {
+		return errors.New("too many redirects")
+	}
+	if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+		return fmt.Errorf("redirect to scheme %s is not allowed", req.URL.Scheme)
+	}
+	if port := req.URL.Port(); port != "" && port != "80" && port != "443" {
+		return fmt.Errorf("redirect to port %s is not allowed", port)
+	}
+	return nil
+}
+
+// validateHTTP01Challenge fetches the key authorization from the host, RFC 8555 section 8.3
+func validateHTTP01Challenge(c context.Context, hostport string, token string, keyAuthorization string) *acmeProblem {
+	c, cancel := context.WithTimeout(c, acmeValidationTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(c, http.MethodGet, "http://"+hostport+"/.well-known/acme-challenge/"+token, nil)
+	if err != nil {
+		return newACMEProblem(acmeErrorMalformed, http.StatusBadRequest, "invalid challenge URL")
+	}
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: newACMEValidationDialer().DialContext,
+			// the challenge may redirect to HTTPS on hosts that do not have a certificate yet
+			TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+			DisableKeepAlives: true,
+		},
+		CheckRedirect: checkACMEValidationRedirect,
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return newACMEProblem(acmeErrorConnection, http.StatusBadRequest, "failed to fetch challenge response: %s", err.Error())
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return newACMEProblem(acmeErrorIncorrectResponse, http.StatusForbidden, "challenge response status: %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, acmeHTTP01MaxResponseSize))
+	if err != nil {
+		return newACMEProblem(acmeErrorConnection, http.StatusBadRequest, "failed to read challenge response: %s", err.Error())
+	}
+	if string(bytes.TrimSpace(body)) != keyAuthorization {
+		return newACMEProblem(acmeErrorIncorrectResponse, http.StatusForbidden, "key authorization does not match")
+	}
+	return nil
+}
+
+// validateTLSALPN01Challenge checks the self-signed certificate served for the acme-tls/1 protocol, RFC 8737 section 3
+func validateTLSALPN01Challenge(c context.Context, hostport string, domain string, keyAuthorization string) *acmeProblem {
+	dialer := &tls.Dialer{
+		NetDialer: newACMEValidationDialer(),
+		Config: &tls.Config{
+			ServerName: domain,
+			NextProtos: []string{acmeTLSALPNProtocol},
+			// the challenge certificate is self-signed
+			InsecureSkipVerify: true,
+		},
+	}
+	c, cancel := context.WithTimeout(c, acmeValidationTimeout)
+	defer cancel()
+	conn, err := dialer.DialContext(c, "tcp", hostport)
+	if err != nil {
+		return newACMEProblem(acmeErrorConnection, http.StatusBadRequest, "failed to connect: %s", err.Error())
+	}
+	defer conn.Close()
+	state := conn.(*tls.Conn).ConnectionState()
+	if state.NegotiatedProtocol != acmeTLSALPNProtocol {
+		return newACMEProblem(acmeErrorTLS, http.StatusBadRequest, "%s protocol was not negotiated", acmeTLSALPNProtocol)
+	}
+	if len(state.PeerCertificates) == 0 {
+		return newACMEProblem(acmeErrorTLS, http.StatusBadRequest, "no certificate was presented")
+	}
+	return verifyTLSALPN01Certificate(state.PeerCertificates[0], domain, keyAuthorization)
+}
+
+func verifyTLSALPN01Certificate(cert *x509.Certificate, domain string, keyAuthorization string) *acmeProblem {
+	if len(cert.DNSNames) != 1 || cert.DNSNames[0] != domain ||
+		len(cert.IPAddresses) > 0 || len(cert.EmailAddresses) > 0 || len(cert.URIs) > 0 {
+		return newACMEProblem(acmeErrorIncorrectResponse, http.StatusForbidden, "certificate must only contain the DNS name %s", domain)
+	}
+	digest := sha256.Sum256([]byte(keyAuthorization))
+	for _, ext := range cert.Extensions {
+		if !ext.Id.Equal(oidExtensionACMEIdentifier) {
+			continue
+		}
+		if !ext.Critical {
+			return newACMEProblem(acmeErrorIncorrectResponse, http.StatusForbidden, "acmeIdentifier extension must be critical")
+		}
+		var value []byte
+		if rest, err := asn1.Unmarshal(ext.Value, &value); err != nil || len(rest) > 0 || !bytes.Equal(value, digest[:]) {
+			return newACMEProblem(acmeErrorIncorrectResponse, http.StatusForbidden, "key authorization does not match")
+		}
+		return nil
+	}
+	return newACMEProblem(acmeErrorIncorrectResponse, http.StatusForbidden, "acmeIdentifier extension not found")
+}
@@ -0,0 +1,84 @@
+package cert
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyTLSALPN01Certificate(t *testing.T) {
+	keyAuthorization := "token.thumbprint"
+	newChallengeCert := func(dnsName string, keyAuthorization string) *x509.Certificate {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		digest := sha256.Sum256([]byte(keyAuthorization))
+		extValue, err := asn1.Marshal(digest[:])
+		require.NoError(t, err)
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(1),
+			Subject:      pkix.Name{CommonName: dnsName},
+			DNSNames:     []string{dnsName},
+			NotBefore:    time.Now(),
+			NotAfter:     time.Now().Add(time.Hour),
+			ExtraExtensions: []pkix.Extension{
+				{Id: oidExtensionACMEIdentifier, Critical: true, Value: extValue},
+			},
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+		require.NoError(t, err)
+		cert, err := x509.ParseCertificate(der)
+		require.NoError(t, err)
+		return cert
+	}
+
+	assert.Nil(t, verifyTLSALPN01Certificate(newChallengeCert("example.com", keyAuthorization), "example.com", keyAuthorization))
+	assert.NotNil(t, verifyTLSALPN01Certificate(newChallengeCert("other.example.com", keyAuthorization), "example.com", keyAuthorization))
+	assert.NotNil(t, verifyTLSALPN01Certificate(newChallengeCert("example.com", "token.other"), "example.com", keyAuthorization))
+}
+
+func TestCheckACMEValidationAddress(t *testing.T) {
+	assert.NoError(t, checkACMEValidationAddress("tcp4", "93.184.216.34:80", nil))
+	assert.NoError(t, checkACMEValidationAddress("tcp6", "[2606:2800:220:1::]:443", nil))
+	for _, rejected := range []string{
+		"93.184.216.34:22",
+		"127.0.0.1:80",
+		"10.0.0.1:80",
+		"172.16.0.1:443",
+		"192.168.1.1:80",
+		"100.64.0.1:80",
+		"169.254.169.254:80",
+		"0.0.0.0:80",
+		"[::1]:80",
+		"[fd00:ec2::254]:80",
+		"[fe80::1]:443",
+		"[::ffff:127.0.0.1]:80",
+	} {
+		assert.Error(t, checkACMEValidationAddress("tcp", rejected, nil), rejected)
+	}
+}
+
+func TestCheckACMEValidationRedirect(t *testing.T) {
+	newRequest := func(rawURL string) *http.Request {
+		u, err := url.Parse(rawURL)
+		require.NoError(t, err)
+		return &http.Request{URL: u}
+	}
+	via := []*http.Request{newRequest("http://example.com/.well-known/acme-challenge/token")}
+	assert.NoError(t, checkACMEValidationRedirect(newRequest("https://www.example.com/token"), via))
+	assert.NoError(t, checkACMEValidationRedirect(newRequest("http://www.example.com:80/token"), via))
+	assert.Error(t, checkACMEValidationRedirect(newRequest("https://www.example.com:8443/token"), via))
+	assert.Error(t, checkACMEValidationRedirect(newRequest("ftp://www.example.com/token"), via))
+	assert.Error(t, checkACMEValidationRedirect(newRequest("https://www.example.com/token"), make([]*http.Request, 10)))
+}
@@ -0,0 +1,283 @@
+package cert
+
+import (
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net"
+	"net/mail"
+	"slices"
+	"strings"
+
+	"github.com/stephenzsy/small-kms/backend/base"
+	certmodels "github.com/stephenzsy/small-kms/backend/models/cert"
+)
+
+// rootCAMaxPathLen allows one level of intermediate CAs below the root CA, intermediate CAs only issue end entity certificates
+const rootCAMaxPathLen = 1
+
+// caConstraints are the extensions of intermediate CA certificates restricting what the CA may sign
+type caConstraints struct {
+	MaxPathLen        *int                                   `json:"maxPathLen,omitempty"`
+	NameConstraints   *certmodels.CertificateNameConstraints `json:"nameConstraints,omitempty"`
+	PolicyIdentifiers []string                               `json:"policyIdentifiers,omitempty"`
+}
+
+func (cc *caConstraints) isEmpty() bool {
+	return cc.MaxPathLen == nil && cc.NameConstraints == nil && len(cc.PolicyIdentifiers) == 0
+}
+
+func (cc *caConstraints) digest(w io.Writer) {
+	if cc.MaxPathLen != nil {
+		fmt.Fprintf(w, "maxPathLen=%d", *cc.MaxPathLen)
+	}
+	if nc := cc.NameConstraints; nc != nil {
+		for _, list := range [][]string{
+			nc.PermittedDNSDomains, nc.ExcludedDNSDomains,
+			nc.PermittedIPRanges, nc.ExcludedIPRanges,
+			nc.PermittedEmailAddresses, nc.ExcludedEmailAddresses,
+		} {
+			io.WriteString(w, strings.Join(list, ","))
+			io.WriteString(w, ";")
+		}
+	}
+	for _, oid := range cc.PolicyIdentifiers {
+		io.WriteString(w, oid)
+	}
+}
+
+func newCAConstraints(maxPathLen *int, nc *certmodels.CertificateNameConstraints, policyIdentifiers []string) (cc caConstraints, err error) {
+	if maxPathLen != nil {
+		if *maxPathLen < 0 {
+			return cc, fmt.Errorf("%w: max path length cannot be negative", base.ErrResponseStatusBadRequest)
+		}
+		// intermediate CAs are issued by the root CA, a longer path would fail at issuance
+		if *maxPathLen >= rootCAMaxPathLen {
+			return cc, fmt.Errorf("%w: max path length must be less than %d of the root CA", base.ErrResponseStatusBadRequest, rootCAMaxPathLen)
+		}
+		cc.MaxPathLen = maxPathLen
+	}
+	if cc.NameConstraints, err = sanitizeNameConstraints(nc); err != nil {
+		return cc, err
+	}
+	for _, v := range policyIdentifiers {
+		oid, err := x509.ParseOID(strings.TrimSpace(v))
+		if err != nil {
+			return cc, fmt.Errorf("%w: invalid policy identifier: %s", base.ErrResponseStatusBadRequest, v)
+		}
+		cc.PolicyIdentifiers = append(cc.PolicyIdentifiers, oid.String())
+	}
+	slices.Sort(cc.PolicyIdentifiers)
+	cc.PolicyIdentifiers = slices.Compact(cc.PolicyIdentifiers)
+	return cc, nil
+}
+
+func sanitizeNameConstraints(nc *certmodels.CertificateNameConstraints) (*certmodels.CertificateNameConstraints, error) {
+	if nc == nil {
+		return nil, nil
+	}
+	sanitized := &certmodels.CertificateNameConstraints{}
+	var err error
+	if sanitized.PermittedDNSDomains, err = sanitizeDNSDomainConstraints(nc.PermittedDNSDomains); err != nil {
+		return nil, err
+	}
+	if sanitized.ExcludedDNSDomains, err = sanitizeDNSDomainConstraints(nc.ExcludedDNSDomains); err != nil {
+		return nil, err
+	}
+	if sanitized.PermittedIPRanges, err = sanitizeIPRangeConstraints(nc.PermittedIPRanges); err != nil {
+		return nil, err
+	}
+	if sanitized.ExcludedIPRanges, err = sanitizeIPRangeConstraints(nc.ExcludedIPRanges); err != nil {
+		return nil, err
+	}
+	if sanitized.PermittedEmailAddresses, err = sanitizeEmailConstraints(nc.PermittedEmailAddresses); err != nil {
+		return nil, err
+	}
+	if sanitized.ExcludedEmailAddresses, err = sanitizeEmailConstraints(nc.ExcludedEmailAddresses); err != nil {
+		return nil, err
+	}
+	if sanitized.PermittedDNSDomains == nil && sanitized.ExcludedDNSDomains == nil &&
+		sanitized.PermittedIPRanges == nil && sanitized.ExcludedIPRanges == nil &&
+		sanitized.PermittedEmailAddresses == nil && sanitized.ExcludedEmailAddresses == nil {
+		return nil, nil
+	}
+	return sanitized, nil
+}
+
+func compactConstraints(values []string) []string {
+	slices.Sort(values)
+	values = slices.Compact(values)
+	if len(values) == 0 {
+		return nil
+	}
+	return values
+}
+
+// sanitizeDNSDomainConstraints accepts domains, which also match their subdomains, and domains with a leading '.',
+// which match the subdomains only
+func sanitizeDNSDomainConstraints(domains []string) ([]string, error) {
+	sanitized := make([]string, 0, len(domains))
+	for _, v := range domains {
+		v = strings.ToLower(strings.TrimSpace(v))
+		if v == "" {
+			continue
+		}
+		if !dnsNameRegex.MatchString(strings.TrimPrefix(v, ".")) || strings.HasPrefix(v, "*") {
+			return nil, fmt.Errorf("%w: invalid DNS domain constraint: %s", base.ErrResponseStatusBadRequest, v)
+		}
+		sanitized = append(sanitized, v)
+	}
+	return compactConstraints(sanitized), nil
+}
+
+func sanitizeIPRangeConstraints(ranges []string) ([]string, error) {
+	sanitized := make([]string, 0, len(ranges))
+	for _, v := range ranges {
+		if v = strings.TrimSpace(v); v == "" {
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(v)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid IP range constraint, must be in CIDR notation: %s", base.ErrResponseStatusBadRequest, v)
+		}
+		sanitized = append(sanitized, ipNet.String())
+	}
+	return compactConstraints(sanitized), nil
+}
+
+// sanitizeEmailConstraints accepts mailboxes, hosts and domains with a leading '.' as defined in RFC 5280 section 4.2.1.10
+func sanitizeEmailConstraints(constraints []string) ([]string, error) {
+	sanitized := make([]string, 0, len(constraints))
+	for _, v := range constraints {
+		if v = strings.TrimSpace(v); v == "" {
+			continue
+		}
+		if strings.Contains(v, "@") {
+			parsed, err := mail.ParseAddress(v)
+			if err != nil || parsed.Address != v {
+				return nil, fmt.Errorf("%w: invalid email constraint: %s", base.ErrResponseStatusBadRequest, v)
+			}
+		} else {
+			v = strings.ToLower(v)
+			if !dnsNameRegex.MatchString(strings.TrimPrefix(v, ".")) {
+				return nil, fmt.Errorf("%w: invalid email constraint: %s", base.ErrResponseStatusBadRequest, v)
+			}
+		}
+		sanitized = append(sanitized, v)
+	}
+	return compactConstraints(sanitized), nil
+}
+
+// applyCAConstraints sets the extensions of the constraints on the CA certificate template
+func applyCAConstraints(cert *x509.Certificate, cc *caConstraints) error {
+	if cc.MaxPathLen != nil {
+		cert.MaxPathLen = *cc.MaxPathLen
+		cert.MaxPathLenZero = *cc.MaxPathLen == 0
+	}
+	if nc := cc.NameConstraints; nc != nil {
+		// RFC 5280 section 4.2.1.10, conforming CAs must mark the extension critical
+		cert.PermittedDNSDomainsCritical = true
+		cert.PermittedDNSDomains = nc.PermittedDNSDomains
+		cert.ExcludedDNSDomains = nc.ExcludedDNSDomains
+		cert.PermittedEmailAddresses = nc.PermittedEmailAddresses
+		cert.ExcludedEmailAddresses = nc.ExcludedEmailAddresses
+		var err error
+		if cert.PermittedIPRanges, err = parseIPRangeConstraints(nc.PermittedIPRanges); err != nil {
+			return err
+		}
+		if cert.ExcludedIPRanges, err = parseIPRangeConstraints(nc.ExcludedIPRanges); err != nil {
+			return err
+		}
+	}
+	for _, v := range cc.PolicyIdentifiers {
+		oid, err := x509.ParseOID(v)
+		if err != nil {
+			return err
+		}
+		cert.Policies = append(cert.Policies, oid)
+	}
+	return nil
+}
+
+func parseIPRangeConstraints(ranges []string) ([]*net.IPNet, error) {
+	parsed := make([]*net.IPNet, 0, len(ranges))
+	for _, v := range ranges {
+		_, ipNet, err := net.ParseCIDR(v)
+		if err != nil {
+			return nil, err
+		}
+		parsed = append(parsed, ipNet)
+	}
+	return parsed, nil
+}
+
+// checkIssuerConstraints rejects certificates the issuer is not allowed to sign by its name constraints and path length
+func checkIssuerConstraints(issuer *x509.Certificate, cert *x509.Certificate) error {
+	// parsed certificates have -1 if the path length is not constrained
+	if cert.IsCA && issuer.MaxPathLen >= 0 {
+		if issuer.MaxPathLen == 0 {
+			return fmt.Errorf("%w: issuer path length does not allow CA certificates", base.ErrResponseStatusBadRequest)
+		}
+		if (cert.MaxPathLen <= 0 && !cert.MaxPathLenZero) || cert.MaxPathLen >= issuer.MaxPathLen {
+			return fmt.Errorf("%w: max path length must be less than %d of the issuer", base.ErrResponseStatusBadRequest, issuer.MaxPathLen)
+		}
+	}
+
+	for _, name := range cert.DNSNames {
+		if err := checkNameConstraint("DNS name", name, issuer.PermittedDNSDomains, issuer.ExcludedDNSDomains, matchDNSDomainConstraint); err != nil {
+			return err
+		}
+	}
+	for _, email := range cert.EmailAddresses {
+		if err := checkNameConstraint("email address", email, issuer.PermittedEmailAddresses, issuer.ExcludedEmailAddresses, matchEmailConstraint); err != nil {
+			return err
+		}
+	}
+	for _, ip := range cert.IPAddresses {
+		if err := checkNameConstraint("IP address", ip, issuer.PermittedIPRanges, issuer.ExcludedIPRanges, matchIPRangeConstraint); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkNameConstraint[N any, C any](kind string, name N, permitted []C, excluded []C, match func(N, C) bool) error {
+	for _, constraint := range excluded {
+		if match(name, constraint) {
+			return fmt.Errorf("%w: %s %v is excluded by the issuer", base.ErrResponseStatusBadRequest, kind, name)
+		}
+	}
+	if len(permitted) > 0 && !slices.ContainsFunc(permitted, func(constraint C) bool { return match(name, constraint) }) {
+		return fmt.Errorf("%w: %s %v is not permitted by the issuer", base.ErrResponseStatusBadRequest, kind, name)
+	}
+	return nil
+}
+
+func matchDNSDomainConstraint(name string, constraint string) bool {
+	name = strings.ToLower(name)
+	constraint = strings.ToLower(constraint)
+	if strings.HasPrefix(constraint, ".") {
+		return strings.HasSuffix(name, constraint)
+	}
+	return name == constraint || strings.HasSuffix(name, "."+constraint)
+}
+
+func matchEmailConstraint(email string, constraint string) bool {
+	if strings.Contains(constraint, "@") {
+		return strings.EqualFold(email, constraint)
+	}
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return false
+	}
+	host := strings.ToLower(email[at+1:])
+	constraint = strings.ToLower(constraint)
+	if strings.HasPrefix(constraint, ".") {
+		return strings.HasSuffix(host, constraint)
+	}
+	return host == constraint
+}
+
+func matchIPRangeConstraint(ip net.IP, constraint *net.IPNet) bool {
+	return constraint.Contains(ip)
+}
@@ -0,0 +1,110 @@
+package cert
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stephenzsy/small-kms/backend/base"
+	certmodels "github.com/stephenzsy/small-kms/backend/models/cert"
+	"github.com/stephenzsy/small-kms/backend/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCAConstraints(t *testing.T) {
+	cc, err := newCAConstraints(utils.ToPtr(0), &certmodels.CertificateNameConstraints{
+		PermittedDNSDomains:     []string{" Example.com", ".corp.example.com", "example.com"},
+		PermittedIPRanges:       []string{"10.1.2.3/16"},
+		PermittedEmailAddresses: []string{"example.com", "admin@example.org"},
+	}, []string{"2.23.140.1.2.1", " 1.3.6.1.4.1.311.21.8.1 "})
+	require.NoError(t, err)
+	assert.Equal(t, []string{".corp.example.com", "example.com"}, cc.NameConstraints.PermittedDNSDomains)
+	assert.Equal(t, []string{"10.1.0.0/16"}, cc.NameConstraints.PermittedIPRanges)
+	assert.Equal(t, []string{"admin@example.org", "example.com"}, cc.NameConstraints.PermittedEmailAddresses)
+	assert.Equal(t, []string{"1.3.6.1.4.1.311.21.8.1", "2.23.140.1.2.1"}, cc.PolicyIdentifiers)
+
+	cc, err = newCAConstraints(nil, &certmodels.CertificateNameConstraints{PermittedDNSDomains: []string{" "}}, nil)
+	require.NoError(t, err)
+	assert.True(t, cc.isEmpty())
+
+	for _, invalid := range []func() error{
+		func() error { _, err := newCAConstraints(utils.ToPtr(-1), nil, nil); return err },
+		func() error { _, err := newCAConstraints(utils.ToPtr(rootCAMaxPathLen), nil, nil); return err },
+		func() error { _, err := newCAConstraints(nil, nil, []string{"not an oid"}); return err },
+		func() error {
+			_, err := newCAConstraints(nil, &certmodels.CertificateNameConstraints{ExcludedDNSDomains: []string{"*.example.com"}}, nil)
+			return err
+		},
+		func() error {
+			_, err := newCAConstraints(nil, &certmodels.CertificateNameConstraints{ExcludedIPRanges: []string{"10.0.0.1"}}, nil)
+			return err
+		},
+		func() error {
+			_, err := newCAConstraints(nil, &certmodels.CertificateNameConstraints{ExcludedEmailAddresses: []string{"a b@example.com"}}, nil)
+			return err
+		},
+	} {
+		assert.ErrorIs(t, invalid(), base.ErrResponseStatusBadRequest)
+	}
+}
+
+func TestCheckIssuerConstraints(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	cc, err := newCAConstraints(utils.ToPtr(0), &certmodels.CertificateNameConstraints{
+		PermittedDNSDomains:     []string{"example.com"},
+		ExcludedDNSDomains:      []string{"secret.example.com"},
+		PermittedIPRanges:       []string{"10.0.0.0/8"},
+		PermittedEmailAddresses: []string{".example.com"},
+	}, []string{"2.23.140.1.2.1"})
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test Intermediate CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	require.NoError(t, applyCAConstraints(template, &cc))
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	require.NoError(t, err)
+	issuer, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	assert.Equal(t, 0, issuer.MaxPathLen)
+	assert.True(t, issuer.MaxPathLenZero)
+	assert.True(t, issuer.PermittedDNSDomainsCritical)
+	require.Len(t, issuer.Policies, 1)
+	assert.Equal(t, "2.23.140.1.2.1", issuer.Policies[0].String())
+
+	assert.NoError(t, checkIssuerConstraints(issuer, &x509.Certificate{
+		DNSNames:       []string{"example.com", "www.example.com"},
+		IPAddresses:    []net.IP{net.ParseIP("10.1.2.3")},
+		EmailAddresses: []string{"user@mail.example.com"},
+	}))
+	for _, rejected := range []*x509.Certificate{
+		{DNSNames: []string{"example.org"}},
+		{DNSNames: []string{"notexample.com"}},
+		{DNSNames: []string{"host.secret.example.com"}},
+		{IPAddresses: []net.IP{net.ParseIP("192.168.1.1")}},
+		{IPAddresses: []net.IP{net.ParseIP("::1")}},
+		{EmailAddresses: []string{"user@example.com"}},
+		{IsCA: true, MaxPathLenZero: true},
+	} {
+		assert.ErrorIs(t, checkIssuerConstraints(issuer, rejected), base.ErrResponseStatusBadRequest)
+	}
+
+	root := &x509.Certificate{IsCA: true, BasicConstraintsValid: true, MaxPathLen: rootCAMaxPathLen}
+	assert.NoError(t, checkIssuerConstraints(root, &x509.Certificate{IsCA: true, MaxPathLenZero: true}))
+	assert.Error(t, checkIssuerConstraints(root, &x509.Certificate{IsCA: true, MaxPathLen: 1}))
+	assert.Error(t, checkIssuerConstraints(root, &x509.Certificate{IsCA: true, MaxPathLen: -1}))
+}
@@ -0,0 +1,157 @@
+package cert
+
+import (
+	"crypto/rand"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
+	"github.com/stephenzsy/small-kms/backend/base"
+	ctx "github.com/stephenzsy/small-kms/backend/internal/context"
+	"github.com/stephenzsy/small-kms/backend/models"
+	certmodels "github.com/stephenzsy/small-kms/backend/models/cert"
+	"github.com/stephenzsy/small-kms/backend/resdoc"
+)
+
+const (
+	crlRefreshInterval = 24 * time.Hour
+	crlValidity        = 7 * 24 * time.Hour
+)
+
+// certCRLDoc holds the latest CRL signed by a CA certificate, it shares the ID of the CA certificate
+type certCRLDoc struct {
+	resdoc.ResourceDoc
+	Number     int64              `json:"number"`
+	ThisUpdate resdoc.NumericDate `json:"thisUpdate"`
+	NextUpdate resdoc.NumericDate `json:"nextUpdate"`
+	CRL        []byte             `json:"crl"`
+}
+
+func (d *certCRLDoc) isStale() bool {
+	return time.Now().After(d.ThisUpdate.Add(crlRefreshInterval))
+}
+
+func getCRLDocIdentifier(issuer resdoc.DocIdentifier) resdoc.DocIdentifier {
+	return resdoc.NewDocIdentifier(issuer.NamespaceProvider, issuer.NamespaceID, models.ResourceProviderCertCRL, issuer.ID)
+}
+
+// getCRLInternal returns the current CRL of the issuer, a new CRL is published if none exists or the existing one is stale
+func getCRLInternal(c ctx.RequestContext, issuer resdoc.DocIdentifier) (*certCRLDoc, error) {
+	crlDoc := &certCRLDoc{}
+	if err := resdoc.GetDocService(c).Read(c, getCRLDocIdentifier(issuer), crlDoc, nil); err != nil {
+		if !errors.Is(err, resdoc.ErrAzCosmosDocNotFound) {
+			return nil, err
+		}
+		crlDoc = nil
+	} else if !crlDoc.isStale() {
+		return crlDoc, nil
+	}
+
+	issuerDoc := &certDocBase{}
+	if err := readCertDocInternal(c, issuer.NamespaceProvider, issuer.NamespaceID, issuer.ID, issuerDoc); err != nil {
+		return nil, err
+	}
+	return publishCRLInternal(c, issuerDoc, crlDoc)
+}
+
+// publishCRLInternal signs a new CRL with the issuer certificate key and stores it
+func publishCRLInternal(c ctx.RequestContext, issuerDoc *certDocBase, prevDoc *certCRLDoc) (*certCRLDoc, error) {
+	if issuerDoc.PartitionKey.NamespaceProvider != models.NamespaceProviderRootCA &&
+		issuerDoc.PartitionKey.NamespaceProvider != models.NamespaceProviderIntermediateCA {
+		return nil, fmt.Errorf("%w: certificate is not a CA certificate", base.ErrResponseStatusBadRequest)
+	}
+	if issuerDoc.Status == certmodels.CertificateStatusPending {
+		return nil, fmt.Errorf("%w: certificate is not issued", base.ErrResponseStatusBadRequest)
+	}
+	issuerCert, err := issuerDoc.X509Certificate()
+	if err != nil {
+		return nil, err
+	}
+
+	links, err := listRevokedCertLinksInternal(c, issuerDoc.Identifier())
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]x509.RevocationListEntry, 0, len(links))
+	for _, link := range links {
+		entry := x509.RevocationListEntry{
+			SerialNumber: big.NewInt(0).SetBytes(link.SerialNumber),
+		}
+		if link.Revocation != nil {
+			entry.RevocationTime = link.Revocation.RevokedAt.Time
+			entry.ReasonCode, _ = link.Revocation.Reason.ReasonCode()
+		} else {
+			// deactivated certificate
+			entry.ReasonCode, _ = certmodels.RevocationReasonCessationOfOperation.ReasonCode()
+			if link.Timestamp != nil {
+				entry.RevocationTime = link.Timestamp.Time
+			}
+		}
+		if entry.RevocationTime.IsZero() {
+			entry.RevocationTime = time.Now()
+		}
+		entries = append(entries, entry)
+	}
+
+	crlDoc := &certCRLDoc{
+		ResourceDoc: resdoc.ResourceDoc{
+			PartitionKey: resdoc.PartitionKey{
+				NamespaceProvider: issuerDoc.PartitionKey.NamespaceProvider,
+				NamespaceID:       issuerDoc.PartitionKey.NamespaceID,
+				ResourceProvider:  models.ResourceProviderCertCRL,
+			},
+			ID: issuerDoc.ID,
+		},
+		Number: 1,
+	}
+	var upsertOpts *azcosmos.ItemOptions
+	if prevDoc != nil {
+		crlDoc.Number = prevDoc.Number + 1
+		upsertOpts = &azcosmos.ItemOptions{
+			IfMatchEtag: prevDoc.GetETag(),
+		}
+	}
+	now := time.Now().Truncate(time.Second)
+	crlDoc.ThisUpdate.Time = now
+	crlDoc.NextUpdate.Time = now.Add(crlValidity)
+
+	signer, sigAlg := issuerDoc.getCloudSignatureKey(c)
+	crlDoc.CRL, err = x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		SignatureAlgorithm:        sigAlg.X509SignatureAlgorithm(),
+		RevokedCertificateEntries: entries,
+		Number:                    big.NewInt(crlDoc.Number),
+		ThisUpdate:                crlDoc.ThisUpdate.Time,
+		NextUpdate:                crlDoc.NextUpdate.Time,
+	}, issuerCert, signer)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := resdoc.GetDocService(c).Upsert(c, crlDoc, upsertOpts); err != nil {
+		return nil, err
+	}
+	return crlDoc, nil
+}
+
+// republishCRLInternal publishes a new CRL for the issuer after a certificate has been revoked or deactivated
+func republishCRLInternal(c ctx.RequestContext, issuer resdoc.DocIdentifier) error {
+	if !isInternalIssuer(issuer) {
+		return nil
+	}
+	prevDoc := &certCRLDoc{}
+	if err := resdoc.GetDocService(c).Read(c, getCRLDocIdentifier(issuer), prevDoc, nil); err != nil {
+		if !errors.Is(err, resdoc.ErrAzCosmosDocNotFound) {
+			return err
+		}
+		prevDoc = nil
+	}
+	issuerDoc := &certDocBase{}
+	if err := readCertDocInternal(c, issuer.NamespaceProvider, issuer.NamespaceID, issuer.ID, issuerDoc); err != nil {
+		return err
+	}
+	_, err := publishCRLInternal(c, issuerDoc, prevDoc)
+	return err
+}
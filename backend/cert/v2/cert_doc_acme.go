@@ -7,11 +7,14 @@ import (
 	"net"
 	"net/http"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
 	"github.com/rs/zerolog/log"
 	cloudkey "github.com/stephenzsy/small-kms/backend/cloud/key"
 	ctx "github.com/stephenzsy/small-kms/backend/internal/context"
 	"github.com/stephenzsy/small-kms/backend/models"
 	certmodels "github.com/stephenzsy/small-kms/backend/models/cert"
+	webhookmodels "github.com/stephenzsy/small-kms/backend/models/webhook"
+	"github.com/stephenzsy/small-kms/backend/resdoc"
 	"github.com/stephenzsy/small-kms/backend/utils"
 	"golang.org/x/crypto/acme"
 )
@@ -23,12 +26,22 @@ const (
 	AcmeStepOrderCreated AcmeStep = "orderCreated"
 )
 
+// certDocACMEChallenge is a challenge presented by a solver, kept for clean up
+type certDocACMEChallenge struct {
+	Type   string `json:"type"`
+	URL    string `json:"url"`
+	Token  string `json:"token"`
+	Domain string `json:"domain"`
+}
+
 type certDocACME struct {
 	certDocPending
-	ACMEStep AcmeStep `json:"acmeStep"`
-	OrderURL string   `json:"orderUrl"`
+	ACMEStep   AcmeStep               `json:"acmeStep"`
+	OrderURL   string                 `json:"orderUrl"`
+	Challenges []certDocACMEChallenge `json:"acmeChallenges,omitempty"`
 
 	acmeClient *acme.Client
+	issuerDoc  *CertIssuerDoc
 }
 
 // CreateCertificate implements CertDocumentPending.
@@ -70,6 +83,7 @@ func (doc *certDocACME) init(c ctx.RequestContext,
 		return err
 	} else {
 		doc.Issuer = issuerDoc.Identifier()
+		doc.issuerDoc = issuerDoc
 		doc.acmeClient, err = issuerDoc.ACMEClient(c)
 		if err != nil {
 			return err
@@ -84,6 +98,7 @@ func (doc *certDocACME) restore(c ctx.RequestContext) error {
 	if error != nil {
 		return error
 	}
+	doc.issuerDoc = issuerDoc
 	doc.acmeClient, error = issuerDoc.ACMEClient(c)
 	return error
 }
@@ -102,7 +117,116 @@ func (doc *certDocACME) Authorize(c context.Context) (bool, error) {
 	doc.ACMEStep = AcmeStepOrderCreated
 	doc.OrderURL = order.URI
 	doc.Status = certmodels.CertificateStatusPendingAuthorization
-	return order.Status == acme.StatusValid, nil
+	if order.Status == acme.StatusValid || order.Status == acme.StatusReady {
+		return order.Status == acme.StatusValid, nil
+	}
+	return false, doc.solveChallenges(c, order)
+}
+
+// solveChallenges presents and accepts a challenge for each pending authorization of the order,
+// authorizations without a configured solver are left to be completed manually
+func (doc *certDocACME) solveChallenges(c context.Context, order *acme.Order) error {
+	logger := log.Ctx(c)
+	solvers := doc.issuerDoc.acmeSolvers()
+	if len(solvers) == 0 {
+		return nil
+	}
+	for _, authzURL := range order.AuthzURLs {
+		authz, err := doc.acmeClient.GetAuthorization(c, authzURL)
+		if err != nil {
+			return err
+		}
+		if authz.Status != acme.StatusPending {
+			continue
+		}
+		challenge, solver := selectACMEChallenge(authz, solvers)
+		if challenge == nil {
+			logger.Warn().Str("authz", authz.URI).Str("identifier", authz.Identifier.Value).Msg("no solver for ACME authorization")
+			continue
+		}
+		if err := solver.Present(c, doc.acmeClient, challenge, authz.Identifier.Value); err != nil {
+			return err
+		}
+		doc.Challenges = append(doc.Challenges, certDocACMEChallenge{
+			Type:   challenge.Type,
+			URL:    challenge.URI,
+			Token:  challenge.Token,
+			Domain: authz.Identifier.Value,
+		})
+		if _, err := doc.acmeClient.Accept(c, challenge); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// selectACMEChallenge prefers http-01, wildcard authorizations can only be validated with dns-01
+func selectACMEChallenge(authz *acme.Authorization, solvers map[string]acmeChallengeSolver) (*acme.Challenge, acmeChallengeSolver) {
+	var selected *acme.Challenge
+	for _, challenge := range authz.Challenges {
+		if challenge.Status != acme.StatusPending {
+			continue
+		}
+		switch challenge.Type {
+		case acmeChallengeTypeHTTP01:
+			if _, ok := solvers[challenge.Type]; ok && !authz.Wildcard {
+				return challenge, solvers[challenge.Type]
+			}
+		case acmeChallengeTypeDNS01:
+			if _, ok := solvers[challenge.Type]; ok && authz.Identifier.Type == acmeIdentifierTypeDNS {
+				selected = challenge
+			}
+		}
+	}
+	if selected == nil {
+		return nil, nil
+	}
+	return selected, solvers[selected.Type]
+}
+
+// cleanUpChallenges removes the presented challenge responses, failures are logged only
+func (doc *certDocACME) cleanUpChallenges(c context.Context) {
+	logger := log.Ctx(c)
+	if doc.issuerDoc == nil {
+		return
+	}
+	solvers := doc.issuerDoc.acmeSolvers()
+	for _, challenge := range doc.Challenges {
+		solver, ok := solvers[challenge.Type]
+		if !ok {
+			continue
+		}
+		if err := solver.CleanUp(c, doc.acmeClient, &acme.Challenge{
+			Type:  challenge.Type,
+			URI:   challenge.URL,
+			Token: challenge.Token,
+		}, challenge.Domain); err != nil {
+			logger.Error().Err(err).Str("type", challenge.Type).Str("domain", challenge.Domain).Msg("failed to clean up ACME challenge")
+		}
+	}
+}
+
+// finalize collects the certificate of a ready or valid order
+func (doc *certDocACME) finalize(c ctx.RequestContext) error {
+	csr, err := doc.GetCertificateRequest(c, false)
+	if err != nil {
+		return err
+	}
+	der, err := doc.CreateCertificate(c, csr)
+	if err != nil {
+		return err
+	}
+	if err := doc.CollectSignedCertificate(c, der); err != nil {
+		return err
+	}
+	if _, err := resdoc.GetDocService(c).Upsert(c, doc, &azcosmos.ItemOptions{
+		IfMatchEtag: doc.ETag,
+	}); err != nil {
+		return err
+	}
+	doc.cleanUpChallenges(c)
+	publishCertificateEvent(c, doc, webhookmodels.EventTypeCertificateIssued)
+	return registerCertRenewalInternal(c, doc)
 }
 
 var _ CertDocumentPending = (*certDocACME)(nil)
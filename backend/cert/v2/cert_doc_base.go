@@ -39,6 +39,10 @@ type CertDocument interface {
 	IsExpired() bool
 	GetNotBefore() time.Time
 	GetNotAfter() time.Time
+	GetIssuer() resdoc.DocIdentifier
+	GetPolicyIdentifier() resdoc.DocIdentifier
+	GetSerialNumber() []byte
+	GetRevocation() *certmodels.CertificateRevocation
 	KeyVaultSecretID() string
 }
 
@@ -76,11 +80,18 @@ type certDocBase struct {
 
 	// enrolled certificate will have this as empty
 	KeyVaultStore *CertDocKeyVaultStore `json:"keyVaultStore,omitempty"`
+	// key store of the cloud mastered key, empty for the default key store
+	KeyStore cloudkey.KeyStoreKind `json:"keyStore,omitempty"`
 
 	SerialNumber []byte             `json:"serialNumber"`
 	IssuedAt     resdoc.NumericDate `json:"iat"`
 
 	Checksum []byte `json:"checksum"` // sha256 of the cloud certificate and critical fields
+
+	Revocation *certmodels.CertificateRevocation `json:"revocation,omitempty"`
+
+	// set once the expiring event has been queued, not covered by the checksum
+	ExpiringNotified *resdoc.NumericDate `json:"expiringNotified,omitempty"`
 }
 
 // KeyVaultSecretID implements CertDocument.
@@ -91,6 +102,15 @@ func (d *certDocBase) KeyVaultSecretID() string {
 	return d.KeyVaultStore.SID
 }
 
+// getKeyStore returns the key store the cloud mastered key is created in
+func (d *certDocBase) getKeyStore(c context.Context) (cloudkey.KeyStore, error) {
+	keyStore, err := cloudkey.SelectKeyStore(kv.GetCloudKeyStore(c), d.KeyStore)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", base.ErrResponseStatusBadRequest, err)
+	}
+	return keyStore, nil
+}
+
 type certDocPending struct {
 	certDocBase
 	certUUID   uuid.UUID
@@ -117,6 +137,26 @@ func (doc *certDocBase) IsExpired() bool {
 	return doc.NotAfter.Before(time.Now())
 }
 
+// GetIssuer implements CertDocument.
+func (doc *certDocBase) GetIssuer() resdoc.DocIdentifier {
+	return doc.Issuer
+}
+
+// GetPolicyIdentifier implements CertDocument.
+func (doc *certDocBase) GetPolicyIdentifier() resdoc.DocIdentifier {
+	return doc.PolicyIdentifier
+}
+
+// GetSerialNumber implements CertDocument.
+func (doc *certDocBase) GetSerialNumber() []byte {
+	return doc.SerialNumber
+}
+
+// GetRevocation implements CertDocument.
+func (doc *certDocBase) GetRevocation() *certmodels.CertificateRevocation {
+	return doc.Revocation
+}
+
 // GetStatus implements CertDocument.
 func (doc *certDocBase) GetStatus() certmodels.CertificateStatus {
 	return doc.Status
@@ -127,6 +167,12 @@ func (doc *certDocBase) GetJsonWebKey() *cloudkey.JsonWebKey {
 	return &doc.JsonWebKey
 }
 
+// getCloudSignatureKey returns the key store signer of an issued CA certificate
+func (d *certDocBase) getCloudSignatureKey(c context.Context) (cloudkey.CloudSignatureKey, cloudkey.JsonWebSignatureAlgorithm) {
+	sigAlg := cloudkey.JsonWebSignatureAlgorithm(d.JsonWebKey.Alg)
+	return kv.GetCloudKeyStore(c).NewSignatureKey(c, d.JsonWebKey.KeyID, sigAlg, true, d.JsonWebKey.PublicKey()), sigAlg
+}
+
 // X509Certificate implements CertDocument.
 func (d *certDocBase) X509Certificate() (*x509.Certificate, error) {
 	if len(d.JsonWebKey.CertificateChain) == 0 {
@@ -172,6 +218,7 @@ func (d *certDocPending) init(
 		d.KeyVaultStore = &CertDocKeyVaultStore{
 			Name: materialName,
 		}
+		d.KeyStore = pDoc.KeySpec.KeyStore
 	} else {
 		if d.JsonWebKey.KeyType != publicJwk.KeyType {
 			return fmt.Errorf("%w: public key type does not match", base.ErrResponseStatusBadRequest)
@@ -189,6 +236,14 @@ func (d *certDocPending) init(
 			}
 			d.JsonWebKey.X = publicJwk.X
 			d.JsonWebKey.Y = publicJwk.Y
+		case cloudkey.KeyTypeOKP:
+			if d.JsonWebKey.Curve != publicJwk.Curve {
+				return fmt.Errorf("%w: public key curve does not match", base.ErrResponseStatusBadRequest)
+			}
+			if publicJwk.PublicKey() == nil {
+				return fmt.Errorf("%w: invalid public key", base.ErrResponseStatusBadRequest)
+			}
+			d.JsonWebKey.X = publicJwk.X
 		default:
 			return fmt.Errorf("%w: invalid key type", base.ErrResponseStatusBadRequest)
 		}
@@ -198,7 +253,10 @@ func (d *certDocPending) init(
 	if err != nil {
 		return err
 	}
-	d.SANs = pDoc.SANs
+	d.SANs, err = processSANsTemplate(c, pDoc.SANs)
+	if err != nil {
+		return err
+	}
 	d.Flags = pDoc.Flags
 	d.PolicyIdentifier = pDoc.Identifier()
 	d.PolicyVersion = pDoc.Version
@@ -258,6 +316,19 @@ func (doc *certDocPending) GetCertificateRequest(c ctx.RequestContext, skipCheck
 	if doc.KeyVaultStore == nil {
 		return &enrollPublicKeyCSR{doc.JsonWebKey.PublicKey()}, nil
 	}
+	keyStore, err := doc.getKeyStore(c)
+	if err != nil {
+		return nil, err
+	}
+	if keyStore.Kind() != cloudkey.KeyStoreKindAzKeyVault {
+		// only the key is kept in the key store, the certificate is kept in the document
+		created, err := keyStore.CreateKey(c, doc.KeyVaultStore.Name, doc.getCreateKeyParams())
+		if err != nil {
+			return nil, err
+		}
+		doc.JsonWebKey.KeyID = created.KeyID
+		return &enrollPublicKeyCSR{created.PublicKey()}, nil
+	}
 	client := kv.GetAzKeyVaultService(c).AzCertificatesClient()
 	// if !skipCheckExisting {
 	// 	if resp, err := client.GetCertificateOperation(c, doc.KeyVaultStore.Name, nil); err != nil {
@@ -280,6 +351,18 @@ func (doc *certDocPending) GetCertificateRequest(c ctx.RequestContext, skipCheck
 	return &kvCreateCertCSR{&resp.CertificateOperation}, nil
 }
 
+func (d *certDocPending) getCreateKeyParams() cloudkey.CreateKeyParams {
+	return cloudkey.CreateKeyParams{
+		KeyType:       d.JsonWebKey.KeyType,
+		Curve:         d.JsonWebKey.Curve,
+		KeySize:       int(d.rsaKeySize),
+		KeyOperations: d.JsonWebKey.KeyOperations,
+		Exportable:    d.JsonWebKey.Extractable,
+		NotBefore:     &d.NotBefore.Time,
+		Expires:       &d.NotAfter.Time,
+	}
+}
+
 func (d *certDocPending) getAzCreateCertParams() (params azcertificates.CreateCertificateParameters, err error) {
 	params.CertificateAttributes = &azcertificates.CertificateAttributes{
 		Enabled:   to.Ptr(true),
@@ -297,9 +380,12 @@ func (d *certDocPending) getAzCreateCertParams() (params azcertificates.CreateCe
 			Subject: to.Ptr(d.Subject.String()),
 		},
 	}
+	x509Props := params.CertificatePolicy.X509CertificateProperties
+	x509Props.KeyUsage, x509Props.EnhancedKeyUsage = getAzCertUsages(d.Flags)
 	if d.SANs != nil {
 		params.CertificatePolicy.X509CertificateProperties.SubjectAlternativeNames = &azcertificates.SubjectAlternativeNames{
 			DNSNames: to.SliceOfPtrs(d.SANs.DNSNames...),
+			Emails:   to.SliceOfPtrs(d.SANs.Emails...),
 		}
 	}
 	kp := params.CertificatePolicy.KeyProperties
@@ -363,20 +449,35 @@ func (d *certDocBase) CollectSignedCertificate(c ctx.RequestContext, der [][]byt
 		Emails:      parsed.EmailAddresses,
 		IPAddresses: parsed.IPAddresses,
 	}
+	for _, uri := range parsed.URIs {
+		d.SANs.URIs = append(d.SANs.URIs, uri.String())
+	}
 	d.Subject = certmodels.CertificateSubject{
 		CommonName: parsed.Subject.CommonName,
 	}
-	d.Flags = make([]certmodels.CertificateFlag, 0, len(d.Flags))
-	for _, ext := range parsed.ExtKeyUsage {
-		switch ext {
-		case x509.ExtKeyUsageServerAuth:
-			d.Flags = append(d.Flags, certmodels.CertificateFlagServerAuth)
-		case x509.ExtKeyUsageClientAuth:
-			d.Flags = append(d.Flags, certmodels.CertificateFlagClientAuth)
-		}
+	if len(parsed.Subject.Country) > 0 {
+		d.Subject.Country = parsed.Subject.Country[0]
+	}
+	if len(parsed.Subject.Organization) > 0 {
+		d.Subject.Organization = parsed.Subject.Organization[0]
+	}
+	if len(parsed.Subject.OrganizationalUnit) > 0 {
+		d.Subject.OrganizationalUnit = parsed.Subject.OrganizationalUnit[0]
 	}
+	if len(parsed.Subject.Locality) > 0 {
+		d.Subject.Locality = parsed.Subject.Locality[0]
+	}
+	if len(parsed.Subject.Province) > 0 {
+		d.Subject.Province = parsed.Subject.Province[0]
+	}
+	d.Flags = parseCertificateFlags(parsed)
 
-	if d.KeyVaultStore != nil && d.PartitionKey.NamespaceProvider != models.NamespaceProviderRootCA {
+	keyStore, err := d.getKeyStore(c)
+	if err != nil {
+		return err
+	}
+	if d.KeyVaultStore != nil && d.PartitionKey.NamespaceProvider != models.NamespaceProviderRootCA &&
+		keyStore.Kind() == cloudkey.KeyStoreKindAzKeyVault {
 		certClient := kv.GetAzKeyVaultService(c).AzCertificatesClient()
 		resp, err := certClient.MergeCertificate(c, d.KeyVaultStore.Name, azcertificates.MergeCertificateParameters{
 			X509Certificates: der,
@@ -415,6 +516,7 @@ func (d *certDocBase) ToModel(includeKey bool) *certmodels.Certificate {
 			Subject:                 d.Subject.String(),
 			SubjectAlternativeNames: d.SANs,
 			Flags:                   d.Flags,
+			Revocation:              d.Revocation,
 		},
 	}
 	if !d.IssuedAt.Time.IsZero() {
@@ -6,22 +6,24 @@ import (
 	"crypto/x509"
 	"fmt"
 	"math/big"
+	"net/url"
 	"slices"
 	"time"
 
-	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
-	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azkeys"
+	"github.com/stephenzsy/small-kms/backend/api"
 	cloudkey "github.com/stephenzsy/small-kms/backend/cloud/key"
-	cloudkeyaz "github.com/stephenzsy/small-kms/backend/cloud/key/az"
 	ctx "github.com/stephenzsy/small-kms/backend/internal/context"
 	kv "github.com/stephenzsy/small-kms/backend/internal/keyvault"
 	"github.com/stephenzsy/small-kms/backend/models"
 	certmodels "github.com/stephenzsy/small-kms/backend/models/cert"
+	"github.com/stephenzsy/small-kms/backend/resdoc"
 	"github.com/stephenzsy/small-kms/backend/utils"
 )
 
 type certDocInternal struct {
 	certDocPending
+	// constraints of the intermediate CA policy, set at init
+	constraints *caConstraints
 }
 
 func (doc *certDocInternal) init(c ctx.RequestContext,
@@ -30,6 +32,9 @@ func (doc *certDocInternal) init(c ctx.RequestContext,
 	if err = doc.certDocPending.init(c, nsProvider, nsID, pDoc, publicKey); err != nil {
 		return err
 	}
+	if doc.PartitionKey.NamespaceProvider == models.NamespaceProviderIntermediateCA {
+		doc.constraints = &pDoc.caConstraints
+	}
 	if doc.PartitionKey.NamespaceProvider == models.NamespaceProviderRootCA {
 		doc.Issuer = doc.Identifier()
 	} else {
@@ -47,28 +52,30 @@ func (doc *certDocInternal) init(c ctx.RequestContext,
 
 // CreateCertificate implements CertDocument.
 func (doc *certDocInternal) CreateCertificate(c ctx.RequestContext, csr CertCSR) ([][]byte, error) {
-	template := doc.getCertificateTemplate()
+	template, err := doc.getCertificateTemplate(c)
+	if err != nil {
+		return nil, err
+	}
 	var issuerCert *x509.Certificate
 	var signer crypto.Signer
-	azKeysClient := kv.GetAzKeyVaultService(c).AzKeysClient()
+	keyStore := kv.GetCloudKeyStore(c)
 	var signerChain [][]byte
 	var publicKey crypto.PublicKey
 	if doc.PartitionKey.NamespaceProvider == models.NamespaceProviderRootCA {
 		issuerCert = template
 
-		ckParams, err := doc.getAzCreateKeyParams()
+		sigAlg := cloudkey.JsonWebSignatureAlgorithm(doc.JsonWebKey.Alg)
+		policyKeyStore, err := doc.getKeyStore(c)
 		if err != nil {
 			return nil, err
 		}
-		sigAlg := cloudkey.JsonWebSignatureAlgorithm(doc.JsonWebKey.Alg)
-		ckResp, ck, err := cloudkeyaz.CreateCloudSignatureKey(c,
-			azKeysClient, doc.KeyVaultStore.Name, ckParams, sigAlg, true)
+		created, err := policyKeyStore.CreateKey(c, doc.KeyVaultStore.Name, doc.getCreateKeyParams())
 		if err != nil {
 			return nil, err
 		}
-		doc.JsonWebKey.KeyID = string(*ckResp.Key.KID)
-		publicKey = ck.Public()
-		signer = ck
+		doc.JsonWebKey.KeyID = created.KeyID
+		publicKey = created.PublicKey()
+		signer = keyStore.NewSignatureKey(c, created.KeyID, sigAlg, true, publicKey)
 		doc.Issuer = doc.Identifier()
 		template.SignatureAlgorithm = sigAlg.X509SignatureAlgorithm()
 	} else {
@@ -84,14 +91,13 @@ func (doc *certDocInternal) CreateCertificate(c ctx.RequestContext, csr CertCSR)
 		if err != nil {
 			return nil, err
 		}
+		if err := checkIssuerConstraints(issuerCert, template); err != nil {
+			return nil, err
+		}
 		issuerJwk := issuerCertDoc.GetJsonWebKey()
 		sigAlg := cloudkey.JsonWebSignatureAlgorithm(issuerJwk.Alg)
 
-		signer = cloudkeyaz.NewAzCloudSignatureKeyWithKID(
-			c, azKeysClient, issuerJwk.KeyID,
-			sigAlg,
-			true,
-			issuerJwk.PublicKey())
+		signer = keyStore.NewSignatureKey(c, issuerJwk.KeyID, sigAlg, true, issuerJwk.PublicKey())
 		signerChain = utils.MapSlice(issuerCertDoc.GetJsonWebKey().CertificateChain, func(b cloudkey.Base64RawURLEncodableBytes) []byte { return b })
 		publicKey, err = csr.PublicKey()
 		if err != nil {
@@ -114,7 +120,7 @@ func (doc *certDocInternal) CreateCertificate(c ctx.RequestContext, csr CertCSR)
 	return der, nil
 }
 
-func (d *certDocInternal) getCertificateTemplate() *x509.Certificate {
+func (d *certDocInternal) getCertificateTemplate(c ctx.RequestContext) (*x509.Certificate, error) {
 
 	cert := &x509.Certificate{
 		SerialNumber: big.NewInt(0).SetBytes(d.certUUID[:]),
@@ -129,10 +135,15 @@ func (d *certDocInternal) getCertificateTemplate() *x509.Certificate {
 		cert.BasicConstraintsValid = true
 		cert.IsCA = true
 		if d.PartitionKey.NamespaceProvider == models.NamespaceProviderRootCA {
-			cert.MaxPathLen = 1
+			cert.MaxPathLen = rootCAMaxPathLen
 			cert.MaxPathLenZero = false
 		} else {
 			cert.MaxPathLenZero = true
+			if d.constraints != nil {
+				if err := applyCAConstraints(cert, d.constraints); err != nil {
+					return nil, err
+				}
+			}
 		}
 	} else {
 		cert.KeyUsage |= x509.KeyUsageDigitalSignature
@@ -148,11 +159,15 @@ func (d *certDocInternal) getCertificateTemplate() *x509.Certificate {
 			slices.Contains(d.JsonWebKey.KeyOperations, cloudkey.JsonWebKeyOperationDeriveBits) {
 			cert.KeyUsage |= x509.KeyUsageKeyAgreement
 		}
-		if slices.Contains(d.Flags, certmodels.CertificateFlagServerAuth) {
-			cert.ExtKeyUsage = append(cert.ExtKeyUsage, x509.ExtKeyUsageServerAuth)
+		applyCertificateFlags(cert, d.Flags)
+	}
+
+	if d.Issuer != d.Identifier() {
+		if crlDP := getIssuerPublicURL(c, d.Issuer, "crl"); crlDP != "" {
+			cert.CRLDistributionPoints = []string{crlDP}
 		}
-		if slices.Contains(d.Flags, certmodels.CertificateFlagClientAuth) {
-			cert.ExtKeyUsage = append(cert.ExtKeyUsage, x509.ExtKeyUsageClientAuth)
+		if ocspServer := getIssuerPublicURL(c, d.Issuer, "ocsp"); ocspServer != "" {
+			cert.OCSPServer = []string{ocspServer}
 		}
 	}
 
@@ -160,47 +175,25 @@ func (d *certDocInternal) getCertificateTemplate() *x509.Certificate {
 		cert.DNSNames = d.SANs.DNSNames
 		cert.EmailAddresses = d.SANs.Emails
 		cert.IPAddresses = d.SANs.IPAddresses
+		for _, v := range d.SANs.URIs {
+			// URIs are validated when the policy is saved and when templates are evaluated
+			if uri, err := url.Parse(v); err == nil {
+				cert.URIs = append(cert.URIs, uri)
+			}
+		}
 	}
 
-	return cert
+	return cert, nil
 }
 
-func (d *certDocInternal) getAzCreateKeyParams() (params azkeys.CreateKeyParameters, err error) {
-	switch d.JsonWebKey.KeyType {
-	case cloudkey.KeyTypeEC:
-		params.Kty = to.Ptr(azkeys.KeyTypeEC)
-		switch d.JsonWebKey.Curve {
-		case cloudkey.CurveNameP256:
-			params.Curve = to.Ptr(azkeys.CurveNameP256)
-		case cloudkey.CurveNameP384:
-			params.Curve = to.Ptr(azkeys.CurveNameP384)
-		case cloudkey.CurveNameP521:
-			params.Curve = to.Ptr(azkeys.CurveNameP521)
-		default:
-			return params, cloudkey.ErrInvalidCurve
-		}
-	case cloudkey.KeyTypeRSA:
-		params.Kty = to.Ptr(azkeys.KeyTypeRSA)
-		switch d.rsaKeySize {
-		case 2048, 3072, 4096:
-			params.KeySize = to.Ptr(int32(d.rsaKeySize))
-		}
-	default:
-		return params, cloudkey.ErrInvalidKeyType
-	}
-	// keyops
-	params.KeyOps = make([]*azkeys.KeyOperation, len(d.JsonWebKey.KeyOperations))
-	for i, keyOp := range d.JsonWebKey.KeyOperations {
-		params.KeyOps[i] = to.Ptr(azkeys.KeyOperation(keyOp))
-	}
-	// exportable
-	params.KeyAttributes = &azkeys.KeyAttributes{
-		Exportable: d.JsonWebKey.Extractable,
-		NotBefore:  &d.NotBefore.Time,
-		Expires:    &d.NotAfter.Time,
-		Enabled:    to.Ptr(true),
+// getIssuerPublicURL returns the URL of an anonymous endpoint of the issuer certificate,
+// empty if the public base URL is not configured
+func getIssuerPublicURL(c ctx.RequestContext, issuer resdoc.DocIdentifier, endpoint string) string {
+	baseURL := api.GetPublicBaseURL(c)
+	if baseURL == "" {
+		return ""
 	}
-	return params, nil
+	return fmt.Sprintf("%s/v2/%s/%s/certificates/%s/%s", baseURL, issuer.NamespaceProvider, issuer.NamespaceID, issuer.ID, endpoint)
 }
 
 var _ CertDocumentPending = (*certDocInternal)(nil)
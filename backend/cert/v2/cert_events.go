@@ -0,0 +1,32 @@
+package cert
+
+import (
+	"context"
+
+	certmodels "github.com/stephenzsy/small-kms/backend/models/cert"
+	webhookmodels "github.com/stephenzsy/small-kms/backend/models/webhook"
+	"github.com/stephenzsy/small-kms/backend/webhook"
+)
+
+type certificateRenewedEventData struct {
+	*certmodels.Certificate
+	RenewedFrom string `json:"renewedFrom"`
+}
+
+func getCertificateEventSubject(certDoc CertDocument) string {
+	return "certificates/" + certDoc.Identifier().ID
+}
+
+// publishCertificateEvent notifies the webhook subscribers of the certificate namespace
+func publishCertificateEvent(c context.Context, certDoc CertDocument, eventType webhookmodels.WebhookEventType) {
+	identifier := certDoc.Identifier()
+	webhook.Publish(c, identifier.NamespaceProvider, identifier.NamespaceID, eventType,
+		getCertificateEventSubject(certDoc), certDoc.ToModel(false))
+}
+
+// publishCertificateEventInternal returns the error if the event could not be queued, so it can be retried
+func publishCertificateEventInternal(c context.Context, certDoc CertDocument, eventType webhookmodels.WebhookEventType) error {
+	identifier := certDoc.Identifier()
+	return webhook.PublishInternal(c, identifier.NamespaceProvider, identifier.NamespaceID, eventType,
+		getCertificateEventSubject(certDoc), certDoc.ToModel(false))
+}
@@ -0,0 +1,139 @@
+package cert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	ctx "github.com/stephenzsy/small-kms/backend/internal/context"
+	"github.com/stephenzsy/small-kms/backend/models"
+	certmodels "github.com/stephenzsy/small-kms/backend/models/cert"
+	webhookmodels "github.com/stephenzsy/small-kms/backend/models/webhook"
+	"github.com/stephenzsy/small-kms/backend/resdoc"
+	"github.com/stephenzsy/small-kms/backend/taskmanager"
+	"github.com/stephenzsy/small-kms/backend/utils"
+	"github.com/stephenzsy/small-kms/backend/utils/caldur"
+	"github.com/stephenzsy/small-kms/backend/webhook"
+)
+
+const (
+	EnvKeyCertExpiryNotificationWindow  = "CERT_EXPIRY_NOTIFICATION_WINDOW"
+	DefaultCertExpiryNotificationWindow = "P14D"
+
+	certExpiryScanInterval        = time.Hour
+	certExpiryNotifyLeaseDuration = 5 * time.Minute
+)
+
+func listExpiringCertsInternal(c context.Context, nsProvider models.NamespaceProvider, nsID string, now, notAfterBefore time.Time) ([]*CertQueryDoc, error) {
+	qb := resdoc.NewDefaultCosmoQueryBuilder().
+		WithExtraColumns(certDocQueryColStatus, certIssuedLinkQueryColNotAfter).
+		WithWhereClauses("c.status = @status", "c.exp > @now", "c.exp <= @notAfterBefore", "NOT IS_DEFINED(c.expiringNotified)")
+	qb.Parameters = append(qb.Parameters,
+		azcosmos.QueryParameter{Name: "@status", Value: certmodels.CertificateStatusIssued},
+		azcosmos.QueryParameter{Name: "@now", Value: now.Unix()},
+		azcosmos.QueryParameter{Name: "@notAfterBefore", Value: notAfterBefore.Unix()})
+	pager := resdoc.NewQueryDocPager[*CertQueryDoc](c, qb, resdoc.PartitionKey{
+		NamespaceProvider: nsProvider,
+		NamespaceID:       nsID,
+		ResourceProvider:  models.ResourceProviderCert,
+	})
+	return utils.PagerToSlice[*CertQueryDoc](pager)
+}
+
+type certExpiryScanTaskExecutor struct {
+	serviceContext     context.Context
+	notificationWindow caldur.CalendarDuration
+	leaseHolder        string
+}
+
+// notifyExpiring publishes the event once per certificate, the certificate is marked notified after the event is queued,
+// so a failed publish is retried by the next scan.
+// The lease keeps replicas scanning at the same time from publishing the event twice
+func (e *certExpiryScanTaskExecutor) notifyExpiring(c ctx.RequestContext, nsProvider models.NamespaceProvider, nsID string, certID string) error {
+	lease, err := resdoc.AcquireLease(c, resdoc.DocIdentifier{
+		PartitionKey: certRenewalLeasePartitionKey,
+		ID:           fmt.Sprintf("cert-expiring-%s-%s-%s", nsProvider, nsID, certID),
+	}, e.leaseHolder, certExpiryNotifyLeaseDuration)
+	if err != nil {
+		if errors.Is(err, resdoc.ErrLeaseHeld) {
+			return nil
+		}
+		return err
+	}
+	defer func() {
+		if err := lease.Release(c); err != nil {
+			log.Ctx(c).Error().Err(err).Str("cert", certID).Msg("failed to release certificate expiring lease")
+		}
+	}()
+
+	certDoc := &certDocBase{}
+	if err := readCertDocInternal(c, nsProvider, nsID, certID, certDoc); err != nil {
+		return err
+	}
+	if certDoc.ExpiringNotified != nil {
+		return nil
+	}
+	if err := publishCertificateEventInternal(c, certDoc, webhookmodels.EventTypeCertificateExpiring); err != nil {
+		return err
+	}
+	patchOps := azcosmos.PatchOperations{}
+	patchOps.AppendSet("/expiringNotified", time.Now().Unix())
+	_, err = resdoc.GetDocService(c).Patch(c, certDoc, patchOps, nil)
+	return err
+}
+
+// Close implements taskmanager.IntervalExecutor.
+func (*certExpiryScanTaskExecutor) Close(context.Context) error {
+	return nil
+}
+
+// Execute implements taskmanager.IntervalExecutor.
+func (e *certExpiryScanTaskExecutor) Execute(c context.Context) (time.Duration, error) {
+	logger := log.Ctx(c)
+	rc := ctx.NewBackgroundRequestContext(c, e.serviceContext)
+	subscriptions, err := webhook.ListSubscriptionsByEventTypeInternal(rc, webhookmodels.EventTypeCertificateExpiring)
+	if err != nil {
+		return certExpiryScanInterval, err
+	}
+
+	now := time.Now()
+	scanned := make(map[resdoc.PartitionKey]bool, len(subscriptions))
+	for _, subscription := range subscriptions {
+		if scanned[subscription.PartitionKey] {
+			continue
+		}
+		scanned[subscription.PartitionKey] = true
+		nsProvider, nsID := subscription.NamespaceProvider, subscription.NamespaceID
+		certs, err := listExpiringCertsInternal(rc, nsProvider, nsID, now, caldur.Shift(now, e.notificationWindow))
+		if err != nil {
+			logger.Error().Err(err).Str("namespace", fmt.Sprintf("%s/%s", nsProvider, nsID)).Msg("failed to list expiring certificates")
+			continue
+		}
+		for _, cert := range certs {
+			if err := e.notifyExpiring(rc, nsProvider, nsID, cert.ID); err != nil {
+				logger.Error().Err(err).Str("cert", cert.ID).Msg("failed to notify expiring certificate")
+			}
+		}
+	}
+	return certExpiryScanInterval, nil
+}
+
+// Name implements taskmanager.IntervalExecutor.
+func (*certExpiryScanTaskExecutor) Name() string {
+	return "CertExpiryScan"
+}
+
+var _ taskmanager.IntervalExecutor = (*certExpiryScanTaskExecutor)(nil)
+
+// NewCertExpiryScanTaskExecutor notifies the webhook subscribers of certificates expiring within the notification window
+func NewCertExpiryScanTaskExecutor(serviceContext context.Context, notificationWindow caldur.CalendarDuration) taskmanager.IntervalExecutor {
+	return &certExpiryScanTaskExecutor{
+		serviceContext:     serviceContext,
+		notificationWindow: notificationWindow,
+		leaseHolder:        uuid.NewString(),
+	}
+}
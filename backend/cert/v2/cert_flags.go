@@ -0,0 +1,197 @@
+package cert
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+	"slices"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azcertificates"
+	"github.com/stephenzsy/small-kms/backend/base"
+	certmodels "github.com/stephenzsy/small-kms/backend/models/cert"
+)
+
+var (
+	oidExtensionExtendedKeyUsage  = asn1.ObjectIdentifier{2, 5, 29, 37}
+	oidExtKeyUsageServerAuth      = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 3, 1}
+	oidExtKeyUsageClientAuth      = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 3, 2}
+	oidExtKeyUsageCodeSigning     = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 3, 3}
+	oidExtKeyUsageEmailProtection = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 3, 4}
+	oidExtKeyUsageTimeStamping    = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 3, 8}
+	oidExtKeyUsageOCSPSigning     = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 3, 9}
+	// id-kp-documentSigning, RFC 9336
+	oidExtKeyUsageDocumentSigning = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 3, 36}
+)
+
+type certFlagUsage struct {
+	keyUsage x509.KeyUsage
+	// extended key usage known to crypto/x509, ExtKeyUsageAny if it is not
+	extKeyUsage    x509.ExtKeyUsage
+	extKeyUsageOID asn1.ObjectIdentifier
+	// must be the only extended key usage of the certificate
+	exclusive    bool
+	incompatible []certmodels.CertificateFlag
+}
+
+// certFlags is the canonical order of the flags
+var certFlags = []certmodels.CertificateFlag{
+	certmodels.CertificateFlagServerAuth,
+	certmodels.CertificateFlagClientAuth,
+	certmodels.CertificateFlagCodeSigning,
+	certmodels.CertificateFlagEmailProtection,
+	certmodels.CertificateFlagOCSPSigning,
+	certmodels.CertificateFlagTimeStamping,
+	certmodels.CertificateFlagDocumentSigning,
+}
+
+var certFlagUsages = map[certmodels.CertificateFlag]certFlagUsage{
+	certmodels.CertificateFlagServerAuth: {
+		keyUsage:       x509.KeyUsageDigitalSignature,
+		extKeyUsage:    x509.ExtKeyUsageServerAuth,
+		extKeyUsageOID: oidExtKeyUsageServerAuth,
+	},
+	certmodels.CertificateFlagClientAuth: {
+		keyUsage:       x509.KeyUsageDigitalSignature,
+		extKeyUsage:    x509.ExtKeyUsageClientAuth,
+		extKeyUsageOID: oidExtKeyUsageClientAuth,
+	},
+	// CA/Browser Forum code signing requirements prohibit serverAuth
+	certmodels.CertificateFlagCodeSigning: {
+		keyUsage:       x509.KeyUsageDigitalSignature,
+		extKeyUsage:    x509.ExtKeyUsageCodeSigning,
+		extKeyUsageOID: oidExtKeyUsageCodeSigning,
+		incompatible:   []certmodels.CertificateFlag{certmodels.CertificateFlagServerAuth},
+	},
+	// CA/Browser Forum S/MIME requirements prohibit serverAuth
+	certmodels.CertificateFlagEmailProtection: {
+		keyUsage:       x509.KeyUsageDigitalSignature,
+		extKeyUsage:    x509.ExtKeyUsageEmailProtection,
+		extKeyUsageOID: oidExtKeyUsageEmailProtection,
+		incompatible:   []certmodels.CertificateFlag{certmodels.CertificateFlagServerAuth},
+	},
+	certmodels.CertificateFlagOCSPSigning: {
+		keyUsage:       x509.KeyUsageDigitalSignature,
+		extKeyUsage:    x509.ExtKeyUsageOCSPSigning,
+		extKeyUsageOID: oidExtKeyUsageOCSPSigning,
+		exclusive:      true,
+	},
+	// RFC 3161 section 2.3, the extension must be critical
+	certmodels.CertificateFlagTimeStamping: {
+		keyUsage:       x509.KeyUsageDigitalSignature,
+		extKeyUsage:    x509.ExtKeyUsageTimeStamping,
+		extKeyUsageOID: oidExtKeyUsageTimeStamping,
+		exclusive:      true,
+	},
+	// RFC 9336 section 3, not to be mixed with TLS usages
+	certmodels.CertificateFlagDocumentSigning: {
+		keyUsage:       x509.KeyUsageDigitalSignature | x509.KeyUsageContentCommitment,
+		extKeyUsage:    x509.ExtKeyUsageAny,
+		extKeyUsageOID: oidExtKeyUsageDocumentSigning,
+		incompatible:   []certmodels.CertificateFlag{certmodels.CertificateFlagServerAuth, certmodels.CertificateFlagClientAuth},
+	},
+}
+
+// validateCertificateFlags returns the flags in canonical order,
+// unknown flags and usages that cannot be combined in one certificate are rejected
+func validateCertificateFlags(flags []certmodels.CertificateFlag) ([]certmodels.CertificateFlag, error) {
+	for _, flag := range flags {
+		if _, ok := certFlagUsages[flag]; !ok {
+			return nil, fmt.Errorf("%w: unsupported certificate flag: %s", base.ErrResponseStatusBadRequest, flag)
+		}
+	}
+	validated := make([]certmodels.CertificateFlag, 0, len(flags))
+	for _, flag := range certFlags {
+		if slices.Contains(flags, flag) {
+			validated = append(validated, flag)
+		}
+	}
+	for _, flag := range validated {
+		usage := certFlagUsages[flag]
+		if usage.exclusive && len(validated) > 1 {
+			return nil, fmt.Errorf("%w: %s cannot be combined with other usages", base.ErrResponseStatusBadRequest, flag)
+		}
+		for _, other := range usage.incompatible {
+			if slices.Contains(validated, other) {
+				return nil, fmt.Errorf("%w: %s cannot be combined with %s", base.ErrResponseStatusBadRequest, flag, other)
+			}
+		}
+	}
+	return validated, nil
+}
+
+// applyCertificateFlags sets the key usages and extended key usages of the flags on the template
+func applyCertificateFlags(cert *x509.Certificate, flags []certmodels.CertificateFlag) {
+	for _, flag := range certFlags {
+		if !slices.Contains(flags, flag) {
+			continue
+		}
+		usage := certFlagUsages[flag]
+		cert.KeyUsage |= usage.keyUsage
+		if usage.extKeyUsage != x509.ExtKeyUsageAny {
+			cert.ExtKeyUsage = append(cert.ExtKeyUsage, usage.extKeyUsage)
+		} else {
+			cert.UnknownExtKeyUsage = append(cert.UnknownExtKeyUsage, usage.extKeyUsageOID)
+		}
+		if flag == certmodels.CertificateFlagTimeStamping {
+			// overrides the non-critical extension crypto/x509 would add
+			cert.ExtraExtensions = append(cert.ExtraExtensions, newCriticalExtKeyUsageExtension(usage.extKeyUsageOID))
+		}
+	}
+}
+
+func newCriticalExtKeyUsageExtension(oids ...asn1.ObjectIdentifier) pkix.Extension {
+	value, err := asn1.Marshal(oids)
+	if err != nil {
+		// a sequence of valid OIDs always marshals
+		panic(err)
+	}
+	return pkix.Extension{Id: oidExtensionExtendedKeyUsage, Critical: true, Value: value}
+}
+
+// parseCertificateFlags returns the flags of the extended key usages of the certificate
+func parseCertificateFlags(cert *x509.Certificate) []certmodels.CertificateFlag {
+	flags := make([]certmodels.CertificateFlag, 0, len(cert.ExtKeyUsage)+len(cert.UnknownExtKeyUsage))
+	for _, flag := range certFlags {
+		usage := certFlagUsages[flag]
+		if usage.extKeyUsage != x509.ExtKeyUsageAny {
+			if slices.Contains(cert.ExtKeyUsage, usage.extKeyUsage) {
+				flags = append(flags, flag)
+			}
+		} else if slices.ContainsFunc(cert.UnknownExtKeyUsage, usage.extKeyUsageOID.Equal) {
+			flags = append(flags, flag)
+		}
+	}
+	return flags
+}
+
+var azCertKeyUsageTypes = []struct {
+	keyUsage x509.KeyUsage
+	azType   azcertificates.KeyUsageType
+}{
+	{x509.KeyUsageDigitalSignature, azcertificates.KeyUsageTypeDigitalSignature},
+	{x509.KeyUsageContentCommitment, azcertificates.KeyUsageTypeNonRepudiation},
+	{x509.KeyUsageKeyEncipherment, azcertificates.KeyUsageTypeKeyEncipherment},
+	{x509.KeyUsageDataEncipherment, azcertificates.KeyUsageTypeDataEncipherment},
+	{x509.KeyUsageKeyAgreement, azcertificates.KeyUsageTypeKeyAgreement},
+}
+
+// getAzCertUsages returns the key usages and extended key usages of the flags for Key Vault certificate policies
+func getAzCertUsages(flags []certmodels.CertificateFlag) (keyUsage []*azcertificates.KeyUsageType, ekus []*string) {
+	var ku x509.KeyUsage
+	for _, flag := range certFlags {
+		if !slices.Contains(flags, flag) {
+			continue
+		}
+		usage := certFlagUsages[flag]
+		ku |= usage.keyUsage
+		ekus = append(ekus, to.Ptr(usage.extKeyUsageOID.String()))
+	}
+	for _, t := range azCertKeyUsageTypes {
+		if ku&t.keyUsage != 0 {
+			keyUsage = append(keyUsage, to.Ptr(t.azType))
+		}
+	}
+	return keyUsage, ekus
+}
@@ -0,0 +1,86 @@
+package cert
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stephenzsy/small-kms/backend/base"
+	certmodels "github.com/stephenzsy/small-kms/backend/models/cert"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateCertificateFlags(t *testing.T) {
+	flags, err := validateCertificateFlags([]certmodels.CertificateFlag{
+		certmodels.CertificateFlagEmailProtection,
+		certmodels.CertificateFlagClientAuth,
+		certmodels.CertificateFlagClientAuth,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []certmodels.CertificateFlag{
+		certmodels.CertificateFlagClientAuth,
+		certmodels.CertificateFlagEmailProtection,
+	}, flags)
+
+	for _, rejected := range [][]certmodels.CertificateFlag{
+		{"unknown"},
+		{certmodels.CertificateFlagServerAuth, certmodels.CertificateFlagCodeSigning},
+		{certmodels.CertificateFlagServerAuth, certmodels.CertificateFlagEmailProtection},
+		{certmodels.CertificateFlagClientAuth, certmodels.CertificateFlagDocumentSigning},
+		{certmodels.CertificateFlagCodeSigning, certmodels.CertificateFlagTimeStamping},
+		{certmodels.CertificateFlagClientAuth, certmodels.CertificateFlagOCSPSigning},
+	} {
+		_, err := validateCertificateFlags(rejected)
+		assert.ErrorIs(t, err, base.ErrResponseStatusBadRequest, "%v", rejected)
+	}
+}
+
+func TestCertificateFlagsRoundTrip(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	for _, flags := range [][]certmodels.CertificateFlag{
+		{certmodels.CertificateFlagServerAuth, certmodels.CertificateFlagClientAuth},
+		{certmodels.CertificateFlagCodeSigning, certmodels.CertificateFlagEmailProtection},
+		{certmodels.CertificateFlagOCSPSigning},
+		{certmodels.CertificateFlagTimeStamping},
+		{certmodels.CertificateFlagEmailProtection, certmodels.CertificateFlagDocumentSigning},
+	} {
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(1),
+			Subject:      pkix.Name{CommonName: "test"},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+		}
+		applyCertificateFlags(template, flags)
+		der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+		require.NoError(t, err)
+		cert, err := x509.ParseCertificate(der)
+		require.NoError(t, err)
+
+		assert.Equal(t, flags, parseCertificateFlags(cert))
+		if slices.Contains(flags, certmodels.CertificateFlagDocumentSigning) {
+			assert.NotZero(t, cert.KeyUsage&x509.KeyUsageContentCommitment)
+		}
+
+		ekuExts := 0
+		for _, ext := range cert.Extensions {
+			if ext.Id.Equal(oidExtensionExtendedKeyUsage) {
+				ekuExts++
+				assert.Equal(t, slices.Contains(flags, certmodels.CertificateFlagTimeStamping), ext.Critical)
+			}
+		}
+		assert.Equal(t, 1, ekuExts)
+
+		keyUsage, ekus := getAzCertUsages(flags)
+		assert.Len(t, ekus, len(flags))
+		assert.NotEmpty(t, keyUsage)
+	}
+}
@@ -0,0 +1,129 @@
+package cert
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
+	ctx "github.com/stephenzsy/small-kms/backend/internal/context"
+	"github.com/stephenzsy/small-kms/backend/models"
+	certmodels "github.com/stephenzsy/small-kms/backend/models/cert"
+	"github.com/stephenzsy/small-kms/backend/resdoc"
+	"github.com/stephenzsy/small-kms/backend/utils"
+)
+
+// certIssuedLinkDoc is stored in the link partition of the issuer namespace,
+// so certificates issued by a CA can be found without a cross partition query
+type certIssuedLinkDoc struct {
+	resdoc.LinkResourceDoc
+	Issuer       resdoc.DocIdentifier              `json:"issuer"`
+	Status       certmodels.CertificateStatus      `json:"status"`
+	SerialNumber []byte                            `json:"serialNumber"`
+	NotAfter     resdoc.NumericDate                `json:"exp"`
+	Revocation   *certmodels.CertificateRevocation `json:"revocation,omitempty"`
+}
+
+const (
+	certIssuedLinkQueryColLinkTo       = "c.linkTo"
+	certIssuedLinkQueryColIssuer       = "c.issuer"
+	certIssuedLinkQueryColStatus       = "c.status"
+	certIssuedLinkQueryColSerialNumber = "c.serialNumber"
+	certIssuedLinkQueryColNotAfter     = "c.exp"
+	certIssuedLinkQueryColRevocation   = "c.revocation"
+)
+
+func getIssuedCertLinkID(certID string) string {
+	return fmt.Sprintf("%s-%s", models.LinkProviderIssuedCertificate, certID)
+}
+
+func isInternalIssuer(issuer resdoc.DocIdentifier) bool {
+	return issuer.ResourceProvider == models.ResourceProviderCert &&
+		(issuer.NamespaceProvider == models.NamespaceProviderRootCA || issuer.NamespaceProvider == models.NamespaceProviderIntermediateCA)
+}
+
+// syncIssuedCertLinkInternal records the certificate state under its issuer, self-signed certificates are skipped
+func syncIssuedCertLinkInternal(c ctx.RequestContext, certDoc CertDocument) error {
+	issuer := certDoc.GetIssuer()
+	if !isInternalIssuer(issuer) || issuer == certDoc.Identifier() {
+		return nil
+	}
+	doc := &certIssuedLinkDoc{
+		LinkResourceDoc: resdoc.LinkResourceDoc{
+			ResourceDoc: resdoc.ResourceDoc{
+				PartitionKey: resdoc.PartitionKey{
+					NamespaceProvider: issuer.NamespaceProvider,
+					NamespaceID:       issuer.NamespaceID,
+					ResourceProvider:  models.ResourceProviderLink,
+				},
+				ID: getIssuedCertLinkID(certDoc.Identifier().ID),
+			},
+			LinkTo:       certDoc.Identifier(),
+			LinkProvider: models.LinkProviderIssuedCertificate,
+		},
+		Issuer:       issuer,
+		Status:       certDoc.GetStatus(),
+		SerialNumber: certDoc.GetSerialNumber(),
+		Revocation:   certDoc.GetRevocation(),
+	}
+	doc.NotAfter.Time = certDoc.GetNotAfter()
+	_, err := resdoc.GetDocService(c).Upsert(c, doc, nil)
+	return err
+}
+
+func listIssuedCertLinksInternal(c ctx.RequestContext, issuer resdoc.DocIdentifier) ([]*certIssuedLinkDoc, error) {
+	qb := resdoc.NewDefaultCosmoQueryBuilder().
+		WithExtraColumns(certIssuedLinkQueryColLinkTo, certIssuedLinkQueryColIssuer).
+		WithWhereClauses("c.linkProvider = @linkProvider", "c.issuer = @issuer")
+	qb.Parameters = append(qb.Parameters,
+		azcosmos.QueryParameter{Name: "@linkProvider", Value: models.LinkProviderIssuedCertificate},
+		azcosmos.QueryParameter{Name: "@issuer", Value: issuer.String()})
+	pager := resdoc.NewQueryDocPager[*certIssuedLinkDoc](c, qb, resdoc.PartitionKey{
+		NamespaceProvider: issuer.NamespaceProvider,
+		NamespaceID:       issuer.NamespaceID,
+		ResourceProvider:  models.ResourceProviderLink,
+	})
+	return utils.PagerToSlice[*certIssuedLinkDoc](pager)
+}
+
+// listRevokedCertLinksInternal returns revoked and deactivated certificates of the issuer which have not expired
+func listRevokedCertLinksInternal(c ctx.RequestContext, issuer resdoc.DocIdentifier) ([]*certIssuedLinkDoc, error) {
+	qb := resdoc.NewDefaultCosmoQueryBuilder().
+		WithExtraColumns(certIssuedLinkQueryColStatus, certIssuedLinkQueryColSerialNumber,
+			certIssuedLinkQueryColNotAfter, certIssuedLinkQueryColRevocation).
+		WithWhereClauses("c.linkProvider = @linkProvider", "c.issuer = @issuer",
+			"c.status = @statusRevoked OR c.status = @statusDeactivated", "c.exp > @now")
+	qb.Parameters = append(qb.Parameters,
+		azcosmos.QueryParameter{Name: "@linkProvider", Value: models.LinkProviderIssuedCertificate},
+		azcosmos.QueryParameter{Name: "@issuer", Value: issuer.String()},
+		azcosmos.QueryParameter{Name: "@statusRevoked", Value: certmodels.CertificateStatusRevoked},
+		azcosmos.QueryParameter{Name: "@statusDeactivated", Value: certmodels.CertificateStatusDeactivated},
+		azcosmos.QueryParameter{Name: "@now", Value: time.Now().Unix()})
+	pager := resdoc.NewQueryDocPager[*certIssuedLinkDoc](c, qb, resdoc.PartitionKey{
+		NamespaceProvider: issuer.NamespaceProvider,
+		NamespaceID:       issuer.NamespaceID,
+		ResourceProvider:  models.ResourceProviderLink,
+	})
+	return utils.PagerToSlice[*certIssuedLinkDoc](pager)
+}
+
+// findIssuedCertLinkBySerialNumberInternal returns nil if no certificate issued by the issuer has the serial number
+func findIssuedCertLinkBySerialNumberInternal(c ctx.RequestContext, issuer resdoc.DocIdentifier, serialNumber []byte) (*certIssuedLinkDoc, error) {
+	qb := resdoc.NewDefaultCosmoQueryBuilder().
+		WithExtraColumns(certIssuedLinkQueryColLinkTo, certIssuedLinkQueryColStatus, certIssuedLinkQueryColSerialNumber,
+			certIssuedLinkQueryColNotAfter, certIssuedLinkQueryColRevocation).
+		WithWhereClauses("c.linkProvider = @linkProvider", "c.issuer = @issuer", "c.serialNumber = @serialNumber")
+	qb.Parameters = append(qb.Parameters,
+		azcosmos.QueryParameter{Name: "@linkProvider", Value: models.LinkProviderIssuedCertificate},
+		azcosmos.QueryParameter{Name: "@issuer", Value: issuer.String()},
+		azcosmos.QueryParameter{Name: "@serialNumber", Value: serialNumber})
+	pager := resdoc.NewQueryDocPager[*certIssuedLinkDoc](c, qb, resdoc.PartitionKey{
+		NamespaceProvider: issuer.NamespaceProvider,
+		NamespaceID:       issuer.NamespaceID,
+		ResourceProvider:  models.ResourceProviderLink,
+	})
+	links, err := utils.PagerToSlice[*certIssuedLinkDoc](pager)
+	if err != nil || len(links) == 0 {
+		return nil, err
+	}
+	return links[0], nil
+}
@@ -1,8 +1,10 @@
 package cert
 
 import (
+	"context"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azsecrets"
 	cloudkey "github.com/stephenzsy/small-kms/backend/cloud/key"
-	cloudkeyaz "github.com/stephenzsy/small-kms/backend/cloud/key/az"
 	ctx "github.com/stephenzsy/small-kms/backend/internal/context"
 	kv "github.com/stephenzsy/small-kms/backend/internal/keyvault"
 	"github.com/stephenzsy/small-kms/backend/key/v2"
@@ -12,12 +14,27 @@ import (
 	"golang.org/x/crypto/acme"
 )
 
+type CertIssuerDocACMEHTTP01Solver struct {
+	AgentNamespaceID string `json:"agentNamespaceId"`
+}
+
+type CertIssuerDocACMEDNS01Solver struct {
+	Nameserver    string `json:"nameserver"`
+	Zone          string `json:"zone"`
+	TSIGKeyName   string `json:"tsigKeyName"`
+	TSIGAlgorithm string `json:"tsigAlgorithm"`
+	// Key Vault secret ID of the TSIG secret
+	TSIGSecretID azsecrets.ID `json:"tsigSecretId"`
+}
+
 type CertIssuerDocACME struct {
-	DirectoryURL   string   `json:"directoryUrl"`
-	AccountURI     string   `json:"accountUri"`
-	AccountKeyID   string   `json:"accountKeyId"`
-	AccountContact []string `json:"accountContact"`
-	AccountStatus  string   `json:"accountStatus"`
+	DirectoryURL   string                         `json:"directoryUrl"`
+	AccountURI     string                         `json:"accountUri"`
+	AccountKeyID   string                         `json:"accountKeyId"`
+	AccountContact []string                       `json:"accountContact"`
+	AccountStatus  string                         `json:"accountStatus"`
+	HTTP01Solver   *CertIssuerDocACMEHTTP01Solver `json:"http01Solver,omitempty"`
+	DNS01Solver    *CertIssuerDocACMEDNS01Solver  `json:"dns01Solver,omitempty"`
 }
 
 type CertIssuerDoc struct {
@@ -46,9 +63,57 @@ func (d *CertIssuerDoc) ToModel() *certmodels.CertificateExternalIssuer {
 			},
 		},
 	}
+	if d.ACME.HTTP01Solver != nil {
+		m.Acme.Http01Solver = &certmodels.CertificateExternalIssuerAcmeHttp01Solver{
+			AgentNamespaceID: d.ACME.HTTP01Solver.AgentNamespaceID,
+		}
+	}
+	if d.ACME.DNS01Solver != nil {
+		m.Acme.Dns01Solver = &certmodels.CertificateExternalIssuerAcmeDns01Solver{
+			Nameserver:    d.ACME.DNS01Solver.Nameserver,
+			Zone:          d.ACME.DNS01Solver.Zone,
+			TsigKeyName:   d.ACME.DNS01Solver.TSIGKeyName,
+			TsigAlgorithm: d.ACME.DNS01Solver.TSIGAlgorithm,
+		}
+	}
 	return m
 }
 
+// acmeSolvers returns the configured challenge solvers keyed by challenge type
+func (d *CertIssuerDoc) acmeSolvers() map[string]acmeChallengeSolver {
+	solvers := make(map[string]acmeChallengeSolver, 2)
+	if d == nil || d.ACME == nil {
+		return solvers
+	}
+	if s := d.ACME.HTTP01Solver; s != nil {
+		solvers[acmeChallengeTypeHTTP01] = &acmeHTTP01AgentSolver{
+			agentNamespaceID: s.AgentNamespaceID,
+		}
+	}
+	if s := d.ACME.DNS01Solver; s != nil {
+		solver := &acmeDNS01RFC2136Solver{
+			provider: &rfc2136Provider{
+				nameserver:    s.Nameserver,
+				zone:          s.Zone,
+				tsigKeyName:   s.TSIGKeyName,
+				tsigAlgorithm: s.TSIGAlgorithm,
+			},
+		}
+		if s.TSIGSecretID != "" {
+			secretID := s.TSIGSecretID
+			solver.getSecret = func(c context.Context) (string, error) {
+				resp, err := kv.GetAzKeyVaultService(c).AzSecretsClient().GetSecret(c, secretID.Name(), secretID.Version(), nil)
+				if err != nil {
+					return "", err
+				}
+				return *resp.Value, nil
+			}
+		}
+		solvers[acmeChallengeTypeDNS01] = solver
+	}
+	return solvers
+}
+
 func (d *CertIssuerDoc) ACMEClient(c ctx.RequestContext) (*acme.Client, error) {
 	if d.acmeClient == nil {
 		// load cloudKey
@@ -56,7 +121,7 @@ func (d *CertIssuerDoc) ACMEClient(c ctx.RequestContext) (*acme.Client, error) {
 		if err != nil {
 			return nil, err
 		}
-		ck := cloudkeyaz.NewAzCloudSignatureKeyWithKID(c, kv.GetAzKeyVaultService(c).AzKeysClient(), keyDoc.KeyID, cloudkey.SignatureAlgorithmES384, true, keyDoc.PublicKey())
+		ck := kv.GetCloudKeyStore(c).NewSignatureKey(c, keyDoc.KeyID, cloudkey.SignatureAlgorithmES384, true, keyDoc.PublicKey())
 		d.acmeClient = &acme.Client{
 			DirectoryURL: d.ACME.DirectoryURL,
 			Key:          ck,
@@ -0,0 +1,160 @@
+package cert
+
+import (
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
+	"github.com/stephenzsy/small-kms/backend/base"
+	cloudkey "github.com/stephenzsy/small-kms/backend/cloud/key"
+	ctx "github.com/stephenzsy/small-kms/backend/internal/context"
+	kv "github.com/stephenzsy/small-kms/backend/internal/keyvault"
+	"github.com/stephenzsy/small-kms/backend/models"
+	certmodels "github.com/stephenzsy/small-kms/backend/models/cert"
+	"github.com/stephenzsy/small-kms/backend/resdoc"
+)
+
+const (
+	ocspResponderCertValidity    = 30 * 24 * time.Hour
+	ocspResponderCertRenewBefore = 7 * 24 * time.Hour
+)
+
+// id-pkix-ocsp-nocheck, RFC 6960 section 4.2.2.2.1
+var oidOCSPNoCheck = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 5}
+
+// certOCSPResponderDoc holds the delegated OCSP signing certificate of a CA certificate,
+// it shares the ID of the CA certificate
+type certOCSPResponderDoc struct {
+	resdoc.ResourceDoc
+	JsonWebKey cloudkey.JsonWebKey `json:"jwk"`
+	NotAfter   resdoc.NumericDate  `json:"exp"`
+}
+
+func (d *certOCSPResponderDoc) needsRenewal(issuerCert *x509.Certificate) bool {
+	if len(d.JsonWebKey.CertificateChain) == 0 {
+		return true
+	}
+	if time.Until(d.NotAfter.Time) < ocspResponderCertRenewBefore {
+		// do not renew if the issuer expires first
+		return d.NotAfter.Before(issuerCert.NotAfter)
+	}
+	return false
+}
+
+func (d *certOCSPResponderDoc) X509Certificate() (*x509.Certificate, error) {
+	return x509.ParseCertificate(d.JsonWebKey.CertificateChain[0])
+}
+
+func (d *certOCSPResponderDoc) getCloudSignatureKey(c ctx.RequestContext) cloudkey.CloudSignatureKey {
+	return kv.GetCloudKeyStore(c).NewSignatureKey(c, d.JsonWebKey.KeyID, cloudkey.SignatureAlgorithmES256, true, d.JsonWebKey.PublicKey())
+}
+
+// getOCSPResponderInternal returns the OCSP responder of the issuer, the responder certificate is
+// issued by the issuer certificate on first use and renewed before it expires
+func getOCSPResponderInternal(c ctx.RequestContext, issuerDoc *certDocBase, issuerCert *x509.Certificate) (*certOCSPResponderDoc, error) {
+	docSvc := resdoc.GetDocService(c)
+	doc := &certOCSPResponderDoc{}
+	var upsertOpts *azcosmos.ItemOptions
+	if err := docSvc.Read(c, resdoc.NewDocIdentifier(issuerDoc.PartitionKey.NamespaceProvider, issuerDoc.PartitionKey.NamespaceID,
+		models.ResourceProviderCertOCSPResponder, issuerDoc.ID), doc, nil); err != nil {
+		if !errors.Is(err, resdoc.ErrAzCosmosDocNotFound) {
+			return nil, err
+		}
+		doc.PartitionKey = resdoc.PartitionKey{
+			NamespaceProvider: issuerDoc.PartitionKey.NamespaceProvider,
+			NamespaceID:       issuerDoc.PartitionKey.NamespaceID,
+			ResourceProvider:  models.ResourceProviderCertOCSPResponder,
+		}
+		doc.ID = issuerDoc.ID
+	} else if !doc.needsRenewal(issuerCert) {
+		return doc, nil
+	} else {
+		upsertOpts = &azcosmos.ItemOptions{
+			IfMatchEtag: doc.GetETag(),
+		}
+	}
+
+	if issuerDoc.Status != certmodels.CertificateStatusIssued {
+		return nil, fmt.Errorf("%w: issuer certificate is not active", base.ErrResponseStatusBadRequest)
+	}
+
+	now := time.Now().Truncate(time.Second)
+	notAfter := now.Add(ocspResponderCertValidity)
+	if notAfter.After(issuerCert.NotAfter) {
+		notAfter = issuerCert.NotAfter
+	}
+
+	keyStore := kv.GetCloudKeyStore(c)
+	created, err := keyStore.CreateKey(c,
+		kv.GetMaterialName(kv.MaterialNameKindOCSPKey, issuerDoc.PartitionKey.NamespaceProvider, issuerDoc.PartitionKey.NamespaceID, issuerDoc.ID),
+		cloudkey.CreateKeyParams{
+			KeyType:       cloudkey.KeyTypeEC,
+			Curve:         cloudkey.CurveNameP256,
+			KeyOperations: []cloudkey.JsonWebKeyOperation{cloudkey.JsonWebKeyOperationSign, cloudkey.JsonWebKeyOperationVerify},
+			Exportable:    to.Ptr(false),
+			NotBefore:     &now,
+			Expires:       &notAfter,
+		})
+	if err != nil {
+		return nil, err
+	}
+	ck := keyStore.NewSignatureKey(c, created.KeyID, cloudkey.SignatureAlgorithmES256, true, created.PublicKey())
+
+	serialNumber, err := rand.Int(rand.Reader, big.NewInt(0).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject: pkix.Name{
+			CommonName: fmt.Sprintf("%s OCSP Responder", issuerCert.Subject.CommonName),
+		},
+		NotBefore:   now,
+		NotAfter:    notAfter,
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageOCSPSigning},
+		ExtraExtensions: []pkix.Extension{
+			{Id: oidOCSPNoCheck, Value: asn1.NullBytes},
+		},
+	}
+	issuerSigner, sigAlg := issuerDoc.getCloudSignatureKey(c)
+	template.SignatureAlgorithm = sigAlg.X509SignatureAlgorithm()
+	signed, err := x509.CreateCertificate(rand.Reader, template, issuerCert, ck.Public(), issuerSigner)
+	if err != nil {
+		return nil, err
+	}
+
+	doc.JsonWebKey = cloudkey.JsonWebKey{
+		KeyType: cloudkey.KeyTypeEC,
+		Curve:   cloudkey.CurveNameP256,
+		Alg:     string(cloudkey.SignatureAlgorithmES256),
+		KeyID:   created.KeyID,
+		KeyOperations: []cloudkey.JsonWebKeyOperation{
+			cloudkey.JsonWebKeyOperationSign,
+			cloudkey.JsonWebKeyOperationVerify,
+		},
+		CertificateChain: []cloudkey.Base64RawURLEncodableBytes{signed},
+	}
+	if err := doc.JsonWebKey.SetPublicKey(ck.Public()); err != nil {
+		return nil, err
+	}
+	sha1d := sha1.Sum(signed)
+	doc.JsonWebKey.ThumbprintSHA1 = sha1d[:]
+	sha256d := sha256.Sum256(signed)
+	doc.JsonWebKey.ThumbprintSHA256 = sha256d[:]
+	doc.NotAfter.Time = notAfter
+
+	if _, err := docSvc.Upsert(c, doc, upsertOpts); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
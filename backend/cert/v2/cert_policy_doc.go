@@ -32,6 +32,9 @@ type CertPolicyDoc struct {
 	SANs          *certmodels.SubjectAlternativeNames `json:"sans,omitempty"`
 	Flags         []certmodels.CertificateFlag        `json:"flags,omitempty"`
 	IssuerPolicy  resdoc.DocIdentifier                `json:"issuerPolicy"`
+	caConstraints
+	// serve time-stamping requests, intermediate CA only
+	AllowTimestamping bool `json:"allowTimestamping,omitempty"`
 
 	Version []byte `json:"version"`
 }
@@ -159,12 +162,11 @@ func (d *CertPolicyDoc) init(
 		if len(p.Flags) == 0 {
 			d.Flags = []certmodels.CertificateFlag{certmodels.CertificateFlagServerAuth, certmodels.CertificateFlagClientAuth}
 		} else {
-			if slices.Contains(p.Flags, certmodels.CertificateFlagServerAuth) {
-				d.Flags = append(d.Flags, certmodels.CertificateFlagServerAuth)
-			}
-			if slices.Contains(p.Flags, certmodels.CertificateFlagClientAuth) {
-				d.Flags = append(d.Flags, certmodels.CertificateFlagClientAuth)
+			flags, err := validateCertificateFlags(p.Flags)
+			if err != nil {
+				return err
 			}
+			d.Flags = flags
 		}
 		if len(d.Flags) == 0 {
 			return fmt.Errorf("%w: certificate must have at least one usage flag", base.ErrResponseStatusBadRequest)
@@ -173,11 +175,33 @@ func (d *CertPolicyDoc) init(
 		return fmt.Errorf("%w: unsupported namespace provider: %s", base.ErrResponseStatusBadRequest, nsProvider)
 	}
 
+	cc, err := newCAConstraints(p.MaxPathLen, p.NameConstraints, p.PolicyIdentifiers)
+	if err != nil {
+		return err
+	}
+	if nsProvider == models.NamespaceProviderIntermediateCA {
+		d.caConstraints = cc
+	} else if !cc.isEmpty() {
+		return fmt.Errorf("%w: max path length, name constraints and policy identifiers are only supported by intermediate CA policies", base.ErrResponseStatusBadRequest)
+	}
+	if p.AllowTimestamping != nil && *p.AllowTimestamping {
+		if nsProvider != models.NamespaceProviderIntermediateCA {
+			return fmt.Errorf("%w: time stamping is only supported by intermediate CA policies", base.ErrResponseStatusBadRequest)
+		}
+		d.AllowTimestamping = true
+	}
+
 	var pAlg cloudkey.JsonWebSignatureAlgorithm
 
 	if p.KeySpec != nil {
 		ks := *p.KeySpec
 		pAlg = cloudkey.JsonWebSignatureAlgorithm(ks.Alg)
+		if ks.KeyStore != "" {
+			if !ks.KeyStore.IsSupported() {
+				return fmt.Errorf("%w: unsupported key store: %s", base.ErrResponseStatusBadRequest, ks.KeyStore)
+			}
+			d.KeySpec.KeyStore = ks.KeyStore
+		}
 		switch ks.Kty {
 		case cloudkey.KeyTypeRSA:
 			d.KeySpec.Kty = cloudkey.KeyTypeRSA
@@ -200,6 +224,18 @@ func (d *CertPolicyDoc) init(
 				d.KeySpec.Crv = ks.Crv
 				// other values will use default
 			}
+		case cloudkey.KeyTypeOKP:
+			// Ed25519 keys are held by the enrolling client, they cannot be generated in Key Vault
+			if d.AllowGenerate || !d.AllowEnroll {
+				return fmt.Errorf("%w: OKP keys are only supported for enrolled certificates", base.ErrResponseStatusBadRequest)
+			}
+			if ks.Crv != "" && ks.Crv != cloudkey.CurveNameEd25519 {
+				return fmt.Errorf("%w: unsupported curve for certificate: %s", base.ErrResponseStatusBadRequest, ks.Crv)
+			}
+			d.KeySpec.Kty = cloudkey.KeyTypeOKP
+			d.KeySpec.KeySize = nil
+			d.KeySpec.Crv = cloudkey.CurveNameEd25519
+			keySignVerifyOnly = true
 		default:
 			// other values use default
 		}
@@ -248,6 +284,8 @@ func (d *CertPolicyDoc) init(
 			cloudkey.SignatureAlgorithmPS512:
 			d.KeySpec.Alg = string(pAlg)
 		}
+	case cloudkey.KeyTypeOKP:
+		d.KeySpec.Alg = string(cloudkey.SignatureAlgorithmEdDSA)
 	case cloudkey.KeyTypeEC:
 		switch d.KeySpec.Crv {
 		case cloudkey.CurveNameP256:
@@ -282,6 +320,9 @@ func (d *CertPolicyDoc) init(
 	}
 
 	d.SANs = p.SubjectAlternativeNames.Sanitize()
+	if err := validateCertificateTemplates(d.Subject, d.SANs); err != nil {
+		return err
+	}
 
 	// get checksum of key fields
 	dw := md5.New()
@@ -300,6 +341,7 @@ func (d *CertPolicyDoc) init(
 	for _, flag := range d.Flags {
 		dw.Write([]byte(flag))
 	}
+	d.caConstraints.digest(dw)
 	d.Version = dw.Sum(nil)
 
 	return nil
@@ -324,6 +366,10 @@ func (d *CertPolicyDoc) ToModel() (m certmodels.CertificatePolicy) {
 	m.Subject = d.Subject
 	m.SubjectAlternativeNames = d.SANs
 	m.Flags = d.Flags
+	m.MaxPathLen = d.MaxPathLen
+	m.NameConstraints = d.NameConstraints
+	m.PolicyIdentifiers = d.PolicyIdentifiers
+	m.AllowTimestamping = d.AllowTimestamping
 	m.IssuerPolicyIdentifier = d.IssuerPolicy.String()
 	if m.IssuerPolicyIdentifier == "" {
 		m.IssuerPolicyIdentifier = "self"
@@ -0,0 +1,251 @@
+package cert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/stephenzsy/small-kms/backend/base"
+	ctx "github.com/stephenzsy/small-kms/backend/internal/context"
+	"github.com/stephenzsy/small-kms/backend/models"
+	certmodels "github.com/stephenzsy/small-kms/backend/models/cert"
+	webhookmodels "github.com/stephenzsy/small-kms/backend/models/webhook"
+	"github.com/stephenzsy/small-kms/backend/resdoc"
+	"github.com/stephenzsy/small-kms/backend/taskmanager"
+	"github.com/stephenzsy/small-kms/backend/utils"
+	"github.com/stephenzsy/small-kms/backend/utils/caldur"
+	"github.com/stephenzsy/small-kms/backend/webhook"
+)
+
+const (
+	EnvKeyCertRenewalWindow  = "CERT_RENEWAL_WINDOW"
+	DefaultCertRenewalWindow = "P30D"
+
+	certRenewalScanInterval  = time.Hour
+	certRenewalLeaseDuration = 10 * time.Minute
+)
+
+// renewal links of all namespaces share one partition, so the scheduler can find them without a cross partition query
+var certRenewalLinkPartitionKey = resdoc.PartitionKey{
+	NamespaceProvider: models.NamespaceProviderProfile,
+	NamespaceID:       "default",
+	ResourceProvider:  models.ResourceProviderLink,
+}
+
+var certRenewalLeasePartitionKey = resdoc.PartitionKey{
+	NamespaceProvider: models.NamespaceProviderProfile,
+	NamespaceID:       "default",
+	ResourceProvider:  models.ResourceProviderLease,
+}
+
+// certRenewalLinkDoc points to the latest generated certificate of a policy
+type certRenewalLinkDoc struct {
+	resdoc.LinkResourceDoc
+	Policy    resdoc.DocIdentifier `json:"policy"`
+	NotBefore resdoc.NumericDate   `json:"nbf"`
+	NotAfter  resdoc.NumericDate   `json:"exp"`
+}
+
+const (
+	certRenewalLinkQueryColPolicy    = "c.policy"
+	certRenewalLinkQueryColNotBefore = "c.nbf"
+	certRenewalLinkQueryColNotAfter  = "c.exp"
+)
+
+// isDue returns true once the certificate is within the renewal window and past half of its lifetime,
+// so short lived certificates are not renewed right after being issued
+func (d *certRenewalLinkDoc) isDue(now time.Time, window caldur.CalendarDuration) bool {
+	if caldur.Shift(now, window).Before(d.NotAfter.Time) {
+		return false
+	}
+	halfLife := d.NotAfter.Sub(d.NotBefore.Time) / 2
+	return !now.Before(d.NotBefore.Add(halfLife))
+}
+
+func getCertRenewalLinkID(policy resdoc.DocIdentifier) string {
+	return fmt.Sprintf("%s-%s-%s-%s", models.LinkProviderCertRenewal, policy.NamespaceProvider, policy.NamespaceID, policy.ID)
+}
+
+// registerCertRenewalInternal schedules the certificate to be renewed, replacing the previous certificate of the policy
+func registerCertRenewalInternal(c context.Context, certDoc CertDocument) error {
+	doc := &certRenewalLinkDoc{
+		LinkResourceDoc: resdoc.LinkResourceDoc{
+			ResourceDoc: resdoc.ResourceDoc{
+				PartitionKey: certRenewalLinkPartitionKey,
+				ID:           getCertRenewalLinkID(certDoc.GetPolicyIdentifier()),
+			},
+			LinkTo:       certDoc.Identifier(),
+			LinkProvider: models.LinkProviderCertRenewal,
+		},
+		Policy: certDoc.GetPolicyIdentifier(),
+	}
+	doc.NotBefore.Time = certDoc.GetNotBefore()
+	doc.NotAfter.Time = certDoc.GetNotAfter()
+	_, err := resdoc.GetDocService(c).Upsert(c, doc, nil)
+	return err
+}
+
+func listCertRenewalLinksInternal(c context.Context, notAfterBefore time.Time) ([]*certRenewalLinkDoc, error) {
+	qb := resdoc.NewDefaultCosmoQueryBuilder().
+		WithExtraColumns(certIssuedLinkQueryColLinkTo, certRenewalLinkQueryColPolicy,
+			certRenewalLinkQueryColNotBefore, certRenewalLinkQueryColNotAfter).
+		WithWhereClauses("c.linkProvider = @linkProvider", "c.exp <= @notAfterBefore")
+	qb.Parameters = append(qb.Parameters,
+		azcosmos.QueryParameter{Name: "@linkProvider", Value: models.LinkProviderCertRenewal},
+		azcosmos.QueryParameter{Name: "@notAfterBefore", Value: notAfterBefore.Unix()})
+	pager := resdoc.NewQueryDocPager[*certRenewalLinkDoc](c, qb, certRenewalLinkPartitionKey)
+	return utils.PagerToSlice[*certRenewalLinkDoc](pager)
+}
+
+// recordCertRenewedFromInternal links the renewed certificate to the certificate it replaces
+func recordCertRenewedFromInternal(c context.Context, certDoc CertDocument, renewedFrom resdoc.DocIdentifier) error {
+	certIdentifier := certDoc.Identifier()
+	doc := &resdoc.LinkResourceDoc{
+		ResourceDoc: resdoc.ResourceDoc{
+			PartitionKey: resdoc.PartitionKey{
+				NamespaceProvider: certIdentifier.NamespaceProvider,
+				NamespaceID:       certIdentifier.NamespaceID,
+				ResourceProvider:  models.ResourceProviderLink,
+			},
+			ID: fmt.Sprintf("%s-%s", models.LinkProviderRenewedFrom, certIdentifier.ID),
+		},
+		LinkTo:       renewedFrom,
+		LinkProvider: models.LinkProviderRenewedFrom,
+	}
+	_, err := resdoc.GetDocService(c).Upsert(c, doc, nil)
+	return err
+}
+
+func deleteCertRenewalLinkInternal(c context.Context, link *certRenewalLinkDoc) error {
+	_, err := resdoc.GetDocService(c).Delete(c, link.Identifier(), &azcosmos.ItemOptions{
+		IfMatchEtag: link.ETag,
+	})
+	return resdoc.HandleAzCosmosError(err)
+}
+
+// renewCertificateInternal must be called while holding the lease of the renewal link
+func renewCertificateInternal(c ctx.RequestContext, linkIdentifier resdoc.DocIdentifier, now time.Time, window caldur.CalendarDuration) error {
+	logger := log.Ctx(c)
+	link := &certRenewalLinkDoc{}
+	if err := resdoc.GetDocService(c).Read(c, linkIdentifier, link, nil); err != nil {
+		if errors.Is(err, resdoc.ErrAzCosmosDocNotFound) {
+			return nil
+		}
+		return err
+	}
+	// renewed by another replica before the lease was acquired
+	if !link.isDue(now, window) {
+		return nil
+	}
+
+	certDoc := &certDocBase{}
+	certIdentifier := link.LinkTo
+	if err := readCertDocInternal(c, certIdentifier.NamespaceProvider, certIdentifier.NamespaceID, certIdentifier.ID, certDoc); err != nil {
+		if errors.Is(err, base.ErrResponseStatusNotFound) {
+			return deleteCertRenewalLinkInternal(c, link)
+		}
+		return err
+	}
+	if certDoc.Status != certmodels.CertificateStatusIssued {
+		logger.Info().Str("cert", certIdentifier.String()).Str("status", string(certDoc.Status)).Msg("certificate is no longer active, skip renewal")
+		return deleteCertRenewalLinkInternal(c, link)
+	}
+
+	policy, err := GetCertificatePolicyInternal(c, link.Policy.NamespaceProvider, link.Policy.NamespaceID, link.Policy.ID)
+	if err != nil {
+		if errors.Is(err, base.ErrResponseStatusNotFound) {
+			return deleteCertRenewalLinkInternal(c, link)
+		}
+		return err
+	}
+	if !policy.AllowGenerate {
+		logger.Info().Str("policy", link.Policy.String()).Msg("policy no longer allows generate, skip renewal")
+		return deleteCertRenewalLinkInternal(c, link)
+	}
+
+	renewed, issued, err := generateCertificateInternal(c, certIdentifier.NamespaceProvider, certIdentifier.NamespaceID, policy)
+	if err != nil {
+		return err
+	}
+	logger.Info().Str("cert", renewed.Identifier().String()).Str("renewedFrom", certIdentifier.String()).Bool("issued", issued).Msg("certificate renewed")
+	if err := recordCertRenewedFromInternal(c, renewed, certIdentifier); err != nil {
+		return err
+	}
+	webhook.Publish(c, certIdentifier.NamespaceProvider, certIdentifier.NamespaceID, webhookmodels.EventTypeCertificateRenewed,
+		getCertificateEventSubject(renewed), &certificateRenewedEventData{
+			Certificate: renewed.ToModel(false),
+			RenewedFrom: certIdentifier.String(),
+		})
+	if !issued {
+		// the link is registered again once the pending order is finalized
+		if err := deleteCertRenewalLinkInternal(c, link); err != nil && !resdoc.IsAzCosmosConditionFailed(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+type certRenewalTaskExecutor struct {
+	serviceContext context.Context
+	renewalWindow  caldur.CalendarDuration
+	leaseHolder    string
+}
+
+// Close implements taskmanager.IntervalExecutor.
+func (*certRenewalTaskExecutor) Close(context.Context) error {
+	return nil
+}
+
+// Execute implements taskmanager.IntervalExecutor.
+func (e *certRenewalTaskExecutor) Execute(c context.Context) (time.Duration, error) {
+	logger := log.Ctx(c)
+	rc := ctx.NewBackgroundRequestContext(c, e.serviceContext)
+	now := time.Now()
+	links, err := listCertRenewalLinksInternal(rc, caldur.Shift(now, e.renewalWindow))
+	if err != nil {
+		return certRenewalScanInterval, err
+	}
+	for _, link := range links {
+		if !link.isDue(now, e.renewalWindow) {
+			continue
+		}
+		lease, err := resdoc.AcquireLease(rc, resdoc.DocIdentifier{
+			PartitionKey: certRenewalLeasePartitionKey,
+			ID:           link.ID,
+		}, e.leaseHolder, certRenewalLeaseDuration)
+		if err != nil {
+			if !errors.Is(err, resdoc.ErrLeaseHeld) {
+				logger.Error().Err(err).Str("link", link.ID).Msg("failed to acquire certificate renewal lease")
+			}
+			continue
+		}
+		if err := renewCertificateInternal(rc, link.Identifier(), now, e.renewalWindow); err != nil {
+			logger.Error().Err(err).Str("cert", link.LinkTo.String()).Msg("failed to renew certificate")
+		}
+		if err := lease.Release(rc); err != nil {
+			logger.Error().Err(err).Str("link", link.ID).Msg("failed to release certificate renewal lease")
+		}
+	}
+	return certRenewalScanInterval, nil
+}
+
+// Name implements taskmanager.IntervalExecutor.
+func (*certRenewalTaskExecutor) Name() string {
+	return "CertRenewal"
+}
+
+var _ taskmanager.IntervalExecutor = (*certRenewalTaskExecutor)(nil)
+
+// NewCertRenewalTaskExecutor renews the latest generated certificates of policies which allow generate
+// once they are within the renewal window
+func NewCertRenewalTaskExecutor(serviceContext context.Context, renewalWindow caldur.CalendarDuration) taskmanager.IntervalExecutor {
+	return &certRenewalTaskExecutor{
+		serviceContext: serviceContext,
+		renewalWindow:  renewalWindow,
+		leaseHolder:    uuid.NewString(),
+	}
+}
@@ -0,0 +1,29 @@
+package cert
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stephenzsy/small-kms/backend/utils/caldur"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCertRenewalLinkIsDue(t *testing.T) {
+	window := caldur.CalendarDuration{Day: 30}
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	newLink := func(notBefore, notAfter time.Time) *certRenewalLinkDoc {
+		link := &certRenewalLinkDoc{}
+		link.NotBefore.Time = notBefore
+		link.NotAfter.Time = notAfter
+		return link
+	}
+
+	// one year certificate
+	assert.False(t, newLink(now.AddDate(0, -10, 0), now.AddDate(0, 2, 0)).isDue(now, window))
+	assert.True(t, newLink(now.AddDate(-1, 0, 20), now.AddDate(0, 0, 20)).isDue(now, window))
+	assert.True(t, newLink(now.AddDate(-1, 0, -1), now.AddDate(0, 0, -1)).isDue(now, window))
+
+	// ten day certificate is within the window right away, renewed past half of its lifetime
+	assert.False(t, newLink(now.AddDate(0, 0, -2), now.AddDate(0, 0, 8)).isDue(now, window))
+	assert.True(t, newLink(now.AddDate(0, 0, -6), now.AddDate(0, 0, 4)).isDue(now, window))
+}
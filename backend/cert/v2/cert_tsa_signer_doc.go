@@ -0,0 +1,227 @@
+package cert
+
+import (
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
+	"github.com/google/uuid"
+	"github.com/stephenzsy/small-kms/backend/base"
+	cloudkey "github.com/stephenzsy/small-kms/backend/cloud/key"
+	ctx "github.com/stephenzsy/small-kms/backend/internal/context"
+	kv "github.com/stephenzsy/small-kms/backend/internal/keyvault"
+	"github.com/stephenzsy/small-kms/backend/models"
+	certmodels "github.com/stephenzsy/small-kms/backend/models/cert"
+	"github.com/stephenzsy/small-kms/backend/resdoc"
+)
+
+const (
+	tsaSignerCertValidity    = 365 * 24 * time.Hour
+	tsaSignerCertRenewBefore = 30 * 24 * time.Hour
+)
+
+// certTSASignerDoc holds the time-stamping certificate of a CA policy, it shares the ID of the policy
+type certTSASignerDoc struct {
+	resdoc.ResourceDoc
+	Issuer     resdoc.DocIdentifier `json:"issuer"`
+	JsonWebKey cloudkey.JsonWebKey  `json:"jwk"`
+	NotAfter   resdoc.NumericDate   `json:"exp"`
+}
+
+// tsaPolicyOID returns the TSA policy of the CA policy, an OID under the UUID arc (ITU-T X.667) so it needs no registration
+func tsaPolicyOID(policy resdoc.DocIdentifier) (x509.OID, error) {
+	u := uuid.NewSHA1(uuid.NameSpaceURL, []byte(policy.String()))
+	return x509.ParseOID("2.25." + new(big.Int).SetBytes(u[:]).String())
+}
+
+func (d *certTSASignerDoc) needsRenewal(issuerDoc *certDocBase, issuerCert *x509.Certificate) bool {
+	if len(d.JsonWebKey.CertificateChain) == 0 || d.Issuer != issuerDoc.Identifier() {
+		return true
+	}
+	if time.Until(d.NotAfter.Time) < tsaSignerCertRenewBefore {
+		// do not renew if the issuer expires first
+		return d.NotAfter.Before(issuerCert.NotAfter)
+	}
+	return false
+}
+
+func (d *certTSASignerDoc) X509Certificate() (*x509.Certificate, error) {
+	return x509.ParseCertificate(d.JsonWebKey.CertificateChain[0])
+}
+
+func (d *certTSASignerDoc) getCloudSignatureKey(c ctx.RequestContext) cloudkey.CloudSignatureKey {
+	return kv.GetCloudKeyStore(c).NewSignatureKey(c, d.JsonWebKey.KeyID, cloudkey.SignatureAlgorithmES256, true, d.JsonWebKey.PublicKey())
+}
+
+func getTSASignerDocIdentifier(policyDoc *CertPolicyDoc) resdoc.DocIdentifier {
+	return resdoc.NewDocIdentifier(policyDoc.PartitionKey.NamespaceProvider, policyDoc.PartitionKey.NamespaceID,
+		models.ResourceProviderCertTSASigner, policyDoc.ID)
+}
+
+// readTSASignerInternal returns the time-stamping signer of the CA policy, it never issues a signer as the TSA endpoint is anonymous
+func readTSASignerInternal(c ctx.RequestContext, policyDoc *CertPolicyDoc) (*certTSASignerDoc, error) {
+	doc := &certTSASignerDoc{}
+	if err := resdoc.GetDocService(c).Read(c, getTSASignerDocIdentifier(policyDoc), doc, nil); err != nil {
+		if errors.Is(err, resdoc.ErrAzCosmosDocNotFound) {
+			return nil, fmt.Errorf("%w: time stamping signer has not been issued", base.ErrResponseStatusBadRequest)
+		}
+		return nil, err
+	}
+	if len(doc.JsonWebKey.CertificateChain) == 0 || time.Now().After(doc.NotAfter.Time) {
+		return nil, fmt.Errorf("%w: time stamping signer has expired", base.ErrResponseStatusBadRequest)
+	}
+	issuerDoc := &certDocBase{}
+	if err := readCertDocInternal(c, doc.Issuer.NamespaceProvider, doc.Issuer.NamespaceID, doc.Issuer.ID, issuerDoc); err != nil {
+		return nil, err
+	}
+	if issuerDoc.Status != certmodels.CertificateStatusIssued {
+		return nil, fmt.Errorf("%w: issuer certificate is not active", base.ErrResponseStatusBadRequest)
+	}
+	return doc, nil
+}
+
+// syncTSASignerInternal issues or renews the signer of a policy which allows time stamping, once the policy has an issuer certificate
+func syncTSASignerInternal(c ctx.RequestContext, policyDoc *CertPolicyDoc) error {
+	if !policyDoc.AllowTimestamping {
+		return nil
+	}
+	if _, err := issueTSASignerInternal(c, policyDoc); err != nil && !errors.Is(err, base.ErrResponseStatusNotFound) {
+		return err
+	}
+	return nil
+}
+
+// issueTSASignerInternal issues the time-stamping signer of the CA policy by the current issuer certificate of the policy,
+// and renews it before it expires or when the issuer changes.
+// A concurrent issuance wins over this one, the signer it saved is returned instead
+func issueTSASignerInternal(c ctx.RequestContext, policyDoc *CertPolicyDoc) (*certTSASignerDoc, error) {
+	issuer, err := policyDoc.getIssuerCertIdentifier(c)
+	if err != nil {
+		return nil, err
+	}
+	issuerDoc := &certDocBase{}
+	if err := readCertDocInternal(c, issuer.NamespaceProvider, issuer.NamespaceID, issuer.ID, issuerDoc); err != nil {
+		return nil, err
+	}
+	issuerCert, err := issuerDoc.X509Certificate()
+	if err != nil {
+		return nil, err
+	}
+
+	docSvc := resdoc.GetDocService(c)
+	docIdentifier := getTSASignerDocIdentifier(policyDoc)
+	doc := &certTSASignerDoc{}
+	var etag *azcore.ETag
+	if err := docSvc.Read(c, docIdentifier, doc, nil); err != nil {
+		if !errors.Is(err, resdoc.ErrAzCosmosDocNotFound) {
+			return nil, err
+		}
+		doc.PartitionKey = docIdentifier.PartitionKey
+		doc.ID = docIdentifier.ID
+	} else if !doc.needsRenewal(issuerDoc, issuerCert) {
+		return doc, nil
+	} else {
+		etag = doc.GetETag()
+	}
+
+	if issuerDoc.Status != certmodels.CertificateStatusIssued {
+		return nil, fmt.Errorf("%w: issuer certificate is not active", base.ErrResponseStatusBadRequest)
+	}
+	policyOID, err := tsaPolicyOID(policyDoc.Identifier())
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().Truncate(time.Second)
+	notAfter := now.Add(tsaSignerCertValidity)
+	if notAfter.After(issuerCert.NotAfter) {
+		notAfter = issuerCert.NotAfter
+	}
+
+	keyStore := kv.GetCloudKeyStore(c)
+	created, err := keyStore.CreateKey(c,
+		kv.GetMaterialName(kv.MaterialNameKindTSAKey, policyDoc.PartitionKey.NamespaceProvider, policyDoc.PartitionKey.NamespaceID, policyDoc.ID),
+		cloudkey.CreateKeyParams{
+			KeyType:       cloudkey.KeyTypeEC,
+			Curve:         cloudkey.CurveNameP256,
+			KeyOperations: []cloudkey.JsonWebKeyOperation{cloudkey.JsonWebKeyOperationSign, cloudkey.JsonWebKeyOperationVerify},
+			Exportable:    to.Ptr(false),
+			NotBefore:     &now,
+			Expires:       &notAfter,
+		})
+	if err != nil {
+		return nil, err
+	}
+	ck := keyStore.NewSignatureKey(c, created.KeyID, cloudkey.SignatureAlgorithmES256, true, created.PublicKey())
+
+	serialNumber, err := rand.Int(rand.Reader, big.NewInt(0).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject: pkix.Name{
+			CommonName: fmt.Sprintf("%s Time Stamping Authority", issuerCert.Subject.CommonName),
+		},
+		NotBefore: now,
+		NotAfter:  notAfter,
+		KeyUsage:  x509.KeyUsageDigitalSignature,
+		Policies:  []x509.OID{policyOID},
+		// RFC 3161 section 2.3, the only extended key usage must be time stamping and critical
+		ExtraExtensions: []pkix.Extension{newCriticalExtKeyUsageExtension(oidExtKeyUsageTimeStamping)},
+	}
+	issuerSigner, sigAlg := issuerDoc.getCloudSignatureKey(c)
+	template.SignatureAlgorithm = sigAlg.X509SignatureAlgorithm()
+	signed, err := x509.CreateCertificate(rand.Reader, template, issuerCert, ck.Public(), issuerSigner)
+	if err != nil {
+		return nil, err
+	}
+
+	doc.Issuer = issuerDoc.Identifier()
+	doc.JsonWebKey = cloudkey.JsonWebKey{
+		KeyType: cloudkey.KeyTypeEC,
+		Curve:   cloudkey.CurveNameP256,
+		Alg:     string(cloudkey.SignatureAlgorithmES256),
+		KeyID:   created.KeyID,
+		KeyOperations: []cloudkey.JsonWebKeyOperation{
+			cloudkey.JsonWebKeyOperationSign,
+			cloudkey.JsonWebKeyOperationVerify,
+		},
+		// the chain is included in time-stamp tokens on request
+		CertificateChain: append([]cloudkey.Base64RawURLEncodableBytes{signed}, issuerDoc.JsonWebKey.CertificateChain...),
+	}
+	if err := doc.JsonWebKey.SetPublicKey(ck.Public()); err != nil {
+		return nil, err
+	}
+	sha1d := sha1.Sum(signed)
+	doc.JsonWebKey.ThumbprintSHA1 = sha1d[:]
+	sha256d := sha256.Sum256(signed)
+	doc.JsonWebKey.ThumbprintSHA256 = sha256d[:]
+	doc.NotAfter.Time = notAfter
+
+	if etag == nil {
+		_, err = docSvc.Create(c, doc, nil)
+	} else {
+		_, err = docSvc.Upsert(c, doc, &azcosmos.ItemOptions{IfMatchEtag: etag})
+	}
+	if err != nil {
+		if !resdoc.IsAzCosmosConditionFailed(err) {
+			return nil, err
+		}
+		saved := &certTSASignerDoc{}
+		if err := docSvc.Read(c, docIdentifier, saved, nil); err != nil {
+			return nil, err
+		}
+		return saved, nil
+	}
+	return doc, nil
+}
@@ -0,0 +1,123 @@
+package cert
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/asn1"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	cloudkeylocal "github.com/stephenzsy/small-kms/backend/cloud/key/local"
+	ctx "github.com/stephenzsy/small-kms/backend/internal/context"
+	kv "github.com/stephenzsy/small-kms/backend/internal/keyvault"
+	"github.com/stephenzsy/small-kms/backend/models"
+	"github.com/stephenzsy/small-kms/backend/resdoc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTSASigner(t *testing.T) {
+	docSvc, err := resdoc.NewEmbeddedDocService(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	masterKey := make([]byte, 32)
+	_, err = rand.Read(masterKey)
+	require.NoError(t, err)
+	keyStore, err := cloudkeylocal.NewKeyStore(t.TempDir(), masterKey)
+	require.NoError(t, err)
+	serviceCtx := context.WithValue(context.Background(), resdoc.DocServiceContextKey, docSvc)
+	serviceCtx = context.WithValue(serviceCtx, kv.CloudKeyStoreContextKey, keyStore)
+	c := ctx.NewBackgroundRequestContext(context.Background(), serviceCtx)
+
+	caDoc := newRevokeTestCADoc(t, c, keyStore)
+	_, err = docSvc.Create(c, caDoc, nil)
+	require.NoError(t, err)
+	policyDoc := &CertPolicyDoc{
+		ResourceDoc: resdoc.ResourceDoc{
+			PartitionKey: resdoc.PartitionKey{
+				NamespaceProvider: models.NamespaceProviderIntermediateCA,
+				NamespaceID:       "default",
+				ResourceProvider:  models.ResourceProviderCertPolicy,
+			},
+			ID: "tsa",
+		},
+	}
+	_, err = docSvc.Create(c, policyDoc, nil)
+	require.NoError(t, err)
+	_, err = docSvc.Create(c, &resdoc.LinkResourceDoc{
+		ResourceDoc: resdoc.ResourceDoc{
+			PartitionKey: resdoc.PartitionKey{
+				NamespaceProvider: models.NamespaceProviderIntermediateCA,
+				NamespaceID:       "default",
+				ResourceProvider:  models.ResourceProviderLink,
+			},
+			ID: getPolicyIssuerCertLinkID(policyDoc.ID),
+		},
+		LinkTo:       caDoc.Identifier(),
+		LinkProvider: models.LinkProviderCAPolicyIssuerCertificate,
+	}, nil)
+	require.NoError(t, err)
+
+	digest := sha256.Sum256([]byte("document"))
+	respond := func() tspResponse {
+		httpReq := httptest.NewRequest(http.MethodPost, "/",
+			bytes.NewReader(newTestTSPRequest(t, oidDigestAlgorithmSHA256, digest[:], nil, big.NewInt(1))))
+		rec := httptest.NewRecorder()
+		err := (&CertServer{}).RespondTimestamp(ctx.NewInjectedRequestContext(echo.New().NewContext(httpReq, rec), serviceCtx),
+			policyDoc.PartitionKey.NamespaceProvider, policyDoc.PartitionKey.NamespaceID, policyDoc.ID)
+		require.NoError(t, err)
+		var resp tspResponse
+		_, err = asn1.Unmarshal(rec.Body.Bytes(), &resp)
+		require.NoError(t, err)
+		return resp
+	}
+
+	// time stamping is not enabled
+	resp := respond()
+	assert.Equal(t, tspStatusRejection, resp.Status.Status)
+	assert.Equal(t, 1, resp.Status.FailInfo.At(int(tspFailureBadRequest)))
+	require.NoError(t, syncTSASignerInternal(c, policyDoc))
+
+	// the anonymous endpoint does not issue the signer
+	policyDoc.AllowTimestamping = true
+	_, err = docSvc.Upsert(c, policyDoc, nil)
+	require.NoError(t, err)
+	resp = respond()
+	assert.Equal(t, tspStatusRejection, resp.Status.Status)
+	assert.Equal(t, 1, resp.Status.FailInfo.At(int(tspFailureSystemFailure)))
+	_, err = readTSASignerInternal(c, policyDoc)
+	require.Error(t, err)
+
+	// concurrent first issuances return the signer which was saved
+	const issuers = 4
+	signers := make([]*certTSASignerDoc, issuers)
+	errs := make([]error, issuers)
+	wg := sync.WaitGroup{}
+	for i := 0; i < issuers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			signers[i], errs[i] = issueTSASignerInternal(c, policyDoc)
+		}(i)
+	}
+	wg.Wait()
+	saved, err := readTSASignerInternal(c, policyDoc)
+	require.NoError(t, err)
+	for i := 0; i < issuers; i++ {
+		require.NoError(t, errs[i])
+		assert.Equal(t, saved.JsonWebKey.KeyID, signers[i].JsonWebKey.KeyID)
+	}
+	reissued, err := issueTSASignerInternal(c, policyDoc)
+	require.NoError(t, err)
+	assert.Equal(t, saved.JsonWebKey.KeyID, reissued.JsonWebKey.KeyID)
+
+	resp = respond()
+	assert.Equal(t, tspStatusGranted, resp.Status.Status)
+	assert.NotEmpty(t, resp.TimeStampToken.FullBytes)
+}
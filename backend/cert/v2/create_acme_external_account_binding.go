@@ -0,0 +1,91 @@
+package cert
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stephenzsy/small-kms/backend/base"
+	"github.com/stephenzsy/small-kms/backend/internal/authz"
+	ctx "github.com/stephenzsy/small-kms/backend/internal/context"
+	"github.com/stephenzsy/small-kms/backend/models"
+	certmodels "github.com/stephenzsy/small-kms/backend/models/cert"
+	ns "github.com/stephenzsy/small-kms/backend/namespace"
+	"github.com/stephenzsy/small-kms/backend/resdoc"
+)
+
+const (
+	acmeExternalAccountKeyValidity = 24 * time.Hour
+	acmeExternalAccountKeySize     = 32
+)
+
+// CreateACMEExternalAccountBinding implements admin.ServerInterface.
+func (*CertServer) CreateACMEExternalAccountBinding(ec echo.Context, namespaceProvider models.NamespaceProvider, namespaceId string) error {
+	c := ec.(ctx.RequestContext)
+
+	switch namespaceProvider {
+	case models.NamespaceProviderServicePrincipal, models.NamespaceProviderUser, models.NamespaceProviderGroup:
+	default:
+		return fmt.Errorf("%w: ACME accounts can only be bound to service principals, users or groups", base.ErrResponseStatusBadRequest)
+	}
+	namespaceId = ns.ResolveMeNamespace(c, namespaceId)
+	if _, authOk := authz.Authorize(c, authz.AllowAdmin, authz.AllowSelf(namespaceId)); !authOk {
+		return base.ErrResponseStatusForbidden
+	}
+
+	req := new(certmodels.CreateAcmeExternalAccountBindingRequest)
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+	policyIdentifier, err := resdoc.ParseIdentifier(req.PolicyIdentifier)
+	if err != nil {
+		return fmt.Errorf("%w: invalid policy identifier: %s", base.ErrResponseStatusBadRequest, req.PolicyIdentifier)
+	}
+	if policyIdentifier.NamespaceProvider != models.NamespaceProviderIntermediateCA ||
+		policyIdentifier.ResourceProvider != models.ResourceProviderCertPolicy {
+		return fmt.Errorf("%w: ACME is only served by intermediate CA certificate policies", base.ErrResponseStatusBadRequest)
+	}
+
+	c = c.Elevate()
+	policy, err := GetCertificatePolicyInternal(c, policyIdentifier.NamespaceProvider, policyIdentifier.NamespaceID, policyIdentifier.ID)
+	if err != nil {
+		return err
+	}
+
+	keyID, err := uuid.NewRandom()
+	if err != nil {
+		return err
+	}
+	doc := &acmeExternalAccountKeyDoc{
+		ResourceDoc: resdoc.ResourceDoc{
+			PartitionKey: resdoc.PartitionKey{
+				NamespaceProvider: policy.PartitionKey.NamespaceProvider,
+				NamespaceID:       policy.PartitionKey.NamespaceID,
+				ResourceProvider:  models.ResourceProviderACMEExternalAccountKey,
+			},
+			ID: keyID.String(),
+		},
+		HMACKey:                make([]byte, acmeExternalAccountKeySize),
+		BoundNamespaceProvider: namespaceProvider,
+		BoundNamespaceID:       namespaceId,
+	}
+	if _, err := rand.Read(doc.HMACKey); err != nil {
+		return err
+	}
+	doc.NotAfter.Time = time.Now().Add(acmeExternalAccountKeyValidity).Truncate(time.Second)
+	if _, err := resdoc.GetDocService(c).Create(c, doc, nil); err != nil {
+		return err
+	}
+
+	m := &certmodels.AcmeExternalAccountBinding{
+		KeyID:        doc.ID,
+		HmacKey:      base64.RawURLEncoding.EncodeToString(doc.HMACKey),
+		DirectoryURL: getACMEDirectoryURL(c, policy.Identifier()) + "/directory",
+	}
+	m.Exp.Time = doc.NotAfter.Time
+	return c.JSON(http.StatusCreated, m)
+}
@@ -23,8 +23,8 @@ func (*CertServer) DeleteCertificate(ec echo.Context,
 		return base.ErrResponseStatusForbidden
 	}
 
-	doc, err := GetCertificateInternal(c, namespaceProvider, namespaceId, id)
-	if err != nil {
+	doc := &certDocBase{}
+	if err := readCertDocInternal(c, namespaceProvider, namespaceId, id, doc); err != nil {
 		if errors.Is(err, base.ErrResponseStatusNotFound) {
 			return c.NoContent(http.StatusNoContent)
 		}
@@ -46,9 +46,11 @@ func (*CertServer) DeleteCertificate(ec echo.Context,
 		}
 		return c.NoContent(http.StatusNoContent)
 	}
-	// will be put to deactivated state
+	// will be put to deactivated state, revoked certificate keeps its status
 	patchOps := azcosmos.PatchOperations{}
-	patchOps.AppendSet("/status", certmodels.CertificateStatusDeactivated)
+	if doc.Status != certmodels.CertificateStatusRevoked {
+		patchOps.AppendSet("/status", certmodels.CertificateStatusDeactivated)
+	}
 	patchOps.AppendSet("/deleted", time.Now().UTC())
 	resp, err := resdoc.GetDocService(c).Patch(c, doc, patchOps, &azcosmos.ItemOptions{
 		IfMatchEtag: doc.GetETag(),
@@ -56,5 +58,16 @@ func (*CertServer) DeleteCertificate(ec echo.Context,
 	if err != nil {
 		return err
 	}
+	if doc.Status != certmodels.CertificateStatusRevoked {
+		doc.Status = certmodels.CertificateStatusDeactivated
+		if err := syncIssuedCertLinkInternal(c, doc); err != nil {
+			return err
+		}
+		if doc.Issuer != doc.Identifier() {
+			if err := republishCRLInternal(c, doc.Issuer); err != nil {
+				return err
+			}
+		}
+	}
 	return c.JSON(resp.RawResponse.StatusCode, doc.ToModel(true))
 }
@@ -1,6 +1,8 @@
 package cert
 
 import (
+	"crypto"
+	"crypto/x509"
 	"fmt"
 
 	"github.com/google/uuid"
@@ -8,11 +10,13 @@ import (
 	"github.com/stephenzsy/small-kms/backend/admin"
 	"github.com/stephenzsy/small-kms/backend/admin/profile"
 	"github.com/stephenzsy/small-kms/backend/base"
+	cloudkey "github.com/stephenzsy/small-kms/backend/cloud/key"
 	"github.com/stephenzsy/small-kms/backend/internal/auth"
 	ctx "github.com/stephenzsy/small-kms/backend/internal/context"
 	"github.com/stephenzsy/small-kms/backend/internal/graph"
 	"github.com/stephenzsy/small-kms/backend/models"
 	certmodels "github.com/stephenzsy/small-kms/backend/models/cert"
+	webhookmodels "github.com/stephenzsy/small-kms/backend/models/webhook"
 	ns "github.com/stephenzsy/small-kms/backend/namespace"
 	"github.com/stephenzsy/small-kms/backend/resdoc"
 )
@@ -38,6 +42,30 @@ func (s *CertServer) EnrollCertificate(ec echo.Context, namespaceProvider models
 	if !reqIdentity.HasAdminRole() && !reqIdentity.HasRole(auth.RoleValueAgentActiveHost) && !reqIdentity.HasRole(auth.RoleValueCertificateEnroll) {
 		return base.ErrResponseStatusForbidden
 	}
+	if c, requesterProfile, err = authorizeEnrollInternal(c, requesterID, requesterProfile, namespaceProvider, namespaceId); err != nil {
+		return err
+	}
+
+	req := &certmodels.EnrollCertificateRequest{}
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+
+	policy, err := GetCertificatePolicyInternal(c, namespaceProvider, namespaceId, policyID)
+	if err != nil {
+		return err
+	} else if !policy.AllowEnroll {
+		return fmt.Errorf("%w: policy %s does not allow enroll", base.ErrResponseStatusBadRequest, policyID)
+	}
+
+	return s.enrollInternal(c, requesterProfile.TargetNamespaceProvider(), requesterProfile.ID, policy, req)
+
+}
+
+// authorizeEnrollInternal authorizes the requester to enroll in the namespace of itself or of a group it is a member of,
+// the returned context carries the template variables of the requester and the namespace
+func authorizeEnrollInternal(c ctx.RequestContext, requesterID uuid.UUID, requesterProfile *profile.ProfileDoc,
+	namespaceProvider models.NamespaceProvider, namespaceId string) (_ ctx.RequestContext, _ *profile.ProfileDoc, err error) {
 	namespaceUUID, err := uuid.Parse(namespaceId)
 	if err != nil {
 		namespaceUUID = uuid.UUID{}
@@ -51,12 +79,10 @@ func (s *CertServer) EnrollCertificate(ec echo.Context, namespaceProvider models
 
 			requesterProfile, err = profile.SyncProfileInternal(c, requesterID.String(), gclient)
 			if err != nil {
-				return err
+				return c, nil, err
 			}
 		}
-		requesterTemplateVarData := &ResourceTemplateGraphVarData{
-			ID: requesterProfile.ID,
-		}
+		requesterTemplateVarData := newResourceTemplateGraphVarData(requesterProfile)
 		c = c.WithValue(templateContextKeyRequesterGraph, requesterTemplateVarData)
 		c = c.WithValue(templateContextKeyNamespaceGraph, requesterTemplateVarData)
 		canEnroll = true
@@ -64,45 +90,53 @@ func (s *CertServer) EnrollCertificate(ec echo.Context, namespaceProvider models
 		// authorize group member
 		var nsProfile *profile.ProfileDoc
 		if _, requesterProfile, nsProfile, err = profile.SyncMemberOfInternal(c, requesterID.String(), namespaceId); err == nil {
-			c = c.WithValue(templateContextKeyRequesterGraph, &ResourceTemplateGraphVarData{
-				ID: requesterProfile.ID,
-			})
-			c = c.WithValue(templateContextKeyNamespaceGraph, &ResourceTemplateGraphVarData{
-				ID: nsProfile.ID,
-			})
+			c = c.WithValue(templateContextKeyRequesterGraph, newResourceTemplateGraphVarData(requesterProfile))
+			c = c.WithValue(templateContextKeyNamespaceGraph, newResourceTemplateGraphVarData(nsProfile))
 			canEnroll = true
 		}
 	}
 	if !canEnroll {
-		return base.ErrResponseStatusForbidden
+		return c, nil, base.ErrResponseStatusForbidden
 	}
 
-	req := &certmodels.EnrollCertificateRequest{}
-	if err := c.Bind(req); err != nil {
-		return err
-	}
-
-	policy, err := GetCertificatePolicyInternal(c, namespaceProvider, namespaceId, policyID)
-	if err != nil {
-		return err
-	} else if !policy.AllowEnroll {
-		return fmt.Errorf("%w: policy %s does not allow enroll", base.ErrResponseStatusBadRequest, policyID)
-	}
-
-	return s.enrollInternal(c, requesterProfile.TargetNamespaceProvider(), requesterProfile.ID, policy, req)
-
+	return c, requesterProfile, nil
 }
 
 func (s *CertServer) enrollInternal(c ctx.RequestContext, nsProvider models.NamespaceProvider, nsID string, policy *CertPolicyDoc,
 	req *certmodels.EnrollCertificateRequest) (err error) {
 	certDoc := &certDocInternal{}
 
-	if err = certDoc.init(c, nsProvider, nsID, policy, &req.PublicKey); err != nil {
+	publicJwk := &req.PublicKey
+	var x509CSR *x509.CertificateRequest
+	if req.CSR != "" {
+		if x509CSR, err = parseEnrollCSR(req.CSR); err != nil {
+			return err
+		}
+		if publicJwk, err = cloudkey.NewJsonWebKeyFromPublicKey(x509CSR.PublicKey); err != nil {
+			return fmt.Errorf("%w: unsupported CSR public key", base.ErrResponseStatusBadRequest)
+		}
+		if req.PublicKey.KeyType != "" {
+			if pub, ok := x509CSR.PublicKey.(interface{ Equal(crypto.PublicKey) bool }); !ok || !pub.Equal(req.PublicKey.PublicKey()) {
+				return fmt.Errorf("%w: public key does not match the CSR", base.ErrResponseStatusBadRequest)
+			}
+		}
+	} else if req.PublicKey.KeyType == "" {
+		return fmt.Errorf("%w: public key or CSR is required", base.ErrResponseStatusBadRequest)
+	}
+
+	if err = certDoc.init(c, nsProvider, nsID, policy, publicJwk); err != nil {
 		return
 	}
+	if x509CSR != nil {
+		if err = certDoc.applyCSRSubjectAlternativeNames(x509CSR); err != nil {
+			return err
+		}
+	}
 
 	var csr CertCSR
-	if nsProvider != models.NamespaceProviderRootCA {
+	if x509CSR != nil {
+		csr = &enrollX509CSR{x509CSR}
+	} else if nsProvider != models.NamespaceProviderRootCA {
 		csr, err = certDoc.GetCertificateRequest(c, true)
 		if err != nil {
 			return err
@@ -122,6 +156,10 @@ func (s *CertServer) enrollInternal(c ctx.RequestContext, nsProvider models.Name
 	if err != nil {
 		return err
 	}
+	if err := syncIssuedCertLinkInternal(c, certDoc); err != nil {
+		return err
+	}
+	publishCertificateEvent(c, certDoc, webhookmodels.EventTypeCertificateIssued)
 
 	m := certDoc.ToModel(true)
 	return c.JSON(resp.RawResponse.StatusCode, m)
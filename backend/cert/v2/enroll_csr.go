@@ -0,0 +1,159 @@
+package cert
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+
+	"github.com/stephenzsy/small-kms/backend/base"
+	certmodels "github.com/stephenzsy/small-kms/backend/models/cert"
+)
+
+var oidExtensionBasicConstraints = asn1.ObjectIdentifier{2, 5, 29, 19}
+
+type enrollX509CSR struct {
+	csr *x509.CertificateRequest
+}
+
+// PublicKey implements CertCSR.
+func (csr *enrollX509CSR) PublicKey() (crypto.PublicKey, error) {
+	return csr.csr.PublicKey, nil
+}
+
+// X509CSRBytes implements CertCSR.
+func (csr *enrollX509CSR) X509CSRBytes() []byte {
+	return csr.csr.Raw
+}
+
+var _ CertCSR = (*enrollX509CSR)(nil)
+
+// parseEnrollCSR accepts a PEM encoded or a base64 encoded DER certificate request,
+// the signature is verified as proof of possession of the private key
+func parseEnrollCSR(encoded string) (*x509.CertificateRequest, error) {
+	encoded = strings.TrimSpace(encoded)
+	var der []byte
+	if block, _ := pem.Decode([]byte(encoded)); block != nil {
+		if block.Type != "CERTIFICATE REQUEST" && block.Type != "NEW CERTIFICATE REQUEST" {
+			return nil, fmt.Errorf("%w: unexpected PEM block type: %s", base.ErrResponseStatusBadRequest, block.Type)
+		}
+		der = block.Bytes
+	} else {
+		var err error
+		if der, err = base64.StdEncoding.DecodeString(encoded); err != nil {
+			if der, err = base64.RawURLEncoding.DecodeString(encoded); err != nil {
+				return nil, fmt.Errorf("%w: invalid CSR encoding", base.ErrResponseStatusBadRequest)
+			}
+		}
+	}
+	csr, err := x509.ParseCertificateRequest(der)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid CSR: %s", base.ErrResponseStatusBadRequest, err.Error())
+	}
+	if err := csr.CheckSignature(); err != nil {
+		return nil, fmt.Errorf("%w: invalid CSR signature", base.ErrResponseStatusBadRequest)
+	}
+	if err := validateEnrollCSRExtensions(csr); err != nil {
+		return nil, err
+	}
+	return csr, nil
+}
+
+// validateEnrollCSRExtensions rejects a request for a CA certificate, other requested extensions
+// are not copied to the certificate, key usages are determined by the policy
+func validateEnrollCSRExtensions(csr *x509.CertificateRequest) error {
+	for _, ext := range csr.Extensions {
+		if !ext.Id.Equal(oidExtensionBasicConstraints) {
+			continue
+		}
+		var constraints struct {
+			IsCA       bool `asn1:"optional"`
+			MaxPathLen int  `asn1:"optional,default:-1"`
+		}
+		if _, err := asn1.Unmarshal(ext.Value, &constraints); err != nil {
+			return fmt.Errorf("%w: invalid basic constraints extension in CSR", base.ErrResponseStatusBadRequest)
+		}
+		if constraints.IsCA {
+			return fmt.Errorf("%w: CSR must not request a CA certificate", base.ErrResponseStatusBadRequest)
+		}
+	}
+	return nil
+}
+
+func csrHasSubjectAlternativeNames(csr *x509.CertificateRequest) bool {
+	return len(csr.DNSNames) > 0 || len(csr.EmailAddresses) > 0 || len(csr.IPAddresses) > 0 || len(csr.URIs) > 0
+}
+
+// applyCSRSubjectAlternativeNames narrows the names of the certificate to the names requested in the CSR,
+// the requested names are matched against the names evaluated from the policy templates
+func (d *certDocPending) applyCSRSubjectAlternativeNames(csr *x509.CertificateRequest) error {
+	if !csrHasSubjectAlternativeNames(csr) {
+		return nil
+	}
+	if d.SANs = filterCSRSubjectAlternativeNames(csr, d.SANs); d.SANs == nil {
+		return fmt.Errorf("%w: none of the requested subject alternative names are allowed by the policy", base.ErrResponseStatusBadRequest)
+	}
+	return nil
+}
+
+// filterCSRSubjectAlternativeNames keeps the requested names allowed by the policy,
+// a policy DNS name of the form *.example.com allows the wildcard and any single label name under example.com,
+// URIs must match exactly
+func filterCSRSubjectAlternativeNames(csr *x509.CertificateRequest, allowed *certmodels.SubjectAlternativeNames) *certmodels.SubjectAlternativeNames {
+	if allowed == nil {
+		return nil
+	}
+	sans := &certmodels.SubjectAlternativeNames{}
+	for _, name := range certmodels.SanitizeDNSNames(slices.Clone(csr.DNSNames)) {
+		if slices.ContainsFunc(allowed.DNSNames, func(pattern string) bool {
+			return matchDNSNamePattern(pattern, name)
+		}) {
+			sans.DNSNames = append(sans.DNSNames, name)
+		}
+	}
+	for _, email := range certmodels.SanitizeEmailAddresses(csr.EmailAddresses) {
+		if slices.ContainsFunc(allowed.Emails, func(v string) bool {
+			return strings.EqualFold(v, email)
+		}) {
+			sans.Emails = append(sans.Emails, email)
+		}
+	}
+	for _, ip := range certmodels.SanitizeIpAddresses(slices.Clone(csr.IPAddresses)) {
+		if slices.ContainsFunc(allowed.IPAddresses, func(v net.IP) bool {
+			return v.Equal(ip)
+		}) {
+			sans.IPAddresses = append(sans.IPAddresses, ip)
+		}
+	}
+	csrURIs := make([]string, len(csr.URIs))
+	for i, uri := range csr.URIs {
+		csrURIs[i] = uri.String()
+	}
+	for _, uri := range certmodels.SanitizeURIs(csrURIs) {
+		if slices.Contains(allowed.URIs, uri) {
+			sans.URIs = append(sans.URIs, uri)
+		}
+	}
+	if len(sans.DNSNames) == 0 && len(sans.Emails) == 0 && len(sans.IPAddresses) == 0 && len(sans.URIs) == 0 {
+		return nil
+	}
+	return sans
+}
+
+func matchDNSNamePattern(pattern string, name string) bool {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	if pattern == name {
+		return true
+	}
+	suffix, isWildcard := strings.CutPrefix(pattern, "*.")
+	if !isWildcard {
+		return false
+	}
+	label, ok := strings.CutSuffix(name, "."+suffix)
+	return ok && label != "" && label != "*" && !strings.Contains(label, ".")
+}
@@ -0,0 +1,150 @@
+package cert
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/pem"
+	"net"
+	"net/url"
+	"testing"
+
+	"github.com/stephenzsy/small-kms/backend/base"
+	cloudkey "github.com/stephenzsy/small-kms/backend/cloud/key"
+	ctx "github.com/stephenzsy/small-kms/backend/internal/context"
+	"github.com/stephenzsy/small-kms/backend/models"
+	certmodels "github.com/stephenzsy/small-kms/backend/models/cert"
+	keymodels "github.com/stephenzsy/small-kms/backend/models/key"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestEnrollCSR(t *testing.T, template *x509.CertificateRequest) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.CreateCertificateRequest(rand.Reader, template, key)
+	require.NoError(t, err)
+	return der
+}
+
+func TestParseEnrollCSR(t *testing.T) {
+	der := newTestEnrollCSR(t, &x509.CertificateRequest{
+		DNSNames: []string{"www.example.com"},
+	})
+	pemEncoded := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der}))
+
+	csr, err := parseEnrollCSR(pemEncoded)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"www.example.com"}, csr.DNSNames)
+	_, err = parseEnrollCSR(base64.StdEncoding.EncodeToString(der))
+	assert.NoError(t, err)
+
+	// tampered signature
+	tampered := append([]byte{}, der...)
+	tampered[len(tampered)-1] ^= 0xff
+	_, err = parseEnrollCSR(base64.StdEncoding.EncodeToString(tampered))
+	assert.Error(t, err)
+
+	_, err = parseEnrollCSR(string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})))
+	assert.Error(t, err)
+
+	// CA certificate request
+	bcValue, err := asn1.Marshal(struct{ IsCA bool }{true})
+	require.NoError(t, err)
+	der = newTestEnrollCSR(t, &x509.CertificateRequest{
+		ExtraExtensions: []pkix.Extension{{Id: oidExtensionBasicConstraints, Critical: true, Value: bcValue}},
+	})
+	_, err = parseEnrollCSR(base64.StdEncoding.EncodeToString(der))
+	assert.Error(t, err)
+}
+
+func TestFilterCSRSubjectAlternativeNames(t *testing.T) {
+	csr := &x509.CertificateRequest{
+		DNSNames:       []string{"WWW.example.com", "a.b.example.com", "api.example.org", "*.example.com"},
+		EmailAddresses: []string{"user@example.com", "other@example.com"},
+		IPAddresses:    []net.IP{net.ParseIP("10.0.0.1"), net.ParseIP("10.0.0.2")},
+	}
+	allowed := &certmodels.SubjectAlternativeNames{
+		DNSNames:    []string{"*.example.com", "api.example.net"},
+		Emails:      []string{"user@example.com"},
+		IPAddresses: []net.IP{net.ParseIP("10.0.0.1")},
+	}
+	sans := filterCSRSubjectAlternativeNames(csr, allowed)
+	require.NotNil(t, sans)
+	assert.Equal(t, []string{"*.example.com", "www.example.com"}, sans.DNSNames)
+	assert.Equal(t, []string{"user@example.com"}, sans.Emails)
+	require.Len(t, sans.IPAddresses, 1)
+	assert.True(t, sans.IPAddresses[0].Equal(net.ParseIP("10.0.0.1")))
+
+	assert.Empty(t, sans.URIs)
+
+	// URI only and mixed requests
+	spiffeID, _ := url.Parse("spiffe://example.com/workload")
+	otherID, _ := url.Parse("spiffe://example.com/other")
+	allowed.URIs = []string{spiffeID.String()}
+	sans = filterCSRSubjectAlternativeNames(&x509.CertificateRequest{URIs: []*url.URL{spiffeID, otherID}}, allowed)
+	require.NotNil(t, sans)
+	assert.Equal(t, []string{"spiffe://example.com/workload"}, sans.URIs)
+	sans = filterCSRSubjectAlternativeNames(&x509.CertificateRequest{
+		DNSNames: []string{"www.example.com"},
+		URIs:     []*url.URL{spiffeID},
+	}, allowed)
+	require.NotNil(t, sans)
+	assert.Equal(t, []string{"www.example.com"}, sans.DNSNames)
+	assert.Equal(t, []string{"spiffe://example.com/workload"}, sans.URIs)
+	assert.Nil(t, filterCSRSubjectAlternativeNames(&x509.CertificateRequest{URIs: []*url.URL{otherID}}, allowed))
+
+	assert.Nil(t, filterCSRSubjectAlternativeNames(csr, nil))
+	assert.Nil(t, filterCSRSubjectAlternativeNames(&x509.CertificateRequest{
+		DNSNames: []string{"api.example.org"},
+	}, allowed))
+}
+
+func TestApplyCSRSubjectAlternativeNamesTemplated(t *testing.T) {
+	c := ctx.NewBackgroundRequestContext(context.Background(), context.Background())
+	c = c.WithValue(templateContextKeyRequesterGraph, &ResourceTemplateGraphVarData{
+		ID:   "123",
+		Mail: "alice@example.com",
+	})
+	policy := &CertPolicyDoc{
+		KeySpec: keymodels.JsonWebKeySpec{Kty: cloudkey.KeyTypeEC, Crv: cloudkey.CurveNameP256},
+		Subject: certmodels.CertificateSubject{CommonName: "test"},
+		SANs: &certmodels.SubjectAlternativeNames{
+			DNSNames: []string{"{{requester.graph.id}}.devices.example.com"},
+			Emails:   []string{"{{requester.graph.mail}}"},
+		},
+	}
+	enroll := func(template *x509.CertificateRequest) (*certDocPending, error) {
+		csr, err := x509.ParseCertificateRequest(newTestEnrollCSR(t, template))
+		require.NoError(t, err)
+		jwk, err := cloudkey.NewJsonWebKeyFromPublicKey(csr.PublicKey)
+		require.NoError(t, err)
+		certDoc := &certDocPending{}
+		require.NoError(t, certDoc.init(c, models.NamespaceProviderUser, "123", policy, jwk))
+		return certDoc, certDoc.applyCSRSubjectAlternativeNames(csr)
+	}
+
+	certDoc, err := enroll(&x509.CertificateRequest{
+		DNSNames:       []string{"123.devices.example.com", "456.devices.example.com"},
+		EmailAddresses: []string{"alice@example.com"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"123.devices.example.com"}, certDoc.SANs.DNSNames)
+	assert.Equal(t, []string{"alice@example.com"}, certDoc.SANs.Emails)
+
+	// the template itself is not a name the policy allows
+	_, err = enroll(&x509.CertificateRequest{
+		EmailAddresses: []string{"{{requester.graph.mail}}"},
+	})
+	assert.ErrorIs(t, err, base.ErrResponseStatusBadRequest)
+
+	// without requested names the evaluated names of the policy are kept
+	certDoc, err = enroll(&x509.CertificateRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"123.devices.example.com"}, certDoc.SANs.DNSNames)
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/stephenzsy/small-kms/backend/internal/authz"
 	ctx "github.com/stephenzsy/small-kms/backend/internal/context"
 	"github.com/stephenzsy/small-kms/backend/models"
+	webhookmodels "github.com/stephenzsy/small-kms/backend/models/webhook"
 	"github.com/stephenzsy/small-kms/backend/resdoc"
 )
 
@@ -30,6 +31,19 @@ func (*CertServer) GenerateCertificate(ec echo.Context,
 		return base.ErrResponseStatusBadRequest
 	}
 
+	certDoc, issued, err := generateCertificateInternal(c, nsProvider, nsID, policy)
+	if err != nil {
+		return err
+	}
+	if !issued {
+		return c.JSON(http.StatusAccepted, certDoc.ToModel(true))
+	}
+	return c.JSON(http.StatusCreated, certDoc.ToModel(true))
+}
+
+// generateCertificateInternal returns false if the certificate is pending authorization of an external issuer
+func generateCertificateInternal(c ctx.RequestContext,
+	nsProvider models.NamespaceProvider, nsID string, policy *CertPolicyDoc) (CertDocumentPending, bool, error) {
 	var certDoc CertDocumentPending
 	if policy.IssuerPolicy.NamespaceProvider == models.NamespaceProviderExternalCA {
 		pending := &certDocACME{}
@@ -43,32 +57,44 @@ func (*CertServer) GenerateCertificate(ec echo.Context,
 
 	docSvc := resdoc.GetDocService(c)
 	if certAuthorized, err := certDoc.Authorize(c); err != nil {
-		return err
+		return nil, false, err
 	} else if !certAuthorized {
 		if _, err := docSvc.Create(c, certDoc, nil); err != nil {
-			return err
+			return nil, false, err
 		}
-		return c.JSON(http.StatusAccepted, certDoc.ToModel(true))
+		if acmeDoc, ok := certDoc.(*certDocACME); ok {
+			if err := registerACMEPendingOrderInternal(c, acmeDoc); err != nil {
+				return nil, false, err
+			}
+		}
+		return certDoc, false, nil
 	}
 
 	var csr CertCSR
 	if nsProvider != models.NamespaceProviderRootCA {
+		var err error
 		csr, err = certDoc.GetCertificateRequest(c, true)
 		if err != nil {
-			return err
+			return nil, false, err
 		}
 	}
 
 	der, err := certDoc.CreateCertificate(c, csr)
 	if err != nil {
-		return err
+		return nil, false, err
 	}
 	if err := certDoc.CollectSignedCertificate(c, der); err != nil {
-		return err
+		return nil, false, err
 	}
-	resp, err := docSvc.Create(c, certDoc, nil)
-	if err != nil {
-		return err
+	if _, err := docSvc.Create(c, certDoc, nil); err != nil {
+		return nil, false, err
+	}
+	if err := syncIssuedCertLinkInternal(c, certDoc); err != nil {
+		return nil, false, err
+	}
+	if err := registerCertRenewalInternal(c, certDoc); err != nil {
+		return nil, false, err
 	}
-	return c.JSON(resp.RawResponse.StatusCode, certDoc.ToModel(true))
+	publishCertificateEvent(c, certDoc, webhookmodels.EventTypeCertificateIssued)
+	return certDoc, true, nil
 }
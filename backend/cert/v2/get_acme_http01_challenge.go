@@ -0,0 +1,41 @@
+package cert
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stephenzsy/small-kms/backend/base"
+	"github.com/stephenzsy/small-kms/backend/internal/authz"
+	ctx "github.com/stephenzsy/small-kms/backend/internal/context"
+	"github.com/stephenzsy/small-kms/backend/models"
+	certmodels "github.com/stephenzsy/small-kms/backend/models/cert"
+	ns "github.com/stephenzsy/small-kms/backend/namespace"
+	"github.com/stephenzsy/small-kms/backend/resdoc"
+)
+
+// GetACMEHTTP01Challenge implements admin.ServerInterface.
+func (*CertServer) GetACMEHTTP01Challenge(ec echo.Context, namespaceId string, id string) error {
+	c := ec.(ctx.RequestContext)
+
+	namespaceId = ns.ResolveMeNamespace(c, namespaceId)
+	if _, authOk := authz.Authorize(c, authz.AllowAdmin, authz.AllowSelf(namespaceId)); !authOk {
+		return base.ErrResponseStatusForbidden
+	}
+
+	doc := &acmeHTTP01ChallengeDoc{}
+	if err := resdoc.GetDocService(c).Read(c, resdoc.NewDocIdentifier(models.NamespaceProviderServicePrincipal, namespaceId,
+		models.ResourceProviderACMEHTTP01Challenge, id), doc, nil); err != nil {
+		if errors.Is(err, resdoc.ErrAzCosmosDocNotFound) {
+			return fmt.Errorf("%w: challenge not found", base.ErrResponseStatusNotFound)
+		}
+		return err
+	}
+
+	return c.JSON(http.StatusOK, &certmodels.AcmeHttp01Challenge{
+		Token:            doc.ID,
+		KeyAuthorization: doc.KeyAuthorization,
+		Domain:           doc.Domain,
+	})
+}
@@ -86,7 +86,7 @@ func getCertificatePending(c ctx.RequestContext, namespaceProvider models.Namesp
 			URL:        a.URI,
 		}
 		for j, ch := range a.Challenges {
-			if ch.Type == "dns-01" {
+			if ch.Type == acmeChallengeTypeDNS01 {
 				record, err := certDoc.acmeClient.DNS01ChallengeRecord(ch.Token)
 				if err != nil {
 					return err
@@ -0,0 +1,38 @@
+package cert
+
+import (
+	"encoding/pem"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stephenzsy/small-kms/backend/admin"
+	"github.com/stephenzsy/small-kms/backend/base"
+	ctx "github.com/stephenzsy/small-kms/backend/internal/context"
+	"github.com/stephenzsy/small-kms/backend/models"
+	"github.com/stephenzsy/small-kms/backend/resdoc"
+)
+
+// GetCertificateCRL implements admin.ServerInterface.
+func (*CertServer) GetCertificateCRL(ec echo.Context, namespaceProvider models.NamespaceProvider, namespaceId string, id string, params admin.GetCertificateCRLParams) error {
+	c := ec.(ctx.RequestContext)
+
+	issuer := resdoc.NewDocIdentifier(namespaceProvider, namespaceId, models.ResourceProviderCert, id)
+	if !isInternalIssuer(issuer) {
+		return base.ErrResponseStatusNotFound
+	}
+
+	// anonymous endpoint
+	c = c.Elevate()
+	crlDoc, err := getCRLInternal(c, issuer)
+	if err != nil {
+		return err
+	}
+
+	if params.Pem != nil && *params.Pem {
+		return c.Blob(http.StatusOK, "application/x-pem-file", pem.EncodeToMemory(&pem.Block{
+			Type:  "X509 CRL",
+			Bytes: crlDoc.CRL,
+		}))
+	}
+	return c.Blob(http.StatusOK, "application/pkix-crl", crlDoc.CRL)
+}
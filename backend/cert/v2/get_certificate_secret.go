@@ -37,14 +37,19 @@ func (s *CertServer) GetCertificateSecret(ec echo.Context, namespaceProvider mod
 		return err
 	}
 
-	jwk, err := cloudkey.NewEphemeralECDHJwk(s.cryptoProvider)
+	jwk, err := cloudkey.NewEphemeralKeyAgreementJwk(s.cryptoProvider, &req.Jwk)
 	if err != nil {
-		return err
+		return fmt.Errorf("%w: %w", base.ErrResponseStatusBadRequest, err)
 	}
 
 	jweBuilder := cloudkey.JWEAes256GcmEncBuilder{}
-	if err := jweBuilder.SetEcdhEsKeyAgreement(jwk, &req.Jwk); err != nil {
-		return err
+	if kemJwk := findMLKEM768Jwk(req.Jwks); kemJwk != nil {
+		err = jweBuilder.SetHybridKeyAgreement(jwk, &req.Jwk, kemJwk)
+	} else {
+		err = jweBuilder.SetEcdhEsKeyAgreement(jwk, &req.Jwk)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %w", base.ErrResponseStatusBadRequest, err)
 	}
 
 	sClient := kv.GetAzKeyVaultService(c).AzSecretsClient()
@@ -67,3 +72,13 @@ func (s *CertServer) GetCertificateSecret(ec echo.Context, namespaceProvider mod
 		Payload: payload,
 	})
 }
+
+// findMLKEM768Jwk returns the ML-KEM-768 key of the requester, if the requester supports the hybrid key agreement
+func findMLKEM768Jwk(jwks []cloudkey.JsonWebKey) *cloudkey.JsonWebKey {
+	for i := range jwks {
+		if jwks[i].KeyType == cloudkey.KeyTypeAKP && jwks[i].Alg == cloudkey.KeyAlgMLKEM768 {
+			return &jwks[i]
+		}
+	}
+	return nil
+}
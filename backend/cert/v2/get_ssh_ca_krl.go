@@ -0,0 +1,63 @@
+package cert
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+	ctx "github.com/stephenzsy/small-kms/backend/internal/context"
+	"github.com/stephenzsy/small-kms/backend/models"
+	"github.com/stephenzsy/small-kms/backend/resdoc"
+	"github.com/stephenzsy/small-kms/backend/utils"
+)
+
+type sshCertQueryDoc struct {
+	resdoc.ResourceQueryDoc
+	Certificate string `json:"certificate"`
+}
+
+// GetSSHCAPolicyKRL implements admin.ServerInterface.
+func (*CertServer) GetSSHCAPolicyKRL(ec echo.Context, namespaceProvider models.NamespaceProvider, namespaceId string, id string) error {
+	c := ec.(ctx.RequestContext)
+
+	// anonymous endpoint
+	c = c.Elevate()
+	policy, err := getSSHCAPolicyInternal(c, namespaceProvider, namespaceId, id)
+	if err != nil {
+		return err
+	}
+
+	// expired certificates are rejected by sshd anyway
+	qb := resdoc.NewDefaultCosmoQueryBuilder().
+		WithExtraColumns("c.certificate").
+		WithWhereClauses("c.status = 'revoked'").
+		WithWhereClauses("c.policy = @policy").
+		WithWhereClauses("c.exp > (GetCurrentTimestamp() / 1000)")
+	qb.Parameters = append(qb.Parameters, azcosmos.QueryParameter{Name: "@policy", Value: policy.Identifier().String()})
+	pager := resdoc.NewQueryDocPager[*sshCertQueryDoc](c, qb, resdoc.PartitionKey{
+		NamespaceProvider: namespaceProvider,
+		NamespaceID:       namespaceId,
+		ResourceProvider:  models.ResourceProviderSSHCert,
+	})
+	docs, err := utils.PagerToSlice(pager)
+	if err != nil {
+		return err
+	}
+
+	krl := newSSHKRLBuilder()
+	for _, queryDoc := range docs {
+		doc := &sshCertDoc{Certificate: queryDoc.Certificate}
+		cert, err := doc.parseCertificate()
+		if err != nil {
+			log.Ctx(c).Warn().Err(err).Str("id", queryDoc.ID).Msg("failed to parse revoked SSH certificate, skip")
+			continue
+		}
+		krl.revokeCertificate(cert)
+	}
+
+	return c.Blob(http.StatusOK, "application/octet-stream",
+		krl.marshal(time.Now(), fmt.Sprintf("%s/%s/%s", namespaceProvider, namespaceId, id)))
+}
@@ -0,0 +1,27 @@
+package cert
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stephenzsy/small-kms/backend/base"
+	"github.com/stephenzsy/small-kms/backend/internal/authz"
+	ctx "github.com/stephenzsy/small-kms/backend/internal/context"
+	"github.com/stephenzsy/small-kms/backend/models"
+	ns "github.com/stephenzsy/small-kms/backend/namespace"
+)
+
+// GetSSHCAPolicy implements admin.ServerInterface.
+func (*CertServer) GetSSHCAPolicy(ec echo.Context, namespaceProvider models.NamespaceProvider, namespaceId string, id string) error {
+	c := ec.(ctx.RequestContext)
+	namespaceId = ns.ResolveMeNamespace(c, namespaceId)
+	if _, authOk := authz.Authorize(c, authz.AllowAdmin, authz.AllowSelf(namespaceId)); !authOk {
+		return base.ErrResponseStatusForbidden
+	}
+
+	doc, err := getSSHCAPolicyInternal(c, namespaceProvider, namespaceId, id)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, doc.ToModel())
+}
@@ -0,0 +1,25 @@
+package cert
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stephenzsy/small-kms/backend/base"
+	"github.com/stephenzsy/small-kms/backend/internal/authz"
+	ctx "github.com/stephenzsy/small-kms/backend/internal/context"
+	"github.com/stephenzsy/small-kms/backend/models"
+)
+
+// GetSSHCertificate implements admin.ServerInterface.
+func (*CertServer) GetSSHCertificate(ec echo.Context, namespaceProvider models.NamespaceProvider, namespaceId string, id string) error {
+	c := ec.(ctx.RequestContext)
+	if !authz.AuthorizeAdminOnly(c) {
+		return base.ErrResponseStatusForbidden
+	}
+
+	doc, err := readSSHCertDocInternal(c, namespaceProvider, namespaceId, id)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, doc.ToModel())
+}
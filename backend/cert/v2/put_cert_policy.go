@@ -45,6 +45,10 @@ func (*CertServer) PutCertificatePolicy(ec echo.Context, nsProvider models.Names
 	if err != nil {
 		return err
 	}
+	// the TSA endpoint is anonymous, so the signer is only issued by admins
+	if err := syncTSASignerInternal(c, doc); err != nil {
+		return err
+	}
 
 	return c.JSON(resp.RawResponse.StatusCode, doc.ToModel())
 }
@@ -1,6 +1,7 @@
 package cert
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/labstack/echo/v4"
@@ -59,6 +60,16 @@ func (*CertServer) PutCertificatePolicyIssuer(ec echo.Context, namespaceProvider
 	if err != nil {
 		return err
 	}
+	// renew the TSA signer by the new issuer certificate
+	if namespaceProvider == models.NamespaceProviderIntermediateCA {
+		if policyDoc, err := GetCertificatePolicyInternal(c, namespaceProvider, namespaceId, id); err == nil {
+			if err := syncTSASignerInternal(c, policyDoc); err != nil {
+				return err
+			}
+		} else if !errors.Is(err, base.ErrResponseStatusNotFound) {
+			return err
+		}
+	}
 
 	return c.JSON(resp.RawResponse.StatusCode, doc.ToModel())
 }
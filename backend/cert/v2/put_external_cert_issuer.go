@@ -1,13 +1,17 @@
 package cert
 
 import (
+	"encoding/base64"
 	"errors"
+	"fmt"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azsecrets"
 
 	"github.com/labstack/echo/v4"
 	"github.com/rs/zerolog/log"
 	"github.com/stephenzsy/small-kms/backend/base"
 	cloudkey "github.com/stephenzsy/small-kms/backend/cloud/key"
-	cloudkeyaz "github.com/stephenzsy/small-kms/backend/cloud/key/az"
 	"github.com/stephenzsy/small-kms/backend/internal/authz"
 	ctx "github.com/stephenzsy/small-kms/backend/internal/context"
 	kv "github.com/stephenzsy/small-kms/backend/internal/keyvault"
@@ -37,12 +41,20 @@ func (*CertServer) PutExternalCertificateIssuer(ec echo.Context, namespaceId str
 
 	logger := log.Ctx(c)
 
+	if acmeReq.Http01Solver != nil && acmeReq.Http01Solver.AgentNamespaceID == "" {
+		return fmt.Errorf("%w: agent namespace ID is required for the http-01 solver", base.ErrResponseStatusBadRequest)
+	}
+	dns01Solver, err := putACMEDNS01Solver(c, namespaceId, issuerID, acmeReq.Dns01Solver)
+	if err != nil {
+		return err
+	}
+
 	// load cloudKey
 	keyDoc, err := key.GetKeyInternal(c, models.NamespaceProviderExternalCA, namespaceId, acmeReq.AccountKeyID)
 	if err != nil {
 		return err
 	}
-	ck := cloudkeyaz.NewAzCloudSignatureKeyWithKID(c, kv.GetAzKeyVaultService(c).AzKeysClient(), keyDoc.KeyID, cloudkey.SignatureAlgorithmES384, true, keyDoc.PublicKey())
+	ck := kv.GetCloudKeyStore(c).NewSignatureKey(c, keyDoc.KeyID, cloudkey.SignatureAlgorithmES384, true, keyDoc.PublicKey())
 
 	acmeClient := acme.Client{
 		Key:          ck,
@@ -81,8 +93,14 @@ func (*CertServer) PutExternalCertificateIssuer(ec echo.Context, namespaceId str
 			DirectoryURL:   acmeReq.DirectoryURL,
 			AccountContact: account.Contact,
 			AccountStatus:  account.Status,
+			DNS01Solver:    dns01Solver,
 		},
 	}
+	if acmeReq.Http01Solver != nil {
+		issuerDoc.ACME.HTTP01Solver = &CertIssuerDocACMEHTTP01Solver{
+			AgentNamespaceID: acmeReq.Http01Solver.AgentNamespaceID,
+		}
+	}
 
 	docSvc := resdoc.GetDocService(c)
 	resp, err := docSvc.Upsert(c, issuerDoc, nil)
@@ -92,3 +110,55 @@ func (*CertServer) PutExternalCertificateIssuer(ec echo.Context, namespaceId str
 
 	return c.JSON(resp.RawResponse.StatusCode, issuerDoc.ToModel())
 }
+
+// putACMEDNS01Solver validates the RFC 2136 solver and stores the TSIG secret in Key Vault,
+// the secret of the existing issuer is kept if the request does not have one
+func putACMEDNS01Solver(c ctx.RequestContext, namespaceId string, issuerID string, req *certmodels.CertificateExternalIssuerAcmeDns01Solver) (*CertIssuerDocACMEDNS01Solver, error) {
+	if req == nil {
+		return nil, nil
+	}
+	if req.Nameserver == "" || req.Zone == "" || req.TsigKeyName == "" {
+		return nil, fmt.Errorf("%w: nameserver, zone and TSIG key name are required for the dns-01 solver", base.ErrResponseStatusBadRequest)
+	}
+	if _, ok := normalizeTSIGAlgorithm(req.TsigAlgorithm); !ok {
+		return nil, fmt.Errorf("%w: unsupported TSIG algorithm: %s", base.ErrResponseStatusBadRequest, req.TsigAlgorithm)
+	}
+	doc := &CertIssuerDocACMEDNS01Solver{
+		Nameserver:    req.Nameserver,
+		Zone:          req.Zone,
+		TSIGKeyName:   req.TsigKeyName,
+		TSIGAlgorithm: req.TsigAlgorithm,
+	}
+
+	if req.TsigSecret == "" {
+		existing, err := getExternalCertificateIssuerInternal(c, namespaceId, issuerID)
+		if err != nil {
+			if errors.Is(err, base.ErrResponseStatusNotFound) {
+				return nil, fmt.Errorf("%w: TSIG secret is required for the dns-01 solver", base.ErrResponseStatusBadRequest)
+			}
+			return nil, err
+		}
+		if existing.ACME == nil || existing.ACME.DNS01Solver == nil || existing.ACME.DNS01Solver.TSIGSecretID == "" {
+			return nil, fmt.Errorf("%w: TSIG secret is required for the dns-01 solver", base.ErrResponseStatusBadRequest)
+		}
+		doc.TSIGSecretID = existing.ACME.DNS01Solver.TSIGSecretID
+		return doc, nil
+	}
+
+	if _, err := base64.StdEncoding.DecodeString(req.TsigSecret); err != nil {
+		return nil, fmt.Errorf("%w: TSIG secret must be base64 encoded", base.ErrResponseStatusBadRequest)
+	}
+	secretName := kv.GetMaterialName(kv.MaterialNameKindSecret, models.NamespaceProviderExternalCA, namespaceId, issuerID+"-tsig")
+	resp, err := kv.GetAzKeyVaultService(c).AzSecretsClient().SetSecret(c, secretName, azsecrets.SetSecretParameters{
+		Value:       &req.TsigSecret,
+		ContentType: to.Ptr("text/plain"),
+		SecretAttributes: &azsecrets.SecretAttributes{
+			Enabled: to.Ptr(true),
+		},
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	doc.TSIGSecretID = *resp.ID
+	return doc, nil
+}
@@ -0,0 +1,130 @@
+package cert
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/asn1"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+	"github.com/stephenzsy/small-kms/backend/base"
+	ctx "github.com/stephenzsy/small-kms/backend/internal/context"
+	"github.com/stephenzsy/small-kms/backend/models"
+	certmodels "github.com/stephenzsy/small-kms/backend/models/cert"
+	"github.com/stephenzsy/small-kms/backend/resdoc"
+	"golang.org/x/crypto/ocsp"
+)
+
+const (
+	ocspResponseValidity   = time.Hour
+	ocspRequestMaxBodySize = 16 * 1024
+	contentTypeOCSPResp    = "application/ocsp-response"
+)
+
+// RespondOCSP implements admin.ServerInterface.
+func (*CertServer) RespondOCSP(ec echo.Context, namespaceProvider models.NamespaceProvider, namespaceId string, id string) error {
+	c := ec.(ctx.RequestContext)
+
+	body, err := io.ReadAll(io.LimitReader(c.Request().Body, ocspRequestMaxBodySize))
+	if err != nil {
+		return c.Blob(http.StatusOK, contentTypeOCSPResp, ocsp.MalformedRequestErrorResponse)
+	}
+	req, err := ocsp.ParseRequest(body)
+	if err != nil {
+		return c.Blob(http.StatusOK, contentTypeOCSPResp, ocsp.MalformedRequestErrorResponse)
+	}
+
+	issuer := resdoc.NewDocIdentifier(namespaceProvider, namespaceId, models.ResourceProviderCert, id)
+	if !isInternalIssuer(issuer) {
+		return c.Blob(http.StatusOK, contentTypeOCSPResp, ocsp.UnauthorizedErrorResponse)
+	}
+
+	// anonymous endpoint
+	c = c.Elevate()
+	issuerDoc := &certDocBase{}
+	if err := readCertDocInternal(c, issuer.NamespaceProvider, issuer.NamespaceID, issuer.ID, issuerDoc); err != nil {
+		if errors.Is(err, base.ErrResponseStatusNotFound) {
+			return c.Blob(http.StatusOK, contentTypeOCSPResp, ocsp.UnauthorizedErrorResponse)
+		}
+		return err
+	}
+	issuerCert, err := issuerDoc.X509Certificate()
+	if err != nil {
+		return c.Blob(http.StatusOK, contentTypeOCSPResp, ocsp.UnauthorizedErrorResponse)
+	}
+	if ok, err := isOCSPRequestForIssuer(req, issuerCert); err != nil || !ok {
+		return c.Blob(http.StatusOK, contentTypeOCSPResp, ocsp.UnauthorizedErrorResponse)
+	}
+
+	responder, err := getOCSPResponderInternal(c, issuerDoc, issuerCert)
+	if err != nil {
+		log.Ctx(c).Error().Err(err).Msg("failed to get OCSP responder")
+		return c.Blob(http.StatusOK, contentTypeOCSPResp, ocsp.TryLaterErrorResponse)
+	}
+	responderCert, err := responder.X509Certificate()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().Truncate(time.Second)
+	template := ocsp.Response{
+		Status:       ocsp.Unknown,
+		SerialNumber: req.SerialNumber,
+		ThisUpdate:   now,
+		NextUpdate:   now.Add(ocspResponseValidity),
+		Certificate:  responderCert,
+		IssuerHash:   req.HashAlgorithm,
+	}
+	link, err := findIssuedCertLinkBySerialNumberInternal(c, issuer, req.SerialNumber.Bytes())
+	if err != nil {
+		return err
+	}
+	if link != nil {
+		switch link.Status {
+		case certmodels.CertificateStatusIssued:
+			template.Status = ocsp.Good
+		case certmodels.CertificateStatusRevoked, certmodels.CertificateStatusDeactivated:
+			template.Status = ocsp.Revoked
+			if link.Revocation != nil {
+				template.RevokedAt = link.Revocation.RevokedAt.Time
+				template.RevocationReason, _ = link.Revocation.Reason.ReasonCode()
+			} else {
+				template.RevocationReason = ocsp.CessationOfOperation
+				if link.Timestamp != nil {
+					template.RevokedAt = link.Timestamp.Time
+				}
+			}
+		}
+	}
+
+	resp, err := ocsp.CreateResponse(issuerCert, responderCert, template, responder.getCloudSignatureKey(c))
+	if err != nil {
+		return err
+	}
+	return c.Blob(http.StatusOK, contentTypeOCSPResp, resp)
+}
+
+func isOCSPRequestForIssuer(req *ocsp.Request, issuerCert *x509.Certificate) (bool, error) {
+	if !req.HashAlgorithm.Available() {
+		return false, nil
+	}
+	var publicKeyInfo struct {
+		Algorithm asn1.RawValue
+		PublicKey asn1.BitString
+	}
+	if _, err := asn1.Unmarshal(issuerCert.RawSubjectPublicKeyInfo, &publicKeyInfo); err != nil {
+		return false, err
+	}
+	h := req.HashAlgorithm.New()
+	h.Write(issuerCert.RawSubject)
+	if !bytes.Equal(h.Sum(nil), req.IssuerNameHash) {
+		return false, nil
+	}
+	h.Reset()
+	h.Write(publicKeyInfo.PublicKey.RightAlign())
+	return bytes.Equal(h.Sum(nil), req.IssuerKeyHash), nil
+}
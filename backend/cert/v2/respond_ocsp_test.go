@@ -0,0 +1,59 @@
+package cert
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ocsp"
+)
+
+func newTestCertificate(t *testing.T, cn string, issuer *x509.Certificate, issuerKey crypto.Signer) (*x509.Certificate, crypto.Signer) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  issuer == nil,
+	}
+	if issuer == nil {
+		issuer, issuerKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, issuer, key.Public(), issuerKey)
+	assert.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+	return cert, key
+}
+
+func TestIsOCSPRequestForIssuer(t *testing.T) {
+	caCert, caKey := newTestCertificate(t, "Test CA", nil, nil)
+	otherCACert, _ := newTestCertificate(t, "Other CA", nil, nil)
+	leafCert, _ := newTestCertificate(t, "leaf", caCert, caKey)
+
+	for _, hash := range []crypto.Hash{crypto.SHA1, crypto.SHA256} {
+		reqBytes, err := ocsp.CreateRequest(leafCert, caCert, &ocsp.RequestOptions{Hash: hash})
+		assert.NoError(t, err)
+		req, err := ocsp.ParseRequest(reqBytes)
+		assert.NoError(t, err)
+
+		ok, err := isOCSPRequestForIssuer(req, caCert)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+
+		ok, err = isOCSPRequestForIssuer(req, otherCACert)
+		assert.NoError(t, err)
+		assert.False(t, ok)
+	}
+}
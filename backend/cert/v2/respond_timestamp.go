@@ -0,0 +1,142 @@
+package cert
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+	"github.com/stephenzsy/small-kms/backend/base"
+	cloudkey "github.com/stephenzsy/small-kms/backend/cloud/key"
+	ctx "github.com/stephenzsy/small-kms/backend/internal/context"
+	"github.com/stephenzsy/small-kms/backend/models"
+	"github.com/stephenzsy/small-kms/backend/resdoc"
+	"github.com/stephenzsy/small-kms/backend/utils"
+)
+
+// certTimestampDoc records a time-stamp token issued by the TSA of a CA policy, the ID is the serial number in hex
+type certTimestampDoc struct {
+	resdoc.ResourceDoc
+	Policy        resdoc.DocIdentifier `json:"policy"`
+	Signer        string               `json:"signer"` // SHA-256 thumbprint of the TSA certificate in hex
+	SerialNumber  []byte               `json:"serialNumber"`
+	GenTime       resdoc.NumericDate   `json:"iat"`
+	HashAlgorithm string               `json:"hashAlgorithm"`
+	HashedMessage []byte               `json:"hashedMessage"`
+	Nonce         string               `json:"nonce,omitempty"` // decimal
+}
+
+func respondTSPRejection(c ctx.RequestContext, err error) error {
+	resp, merr := marshalTSPRejection(err)
+	if merr != nil {
+		return merr
+	}
+	return c.Blob(http.StatusOK, contentTypeTimestampReply, resp)
+}
+
+// RespondTimestamp implements admin.ServerInterface.
+func (*CertServer) RespondTimestamp(ec echo.Context, namespaceProvider models.NamespaceProvider, namespaceId string, id string) error {
+	c := ec.(ctx.RequestContext)
+
+	body, err := io.ReadAll(io.LimitReader(c.Request().Body, tspRequestMaxBodySize))
+	if err != nil {
+		return respondTSPRejection(c, &tspError{failureInfo: tspFailureBadDataFormat, message: "failed to read request"})
+	}
+	req, err := parseTSPRequest(body)
+	if err != nil {
+		return respondTSPRejection(c, err)
+	}
+
+	if namespaceProvider != models.NamespaceProviderIntermediateCA {
+		return respondTSPRejection(c, &tspError{failureInfo: tspFailureBadRequest, message: "time stamping is only available for intermediate CA policies"})
+	}
+
+	// anonymous endpoint
+	c = c.Elevate()
+	policyDoc, err := GetCertificatePolicyInternal(c, namespaceProvider, namespaceId, id)
+	if err != nil {
+		if errors.Is(err, base.ErrResponseStatusNotFound) {
+			return respondTSPRejection(c, &tspError{failureInfo: tspFailureBadRequest, message: "policy not found"})
+		}
+		return err
+	}
+	if !policyDoc.AllowTimestamping {
+		return respondTSPRejection(c, &tspError{failureInfo: tspFailureBadRequest, message: "time stamping is not enabled for the policy"})
+	}
+	// the signer is issued when an admin saves the policy or its issuer
+	signer, err := readTSASignerInternal(c, policyDoc)
+	if err != nil {
+		if errors.Is(err, base.ErrResponseStatusBadRequest) {
+			return respondTSPRejection(c, &tspError{failureInfo: tspFailureSystemFailure, message: "time stamping signer is not available"})
+		}
+		log.Ctx(c).Error().Err(err).Msg("failed to get TSA signer")
+		return respondTSPRejection(c, err)
+	}
+	signerCert, err := signer.X509Certificate()
+	if err != nil {
+		return err
+	}
+	policyOID, err := tsaPolicyOID(policyDoc.Identifier())
+	if err != nil {
+		return err
+	}
+	policyOIDBytes, err := policyOID.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	serialNumber, err := rand.Int(rand.Reader, big.NewInt(0).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return err
+	}
+	genTime := time.Now().Truncate(time.Second)
+	tstInfo, err := req.marshalTSTInfo(policyOIDBytes, serialNumber, genTime)
+	if err != nil {
+		return respondTSPRejection(c, err)
+	}
+	var certs [][]byte
+	if req.certReq {
+		certs = utils.MapSlice(signer.JsonWebKey.CertificateChain, func(b cloudkey.Base64RawURLEncodableBytes) []byte { return b })
+	}
+	token, err := createTSPToken(tstInfo, signer.getCloudSignatureKey(c), signerCert, certs)
+	if err != nil {
+		log.Ctx(c).Error().Err(err).Msg("failed to sign time-stamp token")
+		return respondTSPRejection(c, err)
+	}
+
+	// tokens are only returned once recorded
+	doc := &certTimestampDoc{
+		ResourceDoc: resdoc.ResourceDoc{
+			PartitionKey: resdoc.PartitionKey{
+				NamespaceProvider: namespaceProvider,
+				NamespaceID:       namespaceId,
+				ResourceProvider:  models.ResourceProviderCertTimestamp,
+			},
+			ID: hex.EncodeToString(serialNumber.Bytes()),
+		},
+		Policy:        policyDoc.Identifier(),
+		Signer:        hex.EncodeToString(signer.JsonWebKey.ThumbprintSHA256),
+		SerialNumber:  serialNumber.Bytes(),
+		HashAlgorithm: req.hash.String(),
+		HashedMessage: req.hashedMessage,
+	}
+	doc.GenTime.Time = genTime
+	if req.nonce != nil {
+		doc.Nonce = req.nonce.String()
+	}
+	if _, err := resdoc.GetDocService(c).Create(c, doc, nil); err != nil {
+		log.Ctx(c).Error().Err(err).Msg("failed to record time-stamp token")
+		return respondTSPRejection(c, err)
+	}
+
+	resp, err := marshalTSPGranted(token)
+	if err != nil {
+		return err
+	}
+	return c.Blob(http.StatusOK, contentTypeTimestampReply, resp)
+}
@@ -0,0 +1,138 @@
+package cert
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+	"github.com/stephenzsy/small-kms/backend/base"
+	"github.com/stephenzsy/small-kms/backend/internal/auth"
+	"github.com/stephenzsy/small-kms/backend/internal/authz"
+	ctx "github.com/stephenzsy/small-kms/backend/internal/context"
+	"github.com/stephenzsy/small-kms/backend/models"
+	certmodels "github.com/stephenzsy/small-kms/backend/models/cert"
+	webhookmodels "github.com/stephenzsy/small-kms/backend/models/webhook"
+	"github.com/stephenzsy/small-kms/backend/resdoc"
+)
+
+// RevokeCertificate implements admin.ServerInterface.
+func (*CertServer) RevokeCertificate(ec echo.Context, namespaceProvider models.NamespaceProvider, namespaceId string, id string) error {
+	c := ec.(ctx.RequestContext)
+	if !authz.AuthorizeAdminOnly(c) {
+		return base.ErrResponseStatusForbidden
+	}
+
+	req := new(certmodels.RevokeCertificateRequest)
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+	if _, ok := req.Reason.ReasonCode(); !ok {
+		return fmt.Errorf("%w: invalid revocation reason: %s", base.ErrResponseStatusBadRequest, req.Reason)
+	}
+
+	doc := &certDocBase{}
+	if err := readCertDocInternal(c, namespaceProvider, namespaceId, id, doc); err != nil {
+		return err
+	}
+
+	revocation := certmodels.CertificateRevocation{
+		Reason:    req.Reason,
+		RevokedBy: auth.GetAuthIdentity(c).ClientPrincipalDisplayName(),
+	}
+	revocation.RevokedAt.Time = time.Now().Truncate(time.Second)
+
+	c = c.Elevate()
+	if err := revokeCertificateInternal(c, doc, revocation); err != nil {
+		return err
+	}
+	if doc.Issuer != doc.Identifier() {
+		if err := republishCRLInternal(c, doc.Issuer); err != nil {
+			return err
+		}
+	}
+	return c.JSON(http.StatusOK, doc.ToModel(false))
+}
+
+// revokeCertificateInternal rejects a certificate which has already been revoked, so the original revocation is kept
+func revokeCertificateInternal(c ctx.RequestContext, doc *certDocBase, revocation certmodels.CertificateRevocation) error {
+	switch doc.Status {
+	case certmodels.CertificateStatusIssued, certmodels.CertificateStatusDeactivated:
+	case certmodels.CertificateStatusRevoked:
+		return fmt.Errorf("%w: certificate has already been revoked", base.ErrResponseStatusBadRequest)
+	default:
+		return fmt.Errorf("%w: certificate is not issued", base.ErrResponseStatusBadRequest)
+	}
+
+	patchOps := azcosmos.PatchOperations{}
+	patchOps.AppendSet("/status", certmodels.CertificateStatusRevoked)
+	patchOps.AppendSet("/revocation", revocation)
+	if _, err := resdoc.GetDocService(c).Patch(c, doc, patchOps, &azcosmos.ItemOptions{
+		IfMatchEtag: doc.GetETag(),
+	}); err != nil {
+		return err
+	}
+	doc.Status = certmodels.CertificateStatusRevoked
+	doc.Revocation = &revocation
+	if err := syncIssuedCertLinkInternal(c, doc); err != nil {
+		return err
+	}
+	publishCertificateEvent(c, doc, webhookmodels.EventTypeCertificateRevoked)
+
+	if doc.PartitionKey.NamespaceProvider != models.NamespaceProviderRootCA &&
+		doc.PartitionKey.NamespaceProvider != models.NamespaceProviderIntermediateCA {
+		return nil
+	}
+	return revokeIssuedCertificatesInternal(c, doc, revocation)
+}
+
+// revokeIssuedCertificatesInternal revokes every certificate issued by a revoked CA certificate
+func revokeIssuedCertificatesInternal(c ctx.RequestContext, issuerDoc *certDocBase, issuerRevocation certmodels.CertificateRevocation) error {
+	links, err := listIssuedCertLinksInternal(c, issuerDoc.Identifier())
+	if err != nil {
+		return err
+	}
+
+	revocation := certmodels.CertificateRevocation{
+		Reason:       cascadedRevocationReason(issuerRevocation.Reason),
+		RevokedAt:    issuerRevocation.RevokedAt,
+		RevokedBy:    issuerRevocation.RevokedBy,
+		CascadedFrom: issuerDoc.Identifier().String(),
+	}
+	for _, link := range links {
+		if link.LinkTo == issuerDoc.Identifier() {
+			continue
+		}
+		certDoc := &certDocBase{}
+		if err := readCertDocInternal(c, link.LinkTo.NamespaceProvider, link.LinkTo.NamespaceID, link.LinkTo.ID, certDoc); err != nil {
+			if errors.Is(err, base.ErrResponseStatusNotFound) {
+				log.Ctx(c).Warn().Str("linkTo", link.LinkTo.String()).Msg("issued certificate not found, skip revocation")
+				continue
+			}
+			return err
+		}
+		if certDoc.Status == certmodels.CertificateStatusPending || certDoc.Status == certmodels.CertificateStatusRevoked {
+			continue
+		}
+		if err := revokeCertificateInternal(c, certDoc, revocation); err != nil {
+			return err
+		}
+	}
+	if len(links) == 0 {
+		return nil
+	}
+	return republishCRLInternal(c, issuerDoc.Identifier())
+}
+
+// a compromised CA key compromises everything it signed, otherwise the issued certificates
+// are revoked because the CA is no longer operating
+func cascadedRevocationReason(issuerReason certmodels.CertificateRevocationReason) certmodels.CertificateRevocationReason {
+	switch issuerReason {
+	case certmodels.RevocationReasonKeyCompromise, certmodels.RevocationReasonCACompromise:
+		return certmodels.RevocationReasonCACompromise
+	}
+	return certmodels.RevocationReasonCessationOfOperation
+}
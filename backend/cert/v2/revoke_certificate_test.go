@@ -0,0 +1,199 @@
+package cert
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stephenzsy/small-kms/backend/base"
+	cloudkey "github.com/stephenzsy/small-kms/backend/cloud/key"
+	cloudkeylocal "github.com/stephenzsy/small-kms/backend/cloud/key/local"
+	ctx "github.com/stephenzsy/small-kms/backend/internal/context"
+	kv "github.com/stephenzsy/small-kms/backend/internal/keyvault"
+	"github.com/stephenzsy/small-kms/backend/models"
+	certmodels "github.com/stephenzsy/small-kms/backend/models/cert"
+	"github.com/stephenzsy/small-kms/backend/resdoc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRevocationReasonCodes(t *testing.T) {
+	for reason, code := range map[certmodels.CertificateRevocationReason]int{
+		certmodels.RevocationReasonUnspecified:          0,
+		certmodels.RevocationReasonKeyCompromise:        1,
+		certmodels.RevocationReasonCACompromise:         2,
+		certmodels.RevocationReasonAffiliationChanged:   3,
+		certmodels.RevocationReasonSuperseded:           4,
+		certmodels.RevocationReasonCessationOfOperation: 5,
+		certmodels.RevocationReasonPrivilegeWithdrawn:   9,
+		certmodels.RevocationReasonAACompromise:         10,
+	} {
+		actual, ok := reason.ReasonCode()
+		assert.True(t, ok, reason)
+		assert.Equal(t, code, actual, reason)
+		parsed, ok := certmodels.RevocationReasonFromCode(code)
+		assert.True(t, ok, code)
+		assert.Equal(t, reason, parsed)
+	}
+	// removeFromCRL is only used in delta CRLs
+	_, ok := certmodels.CertificateRevocationReason("removeFromCRL").ReasonCode()
+	assert.False(t, ok)
+	_, ok = certmodels.RevocationReasonFromCode(8)
+	assert.False(t, ok)
+}
+
+func TestCascadedRevocationReason(t *testing.T) {
+	assert.Equal(t, certmodels.RevocationReasonCACompromise, cascadedRevocationReason(certmodels.RevocationReasonKeyCompromise))
+	assert.Equal(t, certmodels.RevocationReasonCACompromise, cascadedRevocationReason(certmodels.RevocationReasonCACompromise))
+	for _, reason := range []certmodels.CertificateRevocationReason{
+		certmodels.RevocationReasonUnspecified,
+		certmodels.RevocationReasonSuperseded,
+		certmodels.RevocationReasonCessationOfOperation,
+		certmodels.RevocationReasonAffiliationChanged,
+	} {
+		assert.Equal(t, certmodels.RevocationReasonCessationOfOperation, cascadedRevocationReason(reason), reason)
+	}
+}
+
+// newRevokeTestContext serves the embedded doc service and a local key store, which signs the CRLs
+func newRevokeTestContext(t *testing.T) (ctx.RequestContext, cloudkey.KeyStore) {
+	docSvc, err := resdoc.NewEmbeddedDocService(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	masterKey := make([]byte, 32)
+	_, err = rand.Read(masterKey)
+	require.NoError(t, err)
+	keyStore, err := cloudkeylocal.NewKeyStore(t.TempDir(), masterKey)
+	require.NoError(t, err)
+	serviceCtx := context.WithValue(context.Background(), resdoc.DocServiceContextKey, docSvc)
+	serviceCtx = context.WithValue(serviceCtx, kv.CloudKeyStoreContextKey, keyStore)
+	return ctx.NewBackgroundRequestContext(context.Background(), serviceCtx), keyStore
+}
+
+// newRevokeTestCADoc creates an issued intermediate CA certificate with its key in the local key store
+func newRevokeTestCADoc(t *testing.T, c ctx.RequestContext, keyStore cloudkey.KeyStore) *certDocBase {
+	key, err := keyStore.CreateKey(c, "test-intermediate-ca", cloudkey.CreateKeyParams{
+		KeyType:       cloudkey.KeyTypeEC,
+		Curve:         cloudkey.CurveNameP256,
+		KeyOperations: []cloudkey.JsonWebKeyOperation{cloudkey.JsonWebKeyOperationSign, cloudkey.JsonWebKeyOperationVerify},
+	})
+	require.NoError(t, err)
+	signer := keyStore.NewSignatureKey(c, key.KeyID, cloudkey.SignatureAlgorithmES256, true, key.PublicKey())
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test Intermediate CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().AddDate(1, 0, 0),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.PublicKey(), signer)
+	require.NoError(t, err)
+
+	doc := &certDocBase{
+		ResourceDoc: resdoc.ResourceDoc{
+			PartitionKey: resdoc.PartitionKey{
+				NamespaceProvider: models.NamespaceProviderIntermediateCA,
+				NamespaceID:       "default",
+				ResourceProvider:  models.ResourceProviderCert,
+			},
+			ID: "intermediate",
+		},
+		Status:       certmodels.CertificateStatusIssued,
+		JsonWebKey:   key.JsonWebKey,
+		Issuer:       resdoc.NewDocIdentifier(models.NamespaceProviderRootCA, "default", models.ResourceProviderCert, "root"),
+		SerialNumber: template.SerialNumber.Bytes(),
+	}
+	doc.JsonWebKey.Alg = string(cloudkey.SignatureAlgorithmES256)
+	doc.JsonWebKey.CertificateChain = []cloudkey.Base64RawURLEncodableBytes{der}
+	doc.NotAfter.Time = template.NotAfter
+	return doc
+}
+
+func newRevokeTestLeafDoc(issuer *certDocBase, id string, serialNumber int64, status certmodels.CertificateStatus) *certDocBase {
+	doc := &certDocBase{
+		ResourceDoc: resdoc.ResourceDoc{
+			PartitionKey: resdoc.PartitionKey{
+				NamespaceProvider: models.NamespaceProviderServicePrincipal,
+				NamespaceID:       "sp",
+				ResourceProvider:  models.ResourceProviderCert,
+			},
+			ID: id,
+		},
+		Status:       status,
+		Issuer:       issuer.Identifier(),
+		SerialNumber: big.NewInt(serialNumber).Bytes(),
+	}
+	doc.NotAfter.Time = time.Now().AddDate(0, 1, 0)
+	return doc
+}
+
+func TestRevokeCertificateCascade(t *testing.T) {
+	c, keyStore := newRevokeTestContext(t)
+	docSvc := resdoc.GetDocService(c)
+
+	caDoc := newRevokeTestCADoc(t, c, keyStore)
+	issued := newRevokeTestLeafDoc(caDoc, "issued", 100, certmodels.CertificateStatusIssued)
+	pending := newRevokeTestLeafDoc(caDoc, "pending", 101, certmodels.CertificateStatusPending)
+	superseded := newRevokeTestLeafDoc(caDoc, "superseded", 102, certmodels.CertificateStatusIssued)
+	for _, doc := range []*certDocBase{caDoc, issued, pending, superseded} {
+		_, err := docSvc.Create(c, doc, nil)
+		require.NoError(t, err)
+		require.NoError(t, syncIssuedCertLinkInternal(c, doc))
+	}
+	readDoc := func(doc *certDocBase) *certDocBase {
+		read := &certDocBase{}
+		require.NoError(t, readCertDocInternal(c, doc.PartitionKey.NamespaceProvider, doc.PartitionKey.NamespaceID, doc.ID, read))
+		return read
+	}
+	newRevocation := func(reason certmodels.CertificateRevocationReason) certmodels.CertificateRevocation {
+		revocation := certmodels.CertificateRevocation{Reason: reason, RevokedBy: "tester"}
+		revocation.RevokedAt.Time = time.Now().Truncate(time.Second)
+		return revocation
+	}
+
+	// a certificate revoked before the CA keeps its own revocation
+	require.NoError(t, revokeCertificateInternal(c, readDoc(superseded), newRevocation(certmodels.RevocationReasonSuperseded)))
+	err := revokeCertificateInternal(c, readDoc(superseded), newRevocation(certmodels.RevocationReasonKeyCompromise))
+	assert.ErrorIs(t, err, base.ErrResponseStatusBadRequest)
+	assert.Equal(t, certmodels.RevocationReasonSuperseded, readDoc(superseded).Revocation.Reason)
+
+	caRevocation := newRevocation(certmodels.RevocationReasonKeyCompromise)
+	require.NoError(t, revokeCertificateInternal(c, readDoc(caDoc), caRevocation))
+
+	revokedCA := readDoc(caDoc)
+	assert.Equal(t, certmodels.CertificateStatusRevoked, revokedCA.Status)
+	require.NotNil(t, revokedCA.Revocation)
+	assert.Equal(t, certmodels.RevocationReasonKeyCompromise, revokedCA.Revocation.Reason)
+	assert.Empty(t, revokedCA.Revocation.CascadedFrom)
+
+	revokedLeaf := readDoc(issued)
+	assert.Equal(t, certmodels.CertificateStatusRevoked, revokedLeaf.Status)
+	require.NotNil(t, revokedLeaf.Revocation)
+	assert.Equal(t, certmodels.RevocationReasonCACompromise, revokedLeaf.Revocation.Reason)
+	assert.Equal(t, caDoc.Identifier().String(), revokedLeaf.Revocation.CascadedFrom)
+	assert.Equal(t, caRevocation.RevokedAt.Unix(), revokedLeaf.Revocation.RevokedAt.Unix())
+
+	assert.Equal(t, certmodels.CertificateStatusPending, readDoc(pending).Status)
+	assert.Equal(t, certmodels.RevocationReasonSuperseded, readDoc(superseded).Revocation.Reason)
+
+	// the CRL of the revoked CA lists the issued certificates with their reasons
+	crlDoc := &certCRLDoc{}
+	require.NoError(t, docSvc.Read(c, getCRLDocIdentifier(caDoc.Identifier()), crlDoc, nil))
+	crl, err := x509.ParseRevocationList(crlDoc.CRL)
+	require.NoError(t, err)
+	caCert, err := caDoc.X509Certificate()
+	require.NoError(t, err)
+	require.NoError(t, crl.CheckSignatureFrom(caCert))
+	reasonCodes := map[int64]int{}
+	for _, entry := range crl.RevokedCertificateEntries {
+		reasonCodes[entry.SerialNumber.Int64()] = entry.ReasonCode
+	}
+	assert.Equal(t, map[int64]int{100: 2, 102: 4}, reasonCodes)
+}
@@ -0,0 +1,56 @@
+package cert
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
+	"github.com/labstack/echo/v4"
+	"github.com/stephenzsy/small-kms/backend/base"
+	"github.com/stephenzsy/small-kms/backend/internal/auth"
+	"github.com/stephenzsy/small-kms/backend/internal/authz"
+	ctx "github.com/stephenzsy/small-kms/backend/internal/context"
+	"github.com/stephenzsy/small-kms/backend/models"
+	certmodels "github.com/stephenzsy/small-kms/backend/models/cert"
+	"github.com/stephenzsy/small-kms/backend/resdoc"
+)
+
+// RevokeSSHCertificate implements admin.ServerInterface.
+func (*CertServer) RevokeSSHCertificate(ec echo.Context, namespaceProvider models.NamespaceProvider, namespaceId string, id string) error {
+	c := ec.(ctx.RequestContext)
+	if !authz.AuthorizeAdminOnly(c) {
+		return base.ErrResponseStatusForbidden
+	}
+
+	doc, err := readSSHCertDocInternal(c, namespaceProvider, namespaceId, id)
+	if err != nil {
+		return err
+	}
+	switch doc.Status {
+	case certmodels.CertificateStatusIssued:
+	case certmodels.CertificateStatusRevoked:
+		return c.JSON(http.StatusOK, doc.ToModel())
+	default:
+		return fmt.Errorf("%w: SSH certificate is not issued", base.ErrResponseStatusBadRequest)
+	}
+
+	// OpenSSH KRLs do not carry a reason
+	revocation := certmodels.CertificateRevocation{
+		Reason:    certmodels.RevocationReasonUnspecified,
+		RevokedBy: auth.GetAuthIdentity(c).ClientPrincipalDisplayName(),
+	}
+	revocation.RevokedAt.Time = time.Now().Truncate(time.Second)
+
+	patchOps := azcosmos.PatchOperations{}
+	patchOps.AppendSet("/status", certmodels.CertificateStatusRevoked)
+	patchOps.AppendSet("/revocation", revocation)
+	if _, err := resdoc.GetDocService(c).Patch(c, doc, patchOps, &azcosmos.ItemOptions{
+		IfMatchEtag: doc.GetETag(),
+	}); err != nil {
+		return err
+	}
+	doc.Status = certmodels.CertificateStatusRevoked
+	doc.Revocation = &revocation
+	return c.JSON(http.StatusOK, doc.ToModel())
+}
@@ -0,0 +1,91 @@
+package cert
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stephenzsy/small-kms/backend/base"
+	"github.com/stephenzsy/small-kms/backend/internal/auth"
+	ctx "github.com/stephenzsy/small-kms/backend/internal/context"
+	"github.com/stephenzsy/small-kms/backend/models"
+	certmodels "github.com/stephenzsy/small-kms/backend/models/cert"
+	ns "github.com/stephenzsy/small-kms/backend/namespace"
+	"github.com/stephenzsy/small-kms/backend/resdoc"
+)
+
+// SignSSHCertificate implements admin.ServerInterface.
+func (*CertServer) SignSSHCertificate(ec echo.Context, namespaceProvider models.NamespaceProvider, namespaceId string, policyID string) (err error) {
+	c := ec.(ctx.RequestContext)
+	reqIdentity := auth.GetAuthIdentity(c)
+	requesterID := reqIdentity.ClientPrincipalID()
+	namespaceId = ns.ResolveMeNamespace(c, namespaceId)
+
+	policy, err := getSSHCAPolicyInternal(c, namespaceProvider, namespaceId, policyID)
+	if err != nil {
+		return err
+	}
+
+	// principals of host certificates enrolled by agents must be derived from the agent identity
+	requesterBound := false
+	if reqIdentity.HasAdminRole() {
+		c = c.WithValue(templateContextKeyRequesterGraph, &ResourceTemplateGraphVarData{
+			ID: requesterID.String(),
+		})
+		c = c.WithValue(templateContextKeyNamespaceGraph, &ResourceTemplateGraphVarData{
+			ID: namespaceId,
+		})
+	} else {
+		if !policy.AllowEnroll {
+			return base.ErrResponseStatusForbidden
+		}
+		switch policy.CertType {
+		case certmodels.SSHCertificateTypeHost:
+			// host keys are signed for the machines agents run on
+			if !reqIdentity.HasRole(auth.RoleValueAgentActiveHost) {
+				return base.ErrResponseStatusForbidden
+			}
+			requesterBound = true
+		default:
+			if !reqIdentity.HasRole(auth.RoleValueAgentActiveHost) && !reqIdentity.HasRole(auth.RoleValueCertificateEnroll) {
+				return base.ErrResponseStatusForbidden
+			}
+		}
+		if c, _, err = authorizeEnrollInternal(c, requesterID, nil, namespaceProvider, namespaceId); err != nil {
+			return err
+		}
+	}
+
+	req := new(certmodels.SignSSHCertificateRequest)
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+	pub, err := parseSSHPublicKey(req.PublicKey)
+	if err != nil {
+		return err
+	}
+	principals, err := resolveSSHPrincipals(c, policy, req.Principals, requesterBound)
+	if err != nil {
+		return err
+	}
+	if len(principals) == 0 {
+		if requesterBound {
+			return fmt.Errorf("%w: policy has no principals templated from the requester", base.ErrResponseStatusBadRequest)
+		}
+		return fmt.Errorf("%w: no principals to sign", base.ErrResponseStatusBadRequest)
+	}
+
+	doc := &sshCertDoc{}
+	if err := doc.init(policy, principals, requesterID.String()); err != nil {
+		return err
+	}
+	if err := doc.sign(c, policy, pub); err != nil {
+		return err
+	}
+
+	c = c.Elevate()
+	if _, err := resdoc.GetDocService(c).Create(c, doc, nil); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusCreated, doc.ToModel())
+}
@@ -0,0 +1,363 @@
+package cert
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/stephenzsy/small-kms/backend/base"
+	cloudkey "github.com/stephenzsy/small-kms/backend/cloud/key"
+	ctx "github.com/stephenzsy/small-kms/backend/internal/context"
+	kv "github.com/stephenzsy/small-kms/backend/internal/keyvault"
+	"github.com/stephenzsy/small-kms/backend/models"
+	certmodels "github.com/stephenzsy/small-kms/backend/models/cert"
+	keymodels "github.com/stephenzsy/small-kms/backend/models/key"
+	"github.com/stephenzsy/small-kms/backend/resdoc"
+	"github.com/stephenzsy/small-kms/backend/utils"
+	"github.com/stephenzsy/small-kms/backend/utils/caldur"
+	"golang.org/x/crypto/ssh"
+)
+
+// SSHCAPolicyDoc signs OpenSSH certificates with a CA key kept in the key store
+type SSHCAPolicyDoc struct {
+	resdoc.ResourceDoc
+	DisplayName string `json:"displayName"`
+
+	CertType        certmodels.SSHCertificateType `json:"certType"`
+	KeySpec         keymodels.JsonWebKeySpec      `json:"keySpec"`
+	Principals      []string                      `json:"principals"`
+	Validity        caldur.CalendarDuration       `json:"validity"`
+	CriticalOptions map[string]string             `json:"criticalOptions,omitempty"`
+	Extensions      map[string]string             `json:"extensions,omitempty"`
+	AllowEnroll     bool                          `json:"allowEnroll"`
+
+	// public key of the CA, with the key ID in the key store
+	CAKey          cloudkey.JsonWebKey `json:"caKey"`
+	KeySpecVersion []byte              `json:"keySpecVersion"`
+
+	Version []byte `json:"version"`
+}
+
+const (
+	sshCertValidityMin = 5 * time.Minute
+	sshCertValidityMax = 365 * 24 * time.Hour
+)
+
+var (
+	sshCertDefaultUserValidity = caldur.CalendarDuration{Hour: 8}
+	sshCertDefaultHostValidity = caldur.CalendarDuration{Day: 30}
+
+	// same as ssh-keygen
+	sshCertDefaultUserExtensions = map[string]string{
+		"permit-X11-forwarding":   "",
+		"permit-agent-forwarding": "",
+		"permit-port-forwarding":  "",
+		"permit-pty":              "",
+		"permit-user-rc":          "",
+	}
+	sshCertKnownUserExtensions = []string{
+		"no-touch-required",
+		"permit-X11-forwarding",
+		"permit-agent-forwarding",
+		"permit-port-forwarding",
+		"permit-pty",
+		"permit-user-rc",
+	}
+)
+
+func (d *SSHCAPolicyDoc) initKeySpec(ks *keymodels.JsonWebKeySpec) error {
+	d.KeySpec = keymodels.JsonWebKeySpec{
+		Kty:         cloudkey.KeyTypeEC,
+		Crv:         cloudkey.CurveNameP384,
+		Extractable: utils.ToPtr(false),
+		KeyOperations: []cloudkey.JsonWebKeyOperation{
+			cloudkey.JsonWebKeyOperationSign,
+			cloudkey.JsonWebKeyOperationVerify,
+		},
+	}
+	if ks != nil {
+		if ks.KeyStore != "" {
+			if !ks.KeyStore.IsSupported() {
+				return fmt.Errorf("%w: unsupported key store: %s", base.ErrResponseStatusBadRequest, ks.KeyStore)
+			}
+			d.KeySpec.KeyStore = ks.KeyStore
+		}
+		switch ks.Kty {
+		case cloudkey.KeyTypeRSA:
+			d.KeySpec.Kty = cloudkey.KeyTypeRSA
+			d.KeySpec.Crv = ""
+			d.KeySpec.KeySize = utils.ToPtr(3072)
+			if ks.KeySize != nil {
+				switch *ks.KeySize {
+				case 2048, 3072, 4096:
+					d.KeySpec.KeySize = ks.KeySize
+				}
+			}
+		case cloudkey.KeyTypeEC:
+			switch ks.Crv {
+			case cloudkey.CurveNameP256, cloudkey.CurveNameP384, cloudkey.CurveNameP521:
+				d.KeySpec.Crv = ks.Crv
+			}
+		case cloudkey.KeyTypeOKP:
+			if ks.Crv != "" && ks.Crv != cloudkey.CurveNameEd25519 {
+				return fmt.Errorf("%w: unsupported curve for SSH CA: %s", base.ErrResponseStatusBadRequest, ks.Crv)
+			}
+			d.KeySpec.Kty = cloudkey.KeyTypeOKP
+			d.KeySpec.Crv = cloudkey.CurveNameEd25519
+			switch d.KeySpec.KeyStore {
+			case "":
+				d.KeySpec.KeyStore = cloudkey.KeyStoreKindLocal
+			case cloudkey.KeyStoreKindLocal:
+			default:
+				return fmt.Errorf("%w: %s keys can only be kept in the local key store", base.ErrResponseStatusBadRequest, d.KeySpec.Crv)
+			}
+		}
+	}
+
+	// OpenSSH fixes the hash of each key type, RSA keys sign with rsa-sha2-512
+	switch d.KeySpec.Kty {
+	case cloudkey.KeyTypeRSA:
+		d.KeySpec.Alg = string(cloudkey.SignatureAlgorithmRS512)
+	case cloudkey.KeyTypeOKP:
+		d.KeySpec.Alg = string(cloudkey.SignatureAlgorithmEdDSA)
+	case cloudkey.KeyTypeEC:
+		switch d.KeySpec.Crv {
+		case cloudkey.CurveNameP256:
+			d.KeySpec.Alg = string(cloudkey.SignatureAlgorithmES256)
+		case cloudkey.CurveNameP384:
+			d.KeySpec.Alg = string(cloudkey.SignatureAlgorithmES384)
+		case cloudkey.CurveNameP521:
+			d.KeySpec.Alg = string(cloudkey.SignatureAlgorithmES512)
+		}
+	}
+
+	dw := md5.New()
+	d.KeySpec.Digest(dw)
+	io.WriteString(dw, d.KeySpec.Alg)
+	d.KeySpecVersion = dw.Sum(nil)
+	return nil
+}
+
+func validateSSHCriticalOptions(certType certmodels.SSHCertificateType, options map[string]string) error {
+	if len(options) > 0 && certType != certmodels.SSHCertificateTypeUser {
+		return fmt.Errorf("%w: critical options are only supported for user certificates", base.ErrResponseStatusBadRequest)
+	}
+	for name, value := range options {
+		switch name {
+		case "force-command":
+			if value == "" {
+				return fmt.Errorf("%w: force-command cannot be empty", base.ErrResponseStatusBadRequest)
+			}
+		case "source-address":
+			for _, addr := range strings.Split(value, ",") {
+				if _, _, err := net.ParseCIDR(addr); err != nil && net.ParseIP(addr) == nil {
+					return fmt.Errorf("%w: invalid source-address: %s", base.ErrResponseStatusBadRequest, addr)
+				}
+			}
+		case "verify-required":
+			if value != "" {
+				return fmt.Errorf("%w: verify-required does not take a value", base.ErrResponseStatusBadRequest)
+			}
+		default:
+			return fmt.Errorf("%w: unsupported critical option: %s", base.ErrResponseStatusBadRequest, name)
+		}
+	}
+	return nil
+}
+
+func validateSSHExtensions(certType certmodels.SSHCertificateType, extensions map[string]string) error {
+	if len(extensions) > 0 && certType != certmodels.SSHCertificateTypeUser {
+		return fmt.Errorf("%w: extensions are only supported for user certificates", base.ErrResponseStatusBadRequest)
+	}
+	for name, value := range extensions {
+		if strings.Contains(name, "@") {
+			// vendor extension
+			continue
+		}
+		if !slices.Contains(sshCertKnownUserExtensions, name) {
+			return fmt.Errorf("%w: unsupported extension: %s", base.ErrResponseStatusBadRequest, name)
+		}
+		if value != "" {
+			return fmt.Errorf("%w: extension %s does not take a value", base.ErrResponseStatusBadRequest, name)
+		}
+	}
+	return nil
+}
+
+func (d *SSHCAPolicyDoc) init(p *certmodels.SSHCAPolicyParameters) error {
+	switch d.PartitionKey.NamespaceProvider {
+	case models.NamespaceProviderServicePrincipal,
+		models.NamespaceProviderGroup:
+	default:
+		return fmt.Errorf("%w: unsupported namespace provider: %s", base.ErrResponseStatusBadRequest, d.PartitionKey.NamespaceProvider)
+	}
+
+	d.DisplayName = d.ID
+	if p.DisplayName != "" {
+		d.DisplayName = p.DisplayName
+	}
+
+	d.CertType = p.CertType
+	switch d.CertType {
+	case certmodels.SSHCertificateTypeUser:
+		d.Validity = sshCertDefaultUserValidity
+		d.Extensions = sshCertDefaultUserExtensions
+	case certmodels.SSHCertificateTypeHost:
+		d.Validity = sshCertDefaultHostValidity
+	default:
+		return fmt.Errorf("%w: invalid certificate type: %s", base.ErrResponseStatusBadRequest, p.CertType)
+	}
+
+	if err := d.initKeySpec(p.KeySpec); err != nil {
+		return err
+	}
+
+	if len(p.Principals) == 0 {
+		return fmt.Errorf("%w: principals cannot be empty", base.ErrResponseStatusBadRequest)
+	}
+	d.Principals = make([]string, 0, len(p.Principals))
+	for _, principal := range p.Principals {
+		principal = strings.TrimSpace(principal)
+		if principal == "" {
+			continue
+		}
+		if _, _, err := preprocessTemplate(principal); err != nil {
+			return fmt.Errorf("%w: invalid principal template %s: %w", base.ErrResponseStatusBadRequest, principal, err)
+		}
+		if !slices.Contains(d.Principals, principal) {
+			d.Principals = append(d.Principals, principal)
+		}
+	}
+	if len(d.Principals) == 0 {
+		return fmt.Errorf("%w: principals cannot be empty", base.ErrResponseStatusBadRequest)
+	}
+
+	if p.Validity != "" {
+		validity, err := caldur.Parse(p.Validity)
+		if err != nil {
+			return fmt.Errorf("%w: invalid validity format", base.ErrResponseStatusBadRequest)
+		}
+		now := time.Now()
+		if evaluated := caldur.Shift(now, validity).Sub(now); evaluated < sshCertValidityMin || evaluated > sshCertValidityMax {
+			return fmt.Errorf("%w: validity cannot be more than 1 year or less than 5 minutes", base.ErrResponseStatusBadRequest)
+		}
+		d.Validity = validity
+	}
+
+	if err := validateSSHCriticalOptions(d.CertType, p.CriticalOptions); err != nil {
+		return err
+	}
+	d.CriticalOptions = p.CriticalOptions
+	if p.Extensions != nil {
+		if err := validateSSHExtensions(d.CertType, p.Extensions); err != nil {
+			return err
+		}
+		d.Extensions = p.Extensions
+	}
+	d.AllowEnroll = p.AllowEnroll
+	if d.AllowEnroll && d.CertType == certmodels.SSHCertificateTypeHost &&
+		!slices.ContainsFunc(d.Principals, isRequesterTemplate) {
+		return fmt.Errorf("%w: host certificates enrolled by agents require principals templated from the requester, e.g. {{requester.graph.displayName}}", base.ErrResponseStatusBadRequest)
+	}
+
+	dw := md5.New()
+	io.WriteString(dw, string(d.CertType))
+	dw.Write(d.KeySpecVersion)
+	for _, principal := range d.Principals {
+		io.WriteString(dw, principal)
+	}
+	dw.Write(d.Validity.Bytes())
+	for _, name := range slices.Sorted(maps.Keys(d.CriticalOptions)) {
+		io.WriteString(dw, name)
+		io.WriteString(dw, d.CriticalOptions[name])
+	}
+	for _, name := range slices.Sorted(maps.Keys(d.Extensions)) {
+		io.WriteString(dw, name)
+		io.WriteString(dw, d.Extensions[name])
+	}
+	if d.AllowEnroll {
+		dw.Write([]byte("allowEnroll"))
+	}
+	d.Version = dw.Sum(nil)
+	return nil
+}
+
+// initCAKey keeps the CA key of the existing policy unless the key spec changed, otherwise a new key is created
+func (d *SSHCAPolicyDoc) initCAKey(c context.Context, existing *SSHCAPolicyDoc) error {
+	if existing != nil && existing.CAKey.KeyID != "" && bytes.Equal(existing.KeySpecVersion, d.KeySpecVersion) {
+		d.CAKey = existing.CAKey
+		return nil
+	}
+
+	keyStore, err := cloudkey.SelectKeyStore(kv.GetCloudKeyStore(c), d.KeySpec.KeyStore)
+	if err != nil {
+		return fmt.Errorf("%w: %w", base.ErrResponseStatusBadRequest, err)
+	}
+	params := cloudkey.CreateKeyParams{
+		KeyType:       d.KeySpec.Kty,
+		Curve:         d.KeySpec.Crv,
+		KeyOperations: d.KeySpec.KeyOperations,
+		Exportable:    d.KeySpec.Extractable,
+	}
+	if d.KeySpec.KeySize != nil {
+		params.KeySize = *d.KeySpec.KeySize
+	}
+	created, err := keyStore.CreateKey(c,
+		kv.GetMaterialName(kv.MaterialNameKindSSHCAKey, d.PartitionKey.NamespaceProvider, d.PartitionKey.NamespaceID, d.ID), params)
+	if err != nil {
+		return err
+	}
+	d.CAKey = created.JsonWebKey
+	d.CAKey.Alg = d.KeySpec.Alg
+	return nil
+}
+
+// getSSHSigner returns the signer of the CA key in the key store
+func (d *SSHCAPolicyDoc) getSSHSigner(c context.Context) (ssh.Signer, error) {
+	ck := kv.GetCloudKeyStore(c).NewSignatureKey(c, d.CAKey.KeyID,
+		cloudkey.JsonWebSignatureAlgorithm(d.CAKey.Alg), true, d.CAKey.PublicKey())
+	return ssh.NewSignerFromSigner(ck)
+}
+
+func (d *SSHCAPolicyDoc) getCAPublicKey() (ssh.PublicKey, error) {
+	return ssh.NewPublicKey(d.CAKey.PublicKey())
+}
+
+func (d *SSHCAPolicyDoc) ToRef() (m models.Ref) {
+	m = d.ResourceDoc.ToRef()
+	m.DisplayName = &d.DisplayName
+	return m
+}
+
+func (d *SSHCAPolicyDoc) ToModel() (m certmodels.SSHCAPolicy) {
+	m.Ref = d.ToRef()
+	m.CertType = d.CertType
+	m.Principals = d.Principals
+	m.Validity = d.Validity.String()
+	m.CriticalOptions = d.CriticalOptions
+	m.Extensions = d.Extensions
+	m.AllowEnroll = d.AllowEnroll
+	if pub, err := d.getCAPublicKey(); err == nil {
+		m.CAPublicKey = strings.TrimSpace(string(ssh.MarshalAuthorizedKey(pub)))
+	}
+	return m
+}
+
+func getSSHCAPolicyInternal(c ctx.RequestContext, namespaceProvider models.NamespaceProvider, namespaceId string, id string) (*SSHCAPolicyDoc, error) {
+	doc := &SSHCAPolicyDoc{}
+	if err := resdoc.GetDocService(c).Read(c, resdoc.NewDocIdentifier(namespaceProvider, namespaceId, models.ResourceProviderSSHCAPolicy, id), doc, nil); err != nil {
+		if errors.Is(err, resdoc.ErrAzCosmosDocNotFound) {
+			return nil, fmt.Errorf("%w: SSH CA policy not found: %s", base.ErrResponseStatusNotFound, id)
+		}
+		return nil, err
+	}
+	return doc, nil
+}
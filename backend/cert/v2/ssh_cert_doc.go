@@ -0,0 +1,196 @@
+package cert
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stephenzsy/small-kms/backend/base"
+	ctx "github.com/stephenzsy/small-kms/backend/internal/context"
+	"github.com/stephenzsy/small-kms/backend/models"
+	certmodels "github.com/stephenzsy/small-kms/backend/models/cert"
+	"github.com/stephenzsy/small-kms/backend/resdoc"
+	"github.com/stephenzsy/small-kms/backend/utils/caldur"
+	"golang.org/x/crypto/ssh"
+)
+
+// sshCertDoc is an OpenSSH certificate signed by an SSH CA policy, kept in the namespace of the policy
+type sshCertDoc struct {
+	resdoc.ResourceDoc
+	Policy        resdoc.DocIdentifier          `json:"policy"`
+	PolicyVersion []byte                        `json:"policyVersion"`
+	CertType      certmodels.SSHCertificateType `json:"certType"`
+	// decimal, uint64 serials do not fit in JSON numbers
+	Serial      string                            `json:"serial"`
+	KeyID       string                            `json:"keyId"`
+	Principals  []string                          `json:"principals"`
+	NotBefore   resdoc.NumericDate                `json:"nbf"`
+	NotAfter    resdoc.NumericDate                `json:"exp"`
+	Status      certmodels.CertificateStatus      `json:"status"`
+	RequestedBy string                            `json:"requestedBy"`
+	Certificate string                            `json:"certificate"`
+	Revocation  *certmodels.CertificateRevocation `json:"revocation,omitempty"`
+}
+
+func (d *sshCertDoc) serial() (uint64, error) {
+	return strconv.ParseUint(d.Serial, 10, 64)
+}
+
+// resolveSSHPrincipals returns the principals of the policy with the templates evaluated,
+// requested principals must be a subset of them.
+// With requesterBound, only the principals templated from the requester are resolved,
+// so a host cannot obtain a certificate for the names of other hosts
+func resolveSSHPrincipals(c ctx.RequestContext, policy *SSHCAPolicyDoc, requested []string, requesterBound bool) ([]string, error) {
+	principals := make([]string, 0, len(policy.Principals))
+	for i, principalTemplate := range policy.Principals {
+		if requesterBound && !isRequesterTemplate(principalTemplate) {
+			continue
+		}
+		principal, err := processTemplate(c, fmt.Sprintf("principals[%d]", i), principalTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("%w: failed to evaluate principal %s: %w", base.ErrResponseStatusBadRequest, principalTemplate, err)
+		}
+		if principal != principalTemplate && strings.ContainsAny(principal, sshPrincipalInvalidChars) {
+			return nil, fmt.Errorf("%w: evaluated principal is invalid: %q", base.ErrResponseStatusBadRequest, principal)
+		}
+		if principal != "" && !slices.Contains(principals, principal) {
+			principals = append(principals, principal)
+		}
+	}
+	if len(requested) == 0 {
+		return principals, nil
+	}
+	selected := make([]string, 0, len(requested))
+	for _, principal := range requested {
+		if !slices.Contains(principals, principal) {
+			return nil, fmt.Errorf("%w: principal is not allowed by the policy: %s", base.ErrResponseStatusBadRequest, principal)
+		}
+		if !slices.Contains(selected, principal) {
+			selected = append(selected, principal)
+		}
+	}
+	return selected, nil
+}
+
+// characters of patterns and lists in sshd and known_hosts, not allowed in principals evaluated from directory attributes
+const sshPrincipalInvalidChars = "*?!, \t\r\n"
+
+func parseSSHPublicKey(s string) (ssh.PublicKey, error) {
+	pub, _, _, _, err := ssh.ParseAuthorizedKey([]byte(s))
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid public key: %w", base.ErrResponseStatusBadRequest, err)
+	}
+	if _, isCert := pub.(*ssh.Certificate); isCert {
+		return nil, fmt.Errorf("%w: public key cannot be a certificate", base.ErrResponseStatusBadRequest)
+	}
+	return pub, nil
+}
+
+func (d *sshCertDoc) init(policy *SSHCAPolicyDoc, principals []string, requestedBy string) error {
+	certUUID, err := uuid.NewRandom()
+	if err != nil {
+		return err
+	}
+	var serial [8]byte
+	if _, err := rand.Read(serial[:]); err != nil {
+		return err
+	}
+	d.PartitionKey = resdoc.PartitionKey{
+		NamespaceProvider: policy.PartitionKey.NamespaceProvider,
+		NamespaceID:       policy.PartitionKey.NamespaceID,
+		ResourceProvider:  models.ResourceProviderSSHCert,
+	}
+	d.ID = certUUID.String()
+	d.Policy = policy.Identifier()
+	d.PolicyVersion = policy.Version
+	d.CertType = policy.CertType
+	d.Serial = strconv.FormatUint(binary.BigEndian.Uint64(serial[:]), 10)
+	// the key ID is logged by sshd, it identifies the certificate document
+	d.KeyID = d.ID
+	d.Principals = principals
+	d.RequestedBy = requestedBy
+
+	now := time.Now().Truncate(time.Second)
+	d.NotBefore.Time = now
+	d.NotAfter.Time = caldur.Shift(now, policy.Validity)
+	return nil
+}
+
+// sign signs the certificate of the public key with the CA key of the policy
+func (d *sshCertDoc) sign(c ctx.RequestContext, policy *SSHCAPolicyDoc, pub ssh.PublicKey) error {
+	signer, err := policy.getSSHSigner(c)
+	if err != nil {
+		return err
+	}
+	serial, err := d.serial()
+	if err != nil {
+		return err
+	}
+	cert := &ssh.Certificate{
+		Key:             pub,
+		Serial:          serial,
+		KeyId:           d.KeyID,
+		ValidPrincipals: d.Principals,
+		ValidAfter:      uint64(d.NotBefore.Unix()),
+		ValidBefore:     uint64(d.NotAfter.Unix()),
+		Permissions: ssh.Permissions{
+			CriticalOptions: policy.CriticalOptions,
+			Extensions:      policy.Extensions,
+		},
+	}
+	switch d.CertType {
+	case certmodels.SSHCertificateTypeHost:
+		cert.CertType = ssh.HostCert
+	default:
+		cert.CertType = ssh.UserCert
+	}
+	if err := cert.SignCert(rand.Reader, signer); err != nil {
+		return err
+	}
+	d.Certificate = strings.TrimSpace(string(ssh.MarshalAuthorizedKey(cert)))
+	d.Status = certmodels.CertificateStatusIssued
+	return nil
+}
+
+func (d *sshCertDoc) parseCertificate() (*ssh.Certificate, error) {
+	pub, _, _, _, err := ssh.ParseAuthorizedKey([]byte(d.Certificate))
+	if err != nil {
+		return nil, err
+	}
+	cert, ok := pub.(*ssh.Certificate)
+	if !ok {
+		return nil, fmt.Errorf("not an SSH certificate")
+	}
+	return cert, nil
+}
+
+func (d *sshCertDoc) ToModel() (m certmodels.SSHCertificate) {
+	m.Ref = d.ResourceDoc.ToRef()
+	m.Policy = d.Policy.String()
+	m.CertType = d.CertType
+	m.Serial = d.Serial
+	m.KeyID = d.KeyID
+	m.Principals = d.Principals
+	m.Nbf = d.NotBefore
+	m.Exp = d.NotAfter
+	m.Status = d.Status
+	m.Certificate = d.Certificate
+	return m
+}
+
+func readSSHCertDocInternal(c ctx.RequestContext, namespaceProvider models.NamespaceProvider, namespaceId string, id string) (*sshCertDoc, error) {
+	doc := &sshCertDoc{}
+	if err := resdoc.GetDocService(c).Read(c, resdoc.NewDocIdentifier(namespaceProvider, namespaceId, models.ResourceProviderSSHCert, id), doc, nil); err != nil {
+		if errors.Is(err, resdoc.ErrAzCosmosDocNotFound) {
+			return nil, fmt.Errorf("%w: SSH certificate not found: %s", base.ErrResponseStatusNotFound, id)
+		}
+		return nil, err
+	}
+	return doc, nil
+}
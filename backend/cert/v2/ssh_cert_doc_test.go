@@ -0,0 +1,38 @@
+package cert
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stephenzsy/small-kms/backend/base"
+	ctx "github.com/stephenzsy/small-kms/backend/internal/context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveSSHPrincipalsRequesterBound(t *testing.T) {
+	c := ctx.NewBackgroundRequestContext(context.Background(), context.Background())
+	c = c.WithValue(templateContextKeyRequesterGraph, &ResourceTemplateGraphVarData{DisplayName: "host-1"})
+	c = c.WithValue(templateContextKeyNamespaceGraph, &ResourceTemplateGraphVarData{DisplayName: "servers"})
+	policy := &SSHCAPolicyDoc{Principals: []string{
+		"{{requester.graph.displayName}}",
+		"{{requester.graph.displayName}}.example.com",
+		"{{namespace.graph.displayName}}.example.com",
+		"bastion.example.com",
+	}}
+
+	principals, err := resolveSSHPrincipals(c, policy, nil, false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"host-1", "host-1.example.com", "servers.example.com", "bastion.example.com"}, principals)
+
+	principals, err = resolveSSHPrincipals(c, policy, nil, true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"host-1", "host-1.example.com"}, principals)
+
+	_, err = resolveSSHPrincipals(c, policy, []string{"bastion.example.com"}, true)
+	assert.ErrorIs(t, err, base.ErrResponseStatusBadRequest)
+
+	c = c.WithValue(templateContextKeyRequesterGraph, &ResourceTemplateGraphVarData{DisplayName: "*"})
+	_, err = resolveSSHPrincipals(c, policy, nil, true)
+	assert.ErrorIs(t, err, base.ErrResponseStatusBadRequest)
+}
@@ -0,0 +1,82 @@
+package cert
+
+import (
+	"bytes"
+	"encoding/binary"
+	"slices"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// OpenSSH key revocation list, PROTOCOL.krl of OpenSSH
+const (
+	sshKRLMagic         uint64 = 0x5353484b524c0a00
+	sshKRLFormatVersion uint32 = 1
+
+	sshKRLSectionCertificates   byte = 1
+	sshKRLCertSectionSerialList byte = 0x20
+)
+
+type sshKRLBuilder struct {
+	// serials revoked per CA key, keyed by the wire format of the CA key
+	caKeys  map[string]ssh.PublicKey
+	serials map[string][]uint64
+}
+
+func newSSHKRLBuilder() *sshKRLBuilder {
+	return &sshKRLBuilder{
+		caKeys:  make(map[string]ssh.PublicKey),
+		serials: make(map[string][]uint64),
+	}
+}
+
+func (b *sshKRLBuilder) revokeCertificate(cert *ssh.Certificate) {
+	key := string(cert.SignatureKey.Marshal())
+	b.caKeys[key] = cert.SignatureKey
+	if !slices.Contains(b.serials[key], cert.Serial) {
+		b.serials[key] = append(b.serials[key], cert.Serial)
+	}
+}
+
+func writeSSHString(buf *bytes.Buffer, s []byte) {
+	binary.Write(buf, binary.BigEndian, uint32(len(s)))
+	buf.Write(s)
+}
+
+// marshal writes the KRL, the KRL version is the time it is generated so that newer lists always have greater versions
+func (b *sshKRLBuilder) marshal(generatedAt time.Time, comment string) []byte {
+	buf := &bytes.Buffer{}
+	binary.Write(buf, binary.BigEndian, sshKRLMagic)
+	binary.Write(buf, binary.BigEndian, sshKRLFormatVersion)
+	binary.Write(buf, binary.BigEndian, uint64(generatedAt.Unix())) // krl_version
+	binary.Write(buf, binary.BigEndian, uint64(generatedAt.Unix())) // generated_date
+	binary.Write(buf, binary.BigEndian, uint64(0))                  // flags
+	writeSSHString(buf, nil)                                        // reserved
+	writeSSHString(buf, []byte(comment))
+
+	keys := make([]string, 0, len(b.caKeys))
+	for key := range b.caKeys {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		serials := slices.Clone(b.serials[key])
+		slices.Sort(serials)
+
+		serialList := &bytes.Buffer{}
+		for _, serial := range serials {
+			binary.Write(serialList, binary.BigEndian, serial)
+		}
+
+		section := &bytes.Buffer{}
+		writeSSHString(section, []byte(key))
+		writeSSHString(section, nil) // reserved
+		section.WriteByte(sshKRLCertSectionSerialList)
+		writeSSHString(section, serialList.Bytes())
+
+		buf.WriteByte(sshKRLSectionCertificates)
+		writeSSHString(buf, section.Bytes())
+	}
+	return buf.Bytes()
+}
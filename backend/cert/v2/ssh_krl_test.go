@@ -0,0 +1,126 @@
+package cert
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	certmodels "github.com/stephenzsy/small-kms/backend/models/cert"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+func newTestSSHCert(t *testing.T, ca ssh.Signer, serial uint64) *ssh.Certificate {
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	sshPub, err := ssh.NewPublicKey(pub)
+	require.NoError(t, err)
+	now := time.Now()
+	cert := &ssh.Certificate{
+		Key:             sshPub,
+		Serial:          serial,
+		CertType:        ssh.HostCert,
+		KeyId:           "test",
+		ValidPrincipals: []string{"host.example.com"},
+		ValidAfter:      uint64(now.Unix()),
+		ValidBefore:     uint64(now.Add(time.Hour).Unix()),
+	}
+	require.NoError(t, cert.SignCert(rand.Reader, ca))
+	return cert
+}
+
+func readTestSSHString(t *testing.T, r *bytes.Reader) []byte {
+	var l uint32
+	require.NoError(t, binary.Read(r, binary.BigEndian, &l))
+	s := make([]byte, l)
+	_, err := r.Read(s)
+	if l > 0 {
+		require.NoError(t, err)
+	}
+	return s
+}
+
+func TestSSHKRLMarshal(t *testing.T) {
+	caKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	ca, err := ssh.NewSignerFromSigner(caKey)
+	require.NoError(t, err)
+
+	b := newSSHKRLBuilder()
+	b.revokeCertificate(newTestSSHCert(t, ca, 42))
+	b.revokeCertificate(newTestSSHCert(t, ca, 7))
+	b.revokeCertificate(newTestSSHCert(t, ca, 42))
+
+	generatedAt := time.Unix(1700000000, 0)
+	r := bytes.NewReader(b.marshal(generatedAt, "test"))
+
+	var magic, krlVersion, generatedDate, flags uint64
+	var formatVersion uint32
+	require.NoError(t, binary.Read(r, binary.BigEndian, &magic))
+	assert.Equal(t, sshKRLMagic, magic)
+	require.NoError(t, binary.Read(r, binary.BigEndian, &formatVersion))
+	assert.Equal(t, sshKRLFormatVersion, formatVersion)
+	require.NoError(t, binary.Read(r, binary.BigEndian, &krlVersion))
+	require.NoError(t, binary.Read(r, binary.BigEndian, &generatedDate))
+	assert.Equal(t, uint64(generatedAt.Unix()), krlVersion)
+	assert.Equal(t, uint64(generatedAt.Unix()), generatedDate)
+	require.NoError(t, binary.Read(r, binary.BigEndian, &flags))
+	assert.Empty(t, readTestSSHString(t, r))
+	assert.Equal(t, "test", string(readTestSSHString(t, r)))
+
+	sectionType, err := r.ReadByte()
+	require.NoError(t, err)
+	assert.Equal(t, sshKRLSectionCertificates, sectionType)
+	section := bytes.NewReader(readTestSSHString(t, r))
+	assert.Zero(t, r.Len())
+
+	assert.Equal(t, ca.PublicKey().Marshal(), readTestSSHString(t, section))
+	assert.Empty(t, readTestSSHString(t, section))
+	subType, err := section.ReadByte()
+	require.NoError(t, err)
+	assert.Equal(t, sshKRLCertSectionSerialList, subType)
+	serialList := bytes.NewReader(readTestSSHString(t, section))
+	serials := make([]uint64, serialList.Len()/8)
+	require.NoError(t, binary.Read(serialList, binary.BigEndian, serials))
+	assert.Equal(t, []uint64{7, 42}, serials)
+}
+
+func TestParseSSHPublicKey(t *testing.T) {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	ca, err := ssh.NewSignerFromSigner(caKey)
+	require.NoError(t, err)
+
+	pub, err := parseSSHPublicKey(string(ssh.MarshalAuthorizedKey(ca.PublicKey())))
+	require.NoError(t, err)
+	assert.Equal(t, ca.PublicKey().Marshal(), pub.Marshal())
+
+	cert := newTestSSHCert(t, ca, 1)
+	_, err = parseSSHPublicKey(string(ssh.MarshalAuthorizedKey(cert)))
+	assert.Error(t, err)
+	_, err = parseSSHPublicKey("not a key")
+	assert.Error(t, err)
+}
+
+func TestValidateSSHCertOptions(t *testing.T) {
+	assert.NoError(t, validateSSHExtensions(certmodels.SSHCertificateTypeUser, map[string]string{
+		"permit-pty":             "",
+		"login@example.com":      "value",
+		"permit-port-forwarding": "",
+	}))
+	assert.Error(t, validateSSHExtensions(certmodels.SSHCertificateTypeUser, map[string]string{"permit-everything": ""}))
+	assert.Error(t, validateSSHExtensions(certmodels.SSHCertificateTypeHost, map[string]string{"permit-pty": ""}))
+
+	assert.NoError(t, validateSSHCriticalOptions(certmodels.SSHCertificateTypeUser, map[string]string{
+		"source-address": "10.0.0.0/8,192.168.1.1",
+		"force-command":  "/usr/bin/true",
+	}))
+	assert.Error(t, validateSSHCriticalOptions(certmodels.SSHCertificateTypeUser, map[string]string{"source-address": "nowhere"}))
+	assert.Error(t, validateSSHCriticalOptions(certmodels.SSHCertificateTypeHost, map[string]string{"verify-required": ""}))
+}
@@ -5,15 +5,41 @@ import (
 	"errors"
 	"fmt"
 	"regexp"
+	"slices"
 	"strings"
 	"text/template"
 
 	"github.com/rs/zerolog/log"
+	"github.com/stephenzsy/small-kms/backend/admin/profile"
+	"github.com/stephenzsy/small-kms/backend/base"
 	certmodels "github.com/stephenzsy/small-kms/backend/models/cert"
 )
 
 type ResourceTemplateGraphVarData struct {
-	ID string `json:"id,omitempty"`
+	ID                string `json:"id,omitempty"`
+	UserPrincipalName string `json:"upn,omitempty"`
+	Mail              string `json:"mail,omitempty"`
+	DisplayName       string `json:"displayName,omitempty"`
+	AppID             string `json:"appId,omitempty"`
+	DeviceID          string `json:"deviceId,omitempty"`
+}
+
+// newResourceTemplateGraphVarData returns the template variables of the directory attributes of a synced profile
+func newResourceTemplateGraphVarData(p *profile.ProfileDoc) *ResourceTemplateGraphVarData {
+	deref := func(s *string) string {
+		if s == nil {
+			return ""
+		}
+		return *s
+	}
+	return &ResourceTemplateGraphVarData{
+		ID:                p.ID,
+		UserPrincipalName: deref(p.UserPrincipalName),
+		Mail:              deref(p.Mail),
+		DisplayName:       deref(p.DisplayName),
+		AppID:             deref(p.AppId),
+		DeviceID:          deref(p.DeviceID),
+	}
 }
 
 type ResourceTemplateVarData struct {
@@ -32,15 +58,93 @@ const (
 	templateContextKeyNamespaceGraph templateContextKey = "namespace.graph"
 )
 
+func subjectTemplateFields(subject *certmodels.CertificateSubject) map[string]*string {
+	return map[string]*string{
+		"subject.CommonName":         &subject.CommonName,
+		"subject.Organization":       &subject.Organization,
+		"subject.OrganizationalUnit": &subject.OrganizationalUnit,
+		"subject.Country":            &subject.Country,
+		"subject.Locality":           &subject.Locality,
+		"subject.Province":           &subject.Province,
+	}
+}
+
 func processSubjectTemplate(c context.Context, subject certmodels.CertificateSubject) (certmodels.CertificateSubject, error) {
-	var err error
-	subject.CommonName, err = processTemplate(c, "subject.CommonName", subject.CommonName)
-	if err != nil {
-		return subject, err
+	for name, field := range subjectTemplateFields(&subject) {
+		processed, err := processTemplate(c, name, *field)
+		if err != nil {
+			return subject, fmt.Errorf("%w: failed to evaluate %s: %w", base.ErrResponseStatusBadRequest, name, err)
+		}
+		*field = processed
 	}
 	return subject, nil
 }
 
+// processSANsTemplate returns a copy of the SANs with the templates evaluated,
+// values evaluated from templates must be valid names of their type
+func processSANsTemplate(c context.Context, sans *certmodels.SubjectAlternativeNames) (*certmodels.SubjectAlternativeNames, error) {
+	if sans == nil {
+		return nil, nil
+	}
+	processList := func(name string, values []string, sanitize func([]string) []string) ([]string, error) {
+		processed := make([]string, len(values))
+		for i, v := range values {
+			p, err := processTemplate(c, fmt.Sprintf("%s[%d]", name, i), v)
+			if err != nil {
+				return nil, fmt.Errorf("%w: failed to evaluate %s: %w", base.ErrResponseStatusBadRequest, v, err)
+			}
+			if p != v && len(sanitize([]string{p})) == 0 {
+				return nil, fmt.Errorf("%w: %s evaluated to an invalid name: %s", base.ErrResponseStatusBadRequest, name, p)
+			}
+			processed[i] = p
+		}
+		return processed, nil
+	}
+	processed := &certmodels.SubjectAlternativeNames{
+		IPAddresses: slices.Clone(sans.IPAddresses),
+	}
+	var err error
+	if processed.DNSNames, err = processList("sans.DNSNames", sans.DNSNames, sanitizeTemplatedDNSNames); err != nil {
+		return nil, err
+	}
+	if processed.Emails, err = processList("sans.Emails", sans.Emails, certmodels.SanitizeEmailAddresses); err != nil {
+		return nil, err
+	}
+	if processed.URIs, err = processList("sans.URIs", sans.URIs, certmodels.SanitizeURIs); err != nil {
+		return nil, err
+	}
+	return processed.Sanitize(), nil
+}
+
+var dnsNameRegex = regexp.MustCompile(`^(\*\.)?([a-z0-9]([a-z0-9\-]*[a-z0-9])?\.)*[a-z0-9]([a-z0-9\-]*[a-z0-9])?$`)
+
+// sanitizeTemplatedDNSNames drops DNS names evaluated from directory attributes that are not valid host names,
+// such as display names with spaces
+func sanitizeTemplatedDNSNames(dnsNames []string) []string {
+	return slices.DeleteFunc(certmodels.SanitizeDNSNames(dnsNames), func(s string) bool {
+		return !dnsNameRegex.MatchString(s)
+	})
+}
+
+// validateCertificateTemplates validates the template variables of the subject and the SANs of a policy
+func validateCertificateTemplates(subject certmodels.CertificateSubject, sans *certmodels.SubjectAlternativeNames) error {
+	values := make([]string, 0)
+	for _, field := range subjectTemplateFields(&subject) {
+		values = append(values, *field)
+	}
+	if sans != nil {
+		values = append(values, sans.DNSNames...)
+		values = append(values, sans.Emails...)
+		values = append(values, sans.URIs...)
+	}
+	for _, v := range values {
+		if _, _, err := preprocessTemplate(v); err != nil {
+			return fmt.Errorf("%w: invalid template %s: %w", base.ErrResponseStatusBadRequest, v, err)
+		}
+	}
+	return nil
+}
+
 func processTemplate(c context.Context, templateName, templateStr string) (string, error) {
 	bad := func(e error) (string, error) {
 		return templateStr, e
@@ -52,7 +156,7 @@ func processTemplate(c context.Context, templateName, templateStr string) (strin
 	if !hasTemplate {
 		return templateStr, nil
 	}
-	tmpl, err := template.New(templateName).Option("missingkey=error").Parse(preprocessed)
+	tmpl, err := template.New(templateName).Option("missingkey=error").Funcs(templateFuncs).Parse(preprocessed)
 	if err != nil {
 		// template parse failed, something is wrong probably with preprocess
 		log.Ctx(c).Error().Err(err).Str("originalTemplate", templateStr).Str("preprocessedTemplate", preprocessed).Msg("template parse failed")
@@ -82,22 +186,54 @@ func lookupContextForTemplateVars(c context.Context) (d TemplateVarData) {
 }
 
 var allowedTemplateVars = map[string]string{
-	"requester.graph.id": ".Requester.Graph.ID",
-	"namespace.graph.id": ".Namespace.Graph.ID",
+	"requester.graph.id":          ".Requester.Graph.ID",
+	"requester.graph.upn":         ".Requester.Graph.UserPrincipalName",
+	"requester.graph.mail":        ".Requester.Graph.Mail",
+	"requester.graph.displayName": ".Requester.Graph.DisplayName",
+	"requester.graph.appId":       ".Requester.Graph.AppID",
+	"requester.graph.deviceId":    ".Requester.Graph.DeviceID",
+	"namespace.graph.id":          ".Namespace.Graph.ID",
+	"namespace.graph.upn":         ".Namespace.Graph.UserPrincipalName",
+	"namespace.graph.mail":        ".Namespace.Graph.Mail",
+	"namespace.graph.displayName": ".Namespace.Graph.DisplayName",
+	"namespace.graph.appId":       ".Namespace.Graph.AppID",
+	"namespace.graph.deviceId":    ".Namespace.Graph.DeviceID",
+}
+
+var templateFuncs = template.FuncMap{
+	// required fails the evaluation if the directory attribute is not set on the profile
+	"required": func(name string, value string) (string, error) {
+		if value == "" {
+			return "", fmt.Errorf("%w: %s", ErrTemplateVarNotSet, name)
+		}
+		return value, nil
+	},
 }
 
 var varRegex = regexp.MustCompile(`\{\{([a-zA-Z0-9\.\-_]+)\}\}`)
 
+// isRequesterTemplate returns true if the template references a variable of the requester,
+// so the evaluated value is derived from the identity of the caller
+func isRequesterTemplate(s string) bool {
+	for _, match := range varRegex.FindAllStringSubmatch(s, -1) {
+		if strings.HasPrefix(match[1], "requester.") {
+			return true
+		}
+	}
+	return false
+}
+
 func isSegmentValid(s string) (string, bool) {
 	return s, !(strings.Contains(s, "{{") || strings.Contains(s, "}}"))
 }
 
 var (
 	ErrTemplateInvalidSyntax = errors.New("template has invalid syntax")
+	ErrTemplateVarNotSet     = errors.New("template variable is not set")
 )
 
 func preprocessTemplate(s string) (transformed string, hasTemplate bool, err error) {
-	allMatches := varRegex.FindAllStringIndex(s, -1)
+	allMatches := varRegex.FindAllStringSubmatchIndex(s, -1)
 	sb := strings.Builder{}
 	sbInd := 0
 	for _, match := range allMatches {
@@ -108,11 +244,9 @@ func preprocessTemplate(s string) (transformed string, hasTemplate bool, err err
 			return s, false, fmt.Errorf("%w: unmatched '{{' or '}}'", ErrTemplateInvalidSyntax)
 		}
 
-		matchedInner := varRegex.FindStringSubmatch(s)[1]
+		matchedInner := s[match[2]:match[3]]
 		if t, ok := allowedTemplateVars[matchedInner]; ok {
-			sb.WriteString("{{ ")
-			sb.WriteString(t)
-			sb.WriteString(" }}")
+			fmt.Fprintf(&sb, "{{ required %q %s }}", matchedInner, t)
 		} else {
 			return s, false, fmt.Errorf("%w: invalid template variable '%s'", ErrTemplateInvalidSyntax, matchedInner)
 		}
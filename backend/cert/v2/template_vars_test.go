@@ -4,7 +4,10 @@ import (
 	"context"
 	"testing"
 
+	"github.com/stephenzsy/small-kms/backend/base"
+	certmodels "github.com/stephenzsy/small-kms/backend/models/cert"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestProcessTemplate(t *testing.T) {
@@ -35,3 +38,64 @@ func TestProcessTemplateBadTemplate(t *testing.T) {
 	_, err := processTemplate(ctx, "test", templateStr)
 	assert.Error(t, err)
 }
+
+func TestProcessTemplateMultipleVars(t *testing.T) {
+	ctx := context.WithValue(context.Background(), templateContextKeyRequesterGraph, &ResourceTemplateGraphVarData{
+		ID:          "123",
+		DisplayName: "Alice",
+	})
+	ctx = context.WithValue(ctx, templateContextKeyNamespaceGraph, &ResourceTemplateGraphVarData{
+		ID:          "456",
+		DisplayName: "Engineering",
+	})
+	result, err := processTemplate(ctx, "test", "{{requester.graph.displayName}} ({{namespace.graph.displayName}})")
+	assert.NoError(t, err)
+	assert.Equal(t, "Alice (Engineering)", result)
+
+	_, err = processTemplate(ctx, "test", "{{requester.graph.mail}}")
+	assert.ErrorIs(t, err, ErrTemplateVarNotSet)
+}
+
+func TestProcessSubjectAndSANsTemplate(t *testing.T) {
+	ctx := context.WithValue(context.Background(), templateContextKeyRequesterGraph, &ResourceTemplateGraphVarData{
+		ID:                "123",
+		UserPrincipalName: "alice@example.com",
+		Mail:              "Alice.Smith@example.com",
+		DisplayName:       "Alice Smith",
+	})
+	ctx = context.WithValue(ctx, templateContextKeyNamespaceGraph, &ResourceTemplateGraphVarData{
+		ID:          "456",
+		DisplayName: "Engineering",
+	})
+
+	subject, err := processSubjectTemplate(ctx, certmodels.CertificateSubject{
+		CommonName:         "{{requester.graph.displayName}}",
+		Organization:       "Contoso",
+		OrganizationalUnit: "{{namespace.graph.displayName}}",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "CN=Alice Smith,OU=Engineering,O=Contoso", subject.String())
+
+	policySANs := (&certmodels.SubjectAlternativeNames{
+		DNSNames: []string{"{{requester.graph.id}}.devices.example.com", "Static.example.com"},
+		Emails:   []string{"{{requester.graph.mail}}"},
+		URIs:     []string{"urn:uuid:{{requester.graph.id}}"},
+	}).Sanitize()
+	require.NoError(t, validateCertificateTemplates(subject, policySANs))
+	sans, err := processSANsTemplate(ctx, policySANs)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"123.devices.example.com", "static.example.com"}, sans.DNSNames)
+	assert.Equal(t, []string{"Alice.Smith@example.com"}, sans.Emails)
+	assert.Equal(t, []string{"urn:uuid:123"}, sans.URIs)
+	assert.Equal(t, "{{requester.graph.mail}}", policySANs.Emails[0])
+
+	_, err = processSANsTemplate(ctx, &certmodels.SubjectAlternativeNames{
+		DNSNames: []string{"{{requester.graph.displayName}}.example.com"},
+	})
+	assert.ErrorIs(t, err, base.ErrResponseStatusBadRequest)
+
+	err = validateCertificateTemplates(certmodels.CertificateSubject{CommonName: "test"}, &certmodels.SubjectAlternativeNames{
+		Emails: []string{"{{requester.graph.email}}"},
+	})
+	assert.ErrorIs(t, err, base.ErrResponseStatusBadRequest)
+}
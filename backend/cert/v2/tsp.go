@@ -0,0 +1,338 @@
+package cert
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"math/big"
+	"time"
+
+	"golang.org/x/crypto/cryptobyte"
+	cbasn1 "golang.org/x/crypto/cryptobyte/asn1"
+)
+
+// time-stamp protocol, RFC 3161 with the ESS signing certificate v2 attribute of RFC 5816
+
+const (
+	tspRequestMaxBodySize      = 16 * 1024
+	contentTypeTimestampQuery  = "application/timestamp-query"
+	contentTypeTimestampReply  = "application/timestamp-reply"
+	tspStatusGranted           = 0
+	tspStatusRejection         = 2
+	tspAccuracySeconds         = 1
+	tspSignedDataVersion       = 3 // eContentType is not id-data
+	tspSignerInfoVersion       = 1 // signer identified by issuer and serial number
+	tspTimeStampRequestVersion = 1
+)
+
+// PKIFailureInfo bits
+type tspFailureInfo int
+
+const (
+	tspFailureBadAlg              tspFailureInfo = 0
+	tspFailureBadRequest          tspFailureInfo = 2
+	tspFailureBadDataFormat       tspFailureInfo = 5
+	tspFailureUnacceptedPolicy    tspFailureInfo = 15
+	tspFailureUnacceptedExtension tspFailureInfo = 16
+	tspFailureSystemFailure       tspFailureInfo = 25
+)
+
+var (
+	oidContentTypeSignedData         = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidContentTypeTSTInfo            = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 1, 4}
+	oidAttributeContentType          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+	oidAttributeMessageDigest        = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+	oidAttributeSigningCertificateV2 = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 2, 47}
+	oidDigestAlgorithmSHA256         = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidDigestAlgorithmSHA384         = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 2}
+	oidDigestAlgorithmSHA512         = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 3}
+	oidSignatureECDSAWithSHA256      = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+)
+
+type tspError struct {
+	failureInfo tspFailureInfo
+	message     string
+}
+
+func (e *tspError) Error() string {
+	return e.message
+}
+
+type tspRequest struct {
+	// DER of the AlgorithmIdentifier, echoed in the token as is
+	hashAlgorithm []byte
+	hash          crypto.Hash
+	hashedMessage []byte
+	// DER content of the requested policy OID, nil if not requested
+	reqPolicy []byte
+	nonce     *big.Int
+	certReq   bool
+}
+
+// parseTSPRequest parses a TimeStampReq, the policy OID is read as raw bytes as it may not fit encoding/asn1
+func parseTSPRequest(der []byte) (*tspRequest, error) {
+	bad := func(failureInfo tspFailureInfo, message string) (*tspRequest, error) {
+		return nil, &tspError{failureInfo: failureInfo, message: message}
+	}
+
+	r := &tspRequest{}
+	input := cryptobyte.String(der)
+	var req, imprint, algIDElem cryptobyte.String
+	var version int64
+	if !input.ReadASN1(&req, cbasn1.SEQUENCE) || !input.Empty() ||
+		!req.ReadASN1Integer(&version) ||
+		!req.ReadASN1(&imprint, cbasn1.SEQUENCE) ||
+		!imprint.ReadASN1Element(&algIDElem, cbasn1.SEQUENCE) ||
+		!imprint.ReadASN1Bytes(&r.hashedMessage, cbasn1.OCTET_STRING) || !imprint.Empty() {
+		return bad(tspFailureBadDataFormat, "malformed request")
+	}
+	if version != tspTimeStampRequestVersion {
+		return bad(tspFailureBadRequest, "unsupported request version")
+	}
+	r.hashAlgorithm = algIDElem
+
+	var algID cryptobyte.String
+	var hashOID asn1.ObjectIdentifier
+	if !algIDElem.ReadASN1(&algID, cbasn1.SEQUENCE) || !algID.ReadASN1ObjectIdentifier(&hashOID) {
+		return bad(tspFailureBadDataFormat, "malformed hash algorithm")
+	}
+	switch {
+	case hashOID.Equal(oidDigestAlgorithmSHA256):
+		r.hash = crypto.SHA256
+	case hashOID.Equal(oidDigestAlgorithmSHA384):
+		r.hash = crypto.SHA384
+	case hashOID.Equal(oidDigestAlgorithmSHA512):
+		r.hash = crypto.SHA512
+	default:
+		return bad(tspFailureBadAlg, "unsupported hash algorithm")
+	}
+	if len(r.hashedMessage) != r.hash.Size() {
+		return bad(tspFailureBadDataFormat, "hashed message does not match the hash algorithm")
+	}
+
+	var policy cryptobyte.String
+	var hasPolicy bool
+	if !req.ReadOptionalASN1(&policy, &hasPolicy, cbasn1.OBJECT_IDENTIFIER) {
+		return bad(tspFailureBadDataFormat, "malformed policy")
+	}
+	if hasPolicy {
+		r.reqPolicy = policy
+	}
+	if req.PeekASN1Tag(cbasn1.INTEGER) {
+		r.nonce = new(big.Int)
+		if !req.ReadASN1Integer(r.nonce) {
+			return bad(tspFailureBadDataFormat, "malformed nonce")
+		}
+	}
+	if req.PeekASN1Tag(cbasn1.BOOLEAN) && !req.ReadASN1Boolean(&r.certReq) {
+		return bad(tspFailureBadDataFormat, "malformed certReq")
+	}
+	if req.PeekASN1Tag(cbasn1.Tag(0).ContextSpecific().Constructed()) {
+		return bad(tspFailureUnacceptedExtension, "extensions are not supported")
+	}
+	if !req.Empty() {
+		return bad(tspFailureBadDataFormat, "malformed request")
+	}
+	return r, nil
+}
+
+type tspStatusInfo struct {
+	Status       int
+	StatusString []asn1.RawValue `asn1:"optional"` // PKIFreeText of UTF8String
+	FailInfo     asn1.BitString  `asn1:"optional"`
+}
+
+type tspResponse struct {
+	Status         tspStatusInfo
+	TimeStampToken asn1.RawValue `asn1:"optional"`
+}
+
+type tspAccuracy struct {
+	Seconds int `asn1:"optional"`
+}
+
+type tspTSTInfo struct {
+	Version        int
+	Policy         asn1.RawValue
+	MessageImprint asn1.RawValue
+	SerialNumber   *big.Int
+	GenTime        time.Time   `asn1:"generalized"`
+	Accuracy       tspAccuracy `asn1:"optional"`
+	Nonce          *big.Int    `asn1:"optional"`
+}
+
+type cmsContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue
+}
+
+type cmsEncapsulatedContentInfo struct {
+	EContentType asn1.ObjectIdentifier
+	EContent     []byte `asn1:"explicit,tag:0"`
+}
+
+type cmsIssuerAndSerialNumber struct {
+	Issuer       asn1.RawValue
+	SerialNumber *big.Int
+}
+
+type cmsAttribute struct {
+	Type   asn1.ObjectIdentifier
+	Values []asn1.RawValue `asn1:"set"`
+}
+
+type cmsSignerInfo struct {
+	Version            int
+	SID                cmsIssuerAndSerialNumber
+	DigestAlgorithm    pkix.AlgorithmIdentifier
+	SignedAttrs        asn1.RawValue
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          []byte
+}
+
+type cmsSignedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	EncapContentInfo cmsEncapsulatedContentInfo
+	Certificates     asn1.RawValue   `asn1:"optional"`
+	SignerInfos      []cmsSignerInfo `asn1:"set"`
+}
+
+type essCertIDv2 struct {
+	// DEFAULT sha256, always omitted
+	HashAlgorithm pkix.AlgorithmIdentifier `asn1:"optional"`
+	CertHash      []byte
+}
+
+type essSigningCertificateV2 struct {
+	Certs []essCertIDv2
+}
+
+// marshalTSTInfo creates the TSTInfo of the request, policy is the DER content of the TSA policy OID
+func (r *tspRequest) marshalTSTInfo(policy []byte, serialNumber *big.Int, genTime time.Time) ([]byte, error) {
+	if r.reqPolicy != nil && !bytes.Equal(r.reqPolicy, policy) {
+		return nil, &tspError{failureInfo: tspFailureUnacceptedPolicy, message: "requested policy is not supported"}
+	}
+	imprint, err := asn1.Marshal(struct {
+		HashAlgorithm asn1.RawValue
+		HashedMessage []byte
+	}{asn1.RawValue{FullBytes: r.hashAlgorithm}, r.hashedMessage})
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(tspTSTInfo{
+		Version:        1,
+		Policy:         asn1.RawValue{Tag: asn1.TagOID, Bytes: policy},
+		MessageImprint: asn1.RawValue{FullBytes: imprint},
+		SerialNumber:   serialNumber,
+		// RFC 3161 requires UTC
+		GenTime:  genTime.UTC(),
+		Accuracy: tspAccuracy{Seconds: tspAccuracySeconds},
+		Nonce:    r.nonce,
+	})
+}
+
+func newCMSAttribute(attrType asn1.ObjectIdentifier, value any) (cmsAttribute, error) {
+	der, err := asn1.Marshal(value)
+	if err != nil {
+		return cmsAttribute{}, err
+	}
+	return cmsAttribute{Type: attrType, Values: []asn1.RawValue{{FullBytes: der}}}, nil
+}
+
+// createTSPToken signs the TSTInfo as CMS SignedData with the ES256 signer of the TSA certificate,
+// certs are included when requested with the TSA certificate first
+func createTSPToken(tstInfo []byte, signer crypto.Signer, tsaCert *x509.Certificate, certs [][]byte) ([]byte, error) {
+	tstInfoDigest := sha256.Sum256(tstInfo)
+	certDigest := sha256.Sum256(tsaCert.Raw)
+	attrs := make([]cmsAttribute, 3)
+	var err error
+	if attrs[0], err = newCMSAttribute(oidAttributeContentType, oidContentTypeTSTInfo); err != nil {
+		return nil, err
+	}
+	if attrs[1], err = newCMSAttribute(oidAttributeMessageDigest, tstInfoDigest[:]); err != nil {
+		return nil, err
+	}
+	if attrs[2], err = newCMSAttribute(oidAttributeSigningCertificateV2, essSigningCertificateV2{
+		Certs: []essCertIDv2{{CertHash: certDigest[:]}},
+	}); err != nil {
+		return nil, err
+	}
+
+	// the signature is over the DER of the attributes as a SET, they are carried as [0] IMPLICIT
+	attrsDER, err := asn1.MarshalWithParams(attrs, "set")
+	if err != nil {
+		return nil, err
+	}
+	var attrsSet asn1.RawValue
+	if _, err := asn1.Unmarshal(attrsDER, &attrsSet); err != nil {
+		return nil, err
+	}
+	attrsDigest := sha256.Sum256(attrsDER)
+	signature, err := signer.Sign(rand.Reader, attrsDigest[:], crypto.SHA256)
+	if err != nil {
+		return nil, err
+	}
+
+	digestAlgorithm := pkix.AlgorithmIdentifier{Algorithm: oidDigestAlgorithmSHA256}
+	signedData := cmsSignedData{
+		Version:          tspSignedDataVersion,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{digestAlgorithm},
+		EncapContentInfo: cmsEncapsulatedContentInfo{
+			EContentType: oidContentTypeTSTInfo,
+			EContent:     tstInfo,
+		},
+		SignerInfos: []cmsSignerInfo{{
+			Version: tspSignerInfoVersion,
+			SID: cmsIssuerAndSerialNumber{
+				Issuer:       asn1.RawValue{FullBytes: tsaCert.RawIssuer},
+				SerialNumber: tsaCert.SerialNumber,
+			},
+			DigestAlgorithm:    digestAlgorithm,
+			SignedAttrs:        asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: attrsSet.Bytes},
+			SignatureAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidSignatureECDSAWithSHA256},
+			Signature:          signature,
+		}},
+	}
+	if len(certs) > 0 {
+		signedData.Certificates = asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: bytes.Join(certs, nil)}
+	}
+	signedDataDER, err := asn1.Marshal(signedData)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(cmsContentInfo{
+		ContentType: oidContentTypeSignedData,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: signedDataDER},
+	})
+}
+
+func marshalTSPGranted(token []byte) ([]byte, error) {
+	return asn1.Marshal(tspResponse{
+		Status:         tspStatusInfo{Status: tspStatusGranted},
+		TimeStampToken: asn1.RawValue{FullBytes: token},
+	})
+}
+
+// marshalTSPRejection returns the rejection of the error, failures other than tspError are reported as system failure
+func marshalTSPRejection(err error) ([]byte, error) {
+	te := &tspError{failureInfo: tspFailureSystemFailure, message: "system failure"}
+	errors.As(err, &te)
+	failInfo := asn1.BitString{
+		Bytes:     make([]byte, int(te.failureInfo)/8+1),
+		BitLength: int(te.failureInfo) + 1,
+	}
+	failInfo.Bytes[te.failureInfo/8] |= 0x80 >> (te.failureInfo % 8)
+	return asn1.Marshal(tspResponse{
+		Status: tspStatusInfo{
+			Status:       tspStatusRejection,
+			StatusString: []asn1.RawValue{{Tag: asn1.TagUTF8String, Bytes: []byte(te.message)}},
+			FailInfo:     failInfo,
+		},
+	})
+}
@@ -0,0 +1,162 @@
+package cert
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stephenzsy/small-kms/backend/models"
+	"github.com/stephenzsy/small-kms/backend/resdoc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testTSPMessageImprint struct {
+	HashAlgorithm pkix.AlgorithmIdentifier
+	HashedMessage []byte
+}
+
+type testTSPRequest struct {
+	Version        int
+	MessageImprint testTSPMessageImprint
+	ReqPolicy      asn1.RawValue `asn1:"optional"`
+	Nonce          *big.Int      `asn1:"optional"`
+	CertReq        bool          `asn1:"optional"`
+}
+
+func newTestTSPRequest(t *testing.T, hashOID asn1.ObjectIdentifier, hashed []byte, policy []byte, nonce *big.Int) []byte {
+	req := testTSPRequest{
+		Version: 1,
+		MessageImprint: testTSPMessageImprint{
+			HashAlgorithm: pkix.AlgorithmIdentifier{Algorithm: hashOID, Parameters: asn1.NullRawValue},
+			HashedMessage: hashed,
+		},
+		Nonce:   nonce,
+		CertReq: true,
+	}
+	if policy != nil {
+		req.ReqPolicy = asn1.RawValue{Tag: asn1.TagOID, Bytes: policy}
+	}
+	der, err := asn1.Marshal(req)
+	require.NoError(t, err)
+	return der
+}
+
+func TestTSPTimeStampToken(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	now := time.Now()
+	certDER, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "Test Time Stamping Authority"},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(time.Hour),
+	}, &x509.Certificate{SerialNumber: big.NewInt(1), Subject: pkix.Name{CommonName: "Test Time Stamping Authority"}}, key.Public(), key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(certDER)
+	require.NoError(t, err)
+
+	policyOID, err := tsaPolicyOID(resdoc.NewDocIdentifier(models.NamespaceProviderIntermediateCA, "test", models.ResourceProviderCertPolicy, "tsa"))
+	require.NoError(t, err)
+	policy, err := policyOID.MarshalBinary()
+	require.NoError(t, err)
+
+	digest := sha256.Sum256([]byte("document"))
+	req, err := parseTSPRequest(newTestTSPRequest(t, oidDigestAlgorithmSHA256, digest[:], policy, big.NewInt(42)))
+	require.NoError(t, err)
+	assert.True(t, req.certReq)
+	assert.Equal(t, int64(42), req.nonce.Int64())
+
+	tstInfo, err := req.marshalTSTInfo(policy, big.NewInt(7), now)
+	require.NoError(t, err)
+	var parsedInfo tspTSTInfo
+	_, err = asn1.Unmarshal(tstInfo, &parsedInfo)
+	require.NoError(t, err)
+	assert.Equal(t, policy, parsedInfo.Policy.Bytes)
+	assert.Equal(t, int64(7), parsedInfo.SerialNumber.Int64())
+	assert.Equal(t, now.Unix(), parsedInfo.GenTime.Unix())
+	assert.Equal(t, int64(42), parsedInfo.Nonce.Int64())
+	var imprint testTSPMessageImprint
+	_, err = asn1.Unmarshal(parsedInfo.MessageImprint.FullBytes, &imprint)
+	require.NoError(t, err)
+	assert.Equal(t, digest[:], imprint.HashedMessage)
+
+	token, err := createTSPToken(tstInfo, key, cert, [][]byte{certDER})
+	require.NoError(t, err)
+	resp, err := marshalTSPGranted(token)
+	require.NoError(t, err)
+
+	var parsedResp tspResponse
+	_, err = asn1.Unmarshal(resp, &parsedResp)
+	require.NoError(t, err)
+	assert.Equal(t, tspStatusGranted, parsedResp.Status.Status)
+	var contentInfo cmsContentInfo
+	_, err = asn1.Unmarshal(parsedResp.TimeStampToken.FullBytes, &contentInfo)
+	require.NoError(t, err)
+	assert.True(t, contentInfo.ContentType.Equal(oidContentTypeSignedData))
+	var signedData cmsSignedData
+	_, err = asn1.Unmarshal(contentInfo.Content.Bytes, &signedData)
+	require.NoError(t, err)
+	assert.Equal(t, tstInfo, signedData.EncapContentInfo.EContent)
+	assert.Equal(t, certDER, signedData.Certificates.Bytes)
+	require.Len(t, signedData.SignerInfos, 1)
+	signerInfo := signedData.SignerInfos[0]
+	assert.Equal(t, cert.SerialNumber, signerInfo.SID.SerialNumber)
+
+	// the signature is over the attributes with the SET tag
+	attrsDER := append([]byte{0x31}, signerInfo.SignedAttrs.FullBytes[1:]...)
+	attrsDigest := sha256.Sum256(attrsDER)
+	assert.True(t, ecdsa.VerifyASN1(&key.PublicKey, attrsDigest[:], signerInfo.Signature))
+	var attrs []cmsAttribute
+	_, err = asn1.UnmarshalWithParams(attrsDER, &attrs, "set")
+	require.NoError(t, err)
+	tstInfoDigest := sha256.Sum256(tstInfo)
+	for _, attr := range attrs {
+		if attr.Type.Equal(oidAttributeMessageDigest) {
+			var md []byte
+			_, err = asn1.Unmarshal(attr.Values[0].FullBytes, &md)
+			require.NoError(t, err)
+			assert.Equal(t, tstInfoDigest[:], md)
+		}
+	}
+}
+
+func TestTSPRequestRejected(t *testing.T) {
+	digest := sha256.Sum256([]byte("document"))
+	failureInfo := func(err error) tspFailureInfo {
+		te := &tspError{}
+		require.True(t, errors.As(err, &te))
+		return te.failureInfo
+	}
+
+	_, err := parseTSPRequest([]byte("not a request"))
+	assert.Equal(t, tspFailureBadDataFormat, failureInfo(err))
+
+	_, err = parseTSPRequest(newTestTSPRequest(t, asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}, digest[:20], nil, nil))
+	assert.Equal(t, tspFailureBadAlg, failureInfo(err))
+
+	_, err = parseTSPRequest(newTestTSPRequest(t, oidDigestAlgorithmSHA384, digest[:], nil, nil))
+	assert.Equal(t, tspFailureBadDataFormat, failureInfo(err))
+
+	req, err := parseTSPRequest(newTestTSPRequest(t, oidDigestAlgorithmSHA256, digest[:], []byte{0x2a, 0x03}, nil))
+	require.NoError(t, err)
+	_, err = req.marshalTSTInfo([]byte{0x2a, 0x04}, big.NewInt(1), time.Now())
+	assert.Equal(t, tspFailureUnacceptedPolicy, failureInfo(err))
+
+	resp, err := marshalTSPRejection(err)
+	require.NoError(t, err)
+	var parsed tspResponse
+	_, err = asn1.Unmarshal(resp, &parsed)
+	require.NoError(t, err)
+	assert.Equal(t, tspStatusRejection, parsed.Status.Status)
+	assert.Equal(t, 1, parsed.Status.FailInfo.At(int(tspFailureUnacceptedPolicy)))
+	assert.Equal(t, int(tspFailureUnacceptedPolicy)+1, parsed.Status.FailInfo.BitLength)
+}
@@ -3,14 +3,12 @@ package cert
 import (
 	"net/http"
 
-	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
 	"github.com/labstack/echo/v4"
 	"github.com/stephenzsy/small-kms/backend/base"
 	"github.com/stephenzsy/small-kms/backend/internal/authz"
 	ctx "github.com/stephenzsy/small-kms/backend/internal/context"
 	"github.com/stephenzsy/small-kms/backend/models"
 	certmodels "github.com/stephenzsy/small-kms/backend/models/cert"
-	"github.com/stephenzsy/small-kms/backend/resdoc"
 	"golang.org/x/crypto/acme"
 )
 
@@ -49,24 +47,7 @@ func (*CertServer) UpdatePendingCertificate(ec echo.Context, namespaceProvider m
 			return err
 		}
 	case req.AcmeOrderCertificate != nil && *req.AcmeOrderCertificate:
-
-		csr, err := certDoc.GetCertificateRequest(c, false)
-		if err != nil {
-			return err
-		}
-
-		der, err := certDoc.CreateCertificate(c, csr)
-		if err != nil {
-			return err
-		}
-
-		if err := certDoc.CollectSignedCertificate(c, der); err != nil {
-			return err
-		}
-		docSvc := resdoc.GetDocService(c)
-		if _, err := docSvc.Upsert(c, certDoc, &azcosmos.ItemOptions{
-			IfMatchEtag: certDoc.ETag,
-		}); err != nil {
+		if err := certDoc.finalize(c); err != nil {
 			return err
 		}
 	}
@@ -3,7 +3,6 @@ package cloudkeyaz
 import (
 	"context"
 	"crypto"
-	"crypto/rsa"
 	"fmt"
 	"io"
 
@@ -104,23 +103,32 @@ func (ck *azCloudKey) Decrypt(rand io.Reader, msg []byte, opts crypto.DecrypterO
 	params := azkeys.KeyOperationParameters{
 		Value: msg,
 	}
+	var keyOp i.JsonWebKeyOperation
 	switch ck.keyType {
 	case i.KeyTypeRSA:
-		if opts, ok := opts.(*rsa.OAEPOptions); !ok {
-			return nil, fmt.Errorf("%w: %T", i.ErrInvalidAlgorithm, opts)
-		} else {
-			switch opts.Hash {
-			case crypto.SHA256:
-				params.Algorithm = to.Ptr(azkeys.EncryptionAlgorithmRSAOAEP256)
-			case crypto.SHA1:
-				params.Algorithm = to.Ptr(azkeys.EncryptionAlgorithmRSAOAEP)
-			default:
-				return nil, fmt.Errorf("%w: %s", i.ErrInvalidAlgorithm, opts.Hash)
-			}
+		oaepOpts, op, err := i.ParseOAEPDecrypterOpts(opts)
+		if err != nil {
+			return nil, err
+		}
+		keyOp = op
+		switch oaepOpts.Hash {
+		case crypto.SHA256:
+			params.Algorithm = to.Ptr(azkeys.EncryptionAlgorithmRSAOAEP256)
+		case crypto.SHA1:
+			params.Algorithm = to.Ptr(azkeys.EncryptionAlgorithmRSAOAEP)
+		default:
+			return nil, fmt.Errorf("%w: %s", i.ErrInvalidAlgorithm, oaepOpts.Hash)
 		}
 	default:
 		return nil, fmt.Errorf("%w: %s", i.ErrInvalidKeyType, ck.keyType)
 	}
+	if keyOp == i.JsonWebKeyOperationDecrypt {
+		resp, err := ck.client.Decrypt(ck.c, ck.kid.Name(), ck.kid.Version(), params, nil)
+		if err != nil {
+			return nil, err
+		}
+		return resp.Result, nil
+	}
 	resp, err := ck.client.UnwrapKey(ck.c, ck.kid.Name(), ck.kid.Version(), params, nil)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,248 @@
+package cloudkeyaz
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azkeys"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azsecrets"
+	i "github.com/stephenzsy/small-kms/backend/cloud/key"
+)
+
+// Key Vault keys cannot be oct outside of Managed HSM, oct keys are kept as secrets holding the JWK
+const octKeySecretContentType = "application/jwk+json"
+
+type azKeyStore struct {
+	client        *azkeys.Client
+	secretsClient *azsecrets.Client
+}
+
+// Kind implements cloudkey.KeyStore.
+func (*azKeyStore) Kind() i.KeyStoreKind {
+	return i.KeyStoreKindAzKeyVault
+}
+
+// HasKeyID implements cloudkey.KeyStore.
+func (*azKeyStore) HasKeyID(kid string) bool {
+	return strings.HasPrefix(kid, "https://")
+}
+
+func toAzCreateKeyParams(params i.CreateKeyParams) (azParams azkeys.CreateKeyParameters, err error) {
+	switch params.KeyType {
+	case i.KeyTypeEC:
+		azParams.Kty = to.Ptr(azkeys.KeyTypeEC)
+		switch params.Curve {
+		case i.CurveNameP256:
+			azParams.Curve = to.Ptr(azkeys.CurveNameP256)
+		case i.CurveNameP384:
+			azParams.Curve = to.Ptr(azkeys.CurveNameP384)
+		case i.CurveNameP521:
+			azParams.Curve = to.Ptr(azkeys.CurveNameP521)
+		default:
+			return azParams, i.ErrInvalidCurve
+		}
+	case i.KeyTypeRSA:
+		azParams.Kty = to.Ptr(azkeys.KeyTypeRSA)
+		switch params.KeySize {
+		case 2048, 3072, 4096:
+			azParams.KeySize = to.Ptr(int32(params.KeySize))
+		}
+	default:
+		return azParams, i.ErrInvalidKeyType
+	}
+	azParams.KeyOps = make([]*azkeys.KeyOperation, len(params.KeyOperations))
+	for j, keyOp := range params.KeyOperations {
+		azParams.KeyOps[j] = to.Ptr(azkeys.KeyOperation(keyOp))
+	}
+	azParams.KeyAttributes = &azkeys.KeyAttributes{
+		Exportable: params.Exportable,
+		NotBefore:  params.NotBefore,
+		Expires:    params.Expires,
+		Enabled:    to.Ptr(true),
+	}
+	return azParams, nil
+}
+
+// CreateKey implements cloudkey.KeyStore.
+func (s *azKeyStore) CreateKey(c context.Context, name string, params i.CreateKeyParams) (*i.CreateKeyResult, error) {
+	if params.KeyType == i.KeyTypeOct {
+		return s.createSecretKey(c, name, params)
+	}
+	azParams, err := toAzCreateKeyParams(params)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.CreateKey(c, name, azParams, nil)
+	if err != nil {
+		return nil, err
+	}
+	result := &i.CreateKeyResult{
+		JsonWebKey: *newSigningJWKFromKeyVaultKey(resp.Key),
+	}
+	if resp.Attributes != nil {
+		if resp.Attributes.Created != nil {
+			result.Created = *resp.Attributes.Created
+		}
+		result.NotBefore = resp.Attributes.NotBefore
+		result.Expires = resp.Attributes.Expires
+		result.Exportable = resp.Attributes.Exportable
+	}
+	return result, nil
+}
+
+func (s *azKeyStore) createSecretKey(c context.Context, name string, params i.CreateKeyParams) (*i.CreateKeyResult, error) {
+	if s.secretsClient == nil {
+		return nil, fmt.Errorf("%w: secrets client is not configured", i.ErrSymmetricKeyNotSupported)
+	}
+	keySize := params.KeySize
+	switch keySize {
+	case 0:
+		keySize = 256
+	case 256, 384, 512:
+	default:
+		return nil, i.ErrInvalidKeySize
+	}
+	jwk := &i.JsonWebKey{
+		KeyType:       i.KeyTypeOct,
+		K:             make([]byte, keySize/8),
+		KeyOperations: i.SanitizeKeyOperations(params.KeyOperations),
+	}
+	if _, err := rand.Read(jwk.K); err != nil {
+		return nil, err
+	}
+	value, err := json.Marshal(jwk)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.secretsClient.SetSecret(c, name, azsecrets.SetSecretParameters{
+		Value:       to.Ptr(string(value)),
+		ContentType: to.Ptr(octKeySecretContentType),
+		SecretAttributes: &azsecrets.SecretAttributes{
+			Enabled:   to.Ptr(true),
+			NotBefore: params.NotBefore,
+			Expires:   params.Expires,
+		},
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	result := &i.CreateKeyResult{
+		JsonWebKey: i.JsonWebKey{
+			KeyType: i.KeyTypeOct,
+			KeyID:   string(*resp.ID),
+		},
+		Exportable: params.Exportable,
+	}
+	if resp.Attributes != nil {
+		if resp.Attributes.Created != nil {
+			result.Created = *resp.Attributes.Created
+		}
+		result.NotBefore = resp.Attributes.NotBefore
+		result.Expires = resp.Attributes.Expires
+	}
+	return result, nil
+}
+
+// GetSymmetricKey implements cloudkey.SymmetricKeyStore.
+func (s *azKeyStore) GetSymmetricKey(c context.Context, kid string, op i.JsonWebKeyOperation) ([]byte, error) {
+	if s.secretsClient == nil {
+		return nil, fmt.Errorf("%w: secrets client is not configured", i.ErrSymmetricKeyNotSupported)
+	}
+	sid := azsecrets.ID(kid)
+	resp, err := s.secretsClient.GetSecret(c, sid.Name(), sid.Version(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if resp.ContentType == nil || *resp.ContentType != octKeySecretContentType || resp.Value == nil {
+		return nil, fmt.Errorf("%w: secret is not an oct key: %s", i.ErrInvalidKey, kid)
+	}
+	jwk := &i.JsonWebKey{}
+	if err := json.Unmarshal([]byte(*resp.Value), jwk); err != nil {
+		return nil, err
+	}
+	if jwk.KeyType != i.KeyTypeOct || len(jwk.K) == 0 {
+		return nil, fmt.Errorf("%w: secret is not an oct key: %s", i.ErrInvalidKey, kid)
+	}
+	if len(jwk.KeyOperations) > 0 && !slices.Contains(jwk.KeyOperations, op) {
+		return nil, fmt.Errorf("%w: key does not allow %s", i.ErrInvalidKey, op)
+	}
+	return jwk.K, nil
+}
+
+// ImportKey implements cloudkey.KeyImportStore.
+func (s *azKeyStore) ImportKey(c context.Context, name string, privateJwk *i.JsonWebKey, params i.CreateKeyParams) (*i.CreateKeyResult, error) {
+	if _, err := i.ParsePrivateJWK(privateJwk); err != nil {
+		return nil, err
+	}
+	params.KeyType = privateJwk.KeyType
+	params.Curve = privateJwk.Curve
+	params.Exportable = to.Ptr(false)
+	azParams, err := toAzCreateKeyParams(params)
+	if err != nil {
+		return nil, err
+	}
+	azJwk := &azkeys.JSONWebKey{
+		Kty:    azParams.Kty,
+		Crv:    azParams.Curve,
+		KeyOps: azParams.KeyOps,
+	}
+	switch privateJwk.KeyType {
+	case i.KeyTypeRSA:
+		azJwk.N = privateJwk.N
+		azJwk.E = privateJwk.E
+		azJwk.D = privateJwk.D
+		azJwk.P = privateJwk.P
+		azJwk.Q = privateJwk.Q
+		azJwk.DP = privateJwk.Dp
+		azJwk.DQ = privateJwk.Dq
+		azJwk.QI = privateJwk.Qinv
+	case i.KeyTypeEC:
+		azJwk.X = privateJwk.X
+		azJwk.Y = privateJwk.Y
+		azJwk.D = privateJwk.D
+	}
+	resp, err := s.client.ImportKey(c, name, azkeys.ImportKeyParameters{
+		Key:           azJwk,
+		KeyAttributes: azParams.KeyAttributes,
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	result := &i.CreateKeyResult{
+		JsonWebKey: *newSigningJWKFromKeyVaultKey(resp.Key),
+	}
+	if resp.Attributes != nil {
+		if resp.Attributes.Created != nil {
+			result.Created = *resp.Attributes.Created
+		}
+		result.NotBefore = resp.Attributes.NotBefore
+		result.Expires = resp.Attributes.Expires
+		result.Exportable = resp.Attributes.Exportable
+	}
+	return result, nil
+}
+
+// NewSignatureKey implements cloudkey.KeyStore.
+func (s *azKeyStore) NewSignatureKey(c context.Context, kid string, jwsa i.JsonWebSignatureAlgorithm, formatX509 bool, publicKey crypto.PublicKey) i.CloudSignatureKey {
+	return NewAzCloudSignatureKeyWithKID(c, s.client, kid, jwsa, formatX509, publicKey)
+}
+
+// NewWrappingKey implements cloudkey.KeyStore.
+func (s *azKeyStore) NewWrappingKey(c context.Context, kid string, keyType i.JsonWebKeyType) i.CloudWrappingKey {
+	return NewCloudWrappingKeyWithKID(c, s.client, kid, keyType)
+}
+
+var _ i.KeyStore = (*azKeyStore)(nil)
+var _ i.SymmetricKeyStore = (*azKeyStore)(nil)
+var _ i.KeyImportStore = (*azKeyStore)(nil)
+
+// NewKeyStore keeps asymmetric keys as Key Vault keys, and oct keys as secrets if secretsClient is not nil
+func NewKeyStore(client *azkeys.Client, secretsClient *azsecrets.Client) i.KeyStore {
+	return &azKeyStore{client: client, secretsClient: secretsClient}
+}
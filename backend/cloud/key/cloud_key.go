@@ -1,6 +1,9 @@
 package cloudkey
 
-import "crypto"
+import (
+	"crypto"
+	"crypto/ecdh"
+)
 
 // RFC7518 3.1.  "alg" (Algorithm) Header Parameter Values for JWS
 
@@ -19,3 +22,10 @@ type CloudWrappingKey interface {
 	crypto.Decrypter
 	KeyID() string
 }
+
+// CloudKeyAgreementKey computes ECDH shared secrets with an EC key kept in the key store
+type CloudKeyAgreementKey interface {
+	CloudKey
+	KeyID() string
+	ECDH(remote *ecdh.PublicKey) ([]byte, error)
+}
@@ -6,6 +6,7 @@ import (
 	"crypto/cipher"
 	"crypto/ecdh"
 	"crypto/ecdsa"
+	"crypto/mlkem"
 	"crypto/rsa"
 	"crypto/sha256"
 	"encoding/base64"
@@ -23,12 +24,26 @@ var (
 type JsonWebKeyEncryptionAlgorithm string
 
 const (
+	JwkEncAlgRsaOeap    JsonWebKeyEncryptionAlgorithm = "RSA-OAEP"
 	JwkEncAlgRsaOeap256 JsonWebKeyEncryptionAlgorithm = "RSA-OAEP-256"
 	JwkEncAlgAes256Gcm  JsonWebKeyEncryptionAlgorithm = "A256GCM"
 	JwkEncAlgEcdhEs     JsonWebKeyEncryptionAlgorithm = "ECDH-ES"
 	JwkEncAlgDir        JsonWebKeyEncryptionAlgorithm = "dir"
+
+	// hybrid of ECDH-ES and ML-KEM-768, the KEM ciphertext is carried as the JWE encrypted key,
+	// see newHybridKDF for the key derivation
+	JwkEncAlgEcdhEsMlKem768 JsonWebKeyEncryptionAlgorithm = "ECDH-ES+ML-KEM-768"
 )
 
+// HybridKeyAgreementPrivateKey is the private key to decrypt ECDH-ES+ML-KEM-768 JWEs
+type HybridKeyAgreementPrivateKey struct {
+	// *ecdh.PrivateKey or a CloudKeyAgreementKey
+	ECDH interface {
+		ECDH(remote *ecdh.PublicKey) ([]byte, error)
+	}
+	KEM *mlkem.DecapsulationKey768
+}
+
 type JoseHeader struct {
 	Algorithm           JsonWebKeyEncryptionAlgorithm `json:"alg,omitempty"`
 	EncryptionAlgorithm JsonWebKeyEncryptionAlgorithm `json:"enc"`
@@ -45,6 +60,16 @@ type JsonWebEncryption struct {
 	InitializationVector Base64RawURLEncodableBytes
 	Ciphertext           Base64RawURLEncodableBytes
 	AuthenticationTag    Base64RawURLEncodableBytes
+	// not part of the compact serialization, the same data must be supplied to decrypt
+	AdditionalAuthenticatedData Base64RawURLEncodableBytes
+}
+
+// RFC7516 5.1 step 14, the protected header and the additional authenticated data joined by '.'
+func (jwe *JsonWebEncryption) aad() []byte {
+	if len(jwe.AdditionalAuthenticatedData) == 0 {
+		return []byte(jwe.Protected.Raw)
+	}
+	return []byte(jwe.Protected.Raw + "." + base64.RawURLEncoding.EncodeToString(jwe.AdditionalAuthenticatedData))
 }
 
 func NewJsonWebEncryption(text string) (*JsonWebEncryption, error) {
@@ -125,7 +150,7 @@ func (jwe *JsonWebEncryption) Decrypt(keyFunc func(header *JoseHeader) (crypto.P
 		ciphertext := make([]byte, len(jwe.Ciphertext)+len(jwe.AuthenticationTag))
 		copy(ciphertext, jwe.Ciphertext)
 		copy(ciphertext[len(jwe.Ciphertext):], jwe.AuthenticationTag)
-		plaintext, err = gcm.Open(plaintext, jwe.InitializationVector, ciphertext, []byte(jwe.Protected.Raw))
+		plaintext, err = gcm.Open(plaintext, jwe.InitializationVector, ciphertext, jwe.aad())
 		return plaintext, unwrappedKey, err
 	default:
 		return plaintext, unwrappedKey, fmt.Errorf("unsupported algorithm: %s", jwe.Protected.EncryptionAlgorithm)
@@ -140,6 +165,12 @@ func (jwe *JsonWebEncryption) unwrapKey(keyFunc func(header *JoseHeader) (crypto
 		return nil, err
 	}
 	switch jwe.Protected.Algorithm {
+	case JwkEncAlgDir:
+		if key, ok := privateKey.([]byte); !ok {
+			return nil, fmt.Errorf("incompatable key, key should be the content encryption key")
+		} else {
+			return key, nil
+		}
 	case JwkEncAlgRsaOeap256:
 		if privateKey, ok := privateKey.(crypto.Decrypter); !ok {
 			return nil, fmt.Errorf("incompatable key")
@@ -152,11 +183,14 @@ func (jwe *JsonWebEncryption) unwrapKey(keyFunc func(header *JoseHeader) (crypto
 		if jwe.Protected.EncryptionAlgorithm != JwkEncAlgAes256Gcm {
 			return nil, fmt.Errorf("incompatable enc")
 		}
-		if privateKey, ok := privateKey.(*ecdh.PrivateKey); !ok {
+		// *ecdh.PrivateKey or a CloudKeyAgreementKey
+		if privateKey, ok := privateKey.(interface {
+			ECDH(remote *ecdh.PublicKey) ([]byte, error)
+		}); !ok {
 			return nil, fmt.Errorf("incompatable key")
-		} else if epk, ok := jwe.Protected.EphemeralPublicKey.PublicKey().(*ecdsa.PublicKey); !ok {
-			return nil, fmt.Errorf("incompatable key, key should be ecdsa")
-		} else if ecdhPubKey, err := epk.ECDH(); err != nil {
+		} else if jwe.Protected.EphemeralPublicKey == nil {
+			return nil, fmt.Errorf("missing epk")
+		} else if ecdhPubKey, err := toECDHPublicKey(jwe.Protected.EphemeralPublicKey.PublicKey()); err != nil {
 			return nil, err
 		} else if z, err := privateKey.ECDH(ecdhPubKey); err != nil {
 			return nil, err
@@ -169,17 +203,89 @@ func (jwe *JsonWebEncryption) unwrapKey(keyFunc func(header *JoseHeader) (crypto
 			}
 			return kdf.getAESGCM256DerivedKey(), nil
 		}
+	case JwkEncAlgEcdhEsMlKem768:
+		if jwe.Protected.EncryptionAlgorithm != JwkEncAlgAes256Gcm {
+			return nil, fmt.Errorf("incompatable enc")
+		}
+		if privateKey, ok := privateKey.(*HybridKeyAgreementPrivateKey); !ok || privateKey.ECDH == nil || privateKey.KEM == nil {
+			return nil, fmt.Errorf("incompatable key")
+		} else if jwe.Protected.EphemeralPublicKey == nil {
+			return nil, fmt.Errorf("missing epk")
+		} else if ecdhPubKey, err := toECDHPublicKey(jwe.Protected.EphemeralPublicKey.PublicKey()); err != nil {
+			return nil, err
+		} else if z, err := privateKey.ECDH.ECDH(ecdhPubKey); err != nil {
+			return nil, err
+		} else if k, err := privateKey.KEM.Decapsulate(jwe.EncryptedKey); err != nil {
+			return nil, err
+		} else {
+			return newHybridKDF(jwe.Protected.Algorithm, z, k, jwe.EncryptedKey,
+				jwe.Protected.AgreementPartyUInfo, jwe.Protected.AgreementPartyVInfo).getAESGCM256DerivedKey(), nil
+		}
 	default:
 		return nil, fmt.Errorf("unsupported algorithm: %s", jwe.Protected.Algorithm)
 	}
 
 }
 
+// toECDHPublicKey accepts EC keys of the NIST curves and OKP keys of X25519
+func toECDHPublicKey(publicKey crypto.PublicKey) (*ecdh.PublicKey, error) {
+	switch publicKey := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		return publicKey.ECDH()
+	case *ecdh.PublicKey:
+		return publicKey, nil
+	}
+	return nil, fmt.Errorf("%w: incompatable key for ECDH, %T", ErrInvalidKeyType, publicKey)
+}
+
+func toECDHPrivateKey(privateKey crypto.PrivateKey) (*ecdh.PrivateKey, error) {
+	switch privateKey := privateKey.(type) {
+	case *ecdsa.PrivateKey:
+		return privateKey.ECDH()
+	case *ecdh.PrivateKey:
+		return privateKey, nil
+	}
+	return nil, fmt.Errorf("%w: incompatable key for ECDH, %T", ErrInvalidKeyType, privateKey)
+}
+
 type ecdhesKDF struct {
 	z   []byte
 	alg string
 	apu []byte
 	apv []byte
+	// NIST SP 800-56A SuppPrivInfo, empty for ECDH-ES
+	suppPrivInfo []byte
+}
+
+// newHybridKDF derives the content encryption key of ECDH-ES+ML-KEM-768 with the Concat KDF of RFC 7518 4.6.2
+// (NIST SP 800-56C Rev. 2 4.1 one-step KDF with SHA-256), over the hybrid shared secret Z' = Z || K of
+// SP 800-56C Rev. 2 2, where Z is the full ECDH shared secret and K is the 32 byte ML-KEM-768 shared key.
+// The alg and the KEM ciphertext are bound to the derived key through SuppPrivInfo:
+//
+//	CEK = SHA-256(00000001 || Z || K ||
+//		len("A256GCM") || "A256GCM" || len(apu) || apu || len(apv) || apv ||
+//		00000100 ||
+//		len(alg) || alg || len(ct) || ct)
+//
+// where every len is a 32-bit big-endian byte count, alg is "ECDH-ES+ML-KEM-768" and ct is the 1088 byte KEM
+// ciphertext carried as the JWE encrypted key. The alg is not registered with IANA, it only interoperates with this
+// construction.
+func newHybridKDF(alg JsonWebKeyEncryptionAlgorithm, z, k, ciphertext, apu, apv []byte) *ecdhesKDF {
+	combined := make([]byte, 0, len(z)+len(k))
+	combined = append(combined, z...)
+	combined = append(combined, k...)
+	suppPrivInfo := make([]byte, 0, 8+len(alg)+len(ciphertext))
+	suppPrivInfo = append(suppPrivInfo, uint32ToBytes(uint32(len(alg)))...)
+	suppPrivInfo = append(suppPrivInfo, alg...)
+	suppPrivInfo = append(suppPrivInfo, uint32ToBytes(uint32(len(ciphertext)))...)
+	suppPrivInfo = append(suppPrivInfo, ciphertext...)
+	return &ecdhesKDF{
+		z:            combined,
+		alg:          string(JwkEncAlgAes256Gcm),
+		apu:          apu,
+		apv:          apv,
+		suppPrivInfo: suppPrivInfo,
+	}
 }
 
 func uint32ToBytes(v uint32) []byte {
@@ -199,5 +305,6 @@ func (kdf *ecdhesKDF) getAESGCM256DerivedKey() []byte {
 	d.Write(uint32ToBytes(uint32(len(kdf.apv))))
 	d.Write(kdf.apv)
 	d.Write(uint32ToBytes(256))
+	d.Write(kdf.suppPrivInfo)
 	return d.Sum(nil)
 }
@@ -3,8 +3,10 @@ package cloudkey
 import (
 	"crypto/aes"
 	"crypto/cipher"
-	"crypto/ecdsa"
+	"crypto/mlkem"
 	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
@@ -20,11 +22,11 @@ func (b *JWEAes256GcmEncBuilder) SetEcdhEsKeyAgreement(selfJWK *JsonWebKey, remo
 	b.Protected.Algorithm = JwkEncAlgEcdhEs
 	b.Protected.EncryptionAlgorithm = JwkEncAlgAes256Gcm
 
-	selfEcdhKey, err := selfJWK.PrivateKey().(*ecdsa.PrivateKey).ECDH()
+	selfEcdhKey, err := toECDHPrivateKey(selfJWK.PrivateKey())
 	if err != nil {
 		return err
 	}
-	remotePublicKey, err := remoteJWK.PublicKey().(*ecdsa.PublicKey).ECDH()
+	remotePublicKey, err := toECDHPublicKey(remoteJWK.PublicKey())
 	if err != nil {
 		return err
 	}
@@ -43,12 +45,60 @@ func (b *JWEAes256GcmEncBuilder) SetEcdhEsKeyAgreement(selfJWK *JsonWebKey, remo
 	return nil
 }
 
+// SetHybridKeyAgreement combines ECDH-ES with the remote key and ML-KEM-768 encapsulation to the remote KEM key,
+// the content stays protected as long as either one of them is not broken
+func (b *JWEAes256GcmEncBuilder) SetHybridKeyAgreement(selfJWK *JsonWebKey, remoteJWK *JsonWebKey, remoteKemJWK *JsonWebKey) error {
+	b.Protected.EphemeralPublicKey = selfJWK.PublicJWK()
+	b.Protected.Algorithm = JwkEncAlgEcdhEsMlKem768
+	b.Protected.EncryptionAlgorithm = JwkEncAlgAes256Gcm
+
+	selfEcdhKey, err := toECDHPrivateKey(selfJWK.PrivateKey())
+	if err != nil {
+		return err
+	}
+	remotePublicKey, err := toECDHPublicKey(remoteJWK.PublicKey())
+	if err != nil {
+		return err
+	}
+	remoteKemKey, ok := remoteKemJWK.PublicKey().(*mlkem.EncapsulationKey768)
+	if !ok {
+		return fmt.Errorf("%w: ML-KEM-768 key required", ErrInvalidKeyType)
+	}
+
+	z, err := selfEcdhKey.ECDH(remotePublicKey)
+	if err != nil {
+		return err
+	}
+	k, ciphertext := remoteKemKey.Encapsulate()
+	b.EncryptedKey = ciphertext
+	b.encKey = newHybridKDF(b.Protected.Algorithm, z, k, ciphertext,
+		b.Protected.AgreementPartyUInfo, b.Protected.AgreementPartyVInfo).getAESGCM256DerivedKey()
+	return nil
+}
+
 func (b *JWEAes256GcmEncBuilder) SetDirectEncryptionKey(key []byte) {
 	b.Protected.Algorithm = JwkEncAlgDir
 	b.Protected.EncryptionAlgorithm = JwkEncAlgAes256Gcm
 	b.encKey = key
 }
 
+// SetRsaOaep256KeyWrap generates a random content encryption key and wraps it with the RSA public key
+func (b *JWEAes256GcmEncBuilder) SetRsaOaep256KeyWrap(publicKey *rsa.PublicKey) error {
+	cek := make([]byte, 32)
+	if _, err := rand.Read(cek); err != nil {
+		return err
+	}
+	encryptedKey, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, publicKey, cek, nil)
+	if err != nil {
+		return err
+	}
+	b.Protected.Algorithm = JwkEncAlgRsaOeap256
+	b.Protected.EncryptionAlgorithm = JwkEncAlgAes256Gcm
+	b.EncryptedKey = encryptedKey
+	b.encKey = cek
+	return nil
+}
+
 func (b *JWEAes256GcmEncBuilder) Seal(plaintext []byte) (string, error) {
 	if len(b.encKey) == 0 {
 		return "", fmt.Errorf("encryption key not set")
@@ -70,7 +120,7 @@ func (b *JWEAes256GcmEncBuilder) Seal(plaintext []byte) (string, error) {
 	} else {
 		b.Protected.Raw = base64.RawURLEncoding.EncodeToString(headerJson)
 	}
-	encrypted := gcm.Seal(nil, iv, plaintext, []byte(b.Protected.Raw))
+	encrypted := gcm.Seal(nil, iv, plaintext, b.aad())
 	ciphertext := encrypted[:len(encrypted)-ci.BlockSize()]
 	tag := encrypted[len(encrypted)-ci.BlockSize():]
 	b.InitializationVector = iv
@@ -2,8 +2,13 @@ package cloudkey
 
 import (
 	"crypto"
+	"crypto/ecdh"
 	"crypto/ecdsa"
+	"crypto/mlkem"
+	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"strings"
 	"testing"
@@ -89,3 +94,134 @@ func TestJsonWebEncryption_KDF(t *testing.T) {
 	}
 	assert.DeepEqual(t, expetedSharedSecret, kdf.getAESGCM256DerivedKey())
 }
+
+func TestJsonWebEncryption_HybridKeyAgreement(t *testing.T) {
+	x25519Key, err := ecdh.X25519().GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	remoteJwk, err := NewJsonWebKeyFromPublicKey(x25519Key.PublicKey())
+	require.NoError(t, err)
+	kemJwk, err := NewEphemeralMLKEM768Jwk()
+	require.NoError(t, err)
+
+	// the requester sends public keys only
+	remoteKemJwk := &JsonWebKey{}
+	encoded, err := json.Marshal(kemJwk.PublicJWK())
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(encoded, remoteKemJwk))
+	assert.Assert(t, len(remoteKemJwk.Priv) == 0)
+
+	selfJwk, err := NewEphemeralKeyAgreementJwk(nil, remoteJwk)
+	require.NoError(t, err)
+	assert.Equal(t, CurveNameX25519, selfJwk.Curve)
+
+	builder := &JWEAes256GcmEncBuilder{}
+	require.NoError(t, builder.SetHybridKeyAgreement(selfJwk, remoteJwk, remoteKemJwk))
+	sealed, err := builder.Seal([]byte("secret"))
+	require.NoError(t, err)
+
+	jwe, err := NewJsonWebEncryption(sealed)
+	require.NoError(t, err)
+	assert.Equal(t, JwkEncAlgEcdhEsMlKem768, jwe.Protected.Algorithm)
+	plaintext, _, err := jwe.Decrypt(func(*JoseHeader) (crypto.PrivateKey, error) {
+		return &HybridKeyAgreementPrivateKey{
+			ECDH: x25519Key,
+			KEM:  kemJwk.PrivateKey().(*mlkem.DecapsulationKey768),
+		}, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "secret", string(plaintext))
+
+	// both key agreements are required
+	_, _, err = jwe.Decrypt(func(*JoseHeader) (crypto.PrivateKey, error) {
+		return x25519Key, nil
+	})
+	assert.Assert(t, err != nil)
+	otherKemJwk, err := NewEphemeralMLKEM768Jwk()
+	require.NoError(t, err)
+	_, _, err = jwe.Decrypt(func(*JoseHeader) (crypto.PrivateKey, error) {
+		return &HybridKeyAgreementPrivateKey{
+			ECDH: x25519Key,
+			KEM:  otherKemJwk.PrivateKey().(*mlkem.DecapsulationKey768),
+		}, nil
+	})
+	assert.Assert(t, err != nil)
+}
+
+func mustDecodeHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// a fixed ECDH-ES+ML-KEM-768 JWE to the X25519 private key of RFC 7748 6.1 and the ML-KEM-768 key of the seed 00..3f
+func TestJsonWebEncryption_HybridKeyAgreementKnownAnswer(t *testing.T) {
+	x25519Key, err := ecdh.X25519().NewPrivateKey(mustDecodeHex("77076d0a7318a57d3c16c17251b26645df4c2f87ebc0992ab177fba51db92c2a"))
+	require.NoError(t, err)
+	seed := make([]byte, mlkem.SeedSize)
+	for i := range seed {
+		seed[i] = byte(i)
+	}
+	kemKey, err := mlkem.NewDecapsulationKey768(seed)
+	require.NoError(t, err)
+
+	jweString := strings.Join([]string{
+		"eyJhbGciOiJFQ0RILUVTK01MLUtFTS03NjgiLCJlbmMiOiJBMjU2R0NNIiwiZXBrIjp7Imt0eSI6Ik9LUCIsImNydiI6IlgyNTUxOSIsIngiOiIzcDdiZlh0OXdiVFRXMkhDN09RMU56LURROGhiZUdkTnJmeC1GRy1JSzA4In0sImFwdSI6ImMyMWhiR3d0YTIxeiIsImFwdiI6IllXZGxiblEifQ",
+		// the ML-KEM-768 ciphertext
+		"2kPXkwy-yU_nlUGTLFEbrfiFgn33gN4XRl4j5tG4N7G2w8tTczEIZYxrK2EHa0Yt9CLXPYf5GE8DO7av6fpxD3fZX9vpzi2P" +
+			"rr6ryKKomaMF8e2RIN0nlnBrtkjL5aQ34L9GQXrm86lIo2bgHsXO5qRK-Chg6kUvKb_M34Cu__lHVDwTt6c2ZB6S0qNDlu0D" +
+			"JjTDvOYI8PkQAqozp7nuUolXzCm1IMsP6OMpvwMyXJxq-SFQK3uz869uG79PEM6OBJOL6vuNDW6CNrjUvytBk_vj7HIAyEgc" +
+			"YW2B8FEvBQpZYKnr4DKyQ-Ti8Mq2bdE20PNmMKJh775rNq6-6ypb1x7uTcDjD8w6KcjNRmBmfvMrBvti8NiuMGvefIANhZFs" +
+			"PIW21B8ONaZbvK-UqsViZGW1Fnbxma2TzFHNr12hReFb33vu0eixAva-xtOcPJGribGj6lNjI9-75ncKLz2WEjgvS-3M_UhV" +
+			"OKr_-xitJHBf4bNuxSV6utxazcT1RuLypo6yXCpTuo_VUJtJ8zbRJcciEaYCQ37l8b8-mtzhH09PgZakvGYlv_AJL80DkTEd" +
+			"ct3D1P1s51avK_BmBMeS6MuWIjnIX1-AigpePUBlQ62OqSPJGqxE9UlMUkS64Rf8C9s1r09e7X3pTfeGmfM23tIRWPJaywCB" +
+			"7IXYQVFc6hQZ-sQzd34sJeuzBtnNJOyIYOBfPvnEv95-jquTUn0bvUkcDaLQ31sn48WE1N6ozCnAmbbDsHubw9jz48mhlH7f" +
+			"O7XV8bPPqgcpdfBToqkUImsGv7r-jLSIeas0r9-fpgYKuQfbOGeF6zIYWxuWlMM-wxNSixnm5pJdVzMZ-1MlusxlCA1ywTW2" +
+			"mb5ntkZRUqHcoW40IGTnBhcfGw6lp3ne6MhBELeNs6o7r00iHvs6v6n55ahxKQamT1f-etjogRPwAaY693uJv5oxflpOqqjD" +
+			"_bihFfWIro_Dh3UBOivuMz47NXTgraPHA4NadmcR-sKhKTqN0losGZZqfkyV-gWsG0V7iahO0Mo_uSEheY8-BiifGnzpSQAv" +
+			"hdrwJkqx0Ne5f5aV6OmtlAXpLHmAlk3xwCx6bYPRC6WuMLiDHhttN2tCLlDE3c_xLK57q1t_RbmqI_zp0Wthy446sNWP4qk5" +
+			"VymNMhgFx-P7e5N3yiTd97mk5MWLgerLj1PfP5IH-CoAEK_ndGQYwrP8ZZUM3o9o3FqTEbWUbtNP6gM44nDbXbWlXJdkEX5U" +
+			"jWhCwTtWmIjHop-6Tl6lZyBHPgA1faLKAUn5hex6qAN1zmiBzdVI4JO9abB5CzcJC0ijmh9Blpe__X6DOl1u9rqYlz3cRojJ" +
+			"DvpumXadunhSOs5pgRK8TkxfrtauiY2UpadiVa4fY6IQbmjReJGtj-IEy8m49HdRGJhCpFo4sl9Saca1epY-yWfcvCfUbgys" +
+			"3EjApyw6QNc",
+		"RSZ1HlwG29Wehrf1",
+		"zLVQJCJgW0ymQTj3qnatyC_izw",
+		"yz4UtN-UOEaO_GXvaHimJQ",
+	}, ".")
+	expectedCEK := mustDecodeHex("4e1f6d7642b01eaf8def2ffc7d9715c13f593657574cc902cf01e47db3e8720a")
+
+	jwe, err := NewJsonWebEncryption(jweString)
+	require.NoError(t, err)
+	assert.Equal(t, JwkEncAlgEcdhEsMlKem768, jwe.Protected.Algorithm)
+	assert.Equal(t, "small-kms", string(jwe.Protected.AgreementPartyUInfo))
+	assert.Equal(t, "agent", string(jwe.Protected.AgreementPartyVInfo))
+	assert.Equal(t, mlkem.CiphertextSize768, len(jwe.EncryptedKey))
+	plaintext, cek, err := jwe.Decrypt(func(*JoseHeader) (crypto.PrivateKey, error) {
+		return &HybridKeyAgreementPrivateKey{ECDH: x25519Key, KEM: kemKey}, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "hybrid known answer", string(plaintext))
+	assert.DeepEqual(t, expectedCEK, cek)
+
+	// the construction documented on newHybridKDF
+	epk, err := toECDHPublicKey(jwe.Protected.EphemeralPublicKey.PublicKey())
+	require.NoError(t, err)
+	z, err := x25519Key.ECDH(epk)
+	require.NoError(t, err)
+	k, err := kemKey.Decapsulate(jwe.EncryptedKey)
+	require.NoError(t, err)
+	d := sha256.New()
+	for _, b := range [][]byte{
+		{0, 0, 0, 1}, z, k,
+		{0, 0, 0, 7}, []byte("A256GCM"),
+		{0, 0, 0, 9}, []byte("small-kms"),
+		{0, 0, 0, 5}, []byte("agent"),
+		{0, 0, 1, 0},
+		{0, 0, 0, 18}, []byte("ECDH-ES+ML-KEM-768"),
+		{0, 0, 4, 64}, jwe.EncryptedKey,
+	} {
+		d.Write(b)
+	}
+	assert.DeepEqual(t, expectedCEK, d.Sum(nil))
+}
@@ -2,9 +2,14 @@ package cloudkey
 
 import (
 	"crypto"
+	"crypto/ecdh"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/elliptic"
+	"crypto/mlkem"
+	"crypto/rand"
 	"crypto/rsa"
+	"fmt"
 	"io"
 	"math/big"
 
@@ -18,14 +23,23 @@ const (
 	KeyTypeRSA JsonWebKeyType = "RSA"
 	KeyTypeEC  JsonWebKeyType = "EC"
 	KeyTypeOct JsonWebKeyType = "oct"
+	KeyTypeOKP JsonWebKeyType = "OKP" // RFC8037 2. Key Type "OKP"
+	KeyTypeAKP JsonWebKeyType = "AKP" // draft-ietf-jose-pqc-kem, Algorithm Key Pair of post-quantum algorithms
 )
 
+// key algorithm of "AKP" keys
+const KeyAlgMLKEM768 = "ML-KEM-768"
+
 type JsonWebKeyCurveName string
 
 const (
 	CurveNameP256 JsonWebKeyCurveName = "P-256"
 	CurveNameP384 JsonWebKeyCurveName = "P-384"
 	CurveNameP521 JsonWebKeyCurveName = "P-521"
+
+	// RFC8037 3. curves of the "OKP" key type
+	CurveNameEd25519 JsonWebKeyCurveName = "Ed25519"
+	CurveNameX25519  JsonWebKeyCurveName = "X25519"
 )
 
 // RFC7517 4.3. "key_ops" (Key Operations) Parameter Values for JWK
@@ -57,6 +71,9 @@ type JsonWebKey struct {
 	Qinv             Base64RawURLEncodableBytes   `json:"qi,omitempty"`       // RFC7518 6.3.3.6. "qi" (First CRT Coefficient) Parameter
 	X                Base64RawURLEncodableBytes   `json:"x,omitempty"`        // RFC7518 6.2.1.2. "x" (X Coordinate) Parameter
 	Y                Base64RawURLEncodableBytes   `json:"y,omitempty"`        // RFC7518 6.2.1.3. "y" (Y Coordinate) Parameter
+	K                Base64RawURLEncodableBytes   `json:"k,omitempty"`        // RFC7518 6.4.1. "k" (Key Value) Parameter
+	Pub              Base64RawURLEncodableBytes   `json:"pub,omitempty"`      // "pub" (Public Key) Parameter of "AKP" keys, the ML-KEM encapsulation key
+	Priv             Base64RawURLEncodableBytes   `json:"priv,omitempty"`     // "priv" (Private Key) Parameter of "AKP" keys, the ML-KEM decapsulation key seed
 	KeyOperations    []JsonWebKeyOperation        `json:"key_ops,omitempty"`  // RFC7517 4.3. "key_ops" (Key Operations) Parameter Values for JWK
 	ThumbprintSHA1   Base64RawURLEncodableBytes   `json:"x5t,omitempty"`      // RFC7517 4.8. "x5t" (X.509 Certificate SHA-1 Thumbprint) Parameter
 	ThumbprintSHA256 Base64RawURLEncodableBytes   `json:"x5t#S256,omitempty"` // RFC7517 4.9. "x5t#S256" (X.509 Certificate SHA-256 Thumbprint) Parameter
@@ -74,6 +91,7 @@ func (jwk *JsonWebKey) Digest(w io.Writer) {
 	w.Write(jwk.E)
 	w.Write(jwk.X)
 	w.Write(jwk.Y)
+	w.Write(jwk.Pub)
 	w.Write([]byte(jwk.KeyID))
 	w.Write(jwk.ThumbprintSHA1)
 	w.Write(jwk.ThumbprintSHA256)
@@ -98,6 +116,7 @@ func (jwk *JsonWebKey) PublicJWK() *JsonWebKey {
 		E:                jwk.E,
 		X:                jwk.X,
 		Y:                jwk.Y,
+		Pub:              jwk.Pub,
 		KeyOperations:    jwk.KeyOperations,
 		ThumbprintSHA1:   jwk.ThumbprintSHA1,
 		ThumbprintSHA256: jwk.ThumbprintSHA256,
@@ -115,6 +134,35 @@ func (jwk *JsonWebKey) PublicKey() crypto.PublicKey {
 		return jwk.rsaPublicKey()
 	case KeyTypeEC:
 		return jwk.ecdsaPublicKey()
+	case KeyTypeOKP:
+		return jwk.okpPublicKey()
+	case KeyTypeAKP:
+		if jwk.Alg == KeyAlgMLKEM768 {
+			if publicKey, err := mlkem.NewEncapsulationKey768(jwk.Pub); err == nil {
+				jwk.cachedPublicKey = publicKey
+			}
+		}
+	}
+	return jwk.cachedPublicKey
+}
+
+// okpPublicKey returns ed25519.PublicKey for Ed25519 and *ecdh.PublicKey for X25519
+func (jwk *JsonWebKey) okpPublicKey() crypto.PublicKey {
+	if (jwk.cachedPublicKey) != nil {
+		return jwk.cachedPublicKey
+	}
+	switch jwk.Curve {
+	case CurveNameEd25519:
+		if len(jwk.X) != ed25519.PublicKeySize {
+			return nil
+		}
+		jwk.cachedPublicKey = ed25519.PublicKey(jwk.X)
+	case CurveNameX25519:
+		publicKey, err := ecdh.X25519().NewPublicKey(jwk.X)
+		if err != nil {
+			return nil
+		}
+		jwk.cachedPublicKey = publicKey
 	}
 	return jwk.cachedPublicKey
 }
@@ -177,6 +225,24 @@ func (jwk *JsonWebKey) PrivateKey() crypto.PrivateKey {
 			PublicKey: *jwk.ecdsaPublicKey(),
 			D:         big.NewInt(0).SetBytes(jwk.D),
 		}
+	case KeyTypeOKP:
+		// RFC8037 2. "d" is the private key seed
+		switch jwk.Curve {
+		case CurveNameEd25519:
+			if len(jwk.D) == ed25519.SeedSize {
+				jwk.cachedPrivateKey = ed25519.NewKeyFromSeed(jwk.D)
+			}
+		case CurveNameX25519:
+			if privateKey, err := ecdh.X25519().NewPrivateKey(jwk.D); err == nil {
+				jwk.cachedPrivateKey = privateKey
+			}
+		}
+	case KeyTypeAKP:
+		if jwk.Alg == KeyAlgMLKEM768 {
+			if privateKey, err := mlkem.NewDecapsulationKey768(jwk.Priv); err == nil {
+				jwk.cachedPrivateKey = privateKey
+			}
+		}
 	}
 
 	return jwk.cachedPrivateKey
@@ -206,6 +272,32 @@ func (jwk *JsonWebKey) SetPublicKey(publicKey crypto.PublicKey) error {
 		jwk.Y = publicKey.Y.Bytes()
 		jwk.N = nil
 		jwk.E = nil
+	case ed25519.PublicKey:
+		jwk.KeyType = KeyTypeOKP
+		jwk.Curve = CurveNameEd25519
+		jwk.X = []byte(publicKey)
+		jwk.Y = nil
+		jwk.N = nil
+		jwk.E = nil
+	case *ecdh.PublicKey:
+		if publicKey.Curve() != ecdh.X25519() {
+			return errInvalidCurve
+		}
+		jwk.KeyType = KeyTypeOKP
+		jwk.Curve = CurveNameX25519
+		jwk.X = publicKey.Bytes()
+		jwk.Y = nil
+		jwk.N = nil
+		jwk.E = nil
+	case *mlkem.EncapsulationKey768:
+		jwk.KeyType = KeyTypeAKP
+		jwk.Alg = KeyAlgMLKEM768
+		jwk.Pub = publicKey.Bytes()
+		jwk.Curve = ""
+		jwk.X = nil
+		jwk.Y = nil
+		jwk.N = nil
+		jwk.E = nil
 	default:
 		return ErrInvalidKeyType
 	}
@@ -217,6 +309,7 @@ func (jwk *JsonWebKey) SetPublicKey(publicKey crypto.PublicKey) error {
 	jwk.Dp = nil
 	jwk.Dq = nil
 	jwk.Qinv = nil
+	jwk.Priv = nil
 	return nil
 }
 
@@ -263,3 +356,47 @@ func NewEphemeralECDHJwk(cryptoProvider cryptoprovider.CryptoProvider) (*JsonWeb
 		},
 	}, nil
 }
+
+// NewEphemeralKeyAgreementJwk generates an ephemeral key pair on the curve of the remote key,
+// X25519 for OKP keys, otherwise P-384
+func NewEphemeralKeyAgreementJwk(cryptoProvider cryptoprovider.CryptoProvider, remoteJWK *JsonWebKey) (*JsonWebKey, error) {
+	if remoteJWK == nil || remoteJWK.KeyType != KeyTypeOKP {
+		return NewEphemeralECDHJwk(cryptoProvider)
+	}
+	if remoteJWK.Curve != CurveNameX25519 {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidCurve, remoteJWK.Curve)
+	}
+	privateKey, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return &JsonWebKey{
+		KeyType: KeyTypeOKP,
+		Curve:   CurveNameX25519,
+		X:       privateKey.PublicKey().Bytes(),
+		D:       privateKey.Bytes(),
+		KeyOperations: []JsonWebKeyOperation{
+			JsonWebKeyOperationDeriveKey,
+			JsonWebKeyOperationDeriveBits,
+		},
+	}, nil
+}
+
+// NewEphemeralMLKEM768Jwk generates an ML-KEM-768 key pair, the requester sends its public key to opt in to
+// the hybrid key agreement
+func NewEphemeralMLKEM768Jwk() (*JsonWebKey, error) {
+	privateKey, err := mlkem.GenerateKey768()
+	if err != nil {
+		return nil, err
+	}
+	return &JsonWebKey{
+		KeyType: KeyTypeAKP,
+		Alg:     KeyAlgMLKEM768,
+		Pub:     privateKey.EncapsulationKey().Bytes(),
+		Priv:    privateKey.Bytes(),
+		KeyOperations: []JsonWebKeyOperation{
+			JsonWebKeyOperationDeriveKey,
+			JsonWebKeyOperationDeriveBits,
+		},
+	}, nil
+}
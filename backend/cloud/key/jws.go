@@ -25,6 +25,9 @@ const (
 	SignatureAlgorithmPS256 JsonWebSignatureAlgorithm = "PS256"
 	SignatureAlgorithmPS384 JsonWebSignatureAlgorithm = "PS384"
 	SignatureAlgorithmPS512 JsonWebSignatureAlgorithm = "PS512"
+
+	// RFC8037 3.1. Ed25519 signs the message itself, there is no digest
+	SignatureAlgorithmEdDSA JsonWebSignatureAlgorithm = "EdDSA"
 )
 
 var supportedAlgs = map[JsonWebSignatureAlgorithm]bool{
@@ -40,6 +43,7 @@ var supportedAlgs = map[JsonWebSignatureAlgorithm]bool{
 	SignatureAlgorithmPS256: true,
 	SignatureAlgorithmPS384: true,
 	SignatureAlgorithmPS512: true,
+	SignatureAlgorithmEdDSA: true,
 }
 
 var jwsAlgToX509SigAlg = map[JsonWebSignatureAlgorithm]x509.SignatureAlgorithm{
@@ -52,9 +56,10 @@ var jwsAlgToX509SigAlg = map[JsonWebSignatureAlgorithm]x509.SignatureAlgorithm{
 	SignatureAlgorithmES256: x509.ECDSAWithSHA256,
 	SignatureAlgorithmES384: x509.ECDSAWithSHA384,
 	SignatureAlgorithmES512: x509.ECDSAWithSHA512,
+	SignatureAlgorithmEdDSA: x509.PureEd25519,
 }
 
-// HashFunc implements crypto.SignerOpts.
+// HashFunc implements crypto.SignerOpts, EdDSA returns 0 as required by ed25519.PrivateKey.Sign.
 func (alg JsonWebSignatureAlgorithm) HashFunc() crypto.Hash {
 	switch alg {
 	case SignatureAlgorithmHS256,
@@ -0,0 +1,248 @@
+package cloudkey
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"fmt"
+	"math/big"
+)
+
+// OAEPHash returns the hash of the RSA-OAEP algorithm, 0 for other algorithms
+func (alg JsonWebKeyEncryptionAlgorithm) OAEPHash() crypto.Hash {
+	switch alg {
+	case JwkEncAlgRsaOeap:
+		return crypto.SHA1
+	case JwkEncAlgRsaOeap256:
+		return crypto.SHA256
+	}
+	return 0
+}
+
+// OAEPDecrypterOpts tells the key store which key operation the decryption is for,
+// plain *rsa.OAEPOptions are treated as unwrapKey
+type OAEPDecrypterOpts struct {
+	rsa.OAEPOptions
+	KeyOperation JsonWebKeyOperation
+}
+
+func ParseOAEPDecrypterOpts(opts crypto.DecrypterOpts) (*rsa.OAEPOptions, JsonWebKeyOperation, error) {
+	switch opts := opts.(type) {
+	case *rsa.OAEPOptions:
+		return opts, JsonWebKeyOperationUnwrapKey, nil
+	case *OAEPDecrypterOpts:
+		switch opts.KeyOperation {
+		case JsonWebKeyOperationDecrypt, JsonWebKeyOperationUnwrapKey:
+			return &opts.OAEPOptions, opts.KeyOperation, nil
+		}
+		return nil, "", fmt.Errorf("%w: key operation %s", ErrInvalidAlgorithm, opts.KeyOperation)
+	}
+	return nil, "", fmt.Errorf("%w: %T", ErrInvalidAlgorithm, opts)
+}
+
+// EncryptOAEP performs encrypt and wrapKey with the public key, no call to the key store is needed
+func EncryptOAEP(publicKey crypto.PublicKey, alg JsonWebKeyEncryptionAlgorithm, plaintext []byte) ([]byte, error) {
+	rsaPublicKey, ok := publicKey.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: %T", ErrInvalidKeyType, publicKey)
+	}
+	hash := alg.OAEPHash()
+	if hash == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidAlgorithm, alg)
+	}
+	return rsa.EncryptOAEP(hash.New(), rand.Reader, rsaPublicKey, plaintext, nil)
+}
+
+var jwsAlgCurves = map[JsonWebSignatureAlgorithm]elliptic.Curve{
+	SignatureAlgorithmES256: elliptic.P256(),
+	SignatureAlgorithmES384: elliptic.P384(),
+	SignatureAlgorithmES512: elliptic.P521(),
+}
+
+// CheckSignatureKey returns an error if the signature algorithm cannot be used with the public key
+func CheckSignatureKey(publicKey crypto.PublicKey, alg JsonWebSignatureAlgorithm) error {
+	switch publicKey := publicKey.(type) {
+	case *rsa.PublicKey:
+		switch alg {
+		case SignatureAlgorithmRS256, SignatureAlgorithmRS384, SignatureAlgorithmRS512,
+			SignatureAlgorithmPS256, SignatureAlgorithmPS384, SignatureAlgorithmPS512:
+			return nil
+		}
+	case *ecdsa.PublicKey:
+		if crv, ok := jwsAlgCurves[alg]; ok && crv == publicKey.Curve {
+			return nil
+		}
+	case ed25519.PublicKey:
+		if alg == SignatureAlgorithmEdDSA {
+			return nil
+		}
+	default:
+		return fmt.Errorf("%w: %T", ErrInvalidKeyType, publicKey)
+	}
+	return fmt.Errorf("%w: %s", ErrInvalidAlgorithm, alg)
+}
+
+// CheckDigestLength returns false if the digest does not match the hash of the algorithm,
+// EdDSA and HMAC sign the message itself which may be of any length
+func CheckDigestLength(alg JsonWebSignatureAlgorithm, digest []byte) bool {
+	switch alg {
+	case SignatureAlgorithmEdDSA,
+		SignatureAlgorithmHS256, SignatureAlgorithmHS384, SignatureAlgorithmHS512:
+		return true
+	}
+	hash := alg.HashFunc()
+	return hash != 0 && len(digest) == hash.Size()
+}
+
+// VerifySignature verifies the JWS signature of the digest, ECDSA signatures are r || s,
+// for EdDSA the digest is the message itself,
+// returns an error only if the key or the algorithm is not usable
+func VerifySignature(publicKey crypto.PublicKey, alg JsonWebSignatureAlgorithm, digest, signature []byte) (bool, error) {
+	if err := CheckSignatureKey(publicKey, alg); err != nil {
+		return false, err
+	}
+	if !CheckDigestLength(alg, digest) {
+		return false, nil
+	}
+	switch publicKey := publicKey.(type) {
+	case *rsa.PublicKey:
+		switch alg {
+		case SignatureAlgorithmPS256, SignatureAlgorithmPS384, SignatureAlgorithmPS512:
+			return rsa.VerifyPSS(publicKey, alg.HashFunc(), digest, signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}) == nil, nil
+		default:
+			return rsa.VerifyPKCS1v15(publicKey, alg.HashFunc(), digest, signature) == nil, nil
+		}
+	case *ecdsa.PublicKey:
+		n := (publicKey.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*n {
+			return false, nil
+		}
+		r := new(big.Int).SetBytes(signature[:n])
+		s := new(big.Int).SetBytes(signature[n:])
+		return ecdsa.Verify(publicKey, digest, r, s), nil
+	case ed25519.PublicKey:
+		return ed25519.Verify(publicKey, digest, signature), nil
+	}
+	return false, nil
+}
+
+// ParsePrivateJWK returns the RSA or EC private key of the JWK, after checking the private key matches the public key
+func ParsePrivateJWK(jwk *JsonWebKey) (crypto.Signer, error) {
+	if jwk == nil || len(jwk.D) == 0 {
+		return nil, fmt.Errorf("%w: private key is required", ErrInvalidKey)
+	}
+	switch jwk.KeyType {
+	case KeyTypeRSA:
+		if len(jwk.P) == 0 || len(jwk.Q) == 0 {
+			return nil, fmt.Errorf("%w: prime factors are required", ErrInvalidKey)
+		}
+		privateKey := &rsa.PrivateKey{
+			PublicKey: rsa.PublicKey{
+				N: new(big.Int).SetBytes(jwk.N),
+				E: int(new(big.Int).SetBytes(jwk.E).Int64()),
+			},
+			D:      new(big.Int).SetBytes(jwk.D),
+			Primes: []*big.Int{new(big.Int).SetBytes(jwk.P), new(big.Int).SetBytes(jwk.Q)},
+		}
+		switch privateKey.N.BitLen() {
+		case 2048, 3072, 4096:
+		default:
+			return nil, fmt.Errorf("%w: %d", ErrInvalidKeySize, privateKey.N.BitLen())
+		}
+		if err := privateKey.Validate(); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidKey, err)
+		}
+		privateKey.Precompute()
+		return privateKey, nil
+	case KeyTypeEC:
+		publicKey, ok := jwk.PublicKey().(*ecdsa.PublicKey)
+		if !ok || publicKey == nil {
+			return nil, ErrInvalidCurve
+		}
+		privateKey := &ecdsa.PrivateKey{
+			PublicKey: *publicKey,
+			D:         new(big.Int).SetBytes(jwk.D),
+		}
+		ecdhPublicKey, err := publicKey.ECDH()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidKey, err)
+		}
+		ecdhPrivateKey, err := privateKey.ECDH()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidKey, err)
+		}
+		if !ecdhPrivateKey.PublicKey().Equal(ecdhPublicKey) {
+			return nil, fmt.Errorf("%w: private key does not match the public key", ErrInvalidKey)
+		}
+		return privateKey, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrInvalidKeyType, jwk.KeyType)
+}
+
+// SignHMAC computes the JWS HMAC of the message with the oct key material
+func SignHMAC(key []byte, alg JsonWebSignatureAlgorithm, message []byte) ([]byte, error) {
+	switch alg {
+	case SignatureAlgorithmHS256, SignatureAlgorithmHS384, SignatureAlgorithmHS512:
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrInvalidAlgorithm, alg)
+	}
+	// RFC7518 3.2. the key must be at least the size of the hash output
+	if len(key) < alg.HashFunc().Size() {
+		return nil, fmt.Errorf("%w: key is too short for %s", ErrInvalidKeySize, alg)
+	}
+	mac := hmac.New(alg.HashFunc().New, key)
+	mac.Write(message)
+	return mac.Sum(nil), nil
+}
+
+// VerifyHMAC returns an error only if the key or the algorithm is not usable
+func VerifyHMAC(key []byte, alg JsonWebSignatureAlgorithm, message, signature []byte) (bool, error) {
+	expected, err := SignHMAC(key, alg, message)
+	if err != nil {
+		return false, err
+	}
+	return hmac.Equal(expected, signature), nil
+}
+
+func newAESGCM(key []byte) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("%w: A256GCM requires a 256-bit key", ErrInvalidKeySize)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// EncryptAESGCM encrypts with A256GCM under a random nonce, the result is nonce || ciphertext || tag
+func EncryptAESGCM(key []byte, plaintext []byte) ([]byte, error) {
+	aead, err := newAESGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// DecryptAESGCM decrypts the output of EncryptAESGCM
+func DecryptAESGCM(key []byte, ciphertext []byte) ([]byte, error) {
+	aead, err := newAESGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < aead.NonceSize()+aead.Overhead() {
+		return nil, fmt.Errorf("%w: ciphertext is too short", ErrInvalidKey)
+	}
+	nonce, sealed := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	return aead.Open(nil, nonce, sealed, nil)
+}
@@ -0,0 +1,67 @@
+package cloudkey
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifySignature(t *testing.T) {
+	digest := sha256.Sum256([]byte("payload"))
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	r, s, err := ecdsa.Sign(rand.Reader, ecKey, digest[:])
+	require.NoError(t, err)
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+	valid, err := VerifySignature(ecKey.Public(), SignatureAlgorithmES256, digest[:], sig)
+	require.NoError(t, err)
+	assert.True(t, valid)
+	sig[0] ^= 0xff
+	valid, err = VerifySignature(ecKey.Public(), SignatureAlgorithmES256, digest[:], sig)
+	require.NoError(t, err)
+	assert.False(t, valid)
+	// the curve must match the algorithm
+	_, err = VerifySignature(ecKey.Public(), SignatureAlgorithmES384, digest[:], sig)
+	assert.ErrorIs(t, err, ErrInvalidAlgorithm)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	sig, err = rsa.SignPSS(rand.Reader, rsaKey, crypto.SHA256, digest[:], &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+	require.NoError(t, err)
+	valid, err = VerifySignature(rsaKey.Public(), SignatureAlgorithmPS256, digest[:], sig)
+	require.NoError(t, err)
+	assert.True(t, valid)
+	valid, err = VerifySignature(rsaKey.Public(), SignatureAlgorithmRS256, digest[:], sig)
+	require.NoError(t, err)
+	assert.False(t, valid)
+}
+
+func TestEncryptOAEP(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ciphertext, err := EncryptOAEP(rsaKey.Public(), JwkEncAlgRsaOeap256, []byte("secret"))
+	require.NoError(t, err)
+
+	opts, op, err := ParseOAEPDecrypterOpts(&OAEPDecrypterOpts{
+		OAEPOptions:  rsa.OAEPOptions{Hash: JwkEncAlgRsaOeap256.OAEPHash()},
+		KeyOperation: JsonWebKeyOperationDecrypt,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, JsonWebKeyOperation(JsonWebKeyOperationDecrypt), op)
+	plaintext, err := rsaKey.Decrypt(nil, ciphertext, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "secret", string(plaintext))
+
+	_, err = EncryptOAEP(rsaKey.Public(), JwkEncAlgDir, []byte("secret"))
+	assert.ErrorIs(t, err, ErrInvalidAlgorithm)
+}
@@ -0,0 +1,215 @@
+package cloudkey
+
+import (
+	"context"
+	"crypto"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrKeyStoreNotConfigured    = errors.New("key store not configured")
+	ErrKeyAgreementNotSupported = errors.New("key agreement not supported by key store")
+	ErrSymmetricKeyNotSupported = errors.New("symmetric keys not supported by key store")
+	ErrKeyImportNotSupported    = errors.New("key import not supported by key store")
+)
+
+type KeyStoreKind string
+
+const (
+	KeyStoreKindAzKeyVault KeyStoreKind = "azkeyvault"
+	KeyStoreKindLocal      KeyStoreKind = "local"
+	KeyStoreKindPKCS11     KeyStoreKind = "pkcs11"
+)
+
+func (k KeyStoreKind) IsSupported() bool {
+	switch k {
+	case KeyStoreKindAzKeyVault, KeyStoreKindLocal, KeyStoreKindPKCS11:
+		return true
+	}
+	return false
+}
+
+type CreateKeyParams struct {
+	KeyType       JsonWebKeyType
+	Curve         JsonWebKeyCurveName
+	KeySize       int
+	KeyOperations []JsonWebKeyOperation
+	Exportable    *bool
+	NotBefore     *time.Time
+	Expires       *time.Time
+}
+
+type CreateKeyResult struct {
+	// public key, with the key ID of the created version
+	JsonWebKey
+	Created    time.Time
+	NotBefore  *time.Time
+	Expires    *time.Time
+	Exportable *bool
+}
+
+// KeyStore creates keys and hands out signers and decrypters for them, private keys never leave the store
+type KeyStore interface {
+	Kind() KeyStoreKind
+	// HasKeyID returns true if the key ID is issued by this kind of store
+	HasKeyID(kid string) bool
+	// CreateKey creates a new version of the named key
+	CreateKey(c context.Context, name string, params CreateKeyParams) (*CreateKeyResult, error)
+	NewSignatureKey(c context.Context, kid string, jwsa JsonWebSignatureAlgorithm, formatX509 bool, publicKey crypto.PublicKey) CloudSignatureKey
+	NewWrappingKey(c context.Context, kid string, keyType JsonWebKeyType) CloudWrappingKey
+}
+
+// KeyAgreementKeyStore is implemented by key stores which can compute ECDH with their EC keys, Key Vault cannot
+type KeyAgreementKeyStore interface {
+	NewKeyAgreementKey(c context.Context, kid string) (CloudKeyAgreementKey, error)
+}
+
+// NewKeyAgreementKey returns the key agreement key if the key store supports it
+func NewKeyAgreementKey(c context.Context, store KeyStore, kid string) (CloudKeyAgreementKey, error) {
+	if kaStore, ok := store.(KeyAgreementKeyStore); ok {
+		return kaStore.NewKeyAgreementKey(c, kid)
+	}
+	return nil, fmt.Errorf("%w: %s", ErrKeyAgreementNotSupported, store.Kind())
+}
+
+// SymmetricKeyStore is implemented by key stores which can hold oct keys,
+// HMAC and AES-GCM are computed by the service with the key material released for the operation
+type SymmetricKeyStore interface {
+	GetSymmetricKey(c context.Context, kid string, op JsonWebKeyOperation) ([]byte, error)
+}
+
+// GetSymmetricKey returns the oct key material if the key store supports it and the key allows the operation
+func GetSymmetricKey(c context.Context, store KeyStore, kid string, op JsonWebKeyOperation) ([]byte, error) {
+	if symStore, ok := store.(SymmetricKeyStore); ok {
+		return symStore.GetSymmetricKey(c, kid, op)
+	}
+	return nil, fmt.Errorf("%w: %s", ErrSymmetricKeyNotSupported, store.Kind())
+}
+
+// KeyImportStore is implemented by key stores which can import existing private keys, imported keys are never exportable
+type KeyImportStore interface {
+	// ImportKey creates a new version of the named key from the private JWK, params.KeyType and params.KeySize are ignored
+	ImportKey(c context.Context, name string, privateJwk *JsonWebKey, params CreateKeyParams) (*CreateKeyResult, error)
+}
+
+// ImportKey imports the private key if the key store supports it
+func ImportKey(c context.Context, store KeyStore, name string, privateJwk *JsonWebKey, params CreateKeyParams) (*CreateKeyResult, error) {
+	if importStore, ok := store.(KeyImportStore); ok {
+		return importStore.ImportKey(c, name, privateJwk, params)
+	}
+	return nil, fmt.Errorf("%w: %s", ErrKeyImportNotSupported, store.Kind())
+}
+
+// KeyStoreSet creates keys in the default store, keys of other stores are selected by their key IDs
+type KeyStoreSet struct {
+	defaultStore KeyStore
+	stores       map[KeyStoreKind]KeyStore
+}
+
+// Kind implements KeyStore.
+func (s *KeyStoreSet) Kind() KeyStoreKind {
+	return s.defaultStore.Kind()
+}
+
+// HasKeyID implements KeyStore.
+func (s *KeyStoreSet) HasKeyID(kid string) bool {
+	return s.forKeyID(kid) != nil
+}
+
+func (s *KeyStoreSet) forKeyID(kid string) KeyStore {
+	if s.defaultStore.HasKeyID(kid) {
+		return s.defaultStore
+	}
+	for _, store := range s.stores {
+		if store.HasKeyID(kid) {
+			return store
+		}
+	}
+	return nil
+}
+
+// CreateKey implements KeyStore.
+func (s *KeyStoreSet) CreateKey(c context.Context, name string, params CreateKeyParams) (*CreateKeyResult, error) {
+	return s.defaultStore.CreateKey(c, name, params)
+}
+
+// NewSignatureKey implements KeyStore.
+func (s *KeyStoreSet) NewSignatureKey(c context.Context, kid string, jwsa JsonWebSignatureAlgorithm, formatX509 bool, publicKey crypto.PublicKey) CloudSignatureKey {
+	store := s.forKeyID(kid)
+	if store == nil {
+		store = s.defaultStore
+	}
+	return store.NewSignatureKey(c, kid, jwsa, formatX509, publicKey)
+}
+
+// NewWrappingKey implements KeyStore.
+func (s *KeyStoreSet) NewWrappingKey(c context.Context, kid string, keyType JsonWebKeyType) CloudWrappingKey {
+	store := s.forKeyID(kid)
+	if store == nil {
+		store = s.defaultStore
+	}
+	return store.NewWrappingKey(c, kid, keyType)
+}
+
+// NewKeyAgreementKey implements KeyAgreementKeyStore.
+func (s *KeyStoreSet) NewKeyAgreementKey(c context.Context, kid string) (CloudKeyAgreementKey, error) {
+	store := s.forKeyID(kid)
+	if store == nil {
+		store = s.defaultStore
+	}
+	return NewKeyAgreementKey(c, store, kid)
+}
+
+// GetSymmetricKey implements SymmetricKeyStore.
+func (s *KeyStoreSet) GetSymmetricKey(c context.Context, kid string, op JsonWebKeyOperation) ([]byte, error) {
+	store := s.forKeyID(kid)
+	if store == nil {
+		store = s.defaultStore
+	}
+	return GetSymmetricKey(c, store, kid, op)
+}
+
+// ImportKey implements KeyImportStore.
+func (s *KeyStoreSet) ImportKey(c context.Context, name string, privateJwk *JsonWebKey, params CreateKeyParams) (*CreateKeyResult, error) {
+	return ImportKey(c, s.defaultStore, name, privateJwk, params)
+}
+
+// Select returns the store of the kind, empty kind selects the default store
+func (s *KeyStoreSet) Select(kind KeyStoreKind) (KeyStore, error) {
+	if kind == "" {
+		return s.defaultStore, nil
+	}
+	if store, ok := s.stores[kind]; ok {
+		return store, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrKeyStoreNotConfigured, kind)
+}
+
+var _ KeyStore = (*KeyStoreSet)(nil)
+var _ KeyAgreementKeyStore = (*KeyStoreSet)(nil)
+var _ SymmetricKeyStore = (*KeyStoreSet)(nil)
+var _ KeyImportStore = (*KeyStoreSet)(nil)
+
+func NewKeyStoreSet(defaultStore KeyStore, stores ...KeyStore) *KeyStoreSet {
+	s := &KeyStoreSet{
+		defaultStore: defaultStore,
+		stores:       map[KeyStoreKind]KeyStore{defaultStore.Kind(): defaultStore},
+	}
+	for _, store := range stores {
+		s.stores[store.Kind()] = store
+	}
+	return s
+}
+
+// SelectKeyStore returns the store of the kind from a KeyStoreSet, or the store itself if it is of the kind
+func SelectKeyStore(store KeyStore, kind KeyStoreKind) (KeyStore, error) {
+	if set, ok := store.(*KeyStoreSet); ok {
+		return set.Select(kind)
+	}
+	if store == nil || (kind != "" && kind != store.Kind()) {
+		return nil, fmt.Errorf("%w: %s", ErrKeyStoreNotConfigured, kind)
+	}
+	return store, nil
+}
@@ -0,0 +1,51 @@
+package cloudkey
+
+import (
+	"context"
+	"crypto"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testKeyStore struct {
+	kind   KeyStoreKind
+	prefix string
+}
+
+func (s *testKeyStore) Kind() KeyStoreKind       { return s.kind }
+func (s *testKeyStore) HasKeyID(kid string) bool { return strings.HasPrefix(kid, s.prefix) }
+func (s *testKeyStore) CreateKey(c context.Context, name string, params CreateKeyParams) (*CreateKeyResult, error) {
+	return &CreateKeyResult{JsonWebKey: JsonWebKey{KeyID: s.prefix + name}}, nil
+}
+func (s *testKeyStore) NewSignatureKey(c context.Context, kid string, jwsa JsonWebSignatureAlgorithm, formatX509 bool, publicKey crypto.PublicKey) CloudSignatureKey {
+	return nil
+}
+func (s *testKeyStore) NewWrappingKey(c context.Context, kid string, keyType JsonWebKeyType) CloudWrappingKey {
+	return nil
+}
+
+func TestKeyStoreSetSelect(t *testing.T) {
+	az := &testKeyStore{KeyStoreKindAzKeyVault, "https://"}
+	hsm := &testKeyStore{KeyStoreKindPKCS11, "pkcs11:"}
+	set := NewKeyStoreSet(az, az, hsm)
+
+	selected, err := SelectKeyStore(set, "")
+	require.NoError(t, err)
+	assert.Equal(t, az, selected)
+	selected, err = SelectKeyStore(set, KeyStoreKindPKCS11)
+	require.NoError(t, err)
+	assert.Equal(t, hsm, selected)
+	_, err = SelectKeyStore(set, KeyStoreKindLocal)
+	assert.ErrorIs(t, err, ErrKeyStoreNotConfigured)
+
+	assert.True(t, set.HasKeyID("pkcs11:ck-root-ca/01"))
+	assert.False(t, set.HasKeyID("local:keys/k/1"))
+	assert.Equal(t, hsm, set.forKeyID("pkcs11:ck-root-ca/01"))
+
+	// a single store is only selected for its own kind
+	_, err = SelectKeyStore(hsm, KeyStoreKindAzKeyVault)
+	assert.ErrorIs(t, err, ErrKeyStoreNotConfigured)
+}
@@ -0,0 +1,151 @@
+package cloudkeylocal
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"fmt"
+	"io"
+	"math/big"
+	"time"
+
+	i "github.com/stephenzsy/small-kms/backend/cloud/key"
+)
+
+type localCloudKey struct {
+	store      *localKeyStore
+	kid        string
+	publicKey  crypto.PublicKey
+	keyType    i.JsonWebKeyType
+	jwsa       i.JsonWebSignatureAlgorithm
+	formatX509 bool
+}
+
+// KeyType implements cloudkey.CloudKey.
+func (k *localCloudKey) KeyType() i.JsonWebKeyType {
+	return k.keyType
+}
+
+// KeyID implements cloudkey.CloudSignatureKey.
+func (k *localCloudKey) KeyID() string {
+	return k.kid
+}
+
+// Public implements cloudkey.CloudSignatureKey.
+func (k *localCloudKey) Public() crypto.PublicKey {
+	if k.publicKey == nil {
+		if record, _, err := k.store.load(k.kid); err == nil {
+			k.publicKey = record.PublicKey.PublicKey()
+		}
+	}
+	return k.publicKey
+}
+
+// toRawSignature produces the JWS encoding of ECDSA signatures, r and s padded to the curve size
+func toRawSignature(r, s *big.Int, curveBits int) []byte {
+	n := (curveBits + 7) / 8
+	sig := make([]byte, 2*n)
+	r.FillBytes(sig[:n])
+	s.FillBytes(sig[n:])
+	return sig
+}
+
+// Sign implements cloudkey.CloudSignatureKey.
+func (k *localCloudKey) Sign(rnd io.Reader, digest []byte, opts crypto.SignerOpts) (signature []byte, err error) {
+	record, privateKey, err := k.store.load(k.kid)
+	if err != nil {
+		return nil, err
+	}
+	if err := record.checkOperation(time.Now(), i.JsonWebKeyOperationSign); err != nil {
+		return nil, err
+	}
+	if rnd == nil {
+		rnd = rand.Reader
+	}
+	switch privateKey := privateKey.(type) {
+	case *ecdsa.PrivateKey:
+		if k.formatX509 {
+			return ecdsa.SignASN1(rnd, privateKey, digest)
+		}
+		r, s, err := ecdsa.Sign(rnd, privateKey, digest)
+		if err != nil {
+			return nil, err
+		}
+		return toRawSignature(r, s, privateKey.Curve.Params().BitSize), nil
+	case ed25519.PrivateKey:
+		// the digest is the message, Ed25519 does not prehash
+		return privateKey.Sign(rnd, digest, crypto.Hash(0))
+	case *rsa.PrivateKey:
+		switch k.jwsa {
+		case i.SignatureAlgorithmPS256, i.SignatureAlgorithmPS384, i.SignatureAlgorithmPS512:
+			return rsa.SignPSS(rnd, privateKey, k.jwsa.HashFunc(), digest, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		case i.SignatureAlgorithmRS256, i.SignatureAlgorithmRS384, i.SignatureAlgorithmRS512:
+			return rsa.SignPKCS1v15(rnd, privateKey, k.jwsa.HashFunc(), digest)
+		case i.SignatureAlgoritmNone:
+			// follow the caller, e.g. x509.CreateCertificate passes the options of the template signature algorithm
+			return privateKey.Sign(rnd, digest, opts)
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", i.ErrInvalidAlgorithm, k.jwsa)
+}
+
+// Decrypt implements cloudkey.CloudWrappingKey, use to unwrap keys, large blob of data should not be decrypted directly with this key
+func (k *localCloudKey) Decrypt(rnd io.Reader, msg []byte, opts crypto.DecrypterOpts) (plaintext []byte, err error) {
+	oaepOpts, keyOp, err := i.ParseOAEPDecrypterOpts(opts)
+	if err != nil {
+		return nil, err
+	}
+	allowedOps := []i.JsonWebKeyOperation{keyOp}
+	if _, isPlain := opts.(*rsa.OAEPOptions); isPlain {
+		// keys used for unwrapping before key operations were distinguished
+		allowedOps = append(allowedOps, i.JsonWebKeyOperationDecrypt)
+	}
+	switch oaepOpts.Hash {
+	case crypto.SHA256, crypto.SHA1:
+	default:
+		return nil, fmt.Errorf("%w: %s", i.ErrInvalidAlgorithm, oaepOpts.Hash)
+	}
+	record, privateKey, err := k.store.load(k.kid)
+	if err != nil {
+		return nil, err
+	}
+	if err := record.checkOperation(time.Now(), allowedOps...); err != nil {
+		return nil, err
+	}
+	rsaKey, ok := privateKey.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", i.ErrInvalidKeyType, record.PublicKey.KeyType)
+	}
+	return rsa.DecryptOAEP(oaepOpts.Hash.New(), nil, rsaKey, msg, oaepOpts.Label)
+}
+
+// ECDH implements cloudkey.CloudKeyAgreementKey.
+func (k *localCloudKey) ECDH(remote *ecdh.PublicKey) ([]byte, error) {
+	record, privateKey, err := k.store.load(k.kid)
+	if err != nil {
+		return nil, err
+	}
+	if err := record.checkOperation(time.Now(), i.JsonWebKeyOperationDeriveKey, i.JsonWebKeyOperationDeriveBits); err != nil {
+		return nil, err
+	}
+	var ecdhKey *ecdh.PrivateKey
+	switch privateKey := privateKey.(type) {
+	case *ecdsa.PrivateKey:
+		if ecdhKey, err = privateKey.ECDH(); err != nil {
+			return nil, err
+		}
+	case *ecdh.PrivateKey:
+		// X25519
+		ecdhKey = privateKey
+	default:
+		return nil, fmt.Errorf("%w: %s", i.ErrInvalidKeyType, record.PublicKey.KeyType)
+	}
+	return ecdhKey.ECDH(remote)
+}
+
+var _ i.CloudSignatureKey = (*localCloudKey)(nil)
+var _ i.CloudWrappingKey = (*localCloudKey)(nil)
+var _ i.CloudKeyAgreementKey = (*localCloudKey)(nil)
@@ -0,0 +1,368 @@
+package cloudkeylocal
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	i "github.com/stephenzsy/small-kms/backend/cloud/key"
+)
+
+var (
+	ErrKeyNotFound           = errors.New("local key not found")
+	ErrInvalidMasterKey      = errors.New("invalid master key")
+	ErrKeyOperationForbidden = errors.New("key operation not permitted")
+)
+
+const (
+	keyIDPrefix          = "local:keys/"
+	keyFileExt           = ".jwe"
+	defaultRSAKeySize    = 2048
+	defaultSecretKeySize = 256
+	masterKeyIDByteLen   = 8
+)
+
+// names and versions become file names
+var keyNamePattern = regexp.MustCompile(`^[0-9A-Za-z-]+$`)
+
+// localKeyRecord is the plaintext of a key file, the whole record is encrypted under the master key
+type localKeyRecord struct {
+	KeyID      string        `json:"kid"`
+	PublicKey  *i.JsonWebKey `json:"jwk"`
+	PrivateKey []byte        `json:"pkcs8"`
+	SecretKey  []byte        `json:"k,omitempty"` // key material of oct keys, which have no PKCS#8 encoding
+	Created    time.Time     `json:"iat"`
+	NotBefore  *time.Time    `json:"nbf,omitempty"`
+	Expires    *time.Time    `json:"exp,omitempty"`
+	Exportable *bool         `json:"ext,omitempty"`
+}
+
+func (r *localKeyRecord) checkOperation(now time.Time, ops ...i.JsonWebKeyOperation) error {
+	if r.NotBefore != nil && now.Before(*r.NotBefore) {
+		return fmt.Errorf("%w: key %s is not yet valid", ErrKeyOperationForbidden, r.KeyID)
+	}
+	if r.Expires != nil && now.After(*r.Expires) {
+		return fmt.Errorf("%w: key %s has expired", ErrKeyOperationForbidden, r.KeyID)
+	}
+	if len(r.PublicKey.KeyOperations) == 0 {
+		return nil
+	}
+	for _, op := range ops {
+		for _, allowed := range r.PublicKey.KeyOperations {
+			if op == allowed {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("%w: key %s does not allow %v", ErrKeyOperationForbidden, r.KeyID, ops)
+}
+
+// localKeyStore keeps each key version in its own file under dir, encrypted with A256GCM under the master key
+type localKeyStore struct {
+	dir         string
+	masterKey   []byte
+	masterKeyID string
+}
+
+// Kind implements cloudkey.KeyStore.
+func (*localKeyStore) Kind() i.KeyStoreKind {
+	return i.KeyStoreKindLocal
+}
+
+// HasKeyID implements cloudkey.KeyStore.
+func (*localKeyStore) HasKeyID(kid string) bool {
+	return strings.HasPrefix(kid, keyIDPrefix)
+}
+
+func parseKeyID(kid string) (name string, version string, err error) {
+	if !strings.HasPrefix(kid, keyIDPrefix) {
+		return "", "", fmt.Errorf("%w: %s", ErrKeyNotFound, kid)
+	}
+	name, version, ok := strings.Cut(strings.TrimPrefix(kid, keyIDPrefix), "/")
+	if !ok || !keyNamePattern.MatchString(name) || !keyNamePattern.MatchString(version) {
+		return "", "", fmt.Errorf("%w: %s", ErrKeyNotFound, kid)
+	}
+	return name, version, nil
+}
+
+func (s *localKeyStore) keyFilePath(name, version string) string {
+	return filepath.Join(s.dir, name, version+keyFileExt)
+}
+
+// generatePrivateKey returns crypto.Signer for signing keys, or *ecdh.PrivateKey for X25519
+func generatePrivateKey(params i.CreateKeyParams) (interface{ Public() crypto.PublicKey }, error) {
+	switch params.KeyType {
+	case i.KeyTypeEC:
+		var crv elliptic.Curve
+		switch params.Curve {
+		case i.CurveNameP256:
+			crv = elliptic.P256()
+		case i.CurveNameP384:
+			crv = elliptic.P384()
+		case i.CurveNameP521:
+			crv = elliptic.P521()
+		default:
+			return nil, i.ErrInvalidCurve
+		}
+		return ecdsa.GenerateKey(crv, rand.Reader)
+	case i.KeyTypeRSA:
+		keySize := params.KeySize
+		switch keySize {
+		case 0:
+			keySize = defaultRSAKeySize
+		case 2048, 3072, 4096:
+		default:
+			return nil, i.ErrInvalidKeySize
+		}
+		return rsa.GenerateKey(rand.Reader, keySize)
+	case i.KeyTypeOKP:
+		switch params.Curve {
+		case i.CurveNameEd25519:
+			_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+			return privateKey, err
+		case i.CurveNameX25519:
+			return ecdh.X25519().GenerateKey(rand.Reader)
+		}
+		return nil, i.ErrInvalidCurve
+	}
+	return nil, i.ErrInvalidKeyType
+}
+
+// generateSecretKey returns random oct key material of 256, 384 or 512 bits
+func generateSecretKey(keySize int) ([]byte, error) {
+	switch keySize {
+	case 0:
+		keySize = defaultSecretKeySize
+	case 256, 384, 512:
+	default:
+		return nil, i.ErrInvalidKeySize
+	}
+	secretKey := make([]byte, keySize/8)
+	if _, err := rand.Read(secretKey); err != nil {
+		return nil, err
+	}
+	return secretKey, nil
+}
+
+// CreateKey implements cloudkey.KeyStore.
+func (s *localKeyStore) CreateKey(c context.Context, name string, params i.CreateKeyParams) (*i.CreateKeyResult, error) {
+	if !keyNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("%w: invalid key name %s", i.ErrInvalidKey, name)
+	}
+	if params.KeyType == i.KeyTypeOct {
+		secretKey, err := generateSecretKey(params.KeySize)
+		if err != nil {
+			return nil, err
+		}
+		return s.createVersion(name, &i.JsonWebKey{KeyType: i.KeyTypeOct}, nil, secretKey, params)
+	}
+	privateKey, err := generatePrivateKey(params)
+	if err != nil {
+		return nil, err
+	}
+	return s.createPrivateKeyVersion(name, privateKey, params)
+}
+
+// ImportKey implements cloudkey.KeyImportStore.
+func (s *localKeyStore) ImportKey(c context.Context, name string, privateJwk *i.JsonWebKey, params i.CreateKeyParams) (*i.CreateKeyResult, error) {
+	if !keyNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("%w: invalid key name %s", i.ErrInvalidKey, name)
+	}
+	privateKey, err := i.ParsePrivateJWK(privateJwk)
+	if err != nil {
+		return nil, err
+	}
+	exportable := false
+	params.Exportable = &exportable
+	return s.createPrivateKeyVersion(name, privateKey, params)
+}
+
+func (s *localKeyStore) createPrivateKeyVersion(name string, privateKey interface{ Public() crypto.PublicKey }, params i.CreateKeyParams) (*i.CreateKeyResult, error) {
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	publicJwk, err := i.NewJsonWebKeyFromPublicKey(privateKey.Public())
+	if err != nil {
+		return nil, err
+	}
+	return s.createVersion(name, publicJwk, pkcs8, nil, params)
+}
+
+func (s *localKeyStore) createVersion(name string, publicJwk *i.JsonWebKey, pkcs8, secretKey []byte, params i.CreateKeyParams) (*i.CreateKeyResult, error) {
+	version := strings.ReplaceAll(uuid.NewString(), "-", "")
+	publicJwk.KeyID = keyIDPrefix + name + "/" + version
+	publicJwk.KeyOperations = i.SanitizeKeyOperations(params.KeyOperations)
+
+	record := &localKeyRecord{
+		KeyID:      publicJwk.KeyID,
+		PublicKey:  publicJwk,
+		PrivateKey: pkcs8,
+		SecretKey:  secretKey,
+		Created:    time.Now().Truncate(time.Second),
+		NotBefore:  params.NotBefore,
+		Expires:    params.Expires,
+		Exportable: params.Exportable,
+	}
+	if err := s.store(name, version, record); err != nil {
+		return nil, err
+	}
+	return &i.CreateKeyResult{
+		JsonWebKey: *publicJwk,
+		Created:    record.Created,
+		NotBefore:  record.NotBefore,
+		Expires:    record.Expires,
+		Exportable: record.Exportable,
+	}, nil
+}
+
+func (s *localKeyStore) store(name, version string, record *localKeyRecord) error {
+	plaintext, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	builder := i.JWEAes256GcmEncBuilder{}
+	builder.SetDirectEncryptionKey(s.masterKey)
+	builder.Protected.KeyID = s.masterKeyID
+	sealed, err := builder.Seal(plaintext)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Join(s.dir, name), 0700); err != nil {
+		return err
+	}
+	// write then rename, so a partially written key file is never loaded
+	filePath := s.keyFilePath(name, version)
+	tmpPath := filePath + ".tmp"
+	if err := os.WriteFile(tmpPath, []byte(sealed), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, filePath)
+}
+
+func (s *localKeyStore) load(kid string) (*localKeyRecord, crypto.PrivateKey, error) {
+	name, version, err := parseKeyID(kid)
+	if err != nil {
+		return nil, nil, err
+	}
+	sealed, err := os.ReadFile(s.keyFilePath(name, version))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil, fmt.Errorf("%w: %s", ErrKeyNotFound, kid)
+		}
+		return nil, nil, err
+	}
+	jwe, err := i.NewJsonWebEncryption(string(sealed))
+	if err != nil {
+		return nil, nil, err
+	}
+	plaintext, _, err := jwe.Decrypt(func(header *i.JoseHeader) (crypto.PrivateKey, error) {
+		if header.KeyID != s.masterKeyID {
+			return nil, fmt.Errorf("%w: key %s is encrypted under master key %s", ErrInvalidMasterKey, kid, header.KeyID)
+		}
+		return s.masterKey, nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	record := &localKeyRecord{}
+	if err := json.Unmarshal(plaintext, record); err != nil {
+		return nil, nil, err
+	}
+	// the key ID is inside the authenticated plaintext, a key file moved to another name is rejected
+	if record.KeyID != kid || record.PublicKey == nil {
+		return nil, nil, fmt.Errorf("%w: key file does not match %s", i.ErrInvalidKey, kid)
+	}
+	if record.PublicKey.KeyType == i.KeyTypeOct {
+		return record, record.SecretKey, nil
+	}
+	privateKey, err := x509.ParsePKCS8PrivateKey(record.PrivateKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return record, privateKey, nil
+}
+
+// NewSignatureKey implements cloudkey.KeyStore.
+func (s *localKeyStore) NewSignatureKey(c context.Context, kid string, jwsa i.JsonWebSignatureAlgorithm, formatX509 bool, publicKey crypto.PublicKey) i.CloudSignatureKey {
+	return &localCloudKey{
+		store:      s,
+		kid:        kid,
+		jwsa:       jwsa,
+		formatX509: formatX509,
+		publicKey:  publicKey,
+	}
+}
+
+// NewWrappingKey implements cloudkey.KeyStore.
+func (s *localKeyStore) NewWrappingKey(c context.Context, kid string, keyType i.JsonWebKeyType) i.CloudWrappingKey {
+	return &localCloudKey{
+		store:   s,
+		kid:     kid,
+		keyType: keyType,
+	}
+}
+
+// NewKeyAgreementKey implements cloudkey.KeyAgreementKeyStore.
+func (s *localKeyStore) NewKeyAgreementKey(c context.Context, kid string) (i.CloudKeyAgreementKey, error) {
+	return &localCloudKey{
+		store:   s,
+		kid:     kid,
+		keyType: i.KeyTypeEC,
+	}, nil
+}
+
+// GetSymmetricKey implements cloudkey.SymmetricKeyStore.
+func (s *localKeyStore) GetSymmetricKey(c context.Context, kid string, op i.JsonWebKeyOperation) ([]byte, error) {
+	record, privateKey, err := s.load(kid)
+	if err != nil {
+		return nil, err
+	}
+	if err := record.checkOperation(time.Now(), op); err != nil {
+		return nil, err
+	}
+	secretKey, ok := privateKey.([]byte)
+	if !ok || len(secretKey) == 0 {
+		return nil, fmt.Errorf("%w: %s", i.ErrInvalidKeyType, record.PublicKey.KeyType)
+	}
+	return secretKey, nil
+}
+
+var _ i.KeyStore = (*localKeyStore)(nil)
+var _ i.KeyAgreementKeyStore = (*localKeyStore)(nil)
+var _ i.SymmetricKeyStore = (*localKeyStore)(nil)
+var _ i.KeyImportStore = (*localKeyStore)(nil)
+
+// NewKeyStore stores keys under dir, masterKey must be 32 bytes for A256GCM
+func NewKeyStore(dir string, masterKey []byte) (i.KeyStore, error) {
+	if len(masterKey) != 32 {
+		return nil, fmt.Errorf("%w: master key must be 32 bytes", ErrInvalidMasterKey)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256(masterKey)
+	return &localKeyStore{
+		dir:         dir,
+		masterKey:   masterKey,
+		masterKeyID: hex.EncodeToString(digest[:masterKeyIDByteLen]),
+	}, nil
+}
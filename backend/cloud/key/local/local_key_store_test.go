@@ -0,0 +1,305 @@
+package cloudkeylocal
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	i "github.com/stephenzsy/small-kms/backend/cloud/key"
+	cloudkeyx "github.com/stephenzsy/small-kms/backend/cloud/key/x"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalKeyStoreSign(t *testing.T) {
+	c := context.Background()
+	dir := t.TempDir()
+	masterKey := bytes.Repeat([]byte{1}, 32)
+	ks, err := NewKeyStore(dir, masterKey)
+	require.NoError(t, err)
+
+	created, err := ks.CreateKey(c, "ck-root-ca-test-policy", i.CreateKeyParams{
+		KeyType:       i.KeyTypeEC,
+		Curve:         i.CurveNameP384,
+		KeyOperations: []i.JsonWebKeyOperation{i.JsonWebKeyOperationSign, i.JsonWebKeyOperationVerify},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, created.D)
+
+	signer := ks.NewSignatureKey(c, created.KeyID, i.SignatureAlgorithmES384, true, nil)
+	template := &x509.Certificate{
+		SerialNumber:       big.NewInt(1),
+		Subject:            pkix.Name{CommonName: "test"},
+		NotBefore:          time.Now(),
+		NotAfter:           time.Now().Add(time.Hour),
+		SignatureAlgorithm: x509.ECDSAWithSHA384,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, created.PublicKey(), signer)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	require.NoError(t, cert.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature))
+
+	// JWS signatures are r || s
+	digest := sha256.Sum256([]byte("payload"))
+	raw, err := ks.NewSignatureKey(c, created.KeyID, i.SignatureAlgorithmES384, false, nil).Sign(nil, digest[:], crypto.SHA256)
+	require.NoError(t, err)
+	require.Len(t, raw, 96)
+	assert.True(t, ecdsa.Verify(created.PublicKey().(*ecdsa.PublicKey), digest[:],
+		new(big.Int).SetBytes(raw[:48]), new(big.Int).SetBytes(raw[48:])))
+
+	// a different master key cannot load the key
+	other, err := NewKeyStore(dir, bytes.Repeat([]byte{2}, 32))
+	require.NoError(t, err)
+	_, err = other.NewSignatureKey(c, created.KeyID, i.SignatureAlgorithmES384, true, nil).Sign(nil, digest[:], crypto.SHA256)
+	assert.ErrorIs(t, err, ErrInvalidMasterKey)
+
+	_, err = ks.NewSignatureKey(c, "local:keys/../x", i.SignatureAlgorithmES384, true, nil).Sign(nil, digest[:], crypto.SHA256)
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+}
+
+func TestLocalKeyStoreUnwrap(t *testing.T) {
+	c := context.Background()
+	ks, err := NewKeyStore(t.TempDir(), bytes.Repeat([]byte{1}, 32))
+	require.NoError(t, err)
+
+	created, err := ks.CreateKey(c, "k-profile-default-wrap", i.CreateKeyParams{
+		KeyType:       i.KeyTypeRSA,
+		KeySize:       2048,
+		KeyOperations: []i.JsonWebKeyOperation{i.JsonWebKeyOperationWrapKey, i.JsonWebKeyOperationUnwrapKey},
+	})
+	require.NoError(t, err)
+
+	cek := bytes.Repeat([]byte{3}, 32)
+	wrapped, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, created.PublicKey().(*rsa.PublicKey), cek, nil)
+	require.NoError(t, err)
+	unwrapped, err := ks.NewWrappingKey(c, created.KeyID, i.KeyTypeRSA).Decrypt(nil, wrapped, &rsa.OAEPOptions{Hash: crypto.SHA256})
+	require.NoError(t, err)
+	assert.Equal(t, cek, unwrapped)
+
+	// the key does not allow signing
+	_, err = ks.NewSignatureKey(c, created.KeyID, i.SignatureAlgorithmPS256, true, nil).Sign(nil, cek, crypto.SHA256)
+	assert.ErrorIs(t, err, ErrKeyOperationForbidden)
+}
+
+func TestLocalKeyStoreEnvelopeEncryption(t *testing.T) {
+	c := context.Background()
+	ks, err := NewKeyStore(t.TempDir(), bytes.Repeat([]byte{1}, 32))
+	require.NoError(t, err)
+
+	rsaKey, err := ks.CreateKey(c, "k-envelope-rsa", i.CreateKeyParams{
+		KeyType:       i.KeyTypeRSA,
+		KeySize:       2048,
+		KeyOperations: []i.JsonWebKeyOperation{i.JsonWebKeyOperationWrapKey, i.JsonWebKeyOperationUnwrapKey},
+	})
+	require.NoError(t, err)
+	ecKey, err := ks.CreateKey(c, "k-envelope-ec", i.CreateKeyParams{
+		KeyType:       i.KeyTypeEC,
+		Curve:         i.CurveNameP256,
+		KeyOperations: []i.JsonWebKeyOperation{i.JsonWebKeyOperationDeriveKey},
+	})
+	require.NoError(t, err)
+	ephemeralKey, err := ecdsa.GenerateKey(ecKey.PublicKey().(*ecdsa.PublicKey).Curve, rand.Reader)
+	require.NoError(t, err)
+	epk, err := i.NewJsonWebKeyFromPublicKey(&ephemeralKey.PublicKey)
+	require.NoError(t, err)
+	epk.D = ephemeralKey.D.Bytes()
+
+	dataKey := bytes.Repeat([]byte{7}, 32)
+	aad := []byte(`{"purpose":"test"}`)
+
+	for _, tc := range []struct {
+		name    string
+		wrap    func(b *i.JWEAes256GcmEncBuilder) error
+		keyFunc func(header *i.JoseHeader) (crypto.PrivateKey, error)
+	}{
+		{
+			name: "RSA-OAEP-256",
+			wrap: func(b *i.JWEAes256GcmEncBuilder) error {
+				return b.SetRsaOaep256KeyWrap(rsaKey.PublicKey().(*rsa.PublicKey))
+			},
+			keyFunc: func(header *i.JoseHeader) (crypto.PrivateKey, error) {
+				return ks.NewWrappingKey(c, rsaKey.KeyID, i.KeyTypeRSA), nil
+			},
+		},
+		{
+			name: "ECDH-ES",
+			wrap: func(b *i.JWEAes256GcmEncBuilder) error {
+				return b.SetEcdhEsKeyAgreement(epk, &ecKey.JsonWebKey)
+			},
+			keyFunc: func(header *i.JoseHeader) (crypto.PrivateKey, error) {
+				return i.NewKeyAgreementKey(c, ks, ecKey.KeyID)
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			b := &i.JWEAes256GcmEncBuilder{}
+			b.AdditionalAuthenticatedData = aad
+			require.NoError(t, tc.wrap(b))
+			sealed, err := b.Seal(dataKey)
+			require.NoError(t, err)
+
+			jwe, err := i.NewJsonWebEncryption(sealed)
+			require.NoError(t, err)
+			jwe.AdditionalAuthenticatedData = aad
+			unwrapped, _, err := jwe.Decrypt(tc.keyFunc)
+			require.NoError(t, err)
+			assert.Equal(t, dataKey, unwrapped)
+
+			// a different encryption context must not unwrap the data key
+			jwe.AdditionalAuthenticatedData = []byte(`{"purpose":"other"}`)
+			_, _, err = jwe.Decrypt(tc.keyFunc)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestLocalKeyStoreOKP(t *testing.T) {
+	c := context.Background()
+	ks, err := NewKeyStore(t.TempDir(), bytes.Repeat([]byte{1}, 32))
+	require.NoError(t, err)
+
+	edKey, err := ks.CreateKey(c, "k-ed25519", i.CreateKeyParams{
+		KeyType:       i.KeyTypeOKP,
+		Curve:         i.CurveNameEd25519,
+		KeyOperations: []i.JsonWebKeyOperation{i.JsonWebKeyOperationSign, i.JsonWebKeyOperationVerify},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, i.KeyTypeOKP, edKey.KeyType)
+	assert.Len(t, edKey.X, ed25519.PublicKeySize)
+
+	// the public key is read back from the JWK as stored in the key document
+	publicJwk := &i.JsonWebKey{KeyType: edKey.KeyType, Curve: edKey.Curve, X: edKey.X}
+	signer := ks.NewSignatureKey(c, edKey.KeyID, i.SignatureAlgorithmEdDSA, false, publicJwk.PublicKey())
+	message := []byte("message of any length")
+	signature, err := signer.Sign(nil, message, i.SignatureAlgorithmEdDSA)
+	require.NoError(t, err)
+	valid, err := i.VerifySignature(publicJwk.PublicKey(), i.SignatureAlgorithmEdDSA, message, signature)
+	require.NoError(t, err)
+	assert.True(t, valid)
+
+	token, err := jwt.NewWithClaims(cloudkeyx.NewJWTSigningMethod(i.SignatureAlgorithmEdDSA), jwt.MapClaims{"sub": "test"}).SignedString(signer)
+	require.NoError(t, err)
+	_, err = jwt.Parse(token, func(*jwt.Token) (interface{}, error) {
+		return publicJwk.PublicKey(), nil
+	}, jwt.WithValidMethods([]string{string(i.SignatureAlgorithmEdDSA)}))
+	assert.NoError(t, err)
+
+	xKey, err := ks.CreateKey(c, "k-x25519", i.CreateKeyParams{
+		KeyType:       i.KeyTypeOKP,
+		Curve:         i.CurveNameX25519,
+		KeyOperations: []i.JsonWebKeyOperation{i.JsonWebKeyOperationDeriveKey},
+	})
+	require.NoError(t, err)
+	ephemeralKey, err := ecdh.X25519().GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	epk, err := i.NewJsonWebKeyFromPublicKey(ephemeralKey.PublicKey())
+	require.NoError(t, err)
+	epk.D = ephemeralKey.Bytes()
+
+	b := &i.JWEAes256GcmEncBuilder{}
+	require.NoError(t, b.SetEcdhEsKeyAgreement(epk, &xKey.JsonWebKey))
+	sealed, err := b.Seal([]byte("data key"))
+	require.NoError(t, err)
+	jwe, err := i.NewJsonWebEncryption(sealed)
+	require.NoError(t, err)
+	assert.Equal(t, i.KeyTypeOKP, jwe.Protected.EphemeralPublicKey.KeyType)
+	plaintext, _, err := jwe.Decrypt(func(header *i.JoseHeader) (crypto.PrivateKey, error) {
+		return i.NewKeyAgreementKey(c, ks, xKey.KeyID)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("data key"), plaintext)
+}
+
+func TestLocalKeyStoreOct(t *testing.T) {
+	c := context.Background()
+	ks, err := NewKeyStore(t.TempDir(), bytes.Repeat([]byte{1}, 32))
+	require.NoError(t, err)
+
+	hmacKey, err := ks.CreateKey(c, "k-hs384", i.CreateKeyParams{
+		KeyType:       i.KeyTypeOct,
+		KeySize:       384,
+		KeyOperations: []i.JsonWebKeyOperation{i.JsonWebKeyOperationSign, i.JsonWebKeyOperationVerify},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, i.KeyTypeOct, hmacKey.KeyType)
+	assert.Empty(t, hmacKey.K)
+
+	key, err := i.GetSymmetricKey(c, ks, hmacKey.KeyID, i.JsonWebKeyOperationSign)
+	require.NoError(t, err)
+	assert.Len(t, key, 48)
+	signature, err := i.SignHMAC(key, i.SignatureAlgorithmHS384, []byte("message"))
+	require.NoError(t, err)
+	valid, err := i.VerifyHMAC(key, i.SignatureAlgorithmHS384, []byte("message"), signature)
+	require.NoError(t, err)
+	assert.True(t, valid)
+	_, err = i.GetSymmetricKey(c, ks, hmacKey.KeyID, i.JsonWebKeyOperationEncrypt)
+	assert.ErrorIs(t, err, ErrKeyOperationForbidden)
+
+	aesKey, err := ks.CreateKey(c, "k-a256gcm", i.CreateKeyParams{
+		KeyType:       i.KeyTypeOct,
+		KeySize:       256,
+		KeyOperations: []i.JsonWebKeyOperation{i.JsonWebKeyOperationEncrypt, i.JsonWebKeyOperationDecrypt},
+	})
+	require.NoError(t, err)
+	key, err = i.GetSymmetricKey(c, ks, aesKey.KeyID, i.JsonWebKeyOperationEncrypt)
+	require.NoError(t, err)
+	ciphertext, err := i.EncryptAESGCM(key, []byte("plaintext"))
+	require.NoError(t, err)
+	plaintext, err := i.DecryptAESGCM(key, ciphertext)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("plaintext"), plaintext)
+	ciphertext[len(ciphertext)-1] ^= 1
+	_, err = i.DecryptAESGCM(key, ciphertext)
+	assert.Error(t, err)
+}
+
+func TestLocalKeyStoreImport(t *testing.T) {
+	c := context.Background()
+	ks, err := NewKeyStore(t.TempDir(), bytes.Repeat([]byte{1}, 32))
+	require.NoError(t, err)
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	privateJwk, err := i.NewJsonWebKeyFromPublicKey(ecKey.Public())
+	require.NoError(t, err)
+	privateJwk.D = ecKey.D.FillBytes(make([]byte, 32))
+
+	exportable := true
+	imported, err := i.ImportKey(c, ks, "k-imported", privateJwk, i.CreateKeyParams{
+		KeyType:       i.KeyTypeEC,
+		Curve:         i.CurveNameP256,
+		KeyOperations: []i.JsonWebKeyOperation{i.JsonWebKeyOperationSign, i.JsonWebKeyOperationVerify},
+		Exportable:    &exportable,
+	})
+	require.NoError(t, err)
+	assert.Empty(t, imported.D)
+	assert.True(t, ecKey.PublicKey.Equal(imported.PublicKey()))
+	require.NotNil(t, imported.Exportable)
+	assert.False(t, *imported.Exportable)
+
+	digest := sha256.Sum256([]byte("payload"))
+	signature, err := ks.NewSignatureKey(c, imported.KeyID, i.SignatureAlgorithmES256, false, nil).Sign(nil, digest[:], crypto.SHA256)
+	require.NoError(t, err)
+	valid, err := i.VerifySignature(ecKey.Public(), i.SignatureAlgorithmES256, digest[:], signature)
+	require.NoError(t, err)
+	assert.True(t, valid)
+
+	// private key not matching the public key is rejected
+	privateJwk.D = bytes.Repeat([]byte{1}, 32)
+	_, err = i.ImportKey(c, ks, "k-imported", privateJwk, i.CreateKeyParams{KeyType: i.KeyTypeEC, Curve: i.CurveNameP256})
+	assert.ErrorIs(t, err, i.ErrInvalidKey)
+}
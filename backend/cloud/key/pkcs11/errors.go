@@ -0,0 +1,9 @@
+package cloudkeypkcs11
+
+import "errors"
+
+var (
+	ErrKeyNotFound     = errors.New("pkcs11 key not found")
+	ErrTokenNotFound   = errors.New("pkcs11 token not found")
+	ErrModuleNotLoaded = errors.New("pkcs11 module not loaded")
+)
@@ -0,0 +1,212 @@
+//go:build cgo
+
+package cloudkeypkcs11
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/rsa"
+	"encoding/asn1"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/miekg/pkcs11"
+	i "github.com/stephenzsy/small-kms/backend/cloud/key"
+)
+
+// CKM_RSA_PKCS signs the DigestInfo, the token does not prepend it
+var pkcs1v15DigestInfoPrefixes = map[crypto.Hash][]byte{
+	crypto.SHA256: {0x30, 0x31, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x01, 0x05, 0x00, 0x04, 0x20},
+	crypto.SHA384: {0x30, 0x41, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x02, 0x05, 0x00, 0x04, 0x30},
+	crypto.SHA512: {0x30, 0x51, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x03, 0x05, 0x00, 0x04, 0x40},
+}
+
+var hashMechanisms = map[crypto.Hash]struct{ hashAlg, mgf uint }{
+	crypto.SHA1:   {pkcs11.CKM_SHA_1, pkcs11.CKG_MGF1_SHA1},
+	crypto.SHA256: {pkcs11.CKM_SHA256, pkcs11.CKG_MGF1_SHA256},
+	crypto.SHA384: {pkcs11.CKM_SHA384, pkcs11.CKG_MGF1_SHA384},
+	crypto.SHA512: {pkcs11.CKM_SHA512, pkcs11.CKG_MGF1_SHA512},
+}
+
+type pkcs11CloudKey struct {
+	store      *pkcs11KeyStore
+	kid        string
+	publicKey  crypto.PublicKey
+	keyType    i.JsonWebKeyType
+	jwsa       i.JsonWebSignatureAlgorithm
+	formatX509 bool
+}
+
+// KeyType implements cloudkey.CloudKey.
+func (k *pkcs11CloudKey) KeyType() i.JsonWebKeyType {
+	return k.keyType
+}
+
+// KeyID implements cloudkey.CloudSignatureKey.
+func (k *pkcs11CloudKey) KeyID() string {
+	return k.kid
+}
+
+// Public implements cloudkey.CloudSignatureKey.
+func (k *pkcs11CloudKey) Public() crypto.PublicKey {
+	if k.publicKey == nil {
+		k.store.mu.Lock()
+		defer k.store.mu.Unlock()
+		handle, err := k.store.findObject(k.kid, pkcs11.CKO_PUBLIC_KEY)
+		if err != nil {
+			return nil
+		}
+		// the key type is not part of the key ID, an EC public key has no modulus
+		publicKey, err := k.store.readPublicKey(handle, i.KeyTypeRSA)
+		if err != nil {
+			publicKey, err = k.store.readPublicKey(handle, i.KeyTypeEC)
+		}
+		if err == nil {
+			k.publicKey = publicKey
+		}
+	}
+	return k.publicKey
+}
+
+func (k *pkcs11CloudKey) signMechanism(digest []byte, opts crypto.SignerOpts) (*pkcs11.Mechanism, []byte, error) {
+	switch k.Public().(type) {
+	case *rsa.PublicKey:
+		jwsa := k.jwsa
+		if jwsa == i.SignatureAlgoritmNone {
+			// follow the caller, e.g. x509.CreateCertificate passes the options of the template signature algorithm
+			if _, isPSS := opts.(*rsa.PSSOptions); isPSS {
+				jwsa = i.SignatureAlgorithmPS256
+			} else {
+				jwsa = i.SignatureAlgorithmRS256
+			}
+		}
+		hash := opts.HashFunc()
+		if k.jwsa != i.SignatureAlgoritmNone {
+			hash = k.jwsa.HashFunc()
+		}
+		switch jwsa {
+		case i.SignatureAlgorithmPS256, i.SignatureAlgorithmPS384, i.SignatureAlgorithmPS512:
+			hm, ok := hashMechanisms[hash]
+			if !ok {
+				return nil, nil, fmt.Errorf("%w: %s", i.ErrInvalidAlgorithm, hash)
+			}
+			return pkcs11.NewMechanism(pkcs11.CKM_RSA_PKCS_PSS, pkcs11.NewPSSParams(hm.hashAlg, hm.mgf, uint(hash.Size()))), digest, nil
+		case i.SignatureAlgorithmRS256, i.SignatureAlgorithmRS384, i.SignatureAlgorithmRS512:
+			prefix, ok := pkcs1v15DigestInfoPrefixes[hash]
+			if !ok {
+				return nil, nil, fmt.Errorf("%w: %s", i.ErrInvalidAlgorithm, hash)
+			}
+			return pkcs11.NewMechanism(pkcs11.CKM_RSA_PKCS, nil), append(append([]byte{}, prefix...), digest...), nil
+		}
+	case nil:
+		return nil, nil, fmt.Errorf("%w: %s", ErrKeyNotFound, k.kid)
+	default:
+		return pkcs11.NewMechanism(pkcs11.CKM_ECDSA, nil), digest, nil
+	}
+	return nil, nil, fmt.Errorf("%w: %s", i.ErrInvalidAlgorithm, k.jwsa)
+}
+
+// Sign implements cloudkey.CloudSignatureKey.
+func (k *pkcs11CloudKey) Sign(rnd io.Reader, digest []byte, opts crypto.SignerOpts) (signature []byte, err error) {
+	mech, data, err := k.signMechanism(digest, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	k.store.mu.Lock()
+	defer k.store.mu.Unlock()
+	handle, err := k.store.findObject(k.kid, pkcs11.CKO_PRIVATE_KEY)
+	if err != nil {
+		return nil, err
+	}
+	if err := k.store.ctx.SignInit(k.store.session, []*pkcs11.Mechanism{mech}, handle); err != nil {
+		return nil, err
+	}
+	signature, err = k.store.ctx.Sign(k.store.session, data)
+	if err != nil {
+		return nil, err
+	}
+	if mech.Mechanism == pkcs11.CKM_ECDSA && k.formatX509 {
+		// CKM_ECDSA returns r || s
+		n := len(signature) / 2
+		return asn1.Marshal(struct{ R, S *big.Int }{
+			new(big.Int).SetBytes(signature[:n]),
+			new(big.Int).SetBytes(signature[n:]),
+		})
+	}
+	return signature, nil
+}
+
+// Decrypt implements cloudkey.CloudWrappingKey, use to unwrap keys, large blob of data should not be decrypted directly with this key
+func (k *pkcs11CloudKey) Decrypt(rnd io.Reader, msg []byte, opts crypto.DecrypterOpts) (plaintext []byte, err error) {
+	// the token does not distinguish decrypt from unwrapKey, C_Decrypt is used for both
+	oaepOpts, _, err := i.ParseOAEPDecrypterOpts(opts)
+	if err != nil {
+		return nil, err
+	}
+	switch oaepOpts.Hash {
+	case crypto.SHA256, crypto.SHA1:
+	default:
+		return nil, fmt.Errorf("%w: %s", i.ErrInvalidAlgorithm, oaepOpts.Hash)
+	}
+	hm := hashMechanisms[oaepOpts.Hash]
+	mech := pkcs11.NewMechanism(pkcs11.CKM_RSA_PKCS_OAEP, pkcs11.NewOAEPParams(hm.hashAlg, hm.mgf, pkcs11.CKZ_DATA_SPECIFIED, oaepOpts.Label))
+
+	k.store.mu.Lock()
+	defer k.store.mu.Unlock()
+	handle, err := k.store.findObject(k.kid, pkcs11.CKO_PRIVATE_KEY)
+	if err != nil {
+		return nil, err
+	}
+	if err := k.store.ctx.DecryptInit(k.store.session, []*pkcs11.Mechanism{mech}, handle); err != nil {
+		return nil, err
+	}
+	return k.store.ctx.Decrypt(k.store.session, msg)
+}
+
+// ECDH implements cloudkey.CloudKeyAgreementKey, the shared secret is derived into a session object and read back
+func (k *pkcs11CloudKey) ECDH(remote *ecdh.PublicKey) ([]byte, error) {
+	var secretLen int
+	switch remote.Curve() {
+	case ecdh.P256():
+		secretLen = 32
+	case ecdh.P384():
+		secretLen = 48
+	case ecdh.P521():
+		secretLen = 66
+	default:
+		return nil, i.ErrInvalidCurve
+	}
+	mech := pkcs11.NewMechanism(pkcs11.CKM_ECDH1_DERIVE, pkcs11.NewECDH1DeriveParams(pkcs11.CKD_NULL, nil, remote.Bytes()))
+
+	k.store.mu.Lock()
+	defer k.store.mu.Unlock()
+	handle, err := k.store.findObject(k.kid, pkcs11.CKO_PRIVATE_KEY)
+	if err != nil {
+		return nil, err
+	}
+	secretHandle, err := k.store.ctx.DeriveKey(k.store.session, []*pkcs11.Mechanism{mech}, handle, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_SECRET_KEY),
+		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, pkcs11.CKK_GENERIC_SECRET),
+		pkcs11.NewAttribute(pkcs11.CKA_TOKEN, false),
+		pkcs11.NewAttribute(pkcs11.CKA_SENSITIVE, false),
+		pkcs11.NewAttribute(pkcs11.CKA_EXTRACTABLE, true),
+		pkcs11.NewAttribute(pkcs11.CKA_VALUE_LEN, secretLen),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer k.store.ctx.DestroyObject(k.store.session, secretHandle)
+	attrs, err := k.store.ctx.GetAttributeValue(k.store.session, secretHandle, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_VALUE, nil),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return attrs[0].Value, nil
+}
+
+var _ i.CloudSignatureKey = (*pkcs11CloudKey)(nil)
+var _ i.CloudWrappingKey = (*pkcs11CloudKey)(nil)
+var _ i.CloudKeyAgreementKey = (*pkcs11CloudKey)(nil)
@@ -0,0 +1,358 @@
+//go:build cgo
+
+package cloudkeypkcs11
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/asn1"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/pkcs11"
+	i "github.com/stephenzsy/small-kms/backend/cloud/key"
+)
+
+const (
+	keyIDPrefix       = "pkcs11:"
+	keyIDByteLen      = 16
+	defaultRSAKeySize = 2048
+)
+
+var ellipticCurves = map[i.JsonWebKeyCurveName]elliptic.Curve{
+	i.CurveNameP256: elliptic.P256(),
+	i.CurveNameP384: elliptic.P384(),
+	i.CurveNameP521: elliptic.P521(),
+}
+
+var curveOIDs = map[i.JsonWebKeyCurveName]asn1.ObjectIdentifier{
+	i.CurveNameP256: {1, 2, 840, 10045, 3, 1, 7},
+	i.CurveNameP384: {1, 3, 132, 0, 34},
+	i.CurveNameP521: {1, 3, 132, 0, 35},
+}
+
+// pkcs11KeyStore keeps keys on a single token, one logged in session is shared and serialized
+type pkcs11KeyStore struct {
+	ctx     *pkcs11.Ctx
+	session pkcs11.SessionHandle
+	mu      sync.Mutex
+}
+
+// Kind implements cloudkey.KeyStore.
+func (*pkcs11KeyStore) Kind() i.KeyStoreKind {
+	return i.KeyStoreKindPKCS11
+}
+
+// HasKeyID implements cloudkey.KeyStore.
+func (*pkcs11KeyStore) HasKeyID(kid string) bool {
+	return strings.HasPrefix(kid, keyIDPrefix)
+}
+
+// key IDs are pkcs11:<label>/<hex CKA_ID>
+func parseKeyID(kid string) (label string, id []byte, err error) {
+	if !strings.HasPrefix(kid, keyIDPrefix) {
+		return "", nil, fmt.Errorf("%w: %s", ErrKeyNotFound, kid)
+	}
+	label, idHex, ok := strings.Cut(strings.TrimPrefix(kid, keyIDPrefix), "/")
+	if !ok || label == "" {
+		return "", nil, fmt.Errorf("%w: %s", ErrKeyNotFound, kid)
+	}
+	id, err = hex.DecodeString(idHex)
+	if err != nil || len(id) == 0 {
+		return "", nil, fmt.Errorf("%w: %s", ErrKeyNotFound, kid)
+	}
+	return label, id, nil
+}
+
+func hasKeyOperation(ops []i.JsonWebKeyOperation, allowed ...i.JsonWebKeyOperation) bool {
+	if len(ops) == 0 {
+		return true
+	}
+	for _, op := range ops {
+		for _, a := range allowed {
+			if op == a {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func keyGenTemplates(params i.CreateKeyParams, label string, id []byte) (mech *pkcs11.Mechanism, pubAttrs, privAttrs []*pkcs11.Attribute, err error) {
+	ops := i.SanitizeKeyOperations(params.KeyOperations)
+	canSign := hasKeyOperation(ops, i.JsonWebKeyOperationSign)
+	// unwrapping returns the key bytes to the caller, C_Decrypt is used for both
+	canDecrypt := hasKeyOperation(ops, i.JsonWebKeyOperationDecrypt, i.JsonWebKeyOperationUnwrapKey)
+	canDerive := false
+	extractable := params.Exportable != nil && *params.Exportable
+
+	var keyType uint
+	switch params.KeyType {
+	case i.KeyTypeEC:
+		oid, ok := curveOIDs[params.Curve]
+		if !ok {
+			return nil, nil, nil, i.ErrInvalidCurve
+		}
+		ecParams, err := asn1.Marshal(oid)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		keyType = pkcs11.CKK_EC
+		mech = pkcs11.NewMechanism(pkcs11.CKM_EC_KEY_PAIR_GEN, nil)
+		pubAttrs = append(pubAttrs, pkcs11.NewAttribute(pkcs11.CKA_EC_PARAMS, ecParams))
+		// EC keys cannot decrypt, they derive shared secrets instead
+		canDecrypt = false
+		canDerive = hasKeyOperation(ops, i.JsonWebKeyOperationDeriveKey, i.JsonWebKeyOperationDeriveBits)
+	case i.KeyTypeRSA:
+		keySize := params.KeySize
+		switch keySize {
+		case 0:
+			keySize = defaultRSAKeySize
+		case 2048, 3072, 4096:
+		default:
+			return nil, nil, nil, i.ErrInvalidKeySize
+		}
+		keyType = pkcs11.CKK_RSA
+		mech = pkcs11.NewMechanism(pkcs11.CKM_RSA_PKCS_KEY_PAIR_GEN, nil)
+		pubAttrs = append(pubAttrs,
+			pkcs11.NewAttribute(pkcs11.CKA_MODULUS_BITS, keySize),
+			pkcs11.NewAttribute(pkcs11.CKA_PUBLIC_EXPONENT, []byte{1, 0, 1}))
+	default:
+		return nil, nil, nil, i.ErrInvalidKeyType
+	}
+
+	pubAttrs = append(pubAttrs,
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_PUBLIC_KEY),
+		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, keyType),
+		pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, label),
+		pkcs11.NewAttribute(pkcs11.CKA_ID, id),
+		pkcs11.NewAttribute(pkcs11.CKA_VERIFY, canSign),
+		pkcs11.NewAttribute(pkcs11.CKA_ENCRYPT, canDecrypt))
+	privAttrs = []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_PRIVATE_KEY),
+		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, keyType),
+		pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+		pkcs11.NewAttribute(pkcs11.CKA_PRIVATE, true),
+		pkcs11.NewAttribute(pkcs11.CKA_SENSITIVE, true),
+		pkcs11.NewAttribute(pkcs11.CKA_EXTRACTABLE, extractable),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, label),
+		pkcs11.NewAttribute(pkcs11.CKA_ID, id),
+		pkcs11.NewAttribute(pkcs11.CKA_SIGN, canSign),
+		pkcs11.NewAttribute(pkcs11.CKA_DECRYPT, canDecrypt),
+		pkcs11.NewAttribute(pkcs11.CKA_DERIVE, canDerive),
+	}
+	return mech, pubAttrs, privAttrs, nil
+}
+
+// CreateKey implements cloudkey.KeyStore.
+func (s *pkcs11KeyStore) CreateKey(c context.Context, name string, params i.CreateKeyParams) (*i.CreateKeyResult, error) {
+	if name == "" || strings.Contains(name, "/") {
+		return nil, fmt.Errorf("%w: invalid key name %s", i.ErrInvalidKey, name)
+	}
+	id := make([]byte, keyIDByteLen)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	mech, pubAttrs, privAttrs, err := keyGenTemplates(params, name, id)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pubHandle, _, err := s.ctx.GenerateKeyPair(s.session, []*pkcs11.Mechanism{mech}, pubAttrs, privAttrs)
+	if err != nil {
+		return nil, err
+	}
+	publicKey, err := s.readPublicKey(pubHandle, params.KeyType)
+	if err != nil {
+		return nil, err
+	}
+	publicJwk, err := i.NewJsonWebKeyFromPublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	publicJwk.KeyID = keyIDPrefix + name + "/" + hex.EncodeToString(id)
+	publicJwk.KeyOperations = i.SanitizeKeyOperations(params.KeyOperations)
+	return &i.CreateKeyResult{
+		JsonWebKey: *publicJwk,
+		Created:    time.Now().Truncate(time.Second),
+		NotBefore:  params.NotBefore,
+		Expires:    params.Expires,
+		Exportable: params.Exportable,
+	}, nil
+}
+
+// must be called with the session lock held
+func (s *pkcs11KeyStore) findObject(kid string, class uint) (pkcs11.ObjectHandle, error) {
+	label, id, err := parseKeyID(kid)
+	if err != nil {
+		return 0, err
+	}
+	if err := s.ctx.FindObjectsInit(s.session, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, class),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, label),
+		pkcs11.NewAttribute(pkcs11.CKA_ID, id),
+	}); err != nil {
+		return 0, err
+	}
+	handles, _, err := s.ctx.FindObjects(s.session, 1)
+	if finalErr := s.ctx.FindObjectsFinal(s.session); err == nil {
+		err = finalErr
+	}
+	if err != nil {
+		return 0, err
+	}
+	if len(handles) == 0 {
+		return 0, fmt.Errorf("%w: %s", ErrKeyNotFound, kid)
+	}
+	return handles[0], nil
+}
+
+// must be called with the session lock held
+func (s *pkcs11KeyStore) readPublicKey(handle pkcs11.ObjectHandle, keyType i.JsonWebKeyType) (crypto.PublicKey, error) {
+	switch keyType {
+	case i.KeyTypeRSA:
+		attrs, err := s.ctx.GetAttributeValue(s.session, handle, []*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_MODULUS, nil),
+			pkcs11.NewAttribute(pkcs11.CKA_PUBLIC_EXPONENT, nil),
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(attrs[0].Value),
+			E: int(new(big.Int).SetBytes(attrs[1].Value).Int64()),
+		}, nil
+	case i.KeyTypeEC:
+		attrs, err := s.ctx.GetAttributeValue(s.session, handle, []*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_EC_PARAMS, nil),
+			pkcs11.NewAttribute(pkcs11.CKA_EC_POINT, nil),
+		})
+		if err != nil {
+			return nil, err
+		}
+		var oid asn1.ObjectIdentifier
+		if _, err := asn1.Unmarshal(attrs[0].Value, &oid); err != nil {
+			return nil, err
+		}
+		var curve elliptic.Curve
+		for crv, crvOID := range curveOIDs {
+			if crvOID.Equal(oid) {
+				curve = ellipticCurves[crv]
+			}
+		}
+		if curve == nil {
+			return nil, fmt.Errorf("%w: %s", i.ErrInvalidCurve, oid)
+		}
+		// CKA_EC_POINT is the DER octet string of the uncompressed point
+		var point []byte
+		if _, err := asn1.Unmarshal(attrs[1].Value, &point); err != nil {
+			return nil, err
+		}
+		n := (curve.Params().BitSize + 7) / 8
+		if len(point) != 1+2*n || point[0] != 4 {
+			return nil, fmt.Errorf("%w: invalid EC point", i.ErrInvalidKey)
+		}
+		return &ecdsa.PublicKey{
+			Curve: curve,
+			X:     new(big.Int).SetBytes(point[1 : 1+n]),
+			Y:     new(big.Int).SetBytes(point[1+n:]),
+		}, nil
+	}
+	return nil, i.ErrInvalidKeyType
+}
+
+// NewSignatureKey implements cloudkey.KeyStore.
+func (s *pkcs11KeyStore) NewSignatureKey(c context.Context, kid string, jwsa i.JsonWebSignatureAlgorithm, formatX509 bool, publicKey crypto.PublicKey) i.CloudSignatureKey {
+	return &pkcs11CloudKey{
+		store:      s,
+		kid:        kid,
+		jwsa:       jwsa,
+		formatX509: formatX509,
+		publicKey:  publicKey,
+	}
+}
+
+// NewWrappingKey implements cloudkey.KeyStore.
+func (s *pkcs11KeyStore) NewWrappingKey(c context.Context, kid string, keyType i.JsonWebKeyType) i.CloudWrappingKey {
+	return &pkcs11CloudKey{
+		store:   s,
+		kid:     kid,
+		keyType: keyType,
+	}
+}
+
+// NewKeyAgreementKey implements cloudkey.KeyAgreementKeyStore.
+func (s *pkcs11KeyStore) NewKeyAgreementKey(c context.Context, kid string) (i.CloudKeyAgreementKey, error) {
+	return &pkcs11CloudKey{
+		store:   s,
+		kid:     kid,
+		keyType: i.KeyTypeEC,
+	}, nil
+}
+
+var _ i.KeyStore = (*pkcs11KeyStore)(nil)
+var _ i.KeyAgreementKeyStore = (*pkcs11KeyStore)(nil)
+
+func findTokenSlot(ctx *pkcs11.Ctx, tokenLabel string) (uint, error) {
+	slots, err := ctx.GetSlotList(true)
+	if err != nil {
+		return 0, err
+	}
+	for _, slot := range slots {
+		info, err := ctx.GetTokenInfo(slot)
+		if err != nil {
+			return 0, err
+		}
+		if strings.TrimSpace(info.Label) == tokenLabel {
+			return slot, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: %s", ErrTokenNotFound, tokenLabel)
+}
+
+// NewKeyStore loads the PKCS#11 module and logs in to the token with the label as the user
+func NewKeyStore(modulePath, tokenLabel, pin string) (i.KeyStore, error) {
+	ctx := pkcs11.New(modulePath)
+	if ctx == nil {
+		return nil, fmt.Errorf("%w: failed to load module %s", ErrModuleNotLoaded, modulePath)
+	}
+	if err := ctx.Initialize(); err != nil {
+		ctx.Destroy()
+		return nil, err
+	}
+	slot, err := findTokenSlot(ctx, tokenLabel)
+	if err != nil {
+		ctx.Finalize()
+		ctx.Destroy()
+		return nil, err
+	}
+	session, err := ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION|pkcs11.CKF_RW_SESSION)
+	if err != nil {
+		ctx.Finalize()
+		ctx.Destroy()
+		return nil, err
+	}
+	if err := ctx.Login(session, pkcs11.CKU_USER, pin); err != nil {
+		if pkcsErr, ok := err.(pkcs11.Error); !ok || pkcsErr != pkcs11.CKR_USER_ALREADY_LOGGED_IN {
+			ctx.CloseSession(session)
+			ctx.Finalize()
+			ctx.Destroy()
+			return nil, err
+		}
+	}
+	return &pkcs11KeyStore{
+		ctx:     ctx,
+		session: session,
+	}, nil
+}
@@ -0,0 +1,14 @@
+//go:build !cgo
+
+package cloudkeypkcs11
+
+import (
+	"fmt"
+
+	i "github.com/stephenzsy/small-kms/backend/cloud/key"
+)
+
+// NewKeyStore is not available without cgo, the PKCS#11 module is a shared library
+func NewKeyStore(modulePath, tokenLabel, pin string) (i.KeyStore, error) {
+	return nil, fmt.Errorf("%w: built without cgo, cannot load %s", ErrModuleNotLoaded, modulePath)
+}
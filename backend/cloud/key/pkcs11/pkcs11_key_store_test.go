@@ -0,0 +1,88 @@
+//go:build cgo
+
+package cloudkeypkcs11
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"os"
+	"testing"
+	"time"
+
+	i "github.com/stephenzsy/small-kms/backend/cloud/key"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runs against an initialized SoftHSM2 token, e.g.
+// softhsm2-util --init-token --free --label small-kms-test --pin 1234 --so-pin 1234
+func newTestKeyStore(t *testing.T) i.KeyStore {
+	modulePath := os.Getenv("SOFTHSM2_MODULE")
+	if modulePath == "" {
+		t.Skip("SOFTHSM2_MODULE not set")
+	}
+	tokenLabel := os.Getenv("SOFTHSM2_TOKEN_LABEL")
+	if tokenLabel == "" {
+		tokenLabel = "small-kms-test"
+	}
+	pin := os.Getenv("SOFTHSM2_PIN")
+	if pin == "" {
+		pin = "1234"
+	}
+	ks, err := NewKeyStore(modulePath, tokenLabel, pin)
+	require.NoError(t, err)
+	return ks
+}
+
+func TestPKCS11KeyStoreSign(t *testing.T) {
+	c := context.Background()
+	ks := newTestKeyStore(t)
+
+	created, err := ks.CreateKey(c, "ck-root-ca-test-policy", i.CreateKeyParams{
+		KeyType:       i.KeyTypeEC,
+		Curve:         i.CurveNameP384,
+		KeyOperations: []i.JsonWebKeyOperation{i.JsonWebKeyOperationSign, i.JsonWebKeyOperationVerify},
+	})
+	require.NoError(t, err)
+	assert.True(t, ks.HasKeyID(created.KeyID))
+
+	signer := ks.NewSignatureKey(c, created.KeyID, i.SignatureAlgorithmES384, true, nil)
+	template := &x509.Certificate{
+		SerialNumber:       big.NewInt(1),
+		Subject:            pkix.Name{CommonName: "test"},
+		NotBefore:          time.Now(),
+		NotAfter:           time.Now().Add(time.Hour),
+		SignatureAlgorithm: x509.ECDSAWithSHA384,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, signer.Public(), signer)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	require.NoError(t, cert.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature))
+}
+
+func TestPKCS11KeyStoreUnwrap(t *testing.T) {
+	c := context.Background()
+	ks := newTestKeyStore(t)
+
+	created, err := ks.CreateKey(c, "k-profile-default-wrap", i.CreateKeyParams{
+		KeyType:       i.KeyTypeRSA,
+		KeySize:       2048,
+		KeyOperations: []i.JsonWebKeyOperation{i.JsonWebKeyOperationWrapKey, i.JsonWebKeyOperationUnwrapKey},
+	})
+	require.NoError(t, err)
+
+	cek := bytes.Repeat([]byte{3}, 32)
+	wrapped, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, created.PublicKey().(*rsa.PublicKey), cek, nil)
+	require.NoError(t, err)
+	unwrapped, err := ks.NewWrappingKey(c, created.KeyID, i.KeyTypeRSA).Decrypt(nil, wrapped, &rsa.OAEPOptions{Hash: crypto.SHA256})
+	require.NoError(t, err)
+	assert.Equal(t, cek, unwrapped)
+}
@@ -2,6 +2,7 @@ package cloudkeyx
 
 import (
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/rand"
 	"crypto/rsa"
 	"fmt"
@@ -26,6 +27,10 @@ func (m *cloudKeySigningMethod) Sign(signingString string, key interface{}) ([]b
 	if !ok {
 		return nil, fmt.Errorf("%w: %T", jwt.ErrInvalidKeyType, key)
 	}
+	if m.alg == i.SignatureAlgorithmEdDSA {
+		// Ed25519 signs the message without prehashing
+		return cloudKey.Sign(rand.Reader, []byte(signingString), m.alg)
+	}
 	hashFn := m.alg.HashFunc()
 	if !hashFn.Available() {
 		return nil, jwt.ErrHashUnavailable
@@ -51,6 +56,16 @@ func verifyEcdsaJWTSignature(pubKey *ecdsa.PublicKey, digest, signature []byte,
 
 // This method does not support symmetric signing. use builtin JWT signing method instead.
 func (m *cloudKeySigningMethod) Verify(signingString string, signature []byte, key interface{}) error {
+	if m.alg == i.SignatureAlgorithmEdDSA {
+		pubKey, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return jwt.ErrInvalidKeyType
+		}
+		if !ed25519.Verify(pubKey, []byte(signingString), signature) {
+			return jwt.ErrEd25519Verification
+		}
+		return nil
+	}
 	hashFn := m.alg.HashFunc()
 	if !hashFn.Available() {
 		return jwt.ErrHashUnavailable
@@ -1,6 +1,6 @@
 module github.com/stephenzsy/small-kms/backend
 
-go 1.21
+go 1.24
 
 require (
 	github.com/Azure/azure-sdk-for-go/sdk/azcore v1.9.1
@@ -19,10 +19,13 @@ require (
 	github.com/labstack/echo/v4 v4.11.4
 	github.com/microsoft/go-crypto-winnative v0.0.0-20240117203030-9b0a87ea7b79
 	github.com/microsoftgraph/msgraph-sdk-go v1.30.0
+	github.com/miekg/dns v1.1.57
+	github.com/miekg/pkcs11 v1.1.1
 	github.com/oapi-codegen/runtime v1.1.1
 	github.com/rs/zerolog v1.31.0
 	github.com/stretchr/testify v1.8.4
 	github.com/urfave/cli/v2 v2.25.7
+	go.etcd.io/bbolt v1.3.8
 	golang.org/x/crypto v0.18.0
 	golang.org/x/net v0.20.0
 	gotest.tools/v3 v3.5.1
@@ -0,0 +1,29 @@
+package auth
+
+import (
+	"github.com/labstack/echo/v4"
+	ctx "github.com/stephenzsy/small-kms/backend/internal/context"
+)
+
+var anonymousIdentity = authIdentity{
+	msClientPrincipalName: "anonymous",
+	appRoles:              map[string]bool{},
+}
+
+// AllowAnonymous wraps the authentication middleware, requests routed to one of the route paths
+// skip authentication and are served with an anonymous identity without any role
+func AllowAnonymous(authMiddleware echo.MiddlewareFunc, routePaths ...string) echo.MiddlewareFunc {
+	anonymousRoutes := make(map[string]bool, len(routePaths))
+	for _, p := range routePaths {
+		anonymousRoutes[p] = true
+	}
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		authenticated := authMiddleware(next)
+		return func(c echo.Context) error {
+			if anonymousRoutes[c.Path()] {
+				return next(ctx.EchoContextWithValue(c, authIdentityContextKey, &anonymousIdentity, false))
+			}
+			return authenticated(c)
+		}
+	}
+}
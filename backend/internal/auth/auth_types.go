@@ -80,8 +80,17 @@ func (a *authIdentity) HasAdminRole() bool {
 	return a.appRoles[roleValueAppAdmin]
 }
 
+// background tasks act as the service itself
+var systemIdentity = authIdentity{
+	msClientPrincipalName: "system",
+	appRoles:              map[string]bool{},
+}
+
 func GetAuthIdentity(c context.Context) AuthIdentity {
-	return c.Value(authIdentityContextKey).(AuthIdentity)
+	if identity, ok := c.Value(authIdentityContextKey).(AuthIdentity); ok {
+		return identity
+	}
+	return &systemIdentity
 }
 
 func (a *authIdentity) ClientPrincipalDisplayName() string {
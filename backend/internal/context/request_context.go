@@ -2,6 +2,8 @@ package ctx
 
 import (
 	"context"
+	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/labstack/echo/v4"
@@ -140,3 +142,27 @@ func EchoContextWithValue(c echo.Context, key any, value any, isServiceContext b
 	}
 	return c
 }
+
+// NewBackgroundRequestContext creates an elevated request context for tasks running outside of a request
+func NewBackgroundRequestContext(c context.Context, serviceCtx context.Context) RequestContext {
+	req := (&http.Request{
+		Method: http.MethodGet,
+		URL:    &url.URL{Path: "/"},
+		Header: http.Header{},
+	}).WithContext(c)
+	return NewInjectedRequestContext(echo.New().NewContext(req, nil), serviceCtx).Elevate()
+}
+
+// GetRequestID returns the ID assigned by the request ID middleware, empty for background tasks
+func GetRequestID(c context.Context) string {
+	reqCtx, ok := c.Value(rKey).(*requestContext)
+	if !ok || reqCtx == nil {
+		return ""
+	}
+	if resp := reqCtx.Response(); resp != nil && resp.Writer != nil {
+		if requestID := resp.Header().Get(echo.HeaderXRequestID); requestID != "" {
+			return requestID
+		}
+	}
+	return reqCtx.Request().Header.Get(echo.HeaderXRequestID)
+}
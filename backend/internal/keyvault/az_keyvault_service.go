@@ -7,6 +7,7 @@ import (
 	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azcertificates"
 	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azkeys"
 	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azsecrets"
+	cloudkey "github.com/stephenzsy/small-kms/backend/cloud/key"
 	"github.com/stephenzsy/small-kms/backend/models"
 )
 
@@ -17,6 +18,9 @@ const (
 	MaterialNameKindKey            MaterialNameKind = "k"
 	MaterialNameKindSecret         MaterialNameKind = "s"
 	MaterialNameKindCertificateKey MaterialNameKind = "ck"
+	MaterialNameKindOCSPKey        MaterialNameKind = "ok"
+	MaterialNameKindSSHCAKey       MaterialNameKind = "sk"
+	MaterialNameKindTSAKey         MaterialNameKind = "tk"
 )
 
 type AzKeyVaultService interface {
@@ -30,6 +34,7 @@ type internalContextKey int
 const (
 	AzKeyVaultServiceContextKey internalContextKey = iota
 	delegatedAzSecretsClientContextKey
+	CloudKeyStoreContextKey
 )
 
 func GetAzKeyVaultService(c context.Context) AzKeyVaultService {
@@ -39,6 +44,14 @@ func GetAzKeyVaultService(c context.Context) AzKeyVaultService {
 	return nil
 }
 
+// GetCloudKeyStore returns the store which holds the private keys of certificates and keys
+func GetCloudKeyStore(c context.Context) cloudkey.KeyStore {
+	if s, ok := c.Value(CloudKeyStoreContextKey).(cloudkey.KeyStore); ok {
+		return s
+	}
+	return nil
+}
+
 func GetMaterialName(
 	kind MaterialNameKind,
 	nsProvider models.NamespaceProvider, nsID string, policyID string) string {
@@ -0,0 +1,36 @@
+package netguard
+
+import (
+	"fmt"
+	"net/netip"
+	"slices"
+	"syscall"
+)
+
+// shared address space of carrier-grade NAT, RFC 6598, not covered by netip.Addr.IsPrivate
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// IsPublicAddr reports whether the address is a global unicast address outside of the private and shared address spaces
+func IsPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsGlobalUnicast() && !addr.IsPrivate() && !sharedAddressSpace.Contains(addr)
+}
+
+// PublicAddressControl returns a net.Dialer Control which rejects connections to addresses other than public addresses,
+// and to ports other than the allowed ports if any are given.
+// It runs on the resolved address right before connecting, so a DNS name cannot point the connection to the internal network
+func PublicAddressControl(allowedPorts ...uint16) func(network string, address string, c syscall.RawConn) error {
+	return func(network string, address string, _ syscall.RawConn) error {
+		addrPort, err := netip.ParseAddrPort(address)
+		if err != nil {
+			return err
+		}
+		if len(allowedPorts) > 0 && !slices.Contains(allowedPorts, addrPort.Port()) {
+			return fmt.Errorf("port %d is not allowed", addrPort.Port())
+		}
+		if !IsPublicAddr(addrPort.Addr()) {
+			return fmt.Errorf("address %s is not allowed", addrPort.Addr().Unmap())
+		}
+		return nil
+	}
+}
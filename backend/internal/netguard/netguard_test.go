@@ -0,0 +1,32 @@
+package netguard
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublicAddressControl(t *testing.T) {
+	control := PublicAddressControl()
+	assert.NoError(t, control("tcp4", "93.184.216.34:8443", nil))
+	assert.NoError(t, control("tcp6", "[2606:2800:220:1::]:443", nil))
+	for _, rejected := range []string{
+		"127.0.0.1:443",
+		"10.0.0.1:443",
+		"172.16.0.1:443",
+		"192.168.1.1:443",
+		"100.64.0.1:443",
+		"169.254.169.254:80",
+		"0.0.0.0:443",
+		"[::1]:443",
+		"[fd00::1]:443",
+		"[fe80::1]:443",
+		"[::ffff:127.0.0.1]:443",
+	} {
+		assert.Error(t, control("tcp", rejected, nil), rejected)
+	}
+
+	control = PublicAddressControl(80, 443)
+	assert.NoError(t, control("tcp4", "93.184.216.34:443", nil))
+	assert.Error(t, control("tcp4", "93.184.216.34:8443", nil))
+}
@@ -0,0 +1,195 @@
+package key
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stephenzsy/small-kms/backend/base"
+	cloudkey "github.com/stephenzsy/small-kms/backend/cloud/key"
+	ctx "github.com/stephenzsy/small-kms/backend/internal/context"
+	kv "github.com/stephenzsy/small-kms/backend/internal/keyvault"
+	"github.com/stephenzsy/small-kms/backend/models"
+	keymodels "github.com/stephenzsy/small-kms/backend/models/key"
+)
+
+const dataKeySize = 32
+
+// RSA keys wrap the data key with RSA-OAEP-256, EC and X25519 keys derive the wrapping key with ECDH-ES
+func dataKeyOperation(keyType cloudkey.JsonWebKeyType, unwrap bool) (cloudkey.JsonWebKeyOperation, error) {
+	switch keyType {
+	case cloudkey.KeyTypeRSA:
+		if unwrap {
+			return cloudkey.JsonWebKeyOperationUnwrapKey, nil
+		}
+		return cloudkey.JsonWebKeyOperationWrapKey, nil
+	case cloudkey.KeyTypeEC, cloudkey.KeyTypeOKP:
+		return cloudkey.JsonWebKeyOperationDeriveKey, nil
+	}
+	return "", fmt.Errorf("%w: %w: %s", base.ErrResponseStatusBadRequest, cloudkey.ErrInvalidKeyType, keyType)
+}
+
+func getKeyForDataKey(c ctx.RequestContext, namespaceProvider models.NamespaceProvider, namespaceId string, id string,
+	unwrap bool) (ctx.RequestContext, *KeyDoc, error) {
+	doc, err := getKeyAuthorized(c, namespaceProvider, namespaceId, id)
+	if err != nil {
+		return c, nil, err
+	}
+	op, err := dataKeyOperation(doc.KeyType, unwrap)
+	if err != nil {
+		return c, nil, err
+	}
+	if err := doc.checkOperation(time.Now(), op); err != nil {
+		return c, nil, err
+	}
+	// deriveKey is used by both sides of ECDH-ES, new data keys are only wrapped with the active version
+	if !unwrap && doc.Status != keymodels.KeyStatusActive {
+		return c, nil, fmt.Errorf("%w: key is not active", base.ErrResponseStatusBadRequest)
+	}
+	c = c.Elevate()
+	// a data key is not handed out if the key store could not unwrap it later
+	if op == cloudkey.JsonWebKeyOperationDeriveKey {
+		if _, err := cloudkey.NewKeyAgreementKey(c, kv.GetCloudKeyStore(c), doc.KeyID); err != nil {
+			return c, nil, fmt.Errorf("%w: %w", base.ErrResponseStatusBadRequest, err)
+		}
+	}
+	return c, doc, nil
+}
+
+// the encryption context is bound as JWE additional authenticated data, json.Marshal sorts the map keys
+func encryptionContextAAD(encryptionContext keymodels.EncryptionContext) ([]byte, error) {
+	if len(encryptionContext) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(encryptionContext)
+}
+
+// GenerateDataKey implements admin.ServerInterface.
+func (s *KeyAdminServer) GenerateDataKey(ec echo.Context, namespaceProvider models.NamespaceProvider, namespaceId string, id string) error {
+	c := ec.(ctx.RequestContext)
+	req := new(keymodels.GenerateDataKeyRequest)
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+
+	c, doc, err := getKeyForDataKey(c, namespaceProvider, namespaceId, id, false)
+	if err != nil {
+		return err
+	}
+	aad, err := encryptionContextAAD(req.EncryptionContext)
+	if err != nil {
+		return err
+	}
+
+	builder := cloudkey.JWEAes256GcmEncBuilder{}
+	builder.AdditionalAuthenticatedData = aad
+	switch publicKey := doc.PublicKey().(type) {
+	case *rsa.PublicKey:
+		if err := builder.SetRsaOaep256KeyWrap(publicKey); err != nil {
+			return err
+		}
+	case *ecdsa.PublicKey:
+		ephemeralKey, err := s.cryptoProvider.GenerateECDSAKeyPair(publicKey.Curve)
+		if err != nil {
+			return err
+		}
+		epk, err := cloudkey.NewJsonWebKeyFromPublicKey(&ephemeralKey.PublicKey)
+		if err != nil {
+			return err
+		}
+		epk.D = ephemeralKey.D.Bytes()
+		if err := builder.SetEcdhEsKeyAgreement(epk, &doc.JsonWebKey); err != nil {
+			return err
+		}
+	case *ecdh.PublicKey:
+		ephemeralKey, err := publicKey.Curve().GenerateKey(rand.Reader)
+		if err != nil {
+			return err
+		}
+		epk, err := cloudkey.NewJsonWebKeyFromPublicKey(ephemeralKey.PublicKey())
+		if err != nil {
+			return err
+		}
+		epk.D = ephemeralKey.Bytes()
+		if err := builder.SetEcdhEsKeyAgreement(epk, &doc.JsonWebKey); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("%w: %w: %T", base.ErrResponseStatusBadRequest, cloudkey.ErrInvalidKeyType, publicKey)
+	}
+	builder.Protected.KeyID = doc.KeyID
+
+	dataKey := make([]byte, dataKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return err
+	}
+	ciphertext, err := builder.Seal(dataKey)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, &keymodels.DataKey{
+		Kid:        doc.KeyID,
+		Plaintext:  dataKey,
+		Ciphertext: ciphertext,
+	})
+}
+
+// DecryptDataKey implements admin.ServerInterface.
+func (*KeyAdminServer) DecryptDataKey(ec echo.Context, namespaceProvider models.NamespaceProvider, namespaceId string, id string) error {
+	c := ec.(ctx.RequestContext)
+	req := new(keymodels.DecryptDataKeyRequest)
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+
+	c, doc, err := getKeyForDataKey(c, namespaceProvider, namespaceId, id, true)
+	if err != nil {
+		return err
+	}
+	jwe, err := cloudkey.NewJsonWebEncryption(req.Ciphertext)
+	if err != nil {
+		return fmt.Errorf("%w: invalid ciphertext: %w", base.ErrResponseStatusBadRequest, err)
+	}
+	if jwe.Protected.KeyID != doc.KeyID {
+		return fmt.Errorf("%w: data key was not wrapped with this key", base.ErrResponseStatusBadRequest)
+	}
+	if jwe.AdditionalAuthenticatedData, err = encryptionContextAAD(req.EncryptionContext); err != nil {
+		return err
+	}
+
+	keyStore := kv.GetCloudKeyStore(c)
+	dataKey, _, err := jwe.Decrypt(func(header *cloudkey.JoseHeader) (crypto.PrivateKey, error) {
+		switch header.Algorithm {
+		case cloudkey.JwkEncAlgRsaOeap256:
+			if doc.KeyType != cloudkey.KeyTypeRSA {
+				break
+			}
+			return keyStore.NewWrappingKey(c, doc.KeyID, doc.KeyType), nil
+		case cloudkey.JwkEncAlgEcdhEs:
+			if doc.KeyType != cloudkey.KeyTypeEC && doc.KeyType != cloudkey.KeyTypeOKP {
+				break
+			}
+			return cloudkey.NewKeyAgreementKey(c, keyStore, doc.KeyID)
+		}
+		return nil, fmt.Errorf("%w: %s", cloudkey.ErrInvalidAlgorithm, header.Algorithm)
+	})
+	if err != nil {
+		if errors.Is(err, cloudkey.ErrKeyAgreementNotSupported) || errors.Is(err, cloudkey.ErrInvalidAlgorithm) {
+			return fmt.Errorf("%w: %w", base.ErrResponseStatusBadRequest, err)
+		}
+		// the encryption context does not match or the ciphertext has been tampered with
+		return fmt.Errorf("%w: failed to decrypt data key", base.ErrResponseStatusBadRequest)
+	}
+	return c.JSON(http.StatusOK, &keymodels.DataKey{
+		Kid:       doc.KeyID,
+		Plaintext: dataKey,
+	})
+}
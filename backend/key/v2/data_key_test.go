@@ -0,0 +1,144 @@
+package key
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stephenzsy/small-kms/backend/base"
+	cloudkey "github.com/stephenzsy/small-kms/backend/cloud/key"
+	cloudkeyaz "github.com/stephenzsy/small-kms/backend/cloud/key/az"
+	cloudkeylocal "github.com/stephenzsy/small-kms/backend/cloud/key/local"
+	ctx "github.com/stephenzsy/small-kms/backend/internal/context"
+	"github.com/stephenzsy/small-kms/backend/internal/cryptoprovider"
+	kv "github.com/stephenzsy/small-kms/backend/internal/keyvault"
+	"github.com/stephenzsy/small-kms/backend/models"
+	keymodels "github.com/stephenzsy/small-kms/backend/models/key"
+	"github.com/stephenzsy/small-kms/backend/resdoc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// the background identity has the nil principal ID, so it is authorized for its own namespace
+var dataKeyTestNamespaceID = uuid.Nil.String()
+
+// newDataKeyTestContext serves the embedded doc service and a key store set with Key Vault as the default store,
+// Key Vault is never called as it cannot do key agreement
+func newDataKeyTestContext(t *testing.T) context.Context {
+	docSvc, err := resdoc.NewEmbeddedDocService(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	masterKey := make([]byte, 32)
+	_, err = rand.Read(masterKey)
+	require.NoError(t, err)
+	localKeyStore, err := cloudkeylocal.NewKeyStore(t.TempDir(), masterKey)
+	require.NoError(t, err)
+	serviceCtx := context.WithValue(context.Background(), resdoc.DocServiceContextKey, docSvc)
+	return context.WithValue(serviceCtx, kv.CloudKeyStoreContextKey,
+		cloudkey.NewKeyStoreSet(cloudkeyaz.NewKeyStore(nil, nil), localKeyStore))
+}
+
+func callDataKeyHandler(t *testing.T, serviceCtx context.Context, req any,
+	handler func(c echo.Context) error) (*keymodels.DataKey, error) {
+	body, err := json.Marshal(req)
+	require.NoError(t, err)
+	httpReq := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(string(body)))
+	httpReq.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	if err := handler(ctx.NewInjectedRequestContext(echo.New().NewContext(httpReq, rec), serviceCtx)); err != nil {
+		return nil, err
+	}
+	require.Equal(t, http.StatusOK, rec.Code)
+	dataKey := &keymodels.DataKey{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), dataKey))
+	return dataKey, nil
+}
+
+func TestDataKeyKeyAgreementKeyStore(t *testing.T) {
+	serviceCtx := newDataKeyTestContext(t)
+	c := ctx.NewBackgroundRequestContext(context.Background(), serviceCtx)
+
+	newPolicy := func(keyStore cloudkey.KeyStoreKind) (*KeyPolicyDoc, error) {
+		policy := &KeyPolicyDoc{
+			ResourceDoc: resdoc.ResourceDoc{
+				PartitionKey: resdoc.PartitionKey{
+					NamespaceProvider: models.NamespaceProviderServicePrincipal,
+					NamespaceID:       dataKeyTestNamespaceID,
+					ResourceProvider:  models.ResourceProviderKeyPolicy,
+				},
+				ID: "data-key",
+			},
+		}
+		return policy, policy.init(c, &keymodels.CreateKeyPolicyRequest{
+			KeySpec: &keymodels.JsonWebKeySpec{
+				Kty:           cloudkey.KeyTypeEC,
+				Crv:           cloudkey.CurveNameP256,
+				KeyOperations: []cloudkey.JsonWebKeyOperation{cloudkey.JsonWebKeyOperationDeriveKey},
+				KeyStore:      keyStore,
+			},
+		})
+	}
+
+	// Key Vault is the default store
+	_, err := newPolicy("")
+	assert.ErrorIs(t, err, base.ErrResponseStatusBadRequest)
+	assert.ErrorIs(t, err, cloudkey.ErrKeyAgreementNotSupported)
+	policy, err := newPolicy(cloudkey.KeyStoreKindLocal)
+	require.NoError(t, err)
+	doc, _, err := generateKeyInternal(c, models.NamespaceProviderServicePrincipal, dataKeyTestNamespaceID, policy)
+	require.NoError(t, err)
+
+	s := &KeyAdminServer{}
+	s.cryptoProvider, err = cryptoprovider.NewCryptoProvider()
+	require.NoError(t, err)
+	generate := func(keyID string, encryptionContext keymodels.EncryptionContext) (*keymodels.DataKey, error) {
+		return callDataKeyHandler(t, serviceCtx, &keymodels.GenerateDataKeyRequest{EncryptionContext: encryptionContext},
+			func(c echo.Context) error {
+				return s.GenerateDataKey(c, models.NamespaceProviderServicePrincipal, dataKeyTestNamespaceID, keyID)
+			})
+	}
+	decrypt := func(keyID string, ciphertext string, encryptionContext keymodels.EncryptionContext) (*keymodels.DataKey, error) {
+		return callDataKeyHandler(t, serviceCtx, &keymodels.DecryptDataKeyRequest{Ciphertext: ciphertext, EncryptionContext: encryptionContext},
+			func(c echo.Context) error {
+				return s.DecryptDataKey(c, models.NamespaceProviderServicePrincipal, dataKeyTestNamespaceID, keyID)
+			})
+	}
+
+	encryptionContext := keymodels.EncryptionContext{"tenant": "a", "purpose": "backup"}
+	generated, err := generate(doc.ID, encryptionContext)
+	require.NoError(t, err)
+	assert.Equal(t, doc.KeyID, generated.Kid)
+	assert.Len(t, generated.Plaintext, dataKeySize)
+
+	decrypted, err := decrypt(doc.ID, generated.Ciphertext, keymodels.EncryptionContext{"purpose": "backup", "tenant": "a"})
+	require.NoError(t, err)
+	assert.Equal(t, generated.Plaintext, decrypted.Plaintext)
+
+	_, err = decrypt(doc.ID, generated.Ciphertext, keymodels.EncryptionContext{"tenant": "b", "purpose": "backup"})
+	assert.ErrorIs(t, err, base.ErrResponseStatusBadRequest)
+	_, err = decrypt(doc.ID, generated.Ciphertext, nil)
+	assert.ErrorIs(t, err, base.ErrResponseStatusBadRequest)
+
+	// a key version kept in Key Vault, saved before key agreement was checked at policy save
+	kvDoc := &KeyDoc{
+		ResourceDoc: resdoc.ResourceDoc{
+			PartitionKey: doc.PartitionKey,
+			ID:           "keyvault",
+		},
+		Status: keymodels.KeyStatusActive,
+		Policy: policy.Identifier(),
+	}
+	kvDoc.JsonWebKey = doc.JsonWebKey
+	kvDoc.KeyID = "https://test.vault.azure.net/keys/data-key/1"
+	_, err = resdoc.GetDocService(c).Create(c, kvDoc, nil)
+	require.NoError(t, err)
+	_, err = generate(kvDoc.ID, encryptionContext)
+	assert.ErrorIs(t, err, base.ErrResponseStatusBadRequest)
+	assert.ErrorIs(t, err, cloudkey.ErrKeyAgreementNotSupported)
+}
@@ -1,15 +1,21 @@
 package key
 
 import (
+	"fmt"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/labstack/echo/v4"
 	"github.com/stephenzsy/small-kms/backend/base"
+	cloudkey "github.com/stephenzsy/small-kms/backend/cloud/key"
 	"github.com/stephenzsy/small-kms/backend/internal/authz"
 	ctx "github.com/stephenzsy/small-kms/backend/internal/context"
 	kv "github.com/stephenzsy/small-kms/backend/internal/keyvault"
 	"github.com/stephenzsy/small-kms/backend/models"
 	keymodels "github.com/stephenzsy/small-kms/backend/models/key"
+	webhookmodels "github.com/stephenzsy/small-kms/backend/models/webhook"
 	"github.com/stephenzsy/small-kms/backend/resdoc"
+	"github.com/stephenzsy/small-kms/backend/webhook"
 )
 
 // GenerateKey implements admin.ServerInterface.
@@ -25,6 +31,24 @@ func (*KeyAdminServer) GenerateKey(ec echo.Context, namespaceProvider models.Nam
 		return err
 	}
 
+	doc, resp, err := generateKeyInternal(c, namespaceProvider, namespaceId, policy)
+	if err != nil {
+		return err
+	}
+	return c.JSON(resp.RawResponse.StatusCode, doc.ToModel(true))
+}
+
+// generateKeyInternal creates a new key version of the policy, and schedules its rotation if the policy rotates keys
+func generateKeyInternal(c ctx.RequestContext, namespaceProvider models.NamespaceProvider, namespaceId string, policy *KeyPolicyDoc) (*KeyDoc, azcosmos.ItemResponse, error) {
+	return createKeyVersionInternal(c, namespaceProvider, namespaceId, policy, keymodels.KeyOriginGenerated,
+		func(c ctx.RequestContext, keyStore cloudkey.KeyStore, doc *keyGenerateDoc) (*cloudkey.CreateKeyResult, error) {
+			return keyStore.CreateKey(c, doc.keyVaultStoreName, doc.getCreateKeyParams())
+		})
+}
+
+// createKeyVersionInternal creates the key in the key store of the policy with createKey, then records the key document
+func createKeyVersionInternal(c ctx.RequestContext, namespaceProvider models.NamespaceProvider, namespaceId string, policy *KeyPolicyDoc, origin keymodels.KeyOrigin,
+	createKey func(c ctx.RequestContext, keyStore cloudkey.KeyStore, doc *keyGenerateDoc) (*cloudkey.CreateKeyResult, error)) (*KeyDoc, azcosmos.ItemResponse, error) {
 	doc := &keyGenerateDoc{
 		KeyDoc: KeyDoc{
 			ResourceDoc: resdoc.ResourceDoc{
@@ -36,38 +60,46 @@ func (*KeyAdminServer) GenerateKey(ec echo.Context, namespaceProvider models.Nam
 			},
 		},
 	}
-	err = doc.init(namespaceProvider, namespaceId, policy)
+	var resp azcosmos.ItemResponse
+	err := doc.init(namespaceProvider, namespaceId, policy)
 	if err != nil {
-		return err
+		return nil, resp, err
 	}
-	params, err := doc.getAzCreateKeyParams()
+	keyStore, err := cloudkey.SelectKeyStore(kv.GetCloudKeyStore(c), doc.keyStore)
 	if err != nil {
-		return err
+		return nil, resp, fmt.Errorf("%w: %w", base.ErrResponseStatusBadRequest, err)
 	}
 	c = c.Elevate()
-	result, err := kv.GetAzKeyVaultService(c).AzKeysClient().CreateKey(c, doc.keyVaultStoreName, params, nil)
+	result, err := createKey(c, keyStore, doc)
 	if err != nil {
-		return err
+		return nil, resp, err
 	}
-	doc.KeyID = string(*result.Key.KID)
-	doc.Created.Time = *result.Attributes.Created
-	if result.Attributes.NotBefore != nil {
-		doc.NotBefore = jwt.NewNumericDate(*result.Attributes.NotBefore)
+	doc.KeyID = result.KeyID
+	doc.Created.Time = result.Created
+	if result.NotBefore != nil {
+		doc.NotBefore = jwt.NewNumericDate(*result.NotBefore)
 	}
-	if result.Attributes.Expires != nil {
-		doc.NotAfter = jwt.NewNumericDate(*result.Attributes.Expires)
+	if result.Expires != nil {
+		doc.NotAfter = jwt.NewNumericDate(*result.Expires)
 	}
-	doc.N = result.Key.N
-	doc.E = result.Key.E
-	doc.X = result.Key.X
-	doc.Y = result.Key.Y
-	doc.Extractable = result.Attributes.Exportable
+	doc.N = result.N
+	doc.E = result.E
+	doc.X = result.X
+	doc.Y = result.Y
+	doc.Extractable = result.Exportable
 	doc.Status = keymodels.KeyStatusActive
+	doc.Origin = origin
 
 	doc.Checksum = doc.calculateChecksum()
-	resp, err := resdoc.GetDocService(c).Create(c, doc, nil)
+	resp, err = resdoc.GetDocService(c).Create(c, doc, nil)
 	if err != nil {
-		return err
+		return nil, resp, err
 	}
-	return c.JSON(resp.RawResponse.StatusCode, doc.ToModel(true))
+	if policy.Rotation != nil {
+		if err := registerKeyRotationInternal(c, &doc.KeyDoc, policy.Rotation); err != nil {
+			return nil, resp, err
+		}
+	}
+	webhook.Publish(c, namespaceProvider, namespaceId, webhookmodels.EventTypeKeyCreated, "keys/"+doc.ID, doc.ToModel(false))
+	return &doc.KeyDoc, resp, nil
 }
@@ -0,0 +1,98 @@
+package key
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
+	"github.com/labstack/echo/v4"
+	cloudkey "github.com/stephenzsy/small-kms/backend/cloud/key"
+	ctx "github.com/stephenzsy/small-kms/backend/internal/context"
+	"github.com/stephenzsy/small-kms/backend/models"
+	keymodels "github.com/stephenzsy/small-kms/backend/models/key"
+	"github.com/stephenzsy/small-kms/backend/resdoc"
+	"github.com/stephenzsy/small-kms/backend/utils"
+)
+
+// relying parties pick up rotated keys within this time
+const jwksCacheControl = "public, max-age=300"
+
+// listPublishedKeysInternal returns the public keys of the active and the inactive versions kept by rotation, latest first,
+// retired and expired keys are not published, neither are oct keys which have nothing public
+func listPublishedKeysInternal(c context.Context, namespaceProvider models.NamespaceProvider, namespaceId string, policy *resdoc.DocIdentifier) ([]cloudkey.JsonWebKey, error) {
+	qb := resdoc.NewDefaultCosmoQueryBuilder().
+		WithExtraColumns("c.status", "c.iat", "c.exp",
+			"c.kty", "c.alg", "c.kid", "c.crv", "c.n", "c.e", "c.x", "c.y", "c.key_ops").
+		WithWhereClauses("c.status = 'active' OR c.status = 'inactive'").
+		WithWhereClauses("c.kty != 'oct'").
+		WithWhereClauses("NOT IS_DEFINED(c.deleted) OR IS_NULL(c.deleted)").
+		WithWhereClauses("NOT IS_DEFINED(c.exp) OR c.exp > (GetCurrentTimestamp() / 1000)").
+		WithOrderBy("c.iat DESC")
+	if policy != nil {
+		qb.WithWhereClauses("c.policy = @policy")
+		qb.Parameters = append(qb.Parameters, azcosmos.QueryParameter{Name: "@policy", Value: policy.String()})
+	}
+	pager := resdoc.NewQueryDocPager[*KeyDoc](c, qb, resdoc.PartitionKey{
+		NamespaceProvider: namespaceProvider,
+		NamespaceID:       namespaceId,
+		ResourceProvider:  models.ResourceProviderKey,
+	})
+	return utils.PagerToSlice(utils.NewMappedItemsPager(pager, func(doc *KeyDoc) cloudkey.JsonWebKey {
+		return *doc.JsonWebKey.PublicJWK()
+	}))
+}
+
+// respondJwks sets the cache headers, the ETag is the digest of the key set so relying parties can revalidate
+func respondJwks(c echo.Context, keys []cloudkey.JsonWebKey) error {
+	if keys == nil {
+		keys = []cloudkey.JsonWebKey{}
+	}
+	body, err := json.Marshal(&keymodels.JsonWebKeySet{Keys: keys})
+	if err != nil {
+		return err
+	}
+	digest := sha256.Sum256(body)
+	etag := `"` + base64.RawURLEncoding.EncodeToString(digest[:16]) + `"`
+
+	header := c.Response().Header()
+	header.Set(echo.HeaderCacheControl, jwksCacheControl)
+	header.Set("ETag", etag)
+	if c.Request().Header.Get("If-None-Match") == etag {
+		return c.NoContent(http.StatusNotModified)
+	}
+	return c.JSONBlob(http.StatusOK, body)
+}
+
+// GetNamespaceJwks implements admin.ServerInterface.
+func (*KeyAdminServer) GetNamespaceJwks(ec echo.Context, namespaceProvider models.NamespaceProvider, namespaceId string) error {
+	c := ec.(ctx.RequestContext)
+
+	// anonymous endpoint
+	c = c.Elevate()
+	keys, err := listPublishedKeysInternal(c, namespaceProvider, namespaceId, nil)
+	if err != nil {
+		return err
+	}
+	return respondJwks(c, keys)
+}
+
+// GetKeyPolicyJwks implements admin.ServerInterface.
+func (*KeyAdminServer) GetKeyPolicyJwks(ec echo.Context, namespaceProvider models.NamespaceProvider, namespaceId string, id string) error {
+	c := ec.(ctx.RequestContext)
+
+	// anonymous endpoint
+	c = c.Elevate()
+	policy, err := GetKeyPolicyInternal(c, namespaceProvider, namespaceId, id)
+	if err != nil {
+		return err
+	}
+	policyIdentifier := policy.Identifier()
+	keys, err := listPublishedKeysInternal(c, namespaceProvider, namespaceId, &policyIdentifier)
+	if err != nil {
+		return err
+	}
+	return respondJwks(c, keys)
+}
@@ -0,0 +1,99 @@
+package key
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+	cloudkey "github.com/stephenzsy/small-kms/backend/cloud/key"
+	"github.com/stephenzsy/small-kms/backend/models"
+	keymodels "github.com/stephenzsy/small-kms/backend/models/key"
+	"github.com/stephenzsy/small-kms/backend/resdoc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListPublishedKeys(t *testing.T) {
+	docSvc, err := resdoc.NewEmbeddedDocService(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	c := context.WithValue(context.Background(), resdoc.DocServiceContextKey, docSvc)
+
+	policy := resdoc.NewDocIdentifier(models.NamespaceProviderServicePrincipal, "ns", models.ResourceProviderKeyPolicy, "jwt")
+	otherPolicy := resdoc.NewDocIdentifier(models.NamespaceProviderServicePrincipal, "ns", models.ResourceProviderKeyPolicy, "other")
+	now := time.Now()
+	newKeyDoc := func(id string, policy resdoc.DocIdentifier, status keymodels.KeyStatus, created time.Time) *KeyDoc {
+		doc := &KeyDoc{
+			ResourceDoc: resdoc.ResourceDoc{
+				PartitionKey: resdoc.PartitionKey{
+					NamespaceProvider: models.NamespaceProviderServicePrincipal,
+					NamespaceID:       "ns",
+					ResourceProvider:  models.ResourceProviderKey,
+				},
+				ID: id,
+			},
+			Status: status,
+			Policy: policy,
+		}
+		doc.KeyType = cloudkey.KeyTypeEC
+		doc.KeyID = "kid-" + id
+		doc.D = []byte{1}
+		doc.Created.Time = created
+		return doc
+	}
+
+	expired := newKeyDoc("expired", policy, keymodels.KeyStatusActive, now.Add(-3*time.Hour))
+	expired.NotAfter = jwt.NewNumericDate(now.Add(-time.Hour))
+	retired := newKeyDoc("retired", policy, keymodels.KeyStatusInactive, now.Add(-2*time.Hour))
+	retired.Deleted = &now
+	secret := newKeyDoc("secret", otherPolicy, keymodels.KeyStatusActive, now)
+	secret.KeyType = cloudkey.KeyTypeOct
+	// oct keys have nothing public, even the latest version of the policy is not published
+	hmacKey := newKeyDoc("hmac", policy, keymodels.KeyStatusActive, now.Add(time.Minute))
+	hmacKey.KeyType = cloudkey.KeyTypeOct
+	hmacKey.Alg = string(cloudkey.SignatureAlgorithmHS256)
+	for _, doc := range []*KeyDoc{
+		secret,
+		hmacKey,
+		expired,
+		retired,
+		newKeyDoc("rotated", policy, keymodels.KeyStatusInactive, now.Add(-time.Hour)),
+		newKeyDoc("active", policy, keymodels.KeyStatusActive, now),
+		newKeyDoc("other", otherPolicy, keymodels.KeyStatusActive, now.Add(-time.Minute)),
+	} {
+		_, err := docSvc.Create(c, doc, nil)
+		require.NoError(t, err)
+	}
+
+	keys, err := listPublishedKeysInternal(c, models.NamespaceProviderServicePrincipal, "ns", &policy)
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	assert.Equal(t, "kid-active", keys[0].KeyID)
+	assert.Equal(t, "kid-rotated", keys[1].KeyID)
+	assert.Empty(t, keys[0].D)
+
+	keys, err = listPublishedKeysInternal(c, models.NamespaceProviderServicePrincipal, "ns", nil)
+	require.NoError(t, err)
+	assert.Len(t, keys, 3)
+	for _, key := range keys {
+		assert.NotEqual(t, cloudkey.KeyTypeOct, key.KeyType, key.KeyID)
+	}
+
+	e := echo.New()
+	rec := httptest.NewRecorder()
+	require.NoError(t, respondJwks(e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec), keys))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	etag := rec.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+	assert.Equal(t, jwksCacheControl, rec.Header().Get(echo.HeaderCacheControl))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	require.NoError(t, respondJwks(e.NewContext(req, rec), keys))
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+}
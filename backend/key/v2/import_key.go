@@ -0,0 +1,106 @@
+package key
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
+	"github.com/labstack/echo/v4"
+	"github.com/stephenzsy/small-kms/backend/base"
+	cloudkey "github.com/stephenzsy/small-kms/backend/cloud/key"
+	"github.com/stephenzsy/small-kms/backend/internal/authz"
+	ctx "github.com/stephenzsy/small-kms/backend/internal/context"
+	"github.com/stephenzsy/small-kms/backend/models"
+	keymodels "github.com/stephenzsy/small-kms/backend/models/key"
+)
+
+// ImportKey implements admin.ServerInterface.
+func (*KeyAdminServer) ImportKey(ec echo.Context, namespaceProvider models.NamespaceProvider, namespaceId string, id string) error {
+	c := ec.(ctx.RequestContext)
+
+	if !authz.AuthorizeAdminOnly(c) {
+		return base.ErrResponseStatusForbidden
+	}
+
+	req := new(keymodels.ImportKeyRequest)
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+
+	jwe, err := cloudkey.NewJsonWebEncryption(req.Payload)
+	if err != nil {
+		return fmt.Errorf("%w: invalid payload", base.ErrResponseStatusBadRequest)
+	}
+	if jwe.Protected.KeyID == "" {
+		return fmt.Errorf("%w: invalid payload, one time key id must be specified", base.ErrResponseStatusBadRequest)
+	}
+
+	policy, err := GetKeyPolicyInternal(c, namespaceProvider, namespaceId, id)
+	if err != nil {
+		return err
+	}
+
+	otk, err := ReadOneTimeKey(c, namespaceProvider, namespaceId, jwe.Protected.KeyID)
+	if err != nil {
+		return err
+	}
+	reqPayload, _, err := jwe.Decrypt(func(*cloudkey.JoseHeader) (crypto.PrivateKey, error) {
+		return otk.PrivateKey().(*ecdsa.PrivateKey).ECDH()
+	})
+	if err != nil {
+		return fmt.Errorf("%w: invalid payload", base.ErrResponseStatusBadRequest)
+	}
+	privateJwk := new(cloudkey.JsonWebKey)
+	if err := json.Unmarshal(reqPayload, privateJwk); err != nil {
+		return fmt.Errorf("%w: invalid payload", base.ErrResponseStatusBadRequest)
+	}
+
+	doc, resp, err := importKeyInternal(c, namespaceProvider, namespaceId, policy, privateJwk)
+	if err != nil {
+		return err
+	}
+	return c.JSON(resp.RawResponse.StatusCode, doc.ToModel(true))
+}
+
+// importKeyInternal imports the private key as a new non-exportable key version of the policy
+func importKeyInternal(c ctx.RequestContext, namespaceProvider models.NamespaceProvider, namespaceId string, policy *KeyPolicyDoc, privateJwk *cloudkey.JsonWebKey) (*KeyDoc, azcosmos.ItemResponse, error) {
+	if err := checkImportKeySpec(policy, privateJwk); err != nil {
+		return nil, azcosmos.ItemResponse{}, err
+	}
+	return createKeyVersionInternal(c, namespaceProvider, namespaceId, policy, keymodels.KeyOriginImported,
+		func(c ctx.RequestContext, keyStore cloudkey.KeyStore, doc *keyGenerateDoc) (*cloudkey.CreateKeyResult, error) {
+			result, err := cloudkey.ImportKey(c, keyStore, doc.keyVaultStoreName, privateJwk, doc.getCreateKeyParams())
+			if errors.Is(err, cloudkey.ErrKeyImportNotSupported) || errors.Is(err, cloudkey.ErrInvalidKey) ||
+				errors.Is(err, cloudkey.ErrInvalidKeyType) || errors.Is(err, cloudkey.ErrInvalidKeySize) {
+				return nil, fmt.Errorf("%w: %w", base.ErrResponseStatusBadRequest, err)
+			}
+			return result, err
+		})
+}
+
+// checkImportKeySpec checks the private key is valid and matches the key spec of the policy
+func checkImportKeySpec(policy *KeyPolicyDoc, privateJwk *cloudkey.JsonWebKey) error {
+	ks := policy.KeySpec
+	if privateJwk.KeyType != ks.Kty {
+		return fmt.Errorf("%w: key type %s does not match key policy %s", base.ErrResponseStatusBadRequest, privateJwk.KeyType, ks.Kty)
+	}
+	privateKey, err := cloudkey.ParsePrivateJWK(privateJwk)
+	if err != nil {
+		return fmt.Errorf("%w: %w", base.ErrResponseStatusBadRequest, err)
+	}
+	switch privateKey := privateKey.(type) {
+	case *rsa.PrivateKey:
+		if ks.KeySize == nil || privateKey.N.BitLen() != *ks.KeySize {
+			return fmt.Errorf("%w: key size %d does not match key policy", base.ErrResponseStatusBadRequest, privateKey.N.BitLen())
+		}
+	case *ecdsa.PrivateKey:
+		if privateJwk.Curve != ks.Crv {
+			return fmt.Errorf("%w: curve %s does not match key policy %s", base.ErrResponseStatusBadRequest, privateJwk.Curve, ks.Crv)
+		}
+	}
+	return nil
+}
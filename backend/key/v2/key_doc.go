@@ -4,8 +4,6 @@ import (
 	"crypto/sha512"
 	"time"
 
-	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
-	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azkeys"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	cloudkey "github.com/stephenzsy/small-kms/backend/cloud/key"
@@ -20,6 +18,7 @@ type KeyDoc struct {
 	resdoc.ResourceDoc
 	cloudkey.JsonWebKey
 	Status        keymodels.KeyStatus  `json:"status"`
+	Origin        keymodels.KeyOrigin  `json:"origin,omitempty"`
 	Created       models.NumericDate   `json:"iat"`
 	NotBefore     *models.NumericDate  `json:"nbf,omitempty"`
 	NotAfter      *models.NumericDate  `json:"exp,omitempty"`
@@ -48,8 +47,9 @@ func (d *KeyDoc) calculateChecksum() []byte {
 type keyGenerateDoc struct {
 	KeyDoc
 
-	rsaKeySize        int
+	keySize           int
 	keyVaultStoreName string
+	keyStore          cloudkey.KeyStoreKind
 }
 
 func (d *keyGenerateDoc) init(nsProvider models.NamespaceProvider, nsID string, policy *KeyPolicyDoc) error {
@@ -60,9 +60,13 @@ func (d *keyGenerateDoc) init(nsProvider models.NamespaceProvider, nsID string,
 	d.ID = id.String()
 	d.KeyType = policy.KeySpec.Kty
 	if policy.KeySpec.KeySize != nil {
-		d.rsaKeySize = *policy.KeySpec.KeySize
+		d.keySize = *policy.KeySpec.KeySize
 	}
 	d.Curve = policy.KeySpec.Crv
+	if d.KeyType == cloudkey.KeyTypeOct {
+		// nothing public to tell the algorithm of a secret key
+		d.Alg = policy.KeySpec.Alg
+	}
 	d.KeyOperations = policy.KeySpec.KeyOperations
 	d.Extractable = policy.KeySpec.Extractable
 	if policy.ExpiryTime != nil {
@@ -71,51 +75,27 @@ func (d *keyGenerateDoc) init(nsProvider models.NamespaceProvider, nsID string,
 		d.NotAfter = jwt.NewNumericDate(caldur.Shift(now, *policy.ExpiryTime))
 	}
 	d.keyVaultStoreName = kv.GetMaterialName(kv.MaterialNameKindKey, nsProvider, nsID, policy.ID)
+	d.keyStore = policy.KeySpec.KeyStore
 	d.Policy = policy.Identifier()
 	d.PolicyVersion = policy.Version
 	return nil
 }
 
-func (d *keyGenerateDoc) getAzCreateKeyParams() (params azkeys.CreateKeyParameters, err error) {
-	switch d.KeyType {
-	case cloudkey.KeyTypeEC:
-		params.Kty = to.Ptr(azkeys.KeyTypeEC)
-		switch d.Curve {
-		case cloudkey.CurveNameP256:
-			params.Curve = to.Ptr(azkeys.CurveNameP256)
-		case cloudkey.CurveNameP384:
-			params.Curve = to.Ptr(azkeys.CurveNameP384)
-		case cloudkey.CurveNameP521:
-			params.Curve = to.Ptr(azkeys.CurveNameP521)
-		default:
-			return params, cloudkey.ErrInvalidCurve
-		}
-	case cloudkey.KeyTypeRSA:
-		params.Kty = to.Ptr(azkeys.KeyTypeRSA)
-		switch d.rsaKeySize {
-		case 2048, 3072, 4096:
-			params.KeySize = to.Ptr(int32(d.rsaKeySize))
-		}
-	default:
-		return params, cloudkey.ErrInvalidKeyType
-	}
-	// keyops
-	params.KeyOps = make([]*azkeys.KeyOperation, len(d.KeyOperations))
-	for i, keyOp := range d.KeyOperations {
-		params.KeyOps[i] = to.Ptr(azkeys.KeyOperation(keyOp))
-	}
-	// exportable
-	params.KeyAttributes = &azkeys.KeyAttributes{
-		Exportable: d.Extractable,
-		Enabled:    to.Ptr(true),
+func (d *keyGenerateDoc) getCreateKeyParams() cloudkey.CreateKeyParams {
+	params := cloudkey.CreateKeyParams{
+		KeyType:       d.KeyType,
+		Curve:         d.Curve,
+		KeySize:       d.keySize,
+		KeyOperations: d.KeyOperations,
+		Exportable:    d.Extractable,
 	}
 	if d.NotBefore != nil {
-		params.KeyAttributes.NotBefore = &d.NotBefore.Time
+		params.NotBefore = &d.NotBefore.Time
 	}
 	if d.NotAfter != nil {
-		params.KeyAttributes.Expires = &d.NotAfter.Time
+		params.Expires = &d.NotAfter.Time
 	}
-	return params, nil
+	return params
 }
 
 func (d *KeyDoc) ToKeyRef() (m keymodels.KeyRef) {
@@ -134,5 +114,9 @@ func (d *KeyDoc) ToModel(includeJwk bool) (m keymodels.Key) {
 	}
 	m.Identififier = d.Identifier().String()
 	m.Nbf = d.NotBefore
+	m.Origin = d.Origin
+	if m.Origin == "" {
+		m.Origin = keymodels.KeyOriginGenerated
+	}
 	return m
 }
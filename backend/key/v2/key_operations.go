@@ -0,0 +1,266 @@
+package key
+
+import (
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stephenzsy/small-kms/backend/base"
+	cloudkey "github.com/stephenzsy/small-kms/backend/cloud/key"
+	"github.com/stephenzsy/small-kms/backend/internal/authz"
+	ctx "github.com/stephenzsy/small-kms/backend/internal/context"
+	kv "github.com/stephenzsy/small-kms/backend/internal/keyvault"
+	"github.com/stephenzsy/small-kms/backend/models"
+	keymodels "github.com/stephenzsy/small-kms/backend/models/key"
+	ns "github.com/stephenzsy/small-kms/backend/namespace"
+)
+
+// protecting operations are not allowed once the key has expired or has been rotated,
+// data protected earlier can still be verified or recovered
+var keyOperationsRequireActive = map[cloudkey.JsonWebKeyOperation]bool{
+	cloudkey.JsonWebKeyOperationSign:    true,
+	cloudkey.JsonWebKeyOperationEncrypt: true,
+	cloudkey.JsonWebKeyOperationWrapKey: true,
+}
+
+func (d *KeyDoc) checkOperation(now time.Time, op cloudkey.JsonWebKeyOperation) error {
+	if d.Deleted != nil {
+		return fmt.Errorf("%w: key has been retired", base.ErrResponseStatusBadRequest)
+	}
+	switch d.Status {
+	case keymodels.KeyStatusActive:
+	case keymodels.KeyStatusInactive:
+		if keyOperationsRequireActive[op] {
+			return fmt.Errorf("%w: key is not active", base.ErrResponseStatusBadRequest)
+		}
+	default:
+		return fmt.Errorf("%w: key is not active", base.ErrResponseStatusBadRequest)
+	}
+	if !slices.Contains(d.KeyOperations, op) {
+		return fmt.Errorf("%w: key does not allow operation %s", base.ErrResponseStatusForbidden, op)
+	}
+	if d.NotBefore != nil && now.Before(d.NotBefore.Time) {
+		return fmt.Errorf("%w: key is not yet valid", base.ErrResponseStatusBadRequest)
+	}
+	if keyOperationsRequireActive[op] && d.NotAfter != nil && now.After(d.NotAfter.Time) {
+		return fmt.Errorf("%w: key has expired", base.ErrResponseStatusBadRequest)
+	}
+	return nil
+}
+
+func getKeyAuthorized(c ctx.RequestContext, namespaceProvider models.NamespaceProvider, namespaceId string, id string) (*KeyDoc, error) {
+	namespaceId = ns.ResolveMeNamespace(c, namespaceId)
+	if _, authOk := authz.Authorize(c, authz.AllowAdmin, authz.AllowSelf(namespaceId)); !authOk {
+		return nil, base.ErrResponseStatusForbidden
+	}
+	return GetKeyInternal(c, namespaceProvider, namespaceId, id)
+}
+
+// getKeyForOperation authorizes the caller and returns the key document once the operation is allowed on the key
+func getKeyForOperation(c ctx.RequestContext, namespaceProvider models.NamespaceProvider, namespaceId string, id string,
+	op cloudkey.JsonWebKeyOperation) (ctx.RequestContext, *KeyDoc, error) {
+	doc, err := getKeyAuthorized(c, namespaceProvider, namespaceId, id)
+	if err != nil {
+		return c, nil, err
+	}
+	if err := doc.checkOperation(time.Now(), op); err != nil {
+		return c, nil, err
+	}
+	return c.Elevate(), doc, nil
+}
+
+func wrapKeyOperationError(err error) error {
+	if errors.Is(err, cloudkey.ErrInvalidAlgorithm) || errors.Is(err, cloudkey.ErrInvalidKeyType) ||
+		errors.Is(err, cloudkey.ErrSymmetricKeyNotSupported) {
+		return fmt.Errorf("%w: %w", base.ErrResponseStatusBadRequest, err)
+	}
+	return err
+}
+
+// getSymmetricKeyForOperation releases the oct key material to the service, the algorithm is fixed by the key policy
+func getSymmetricKeyForOperation(c ctx.RequestContext, doc *KeyDoc, alg string, op cloudkey.JsonWebKeyOperation) ([]byte, error) {
+	if alg != doc.Alg {
+		return nil, fmt.Errorf("%w: key can only be used with %s", base.ErrResponseStatusBadRequest, doc.Alg)
+	}
+	key, err := cloudkey.GetSymmetricKey(c, kv.GetCloudKeyStore(c), doc.KeyID, op)
+	if err != nil {
+		return nil, wrapKeyOperationError(err)
+	}
+	return key, nil
+}
+
+// SignWithKey implements admin.ServerInterface.
+func (*KeyAdminServer) SignWithKey(ec echo.Context, namespaceProvider models.NamespaceProvider, namespaceId string, id string) error {
+	c := ec.(ctx.RequestContext)
+	req := new(keymodels.KeySignRequest)
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+
+	c, doc, err := getKeyForOperation(c, namespaceProvider, namespaceId, id, cloudkey.JsonWebKeyOperationSign)
+	if err != nil {
+		return err
+	}
+	if doc.KeyType == cloudkey.KeyTypeOct {
+		key, err := getSymmetricKeyForOperation(c, doc, string(req.Alg), cloudkey.JsonWebKeyOperationSign)
+		if err != nil {
+			return err
+		}
+		signature, err := cloudkey.SignHMAC(key, req.Alg, req.Digest)
+		if err != nil {
+			return wrapKeyOperationError(err)
+		}
+		return c.JSON(http.StatusOK, &keymodels.KeyOperationResult{
+			Kid:   doc.KeyID,
+			Alg:   string(req.Alg),
+			Value: signature,
+		})
+	}
+	if err := cloudkey.CheckSignatureKey(doc.PublicKey(), req.Alg); err != nil {
+		return wrapKeyOperationError(err)
+	}
+	if !cloudkey.CheckDigestLength(req.Alg, req.Digest) {
+		return fmt.Errorf("%w: digest length does not match %s", base.ErrResponseStatusBadRequest, req.Alg)
+	}
+
+	signer := kv.GetCloudKeyStore(c).NewSignatureKey(c, doc.KeyID, req.Alg, false, doc.PublicKey())
+	signature, err := signer.Sign(nil, req.Digest, req.Alg)
+	if err != nil {
+		return wrapKeyOperationError(err)
+	}
+	return c.JSON(http.StatusOK, &keymodels.KeyOperationResult{
+		Kid:   doc.KeyID,
+		Alg:   string(req.Alg),
+		Value: signature,
+	})
+}
+
+// VerifyWithKey implements admin.ServerInterface.
+func (*KeyAdminServer) VerifyWithKey(ec echo.Context, namespaceProvider models.NamespaceProvider, namespaceId string, id string) error {
+	c := ec.(ctx.RequestContext)
+	req := new(keymodels.KeyVerifyRequest)
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+
+	c, doc, err := getKeyForOperation(c, namespaceProvider, namespaceId, id, cloudkey.JsonWebKeyOperationVerify)
+	if err != nil {
+		return err
+	}
+	var valid bool
+	if doc.KeyType == cloudkey.KeyTypeOct {
+		key, err := getSymmetricKeyForOperation(c, doc, string(req.Alg), cloudkey.JsonWebKeyOperationVerify)
+		if err != nil {
+			return err
+		}
+		valid, err = cloudkey.VerifyHMAC(key, req.Alg, req.Digest, req.Signature)
+		if err != nil {
+			return wrapKeyOperationError(err)
+		}
+	} else if valid, err = cloudkey.VerifySignature(doc.PublicKey(), req.Alg, req.Digest, req.Signature); err != nil {
+		return wrapKeyOperationError(err)
+	}
+	return c.JSON(http.StatusOK, &keymodels.KeyVerifyResult{
+		Kid:   doc.KeyID,
+		Valid: valid,
+	})
+}
+
+func encryptWithKey(ec echo.Context, namespaceProvider models.NamespaceProvider, namespaceId string, id string, op cloudkey.JsonWebKeyOperation) error {
+	c := ec.(ctx.RequestContext)
+	req := new(keymodels.KeyOperationRequest)
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+
+	c, doc, err := getKeyForOperation(c, namespaceProvider, namespaceId, id, op)
+	if err != nil {
+		return err
+	}
+	var ciphertext []byte
+	if doc.KeyType == cloudkey.KeyTypeOct {
+		key, err := getSymmetricKeyForOperation(c, doc, string(req.Alg), op)
+		if err != nil {
+			return err
+		}
+		if ciphertext, err = cloudkey.EncryptAESGCM(key, req.Value); err != nil {
+			return wrapKeyOperationError(err)
+		}
+	} else if ciphertext, err = cloudkey.EncryptOAEP(doc.PublicKey(), req.Alg, req.Value); err != nil {
+		return wrapKeyOperationError(err)
+	}
+	return c.JSON(http.StatusOK, &keymodels.KeyOperationResult{
+		Kid:   doc.KeyID,
+		Alg:   string(req.Alg),
+		Value: ciphertext,
+	})
+}
+
+func decryptWithKey(ec echo.Context, namespaceProvider models.NamespaceProvider, namespaceId string, id string, op cloudkey.JsonWebKeyOperation) error {
+	c := ec.(ctx.RequestContext)
+	req := new(keymodels.KeyOperationRequest)
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+
+	c, doc, err := getKeyForOperation(c, namespaceProvider, namespaceId, id, op)
+	if err != nil {
+		return err
+	}
+	if doc.KeyType == cloudkey.KeyTypeOct {
+		key, err := getSymmetricKeyForOperation(c, doc, string(req.Alg), op)
+		if err != nil {
+			return err
+		}
+		plaintext, err := cloudkey.DecryptAESGCM(key, req.Value)
+		if err != nil {
+			return fmt.Errorf("%w: failed to decrypt", base.ErrResponseStatusBadRequest)
+		}
+		return c.JSON(http.StatusOK, &keymodels.KeyOperationResult{
+			Kid:   doc.KeyID,
+			Alg:   string(req.Alg),
+			Value: plaintext,
+		})
+	}
+	hash := req.Alg.OAEPHash()
+	if hash == 0 {
+		return fmt.Errorf("%w: %s", base.ErrResponseStatusBadRequest, req.Alg)
+	}
+	decrypter := kv.GetCloudKeyStore(c).NewWrappingKey(c, doc.KeyID, doc.KeyType)
+	plaintext, err := decrypter.Decrypt(nil, req.Value, &cloudkey.OAEPDecrypterOpts{
+		OAEPOptions:  rsa.OAEPOptions{Hash: hash},
+		KeyOperation: op,
+	})
+	if err != nil {
+		return wrapKeyOperationError(err)
+	}
+	return c.JSON(http.StatusOK, &keymodels.KeyOperationResult{
+		Kid:   doc.KeyID,
+		Alg:   string(req.Alg),
+		Value: plaintext,
+	})
+}
+
+// EncryptWithKey implements admin.ServerInterface.
+func (*KeyAdminServer) EncryptWithKey(ec echo.Context, namespaceProvider models.NamespaceProvider, namespaceId string, id string) error {
+	return encryptWithKey(ec, namespaceProvider, namespaceId, id, cloudkey.JsonWebKeyOperationEncrypt)
+}
+
+// DecryptWithKey implements admin.ServerInterface.
+func (*KeyAdminServer) DecryptWithKey(ec echo.Context, namespaceProvider models.NamespaceProvider, namespaceId string, id string) error {
+	return decryptWithKey(ec, namespaceProvider, namespaceId, id, cloudkey.JsonWebKeyOperationDecrypt)
+}
+
+// WrapWithKey implements admin.ServerInterface.
+func (*KeyAdminServer) WrapWithKey(ec echo.Context, namespaceProvider models.NamespaceProvider, namespaceId string, id string) error {
+	return encryptWithKey(ec, namespaceProvider, namespaceId, id, cloudkey.JsonWebKeyOperationWrapKey)
+}
+
+// UnwrapWithKey implements admin.ServerInterface.
+func (*KeyAdminServer) UnwrapWithKey(ec echo.Context, namespaceProvider models.NamespaceProvider, namespaceId string, id string) error {
+	return decryptWithKey(ec, namespaceProvider, namespaceId, id, cloudkey.JsonWebKeyOperationUnwrapKey)
+}
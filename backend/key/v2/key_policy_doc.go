@@ -4,11 +4,15 @@ import (
 	"context"
 	"crypto/md5"
 	"fmt"
+	"io"
+	"slices"
+	"strconv"
 	"time"
 
 	"github.com/rs/zerolog/log"
 	"github.com/stephenzsy/small-kms/backend/base"
 	cloudkey "github.com/stephenzsy/small-kms/backend/cloud/key"
+	kv "github.com/stephenzsy/small-kms/backend/internal/keyvault"
 	"github.com/stephenzsy/small-kms/backend/models"
 	keymodels "github.com/stephenzsy/small-kms/backend/models/key"
 	"github.com/stephenzsy/small-kms/backend/resdoc"
@@ -22,14 +26,90 @@ type KeyPolicyDoc struct {
 
 	KeySpec    keymodels.JsonWebKeySpec `json:"keySpec"`
 	ExpiryTime *caldur.CalendarDuration `json:"expiryTime,omitempty"`
+	Rotation   *KeyRotationPolicyDoc    `json:"rotation,omitempty"`
 
 	Version []byte `json:"version"`
 }
 
+type KeyRotationPolicyDoc struct {
+	RotateAfter  caldur.CalendarDuration  `json:"rotateAfter"`
+	NotifyBefore *caldur.CalendarDuration `json:"notifyBefore,omitempty"`
+	KeepVersions int                      `json:"keepVersions"`
+}
+
 const (
 	queryColumnDisplayName = "c.displayName"
+
+	defaultKeyRotationKeepVersions = 1
+	maxKeyRotationKeepVersions     = 10
 )
 
+func (r *KeyRotationPolicyDoc) init(req *keymodels.KeyRotationPolicy, expiryTime *caldur.CalendarDuration) (err error) {
+	if r.RotateAfter, err = caldur.Parse(req.RotateAfter); err != nil {
+		return fmt.Errorf("%w: invalid rotate after format", base.ErrResponseStatusBadRequest)
+	}
+	now := time.Now()
+	rotateAt := caldur.Shift(now, r.RotateAfter)
+	if rotateAt.Before(now.AddDate(0, 0, 1)) || rotateAt.After(now.AddDate(10, 0, 0)) {
+		return fmt.Errorf("%w: rotate after cannot be more than 10 years or less than 1 day", base.ErrResponseStatusBadRequest)
+	}
+	if expiryTime != nil && !rotateAt.Before(caldur.Shift(now, *expiryTime)) {
+		return fmt.Errorf("%w: rotate after must be shorter than the expiry time", base.ErrResponseStatusBadRequest)
+	}
+
+	if req.NotifyBefore != "" {
+		notifyBefore, err := caldur.Parse(req.NotifyBefore)
+		if err != nil {
+			return fmt.Errorf("%w: invalid notify before format", base.ErrResponseStatusBadRequest)
+		}
+		if !caldur.Shift(now, notifyBefore).Before(rotateAt) {
+			return fmt.Errorf("%w: notify before must be shorter than rotate after", base.ErrResponseStatusBadRequest)
+		}
+		r.NotifyBefore = &notifyBefore
+	}
+
+	r.KeepVersions = defaultKeyRotationKeepVersions
+	if req.KeepVersions != nil {
+		if *req.KeepVersions < 0 || *req.KeepVersions > maxKeyRotationKeepVersions {
+			return fmt.Errorf("%w: keep versions must be between 0 and %d", base.ErrResponseStatusBadRequest, maxKeyRotationKeepVersions)
+		}
+		r.KeepVersions = *req.KeepVersions
+	}
+	return nil
+}
+
+func (r *KeyRotationPolicyDoc) digest(w io.Writer) {
+	w.Write(r.RotateAfter.Bytes())
+	if r.NotifyBefore != nil {
+		w.Write(r.NotifyBefore.Bytes())
+	}
+	w.Write([]byte(strconv.Itoa(r.KeepVersions)))
+}
+
+// rotateAt returns the time the key version created at the given time is rotated, and the time to notify before that
+func (r *KeyRotationPolicyDoc) rotateAt(created time.Time) (notifyAt time.Time, rotateAt time.Time) {
+	rotateAt = caldur.Shift(created, r.RotateAfter)
+	notifyAt = rotateAt
+	if r.NotifyBefore != nil {
+		notifyAt = caldur.Shift(rotateAt, r.NotifyBefore.Negate())
+	}
+	return notifyAt, rotateAt
+}
+
+func (r *KeyRotationPolicyDoc) ToModel() *keymodels.KeyRotationPolicy {
+	if r == nil {
+		return nil
+	}
+	m := &keymodels.KeyRotationPolicy{
+		RotateAfter:  r.RotateAfter.String(),
+		KeepVersions: &r.KeepVersions,
+	}
+	if r.NotifyBefore != nil {
+		m.NotifyBefore = r.NotifyBefore.String()
+	}
+	return m
+}
+
 func sanitizeKeyOperations(keyOps []JsonWebKeyOperation) []JsonWebKeyOperation {
 	if len(keyOps) == 0 {
 		return nil
@@ -41,7 +121,8 @@ func sanitizeKeyOperations(keyOps []JsonWebKeyOperation) []JsonWebKeyOperation {
 		switch keyOp {
 		case cloudkey.JsonWebKeyOperationSign, cloudkey.JsonWebKeyOperationVerify,
 			cloudkey.JsonWebKeyOperationEncrypt, cloudkey.JsonWebKeyOperationDecrypt,
-			cloudkey.JsonWebKeyOperationWrapKey, cloudkey.JsonWebKeyOperationUnwrapKey:
+			cloudkey.JsonWebKeyOperationWrapKey, cloudkey.JsonWebKeyOperationUnwrapKey,
+			cloudkey.JsonWebKeyOperationDeriveKey, cloudkey.JsonWebKeyOperationDeriveBits:
 			seen[keyOp] = true
 		}
 	}
@@ -53,6 +134,87 @@ func sanitizeKeyOperations(keyOps []JsonWebKeyOperation) []JsonWebKeyOperation {
 	return result
 }
 
+// filterKeyOperations keeps the requested operations the key type can do, or all of them if none is left
+func filterKeyOperations(keyOps []JsonWebKeyOperation, allowedOps []JsonWebKeyOperation) []JsonWebKeyOperation {
+	result := make([]JsonWebKeyOperation, 0, len(allowedOps))
+	for _, op := range keyOps {
+		if slices.Contains(allowedOps, op) {
+			result = append(result, op)
+		}
+	}
+	if len(result) == 0 {
+		return allowedOps
+	}
+	return result
+}
+
+// initOKPKeySpec restricts the key operations to what the curve can do,
+// Key Vault and PKCS#11 tokens cannot hold OKP keys, so they are kept in the local key store
+func initOKPKeySpec(ks *keymodels.JsonWebKeySpec) error {
+	var allowedOps []JsonWebKeyOperation
+	switch ks.Crv {
+	case cloudkey.CurveNameEd25519:
+		allowedOps = []JsonWebKeyOperation{cloudkey.JsonWebKeyOperationSign, cloudkey.JsonWebKeyOperationVerify}
+	case cloudkey.CurveNameX25519:
+		allowedOps = []JsonWebKeyOperation{cloudkey.JsonWebKeyOperationDeriveKey, cloudkey.JsonWebKeyOperationDeriveBits}
+	default:
+		return fmt.Errorf("%w: unsupported curve: %s", base.ErrResponseStatusBadRequest, ks.Crv)
+	}
+	ks.KeyOperations = filterKeyOperations(ks.KeyOperations, allowedOps)
+
+	switch ks.KeyStore {
+	case "":
+		ks.KeyStore = cloudkey.KeyStoreKindLocal
+	case cloudkey.KeyStoreKindLocal:
+	default:
+		return fmt.Errorf("%w: %s keys can only be kept in the local key store", base.ErrResponseStatusBadRequest, ks.Crv)
+	}
+	return nil
+}
+
+// initOctKeySpec sizes the key for the algorithm, AES-256 keys encrypt and decrypt, HMAC keys sign and verify,
+// the key material is kept by the store as a secret, PKCS#11 tokens are not supported
+func initOctKeySpec(ks *keymodels.JsonWebKeySpec) error {
+	var allowedOps []JsonWebKeyOperation
+	switch ks.Alg {
+	case string(cloudkey.JwkEncAlgAes256Gcm):
+		ks.KeySize = utils.ToPtr(256)
+		allowedOps = []JsonWebKeyOperation{cloudkey.JsonWebKeyOperationEncrypt, cloudkey.JsonWebKeyOperationDecrypt}
+	case string(cloudkey.SignatureAlgorithmHS256),
+		string(cloudkey.SignatureAlgorithmHS384),
+		string(cloudkey.SignatureAlgorithmHS512):
+		ks.KeySize = utils.ToPtr(cloudkey.JsonWebSignatureAlgorithm(ks.Alg).HashFunc().Size() * 8)
+		allowedOps = []JsonWebKeyOperation{cloudkey.JsonWebKeyOperationSign, cloudkey.JsonWebKeyOperationVerify}
+	default:
+		return fmt.Errorf("%w: unsupported algorithm for oct key: %s", base.ErrResponseStatusBadRequest, ks.Alg)
+	}
+	ks.KeyOperations = filterKeyOperations(ks.KeyOperations, allowedOps)
+
+	if ks.KeyStore == cloudkey.KeyStoreKindPKCS11 {
+		return fmt.Errorf("%w: oct keys cannot be kept in the %s key store", base.ErrResponseStatusBadRequest, ks.KeyStore)
+	}
+	return nil
+}
+
+// validateKeyAgreementKeyStore rejects key agreement operations for EC keys kept in a store which cannot compute ECDH,
+// such as Key Vault, data keys wrapped with ECDH-ES could never be unwrapped
+func validateKeyAgreementKeyStore(c context.Context, ks *keymodels.JsonWebKeySpec) error {
+	if ks.Kty != cloudkey.KeyTypeEC ||
+		!slices.ContainsFunc(ks.KeyOperations, func(op JsonWebKeyOperation) bool {
+			return op == cloudkey.JsonWebKeyOperationDeriveKey || op == cloudkey.JsonWebKeyOperationDeriveBits
+		}) {
+		return nil
+	}
+	keyStore, err := cloudkey.SelectKeyStore(kv.GetCloudKeyStore(c), ks.KeyStore)
+	if err != nil {
+		return fmt.Errorf("%w: %w", base.ErrResponseStatusBadRequest, err)
+	}
+	if _, ok := keyStore.(cloudkey.KeyAgreementKeyStore); !ok {
+		return fmt.Errorf("%w: %w: %s", base.ErrResponseStatusBadRequest, cloudkey.ErrKeyAgreementNotSupported, keyStore.Kind())
+	}
+	return nil
+}
+
 func (doc *KeyPolicyDoc) init(c context.Context, req *keymodels.CreateKeyPolicyRequest) error {
 	logger := log.Ctx(c)
 
@@ -101,12 +263,54 @@ func (doc *KeyPolicyDoc) init(c context.Context, req *keymodels.CreateKeyPolicyR
 			default:
 				logger.Warn().Str("crv", string(req.KeySpec.Crv)).Msg("invalid curve, default to P384")
 			}
+		case cloudkey.KeyTypeOct:
+			doc.KeySpec.Kty = cloudkey.KeyTypeOct
+			doc.KeySpec.Crv = ""
+			doc.KeySpec.Alg = req.KeySpec.Alg
+			if doc.KeySpec.Alg == "" {
+				doc.KeySpec.Alg = string(cloudkey.SignatureAlgorithmHS256)
+			}
+		case cloudkey.KeyTypeOKP:
+			doc.KeySpec.Kty = cloudkey.KeyTypeOKP
+			doc.KeySpec.KeySize = nil
+			doc.KeySpec.Crv = cloudkey.CurveNameEd25519
+
+			switch req.KeySpec.Crv {
+			case cloudkey.CurveNameEd25519, cloudkey.CurveNameX25519:
+				doc.KeySpec.Crv = req.KeySpec.Crv
+			case "":
+				// default
+			default:
+				logger.Warn().Str("crv", string(req.KeySpec.Crv)).Msg("invalid curve, default to Ed25519")
+			}
 		}
 
 		keyOps := sanitizeKeyOperations(req.KeySpec.KeyOperations)
 		if len(keyOps) > 0 {
 			doc.KeySpec.KeyOperations = keyOps
 		}
+		if req.KeySpec.KeyStore != "" {
+			if !req.KeySpec.KeyStore.IsSupported() {
+				return fmt.Errorf("%w: unsupported key store: %s", base.ErrResponseStatusBadRequest, req.KeySpec.KeyStore)
+			}
+			doc.KeySpec.KeyStore = req.KeySpec.KeyStore
+		}
+	}
+	switch doc.KeySpec.Kty {
+	case cloudkey.KeyTypeOKP:
+		if err := initOKPKeySpec(&doc.KeySpec); err != nil {
+			return err
+		}
+	case cloudkey.KeyTypeOct:
+		if err := initOctKeySpec(&doc.KeySpec); err != nil {
+			return err
+		}
+		// the algorithm of oct keys is fixed by the policy
+		io.WriteString(digester, doc.KeySpec.Alg)
+	case cloudkey.KeyTypeEC:
+		if err := validateKeyAgreementKeyStore(c, &doc.KeySpec); err != nil {
+			return err
+		}
 	}
 	doc.KeySpec.Digest(digester)
 
@@ -128,6 +332,14 @@ func (doc *KeyPolicyDoc) init(c context.Context, req *keymodels.CreateKeyPolicyR
 		digester.Write(doc.ExpiryTime.Bytes())
 	}
 
+	if req.Rotation != nil {
+		doc.Rotation = &KeyRotationPolicyDoc{}
+		if err := doc.Rotation.init(req.Rotation, doc.ExpiryTime); err != nil {
+			return err
+		}
+		doc.Rotation.digest(digester)
+	}
+
 	doc.Version = digester.Sum(nil)
 	return nil
 }
@@ -144,5 +356,6 @@ func (doc *KeyPolicyDoc) ToModel() (m keymodels.KeyPolicy) {
 	if doc.ExpiryTime != nil {
 		m.ExpiryTime = doc.ExpiryTime.String()
 	}
+	m.Rotation = doc.Rotation.ToModel()
 	return m
 }
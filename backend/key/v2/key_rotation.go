@@ -0,0 +1,290 @@
+package key
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/stephenzsy/small-kms/backend/base"
+	ctx "github.com/stephenzsy/small-kms/backend/internal/context"
+	"github.com/stephenzsy/small-kms/backend/models"
+	keymodels "github.com/stephenzsy/small-kms/backend/models/key"
+	webhookmodels "github.com/stephenzsy/small-kms/backend/models/webhook"
+	"github.com/stephenzsy/small-kms/backend/resdoc"
+	"github.com/stephenzsy/small-kms/backend/taskmanager"
+	"github.com/stephenzsy/small-kms/backend/utils"
+	"github.com/stephenzsy/small-kms/backend/webhook"
+)
+
+const (
+	keyRotationScanInterval  = time.Hour
+	keyRotationLeaseDuration = 10 * time.Minute
+)
+
+// rotation links of all namespaces share one partition, so the scheduler can find them without a cross partition query
+var keyRotationLinkPartitionKey = resdoc.PartitionKey{
+	NamespaceProvider: models.NamespaceProviderProfile,
+	NamespaceID:       "default",
+	ResourceProvider:  models.ResourceProviderLink,
+}
+
+var keyRotationLeasePartitionKey = resdoc.PartitionKey{
+	NamespaceProvider: models.NamespaceProviderProfile,
+	NamespaceID:       "default",
+	ResourceProvider:  models.ResourceProviderLease,
+}
+
+// keyRotationLinkDoc points to the latest key version of a policy which rotates keys
+type keyRotationLinkDoc struct {
+	resdoc.LinkResourceDoc
+	Policy   resdoc.DocIdentifier `json:"policy"`
+	NotifyAt resdoc.NumericDate   `json:"notifyAt"`
+	RotateAt resdoc.NumericDate   `json:"rotateAt"`
+}
+
+const (
+	keyRotationLinkQueryColLinkTo   = "c.linkTo"
+	keyRotationLinkQueryColPolicy   = "c.policy"
+	keyRotationLinkQueryColNotifyAt = "c.notifyAt"
+	keyRotationLinkQueryColRotateAt = "c.rotateAt"
+)
+
+type keyRotatedEventData struct {
+	keymodels.Key
+	RotatedFrom string `json:"rotatedFrom"`
+}
+
+// KeyRotatedHandler updates the dependents of the key policy once a new key version has been generated by rotation
+type KeyRotatedHandler func(c ctx.RequestContext, policy resdoc.DocIdentifier) error
+
+func getKeyRotationLinkID(policy resdoc.DocIdentifier) string {
+	return fmt.Sprintf("%s-%s-%s-%s", models.LinkProviderKeyRotation, policy.NamespaceProvider, policy.NamespaceID, policy.ID)
+}
+
+// registerKeyRotationInternal schedules the key to be rotated, replacing the previous key version of the policy
+func registerKeyRotationInternal(c context.Context, keyDoc *KeyDoc, rotation *KeyRotationPolicyDoc) error {
+	doc := &keyRotationLinkDoc{
+		LinkResourceDoc: resdoc.LinkResourceDoc{
+			ResourceDoc: resdoc.ResourceDoc{
+				PartitionKey: keyRotationLinkPartitionKey,
+				ID:           getKeyRotationLinkID(keyDoc.Policy),
+			},
+			LinkTo:       keyDoc.Identifier(),
+			LinkProvider: models.LinkProviderKeyRotation,
+		},
+		Policy: keyDoc.Policy,
+	}
+	doc.NotifyAt.Time, doc.RotateAt.Time = rotation.rotateAt(keyDoc.Created.Time)
+	_, err := resdoc.GetDocService(c).Upsert(c, doc, nil)
+	return err
+}
+
+func listKeyRotationLinksInternal(c context.Context, notifyAtBefore time.Time) ([]*keyRotationLinkDoc, error) {
+	qb := resdoc.NewDefaultCosmoQueryBuilder().
+		WithExtraColumns(keyRotationLinkQueryColLinkTo, keyRotationLinkQueryColPolicy,
+			keyRotationLinkQueryColNotifyAt, keyRotationLinkQueryColRotateAt).
+		WithWhereClauses("c.linkProvider = @linkProvider", "c.notifyAt <= @notifyAtBefore")
+	qb.Parameters = append(qb.Parameters,
+		azcosmos.QueryParameter{Name: "@linkProvider", Value: models.LinkProviderKeyRotation},
+		azcosmos.QueryParameter{Name: "@notifyAtBefore", Value: notifyAtBefore.Unix()})
+	pager := resdoc.NewQueryDocPager[*keyRotationLinkDoc](c, qb, keyRotationLinkPartitionKey)
+	return utils.PagerToSlice[*keyRotationLinkDoc](pager)
+}
+
+func deleteKeyRotationLinkInternal(c context.Context, link *keyRotationLinkDoc) error {
+	_, err := resdoc.GetDocService(c).Delete(c, link.Identifier(), &azcosmos.ItemOptions{
+		IfMatchEtag: link.ETag,
+	})
+	return resdoc.HandleAzCosmosError(err)
+}
+
+// listInactiveKeysByPolicyInternal returns the inactive key versions which have not been retired, latest first
+func listInactiveKeysByPolicyInternal(c context.Context, policy resdoc.DocIdentifier) ([]*KeyDoc, error) {
+	qb := resdoc.NewDefaultCosmoQueryBuilder().
+		WithExtraColumns("c.status", "c.iat").
+		WithWhereClauses("c.status = @status", "c.policy = @policy", "NOT IS_DEFINED(c.deleted) OR IS_NULL(c.deleted)").
+		WithOrderBy("c.iat DESC")
+	qb.Parameters = append(qb.Parameters,
+		azcosmos.QueryParameter{Name: "@status", Value: keymodels.KeyStatusInactive},
+		azcosmos.QueryParameter{Name: "@policy", Value: policy.String()})
+	pager := resdoc.NewQueryDocPager[*KeyDoc](c, qb, resdoc.PartitionKey{
+		NamespaceProvider: policy.NamespaceProvider,
+		NamespaceID:       policy.NamespaceID,
+		ResourceProvider:  models.ResourceProviderKey,
+	})
+	return utils.PagerToSlice[*KeyDoc](pager)
+}
+
+// retireKeyVersionsInternal marks the inactive versions beyond keepVersions deleted, they can no longer be used for any operation,
+// the key material is left in the key store
+func retireKeyVersionsInternal(c context.Context, policy resdoc.DocIdentifier, keepVersions int) error {
+	inactiveKeys, err := listInactiveKeysByPolicyInternal(c, policy)
+	if err != nil {
+		return err
+	}
+	if len(inactiveKeys) <= keepVersions {
+		return nil
+	}
+	docSvc := resdoc.GetDocService(c)
+	for _, keyDoc := range inactiveKeys[keepVersions:] {
+		patchOps := azcosmos.PatchOperations{}
+		patchOps.AppendSet("/deleted", time.Now().UTC())
+		if _, err := docSvc.Patch(c, keyDoc, patchOps, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rotateKeyInternal must be called while holding the lease of the rotation link
+func rotateKeyInternal(c ctx.RequestContext, linkIdentifier resdoc.DocIdentifier, now time.Time, handlers []KeyRotatedHandler) error {
+	logger := log.Ctx(c)
+	link := &keyRotationLinkDoc{}
+	if err := resdoc.GetDocService(c).Read(c, linkIdentifier, link, nil); err != nil {
+		if errors.Is(err, resdoc.ErrAzCosmosDocNotFound) {
+			return nil
+		}
+		return err
+	}
+	// rotated by another replica before the lease was acquired
+	if now.Before(link.RotateAt.Time) {
+		return nil
+	}
+
+	keyIdentifier := link.LinkTo
+	keyDoc, err := GetKeyInternal(c, keyIdentifier.NamespaceProvider, keyIdentifier.NamespaceID, keyIdentifier.ID)
+	if err != nil {
+		if errors.Is(err, base.ErrResponseStatusNotFound) {
+			return deleteKeyRotationLinkInternal(c, link)
+		}
+		return err
+	}
+	policy, err := GetKeyPolicyInternal(c, link.Policy.NamespaceProvider, link.Policy.NamespaceID, link.Policy.ID)
+	if err != nil {
+		if errors.Is(err, base.ErrResponseStatusNotFound) {
+			return deleteKeyRotationLinkInternal(c, link)
+		}
+		return err
+	}
+	if policy.Rotation == nil {
+		logger.Info().Str("policy", link.Policy.String()).Msg("policy no longer rotates keys, skip rotation")
+		return deleteKeyRotationLinkInternal(c, link)
+	}
+
+	rotated, _, err := generateKeyInternal(c, keyIdentifier.NamespaceProvider, keyIdentifier.NamespaceID, policy)
+	if err != nil {
+		return err
+	}
+	if keyDoc.Status == keymodels.KeyStatusActive {
+		patchOps := azcosmos.PatchOperations{}
+		patchOps.AppendSet("/status", keymodels.KeyStatusInactive)
+		if _, err := resdoc.GetDocService(c).Patch(c, keyDoc, patchOps, nil); err != nil {
+			return err
+		}
+	}
+	if err := retireKeyVersionsInternal(c, link.Policy, policy.Rotation.KeepVersions); err != nil {
+		return err
+	}
+	logger.Info().Str("key", rotated.Identifier().String()).Str("rotatedFrom", keyIdentifier.String()).Msg("key rotated")
+
+	webhook.Publish(c, keyIdentifier.NamespaceProvider, keyIdentifier.NamespaceID, webhookmodels.EventTypeKeyRotated,
+		"keys/"+rotated.ID, &keyRotatedEventData{
+			Key:         rotated.ToModel(false),
+			RotatedFrom: keyIdentifier.String(),
+		})
+	for _, handler := range handlers {
+		if err := handler(c, link.Policy); err != nil {
+			logger.Error().Err(err).Str("policy", link.Policy.String()).Msg("failed to update dependents of rotated key")
+		}
+	}
+	return nil
+}
+
+type keyRotationTaskExecutor struct {
+	serviceContext context.Context
+	leaseHolder    string
+	handlers       []KeyRotatedHandler
+}
+
+// notifyRotating publishes the event once per key version, the lease is held until the key is rotated
+func (e *keyRotationTaskExecutor) notifyRotating(c ctx.RequestContext, link *keyRotationLinkDoc) error {
+	keyIdentifier := link.LinkTo
+	keyDoc, err := GetKeyInternal(c, keyIdentifier.NamespaceProvider, keyIdentifier.NamespaceID, keyIdentifier.ID)
+	if err != nil {
+		return err
+	}
+	if _, err := resdoc.AcquireLease(c, resdoc.DocIdentifier{
+		PartitionKey: keyRotationLeasePartitionKey,
+		ID:           fmt.Sprintf("key-rotating-%s-%s-%s", keyIdentifier.NamespaceProvider, keyIdentifier.NamespaceID, keyIdentifier.ID),
+	}, uuid.NewString(), time.Until(link.RotateAt.Time)); err != nil {
+		if errors.Is(err, resdoc.ErrLeaseHeld) {
+			return nil
+		}
+		return err
+	}
+	webhook.Publish(c, keyIdentifier.NamespaceProvider, keyIdentifier.NamespaceID, webhookmodels.EventTypeKeyRotating,
+		"keys/"+keyDoc.ID, keyDoc.ToModel(false))
+	return nil
+}
+
+// Close implements taskmanager.IntervalExecutor.
+func (*keyRotationTaskExecutor) Close(context.Context) error {
+	return nil
+}
+
+// Execute implements taskmanager.IntervalExecutor.
+func (e *keyRotationTaskExecutor) Execute(c context.Context) (time.Duration, error) {
+	logger := log.Ctx(c)
+	rc := ctx.NewBackgroundRequestContext(c, e.serviceContext)
+	now := time.Now()
+	links, err := listKeyRotationLinksInternal(rc, now)
+	if err != nil {
+		return keyRotationScanInterval, err
+	}
+	for _, link := range links {
+		if now.Before(link.RotateAt.Time) {
+			if err := e.notifyRotating(rc, link); err != nil {
+				logger.Error().Err(err).Str("key", link.LinkTo.String()).Msg("failed to notify rotating key")
+			}
+			continue
+		}
+		lease, err := resdoc.AcquireLease(rc, resdoc.DocIdentifier{
+			PartitionKey: keyRotationLeasePartitionKey,
+			ID:           link.ID,
+		}, e.leaseHolder, keyRotationLeaseDuration)
+		if err != nil {
+			if !errors.Is(err, resdoc.ErrLeaseHeld) {
+				logger.Error().Err(err).Str("link", link.ID).Msg("failed to acquire key rotation lease")
+			}
+			continue
+		}
+		if err := rotateKeyInternal(rc, link.Identifier(), now, e.handlers); err != nil {
+			logger.Error().Err(err).Str("key", link.LinkTo.String()).Msg("failed to rotate key")
+		}
+		if err := lease.Release(rc); err != nil {
+			logger.Error().Err(err).Str("link", link.ID).Msg("failed to release key rotation lease")
+		}
+	}
+	return keyRotationScanInterval, nil
+}
+
+// Name implements taskmanager.IntervalExecutor.
+func (*keyRotationTaskExecutor) Name() string {
+	return "KeyRotation"
+}
+
+var _ taskmanager.IntervalExecutor = (*keyRotationTaskExecutor)(nil)
+
+// NewKeyRotationTaskExecutor generates new key versions of policies which rotate keys once the latest version is due,
+// the previous version is kept inactive for verify and decrypt
+func NewKeyRotationTaskExecutor(serviceContext context.Context, handlers ...KeyRotatedHandler) taskmanager.IntervalExecutor {
+	return &keyRotationTaskExecutor{
+		serviceContext: serviceContext,
+		leaseHolder:    uuid.NewString(),
+		handlers:       handlers,
+	}
+}
@@ -0,0 +1,50 @@
+package key
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stephenzsy/small-kms/backend/base"
+	cloudkey "github.com/stephenzsy/small-kms/backend/cloud/key"
+	keymodels "github.com/stephenzsy/small-kms/backend/models/key"
+	"github.com/stephenzsy/small-kms/backend/utils"
+	"github.com/stephenzsy/small-kms/backend/utils/caldur"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyRotationPolicyInit(t *testing.T) {
+	expiryTime := caldur.CalendarDuration{Year: 1}
+
+	r := &KeyRotationPolicyDoc{}
+	require.NoError(t, r.init(&keymodels.KeyRotationPolicy{RotateAfter: "P6M", NotifyBefore: "P7D"}, &expiryTime))
+	assert.Equal(t, defaultKeyRotationKeepVersions, r.KeepVersions)
+
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	notifyAt, rotateAt := r.rotateAt(created)
+	assert.Equal(t, time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC), rotateAt)
+	assert.Equal(t, time.Date(2024, 6, 24, 0, 0, 0, 0, time.UTC), notifyAt)
+
+	for _, req := range []keymodels.KeyRotationPolicy{
+		{RotateAfter: "P1Y"},
+		{RotateAfter: "PT1H"},
+		{RotateAfter: "P6M", NotifyBefore: "P6M"},
+		{RotateAfter: "P6M", KeepVersions: utils.ToPtr(-1)},
+	} {
+		err := (&KeyRotationPolicyDoc{}).init(&req, &expiryTime)
+		assert.True(t, errors.Is(err, base.ErrResponseStatusBadRequest), req)
+	}
+}
+
+func TestKeyDocCheckOperationInactive(t *testing.T) {
+	now := time.Now()
+	doc := &KeyDoc{Status: keymodels.KeyStatusInactive}
+	doc.KeyOperations = []cloudkey.JsonWebKeyOperation{cloudkey.JsonWebKeyOperationSign, cloudkey.JsonWebKeyOperationVerify}
+
+	assert.NoError(t, doc.checkOperation(now, cloudkey.JsonWebKeyOperationVerify))
+	assert.Error(t, doc.checkOperation(now, cloudkey.JsonWebKeyOperationSign))
+
+	doc.Deleted = &now
+	assert.Error(t, doc.checkOperation(now, cloudkey.JsonWebKeyOperationVerify))
+}
@@ -48,16 +48,23 @@ func (*KeyAdminServer) ListKeys(ec echo.Context, namespaceProvider models.Namesp
 	return api.RespondPagerList(c, utils.NewSerializableItemsPager(modelPager))
 }
 
-func ListLatestActiveKeysByPolicyInternal(c ctx.RequestContext, namespaceProvider models.NamespaceProvider, namespaceId string, policyIdentifier resdoc.DocIdentifier) ([]string, error) {
+// ListVerifyKeysByPolicyInternal returns the latest unexpired key versions of the policy to verify signatures with,
+// including the inactive versions kept by rotation
+func ListVerifyKeysByPolicyInternal(c ctx.RequestContext, namespaceProvider models.NamespaceProvider, namespaceId string, policy *KeyPolicyDoc) ([]string, error) {
+	limit := uint(2)
+	if policy.Rotation != nil {
+		limit = uint(policy.Rotation.KeepVersions) + 1
+	}
 	qb := resdoc.NewDefaultCosmoQueryBuilder().
 		WithExtraColumns("c.status", "c.iat", "c.exp").
-		WithWhereClauses("c.status = 'active'").
+		WithWhereClauses("c.status = 'active' OR c.status = 'inactive'").
+		WithWhereClauses("NOT IS_DEFINED(c.deleted) OR IS_NULL(c.deleted)").
 		WithWhereClauses("c.policy = @policy").
 		WithWhereClauses("NOT IS_DEFINED(c.exp) OR c.exp > (GetCurrentTimestamp() / 1000)").
 		WithOrderBy("c.iat DESC").
-		WithOffsetLimit(0, 2)
+		WithOffsetLimit(0, limit)
 
-	qb.Parameters = append(qb.Parameters, azcosmos.QueryParameter{Name: "@policy", Value: policyIdentifier.String()})
+	qb.Parameters = append(qb.Parameters, azcosmos.QueryParameter{Name: "@policy", Value: policy.Identifier().String()})
 
 	pager := resdoc.NewQueryDocPager[*KeyDoc](c, qb, resdoc.PartitionKey{
 		NamespaceProvider: namespaceProvider,
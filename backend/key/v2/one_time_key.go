@@ -42,7 +42,7 @@ func (s *KeyAdminServer) CreateOneTimeKey(ec echo.Context, namespaceProvider mod
 	c := ec.(ctx.RequestContext)
 
 	namespaceId = ns.ResolveMeNamespace(c, namespaceId)
-	if _, authOk := authz.Authorize(c, authz.AllowSelf(namespaceId)); !authOk {
+	if _, authOk := authz.Authorize(c, authz.AllowAdmin, authz.AllowSelf(namespaceId)); !authOk {
 		return base.ErrResponseStatusForbidden
 	}
 
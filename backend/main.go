@@ -23,18 +23,24 @@ import (
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/stephenzsy/small-kms/backend/admin"
+	agentadmin "github.com/stephenzsy/small-kms/backend/admin/agent"
 	adminserver "github.com/stephenzsy/small-kms/backend/admin/server"
 	agentpush "github.com/stephenzsy/small-kms/backend/agent/push"
 	"github.com/stephenzsy/small-kms/backend/api"
 	"github.com/stephenzsy/small-kms/backend/base"
 	"github.com/stephenzsy/small-kms/backend/cert"
+	certv2 "github.com/stephenzsy/small-kms/backend/cert/v2"
+	"github.com/stephenzsy/small-kms/backend/common"
 	"github.com/stephenzsy/small-kms/backend/internal/auth"
 	requestcontext "github.com/stephenzsy/small-kms/backend/internal/context"
 	"github.com/stephenzsy/small-kms/backend/key"
+	keyv2 "github.com/stephenzsy/small-kms/backend/key/v2"
 	"github.com/stephenzsy/small-kms/backend/managedapp"
 	"github.com/stephenzsy/small-kms/backend/profile"
 	"github.com/stephenzsy/small-kms/backend/secret"
 	"github.com/stephenzsy/small-kms/backend/taskmanager"
+	"github.com/stephenzsy/small-kms/backend/utils/caldur"
+	"github.com/stephenzsy/small-kms/backend/webhook"
 	"golang.org/x/net/http2"
 )
 
@@ -93,6 +99,7 @@ func main() {
 	switch role {
 	case "admin":
 		e := echo.New()
+		e.Use(middleware.RequestID())
 		e.Use(middleware.RequestLoggerWithConfig(middleware.RequestLoggerConfig{
 			LogURI:    true,
 			LogStatus: true,
@@ -118,21 +125,22 @@ func main() {
 		e.Use(base.HandleResponseError)
 		e.Use(requestcontext.InjectServiceContextMiddleware(apiServer))
 		if os.Getenv("ENABLE_DEV_AUTH") == "true" {
-			e.Use(auth.UnverifiedAADJwtAuth)
+			e.Use(auth.AllowAnonymous(auth.UnverifiedAADJwtAuth, adminserver.AnonymousRoutePaths...))
 		} else {
-			e.Use(auth.ProxiedAADAuth)
+			e.Use(auth.AllowAnonymous(auth.ProxiedAADAuth, adminserver.AnonymousRoutePaths...))
 		}
-		profile.RegisterHandlers(e, profile.NewServer(apiServer))
-		managedapp.RegisterHandlers(e, managedapp.NewServer(apiServer))
-		cert.RegisterHandlers(e, cert.NewServer(apiServer))
-		agentpush.RegisterHandlers(e, agentpush.NewProxiedServer(apiServer))
-		secret.RegisterHandlers(e, secret.NewServer(apiServer))
-		key.RegisterHandlers(e, key.NewServer(apiServer))
-		adminServer, err := adminserver.NewServer(apiServer)
-		if err != nil {
+		e.Use(adminserver.NewRateLimiter())
+		if err := registerAdminHandlers(ctx, e, apiServer); err != nil {
 			logger.Fatal().Err(err).Msg("failed to initialize admin server")
 		}
-		admin.RegisterHandlers(e, adminServer)
+		certRenewalWindow, err := caldur.Parse(common.LookupEnvWithDefault(certv2.EnvKeyCertRenewalWindow, certv2.DefaultCertRenewalWindow))
+		if err != nil {
+			logger.Fatal().Err(err).Msg("invalid certificate renewal window")
+		}
+		certExpiryNotificationWindow, err := caldur.Parse(common.LookupEnvWithDefault(certv2.EnvKeyCertExpiryNotificationWindow, certv2.DefaultCertExpiryNotificationWindow))
+		if err != nil {
+			logger.Fatal().Err(err).Msg("invalid certificate expiry notification window")
+		}
 
 		tm := taskmanager.NewChainedTaskManager().WithTask(
 			taskmanager.NewTask("echo", func(c context.Context, sigCh <-chan os.Signal) error {
@@ -148,8 +156,42 @@ func main() {
 				}
 				<-sigCh
 				return e.Shutdown(c)
-			}))
+			})).
+			WithTask(taskmanager.IntervalExecutorTask(certv2.NewACMEOrderPollTaskExecutor(apiServer), 0)).
+			WithTask(taskmanager.IntervalExecutorTask(certv2.NewCertRenewalTaskExecutor(apiServer, certRenewalWindow), 0)).
+			WithTask(taskmanager.IntervalExecutorTask(certv2.NewCertExpiryScanTaskExecutor(apiServer, certExpiryNotificationWindow), 0)).
+			WithTask(taskmanager.IntervalExecutorTask(keyv2.NewKeyRotationTaskExecutor(apiServer, agentadmin.RefreshAgentConfigEndpointOnKeyRotated), 0)).
+			WithTask(taskmanager.IntervalExecutorTask(webhook.NewDeliveryTaskExecutor(apiServer), 0))
 		logger.Fatal().Err(taskmanager.StartWithGracefulShutdown(ctx, tm)).Msg("task manager exited")
 	}
 
 }
+
+// adminAPIServer is the api server which also serves as the service context of requests
+type adminAPIServer interface {
+	api.APIServer
+	context.Context
+}
+
+func registerAdminHandlers(c context.Context, e *echo.Echo, apiServer adminAPIServer) error {
+	legacyRouter := api.NewLegacyRouter(e, apiServer)
+	profile.RegisterHandlers(legacyRouter, profile.NewServer(apiServer))
+	managedapp.RegisterHandlers(legacyRouter, managedapp.NewServer(apiServer))
+	cert.RegisterHandlers(legacyRouter, cert.NewServer(apiServer))
+	agentpush.RegisterHandlers(legacyRouter, agentpush.NewProxiedServer(apiServer))
+	secret.RegisterHandlers(legacyRouter, secret.NewServer(apiServer))
+	key.RegisterHandlers(legacyRouter, key.NewServer(apiServer))
+	if disabledRoutes := legacyRouter.DisabledRoutes(); len(disabledRoutes) > 0 {
+		routes := make([]string, len(disabledRoutes))
+		for i, r := range disabledRoutes {
+			routes[i] = r.Method + " " + r.Path
+		}
+		log.Ctx(c).Warn().Strs("routes", routes).Msg("v1 routes are disabled without the cosmos doc service and respond 501")
+	}
+	adminServer, err := adminserver.NewServer(apiServer)
+	if err != nil {
+		return err
+	}
+	admin.RegisterHandlers(e, adminServer)
+	return nil
+}
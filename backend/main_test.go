@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stephenzsy/small-kms/backend/api"
+	"github.com/stephenzsy/small-kms/backend/base"
+	requestcontext "github.com/stephenzsy/small-kms/backend/internal/context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterAdminHandlersEmbedded(t *testing.T) {
+	masterKey := make([]byte, 32)
+	_, err := rand.Read(masterKey)
+	require.NoError(t, err)
+	t.Setenv("AZURE_TENANT_ID", "00000000-0000-0000-0000-000000000001")
+	t.Setenv("AZURE_CLIENT_ID", "00000000-0000-0000-0000-000000000002")
+	t.Setenv("AZURE_CLIENT_SECRET", "secret")
+	t.Setenv("DOC_SERVICE_BACKEND", "embedded")
+	t.Setenv("EMBEDDED_DB_FILE", filepath.Join(t.TempDir(), "kms.db"))
+	t.Setenv("KEY_STORE_BACKEND", "local")
+	t.Setenv("LOCAL_KEY_STORE_DIR", t.TempDir())
+	t.Setenv("LOCAL_KEY_STORE_MASTER_KEY", base64.StdEncoding.EncodeToString(masterKey))
+
+	apiServer, err := api.NewApiServer(context.Background(), "test")
+	require.NoError(t, err)
+	e := echo.New()
+	e.Use(base.HandleResponseError)
+	e.Use(requestcontext.InjectServiceContextMiddleware(apiServer))
+	require.NoError(t, registerAdminHandlers(context.Background(), e, apiServer))
+
+	for _, target := range []string{
+		"/v1/service-principal/00000000-0000-0000-0000-000000000003/secret-policies",
+		"/v1/service-principal/00000000-0000-0000-0000-000000000003/secrets/test",
+	} {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		assert.Equal(t, http.StatusNotImplemented, rec.Code, target)
+	}
+}
@@ -12,8 +12,25 @@ import (
 
 // Defines values for CertificateFlag.
 const (
-	CertificateFlagClientAuth CertificateFlag = "clientAuth"
-	CertificateFlagServerAuth CertificateFlag = "serverAuth"
+	CertificateFlagClientAuth      CertificateFlag = "clientAuth"
+	CertificateFlagCodeSigning     CertificateFlag = "codeSigning"
+	CertificateFlagDocumentSigning CertificateFlag = "documentSigning"
+	CertificateFlagEmailProtection CertificateFlag = "emailProtection"
+	CertificateFlagOCSPSigning     CertificateFlag = "ocspSigning"
+	CertificateFlagServerAuth      CertificateFlag = "serverAuth"
+	CertificateFlagTimeStamping    CertificateFlag = "timeStamping"
+)
+
+// Defines values for CertificateRevocationReason.
+const (
+	RevocationReasonAACompromise         CertificateRevocationReason = "aACompromise"
+	RevocationReasonAffiliationChanged   CertificateRevocationReason = "affiliationChanged"
+	RevocationReasonCACompromise         CertificateRevocationReason = "cACompromise"
+	RevocationReasonCessationOfOperation CertificateRevocationReason = "cessationOfOperation"
+	RevocationReasonKeyCompromise        CertificateRevocationReason = "keyCompromise"
+	RevocationReasonPrivilegeWithdrawn   CertificateRevocationReason = "privilegeWithdrawn"
+	RevocationReasonSuperseded           CertificateRevocationReason = "superseded"
+	RevocationReasonUnspecified          CertificateRevocationReason = "unspecified"
 )
 
 // Defines values for CertificateStatus.
@@ -26,6 +43,31 @@ const (
 	CertificateStatusUnverified           CertificateStatus = "unverified"
 )
 
+// Defines values for SSHCertificateType.
+const (
+	SSHCertificateTypeHost SSHCertificateType = "host"
+	SSHCertificateTypeUser SSHCertificateType = "user"
+)
+
+// AcmeExternalAccountBinding defines model for AcmeExternalAccountBinding.
+type AcmeExternalAccountBinding struct {
+	DirectoryURL string                   `json:"directoryUrl"`
+	Exp          externalRef0.NumericDate `json:"exp"`
+
+	// HmacKey Base64url encoded HMAC key to sign the binding JWS, only returned once
+	HmacKey string `json:"hmacKey"`
+
+	// KeyID Key identifier of the external account binding, the "kid" of the binding JWS
+	KeyID string `json:"keyId"`
+}
+
+// AcmeHttp01Challenge defines model for AcmeHttp01Challenge.
+type AcmeHttp01Challenge struct {
+	Domain           string `json:"domain"`
+	KeyAuthorization string `json:"keyAuthorization"`
+	Token            string `json:"token"`
+}
+
 // Certificate defines model for Certificate.
 type Certificate = certificateComposed
 
@@ -39,6 +81,31 @@ type CertificateExternalIssuerAcme struct {
 	AzureDNSZoneResourceID string   `json:"azureDnsZoneResourceId"`
 	Contacts               []string `json:"contacts"`
 	DirectoryURL           string   `json:"directoryUrl"`
+
+	// Dns01Solver Publish dns-01 challenges with RFC 2136 dynamic updates
+	Dns01Solver *CertificateExternalIssuerAcmeDns01Solver `json:"dns01Solver,omitempty"`
+
+	// Http01Solver Serve http-01 challenges from the agent instances of the service principal
+	Http01Solver *CertificateExternalIssuerAcmeHttp01Solver `json:"http01Solver,omitempty"`
+}
+
+// CertificateExternalIssuerAcmeDns01Solver Publish dns-01 challenges with RFC 2136 dynamic updates
+type CertificateExternalIssuerAcmeDns01Solver struct {
+	// Nameserver Host and port of the primary nameserver accepting updates
+	Nameserver string `json:"nameserver"`
+
+	// TsigAlgorithm TSIG algorithm, defaults to hmac-sha256
+	TsigAlgorithm string `json:"tsigAlgorithm,omitempty"`
+	TsigKeyName   string `json:"tsigKeyName"`
+
+	// TsigSecret Base64 encoded TSIG secret, write only
+	TsigSecret string `json:"tsigSecret,omitempty"`
+	Zone       string `json:"zone"`
+}
+
+// CertificateExternalIssuerAcmeHttp01Solver Serve http-01 challenges from the agent instances of the service principal
+type CertificateExternalIssuerAcmeHttp01Solver struct {
+	AgentNamespaceID string `json:"agentNamespaceId"`
 }
 
 // CertificateExternalIssuerFields defines model for CertificateExternalIssuerFields.
@@ -56,6 +123,7 @@ type CertificateFields struct {
 	Jwk                   *externalRef1.JsonWebKey `json:"jwk,omitempty"`
 	Nbf                   externalRef0.NumericDate `json:"nbf"`
 	PendingAcme           *CertificatePendingAcme  `json:"pendingAcme,omitempty"`
+	Revocation            *CertificateRevocation   `json:"revocation,omitempty"`
 	SerialNumber          string                   `json:"serialNumber"`
 
 	// Sid Key Vault Secret ID
@@ -67,6 +135,16 @@ type CertificateFields struct {
 // CertificateFlag defines model for CertificateFlag.
 type CertificateFlag string
 
+// CertificateNameConstraints Name constraints of an intermediate CA certificate as defined in RFC 5280 section 4.2.1.10
+type CertificateNameConstraints struct {
+	ExcludedDNSDomains      []string `json:"excludedDnsDomains,omitempty"`
+	ExcludedEmailAddresses  []string `json:"excludedEmailAddresses,omitempty"`
+	ExcludedIPRanges        []string `json:"excludedIpRanges,omitempty"`
+	PermittedDNSDomains     []string `json:"permittedDnsDomains,omitempty"`
+	PermittedEmailAddresses []string `json:"permittedEmailAddresses,omitempty"`
+	PermittedIPRanges       []string `json:"permittedIpRanges,omitempty"`
+}
+
 // CertificatePendingAcme defines model for CertificatePendingAcme.
 type CertificatePendingAcme struct {
 	Authorizations []CertificatePendingAcmeAuthorization `json:"authorizations,omitempty"`
@@ -95,33 +173,53 @@ type CertificatePolicy = certificatePolicyComposed
 
 // CertificatePolicyFields defines model for CertificatePolicyFields.
 type CertificatePolicyFields struct {
-	AllowEnroll   bool              `json:"allowEnroll"`
-	AllowGenerate bool              `json:"allowGenerate"`
-	ExpiryTime    string            `json:"expiryTime"`
-	Flags         []CertificateFlag `json:"flags,omitempty"`
+	AllowEnroll   bool `json:"allowEnroll"`
+	AllowGenerate bool `json:"allowGenerate"`
+
+	// AllowTimestamping Serve RFC 3161 time-stamping requests with a TSA certificate issued by the current issuer certificate, intermediate CA only
+	AllowTimestamping bool              `json:"allowTimestamping,omitempty"`
+	ExpiryTime        string            `json:"expiryTime"`
+	Flags             []CertificateFlag `json:"flags,omitempty"`
 
 	// IssuerPolicyIdentifier Policy identififier of parent issuer
 	IssuerPolicyIdentifier string `json:"issuerPolicyIdentifier"`
 
 	// KeySpec these attributes should mostly confirm to JWK (RFC7517)
-	KeySpec                 externalRef1.JsonWebKeySpec `json:"keySpec"`
-	Subject                 CertificateSubject          `json:"subject"`
-	SubjectAlternativeNames *SubjectAlternativeNames    `json:"subjectAlternativeNames,omitempty"`
+	KeySpec externalRef1.JsonWebKeySpec `json:"keySpec"`
+
+	// MaxPathLen Maximum number of intermediate CA certificates below the CA certificate, intermediate CA only, must be less than the path length of the root CA
+	MaxPathLen      *int                        `json:"maxPathLen,omitempty"`
+	NameConstraints *CertificateNameConstraints `json:"nameConstraints,omitempty"`
+
+	// PolicyIdentifiers Certificate policy OIDs in dotted decimal notation, intermediate CA only
+	PolicyIdentifiers       []string                 `json:"policyIdentifiers,omitempty"`
+	Subject                 CertificateSubject       `json:"subject"`
+	SubjectAlternativeNames *SubjectAlternativeNames `json:"subjectAlternativeNames,omitempty"`
 }
 
 // CertificatePolicyParameters defines model for CertificatePolicyParameters.
 type CertificatePolicyParameters struct {
-	AllowEnroll            *bool             `json:"allowEnroll,omitempty"`
-	AllowGenerate          *bool             `json:"allowGenerate,omitempty"`
+	AllowEnroll   *bool `json:"allowEnroll,omitempty"`
+	AllowGenerate *bool `json:"allowGenerate,omitempty"`
+
+	// AllowTimestamping Serve RFC 3161 time-stamping requests with a TSA certificate issued by the current issuer certificate, intermediate CA only
+	AllowTimestamping      *bool             `json:"allowTimestamping,omitempty"`
 	DisplayName            string            `json:"displayName,omitempty"`
 	ExpiryTime             string            `json:"expiryTime,omitempty"`
 	Flags                  []CertificateFlag `json:"flags,omitempty"`
 	IssuerPolicyIdentifier string            `json:"issuerPolicyIdentifier,omitempty"`
 
 	// KeySpec these attributes should mostly confirm to JWK (RFC7517)
-	KeySpec                 *externalRef1.JsonWebKeySpec `json:"keySpec,omitempty"`
-	Subject                 CertificateSubject           `json:"subject"`
-	SubjectAlternativeNames *SubjectAlternativeNames     `json:"subjectAlternativeNames,omitempty"`
+	KeySpec *externalRef1.JsonWebKeySpec `json:"keySpec,omitempty"`
+
+	// MaxPathLen Maximum number of intermediate CA certificates below the CA certificate, intermediate CA only, must be less than the path length of the root CA
+	MaxPathLen      *int                        `json:"maxPathLen,omitempty"`
+	NameConstraints *CertificateNameConstraints `json:"nameConstraints,omitempty"`
+
+	// PolicyIdentifiers Certificate policy OIDs in dotted decimal notation, intermediate CA only
+	PolicyIdentifiers       []string                 `json:"policyIdentifiers,omitempty"`
+	Subject                 CertificateSubject       `json:"subject"`
+	SubjectAlternativeNames *SubjectAlternativeNames `json:"subjectAlternativeNames,omitempty"`
 }
 
 // CertificateRef defines model for CertificateRef.
@@ -138,9 +236,28 @@ type CertificateRefFields struct {
 	Thumbprint string `json:"thumbprint"`
 }
 
+// CertificateRevocation defines model for CertificateRevocation.
+type CertificateRevocation struct {
+	// CascadedFrom Identifier of the issuer certificate whose revocation caused this certificate to be revoked
+	CascadedFrom string `json:"cascadedFrom,omitempty"`
+
+	// Reason CRL reason code as defined in RFC 5280 section 5.3.1, revocation is permanent so certificateHold and removeFromCRL are not supported
+	Reason    CertificateRevocationReason `json:"reason"`
+	RevokedAt externalRef0.NumericDate    `json:"revokedAt"`
+
+	// RevokedBy Display name of the principal who revoked the certificate
+	RevokedBy string `json:"revokedBy"`
+}
+
+// CertificateRevocationReason CRL reason code as defined in RFC 5280 section 5.3.1, revocation is permanent so certificateHold and removeFromCRL are not supported
+type CertificateRevocationReason string
+
 // CertificateSecretRequest defines model for CertificateSecretRequest.
 type CertificateSecretRequest struct {
 	Jwk externalRef1.JsonWebKey `json:"jwk"`
+
+	// Jwks Additional public keys of the requester, an ML-KEM-768 key enables the hybrid ECDH-ES+ML-KEM-768 key agreement
+	Jwks []externalRef1.JsonWebKey `json:"jwks,omitempty"`
 }
 
 // CertificateSecretResult defines model for CertificateSecretResult.
@@ -154,12 +271,25 @@ type CertificateStatus string
 
 // CertificateSubject defines model for CertificateSubject.
 type CertificateSubject struct {
-	CommonName string `json:"cn"`
+	Country            string `json:"c,omitempty"`
+	CommonName         string `json:"cn"`
+	Locality           string `json:"l,omitempty"`
+	Organization       string `json:"o,omitempty"`
+	OrganizationalUnit string `json:"ou,omitempty"`
+	Province           string `json:"st,omitempty"`
+}
+
+// CreateAcmeExternalAccountBindingRequest defines model for CreateAcmeExternalAccountBindingRequest.
+type CreateAcmeExternalAccountBindingRequest struct {
+	// PolicyIdentifier Identifier of the intermediate CA certificate policy serving the ACME directory
+	PolicyIdentifier string `json:"policyIdentifier"`
 }
 
 // EnrollCertificateRequest defines model for EnrollCertificateRequest.
 type EnrollCertificateRequest struct {
-	PublicKey externalRef1.JsonWebKey `json:"publicKey"`
+	// CSR PEM or base64 encoded DER PKCS#10 certificate signing request, proves possession of the private key
+	CSR       string                  `json:"csr,omitempty"`
+	PublicKey externalRef1.JsonWebKey `json:"publicKey,omitempty"`
 }
 
 // ExchangePKCS12Request defines model for ExchangePKCS12Request.
@@ -183,11 +313,89 @@ type ExchangePKCS12Result struct {
 	Payload string `json:"payload"`
 }
 
+// RevokeCertificateRequest defines model for RevokeCertificateRequest.
+type RevokeCertificateRequest struct {
+	// Reason CRL reason code as defined in RFC 5280 section 5.3.1, revocation is permanent so certificateHold and removeFromCRL are not supported
+	Reason CertificateRevocationReason `json:"reason"`
+}
+
+// SSHCAPolicy defines model for SSHCAPolicy.
+type SSHCAPolicy = sshCAPolicyComposed
+
+// SSHCAPolicyFields defines model for SSHCAPolicyFields.
+type SSHCAPolicyFields struct {
+	AllowEnroll bool `json:"allowEnroll"`
+
+	// CAPublicKey public key of the CA in authorized_keys format, for TrustedUserCAKeys or @cert-authority
+	CAPublicKey     string             `json:"caPublicKey"`
+	CertType        SSHCertificateType `json:"certType"`
+	CriticalOptions map[string]string  `json:"criticalOptions,omitempty"`
+	Extensions      map[string]string  `json:"extensions,omitempty"`
+	Principals      []string           `json:"principals"`
+	Validity        string             `json:"validity"`
+}
+
+// SSHCAPolicyParameters defines model for SSHCAPolicyParameters.
+type SSHCAPolicyParameters struct {
+	// AllowEnroll allow principals with the enroll role to sign their own keys
+	AllowEnroll bool               `json:"allowEnroll,omitempty"`
+	CertType    SSHCertificateType `json:"certType"`
+
+	// CriticalOptions force-command, source-address or verify-required, user certificates only
+	CriticalOptions map[string]string `json:"criticalOptions,omitempty"`
+	DisplayName     string            `json:"displayName,omitempty"`
+
+	// Extensions user certificates only, defaults to the extensions of ssh-keygen, permit-pty etc.
+	Extensions map[string]string `json:"extensions,omitempty"`
+
+	// KeySpec these attributes should mostly confirm to JWK (RFC7517)
+	KeySpec *externalRef1.JsonWebKeySpec `json:"keySpec,omitempty"`
+
+	// Principals templates of the principals, e.g. {{requester.graph.id}}
+	Principals []string `json:"principals"`
+
+	// Validity ISO 8601 duration, defaults to PT8H for user certificates and P30D for host certificates
+	Validity string `json:"validity,omitempty"`
+}
+
+// SSHCertificate defines model for SSHCertificate.
+type SSHCertificate = sshCertificateComposed
+
+// SSHCertificateFields defines model for SSHCertificateFields.
+type SSHCertificateFields struct {
+	CertType SSHCertificateType `json:"certType"`
+
+	// Certificate certificate in authorized_keys format
+	Certificate string                   `json:"certificate"`
+	Exp         externalRef0.NumericDate `json:"exp"`
+	KeyID       string                   `json:"keyId"`
+	Nbf         externalRef0.NumericDate `json:"nbf"`
+	Policy      string                   `json:"policy"`
+	Principals  []string                 `json:"principals"`
+
+	// Serial serial number in decimal
+	Serial string            `json:"serial"`
+	Status CertificateStatus `json:"status"`
+}
+
+// SSHCertificateType defines model for SSHCertificateType.
+type SSHCertificateType string
+
+// SignSSHCertificateRequest defines model for SignSSHCertificateRequest.
+type SignSSHCertificateRequest struct {
+	// Principals subset of the principals of the policy, defaults to all of them
+	Principals []string `json:"principals,omitempty"`
+
+	// PublicKey public key in authorized_keys format
+	PublicKey string `json:"publicKey"`
+}
+
 // SubjectAlternativeNames defines model for SubjectAlternativeNames.
 type SubjectAlternativeNames struct {
 	DNSNames    []string `json:"dnsNames,omitempty"`
 	Emails      []string `json:"emails,omitempty"`
 	IPAddresses []net.IP `json:"ipAddresses,omitempty"`
+	URIs        []string `json:"uris,omitempty"`
 }
 
 // UpdatePendingCertificateRequest defines model for UpdatePendingCertificateRequest.
@@ -196,6 +404,12 @@ type UpdatePendingCertificateRequest struct {
 	AcmeOrderCertificate *bool  `json:"acmeOrderCertificate,omitempty"`
 }
 
+// AcmeExternalAccountBindingResponse defines model for AcmeExternalAccountBindingResponse.
+type AcmeExternalAccountBindingResponse = AcmeExternalAccountBinding
+
+// AcmeHttp01ChallengeResponse defines model for AcmeHttp01ChallengeResponse.
+type AcmeHttp01ChallengeResponse = AcmeHttp01Challenge
+
 // CertificateExternalIssuerResponse defines model for CertificateExternalIssuerResponse.
 type CertificateExternalIssuerResponse = CertificateExternalIssuer
 
@@ -207,3 +421,9 @@ type CertificateRefsResponse = []CertificateRef
 
 // CertificateResponse defines model for CertificateResponse.
 type CertificateResponse = Certificate
+
+// SSHCAPolicyResponse defines model for SSHCAPolicyResponse.
+type SSHCAPolicyResponse = SSHCAPolicy
+
+// SSHCertificateResponse defines model for SSHCertificateResponse.
+type SSHCertificateResponse = SSHCertificate
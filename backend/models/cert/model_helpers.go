@@ -5,6 +5,7 @@ import (
 	"io"
 	"net"
 	"net/mail"
+	"net/url"
 	"slices"
 	"strings"
 
@@ -31,6 +32,16 @@ type (
 		models.Ref
 		CertificateExternalIssuerFields
 	}
+
+	sshCAPolicyComposed struct {
+		models.Ref
+		SSHCAPolicyFields
+	}
+
+	sshCertificateComposed struct {
+		models.Ref
+		SSHCertificateFields
+	}
 )
 
 func (cs *CertificateSubject) String() string {
@@ -38,15 +49,39 @@ func (cs *CertificateSubject) String() string {
 }
 
 func (cs *CertificateSubject) ToPkixName() pkix.Name {
-	return pkix.Name{
+	name := pkix.Name{
 		CommonName: cs.CommonName,
 	}
+	if cs.Country != "" {
+		name.Country = []string{cs.Country}
+	}
+	if cs.Organization != "" {
+		name.Organization = []string{cs.Organization}
+	}
+	if cs.OrganizationalUnit != "" {
+		name.OrganizationalUnit = []string{cs.OrganizationalUnit}
+	}
+	if cs.Locality != "" {
+		name.Locality = []string{cs.Locality}
+	}
+	if cs.Province != "" {
+		name.Province = []string{cs.Province}
+	}
+	return name
+}
+
+// hasTemplate reports whether the value contains template variables,
+// such values are kept as is and sanitized once the template is evaluated
+func hasTemplate(s string) bool {
+	return strings.Contains(s, "{{")
 }
 
 func SanitizeDNSNames(dnsNames []string) []string {
 	for i, v := range dnsNames {
 		v = strings.TrimSpace(v)
-		v = strings.ToLower(v)
+		if !hasTemplate(v) {
+			v = strings.ToLower(v)
+		}
 		dnsNames[i] = v
 	}
 	dnsNames = slices.DeleteFunc(dnsNames, func(s string) bool {
@@ -63,7 +98,9 @@ func SanitizeDNSNames(dnsNames []string) []string {
 func SanitizeEmailAddresses(emailAddresses []string) []string {
 	sanitized := make([]string, 0, len(emailAddresses))
 	for _, v := range emailAddresses {
-		if parsed, err := mail.ParseAddress(v); err == nil {
+		if v = strings.TrimSpace(v); hasTemplate(v) {
+			sanitized = append(sanitized, v)
+		} else if parsed, err := mail.ParseAddress(v); err == nil {
 			sanitized = append(sanitized, parsed.Address)
 		}
 	}
@@ -91,6 +128,23 @@ func SanitizeIpAddresses(ips []net.IP) []net.IP {
 	return ips
 }
 
+func SanitizeURIs(uris []string) []string {
+	sanitized := make([]string, 0, len(uris))
+	for _, v := range uris {
+		if v = strings.TrimSpace(v); hasTemplate(v) {
+			sanitized = append(sanitized, v)
+		} else if parsed, err := url.Parse(v); err == nil && parsed.IsAbs() {
+			sanitized = append(sanitized, parsed.String())
+		}
+	}
+	slices.Sort(sanitized)
+	sanitized = slices.Compact(sanitized)
+	if len(sanitized) == 0 {
+		return nil
+	}
+	return sanitized
+}
+
 func (sans *SubjectAlternativeNames) Sanitize() *SubjectAlternativeNames {
 	if sans == nil {
 		return nil
@@ -98,8 +152,9 @@ func (sans *SubjectAlternativeNames) Sanitize() *SubjectAlternativeNames {
 	sans.DNSNames = SanitizeDNSNames(sans.DNSNames)
 	sans.Emails = SanitizeEmailAddresses(sans.Emails)
 	sans.IPAddresses = SanitizeIpAddresses(sans.IPAddresses)
+	sans.URIs = SanitizeURIs(sans.URIs)
 
-	if sans.DNSNames == nil && sans.Emails == nil && sans.IPAddresses == nil {
+	if sans.DNSNames == nil && sans.Emails == nil && sans.IPAddresses == nil && sans.URIs == nil {
 		return nil
 	}
 	return sans
@@ -118,4 +173,36 @@ func (sans *SubjectAlternativeNames) Digest(w io.Writer) {
 	for _, v := range sans.IPAddresses {
 		w.Write(v)
 	}
+	for _, v := range sans.URIs {
+		io.WriteString(w, v)
+	}
+}
+
+var revocationReasonCodes = map[CertificateRevocationReason]int{
+	RevocationReasonUnspecified:          0,
+	RevocationReasonKeyCompromise:        1,
+	RevocationReasonCACompromise:         2,
+	RevocationReasonAffiliationChanged:   3,
+	RevocationReasonSuperseded:           4,
+	RevocationReasonCessationOfOperation: 5,
+	RevocationReasonPrivilegeWithdrawn:   9,
+	RevocationReasonAACompromise:         10,
+}
+
+// ReasonCode returns the CRLReason code defined in RFC 5280 section 5.3.1,
+// ok is false if the reason is not supported
+func (r CertificateRevocationReason) ReasonCode() (code int, ok bool) {
+	code, ok = revocationReasonCodes[r]
+	return
+}
+
+// RevocationReasonFromCode returns the reason of a CRLReason code defined in RFC 5280 section 5.3.1,
+// ok is false if the code is not supported
+func RevocationReasonFromCode(code int) (reason CertificateRevocationReason, ok bool) {
+	for r, c := range revocationReasonCodes {
+		if c == code {
+			return r, true
+		}
+	}
+	return "", false
 }
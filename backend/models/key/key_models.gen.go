@@ -8,6 +8,12 @@ import (
 	externalRef0 "github.com/stephenzsy/small-kms/backend/models"
 )
 
+// Defines values for KeyOrigin.
+const (
+	KeyOriginGenerated KeyOrigin = "generated"
+	KeyOriginImported  KeyOrigin = "imported"
+)
+
 // Defines values for KeyStatus.
 const (
 	KeyStatusActive   KeyStatus = "active"
@@ -22,6 +28,41 @@ type CreateKeyPolicyRequest struct {
 
 	// KeySpec these attributes should mostly confirm to JWK (RFC7517)
 	KeySpec *JsonWebKeySpec `json:"keySpec,omitempty"`
+
+	// Rotation a new key version is generated once the latest version is older than rotateAfter
+	Rotation *KeyRotationPolicy `json:"rotation,omitempty"`
+}
+
+// DataKey defines model for DataKey.
+type DataKey struct {
+	// Ciphertext JWE compact serialization of the wrapped data key
+	Ciphertext string                        `json:"ciphertext,omitempty"`
+	Kid        string                        `json:"kid"`
+	Plaintext  externalRef0.Base64URLEncoded `json:"plaintext"`
+}
+
+// DecryptDataKeyRequest defines model for DecryptDataKeyRequest.
+type DecryptDataKeyRequest struct {
+	// Ciphertext JWE compact serialization of the wrapped data key
+	Ciphertext string `json:"ciphertext"`
+
+	// EncryptionContext bound to the wrapped data key as additional authenticated data, the same context must be supplied to decrypt
+	EncryptionContext EncryptionContext `json:"encryptionContext,omitempty"`
+}
+
+// EncryptionContext bound to the wrapped data key as additional authenticated data, the same context must be supplied to decrypt
+type EncryptionContext map[string]string
+
+// GenerateDataKeyRequest defines model for GenerateDataKeyRequest.
+type GenerateDataKeyRequest struct {
+	// EncryptionContext bound to the wrapped data key as additional authenticated data, the same context must be supplied to decrypt
+	EncryptionContext EncryptionContext `json:"encryptionContext,omitempty"`
+}
+
+// ImportKeyRequest defines model for ImportKeyRequest.
+type ImportKeyRequest struct {
+	// Payload private key in JWK, JWE encrypted to a one time key of the namespace
+	Payload string `json:"payload"`
 }
 
 // JsonWebKey defines model for JsonWebKey.
@@ -30,16 +71,26 @@ type JsonWebKey = cloudkey.JsonWebKey
 // JsonWebKeyCurveName defines model for JsonWebKeyCurveName.
 type JsonWebKeyCurveName = cloudkey.JsonWebKeyCurveName
 
+// JsonWebKeyEncryptionAlgorithm defines model for JsonWebKeyEncryptionAlgorithm.
+type JsonWebKeyEncryptionAlgorithm = cloudkey.JsonWebKeyEncryptionAlgorithm
+
 // JsonWebKeyOperation defines model for JsonWebKeyOperation.
 type JsonWebKeyOperation = cloudkey.JsonWebKeyOperation
 
+// JsonWebKeySet RFC7517 5. JWK Set
+type JsonWebKeySet struct {
+	Keys []JsonWebKey `json:"keys"`
+}
+
 // JsonWebKeySpec these attributes should mostly confirm to JWK (RFC7517)
 type JsonWebKeySpec struct {
+	// Alg for oct keys, HS256, HS384, HS512 or A256GCM, the key size follows the algorithm
 	Alg           string                `json:"alg,omitempty"`
 	Crv           JsonWebKeyCurveName   `json:"crv,omitempty"`
 	Extractable   *bool                 `json:"ext,omitempty"`
 	KeyOperations []JsonWebKeyOperation `json:"key_ops,omitempty"`
 	KeySize       *int                  `json:"key_size,omitempty"`
+	KeyStore      KeyStoreKind          `json:"keyStore,omitempty"`
 	Kty           JsonWebKeyType        `json:"kty,omitempty"`
 }
 
@@ -58,10 +109,29 @@ type KeyFields struct {
 	Jwk          JsonWebKey                `json:"jwk"`
 	Nbf          *externalRef0.NumericDate `json:"nbf,omitempty"`
 
+	// Origin how the key material came into the key store
+	Origin KeyOrigin `json:"origin,omitempty"`
+
 	// Sid Key Vault Secret ID
 	KeyVaultSecretID string `json:"sid,omitempty"`
 }
 
+// KeyOperationRequest defines model for KeyOperationRequest.
+type KeyOperationRequest struct {
+	Alg   JsonWebKeyEncryptionAlgorithm `json:"alg"`
+	Value externalRef0.Base64URLEncoded `json:"value"`
+}
+
+// KeyOperationResult defines model for KeyOperationResult.
+type KeyOperationResult struct {
+	Alg   string                        `json:"alg"`
+	Kid   string                        `json:"kid"`
+	Value externalRef0.Base64URLEncoded `json:"value"`
+}
+
+// KeyOrigin how the key material came into the key store
+type KeyOrigin string
+
 // KeyPolicy defines model for KeyPolicy.
 type KeyPolicy = keyPolicyComposed
 
@@ -71,6 +141,9 @@ type KeyPolicyFields struct {
 
 	// KeySpec these attributes should mostly confirm to JWK (RFC7517)
 	KeySpec JsonWebKeySpec `json:"keySpec"`
+
+	// Rotation a new key version is generated once the latest version is older than rotateAfter
+	Rotation *KeyRotationPolicy `json:"rotation,omitempty"`
 }
 
 // KeyRef defines model for KeyRef.
@@ -84,9 +157,45 @@ type KeyRefFields struct {
 	Status           KeyStatus                 `json:"status"`
 }
 
+// KeySignRequest defines model for KeySignRequest.
+type KeySignRequest struct {
+	Alg JsonWebSignatureAlgorithm `json:"alg"`
+	// Digest digest of the message, or the message itself for EdDSA and HMAC
+	Digest externalRef0.Base64URLEncoded `json:"digest"`
+}
+
+// KeyRotationPolicy a new key version is generated once the latest version is older than rotateAfter
+type KeyRotationPolicy struct {
+	// KeepVersions number of inactive versions kept for verify and decrypt, older versions are retired, defaults to 1
+	KeepVersions *int `json:"keepVersions,omitempty"`
+
+	// NotifyBefore ISO 8601 duration before rotation to publish the rotating event
+	NotifyBefore string `json:"notifyBefore,omitempty"`
+
+	// RotateAfter ISO 8601 duration since the latest key version was created
+	RotateAfter string `json:"rotateAfter"`
+}
+
 // KeyStatus defines model for KeyStatus.
 type KeyStatus string
 
+// KeyStoreKind where the private key is kept, defaults to the configured default key store
+type KeyStoreKind = cloudkey.KeyStoreKind
+
+// KeyVerifyRequest defines model for KeyVerifyRequest.
+type KeyVerifyRequest struct {
+	Alg JsonWebSignatureAlgorithm `json:"alg"`
+	// Digest digest of the message, or the message itself for EdDSA and HMAC
+	Digest    externalRef0.Base64URLEncoded `json:"digest"`
+	Signature externalRef0.Base64URLEncoded `json:"signature"`
+}
+
+// KeyVerifyResult defines model for KeyVerifyResult.
+type KeyVerifyResult struct {
+	Kid   string `json:"kid"`
+	Valid bool   `json:"valid"`
+}
+
 // OneTimeKey OneTimeKey
 type OneTimeKey struct {
 	Exp externalRef0.NumericDate `json:"exp"`
@@ -94,6 +203,15 @@ type OneTimeKey struct {
 	Jwk JsonWebKey               `json:"jwk"`
 }
 
+// DataKeyResponse defines model for DataKeyResponse.
+type DataKeyResponse = DataKey
+
+// JsonWebKeySetResponse defines model for JsonWebKeySetResponse.
+type JsonWebKeySetResponse = JsonWebKeySet
+
+// KeyOperationResponse defines model for KeyOperationResponse.
+type KeyOperationResponse = KeyOperationResult
+
 // KeyPolicyResponse defines model for KeyPolicyResponse.
 type KeyPolicyResponse = KeyPolicy
 
@@ -102,3 +220,6 @@ type KeyRefsResponse = []KeyRef
 
 // KeyResponse defines model for KeyResponse.
 type KeyResponse = Key
+
+// KeyVerifyResponse defines model for KeyVerifyResponse.
+type KeyVerifyResponse = KeyVerifyResult
@@ -36,4 +36,7 @@ func (jwkspec *JsonWebKeySpec) Digest(w io.Writer) {
 	if jwkspec.Extractable != nil {
 		io.WriteString(w, "ext")
 	}
+	if jwkspec.KeyStore != "" {
+		w.Write([]byte(jwkspec.KeyStore))
+	}
 }
@@ -10,6 +10,7 @@ const (
 	ProfileResourceProviderServicePrincipal ResourceProvider = "service-principal"
 	ProfileResourceProviderUser             ResourceProvider = "user"
 	ProfileResourceProviderGroup            ResourceProvider = "group"
+	ProfileResourceProviderDevice           ResourceProvider = "device"
 	ResourceProviderAgentConfig             ResourceProvider = "agent-config"
 	ResourceProviderAgentInstance           ResourceProvider = "agent-instance"
 	ResourceProviderKey                     ResourceProvider = "key"
@@ -18,13 +19,36 @@ const (
 	ResourceProviderCert                    ResourceProvider = "cert"
 	ResourceProviderCertPolicy              ResourceProvider = "cert-policy"
 	ResourceProviderCertExternalIssuer      ResourceProvider = "cert-external-issuer"
+	ResourceProviderCertCRL                 ResourceProvider = "cert-crl"
+	ResourceProviderCertOCSPResponder       ResourceProvider = "cert-ocsp-responder"
+	ResourceProviderCertTimestamp           ResourceProvider = "cert-timestamp"
+	ResourceProviderCertTSASigner           ResourceProvider = "cert-tsa-signer"
+	ResourceProviderACMEAccount             ResourceProvider = "acme-account"
+	ResourceProviderACMEAuthorization       ResourceProvider = "acme-authz"
+	ResourceProviderACMEExternalAccountKey  ResourceProvider = "acme-eab"
+	ResourceProviderACMEHTTP01Challenge     ResourceProvider = "acme-http01"
+	ResourceProviderACMENonce               ResourceProvider = "acme-nonce"
+	ResourceProviderACMEOrder               ResourceProvider = "acme-order"
+	ResourceProviderAuditRecord             ResourceProvider = "audit-record"
+	ResourceProviderLease                   ResourceProvider = "lease"
 	ResourceProviderLink                    ResourceProvider = "link"
+	ResourceProviderSSHCAPolicy             ResourceProvider = "ssh-ca-policy"
+	ResourceProviderSSHCert                 ResourceProvider = "ssh-cert"
+	ResourceProviderWebhookDeadLetter       ResourceProvider = "webhook-dead-letter"
+	ResourceProviderWebhookDelivery         ResourceProvider = "webhook-delivery"
+	ResourceProviderWebhookSubscription     ResourceProvider = "webhook-subscription"
 )
 
 type LinkProvider string
 
 const (
+	LinkProviderACMEPendingOrder          LinkProvider = "acme-pending-order"
 	LinkProviderCAPolicyIssuerCertificate LinkProvider = "issuer-cert"
+	LinkProviderCertRenewal               LinkProvider = "cert-renewal"
 	LinkProviderGraphMemberOf             LinkProvider = "graph-member-of"
 	LinkProviderGraphMember               LinkProvider = "graph-member"
+	LinkProviderIssuedCertificate         LinkProvider = "issued-cert"
+	LinkProviderKeyRotation               LinkProvider = "key-rotation"
+	LinkProviderRenewedFrom               LinkProvider = "renewed-from"
+	LinkProviderWebhookSubscription       LinkProvider = "webhook-subscription"
 )
@@ -0,0 +1,17 @@
+package webhookmodels
+
+import (
+	"github.com/stephenzsy/small-kms/backend/models"
+)
+
+type (
+	webhookSubscriptionComposed struct {
+		models.Ref
+		WebhookSubscriptionFields
+	}
+
+	webhookDeadLetterComposed struct {
+		models.Ref
+		WebhookDeadLetterFields
+	}
+)
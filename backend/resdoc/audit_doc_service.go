@@ -0,0 +1,295 @@
+package resdoc
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
+	"github.com/rs/zerolog/log"
+	"github.com/stephenzsy/small-kms/backend/internal/auth"
+	ctx "github.com/stephenzsy/small-kms/backend/internal/context"
+	"github.com/stephenzsy/small-kms/backend/models"
+	auditmodels "github.com/stephenzsy/small-kms/backend/models/audit"
+)
+
+const auditAppendMaxAttempts = 10
+
+// bookkeeping documents which change on every background scan are not audited
+var unauditedResourceProviders = map[models.ResourceProvider]bool{
+	models.ResourceProviderAuditRecord:     true,
+	models.ResourceProviderACMENonce:       true,
+	models.ResourceProviderLease:           true,
+	models.ResourceProviderWebhookDelivery: true,
+}
+
+// auditSnapshotDoc captures the stored content of a document to compute its checksum
+type auditSnapshotDoc struct {
+	ResourceDoc
+	content map[string]any
+}
+
+func (d *auditSnapshotDoc) UnmarshalJSON(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(&d.content)
+}
+
+// auditChecksum hashes the document content, system properties are excluded as they change on every write
+func auditChecksum(content map[string]any) []byte {
+	if content == nil {
+		return nil
+	}
+	for k := range content {
+		if strings.HasPrefix(k, "_") {
+			delete(content, k)
+		}
+	}
+	// map keys are marshaled in sorted order
+	canonical, err := json.Marshal(content)
+	if err != nil {
+		return nil
+	}
+	sum := sha256.Sum256(canonical)
+	return sum[:]
+}
+
+func auditChecksumOfDoc(doc ResourceDocument) []byte {
+	content, err := json.Marshal(doc)
+	if err != nil {
+		return nil
+	}
+	snapshot := &auditSnapshotDoc{}
+	if err := snapshot.UnmarshalJSON(content); err != nil {
+		return nil
+	}
+	return auditChecksum(snapshot.content)
+}
+
+// auditedDocService appends a hash chained audit record for every successful mutation
+type auditedDocService struct {
+	DocService
+}
+
+func (s *auditedDocService) isAudited(identifier DocIdentifier) bool {
+	return !unauditedResourceProviders[identifier.PartitionKey.ResourceProvider]
+}
+
+// readChecksum returns nil if the document does not exist
+func (s *auditedDocService) readChecksum(c context.Context, identifier DocIdentifier) ([]byte, error) {
+	snapshot := &auditSnapshotDoc{}
+	if err := s.DocService.Read(c, identifier, snapshot, nil); err != nil {
+		if errors.Is(err, ErrAzCosmosDocNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read document for audit checksum: %w", err)
+	}
+	return auditChecksum(snapshot.content), nil
+}
+
+// Create implements DocService.
+func (s *auditedDocService) Create(c context.Context, doc ResourceDocument, o *azcosmos.ItemOptions) (azcosmos.ItemResponse, error) {
+	resp, err := s.DocService.Create(c, doc, o)
+	if err == nil && s.isAudited(doc.Identifier()) {
+		err = s.audit(c, auditmodels.AuditOperationCreate, doc.Identifier(), nil, auditChecksumOfDoc(doc))
+	}
+	return resp, err
+}
+
+// Upsert implements DocService.
+func (s *auditedDocService) Upsert(c context.Context, doc ResourceDocument, o *azcosmos.ItemOptions) (azcosmos.ItemResponse, error) {
+	identifier := doc.Identifier()
+	if !s.isAudited(identifier) {
+		return s.DocService.Upsert(c, doc, o)
+	}
+	before, err := s.readChecksum(c, identifier)
+	if err != nil {
+		return azcosmos.ItemResponse{}, err
+	}
+	resp, err := s.DocService.Upsert(c, doc, o)
+	if err == nil {
+		err = s.audit(c, auditmodels.AuditOperationUpsert, identifier, before, auditChecksumOfDoc(doc))
+	}
+	return resp, err
+}
+
+// Patch implements DocService.
+func (s *auditedDocService) Patch(c context.Context, doc ResourceDocument, patchOps azcosmos.PatchOperations, o *azcosmos.ItemOptions) (azcosmos.ItemResponse, error) {
+	identifier := doc.Identifier()
+	if !s.isAudited(identifier) {
+		return s.DocService.Patch(c, doc, patchOps, o)
+	}
+	before, err := s.readChecksum(c, identifier)
+	if err != nil {
+		return azcosmos.ItemResponse{}, err
+	}
+	resp, err := s.DocService.Patch(c, doc, patchOps, o)
+	if err == nil {
+		err = s.auditPatch(c, identifier, before)
+	}
+	return resp, err
+}
+
+// PatchByIdentifier implements DocService.
+func (s *auditedDocService) PatchByIdentifier(c context.Context, identifier DocIdentifier, patchOps azcosmos.PatchOperations, o *azcosmos.ItemOptions) (azcosmos.ItemResponse, error) {
+	if !s.isAudited(identifier) {
+		return s.DocService.PatchByIdentifier(c, identifier, patchOps, o)
+	}
+	before, err := s.readChecksum(c, identifier)
+	if err != nil {
+		return azcosmos.ItemResponse{}, err
+	}
+	resp, err := s.DocService.PatchByIdentifier(c, identifier, patchOps, o)
+	if err == nil {
+		err = s.auditPatch(c, identifier, before)
+	}
+	return resp, err
+}
+
+// Delete implements DocService.
+func (s *auditedDocService) Delete(c context.Context, identifier DocIdentifier, o *azcosmos.ItemOptions) (azcosmos.ItemResponse, error) {
+	if !s.isAudited(identifier) {
+		return s.DocService.Delete(c, identifier, o)
+	}
+	before, err := s.readChecksum(c, identifier)
+	if err != nil {
+		return azcosmos.ItemResponse{}, err
+	}
+	resp, err := s.DocService.Delete(c, identifier, o)
+	if err == nil {
+		err = s.audit(c, auditmodels.AuditOperationDelete, identifier, before, nil)
+	}
+	return resp, err
+}
+
+func (s *auditedDocService) auditPatch(c context.Context, identifier DocIdentifier, before []byte) error {
+	after, err := s.readChecksum(c, identifier)
+	if err != nil {
+		return s.auditFailed(c, identifier, auditmodels.AuditOperationPatch, err)
+	}
+	return s.audit(c, auditmodels.AuditOperationPatch, identifier, before, after)
+}
+
+// audit fails the mutation if the record cannot be appended, so no mutation is left out of the chain unnoticed,
+// the mutation itself has already been committed
+func (s *auditedDocService) audit(c context.Context, operation auditmodels.AuditOperation, identifier DocIdentifier, before, after []byte) error {
+	identity := auth.GetAuthIdentity(c)
+	record := &AuditRecordDoc{
+		ResourceDoc: ResourceDoc{
+			PartitionKey: AuditRecordPartitionKey,
+		},
+		auditRecordFields: auditRecordFields{
+			Operation:         operation,
+			Actor:             identity.ClientPrincipalDisplayName(),
+			ActorID:           identity.ClientPrincipalID().String(),
+			RequestID:         ctx.GetRequestID(c),
+			NamespaceProvider: identifier.PartitionKey.NamespaceProvider,
+			NamespaceID:       identifier.PartitionKey.NamespaceID,
+			ResourceProvider:  identifier.PartitionKey.ResourceProvider,
+			ResourceID:        identifier.ID,
+			BeforeChecksum:    before,
+			AfterChecksum:     after,
+		},
+	}
+	record.Time.Time = time.Now().Truncate(time.Second)
+	if err := s.appendAuditRecord(c, record); err != nil {
+		return s.auditFailed(c, identifier, operation, err)
+	}
+	return nil
+}
+
+func (s *auditedDocService) auditFailed(c context.Context, identifier DocIdentifier, operation auditmodels.AuditOperation, err error) error {
+	log.Ctx(c).Error().Err(err).Str("identifier", identifier.String()).Str("operation", string(operation)).Msg("failed to append audit record")
+	// the cause is not wrapped, so it is not mistaken for a conflict of the mutation
+	return fmt.Errorf("%w: %s %s: %v", ErrAuditRecordNotAppended, operation, identifier.String(), err)
+}
+
+// appendAuditRecord links the record to the last record of the chain,
+// creating the record with its seq as ID makes sure only one of the concurrent appends takes the seq
+func (s *auditedDocService) appendAuditRecord(c context.Context, record *AuditRecordDoc) error {
+	headIdentifier := DocIdentifier{PartitionKey: AuditRecordPartitionKey, ID: auditChainHeadID}
+	head := &auditChainHeadDoc{}
+	if err := s.DocService.Read(c, headIdentifier, head, nil); err != nil {
+		if !errors.Is(err, ErrAzCosmosDocNotFound) {
+			return err
+		}
+		head.ResourceDoc = ResourceDoc{PartitionKey: AuditRecordPartitionKey, ID: auditChainHeadID}
+	}
+	headETag := head.ETag
+	seq, prevHash := head.Seq, head.Hash
+
+	for attempt := 1; ; attempt++ {
+		record.ID = getAuditRecordID(seq + 1)
+		record.Seq = seq + 1
+		record.PrevHash = prevHash
+		var err error
+		if record.Hash, err = record.computeHash(); err != nil {
+			return err
+		}
+		_, err = s.DocService.Create(c, record, nil)
+		if err == nil {
+			break
+		}
+		if !IsAzCosmosConditionFailed(err) || attempt >= auditAppendMaxAttempts {
+			return err
+		}
+		// the seq has been taken, the chain head is behind, possibly by many records
+		if seq, prevHash, err = s.readAuditChainTail(c); err != nil {
+			return err
+		}
+	}
+
+	head.Seq = record.Seq
+	head.Hash = record.Hash
+	var err error
+	if headETag == nil {
+		_, err = s.DocService.Create(c, head, nil)
+	} else {
+		_, err = s.DocService.Upsert(c, head, &azcosmos.ItemOptions{IfMatchEtag: headETag})
+	}
+	if err != nil && !IsAzCosmosConditionFailed(err) {
+		return err
+	}
+	// the head has been moved by a concurrent append, later appends catch up from the records
+	return nil
+}
+
+// readAuditChainTail returns the seq and hash of the last record of the chain
+func (s *auditedDocService) readAuditChainTail(c context.Context) (int64, []byte, error) {
+	qb := NewDefaultCosmoQueryBuilder().
+		WithExtraColumns(AuditRecordQueryColSeq, "c.hash").
+		WithWhereClauses("IS_DEFINED(c.seq)").
+		WithOrderBy(AuditRecordQueryColSeq+" DESC").
+		WithOffsetLimit(0, 1)
+	query, parameters := qb.BuildQuery()
+	pager := &DocPager[*AuditRecordDoc]{
+		innerPager: s.DocService.NewQueryItemsPager(query, AuditRecordPartitionKey, &azcosmos.QueryOptions{
+			QueryParameters: parameters,
+		}),
+		queryCtx: c,
+	}
+	for pager.More() {
+		records, err := pager.NextPage()
+		if err != nil {
+			return 0, nil, err
+		}
+		if len(records) > 0 {
+			return records[0].Seq, records[0].Hash, nil
+		}
+	}
+	return 0, nil, nil
+}
+
+var _ DocService = (*auditedDocService)(nil)
+
+// NewAuditedDocService wraps the doc service so every Create, Upsert, Patch and Delete is recorded in the audit chain
+func NewAuditedDocService(inner DocService) DocService {
+	return &auditedDocService{
+		DocService: inner,
+	}
+}
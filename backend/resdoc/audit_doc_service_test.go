@@ -0,0 +1,88 @@
+package resdoc
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestAuditedDocService(t *testing.T) (DocService, DocService) {
+	inner, err := NewEmbeddedDocService(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	return NewAuditedDocService(inner), inner
+}
+
+// verifyTestAuditRecords reads the whole chain and returns the number of verified records
+func verifyTestAuditRecords(t *testing.T, s DocService) int {
+	c := context.Background()
+	qb := NewAuditRecordQueryBuilder().WithOrderBy(AuditRecordQueryColSeq + " ASC")
+	query, parameters := qb.BuildQuery()
+	pager := &DocPager[*AuditRecordDoc]{
+		innerPager: s.NewQueryItemsPager(query, AuditRecordPartitionKey, &azcosmos.QueryOptions{QueryParameters: parameters}),
+		queryCtx:   c,
+	}
+	verifier := NewAuditChainVerifier(nil)
+	count := 0
+	for pager.More() {
+		records, err := pager.NextPage()
+		require.NoError(t, err)
+		for _, record := range records {
+			_, err := verifier.Verify(record)
+			require.NoError(t, err)
+			count++
+		}
+	}
+	return count
+}
+
+func TestAuditedDocServiceConcurrentAppend(t *testing.T) {
+	c := context.Background()
+	s, inner := newTestAuditedDocService(t)
+
+	const writers, writes = 8, 10
+	wg := sync.WaitGroup{}
+	errs := make(chan error, writers*writes)
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < writes; i++ {
+				if _, err := s.Create(c, newEmbeddedTestDoc(fmt.Sprintf("%d-%d", w, i), "pending", i), nil); err != nil {
+					errs <- err
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+	assert.Equal(t, writers*writes, verifyTestAuditRecords(t, inner))
+}
+
+func TestAuditedDocServiceHeadBehind(t *testing.T) {
+	c := context.Background()
+	s, inner := newTestAuditedDocService(t)
+
+	const n = 2 * auditAppendMaxAttempts
+	for i := 0; i < n; i++ {
+		_, err := s.Create(c, newEmbeddedTestDoc(fmt.Sprintf("%d", i), "pending", i), nil)
+		require.NoError(t, err)
+	}
+	// the head lags behind the chain by more records than the append attempts
+	_, err := inner.Upsert(c, &auditChainHeadDoc{
+		ResourceDoc: ResourceDoc{PartitionKey: AuditRecordPartitionKey, ID: auditChainHeadID},
+	}, nil)
+	require.NoError(t, err)
+
+	_, err = s.Create(c, newEmbeddedTestDoc("last", "pending", n), nil)
+	require.NoError(t, err)
+	assert.Equal(t, n+1, verifyTestAuditRecords(t, inner))
+}
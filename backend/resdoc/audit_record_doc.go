@@ -0,0 +1,169 @@
+package resdoc
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/stephenzsy/small-kms/backend/models"
+	auditmodels "github.com/stephenzsy/small-kms/backend/models/audit"
+)
+
+var (
+	ErrAuditChainBroken       = errors.New("audit chain broken")
+	ErrAuditRecordNotAppended = errors.New("audit record not appended")
+)
+
+const auditChainHeadID = "chain-head"
+
+// audit records of all namespaces form a single chain in one partition
+var AuditRecordPartitionKey = PartitionKey{
+	NamespaceProvider: models.NamespaceProviderProfile,
+	NamespaceID:       "default",
+	ResourceProvider:  models.ResourceProviderAuditRecord,
+}
+
+// auditRecordFields are covered by the hash of the record
+type auditRecordFields struct {
+	Seq               int64                      `json:"seq"`
+	Time              NumericDate                `json:"time"`
+	Operation         auditmodels.AuditOperation `json:"operation"`
+	Actor             string                     `json:"actor"`
+	ActorID           string                     `json:"actorId"`
+	RequestID         string                     `json:"requestId,omitempty"`
+	NamespaceProvider models.NamespaceProvider   `json:"nsProvider"`
+	NamespaceID       string                     `json:"nsId"`
+	ResourceProvider  models.ResourceProvider    `json:"resourceProvider"`
+	ResourceID        string                     `json:"resourceId"`
+	BeforeChecksum    []byte                     `json:"beforeChecksum,omitempty"`
+	AfterChecksum     []byte                     `json:"afterChecksum,omitempty"`
+	PrevHash          []byte                     `json:"prevHash,omitempty"`
+}
+
+type AuditRecordDoc struct {
+	ResourceDoc
+	auditRecordFields
+	Hash []byte `json:"hash"`
+}
+
+const (
+	AuditRecordQueryColSeq               = "c.seq"
+	AuditRecordQueryColTime              = "c.time"
+	AuditRecordQueryColActorID           = "c.actorId"
+	AuditRecordQueryColNamespaceProvider = "c.nsProvider"
+	AuditRecordQueryColNamespaceID       = "c.nsId"
+	AuditRecordQueryColResourceProvider  = "c.resourceProvider"
+	AuditRecordQueryColResourceID        = "c.resourceId"
+)
+
+// NewAuditRecordQueryBuilder selects all fields of the records, so they can be verified
+func NewAuditRecordQueryBuilder() *CosmosQueryBuilder {
+	return NewDefaultCosmoQueryBuilder().
+		WithExtraColumns(AuditRecordQueryColSeq, AuditRecordQueryColTime, "c.operation", "c.actor", AuditRecordQueryColActorID,
+			"c.requestId", AuditRecordQueryColNamespaceProvider, AuditRecordQueryColNamespaceID,
+			AuditRecordQueryColResourceProvider, AuditRecordQueryColResourceID,
+			"c.beforeChecksum", "c.afterChecksum", "c.prevHash", "c.hash").
+		WithWhereClauses("IS_DEFINED(c.seq)")
+}
+
+func getAuditRecordID(seq int64) string {
+	return fmt.Sprintf("%020d", seq)
+}
+
+func (d *AuditRecordDoc) computeHash() ([]byte, error) {
+	content, err := json.Marshal(&d.auditRecordFields)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(content)
+	return sum[:], nil
+}
+
+func (d *AuditRecordDoc) ToModel() (m auditmodels.AuditRecord) {
+	m.Seq = d.Seq
+	m.Time = d.Time.Time
+	m.Operation = d.Operation
+	m.Actor = d.Actor
+	m.ActorID = d.ActorID
+	m.RequestID = d.RequestID
+	m.NamespaceProvider = d.NamespaceProvider
+	m.NamespaceID = d.NamespaceID
+	m.ResourceProvider = string(d.ResourceProvider)
+	m.ResourceID = d.ResourceID
+	m.BeforeChecksum = d.BeforeChecksum
+	m.AfterChecksum = d.AfterChecksum
+	m.PrevHash = d.PrevHash
+	m.Hash = d.Hash
+	return m
+}
+
+// auditChainHeadDoc points to the last record of the chain, it may lag behind when replicas append concurrently
+type auditChainHeadDoc struct {
+	ResourceDoc
+	Seq  int64  `json:"headSeq"`
+	Hash []byte `json:"headHash,omitempty"`
+}
+
+// GetAuditChainHeadSeq returns the seq of the last record the chain head has recorded
+func GetAuditChainHeadSeq(c context.Context) (int64, error) {
+	head := &auditChainHeadDoc{}
+	if err := GetDocService(c).Read(c, DocIdentifier{PartitionKey: AuditRecordPartitionKey, ID: auditChainHeadID}, head, nil); err != nil {
+		if errors.Is(err, ErrAzCosmosDocNotFound) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return head.Seq, nil
+}
+
+// AuditChainVerifier checks the records, fed one at a time ordered by seq, link to each other and have not been altered,
+// so the chain can be verified page by page
+type AuditChainVerifier struct {
+	prevSeq  int64
+	prevHash []byte
+}
+
+// NewAuditChainVerifier starts the verification after the checkpoint record, which is trusted,
+// a nil checkpoint starts from the beginning of the chain
+func NewAuditChainVerifier(checkpoint *AuditRecordDoc) *AuditChainVerifier {
+	if checkpoint == nil {
+		return &AuditChainVerifier{}
+	}
+	return &AuditChainVerifier{prevSeq: checkpoint.Seq, prevHash: checkpoint.Hash}
+}
+
+// LastSeq returns the seq of the last verified record, or of the checkpoint
+func (v *AuditChainVerifier) LastSeq() int64 {
+	return v.prevSeq
+}
+
+// Verify returns the seq of the first record which fails verification
+func (v *AuditChainVerifier) Verify(record *AuditRecordDoc) (int64, error) {
+	if record.Seq != v.prevSeq+1 {
+		return v.prevSeq + 1, fmt.Errorf("%w: record %d is missing", ErrAuditChainBroken, v.prevSeq+1)
+	}
+	if !bytes.Equal(record.PrevHash, v.prevHash) {
+		return record.Seq, fmt.Errorf("%w: record %d does not link to the previous record", ErrAuditChainBroken, record.Seq)
+	}
+	hash, err := record.computeHash()
+	if err != nil {
+		return record.Seq, err
+	}
+	if !bytes.Equal(record.Hash, hash) {
+		return record.Seq, fmt.Errorf("%w: record %d has been altered", ErrAuditChainBroken, record.Seq)
+	}
+	v.prevSeq, v.prevHash = record.Seq, record.Hash
+	return 0, nil
+}
+
+// ReadAuditRecord reads the record of the seq
+func ReadAuditRecord(c context.Context, seq int64) (*AuditRecordDoc, error) {
+	record := &AuditRecordDoc{}
+	if err := GetDocService(c).Read(c, DocIdentifier{PartitionKey: AuditRecordPartitionKey, ID: getAuditRecordID(seq)}, record, nil); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
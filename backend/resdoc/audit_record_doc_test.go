@@ -0,0 +1,81 @@
+package resdoc
+
+import (
+	"testing"
+
+	auditmodels "github.com/stephenzsy/small-kms/backend/models/audit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestAuditChain(n int) []*AuditRecordDoc {
+	records := make([]*AuditRecordDoc, n)
+	var prevHash []byte
+	for i := range records {
+		record := &AuditRecordDoc{
+			auditRecordFields: auditRecordFields{
+				Seq:        int64(i + 1),
+				Operation:  auditmodels.AuditOperationUpsert,
+				Actor:      "tester",
+				ResourceID: "resource",
+				PrevHash:   prevHash,
+			},
+		}
+		record.Hash, _ = record.computeHash()
+		prevHash = record.Hash
+		records[i] = record
+	}
+	return records
+}
+
+// verifyTestAuditChain feeds the records to the verifier, returns the seq of the first record which fails verification
+func verifyTestAuditChain(checkpoint *AuditRecordDoc, records []*AuditRecordDoc) (int64, error) {
+	v := NewAuditChainVerifier(checkpoint)
+	for _, record := range records {
+		if brokenSeq, err := v.Verify(record); err != nil {
+			return brokenSeq, err
+		}
+	}
+	return 0, nil
+}
+
+func TestVerifyAuditChain(t *testing.T) {
+	records := newTestAuditChain(3)
+	_, err := verifyTestAuditChain(nil, records)
+	require.NoError(t, err)
+
+	records[1].Actor = "someone else"
+	brokenSeq, err := verifyTestAuditChain(nil, records)
+	assert.ErrorIs(t, err, ErrAuditChainBroken)
+	assert.Equal(t, int64(2), brokenSeq)
+
+	records = newTestAuditChain(3)
+	brokenSeq, err = verifyTestAuditChain(nil, []*AuditRecordDoc{records[0], records[2]})
+	assert.ErrorIs(t, err, ErrAuditChainBroken)
+	assert.Equal(t, int64(2), brokenSeq)
+
+	// rewriting a record along with its hash breaks the link of the next record
+	records[1].Actor = "someone else"
+	records[1].Hash, _ = records[1].computeHash()
+	brokenSeq, err = verifyTestAuditChain(nil, records)
+	assert.ErrorIs(t, err, ErrAuditChainBroken)
+	assert.Equal(t, int64(3), brokenSeq)
+
+	// verification from a checkpoint only covers the records after it
+	records = newTestAuditChain(4)
+	records[0].Actor = "someone else"
+	_, err = verifyTestAuditChain(records[1], records[2:])
+	require.NoError(t, err)
+	brokenSeq, err = verifyTestAuditChain(records[1], records[3:])
+	assert.ErrorIs(t, err, ErrAuditChainBroken)
+	assert.Equal(t, int64(3), brokenSeq)
+}
+
+func TestAuditChecksum(t *testing.T) {
+	stored := &auditSnapshotDoc{}
+	require.NoError(t, stored.UnmarshalJSON([]byte(`{"id":"a","exp":1700000000,"_etag":"\"1\"","_ts":1700000001}`)))
+	written := &auditSnapshotDoc{}
+	require.NoError(t, written.UnmarshalJSON([]byte(`{"exp":1700000000,"id":"a"}`)))
+	assert.Equal(t, auditChecksum(written.content), auditChecksum(stored.content))
+	assert.Nil(t, auditChecksum(nil))
+}
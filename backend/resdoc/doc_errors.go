@@ -24,3 +24,10 @@ func HandleAzCosmosError(err error) error {
 	}
 	return err
 }
+
+// IsAzCosmosConditionFailed returns true if a create or conditional write lost to a concurrent write
+func IsAzCosmosConditionFailed(err error) bool {
+	var respErr *azcore.ResponseError
+	return errors.As(err, &respErr) &&
+		(respErr.StatusCode == http.StatusConflict || respErr.StatusCode == http.StatusPreconditionFailed)
+}
@@ -0,0 +1,590 @@
+package resdoc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	azruntime "github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
+	"github.com/google/uuid"
+	"github.com/stephenzsy/small-kms/backend/internal/auth"
+	bolt "go.etcd.io/bbolt"
+)
+
+const embeddedQueryPageSize = 100
+
+// embeddedDocService stores the documents in a local bbolt database, one bucket per partition,
+// for development and tests without Cosmos DB
+type embeddedDocService struct {
+	db *bolt.DB
+}
+
+// newEmbeddedResponseError mimics the Cosmos DB errors, so HandleAzCosmosError and IsAzCosmosConditionFailed apply
+func newEmbeddedResponseError(statusCode int, errorCode string) error {
+	return &azcore.ResponseError{
+		StatusCode: statusCode,
+		ErrorCode:  errorCode,
+	}
+}
+
+func newEmbeddedItemResponse(statusCode int, etag azcore.ETag, value []byte) azcosmos.ItemResponse {
+	header := http.Header{}
+	header.Set("Date", time.Now().UTC().Format(http.TimeFormat))
+	header.Set("ETag", string(etag))
+	return azcosmos.ItemResponse{
+		Value: value,
+		Response: azcosmos.Response{
+			RawResponse: &http.Response{
+				StatusCode: statusCode,
+				Status:     http.StatusText(statusCode),
+				Header:     header,
+			},
+			ETag: etag,
+		},
+	}
+}
+
+// stampEmbeddedDoc sets the system properties Cosmos DB maintains on every write
+func stampEmbeddedDoc(content []byte) (map[string]any, azcore.ETag, error) {
+	doc, err := decodeEmbeddedDoc(content)
+	if err != nil {
+		return nil, "", err
+	}
+	etag := azcore.ETag(strconv.Quote(uuid.NewString()))
+	doc["_etag"] = string(etag)
+	doc["_ts"] = time.Now().Unix()
+	return doc, etag, nil
+}
+
+func decodeEmbeddedDoc(content []byte) (map[string]any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+	doc := map[string]any{}
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+func embeddedDocETag(doc map[string]any) azcore.ETag {
+	etag, _ := doc["_etag"].(string)
+	return azcore.ETag(etag)
+}
+
+func checkEmbeddedIfMatch(existing map[string]any, o *azcosmos.ItemOptions) error {
+	if o == nil || o.IfMatchEtag == nil {
+		return nil
+	}
+	if existing == nil || embeddedDocETag(existing) != *o.IfMatchEtag {
+		return newEmbeddedResponseError(http.StatusPreconditionFailed, "PreconditionFailed")
+	}
+	return nil
+}
+
+// isEmbeddedDocExpired honors the per-item time to live in seconds since the last write, as Cosmos DB does
+func isEmbeddedDocExpired(doc map[string]any, now time.Time) bool {
+	ttl, ok := doc["ttl"].(json.Number)
+	if !ok {
+		return false
+	}
+	seconds, err := ttl.Int64()
+	if err != nil || seconds < 0 {
+		return false
+	}
+	ts, ok := doc["_ts"].(json.Number)
+	if !ok {
+		return false
+	}
+	written, err := ts.Int64()
+	if err != nil {
+		return false
+	}
+	return now.Unix() >= written+seconds
+}
+
+// purgeExpiredEmbeddedDocs removes the documents whose time to live has elapsed from the bucket
+func purgeExpiredEmbeddedDocs(bucket *bolt.Bucket) error {
+	now := time.Now()
+	var expired [][]byte
+	err := bucket.ForEach(func(k, v []byte) error {
+		if doc, err := decodeEmbeddedDoc(v); err == nil && isEmbeddedDocExpired(doc, now) {
+			expired = append(expired, bytes.Clone(k))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, k := range expired {
+		if err := bucket.Delete(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readEmbeddedDoc(bucket *bolt.Bucket, id string) (map[string]any, error) {
+	if bucket == nil {
+		return nil, nil
+	}
+	content := bucket.Get([]byte(id))
+	if content == nil {
+		return nil, nil
+	}
+	doc, err := decodeEmbeddedDoc(content)
+	if err != nil || isEmbeddedDocExpired(doc, time.Now()) {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// write stores the document content, validate returns an error to abort the write based on the existing document
+func (s *embeddedDocService) write(partitionKey PartitionKey, id string, content []byte,
+	validate func(existing map[string]any) error) (statusCode int, etag azcore.ETag, err error) {
+	if id == "" {
+		return 0, "", newEmbeddedResponseError(http.StatusBadRequest, "BadRequest")
+	}
+	doc, etag, err := stampEmbeddedDoc(content)
+	if err != nil {
+		return 0, "", err
+	}
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists([]byte(partitionKey.String()))
+		if err != nil {
+			return err
+		}
+		if _, hasTTL := doc["ttl"]; hasTTL {
+			if err := purgeExpiredEmbeddedDocs(bucket); err != nil {
+				return err
+			}
+		}
+		existing, err := readEmbeddedDoc(bucket, id)
+		if err != nil {
+			return err
+		}
+		if err := validate(existing); err != nil {
+			return err
+		}
+		statusCode = http.StatusCreated
+		if existing != nil {
+			statusCode = http.StatusOK
+		}
+		stored, err := json.Marshal(doc)
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte(id), stored)
+	})
+	return statusCode, etag, err
+}
+
+// Read implements DocService.
+func (s *embeddedDocService) Read(c context.Context, identifier DocIdentifier, dst ResourceDocument, o *azcosmos.ItemOptions) error {
+	var content []byte
+	err := s.db.View(func(tx *bolt.Tx) error {
+		if bucket := tx.Bucket([]byte(identifier.PartitionKey.String())); bucket != nil {
+			content = bytes.Clone(bucket.Get([]byte(identifier.ID)))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if content == nil {
+		return HandleAzCosmosError(newEmbeddedResponseError(http.StatusNotFound, "NotFound"))
+	}
+	doc, err := decodeEmbeddedDoc(content)
+	if err != nil {
+		return err
+	}
+	if isEmbeddedDocExpired(doc, time.Now()) {
+		return HandleAzCosmosError(newEmbeddedResponseError(http.StatusNotFound, "NotFound"))
+	}
+	if err := json.Unmarshal(content, dst); err != nil {
+		return err
+	}
+	dst.setETag(embeddedDocETag(doc))
+	return nil
+}
+
+// Create implements DocService.
+func (s *embeddedDocService) Create(c context.Context, doc ResourceDocument, o *azcosmos.ItemOptions) (resp azcosmos.ItemResponse, err error) {
+	doc.prepareForWrite(c)
+	content, err := json.Marshal(doc)
+	if err != nil {
+		return resp, err
+	}
+	statusCode, etag, err := s.write(doc.partitionKey(), doc.getID(), content, func(existing map[string]any) error {
+		if existing != nil {
+			return newEmbeddedResponseError(http.StatusConflict, "Conflict")
+		}
+		return nil
+	})
+	if err != nil {
+		return resp, err
+	}
+	doc.setETag(etag)
+	doc.setTimestamp(time.Now())
+	return newEmbeddedItemResponse(statusCode, etag, nil), nil
+}
+
+// Upsert implements DocService.
+func (s *embeddedDocService) Upsert(c context.Context, doc ResourceDocument, o *azcosmos.ItemOptions) (resp azcosmos.ItemResponse, err error) {
+	doc.prepareForWrite(c)
+	content, err := json.Marshal(doc)
+	if err != nil {
+		return resp, err
+	}
+	statusCode, etag, err := s.write(doc.partitionKey(), doc.getID(), content, func(existing map[string]any) error {
+		return checkEmbeddedIfMatch(existing, o)
+	})
+	if err != nil {
+		return resp, err
+	}
+	doc.setETag(etag)
+	doc.setTimestamp(time.Now())
+	return newEmbeddedItemResponse(statusCode, etag, nil), nil
+}
+
+// Delete implements DocService.
+func (s *embeddedDocService) Delete(c context.Context, identifier DocIdentifier, o *azcosmos.ItemOptions) (resp azcosmos.ItemResponse, err error) {
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(identifier.PartitionKey.String()))
+		existing, err := readEmbeddedDoc(bucket, identifier.ID)
+		if err != nil {
+			return err
+		}
+		if existing == nil {
+			return newEmbeddedResponseError(http.StatusNotFound, "NotFound")
+		}
+		if err := checkEmbeddedIfMatch(existing, o); err != nil {
+			return err
+		}
+		return bucket.Delete([]byte(identifier.ID))
+	})
+	if err != nil {
+		return resp, err
+	}
+	return newEmbeddedItemResponse(http.StatusNoContent, "", nil), nil
+}
+
+// patch applies the operations to the stored document in place
+func (s *embeddedDocService) patch(identifier DocIdentifier, patchOps azcosmos.PatchOperations, o *azcosmos.ItemOptions) (azcore.ETag, error) {
+	ops, err := decodeEmbeddedPatchOperations(patchOps)
+	if err != nil {
+		return "", err
+	}
+	var etag azcore.ETag
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(identifier.PartitionKey.String()))
+		existing, err := readEmbeddedDoc(bucket, identifier.ID)
+		if err != nil {
+			return err
+		}
+		if existing == nil {
+			return newEmbeddedResponseError(http.StatusNotFound, "NotFound")
+		}
+		if err := checkEmbeddedIfMatch(existing, o); err != nil {
+			return err
+		}
+		for _, op := range ops {
+			if err := op.apply(existing); err != nil {
+				return err
+			}
+		}
+		content, err := json.Marshal(existing)
+		if err != nil {
+			return err
+		}
+		doc, nextETag, err := stampEmbeddedDoc(content)
+		if err != nil {
+			return err
+		}
+		stored, err := json.Marshal(doc)
+		if err != nil {
+			return err
+		}
+		etag = nextETag
+		return bucket.Put([]byte(identifier.ID), stored)
+	})
+	return etag, err
+}
+
+// Patch implements DocService.
+func (s *embeddedDocService) Patch(c context.Context, doc ResourceDocument, patchOps azcosmos.PatchOperations, o *azcosmos.ItemOptions) (azcosmos.ItemResponse, error) {
+	nextUpdatedBy := auth.GetAuthIdentity(c).ClientPrincipalDisplayName()
+	if doc.getUpdatedBy() != nextUpdatedBy {
+		patchOps.AppendSet("/updatedBy", nextUpdatedBy)
+	}
+	etag, err := s.patch(doc.Identifier(), patchOps, o)
+	if err != nil {
+		return azcosmos.ItemResponse{}, err
+	}
+	doc.setUpdatedBy(nextUpdatedBy)
+	doc.setETag(etag)
+	doc.setTimestamp(time.Now())
+	return newEmbeddedItemResponse(http.StatusOK, etag, nil), nil
+}
+
+// PatchByIdentifier implements DocService.
+func (s *embeddedDocService) PatchByIdentifier(c context.Context, identifier DocIdentifier, patchOps azcosmos.PatchOperations, o *azcosmos.ItemOptions) (azcosmos.ItemResponse, error) {
+	patchOps.AppendSet("/updatedBy", auth.GetAuthIdentity(c).ClientPrincipalDisplayName())
+	etag, err := s.patch(identifier, patchOps, o)
+	if err != nil {
+		return azcosmos.ItemResponse{}, err
+	}
+	return newEmbeddedItemResponse(http.StatusOK, etag, nil), nil
+}
+
+// NewQueryItemsPager implements DocService.
+func (s *embeddedDocService) NewQueryItemsPager(query string, partitionKey PartitionKey, o *azcosmos.QueryOptions) *azruntime.Pager[azcosmos.QueryItemsResponse] {
+	var items [][]byte
+	executed := false
+	return azruntime.NewPager(azruntime.PagingHandler[azcosmos.QueryItemsResponse]{
+		More: func(azcosmos.QueryItemsResponse) bool {
+			return len(items) > 0
+		},
+		Fetcher: func(c context.Context, _ *azcosmos.QueryItemsResponse) (azcosmos.QueryItemsResponse, error) {
+			if !executed {
+				executed = true
+				var err error
+				if items, err = s.query(query, partitionKey, o); err != nil {
+					return azcosmos.QueryItemsResponse{}, err
+				}
+			}
+			page := items[:min(embeddedQueryPageSize, len(items))]
+			items = items[len(page):]
+			return azcosmos.QueryItemsResponse{Items: page}, nil
+		},
+	})
+}
+
+func (s *embeddedDocService) query(query string, partitionKey PartitionKey, o *azcosmos.QueryOptions) ([][]byte, error) {
+	q, err := parseEmbeddedQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	var parameters []azcosmos.QueryParameter
+	if o != nil {
+		parameters = o.QueryParameters
+	}
+	params, err := normalizeQueryParameters(parameters)
+	if err != nil {
+		return nil, err
+	}
+	var docs []map[string]any
+	now := time.Now()
+	err = s.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(partitionKey.String()))
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(_, content []byte) error {
+			doc, err := decodeEmbeddedDoc(content)
+			if err != nil {
+				return err
+			}
+			if isEmbeddedDocExpired(doc, now) {
+				return nil
+			}
+			docs = append(docs, doc)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return q.execute(docs, params)
+}
+
+var _ DocService = (*embeddedDocService)(nil)
+
+// NewEmbeddedDocService opens or creates the database file
+func NewEmbeddedDocService(path string) (DocService, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+	return &embeddedDocService{db: db}, nil
+}
+
+type embeddedPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+func decodeEmbeddedPatchOperations(patchOps azcosmos.PatchOperations) ([]embeddedPatchOperation, error) {
+	encoded, err := json.Marshal(patchOps)
+	if err != nil {
+		return nil, err
+	}
+	var decoded struct {
+		Condition  *string                  `json:"condition"`
+		Operations []embeddedPatchOperation `json:"operations"`
+	}
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		return nil, err
+	}
+	if decoded.Condition != nil {
+		return nil, fmt.Errorf("%w: conditional patch is not supported", ErrEmbeddedQueryInvalid)
+	}
+	return decoded.Operations, nil
+}
+
+func newEmbeddedPatchError(op *embeddedPatchOperation, reason string) error {
+	return fmt.Errorf("patch %s %s: %s: %w", op.Op, op.Path, reason, newEmbeddedResponseError(http.StatusBadRequest, "BadRequest"))
+}
+
+// apply follows the Cosmos DB partial document update semantics, paths are JSON pointers
+func (op *embeddedPatchOperation) apply(doc map[string]any) error {
+	segments := strings.Split(strings.TrimPrefix(op.Path, "/"), "/")
+	for i, segment := range segments {
+		segments[i] = strings.ReplaceAll(strings.ReplaceAll(segment, "~1", "/"), "~0", "~")
+	}
+	var parent any = doc
+	for _, segment := range segments[:len(segments)-1] {
+		switch v := parent.(type) {
+		case map[string]any:
+			next, ok := v[segment]
+			if !ok {
+				return newEmbeddedPatchError(op, "parent does not exist")
+			}
+			parent = next
+		case []any:
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= len(v) {
+				return newEmbeddedPatchError(op, "invalid array index")
+			}
+			parent = v[index]
+		default:
+			return newEmbeddedPatchError(op, "parent is not an object or array")
+		}
+	}
+
+	var value any
+	if op.Op != "remove" {
+		decoder := json.NewDecoder(bytes.NewReader(op.Value))
+		decoder.UseNumber()
+		if err := decoder.Decode(&value); err != nil {
+			return newEmbeddedPatchError(op, "invalid value")
+		}
+	}
+	key := segments[len(segments)-1]
+
+	switch container := parent.(type) {
+	case map[string]any:
+		existing, exists := container[key]
+		switch op.Op {
+		case "set", "add":
+			container[key] = value
+		case "replace":
+			if !exists {
+				return newEmbeddedPatchError(op, "path does not exist")
+			}
+			container[key] = value
+		case "remove":
+			if !exists {
+				return newEmbeddedPatchError(op, "path does not exist")
+			}
+			delete(container, key)
+		case "incr":
+			current := json.Number("0")
+			if exists {
+				n, ok := existing.(json.Number)
+				if !ok {
+					return newEmbeddedPatchError(op, "value is not a number")
+				}
+				current = n
+			}
+			incremented, err := incrementEmbeddedNumber(current, value)
+			if err != nil {
+				return newEmbeddedPatchError(op, err.Error())
+			}
+			container[key] = incremented
+		default:
+			return newEmbeddedPatchError(op, "unsupported operation")
+		}
+	case []any:
+		// arrays are patched by replacing the parent, which requires its own parent
+		updated, err := op.applyArray(container, key, value)
+		if err != nil {
+			return err
+		}
+		return (&embeddedPatchOperation{Op: "set", Path: "/" + strings.Join(segments[:len(segments)-1], "/")}).setDecoded(doc, updated)
+	default:
+		return newEmbeddedPatchError(op, "parent is not an object or array")
+	}
+	return nil
+}
+
+func (op *embeddedPatchOperation) applyArray(arr []any, key string, value any) ([]any, error) {
+	if key == "-" && (op.Op == "add" || op.Op == "set") {
+		return append(arr, value), nil
+	}
+	index, err := strconv.Atoi(key)
+	if err != nil || index < 0 || index > len(arr) {
+		return nil, newEmbeddedPatchError(op, "invalid array index")
+	}
+	switch op.Op {
+	case "add":
+		arr = append(arr, nil)
+		copy(arr[index+1:], arr[index:])
+		arr[index] = value
+		return arr, nil
+	case "set", "replace":
+		if index == len(arr) {
+			if op.Op == "set" {
+				return append(arr, value), nil
+			}
+			return nil, newEmbeddedPatchError(op, "invalid array index")
+		}
+		arr[index] = value
+		return arr, nil
+	case "remove":
+		if index == len(arr) {
+			return nil, newEmbeddedPatchError(op, "invalid array index")
+		}
+		return append(arr[:index], arr[index+1:]...), nil
+	}
+	return nil, newEmbeddedPatchError(op, "unsupported operation")
+}
+
+// setDecoded sets an already decoded value at the path
+func (op *embeddedPatchOperation) setDecoded(doc map[string]any, value any) error {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	op.Value = encoded
+	return op.apply(doc)
+}
+
+func incrementEmbeddedNumber(current json.Number, delta any) (json.Number, error) {
+	d, ok := delta.(json.Number)
+	if !ok {
+		return "", errors.New("increment is not a number")
+	}
+	if ci, err := current.Int64(); err == nil {
+		if di, err := d.Int64(); err == nil {
+			return json.Number(strconv.FormatInt(ci+di, 10)), nil
+		}
+	}
+	cf, err := current.Float64()
+	if err != nil {
+		return "", err
+	}
+	df, err := d.Float64()
+	if err != nil {
+		return "", err
+	}
+	return json.Number(strconv.FormatFloat(cf+df, 'f', -1, 64)), nil
+}
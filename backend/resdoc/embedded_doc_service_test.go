@@ -0,0 +1,117 @@
+package resdoc
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
+	"github.com/stephenzsy/small-kms/backend/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type embeddedTestDoc struct {
+	ResourceDoc
+	Status string   `json:"status"`
+	Count  int      `json:"count"`
+	Tags   []string `json:"tags,omitempty"`
+}
+
+var embeddedTestPartitionKey = PartitionKey{
+	NamespaceProvider: models.NamespaceProviderProfile,
+	NamespaceID:       "default",
+	ResourceProvider:  models.ResourceProviderCert,
+}
+
+func newEmbeddedTestDoc(id string, status string, count int) *embeddedTestDoc {
+	return &embeddedTestDoc{
+		ResourceDoc: ResourceDoc{PartitionKey: embeddedTestPartitionKey, ID: id},
+		Status:      status,
+		Count:       count,
+	}
+}
+
+func TestEmbeddedDocServiceCRUD(t *testing.T) {
+	c := context.Background()
+	s, err := NewEmbeddedDocService(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+
+	doc := newEmbeddedTestDoc("a", "pending", 1)
+	resp, err := s.Create(c, doc, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 201, resp.RawResponse.StatusCode)
+	_, err = s.Create(c, newEmbeddedTestDoc("a", "pending", 1), nil)
+	assert.True(t, IsAzCosmosConditionFailed(err))
+
+	read := &embeddedTestDoc{}
+	require.NoError(t, s.Read(c, doc.Identifier(), read, nil))
+	assert.Equal(t, "pending", read.Status)
+	require.NotNil(t, read.ETag)
+	etag := *read.ETag
+
+	_, err = s.Upsert(c, newEmbeddedTestDoc("a", "issued", 1), &azcosmos.ItemOptions{IfMatchEtag: &etag})
+	require.NoError(t, err)
+	_, err = s.Upsert(c, newEmbeddedTestDoc("a", "stale", 1), &azcosmos.ItemOptions{IfMatchEtag: &etag})
+	assert.True(t, IsAzCosmosConditionFailed(err))
+
+	patchOps := azcosmos.PatchOperations{}
+	patchOps.AppendSet("/status", "revoked")
+	patchOps.AppendIncrement("/count", 2)
+	patchOps.AppendAdd("/tags", []string{"x"})
+	patchOps.AppendAdd("/tags/-", "y")
+	_, err = s.PatchByIdentifier(c, doc.Identifier(), patchOps, nil)
+	require.NoError(t, err)
+	require.NoError(t, s.Read(c, doc.Identifier(), read, nil))
+	assert.Equal(t, "revoked", read.Status)
+	assert.Equal(t, 3, read.Count)
+	assert.Equal(t, []string{"x", "y"}, read.Tags)
+
+	resp, err = s.Delete(c, doc.Identifier(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, 204, resp.RawResponse.StatusCode)
+	assert.ErrorIs(t, s.Read(c, doc.Identifier(), read, nil), ErrAzCosmosDocNotFound)
+}
+
+func TestEmbeddedDocServiceQuery(t *testing.T) {
+	c := context.Background()
+	s, err := NewEmbeddedDocService(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	for i, status := range []string{"issued", "pending", "issued", "issued"} {
+		_, err := s.Create(c, newEmbeddedTestDoc(string(rune('a'+i)), status, i), nil)
+		require.NoError(t, err)
+	}
+
+	qb := NewDefaultCosmoQueryBuilder().
+		WithExtraColumns("c.status", "c.count").
+		WithWhereClauses("c.status = @status", "NOT IS_DEFINED(c.deleted) OR IS_NULL(c.deleted)").
+		WithOrderBy("c.count DESC").
+		WithOffsetLimit(0, 2)
+	qb.Parameters = []azcosmos.QueryParameter{{Name: "@status", Value: "issued"}}
+	query, parameters := qb.BuildQuery()
+	pager := ToDocPager[*embeddedTestDoc](s.NewQueryItemsPager(query, embeddedTestPartitionKey,
+		&azcosmos.QueryOptions{QueryParameters: parameters}))
+	pager.queryCtx = c
+	var ids []string
+	for pager.More() {
+		items, err := pager.NextPage()
+		require.NoError(t, err)
+		for _, item := range items {
+			ids = append(ids, item.ID)
+		}
+	}
+	assert.Equal(t, []string{"d", "c"}, ids)
+
+	_, err = parseEmbeddedQuery("SELECT c.id FROM c JOIN t IN c.tags")
+	assert.ErrorIs(t, err, ErrEmbeddedQueryInvalid)
+}
+
+func TestEmbeddedDocExpired(t *testing.T) {
+	now := time.Unix(1000, 0)
+	assert.False(t, isEmbeddedDocExpired(map[string]any{"_ts": json.Number("900")}, now))
+	assert.False(t, isEmbeddedDocExpired(map[string]any{"_ts": json.Number("900"), "ttl": json.Number("-1")}, now))
+	assert.False(t, isEmbeddedDocExpired(map[string]any{"_ts": json.Number("900"), "ttl": json.Number("300")}, now))
+	assert.True(t, isEmbeddedDocExpired(map[string]any{"_ts": json.Number("900"), "ttl": json.Number("100")}, now))
+}
@@ -0,0 +1,857 @@
+package resdoc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
+)
+
+// the subset of the Cosmos DB SQL dialect built by CosmosQueryBuilder:
+//
+//	SELECT [TOP n] <path [AS alias]>, ... | * FROM c [WHERE <expr>] [ORDER BY <expr> [ASC|DESC], ...] [OFFSET n LIMIT m]
+//
+// expressions support comparison, logical and arithmetic operators, parameters, literals
+// and a few built-in functions, undefined values follow the Cosmos DB semantics
+
+var ErrEmbeddedQueryInvalid = errors.New("invalid query")
+
+// undefinedValue is the result of accessing a property which is not present
+type undefinedValue struct{}
+
+var undefined = undefinedValue{}
+
+type queryTokenKind int
+
+const (
+	queryTokenEOF queryTokenKind = iota
+	queryTokenIdent
+	queryTokenParam
+	queryTokenNumber
+	queryTokenString
+	queryTokenSymbol
+)
+
+type queryToken struct {
+	kind queryTokenKind
+	text string
+}
+
+func tokenizeQuery(query string) ([]queryToken, error) {
+	var tokens []queryToken
+	runes := []rune(query)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '@' || r == '_' || unicode.IsLetter(r):
+			start := i
+			i++
+			for i < len(runes) && (runes[i] == '_' || unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+				i++
+			}
+			kind := queryTokenIdent
+			if r == '@' {
+				kind = queryTokenParam
+			}
+			tokens = append(tokens, queryToken{kind, string(runes[start:i])})
+		case unicode.IsDigit(r):
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, queryToken{queryTokenNumber, string(runes[start:i])})
+		case r == '\'' || r == '"':
+			sb := strings.Builder{}
+			i++
+			for {
+				if i >= len(runes) {
+					return nil, fmt.Errorf("%w: unterminated string", ErrEmbeddedQueryInvalid)
+				}
+				if runes[i] == '\\' && i+1 < len(runes) {
+					sb.WriteRune(runes[i+1])
+					i += 2
+					continue
+				}
+				if runes[i] == r {
+					i++
+					break
+				}
+				sb.WriteRune(runes[i])
+				i++
+			}
+			tokens = append(tokens, queryToken{queryTokenString, sb.String()})
+		default:
+			if i+1 < len(runes) {
+				switch two := string(runes[i : i+2]); two {
+				case "!=", "<>", "<=", ">=":
+					tokens = append(tokens, queryToken{queryTokenSymbol, two})
+					i += 2
+					continue
+				}
+			}
+			if !strings.ContainsRune("=<>+-*/(),.[]", r) {
+				return nil, fmt.Errorf("%w: unexpected character %q", ErrEmbeddedQueryInvalid, r)
+			}
+			tokens = append(tokens, queryToken{queryTokenSymbol, string(r)})
+			i++
+		}
+	}
+	return append(tokens, queryToken{kind: queryTokenEOF}), nil
+}
+
+type queryExpr interface {
+	eval(doc map[string]any, params map[string]any) any
+}
+
+type queryLiteralExpr struct{ value any }
+
+func (e *queryLiteralExpr) eval(map[string]any, map[string]any) any {
+	return e.value
+}
+
+type queryParamExpr struct{ name string }
+
+func (e *queryParamExpr) eval(_ map[string]any, params map[string]any) any {
+	if v, ok := params[e.name]; ok {
+		return v
+	}
+	return undefined
+}
+
+// queryPathExpr is a property path rooted at the document alias, e.g. c.jwk.x5t
+type queryPathExpr struct{ path []string }
+
+func (e *queryPathExpr) eval(doc map[string]any, _ map[string]any) any {
+	var current any = doc
+	for _, segment := range e.path {
+		switch v := current.(type) {
+		case map[string]any:
+			next, ok := v[segment]
+			if !ok {
+				return undefined
+			}
+			current = next
+		case []any:
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= len(v) {
+				return undefined
+			}
+			current = v[index]
+		default:
+			return undefined
+		}
+	}
+	return normalizeQueryValue(current)
+}
+
+type queryUnaryExpr struct {
+	op      string
+	operand queryExpr
+}
+
+func (e *queryUnaryExpr) eval(doc map[string]any, params map[string]any) any {
+	v := e.operand.eval(doc, params)
+	switch e.op {
+	case "NOT":
+		if b, ok := v.(bool); ok {
+			return !b
+		}
+	case "-":
+		if n, ok := v.(float64); ok {
+			return -n
+		}
+	}
+	return undefined
+}
+
+type queryBinaryExpr struct {
+	op          string
+	left, right queryExpr
+}
+
+func (e *queryBinaryExpr) eval(doc map[string]any, params map[string]any) any {
+	left := e.left.eval(doc, params)
+	switch e.op {
+	case "AND":
+		if b, ok := left.(bool); ok && !b {
+			return false
+		}
+		right := e.right.eval(doc, params)
+		if b, ok := right.(bool); ok && !b {
+			return false
+		}
+		if left == true && right == true {
+			return true
+		}
+		return undefined
+	case "OR":
+		if left == true {
+			return true
+		}
+		right := e.right.eval(doc, params)
+		if right == true {
+			return true
+		}
+		if left == false && right == false {
+			return false
+		}
+		return undefined
+	}
+
+	right := e.right.eval(doc, params)
+	switch e.op {
+	case "+", "-", "*", "/":
+		l, lok := left.(float64)
+		r, rok := right.(float64)
+		if !lok || !rok {
+			return undefined
+		}
+		switch e.op {
+		case "+":
+			return l + r
+		case "-":
+			return l - r
+		case "*":
+			return l * r
+		default:
+			if r == 0 {
+				return undefined
+			}
+			return l / r
+		}
+	}
+
+	// comparisons of undefined or mismatched types are undefined
+	if left == undefined || right == undefined || queryTypeRank(left) != queryTypeRank(right) {
+		return undefined
+	}
+	switch e.op {
+	case "=":
+		return reflect.DeepEqual(left, right)
+	case "!=", "<>":
+		return !reflect.DeepEqual(left, right)
+	}
+	cmp, ok := compareQueryValues(left, right)
+	if !ok {
+		return undefined
+	}
+	switch e.op {
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	}
+	return undefined
+}
+
+type queryFuncExpr struct {
+	name string
+	args []queryExpr
+}
+
+func (e *queryFuncExpr) eval(doc map[string]any, params map[string]any) any {
+	args := make([]any, len(e.args))
+	for i, arg := range e.args {
+		args[i] = arg.eval(doc, params)
+	}
+	switch e.name {
+	case "IS_DEFINED":
+		return args[0] != undefined
+	case "IS_NULL":
+		return args[0] == nil
+	case "ARRAY_CONTAINS":
+		arr, ok := args[0].([]any)
+		if !ok {
+			return undefined
+		}
+		for _, item := range arr {
+			if reflect.DeepEqual(normalizeQueryValue(item), args[1]) {
+				return true
+			}
+		}
+		return false
+	case "ARRAY_LENGTH":
+		if arr, ok := args[0].([]any); ok {
+			return float64(len(arr))
+		}
+	case "STARTSWITH", "ENDSWITH", "CONTAINS":
+		s, sok := args[0].(string)
+		sub, subok := args[1].(string)
+		if !sok || !subok {
+			return undefined
+		}
+		switch e.name {
+		case "STARTSWITH":
+			return strings.HasPrefix(s, sub)
+		case "ENDSWITH":
+			return strings.HasSuffix(s, sub)
+		default:
+			return strings.Contains(s, sub)
+		}
+	case "LOWER", "UPPER":
+		if s, ok := args[0].(string); ok {
+			if e.name == "LOWER" {
+				return strings.ToLower(s)
+			}
+			return strings.ToUpper(s)
+		}
+	case "GETCURRENTTIMESTAMP":
+		return float64(time.Now().UnixMilli())
+	}
+	return undefined
+}
+
+var queryFuncArity = map[string]int{
+	"IS_DEFINED":          1,
+	"IS_NULL":             1,
+	"ARRAY_CONTAINS":      2,
+	"ARRAY_LENGTH":        1,
+	"STARTSWITH":          2,
+	"ENDSWITH":            2,
+	"CONTAINS":            2,
+	"LOWER":               1,
+	"UPPER":               1,
+	"GETCURRENTTIMESTAMP": 0,
+}
+
+// normalizeQueryValue converts the decoded JSON numbers to float64 for comparison
+func normalizeQueryValue(v any) any {
+	switch t := v.(type) {
+	case json.Number:
+		f, err := t.Float64()
+		if err != nil {
+			return undefined
+		}
+		return f
+	case []any:
+		normalized := make([]any, len(t))
+		for i, item := range t {
+			normalized[i] = normalizeQueryValue(item)
+		}
+		return normalized
+	case map[string]any:
+		normalized := make(map[string]any, len(t))
+		for k, item := range t {
+			normalized[k] = normalizeQueryValue(item)
+		}
+		return normalized
+	}
+	return v
+}
+
+// queryTypeRank orders values of different types as Cosmos DB does: undefined, null, boolean, number, string, array, object
+func queryTypeRank(v any) int {
+	switch v.(type) {
+	case undefinedValue:
+		return 0
+	case nil:
+		return 1
+	case bool:
+		return 2
+	case float64:
+		return 3
+	case string:
+		return 4
+	case []any:
+		return 5
+	}
+	return 6
+}
+
+func compareQueryValues(a, b any) (int, bool) {
+	switch l := a.(type) {
+	case float64:
+		r := b.(float64)
+		switch {
+		case l < r:
+			return -1, true
+		case l > r:
+			return 1, true
+		}
+		return 0, true
+	case string:
+		return strings.Compare(l, b.(string)), true
+	case bool:
+		r := b.(bool)
+		switch {
+		case l == r:
+			return 0, true
+		case !l:
+			return -1, true
+		}
+		return 1, true
+	}
+	return 0, false
+}
+
+type queryProjection struct {
+	name string
+	expr *queryPathExpr
+}
+
+type queryOrderBy struct {
+	expr queryExpr
+	desc bool
+}
+
+type embeddedQuery struct {
+	selectAll   bool
+	projections []queryProjection
+	where       queryExpr
+	orderBy     []queryOrderBy
+	offset      int
+	limit       int
+}
+
+type queryParser struct {
+	tokens []queryToken
+	pos    int
+	alias  string
+}
+
+func (p *queryParser) peek() queryToken {
+	return p.tokens[p.pos]
+}
+
+func (p *queryParser) next() queryToken {
+	t := p.tokens[p.pos]
+	if t.kind != queryTokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *queryParser) isKeyword(keyword string) bool {
+	t := p.peek()
+	return t.kind == queryTokenIdent && strings.EqualFold(t.text, keyword)
+}
+
+func (p *queryParser) acceptKeyword(keyword string) bool {
+	if p.isKeyword(keyword) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *queryParser) acceptSymbol(symbol string) bool {
+	if t := p.peek(); t.kind == queryTokenSymbol && t.text == symbol {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *queryParser) expectKeyword(keyword string) error {
+	if !p.acceptKeyword(keyword) {
+		return fmt.Errorf("%w: expected %s near %q", ErrEmbeddedQueryInvalid, keyword, p.peek().text)
+	}
+	return nil
+}
+
+func (p *queryParser) expectSymbol(symbol string) error {
+	if !p.acceptSymbol(symbol) {
+		return fmt.Errorf("%w: expected %s near %q", ErrEmbeddedQueryInvalid, symbol, p.peek().text)
+	}
+	return nil
+}
+
+func (p *queryParser) parseInt() (int, error) {
+	t := p.next()
+	if t.kind != queryTokenNumber {
+		return 0, fmt.Errorf("%w: expected number near %q", ErrEmbeddedQueryInvalid, t.text)
+	}
+	return strconv.Atoi(t.text)
+}
+
+// parsePath parses the remainder of a property path after the document alias
+func (p *queryParser) parsePath() (*queryPathExpr, error) {
+	expr := &queryPathExpr{}
+	for {
+		if p.acceptSymbol(".") {
+			t := p.next()
+			if t.kind != queryTokenIdent {
+				return nil, fmt.Errorf("%w: expected property name near %q", ErrEmbeddedQueryInvalid, t.text)
+			}
+			expr.path = append(expr.path, t.text)
+		} else if p.acceptSymbol("[") {
+			t := p.next()
+			if t.kind != queryTokenString && t.kind != queryTokenNumber {
+				return nil, fmt.Errorf("%w: expected property name near %q", ErrEmbeddedQueryInvalid, t.text)
+			}
+			expr.path = append(expr.path, t.text)
+			if err := p.expectSymbol("]"); err != nil {
+				return nil, err
+			}
+		} else {
+			return expr, nil
+		}
+	}
+}
+
+func (p *queryParser) parseSelect(q *embeddedQuery) error {
+	if p.acceptKeyword("TOP") {
+		limit, err := p.parseInt()
+		if err != nil {
+			return err
+		}
+		q.limit = limit
+	}
+	if p.acceptSymbol("*") {
+		q.selectAll = true
+		return nil
+	}
+	for {
+		t := p.next()
+		if t.kind != queryTokenIdent {
+			return fmt.Errorf("%w: expected column near %q", ErrEmbeddedQueryInvalid, t.text)
+		}
+		if p.alias == "" {
+			p.alias = t.text
+		} else if t.text != p.alias {
+			return fmt.Errorf("%w: unknown alias %s", ErrEmbeddedQueryInvalid, t.text)
+		}
+		path, err := p.parsePath()
+		if err != nil {
+			return err
+		}
+		if len(path.path) == 0 {
+			return fmt.Errorf("%w: selecting the document by alias is not supported", ErrEmbeddedQueryInvalid)
+		}
+		projection := queryProjection{name: path.path[len(path.path)-1], expr: path}
+		if p.acceptKeyword("AS") {
+			alias := p.next()
+			if alias.kind != queryTokenIdent {
+				return fmt.Errorf("%w: expected alias near %q", ErrEmbeddedQueryInvalid, alias.text)
+			}
+			projection.name = alias.text
+		}
+		q.projections = append(q.projections, projection)
+		if !p.acceptSymbol(",") {
+			return nil
+		}
+	}
+}
+
+func parseEmbeddedQuery(query string) (*embeddedQuery, error) {
+	tokens, err := tokenizeQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	p := &queryParser{tokens: tokens}
+	q := &embeddedQuery{limit: -1}
+	if err := p.expectKeyword("SELECT"); err != nil {
+		return nil, err
+	}
+	if err := p.parseSelect(q); err != nil {
+		return nil, err
+	}
+	if err := p.expectKeyword("FROM"); err != nil {
+		return nil, err
+	}
+	from := p.next()
+	if from.kind != queryTokenIdent || (p.alias != "" && from.text != p.alias) {
+		return nil, fmt.Errorf("%w: unexpected FROM %q", ErrEmbeddedQueryInvalid, from.text)
+	}
+	p.alias = from.text
+	if p.acceptKeyword("WHERE") {
+		if q.where, err = p.parseExpr(0); err != nil {
+			return nil, err
+		}
+	}
+	if p.acceptKeyword("ORDER") {
+		if err := p.expectKeyword("BY"); err != nil {
+			return nil, err
+		}
+		for {
+			expr, err := p.parseExpr(0)
+			if err != nil {
+				return nil, err
+			}
+			orderBy := queryOrderBy{expr: expr}
+			if p.acceptKeyword("DESC") {
+				orderBy.desc = true
+			} else {
+				p.acceptKeyword("ASC")
+			}
+			q.orderBy = append(q.orderBy, orderBy)
+			if !p.acceptSymbol(",") {
+				break
+			}
+		}
+	}
+	if p.acceptKeyword("OFFSET") {
+		if q.offset, err = p.parseInt(); err != nil {
+			return nil, err
+		}
+		if err := p.expectKeyword("LIMIT"); err != nil {
+			return nil, err
+		}
+		if q.limit, err = p.parseInt(); err != nil {
+			return nil, err
+		}
+	}
+	if t := p.peek(); t.kind != queryTokenEOF {
+		return nil, fmt.Errorf("%w: unexpected %q", ErrEmbeddedQueryInvalid, t.text)
+	}
+	return q, nil
+}
+
+var queryBinaryPrecedence = map[string]int{
+	"OR":  1,
+	"AND": 2,
+	"=":   3, "!=": 3, "<>": 3,
+	"<": 4, "<=": 4, ">": 4, ">=": 4,
+	"+": 5, "-": 5,
+	"*": 6, "/": 6,
+}
+
+func (p *queryParser) peekBinaryOp() (string, int, bool) {
+	t := p.peek()
+	op := t.text
+	switch t.kind {
+	case queryTokenIdent:
+		op = strings.ToUpper(op)
+		if op != "AND" && op != "OR" {
+			return "", 0, false
+		}
+	case queryTokenSymbol:
+	default:
+		return "", 0, false
+	}
+	precedence, ok := queryBinaryPrecedence[op]
+	return op, precedence, ok
+}
+
+// parseExpr parses binary operators with precedence climbing
+func (p *queryParser) parseExpr(minPrecedence int) (queryExpr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, precedence, ok := p.peekBinaryOp()
+		if !ok || precedence <= minPrecedence {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseExpr(precedence)
+		if err != nil {
+			return nil, err
+		}
+		left = &queryBinaryExpr{op: op, left: left, right: right}
+	}
+}
+
+func (p *queryParser) parseUnary() (queryExpr, error) {
+	if p.acceptKeyword("NOT") {
+		operand, err := p.parseExpr(queryBinaryPrecedence["AND"])
+		if err != nil {
+			return nil, err
+		}
+		return &queryUnaryExpr{op: "NOT", operand: operand}, nil
+	}
+	if p.acceptSymbol("-") {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &queryUnaryExpr{op: "-", operand: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *queryParser) parsePrimary() (queryExpr, error) {
+	t := p.next()
+	switch t.kind {
+	case queryTokenNumber:
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid number %s", ErrEmbeddedQueryInvalid, t.text)
+		}
+		return &queryLiteralExpr{f}, nil
+	case queryTokenString:
+		return &queryLiteralExpr{t.text}, nil
+	case queryTokenParam:
+		return &queryParamExpr{t.text}, nil
+	case queryTokenSymbol:
+		if t.text == "(" {
+			expr, err := p.parseExpr(0)
+			if err != nil {
+				return nil, err
+			}
+			return expr, p.expectSymbol(")")
+		}
+	case queryTokenIdent:
+		switch strings.ToLower(t.text) {
+		case "true":
+			return &queryLiteralExpr{true}, nil
+		case "false":
+			return &queryLiteralExpr{false}, nil
+		case "null":
+			return &queryLiteralExpr{nil}, nil
+		case "undefined":
+			return &queryLiteralExpr{undefined}, nil
+		}
+		if t.text == p.alias {
+			return p.parsePath()
+		}
+		if p.acceptSymbol("(") {
+			name := strings.ToUpper(t.text)
+			arity, ok := queryFuncArity[name]
+			if !ok {
+				return nil, fmt.Errorf("%w: unsupported function %s", ErrEmbeddedQueryInvalid, t.text)
+			}
+			fn := &queryFuncExpr{name: name}
+			if !p.acceptSymbol(")") {
+				for {
+					arg, err := p.parseExpr(0)
+					if err != nil {
+						return nil, err
+					}
+					fn.args = append(fn.args, arg)
+					if !p.acceptSymbol(",") {
+						break
+					}
+				}
+				if err := p.expectSymbol(")"); err != nil {
+					return nil, err
+				}
+			}
+			if len(fn.args) != arity {
+				return nil, fmt.Errorf("%w: %s expects %d arguments", ErrEmbeddedQueryInvalid, t.text, arity)
+			}
+			return fn, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: unexpected %q", ErrEmbeddedQueryInvalid, t.text)
+}
+
+// normalizeQueryParameters round trips the values through JSON, so they compare with the stored documents
+func normalizeQueryParameters(parameters []azcosmos.QueryParameter) (map[string]any, error) {
+	params := make(map[string]any, len(parameters))
+	for _, parameter := range parameters {
+		encoded, err := json.Marshal(parameter.Value)
+		if err != nil {
+			return nil, err
+		}
+		var decoded any
+		if err := json.Unmarshal(encoded, &decoded); err != nil {
+			return nil, err
+		}
+		params[parameter.Name] = decoded
+	}
+	return params, nil
+}
+
+// execute filters, orders and projects the documents, which are decoded with json.Number to keep their numbers intact
+func (q *embeddedQuery) execute(docs []map[string]any, params map[string]any) ([][]byte, error) {
+	matched := make([]map[string]any, 0, len(docs))
+	for _, doc := range docs {
+		if q.where == nil || q.where.eval(doc, params) == true {
+			matched = append(matched, doc)
+		}
+	}
+	if len(q.orderBy) > 0 {
+		sortKeys := make([][]any, len(matched))
+		for i, doc := range matched {
+			sortKeys[i] = make([]any, len(q.orderBy))
+			for j, orderBy := range q.orderBy {
+				sortKeys[i][j] = orderBy.expr.eval(doc, params)
+			}
+		}
+		indexes := make([]int, len(matched))
+		for i := range indexes {
+			indexes[i] = i
+		}
+		sort.SliceStable(indexes, func(a, b int) bool {
+			for j, orderBy := range q.orderBy {
+				if cmp := compareQueryOrderValues(sortKeys[indexes[a]][j], sortKeys[indexes[b]][j]); cmp != 0 {
+					return (cmp < 0) != orderBy.desc
+				}
+			}
+			return false
+		})
+		sorted := make([]map[string]any, len(matched))
+		for i, index := range indexes {
+			sorted[i] = matched[index]
+		}
+		matched = sorted
+	}
+	if q.offset > 0 {
+		matched = matched[min(q.offset, len(matched)):]
+	}
+	if q.limit >= 0 {
+		matched = matched[:min(q.limit, len(matched))]
+	}
+
+	items := make([][]byte, len(matched))
+	for i, doc := range matched {
+		var item any = doc
+		if !q.selectAll {
+			projected := make(map[string]any, len(q.projections))
+			for _, projection := range q.projections {
+				if v, ok := lookupRawQueryValue(doc, projection.expr.path); ok {
+					projected[projection.name] = v
+				}
+			}
+			item = projected
+		}
+		encoded, err := json.Marshal(item)
+		if err != nil {
+			return nil, err
+		}
+		items[i] = encoded
+	}
+	return items, nil
+}
+
+// lookupRawQueryValue returns the value as stored, not normalized for comparison
+func lookupRawQueryValue(doc map[string]any, path []string) (any, bool) {
+	var current any = doc
+	for _, segment := range path {
+		switch v := current.(type) {
+		case map[string]any:
+			next, ok := v[segment]
+			if !ok {
+				return nil, false
+			}
+			current = next
+		case []any:
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= len(v) {
+				return nil, false
+			}
+			current = v[index]
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+func compareQueryOrderValues(a, b any) int {
+	if rankA, rankB := queryTypeRank(a), queryTypeRank(b); rankA != rankB {
+		if rankA < rankB {
+			return -1
+		}
+		return 1
+	}
+	if cmp, ok := compareQueryValues(a, b); ok {
+		return cmp
+	}
+	return 0
+}
@@ -0,0 +1,72 @@
+package resdoc
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
+)
+
+var (
+	ErrLeaseHeld = errors.New("lease is held by another holder")
+)
+
+// LeaseDoc grants the holder exclusive ownership of a resource until it expires,
+// conditional writes make sure only one of the competing holders acquires the lease
+type LeaseDoc struct {
+	ResourceDoc
+	Holder  string      `json:"holder"`
+	Expires NumericDate `json:"exp"`
+}
+
+// AcquireLease returns ErrLeaseHeld if the lease is held by another holder and has not expired
+func AcquireLease(c context.Context, identifier DocIdentifier, holder string, duration time.Duration) (*LeaseDoc, error) {
+	docSvc := GetDocService(c)
+	doc := &LeaseDoc{}
+	var etag *ETag
+	if err := docSvc.Read(c, identifier, doc, nil); err != nil {
+		if !errors.Is(err, ErrAzCosmosDocNotFound) {
+			return nil, err
+		}
+		doc.ResourceDoc = ResourceDoc{
+			PartitionKey: identifier.PartitionKey,
+			ID:           identifier.ID,
+		}
+	} else {
+		if doc.Holder != holder && doc.Expires.Time.After(time.Now()) {
+			return nil, ErrLeaseHeld
+		}
+		etag = doc.ETag
+	}
+
+	doc.Holder = holder
+	doc.Expires.Time = time.Now().Add(duration)
+	var resp azcosmos.ItemResponse
+	var err error
+	if etag == nil {
+		resp, err = docSvc.Create(c, doc, nil)
+	} else {
+		resp, err = docSvc.Upsert(c, doc, &azcosmos.ItemOptions{IfMatchEtag: etag})
+	}
+	if err != nil {
+		if IsAzCosmosConditionFailed(err) {
+			return nil, ErrLeaseHeld
+		}
+		return nil, err
+	}
+	doc.ETag = &resp.ETag
+	return doc, nil
+}
+
+// Release deletes the lease unless it has been taken over by another holder after it expired
+func (doc *LeaseDoc) Release(c context.Context) error {
+	_, err := GetDocService(c).Delete(c, doc.Identifier(), &azcosmos.ItemOptions{IfMatchEtag: doc.ETag})
+	if err != nil && IsAzCosmosConditionFailed(err) {
+		return nil
+	}
+	if err = HandleAzCosmosError(err); errors.Is(err, ErrAzCosmosDocNotFound) {
+		return nil
+	}
+	return err
+}
@@ -14,7 +14,10 @@ import (
 	"github.com/stephenzsy/small-kms/backend/internal/authz"
 	ctx "github.com/stephenzsy/small-kms/backend/internal/context"
 	kv "github.com/stephenzsy/small-kms/backend/internal/keyvault"
+	"github.com/stephenzsy/small-kms/backend/models"
+	webhookmodels "github.com/stephenzsy/small-kms/backend/models/webhook"
 	ns "github.com/stephenzsy/small-kms/backend/namespace"
+	"github.com/stephenzsy/small-kms/backend/webhook"
 )
 
 // GenerateSecret implements ServerInterface.
@@ -85,5 +88,7 @@ func (s *server) GenerateSecret(ec echo.Context, namespaceKind base.NamespaceKin
 
 	m := &Secret{}
 	doc.PopulateModel(m)
+	webhook.Publish(c, models.NamespaceProvider(namespaceKind), string(namespaceId), webhookmodels.EventTypeSecretCreated,
+		"secrets/"+string(doc.GetID()), m)
 	return c.JSON(200, m)
 }
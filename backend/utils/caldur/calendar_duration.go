@@ -122,6 +122,19 @@ func Shift(t time.Time, p CalendarDuration) time.Time {
 			time.Duration(p.Second)*time.Second)
 }
 
+// Negate returns the duration to shift back in time
+func (p CalendarDuration) Negate() CalendarDuration {
+	return CalendarDuration{
+		Year:   -p.Year,
+		Month:  -p.Month,
+		Week:   -p.Week,
+		Day:    -p.Day,
+		Hour:   -p.Hour,
+		Minute: -p.Minute,
+		Second: -p.Second,
+	}
+}
+
 func (p *CalendarDuration) Bytes() []byte {
 	b, _ := p.MarshalText()
 	return b
@@ -0,0 +1,42 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stephenzsy/small-kms/backend/base"
+	"github.com/stephenzsy/small-kms/backend/internal/authz"
+	ctx "github.com/stephenzsy/small-kms/backend/internal/context"
+	"github.com/stephenzsy/small-kms/backend/models"
+	"github.com/stephenzsy/small-kms/backend/resdoc"
+)
+
+// DeleteWebhookSubscription implements admin.ServerInterface.
+func (*WebhookServer) DeleteWebhookSubscription(ec echo.Context, nsProvider models.NamespaceProvider, nsID string, id string) error {
+	c := ec.(ctx.RequestContext)
+
+	if !authz.AuthorizeAdminOnly(c) {
+		return base.ErrResponseStatusForbidden
+	}
+
+	identifier := newSubscriptionDocIdentifier(nsProvider, nsID, id)
+	docSvc := resdoc.GetDocService(c)
+	if _, err := docSvc.Delete(c, identifier, nil); err != nil {
+		if err = resdoc.HandleAzCosmosError(err); errors.Is(err, resdoc.ErrAzCosmosDocNotFound) {
+			return fmt.Errorf("%w: webhook subscription not found: %s", base.ErrResponseStatusNotFound, id)
+		}
+		return err
+	}
+	// queued deliveries of the subscription are dropped by the dispatcher
+	if _, err := docSvc.Delete(c, resdoc.DocIdentifier{
+		PartitionKey: subscriptionIndexLinkPartitionKey,
+		ID:           getSubscriptionIndexLinkID(identifier),
+	}, nil); err != nil {
+		if err = resdoc.HandleAzCosmosError(err); !errors.Is(err, resdoc.ErrAzCosmosDocNotFound) {
+			return err
+		}
+	}
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,333 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
+	"github.com/rs/zerolog/log"
+	"github.com/stephenzsy/small-kms/backend/base"
+	ctx "github.com/stephenzsy/small-kms/backend/internal/context"
+	"github.com/stephenzsy/small-kms/backend/internal/netguard"
+	"github.com/stephenzsy/small-kms/backend/models"
+	webhookmodels "github.com/stephenzsy/small-kms/backend/models/webhook"
+	"github.com/stephenzsy/small-kms/backend/resdoc"
+	"github.com/stephenzsy/small-kms/backend/taskmanager"
+	"github.com/stephenzsy/small-kms/backend/utils"
+)
+
+const (
+	// set to true to allow plain http webhook endpoints on any address, for local receivers in development
+	EnvKeyWebhookDevMode = "WEBHOOK_DEV_MODE"
+)
+
+const (
+	deliveryDispatchInterval = 10 * time.Second
+	deliveryClaimDuration    = time.Minute
+	deliveryRequestTimeout   = 15 * time.Second
+	deliveryMaxAttempts      = 8
+	deliveryInitialBackoff   = 30 * time.Second
+	deliveryMaxBackoff       = time.Hour
+
+	// Standard Webhooks headers, https://www.standardwebhooks.com
+	headerWebhookID        = "webhook-id"
+	headerWebhookTimestamp = "webhook-timestamp"
+	headerWebhookSignature = "webhook-signature"
+)
+
+// queued deliveries of all namespaces share one partition, so the dispatcher can find them without a cross partition query
+var deliveryPartitionKey = resdoc.PartitionKey{
+	NamespaceProvider: models.NamespaceProviderProfile,
+	NamespaceID:       "default",
+	ResourceProvider:  models.ResourceProviderWebhookDelivery,
+}
+
+type deliveryDoc struct {
+	resdoc.ResourceDoc
+	Subscription   resdoc.DocIdentifier     `json:"subscription"`
+	Event          webhookmodels.CloudEvent `json:"event"`
+	Attempts       int                      `json:"attempts"`
+	NextAttempt    resdoc.NumericDate       `json:"nextAttempt"`
+	LastStatusCode int                      `json:"lastStatusCode,omitempty"`
+	LastError      string                   `json:"lastError,omitempty"`
+}
+
+// deadLetterDoc is stored in the namespace of the subscription once all attempts of a delivery failed
+type deadLetterDoc struct {
+	resdoc.ResourceDoc
+	SubscriptionID string                   `json:"subscriptionId"`
+	URL            string                   `json:"url"`
+	Event          webhookmodels.CloudEvent `json:"event"`
+	Attempts       int                      `json:"attempts"`
+	LastStatusCode int                      `json:"lastStatusCode,omitempty"`
+	LastError      string                   `json:"lastError,omitempty"`
+}
+
+func (d *deadLetterDoc) ToModel() (m webhookmodels.WebhookDeadLetter) {
+	m.Ref = d.ResourceDoc.ToRef()
+	m.SubscriptionID = d.SubscriptionID
+	m.URL = d.URL
+	m.Event = d.Event
+	m.Attempts = d.Attempts
+	m.LastStatusCode = d.LastStatusCode
+	m.LastError = d.LastError
+	return m
+}
+
+func isWebhookDevMode() bool {
+	return os.Getenv(EnvKeyWebhookDevMode) == "true"
+}
+
+// validateWebhookURL requires an absolute https URL, plain http is only allowed in dev mode
+func validateWebhookURL(rawURL string, devMode bool) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || !u.IsAbs() || u.Host == "" {
+		return errors.New("webhook URL must be an absolute URL")
+	}
+	if u.Scheme != "https" && !(devMode && u.Scheme == "http") {
+		return errors.New("webhook URL must be an https URL")
+	}
+	return nil
+}
+
+// newDeliveryClient only connects to public addresses and does not follow redirects, so a subscription cannot point
+// the admin server to the internal network, dev mode lifts the address restriction for local receivers
+func newDeliveryClient(devMode bool) *http.Client {
+	dialer := &net.Dialer{Timeout: deliveryRequestTimeout}
+	if !devMode {
+		dialer.Control = netguard.PublicAddressControl()
+	}
+	return &http.Client{
+		Timeout: deliveryRequestTimeout,
+		// no proxy, the address check has to run on the endpoint itself
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: deliveryRequestTimeout,
+		},
+		// a redirect response fails the delivery
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// retryBackoff doubles the delay after each failed attempt
+func retryBackoff(attempts int) time.Duration {
+	backoff := deliveryInitialBackoff
+	for i := 1; i < attempts && backoff < deliveryMaxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, deliveryMaxBackoff)
+}
+
+// signPayload signs the message id, timestamp and body with HMAC-SHA256 as specified by Standard Webhooks
+func signPayload(secret []byte, msgID string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	fmt.Fprintf(mac, "%s.%d.", msgID, timestamp)
+	mac.Write(body)
+	return "v1," + base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+type deliveryTarget struct {
+	url    string
+	secret []byte
+}
+
+func postCloudEvent(c context.Context, client *http.Client, target *deliveryTarget, event *webhookmodels.CloudEvent) (int, error) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return 0, err
+	}
+	req, err := http.NewRequestWithContext(c, http.MethodPost, target.url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", cloudEventsContentType)
+	req.Header.Set(headerWebhookID, event.ID)
+	req.Header.Set(headerWebhookTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(headerWebhookSignature, signPayload(target.secret, event.ID, timestamp, body))
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("webhook endpoint returned status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+func listDueDeliveriesInternal(c context.Context, now time.Time) ([]*deliveryDoc, error) {
+	qb := resdoc.NewDefaultCosmoQueryBuilder().
+		WithWhereClauses("c.nextAttempt <= @now").
+		WithOrderBy("c.nextAttempt ASC")
+	qb.Parameters = append(qb.Parameters, azcosmos.QueryParameter{Name: "@now", Value: now.Unix()})
+	pager := resdoc.NewQueryDocPager[*deliveryDoc](c, qb, deliveryPartitionKey)
+	return utils.PagerToSlice[*deliveryDoc](pager)
+}
+
+type deliveryTaskExecutor struct {
+	serviceContext context.Context
+	client         *http.Client
+	devMode        bool
+}
+
+// resolveTarget returns nil if the subscription has been deleted or disabled
+func (e *deliveryTaskExecutor) resolveTarget(c context.Context, subscription resdoc.DocIdentifier,
+	targets map[resdoc.DocIdentifier]*deliveryTarget) (*deliveryTarget, error) {
+	if target, ok := targets[subscription]; ok {
+		return target, nil
+	}
+	doc, err := getSubscriptionInternal(c, subscription)
+	if err != nil {
+		if errors.Is(err, base.ErrResponseStatusNotFound) {
+			targets[subscription] = nil
+			return nil, nil
+		}
+		return nil, err
+	}
+	if !doc.Enabled {
+		targets[subscription] = nil
+		return nil, nil
+	}
+	secret, err := doc.getSecret(c)
+	if err != nil {
+		return nil, err
+	}
+	target := &deliveryTarget{url: doc.URL, secret: secret}
+	targets[subscription] = target
+	return target, nil
+}
+
+// dispatch claims the delivery by pushing back its next attempt, so other replicas skip it while it is in flight
+func (e *deliveryTaskExecutor) dispatch(c ctx.RequestContext, deliveryID string, now time.Time,
+	targets map[resdoc.DocIdentifier]*deliveryTarget) error {
+	docSvc := resdoc.GetDocService(c)
+	doc := &deliveryDoc{}
+	if err := docSvc.Read(c, resdoc.DocIdentifier{PartitionKey: deliveryPartitionKey, ID: deliveryID}, doc, nil); err != nil {
+		if errors.Is(err, resdoc.ErrAzCosmosDocNotFound) {
+			return nil
+		}
+		return err
+	}
+	if doc.NextAttempt.After(now) {
+		return nil
+	}
+	doc.NextAttempt.Time = now.Add(deliveryClaimDuration)
+	resp, err := docSvc.Upsert(c, doc, &azcosmos.ItemOptions{IfMatchEtag: doc.ETag})
+	if err != nil {
+		if resdoc.IsAzCosmosConditionFailed(err) {
+			return nil
+		}
+		return err
+	}
+	etag := resp.ETag
+
+	target, err := e.resolveTarget(c, doc.Subscription, targets)
+	if err != nil {
+		return err
+	}
+	if target == nil {
+		_, err := docSvc.Delete(c, doc.Identifier(), &azcosmos.ItemOptions{IfMatchEtag: &etag})
+		return resdoc.HandleAzCosmosError(err)
+	}
+
+	var statusCode int
+	// subscriptions saved with an http URL are not delivered outside of dev mode
+	deliveryErr := validateWebhookURL(target.url, e.devMode)
+	if deliveryErr == nil {
+		statusCode, deliveryErr = postCloudEvent(c, e.client, target, &doc.Event)
+	}
+	doc.Attempts++
+	if deliveryErr == nil {
+		_, err := docSvc.Delete(c, doc.Identifier(), &azcosmos.ItemOptions{IfMatchEtag: &etag})
+		return resdoc.HandleAzCosmosError(err)
+	}
+
+	doc.LastStatusCode = statusCode
+	doc.LastError = deliveryErr.Error()
+	logger := log.Ctx(c).With().Str("delivery", doc.ID).Str("subscription", doc.Subscription.String()).Int("attempts", doc.Attempts).Logger()
+	if doc.Attempts < deliveryMaxAttempts {
+		logger.Warn().Err(deliveryErr).Msg("webhook delivery failed, retry scheduled")
+		doc.NextAttempt.Time = time.Now().Add(retryBackoff(doc.Attempts))
+		_, err := docSvc.Upsert(c, doc, &azcosmos.ItemOptions{IfMatchEtag: &etag})
+		return err
+	}
+
+	logger.Error().Err(deliveryErr).Msg("webhook delivery failed after all attempts, moved to dead letters")
+	if _, err := docSvc.Upsert(c, &deadLetterDoc{
+		ResourceDoc: resdoc.ResourceDoc{
+			PartitionKey: resdoc.PartitionKey{
+				NamespaceProvider: doc.Subscription.NamespaceProvider,
+				NamespaceID:       doc.Subscription.NamespaceID,
+				ResourceProvider:  models.ResourceProviderWebhookDeadLetter,
+			},
+			ID: doc.ID,
+		},
+		SubscriptionID: doc.Subscription.ID,
+		URL:            target.url,
+		Event:          doc.Event,
+		Attempts:       doc.Attempts,
+		LastStatusCode: doc.LastStatusCode,
+		LastError:      doc.LastError,
+	}, nil); err != nil {
+		return err
+	}
+	_, err = docSvc.Delete(c, doc.Identifier(), &azcosmos.ItemOptions{IfMatchEtag: &etag})
+	return resdoc.HandleAzCosmosError(err)
+}
+
+// Close implements taskmanager.IntervalExecutor.
+func (*deliveryTaskExecutor) Close(context.Context) error {
+	return nil
+}
+
+// Execute implements taskmanager.IntervalExecutor.
+func (e *deliveryTaskExecutor) Execute(c context.Context) (time.Duration, error) {
+	logger := log.Ctx(c)
+	rc := ctx.NewBackgroundRequestContext(c, e.serviceContext)
+	now := time.Now()
+	deliveries, err := listDueDeliveriesInternal(rc, now)
+	if err != nil {
+		return deliveryDispatchInterval, err
+	}
+	targets := make(map[resdoc.DocIdentifier]*deliveryTarget)
+	for _, delivery := range deliveries {
+		if err := e.dispatch(rc, delivery.ID, now, targets); err != nil {
+			logger.Error().Err(err).Str("delivery", delivery.ID).Msg("failed to dispatch webhook delivery")
+		}
+	}
+	return deliveryDispatchInterval, nil
+}
+
+// Name implements taskmanager.IntervalExecutor.
+func (*deliveryTaskExecutor) Name() string {
+	return "WebhookDelivery"
+}
+
+var _ taskmanager.IntervalExecutor = (*deliveryTaskExecutor)(nil)
+
+// NewDeliveryTaskExecutor posts queued events to the subscribed endpoints, failed deliveries are retried with backoff
+func NewDeliveryTaskExecutor(serviceContext context.Context) taskmanager.IntervalExecutor {
+	devMode := isWebhookDevMode()
+	return &deliveryTaskExecutor{
+		serviceContext: serviceContext,
+		client:         newDeliveryClient(devMode),
+		devMode:        devMode,
+	}
+}
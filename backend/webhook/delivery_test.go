@@ -0,0 +1,64 @@
+package webhook
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	webhookmodels "github.com/stephenzsy/small-kms/backend/models/webhook"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignPayload(t *testing.T) {
+	signature := signPayload([]byte("whsec-test"), "msg_1", 1700000000, []byte(`{"a":1}`))
+	assert.Equal(t, "v1,JheLhC+XazWfKczPdtdCd7wKbDI4biXb3hLSiqCNQBU=", signature)
+	assert.NotEqual(t, signature, signPayload([]byte("whsec-test"), "msg_1", 1700000001, []byte(`{"a":1}`)))
+}
+
+func TestRetryBackoff(t *testing.T) {
+	assert.Equal(t, 30*time.Second, retryBackoff(1))
+	assert.Equal(t, time.Minute, retryBackoff(2))
+	assert.Equal(t, 4*time.Minute, retryBackoff(4))
+	assert.Equal(t, time.Hour, retryBackoff(deliveryMaxAttempts+10))
+}
+
+func TestValidateWebhookURL(t *testing.T) {
+	assert.NoError(t, validateWebhookURL("https://hooks.example.com/events", false))
+	assert.Error(t, validateWebhookURL("http://hooks.example.com/events", false))
+	assert.NoError(t, validateWebhookURL("http://localhost:8080/events", true))
+	for _, invalid := range []string{"", "/events", "https:///events", "ftp://hooks.example.com/events"} {
+		assert.Error(t, validateWebhookURL(invalid, true), invalid)
+	}
+}
+
+func TestDeliveryClient(t *testing.T) {
+	received := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "/events", http.StatusTemporaryRedirect)
+			return
+		}
+		received++
+	}))
+	defer server.Close()
+	target := &deliveryTarget{url: server.URL + "/events", secret: []byte("whsec-test")}
+	event := &webhookmodels.CloudEvent{ID: "msg_1"}
+
+	// the test server listens on loopback
+	_, err := postCloudEvent(context.Background(), newDeliveryClient(false), target, event)
+	assert.ErrorContains(t, err, "is not allowed")
+	assert.Equal(t, 0, received)
+
+	statusCode, err := postCloudEvent(context.Background(), newDeliveryClient(true), target, event)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, statusCode)
+	assert.Equal(t, 1, received)
+
+	target.url = server.URL + "/redirect"
+	statusCode, err = postCloudEvent(context.Background(), newDeliveryClient(true), target, event)
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusTemporaryRedirect, statusCode)
+	assert.Equal(t, 1, received)
+}
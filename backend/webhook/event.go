@@ -0,0 +1,83 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/stephenzsy/small-kms/backend/api"
+	"github.com/stephenzsy/small-kms/backend/models"
+	webhookmodels "github.com/stephenzsy/small-kms/backend/models/webhook"
+	"github.com/stephenzsy/small-kms/backend/resdoc"
+)
+
+const (
+	cloudEventsSpecVersion = "1.0"
+	cloudEventsContentType = "application/cloudevents+json"
+)
+
+func newCloudEvent(c context.Context, nsProvider models.NamespaceProvider, nsID string,
+	eventType webhookmodels.WebhookEventType, subject string, data any) (*webhookmodels.CloudEvent, error) {
+	dataBytes, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return &webhookmodels.CloudEvent{
+		SpecVersion:     cloudEventsSpecVersion,
+		ID:              uuid.NewString(),
+		Source:          fmt.Sprintf("%s/v2/%s/%s", api.GetPublicBaseURL(c), nsProvider, nsID),
+		Type:            eventType,
+		Subject:         subject,
+		Time:            time.Now().UTC(),
+		DataContentType: "application/json",
+		Data:            dataBytes,
+	}, nil
+}
+
+// Publish queues the event for delivery to the enabled subscriptions of the namespace,
+// the subject is relative to the source, e.g. certificates/{id}.
+// Failures are logged and do not fail the operation which raised the event.
+func Publish(c context.Context, nsProvider models.NamespaceProvider, nsID string,
+	eventType webhookmodels.WebhookEventType, subject string, data any) {
+	if err := PublishInternal(c, nsProvider, nsID, eventType, subject, data); err != nil {
+		log.Ctx(c).Error().Err(err).Str("eventType", string(eventType)).Str("subject", subject).Msg("failed to publish webhook event")
+	}
+}
+
+// PublishInternal queues the event like Publish, but returns the error if the event could not be queued
+// for any of the subscriptions, for callers which retry the event
+func PublishInternal(c context.Context, nsProvider models.NamespaceProvider, nsID string,
+	eventType webhookmodels.WebhookEventType, subject string, data any) error {
+	subscriptions, err := listEnabledSubscriptionsInternal(c, nsProvider, nsID, eventType)
+	if err != nil {
+		return fmt.Errorf("failed to list webhook subscriptions: %w", err)
+	}
+	if len(subscriptions) == 0 {
+		return nil
+	}
+	event, err := newCloudEvent(c, nsProvider, nsID, eventType, subject, data)
+	if err != nil {
+		return fmt.Errorf("failed to create cloud event: %w", err)
+	}
+	docSvc := resdoc.GetDocService(c)
+	var errs []error
+	for _, subscription := range subscriptions {
+		doc := &deliveryDoc{
+			ResourceDoc: resdoc.ResourceDoc{
+				PartitionKey: deliveryPartitionKey,
+				ID:           uuid.NewString(),
+			},
+			Subscription: newSubscriptionDocIdentifier(nsProvider, nsID, subscription.ID),
+			Event:        *event,
+		}
+		doc.NextAttempt.Time = event.Time
+		if _, err := docSvc.Create(c, doc, nil); err != nil {
+			errs = append(errs, fmt.Errorf("failed to queue webhook delivery to subscription %s: %w", subscription.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
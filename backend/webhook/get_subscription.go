@@ -0,0 +1,26 @@
+package webhook
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stephenzsy/small-kms/backend/base"
+	"github.com/stephenzsy/small-kms/backend/internal/authz"
+	ctx "github.com/stephenzsy/small-kms/backend/internal/context"
+	"github.com/stephenzsy/small-kms/backend/models"
+)
+
+// GetWebhookSubscription implements admin.ServerInterface.
+func (*WebhookServer) GetWebhookSubscription(ec echo.Context, nsProvider models.NamespaceProvider, nsID string, id string) error {
+	c := ec.(ctx.RequestContext)
+
+	if !authz.AuthorizeAdminOnly(c) {
+		return base.ErrResponseStatusForbidden
+	}
+
+	doc, err := getSubscriptionInternal(c, newSubscriptionDocIdentifier(nsProvider, nsID, id))
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, doc.ToModel())
+}
@@ -0,0 +1,42 @@
+package webhook
+
+import (
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
+	"github.com/labstack/echo/v4"
+	"github.com/stephenzsy/small-kms/backend/admin"
+	"github.com/stephenzsy/small-kms/backend/api"
+	"github.com/stephenzsy/small-kms/backend/base"
+	"github.com/stephenzsy/small-kms/backend/internal/authz"
+	ctx "github.com/stephenzsy/small-kms/backend/internal/context"
+	"github.com/stephenzsy/small-kms/backend/models"
+	webhookmodels "github.com/stephenzsy/small-kms/backend/models/webhook"
+	"github.com/stephenzsy/small-kms/backend/resdoc"
+	"github.com/stephenzsy/small-kms/backend/utils"
+)
+
+// ListWebhookDeadLetters implements admin.ServerInterface.
+func (*WebhookServer) ListWebhookDeadLetters(ec echo.Context, nsProvider models.NamespaceProvider, nsID string, params admin.ListWebhookDeadLettersParams) error {
+	c := ec.(ctx.RequestContext)
+
+	if !authz.AuthorizeAdminOnly(c) {
+		return base.ErrResponseStatusForbidden
+	}
+
+	qb := resdoc.NewDefaultCosmoQueryBuilder().
+		WithExtraColumns("c.updatedBy", "c.subscriptionId", "c.url", "c.event", "c.attempts", "c.lastStatusCode", "c.lastError").
+		WithOrderBy("c._ts DESC")
+	if params.SubscriptionId != nil && *params.SubscriptionId != "" {
+		qb.WithWhereClauses("c.subscriptionId = @subscriptionId")
+		qb.Parameters = append(qb.Parameters, azcosmos.QueryParameter{Name: "@subscriptionId", Value: *params.SubscriptionId})
+	}
+	pager := resdoc.NewQueryDocPager[*deadLetterDoc](c, qb, resdoc.PartitionKey{
+		NamespaceProvider: nsProvider,
+		NamespaceID:       nsID,
+		ResourceProvider:  models.ResourceProviderWebhookDeadLetter,
+	})
+
+	modelPager := utils.NewMappedItemsPager(pager, func(doc *deadLetterDoc) webhookmodels.WebhookDeadLetter {
+		return doc.ToModel()
+	})
+	return api.RespondPagerList(c, utils.NewSerializableItemsPager(modelPager))
+}
@@ -0,0 +1,36 @@
+package webhook
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/stephenzsy/small-kms/backend/api"
+	"github.com/stephenzsy/small-kms/backend/base"
+	"github.com/stephenzsy/small-kms/backend/internal/authz"
+	ctx "github.com/stephenzsy/small-kms/backend/internal/context"
+	"github.com/stephenzsy/small-kms/backend/models"
+	"github.com/stephenzsy/small-kms/backend/resdoc"
+	"github.com/stephenzsy/small-kms/backend/utils"
+)
+
+// ListWebhookSubscriptions implements admin.ServerInterface.
+func (*WebhookServer) ListWebhookSubscriptions(ec echo.Context, nsProvider models.NamespaceProvider, nsID string) error {
+	c := ec.(ctx.RequestContext)
+
+	if !authz.AuthorizeAdminOnly(c) {
+		return base.ErrResponseStatusForbidden
+	}
+
+	qb := resdoc.NewDefaultCosmoQueryBuilder().WithExtraColumns(queryColumnDisplayName)
+	pager := resdoc.NewQueryDocPager[*SubscriptionDoc](c, qb, resdoc.PartitionKey{
+		NamespaceProvider: nsProvider,
+		NamespaceID:       nsID,
+		ResourceProvider:  models.ResourceProviderWebhookSubscription,
+	})
+
+	modelPager := utils.NewMappedItemsPager(pager, func(doc *SubscriptionDoc) *models.Ref {
+		ref := doc.ToRef()
+		ref.DisplayName = &doc.DisplayName
+		return &ref
+	})
+
+	return api.RespondPagerList(c, utils.NewSerializableItemsPager(modelPager))
+}
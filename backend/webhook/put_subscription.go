@@ -0,0 +1,132 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azsecrets"
+	"github.com/labstack/echo/v4"
+	"github.com/stephenzsy/small-kms/backend/base"
+	"github.com/stephenzsy/small-kms/backend/internal/authz"
+	ctx "github.com/stephenzsy/small-kms/backend/internal/context"
+	kv "github.com/stephenzsy/small-kms/backend/internal/keyvault"
+	"github.com/stephenzsy/small-kms/backend/models"
+	webhookmodels "github.com/stephenzsy/small-kms/backend/models/webhook"
+	ns "github.com/stephenzsy/small-kms/backend/namespace"
+	"github.com/stephenzsy/small-kms/backend/resdoc"
+)
+
+func isValidEventType(eventType webhookmodels.WebhookEventType) bool {
+	switch eventType {
+	case webhookmodels.EventTypeCertificateIssued,
+		webhookmodels.EventTypeCertificateRenewed,
+		webhookmodels.EventTypeCertificateRevoked,
+		webhookmodels.EventTypeCertificateExpiring,
+		webhookmodels.EventTypeKeyCreated,
+		webhookmodels.EventTypeKeyRotating,
+		webhookmodels.EventTypeKeyRotated,
+		webhookmodels.EventTypeSecretCreated:
+		return true
+	}
+	return false
+}
+
+// PutWebhookSubscription implements admin.ServerInterface.
+func (*WebhookServer) PutWebhookSubscription(ec echo.Context, nsProvider models.NamespaceProvider, nsID string, id string) error {
+	c := ec.(ctx.RequestContext)
+
+	if !authz.AuthorizeAdminOnly(c) {
+		return base.ErrResponseStatusForbidden
+	}
+
+	req := new(webhookmodels.WebhookSubscriptionParameters)
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+	if err := ns.ValidateID(id); err != nil {
+		return err
+	}
+
+	if err := validateWebhookURL(req.URL, isWebhookDevMode()); err != nil {
+		return fmt.Errorf("%w: %s", base.ErrResponseStatusBadRequest, err.Error())
+	}
+	if len(req.EventTypes) == 0 {
+		return fmt.Errorf("%w: at least one event type is required", base.ErrResponseStatusBadRequest)
+	}
+	eventTypes := make([]webhookmodels.WebhookEventType, 0, len(req.EventTypes))
+	for _, eventType := range req.EventTypes {
+		if !isValidEventType(eventType) {
+			return fmt.Errorf("%w: invalid event type: %s", base.ErrResponseStatusBadRequest, eventType)
+		}
+		if !slices.Contains(eventTypes, eventType) {
+			eventTypes = append(eventTypes, eventType)
+		}
+	}
+
+	doc := &SubscriptionDoc{
+		ResourceDoc: resdoc.ResourceDoc{
+			PartitionKey: resdoc.PartitionKey{
+				NamespaceProvider: nsProvider,
+				NamespaceID:       nsID,
+				ResourceProvider:  models.ResourceProviderWebhookSubscription,
+			},
+			ID: id,
+		},
+		DisplayName: id,
+		URL:         req.URL,
+		EventTypes:  eventTypes,
+		Enabled:     true,
+	}
+	if req.DisplayName != "" {
+		doc.DisplayName = req.DisplayName
+	}
+	if req.Enabled != nil {
+		doc.Enabled = *req.Enabled
+	}
+
+	if req.Secret == "" {
+		existing, err := getSubscriptionInternal(c, doc.Identifier())
+		if err != nil {
+			if errors.Is(err, base.ErrResponseStatusNotFound) {
+				return fmt.Errorf("%w: secret is required for a new webhook subscription", base.ErrResponseStatusBadRequest)
+			}
+			return err
+		}
+		doc.SecretID = existing.SecretID
+	} else {
+		secretName := kv.GetMaterialName(kv.MaterialNameKindSecret, nsProvider, nsID, "webhook-"+id)
+		resp, err := kv.GetAzKeyVaultService(c).AzSecretsClient().SetSecret(c, secretName, azsecrets.SetSecretParameters{
+			Value:       &req.Secret,
+			ContentType: to.Ptr("text/plain"),
+			SecretAttributes: &azsecrets.SecretAttributes{
+				Enabled: to.Ptr(true),
+			},
+		}, nil)
+		if err != nil {
+			return err
+		}
+		doc.SecretID = *resp.ID
+	}
+
+	docSvc := resdoc.GetDocService(c)
+	resp, err := docSvc.Upsert(c, doc, nil)
+	if err != nil {
+		return err
+	}
+	if _, err := docSvc.Upsert(c, &subscriptionIndexLinkDoc{
+		LinkResourceDoc: resdoc.LinkResourceDoc{
+			ResourceDoc: resdoc.ResourceDoc{
+				PartitionKey: subscriptionIndexLinkPartitionKey,
+				ID:           getSubscriptionIndexLinkID(doc.Identifier()),
+			},
+			LinkTo:       doc.Identifier(),
+			LinkProvider: models.LinkProviderWebhookSubscription,
+		},
+		EventTypes: doc.EventTypes,
+	}, nil); err != nil {
+		return err
+	}
+	return c.JSON(resp.RawResponse.StatusCode, doc.ToModel())
+}
@@ -0,0 +1,115 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azsecrets"
+	"github.com/stephenzsy/small-kms/backend/base"
+	kv "github.com/stephenzsy/small-kms/backend/internal/keyvault"
+	"github.com/stephenzsy/small-kms/backend/models"
+	webhookmodels "github.com/stephenzsy/small-kms/backend/models/webhook"
+	"github.com/stephenzsy/small-kms/backend/resdoc"
+	"github.com/stephenzsy/small-kms/backend/utils"
+)
+
+type SubscriptionDoc struct {
+	resdoc.ResourceDoc
+	DisplayName string                           `json:"displayName"`
+	URL         string                           `json:"url"`
+	EventTypes  []webhookmodels.WebhookEventType `json:"eventTypes"`
+	Enabled     bool                             `json:"enabled"`
+	SecretID    azsecrets.ID                     `json:"secretId"`
+}
+
+const (
+	queryColumnDisplayName = "c.displayName"
+	queryColumnEventTypes  = "c.eventTypes"
+)
+
+func (d *SubscriptionDoc) ToModel() *webhookmodels.WebhookSubscription {
+	m := &webhookmodels.WebhookSubscription{}
+	m.Ref = d.ResourceDoc.ToRef()
+	m.DisplayName = &d.DisplayName
+	m.URL = d.URL
+	m.EventTypes = d.EventTypes
+	m.Enabled = d.Enabled
+	return m
+}
+
+func (d *SubscriptionDoc) getSecret(c context.Context) ([]byte, error) {
+	resp, err := kv.GetAzKeyVaultService(c).AzSecretsClient().GetSecret(c, d.SecretID.Name(), d.SecretID.Version(), nil)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(*resp.Value), nil
+}
+
+// subscriptionIndexLinkDoc is stored in a shared partition,
+// so background tasks can find subscribed namespaces without a cross partition query
+type subscriptionIndexLinkDoc struct {
+	resdoc.LinkResourceDoc
+	EventTypes []webhookmodels.WebhookEventType `json:"eventTypes"`
+}
+
+var subscriptionIndexLinkPartitionKey = resdoc.PartitionKey{
+	NamespaceProvider: models.NamespaceProviderProfile,
+	NamespaceID:       "default",
+	ResourceProvider:  models.ResourceProviderLink,
+}
+
+func getSubscriptionIndexLinkID(subscription resdoc.DocIdentifier) string {
+	return fmt.Sprintf("%s-%s-%s-%s", models.LinkProviderWebhookSubscription,
+		subscription.NamespaceProvider, subscription.NamespaceID, subscription.ID)
+}
+
+func newSubscriptionDocIdentifier(nsProvider models.NamespaceProvider, nsID string, id string) resdoc.DocIdentifier {
+	return resdoc.NewDocIdentifier(nsProvider, nsID, models.ResourceProviderWebhookSubscription, id)
+}
+
+func getSubscriptionInternal(c context.Context, identifier resdoc.DocIdentifier) (*SubscriptionDoc, error) {
+	doc := &SubscriptionDoc{}
+	if err := resdoc.GetDocService(c).Read(c, identifier, doc, nil); err != nil {
+		if errors.Is(err, resdoc.ErrAzCosmosDocNotFound) {
+			return nil, fmt.Errorf("%w: webhook subscription not found: %s", base.ErrResponseStatusNotFound, identifier.ID)
+		}
+		return nil, err
+	}
+	return doc, nil
+}
+
+func listEnabledSubscriptionsInternal(c context.Context, nsProvider models.NamespaceProvider, nsID string,
+	eventType webhookmodels.WebhookEventType) ([]*SubscriptionDoc, error) {
+	qb := resdoc.NewDefaultCosmoQueryBuilder().
+		WithWhereClauses("c.enabled = true", "ARRAY_CONTAINS(c.eventTypes, @eventType)")
+	qb.Parameters = append(qb.Parameters, azcosmos.QueryParameter{Name: "@eventType", Value: eventType})
+	pager := resdoc.NewQueryDocPager[*SubscriptionDoc](c, qb, resdoc.PartitionKey{
+		NamespaceProvider: nsProvider,
+		NamespaceID:       nsID,
+		ResourceProvider:  models.ResourceProviderWebhookSubscription,
+	})
+	return utils.PagerToSlice[*SubscriptionDoc](pager)
+}
+
+// ListSubscriptionsByEventTypeInternal returns subscriptions of all namespaces to the event type,
+// used by scanners which raise events without a request in the namespace
+func ListSubscriptionsByEventTypeInternal(c context.Context, eventType webhookmodels.WebhookEventType) ([]resdoc.DocIdentifier, error) {
+	qb := resdoc.NewDefaultCosmoQueryBuilder().
+		WithExtraColumns("c.linkTo").
+		WithWhereClauses("c.linkProvider = @linkProvider", "ARRAY_CONTAINS(c.eventTypes, @eventType)")
+	qb.Parameters = append(qb.Parameters,
+		azcosmos.QueryParameter{Name: "@linkProvider", Value: models.LinkProviderWebhookSubscription},
+		azcosmos.QueryParameter{Name: "@eventType", Value: eventType})
+	pager := resdoc.NewQueryDocPager[*subscriptionIndexLinkDoc](c, qb, subscriptionIndexLinkPartitionKey)
+	links, err := utils.PagerToSlice[*subscriptionIndexLinkDoc](pager)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]resdoc.DocIdentifier, len(links))
+	for i, link := range links {
+		result[i] = link.LinkTo
+	}
+	return result, nil
+}
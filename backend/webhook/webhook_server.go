@@ -0,0 +1,15 @@
+package webhook
+
+import (
+	"github.com/stephenzsy/small-kms/backend/api"
+)
+
+type WebhookServer struct {
+	api.APIServer
+}
+
+func NewServer(apiServer api.APIServer) *WebhookServer {
+	return &WebhookServer{
+		APIServer: apiServer,
+	}
+}
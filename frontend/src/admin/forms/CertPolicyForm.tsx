@@ -6,6 +6,7 @@ import {
   Divider,
   Form,
   Input,
+  InputNumber,
   Radio,
   Select,
   Typography,
@@ -324,6 +325,18 @@ export function CertPolicyForm({
         >
           <Checkbox disabled={isCA}>Allow Enroll Certificate</Checkbox>
         </Form.Item>
+        {namespaceProvider ===
+          NamespaceProvider.NamespaceProviderIntermediateCA && (
+          <Form.Item<CertificatePolicyParameters>
+            name={"allowTimestamping"}
+            valuePropName="checked"
+            getValueFromEvent={(e: CheckboxChangeEvent) => {
+              return e.target.checked;
+            }}
+          >
+            <Checkbox>Allow Time Stamping (RFC 3161)</Checkbox>
+          </Form.Item>
+        )}
       </div>
 
       <Divider />
@@ -334,8 +347,43 @@ export function CertPolicyForm({
           label="Common name (CN)"
           required
         >
-          <Input placeholder="example.org" />
+          <Input placeholder="example.org or {{requester.graph.displayName}}" />
+        </Form.Item>
+        <Form.Item<CertificatePolicyParameters>
+          name={["subject", "o"]}
+          label="Organization (O)"
+        >
+          <Input />
+        </Form.Item>
+        <Form.Item<CertificatePolicyParameters>
+          name={["subject", "ou"]}
+          label="Organizational unit (OU)"
+        >
+          <Input placeholder="{{namespace.graph.displayName}}" />
         </Form.Item>
+        <Form.Item<CertificatePolicyParameters>
+          name={["subject", "c"]}
+          label="Country (C)"
+        >
+          <Input placeholder="US" />
+        </Form.Item>
+        <Form.Item<CertificatePolicyParameters>
+          name={["subject", "st"]}
+          label="State or province (ST)"
+        >
+          <Input />
+        </Form.Item>
+        <Form.Item<CertificatePolicyParameters>
+          name={["subject", "l"]}
+          label="Locality (L)"
+        >
+          <Input />
+        </Form.Item>
+        <Typography.Paragraph type="secondary">
+          Template variables: requester.graph.* and namespace.graph.* with id,
+          upn, mail, displayName, appId or deviceId, e.g.{" "}
+          <code>{"{{requester.graph.upn}}"}</code>
+        </Typography.Paragraph>
       </div>
       <Divider />
       <div>
@@ -362,7 +410,86 @@ export function CertPolicyForm({
             inputPlaceholder="example@example.com"
           />
         </Form.Item>
+        <Form.Item<CertificatePolicyParameters> label="URIs">
+          <SANFormList
+            name={["subjectAlternativeNames", "uris"]}
+            addButtonLabel="+ Add URI"
+            inputPlaceholder="urn:uuid:{{requester.graph.id}}"
+          />
+        </Form.Item>
       </div>
+      {namespaceProvider ===
+        NamespaceProvider.NamespaceProviderIntermediateCA && (
+        <>
+          <Divider />
+          <div>
+            <Typography.Title level={4}>CA Constraints</Typography.Title>
+            <Form.Item<CertificatePolicyParameters>
+              name="maxPathLen"
+              label="Max path length"
+            >
+              <InputNumber min={0} max={0} placeholder="0" />
+            </Form.Item>
+            <Form.Item<CertificatePolicyParameters>
+              label="Permitted DNS domains"
+            >
+              <SANFormList
+                name={["nameConstraints", "permittedDnsDomains"]}
+                addButtonLabel="+ Add"
+                inputPlaceholder="example.com or .example.com"
+              />
+            </Form.Item>
+            <Form.Item<CertificatePolicyParameters>
+              label="Excluded DNS domains"
+            >
+              <SANFormList
+                name={["nameConstraints", "excludedDnsDomains"]}
+                addButtonLabel="+ Add"
+                inputPlaceholder="example.com or .example.com"
+              />
+            </Form.Item>
+            <Form.Item<CertificatePolicyParameters> label="Permitted IP ranges">
+              <SANFormList
+                name={["nameConstraints", "permittedIpRanges"]}
+                addButtonLabel="+ Add"
+                inputPlaceholder="10.0.0.0/8"
+              />
+            </Form.Item>
+            <Form.Item<CertificatePolicyParameters> label="Excluded IP ranges">
+              <SANFormList
+                name={["nameConstraints", "excludedIpRanges"]}
+                addButtonLabel="+ Add"
+                inputPlaceholder="10.0.0.0/8"
+              />
+            </Form.Item>
+            <Form.Item<CertificatePolicyParameters>
+              label="Permitted email addresses"
+            >
+              <SANFormList
+                name={["nameConstraints", "permittedEmailAddresses"]}
+                addButtonLabel="+ Add"
+                inputPlaceholder="example.com or user@example.com"
+              />
+            </Form.Item>
+            <Form.Item<CertificatePolicyParameters>
+              label="Excluded email addresses"
+            >
+              <SANFormList
+                name={["nameConstraints", "excludedEmailAddresses"]}
+                addButtonLabel="+ Add"
+                inputPlaceholder="example.com or user@example.com"
+              />
+            </Form.Item>
+            <Form.Item<CertificatePolicyParameters> label="Policy identifiers">
+              <SANFormList
+                name={["policyIdentifiers"]}
+                addButtonLabel="+ Add policy OID"
+                inputPlaceholder="2.23.140.1.2.1"
+              />
+            </Form.Item>
+          </div>
+        </>
+      )}
 
       <Divider />
 
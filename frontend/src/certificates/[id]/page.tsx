@@ -242,6 +242,12 @@ export default function CertificatePage() {
               <dd>{cert?.subjectAlternativeNames?.emails?.join(", ")}</dd>
             </div>
           )}
+          {cert?.subjectAlternativeNames?.uris && (
+            <div>
+              <dt>URIs</dt>
+              <dd>{cert?.subjectAlternativeNames?.uris?.join(", ")}</dd>
+            </div>
+          )}
         </dl>
       </Card>
       <Card title="Download certificate">
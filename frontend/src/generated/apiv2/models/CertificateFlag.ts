@@ -19,7 +19,12 @@
  */
 export const CertificateFlag = {
     CertificateFlagServerAuth: 'serverAuth',
-    CertificateFlagClientAuth: 'clientAuth'
+    CertificateFlagClientAuth: 'clientAuth',
+    CertificateFlagCodeSigning: 'codeSigning',
+    CertificateFlagEmailProtection: 'emailProtection',
+    CertificateFlagOCSPSigning: 'ocspSigning',
+    CertificateFlagTimeStamping: 'timeStamping',
+    CertificateFlagDocumentSigning: 'documentSigning'
 } as const;
 export type CertificateFlag = typeof CertificateFlag[keyof typeof CertificateFlag];
 
@@ -0,0 +1,105 @@
+/* tslint:disable */
+/* eslint-disable */
+/**
+ * Cryptocat API
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * The version of the OpenAPI document: 0.1.3
+ * 
+ *
+ * NOTE: This class is auto generated by OpenAPI Generator (https://openapi-generator.tech).
+ * https://openapi-generator.tech
+ * Do not edit the class manually.
+ */
+
+import { exists, mapValues } from '../runtime';
+/**
+ * Name constraints of an intermediate CA certificate as defined in RFC 5280 section 4.2.1.10
+ * @export
+ * @interface CertificateNameConstraints
+ */
+export interface CertificateNameConstraints {
+    /**
+     * 
+     * @type {Array<string>}
+     * @memberof CertificateNameConstraints
+     */
+    permittedDnsDomains?: Array<string>;
+    /**
+     * 
+     * @type {Array<string>}
+     * @memberof CertificateNameConstraints
+     */
+    excludedDnsDomains?: Array<string>;
+    /**
+     * 
+     * @type {Array<string>}
+     * @memberof CertificateNameConstraints
+     */
+    permittedIpRanges?: Array<string>;
+    /**
+     * 
+     * @type {Array<string>}
+     * @memberof CertificateNameConstraints
+     */
+    excludedIpRanges?: Array<string>;
+    /**
+     * 
+     * @type {Array<string>}
+     * @memberof CertificateNameConstraints
+     */
+    permittedEmailAddresses?: Array<string>;
+    /**
+     * 
+     * @type {Array<string>}
+     * @memberof CertificateNameConstraints
+     */
+    excludedEmailAddresses?: Array<string>;
+}
+
+/**
+ * Check if a given object implements the CertificateNameConstraints interface.
+ */
+export function instanceOfCertificateNameConstraints(value: object): boolean {
+    let isInstance = true;
+
+    return isInstance;
+}
+
+export function CertificateNameConstraintsFromJSON(json: any): CertificateNameConstraints {
+    return CertificateNameConstraintsFromJSONTyped(json, false);
+}
+
+export function CertificateNameConstraintsFromJSONTyped(json: any, ignoreDiscriminator: boolean): CertificateNameConstraints {
+    if ((json === undefined) || (json === null)) {
+        return json;
+    }
+    return {
+        
+        'permittedDnsDomains': !exists(json, 'permittedDnsDomains') ? undefined : json['permittedDnsDomains'],
+        'excludedDnsDomains': !exists(json, 'excludedDnsDomains') ? undefined : json['excludedDnsDomains'],
+        'permittedIpRanges': !exists(json, 'permittedIpRanges') ? undefined : json['permittedIpRanges'],
+        'excludedIpRanges': !exists(json, 'excludedIpRanges') ? undefined : json['excludedIpRanges'],
+        'permittedEmailAddresses': !exists(json, 'permittedEmailAddresses') ? undefined : json['permittedEmailAddresses'],
+        'excludedEmailAddresses': !exists(json, 'excludedEmailAddresses') ? undefined : json['excludedEmailAddresses'],
+    };
+}
+
+export function CertificateNameConstraintsToJSON(value?: CertificateNameConstraints | null): any {
+    if (value === undefined) {
+        return undefined;
+    }
+    if (value === null) {
+        return null;
+    }
+    return {
+        
+        'permittedDnsDomains': value.permittedDnsDomains,
+        'excludedDnsDomains': value.excludedDnsDomains,
+        'permittedIpRanges': value.permittedIpRanges,
+        'excludedIpRanges': value.excludedIpRanges,
+        'permittedEmailAddresses': value.permittedEmailAddresses,
+        'excludedEmailAddresses': value.excludedEmailAddresses,
+    };
+}
+
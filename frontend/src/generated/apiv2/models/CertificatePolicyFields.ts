@@ -19,6 +19,12 @@ import {
     CertificateFlagFromJSONTyped,
     CertificateFlagToJSON,
 } from './CertificateFlag';
+import type { CertificateNameConstraints } from './CertificateNameConstraints';
+import {
+    CertificateNameConstraintsFromJSON,
+    CertificateNameConstraintsFromJSONTyped,
+    CertificateNameConstraintsToJSON,
+} from './CertificateNameConstraints';
 import type { CertificateSubject } from './CertificateSubject';
 import {
     CertificateSubjectFromJSON,
@@ -92,6 +98,30 @@ export interface CertificatePolicyFields {
      * @memberof CertificatePolicyFields
      */
     flags?: Array<CertificateFlag>;
+    /**
+     * Maximum number of intermediate CA certificates below the CA certificate, intermediate CA only, must be less than the path length of the root CA
+     * @type {number}
+     * @memberof CertificatePolicyFields
+     */
+    maxPathLen?: number;
+    /**
+     * 
+     * @type {CertificateNameConstraints}
+     * @memberof CertificatePolicyFields
+     */
+    nameConstraints?: CertificateNameConstraints;
+    /**
+     * Certificate policy OIDs in dotted decimal notation, intermediate CA only
+     * @type {Array<string>}
+     * @memberof CertificatePolicyFields
+     */
+    policyIdentifiers?: Array<string>;
+    /**
+     * Serve RFC 3161 time-stamping requests with a TSA certificate issued by the current issuer certificate, intermediate CA only
+     * @type {boolean}
+     * @memberof CertificatePolicyFields
+     */
+    allowTimestamping?: boolean;
 }
 
 /**
@@ -127,6 +157,10 @@ export function CertificatePolicyFieldsFromJSONTyped(json: any, ignoreDiscrimina
         'subject': CertificateSubjectFromJSON(json['subject']),
         'subjectAlternativeNames': !exists(json, 'subjectAlternativeNames') ? undefined : SubjectAlternativeNamesFromJSON(json['subjectAlternativeNames']),
         'flags': !exists(json, 'flags') ? undefined : ((json['flags'] as Array<any>).map(CertificateFlagFromJSON)),
+        'maxPathLen': !exists(json, 'maxPathLen') ? undefined : json['maxPathLen'],
+        'nameConstraints': !exists(json, 'nameConstraints') ? undefined : CertificateNameConstraintsFromJSON(json['nameConstraints']),
+        'policyIdentifiers': !exists(json, 'policyIdentifiers') ? undefined : json['policyIdentifiers'],
+        'allowTimestamping': !exists(json, 'allowTimestamping') ? undefined : json['allowTimestamping'],
     };
 }
 
@@ -147,6 +181,10 @@ export function CertificatePolicyFieldsToJSON(value?: CertificatePolicyFields |
         'subject': CertificateSubjectToJSON(value.subject),
         'subjectAlternativeNames': SubjectAlternativeNamesToJSON(value.subjectAlternativeNames),
         'flags': value.flags === undefined ? undefined : ((value.flags as Array<any>).map(CertificateFlagToJSON)),
+        'maxPathLen': value.maxPathLen,
+        'nameConstraints': CertificateNameConstraintsToJSON(value.nameConstraints),
+        'policyIdentifiers': value.policyIdentifiers,
+        'allowTimestamping': value.allowTimestamping,
     };
 }
 
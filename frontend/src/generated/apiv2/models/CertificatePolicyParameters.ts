@@ -19,6 +19,12 @@ import {
     CertificateFlagFromJSONTyped,
     CertificateFlagToJSON,
 } from './CertificateFlag';
+import type { CertificateNameConstraints } from './CertificateNameConstraints';
+import {
+    CertificateNameConstraintsFromJSON,
+    CertificateNameConstraintsFromJSONTyped,
+    CertificateNameConstraintsToJSON,
+} from './CertificateNameConstraints';
 import type { CertificateSubject } from './CertificateSubject';
 import {
     CertificateSubjectFromJSON,
@@ -98,6 +104,30 @@ export interface CertificatePolicyParameters {
      * @memberof CertificatePolicyParameters
      */
     flags?: Array<CertificateFlag>;
+    /**
+     * Maximum number of intermediate CA certificates below the CA certificate, intermediate CA only, must be less than the path length of the root CA
+     * @type {number}
+     * @memberof CertificatePolicyParameters
+     */
+    maxPathLen?: number;
+    /**
+     * 
+     * @type {CertificateNameConstraints}
+     * @memberof CertificatePolicyParameters
+     */
+    nameConstraints?: CertificateNameConstraints;
+    /**
+     * Certificate policy OIDs in dotted decimal notation, intermediate CA only
+     * @type {Array<string>}
+     * @memberof CertificatePolicyParameters
+     */
+    policyIdentifiers?: Array<string>;
+    /**
+     * Serve RFC 3161 time-stamping requests with a TSA certificate issued by the current issuer certificate, intermediate CA only
+     * @type {boolean}
+     * @memberof CertificatePolicyParameters
+     */
+    allowTimestamping?: boolean;
 }
 
 /**
@@ -129,6 +159,10 @@ export function CertificatePolicyParametersFromJSONTyped(json: any, ignoreDiscri
         'subject': CertificateSubjectFromJSON(json['subject']),
         'subjectAlternativeNames': !exists(json, 'subjectAlternativeNames') ? undefined : SubjectAlternativeNamesFromJSON(json['subjectAlternativeNames']),
         'flags': !exists(json, 'flags') ? undefined : ((json['flags'] as Array<any>).map(CertificateFlagFromJSON)),
+        'maxPathLen': !exists(json, 'maxPathLen') ? undefined : json['maxPathLen'],
+        'nameConstraints': !exists(json, 'nameConstraints') ? undefined : CertificateNameConstraintsFromJSON(json['nameConstraints']),
+        'policyIdentifiers': !exists(json, 'policyIdentifiers') ? undefined : json['policyIdentifiers'],
+        'allowTimestamping': !exists(json, 'allowTimestamping') ? undefined : json['allowTimestamping'],
     };
 }
 
@@ -150,6 +184,10 @@ export function CertificatePolicyParametersToJSON(value?: CertificatePolicyParam
         'subject': CertificateSubjectToJSON(value.subject),
         'subjectAlternativeNames': SubjectAlternativeNamesToJSON(value.subjectAlternativeNames),
         'flags': value.flags === undefined ? undefined : ((value.flags as Array<any>).map(CertificateFlagToJSON)),
+        'maxPathLen': value.maxPathLen,
+        'nameConstraints': CertificateNameConstraintsToJSON(value.nameConstraints),
+        'policyIdentifiers': value.policyIdentifiers,
+        'allowTimestamping': value.allowTimestamping,
     };
 }
 
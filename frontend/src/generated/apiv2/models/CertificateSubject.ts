@@ -25,6 +25,36 @@ export interface CertificateSubject {
      * @memberof CertificateSubject
      */
     cn: string;
+    /**
+     * 
+     * @type {string}
+     * @memberof CertificateSubject
+     */
+    o?: string;
+    /**
+     * 
+     * @type {string}
+     * @memberof CertificateSubject
+     */
+    ou?: string;
+    /**
+     * 
+     * @type {string}
+     * @memberof CertificateSubject
+     */
+    c?: string;
+    /**
+     * 
+     * @type {string}
+     * @memberof CertificateSubject
+     */
+    l?: string;
+    /**
+     * 
+     * @type {string}
+     * @memberof CertificateSubject
+     */
+    st?: string;
 }
 
 /**
@@ -48,6 +78,11 @@ export function CertificateSubjectFromJSONTyped(json: any, ignoreDiscriminator:
     return {
         
         'cn': json['cn'],
+        'o': !exists(json, 'o') ? undefined : json['o'],
+        'ou': !exists(json, 'ou') ? undefined : json['ou'],
+        'c': !exists(json, 'c') ? undefined : json['c'],
+        'l': !exists(json, 'l') ? undefined : json['l'],
+        'st': !exists(json, 'st') ? undefined : json['st'],
     };
 }
 
@@ -61,6 +96,11 @@ export function CertificateSubjectToJSON(value?: CertificateSubject | null): any
     return {
         
         'cn': value.cn,
+        'o': value.o,
+        'ou': value.ou,
+        'c': value.c,
+        'l': value.l,
+        'st': value.st,
     };
 }
 
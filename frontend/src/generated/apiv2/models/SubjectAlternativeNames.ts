@@ -37,6 +37,12 @@ export interface SubjectAlternativeNames {
      * @memberof SubjectAlternativeNames
      */
     emails?: Array<string>;
+    /**
+     * 
+     * @type {Array<string>}
+     * @memberof SubjectAlternativeNames
+     */
+    uris?: Array<string>;
 }
 
 /**
@@ -61,6 +67,7 @@ export function SubjectAlternativeNamesFromJSONTyped(json: any, ignoreDiscrimina
         'dnsNames': !exists(json, 'dnsNames') ? undefined : json['dnsNames'],
         'ipAddresses': !exists(json, 'ipAddresses') ? undefined : json['ipAddresses'],
         'emails': !exists(json, 'emails') ? undefined : json['emails'],
+        'uris': !exists(json, 'uris') ? undefined : json['uris'],
     };
 }
 
@@ -76,6 +83,7 @@ export function SubjectAlternativeNamesToJSON(value?: SubjectAlternativeNames |
         'dnsNames': value.dnsNames,
         'ipAddresses': value.ipAddresses,
         'emails': value.emails,
+        'uris': value.uris,
     };
 }
 
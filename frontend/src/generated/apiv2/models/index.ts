@@ -21,6 +21,7 @@ export * from './CertificateExternalIssuerAcme';
 export * from './CertificateExternalIssuerFields';
 export * from './CertificateFields';
 export * from './CertificateFlag';
+export * from './CertificateNameConstraints';
 export * from './CertificatePendingAcme';
 export * from './CertificatePendingAcmeAuthorization';
 export * from './CertificatePendingAcmeChallenge';
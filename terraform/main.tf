@@ -90,6 +90,8 @@ resource "azurerm_cosmosdb_sql_container" "kmsdbContainer" {
   database_name         = azurerm_cosmosdb_sql_database.db.name
   partition_key_path    = "/namespaceId"
   partition_key_version = 1
+  # enables the per-item ttl of short lived documents, such as ACME nonces, without expiring the others
+  default_ttl = -1
 }
 
 resource "azurerm_user_assigned_identity" "backendManagedIdentity" {